@@ -32,8 +32,29 @@ type App struct {
 	wechatService       *services.WechatService
 	aiService           *services.AIService
 	systemService       *services.SystemService
+	schedulerService    *services.SchedulerService
 	dashboardService    *services.DashboardService
 	ticketService       *services.TicketService
+	ticketWorkflowService *services.TicketWorkflowService
+	ticketSLAService    *services.TicketSLAService
+	ticketAssignmentService *services.TicketAssignmentService
+	ticketRelationService *services.TicketRelationService
+	ticketCategoryService *services.TicketCategoryService
+	ticketTemplateService *services.TicketTemplateService
+	ticketSurveyService *services.TicketSurveyService
+	ticketWorklogService *services.TicketWorklogService
+	inboundMailService  *services.InboundMailService
+	inboundSMTPServer   *services.InboundSMTPServer
+	linkService         *services.LinkService
+	recordWorkflowService *services.RecordWorkflowService
+	recycleBinService   *services.RecycleBinService
+	recordMigrationService *services.RecordMigrationService
+	recordImportService *services.RecordImportService
+	recordBulkService   *services.RecordBulkService
+	tagService          *services.TagService
+	watchService        *services.WatchService
+	recordTemplateService *services.RecordTemplateService
+	recordCommentService *services.RecordCommentService
 	authHandler         *handlers.AuthHandler
 	userHandler         *handlers.UserHandler
 	permissionHandler   *handlers.PermissionHandler
@@ -46,11 +67,29 @@ type App struct {
 	exportHandler       *handlers.ExportHandler
 	notificationHandler *handlers.NotificationHandler
 	ticketHandler       *handlers.TicketHandler
+	ticketWorkflowHandler *handlers.TicketWorkflowHandler
+	ticketSLAHandler    *handlers.TicketSLAHandler
+	ticketAssignmentHandler *handlers.TicketAssignmentHandler
+	ticketRelationHandler *handlers.TicketRelationHandler
+	ticketCategoryHandler *handlers.TicketCategoryHandler
+	ticketTemplateHandler *handlers.TicketTemplateHandler
+	ticketSurveyHandler *handlers.TicketSurveyHandler
+	ticketWorklogHandler *handlers.TicketWorklogHandler
+	inboundMailHandler  *handlers.InboundMailHandler
 	wechatHandler       *handlers.WechatHandler
 	aiHandler           *handlers.AIHandler
 	systemHandler       *handlers.SystemHandler
+	schedulerHandler    *handlers.SchedulerHandler
 	dashboardHandler    *handlers.DashboardHandler
 	flexibleHandler     *handlers.FlexibleHandler
+	linkHandler         *handlers.LinkHandler
+	recycleBinHandler   *handlers.RecycleBinHandler
+	recordImportHandler *handlers.RecordImportHandler
+	recordBulkHandler   *handlers.RecordBulkHandler
+	tagHandler          *handlers.TagHandler
+	watchHandler        *handlers.WatchHandler
+	recordTemplateHandler *handlers.RecordTemplateHandler
+	recordCommentHandler *handlers.RecordCommentHandler
 }
 
 // New 创建新的应用实例
@@ -144,34 +183,95 @@ func (a *App) initServices() error {
 	a.roleService = services.NewRoleService(db)
 	a.auditService = services.NewAuditService(db)
 	a.recordTypeService = services.NewRecordTypeService(db)
-	a.recordService = services.NewRecordService(db, a.recordTypeService, a.auditService)
 	a.fileService = services.NewFileService(db, a.auditService)
+	a.notificationService = services.NewNotificationService(db)
+	a.recordWorkflowService = services.NewRecordWorkflowService(db, a.recordTypeService, a.auditService, a.notificationService)
+	a.recordService = services.NewRecordService(db, a.recordTypeService, a.auditService, a.fileService, a.recordWorkflowService)
 	a.ocrService = services.NewOCRService("", "") // 暂时使用空配置，将使用模拟模式
 	a.exportService = services.NewExportService(db, a.recordService)
-	a.notificationService = services.NewNotificationService(db)
 	a.wechatService = services.NewWechatService(db)
-	a.ticketService = services.NewTicketService(db, a.wechatService)
 	a.aiService = services.NewAIService(db)
 	a.systemService = services.NewSystemService(db)
 	a.dashboardService = services.NewDashboardService(db)
+	a.linkService = services.NewLinkService(db, a.auditService)
+	a.recycleBinService = services.NewRecycleBinService(db, a.auditService, a.fileService)
+	a.recordMigrationService = services.NewRecordMigrationService(db, a.auditService)
+	a.recordImportService = services.NewRecordImportService(db, a.recordService, a.recordTypeService, a.auditService)
+	a.recordBulkService = services.NewRecordBulkService(db, a.recordService, a.recordWorkflowService, a.auditService)
+	a.tagService = services.NewTagService(db, a.auditService)
+	a.watchService = services.NewWatchService(db, a.notificationService)
+	a.ticketSLAService = services.NewTicketSLAService(db, a.auditService, a.watchService)
+	a.ticketWorkflowService = services.NewTicketWorkflowService(db, a.auditService, a.watchService, a.ticketSLAService)
+	a.ticketAssignmentService = services.NewTicketAssignmentService(db, a.auditService)
+	a.ticketRelationService = services.NewTicketRelationService(db, a.auditService, a.ticketWorkflowService, a.ticketSLAService, a.watchService)
+	a.ticketCategoryService = services.NewTicketCategoryService(db, a.auditService)
+	a.ticketTemplateService = services.NewTicketTemplateService(db, a.auditService)
+	a.ticketSurveyService = services.NewTicketSurveyService(db, a.watchService, a.ticketWorkflowService)
+	a.ticketWorklogService = services.NewTicketWorklogService(db, a.auditService)
+	a.ticketService = services.NewTicketService(db, a.wechatService, a.ticketWorkflowService)
+	a.recordTemplateService = services.NewRecordTemplateService(db, a.auditService)
+	a.recordCommentService = services.NewRecordCommentService(db, a.auditService, a.watchService)
+	mailCfg := &a.config.InboundMail
+	a.inboundMailService = services.NewInboundMailService(db, a.fileService, a.ticketWorkflowService, a.ticketSLAService, a.ticketAssignmentService, a.watchService, services.InboundMailOptions{
+		Address:          mailCfg.Address,
+		DefaultType:      mailCfg.DefaultType,
+		DefaultPriority:  mailCfg.DefaultPriority,
+		MaxPerSenderHour: mailCfg.MaxPerSenderHour,
+		MaxMessageSize:   int64(mailCfg.MaxMessageSizeMB) * 1024 * 1024,
+	})
+
+	// 后台任务统一由调度服务运行，多实例部署时通过数据库锁避免重复处理
+	a.schedulerService = services.NewSchedulerService(db, a.auditService, a.config.Scheduler.GetLockTTL(), a.config.Scheduler.RunRetentionDays)
+	if err := a.registerJobs(); err != nil {
+		return fmt.Errorf("failed to register scheduled jobs: %w", err)
+	}
+	if a.config.Scheduler.Enabled {
+		a.schedulerService.Start(a.config.Scheduler.GetTickInterval())
+	}
+
+	// SMTP 收件服务，maildir 由后台任务 inbound_mail_maildir 读取
+	if mailCfg.Enabled && mailCfg.SMTPListen != "" {
+		a.inboundSMTPServer = services.NewInboundSMTPServer(a.inboundMailService, mailCfg.SMTPDomain, int64(mailCfg.MaxMessageSizeMB)*1024*1024)
+		if err := a.inboundSMTPServer.Listen(mailCfg.SMTPListen); err != nil {
+			return fmt.Errorf("failed to start inbound SMTP server: %w", err)
+		}
+	}
 
 	// 初始化处理器
 	a.authHandler = handlers.NewAuthHandler(a.authService, a.userService)
 	a.userHandler = handlers.NewUserHandler(a.userService, a.roleService)
 	a.permissionHandler = handlers.NewPermissionHandler(a.permissionService)
 	a.roleHandler = handlers.NewRoleHandler(a.roleService)
-	a.recordHandler = handlers.NewRecordHandler(a.recordService)
-	a.recordTypeHandler = handlers.NewRecordTypeHandler(a.recordTypeService)
+	a.recordHandler = handlers.NewRecordHandler(a.recordService, a.linkService, a.recordWorkflowService)
+	a.recordTypeHandler = handlers.NewRecordTypeHandler(a.recordTypeService, a.recordMigrationService)
 	a.auditHandler = handlers.NewAuditHandler(a.auditService)
 	a.fileHandler = handlers.NewFileHandler(a.fileService)
 	a.ocrHandler = handlers.NewOCRHandler(a.ocrService)
 	a.exportHandler = handlers.NewExportHandler(a.exportService)
 	a.notificationHandler = handlers.NewNotificationHandler(a.notificationService)
-	a.ticketHandler = handlers.NewTicketHandler(db, a.notificationService)
+	a.ticketHandler = handlers.NewTicketHandler(db, a.notificationService, a.linkService, a.ticketWorkflowService, a.ticketSLAService, a.ticketAssignmentService, a.ticketSurveyService)
+	a.ticketWorkflowHandler = handlers.NewTicketWorkflowHandler(a.ticketWorkflowService)
+	a.ticketSLAHandler = handlers.NewTicketSLAHandler(a.ticketSLAService)
+	a.ticketAssignmentHandler = handlers.NewTicketAssignmentHandler(a.ticketAssignmentService)
+	a.ticketRelationHandler = handlers.NewTicketRelationHandler(a.ticketRelationService)
+	a.ticketCategoryHandler = handlers.NewTicketCategoryHandler(a.ticketCategoryService)
+	a.ticketTemplateHandler = handlers.NewTicketTemplateHandler(a.ticketTemplateService)
+	a.ticketSurveyHandler = handlers.NewTicketSurveyHandler(a.ticketSurveyService)
+	a.ticketWorklogHandler = handlers.NewTicketWorklogHandler(a.ticketWorklogService)
+	a.inboundMailHandler = handlers.NewInboundMailHandler(a.inboundMailService)
 	a.wechatHandler = handlers.NewWechatHandler(a.wechatService)
 	a.aiHandler = handlers.NewAIHandler(a.aiService)
 	a.systemHandler = handlers.NewSystemHandler(a.systemService)
+	a.schedulerHandler = handlers.NewSchedulerHandler(a.schedulerService)
 	a.dashboardHandler = handlers.NewDashboardHandler(a.dashboardService)
+	a.linkHandler = handlers.NewLinkHandler(a.linkService)
+	a.recycleBinHandler = handlers.NewRecycleBinHandler(a.recycleBinService)
+	a.recordImportHandler = handlers.NewRecordImportHandler(a.recordImportService)
+	a.recordBulkHandler = handlers.NewRecordBulkHandler(a.recordBulkService)
+	a.tagHandler = handlers.NewTagHandler(a.tagService)
+	a.watchHandler = handlers.NewWatchHandler(a.watchService)
+	a.recordTemplateHandler = handlers.NewRecordTemplateHandler(a.recordTemplateService)
+	a.recordCommentHandler = handlers.NewRecordCommentHandler(a.recordCommentService)
 	a.flexibleHandler = handlers.NewFlexibleHandler(
 		a.fileService,
 		a.exportService,
@@ -270,6 +370,20 @@ func (a *App) initRouter() {
 				roles.PUT("/batch-status", a.roleHandler.BatchUpdateRoleStatus)
 				roles.DELETE("/batch", a.roleHandler.BatchDeleteRoles)
 			}
+
+			// 后台任务管理路由
+			scheduler := admin.Group("/scheduler")
+			{
+				scheduler.GET("/jobs", a.schedulerHandler.ListJobs)
+				scheduler.GET("/jobs/:name", a.schedulerHandler.GetJob)
+				scheduler.GET("/jobs/:name/runs", a.schedulerHandler.ListRuns)
+				scheduler.POST("/jobs/:name/pause", a.schedulerHandler.PauseJob)
+				scheduler.POST("/jobs/:name/resume", a.schedulerHandler.ResumeJob)
+				scheduler.POST("/jobs/:name/run", a.schedulerHandler.RunJob)
+			}
+
+			// 邮件转工单处理记录
+			admin.GET("/inbound-emails", a.inboundMailHandler.ListEmails)
 		}
 
 		// 权限路由
@@ -307,7 +421,68 @@ func (a *App) initRouter() {
 			records.PUT("/batch-status", a.recordHandler.BatchUpdateRecordStatus)
 			records.DELETE("/batch", a.recordHandler.BatchDeleteRecords)
 			records.POST("/import", a.recordHandler.ImportRecords)
+
+			// 表格导入（CSV/XLSX）
+			records.POST("/import/preview", a.recordImportHandler.PreviewImport)
+			records.POST("/import/jobs", a.recordImportHandler.CreateImportJob)
+			records.GET("/import/jobs", a.recordImportHandler.ListImportJobs)
+			records.GET("/import/jobs/:job_id", a.recordImportHandler.GetImportJob)
+			records.POST("/import/jobs/:job_id/commit", a.recordImportHandler.CommitImportJob)
+			records.GET("/import/jobs/:job_id/error-report", a.recordImportHandler.DownloadImportErrorReport)
+			records.GET("/import/mappings", a.recordImportHandler.ListImportMappings)
+			records.POST("/import/mappings", a.recordImportHandler.SaveImportMapping)
+			records.DELETE("/import/mappings/:mapping_id", a.recordImportHandler.DeleteImportMapping)
+
+			// 按筛选条件批量操作
+			records.POST("/bulk/preview", a.recordBulkHandler.PreviewBulkOperation)
+			records.POST("/bulk/jobs", a.recordBulkHandler.CreateBulkJob)
+			records.GET("/bulk/jobs", a.recordBulkHandler.ListBulkJobs)
+			records.GET("/bulk/jobs/:job_id", a.recordBulkHandler.GetBulkJob)
+			records.POST("/bulk/jobs/:job_id/cancel", a.recordBulkHandler.CancelBulkJob)
+			records.GET("/bulk/jobs/:job_id/errors", a.recordBulkHandler.GetBulkJobErrors)
+
 			records.GET("/type/:type", a.recordHandler.GetRecordsByType)
+
+			// 重复记录检测与合并
+			records.GET("/duplicates", a.recordHandler.FindDuplicateRecords)
+			records.POST("/merge", a.recordHandler.MergeRecords)
+
+			// 记录模板
+			records.GET("/templates", a.recordTemplateHandler.GetTemplates)
+			records.POST("/templates", a.recordTemplateHandler.CreateTemplate)
+			records.GET("/templates/:template_id", a.recordTemplateHandler.GetTemplate)
+			records.PUT("/templates/:template_id", a.recordTemplateHandler.UpdateTemplate)
+			records.DELETE("/templates/:template_id", a.recordTemplateHandler.DeleteTemplate)
+
+			// 记录附件
+			records.GET("/:id/attachments", a.recordHandler.GetRecordFiles)
+			records.POST("/:id/attachments", a.recordHandler.AttachRecordFiles)
+			records.DELETE("/:id/attachments/:file_id", a.recordHandler.DetachRecordFile)
+
+			// 记录编辑锁
+			records.POST("/:id/lock", a.recordHandler.AcquireRecordLock)
+			records.PUT("/:id/lock", a.recordHandler.RenewRecordLock)
+			records.DELETE("/:id/lock", a.recordHandler.ReleaseRecordLock)
+
+			// 记录状态流程
+			records.GET("/:id/transitions", a.recordHandler.GetRecordTransitions)
+			records.POST("/:id/transitions", a.recordHandler.TransitionRecord)
+			records.POST("/:id/review/approve", a.recordHandler.ApproveRecord)
+			records.POST("/:id/review/reject", a.recordHandler.RejectRecord)
+			records.PUT("/:id/reviewer", a.recordHandler.AssignRecordReviewer)
+			records.GET("/:id/status-history", a.recordHandler.GetRecordStatusHistory)
+			records.PUT("/:id/schedule", a.recordHandler.ScheduleRecord)
+
+			// 记录关注
+			records.GET("/:id/watchers", a.watchHandler.GetRecordWatchers)
+			records.POST("/:id/watch", a.watchHandler.WatchRecord)
+			records.DELETE("/:id/watch", a.watchHandler.UnwatchRecord)
+
+			// 记录评论
+			records.GET("/:id/comments", a.recordCommentHandler.GetComments)
+			records.POST("/:id/comments", a.recordCommentHandler.CreateComment)
+			records.PUT("/:id/comments/:comment_id", a.recordCommentHandler.UpdateComment)
+			records.DELETE("/:id/comments/:comment_id", a.recordCommentHandler.DeleteComment)
 		}
 
 		// 工单路由
@@ -318,6 +493,7 @@ func (a *App) initRouter() {
 			tickets.GET("", a.ticketHandler.GetTickets)
 			tickets.POST("", a.ticketHandler.CreateTicket)
 			tickets.GET("/statistics", a.ticketHandler.GetTicketStatistics)
+			tickets.GET("/surveys/statistics", a.ticketSurveyHandler.GetStatistics)
 			tickets.GET("/:id", a.ticketHandler.GetTicket)
 			tickets.PUT("/:id", a.ticketHandler.UpdateTicket)
 			tickets.DELETE("/:id", a.ticketHandler.DeleteTicket)
@@ -333,6 +509,50 @@ func (a *App) initRouter() {
 			tickets.POST("/:id/reject", a.ticketHandler.RejectTicket)
 			tickets.POST("/:id/reopen", a.ticketHandler.ReopenTicket)
 			tickets.POST("/:id/resubmit", a.ticketHandler.ResubmitTicket)
+			tickets.POST("/:id/transition", a.ticketWorkflowHandler.TransitionTicket)
+			tickets.GET("/:id/actions", a.ticketWorkflowHandler.GetTicketActions)
+
+			// 工单流程定义
+			tickets.GET("/workflows", a.ticketWorkflowHandler.ListWorkflows)
+			tickets.POST("/workflows", a.ticketWorkflowHandler.CreateWorkflow)
+			tickets.GET("/workflows/:id", a.ticketWorkflowHandler.GetWorkflow)
+			tickets.PUT("/workflows/:id", a.ticketWorkflowHandler.UpdateWorkflow)
+			tickets.DELETE("/workflows/:id", a.ticketWorkflowHandler.DeleteWorkflow)
+
+			// SLA策略与工作日历
+			tickets.GET("/:id/sla", a.ticketSLAHandler.GetTicketSLA)
+			tickets.GET("/sla/policies", a.ticketSLAHandler.ListPolicies)
+			tickets.POST("/sla/policies", a.ticketSLAHandler.CreatePolicy)
+			tickets.GET("/sla/policies/:id", a.ticketSLAHandler.GetPolicy)
+			tickets.PUT("/sla/policies/:id", a.ticketSLAHandler.UpdatePolicy)
+			tickets.DELETE("/sla/policies/:id", a.ticketSLAHandler.DeletePolicy)
+			tickets.GET("/sla/calendars", a.ticketSLAHandler.ListCalendars)
+			tickets.POST("/sla/calendars", a.ticketSLAHandler.CreateCalendar)
+			tickets.GET("/sla/calendars/:id", a.ticketSLAHandler.GetCalendar)
+			tickets.PUT("/sla/calendars/:id", a.ticketSLAHandler.UpdateCalendar)
+			tickets.DELETE("/sla/calendars/:id", a.ticketSLAHandler.DeleteCalendar)
+
+			// 工单关注
+			tickets.GET("/:id/watchers", a.watchHandler.GetTicketWatchers)
+			tickets.POST("/:id/watch", a.watchHandler.WatchTicket)
+			tickets.DELETE("/:id/watch", a.watchHandler.UnwatchTicket)
+
+			// 工单关系：父子工单、阻塞依赖、合并与拆分
+			tickets.GET("/:id/relations", a.ticketRelationHandler.GetRelations)
+			tickets.GET("/:id/surveys", a.ticketSurveyHandler.GetTicketSurveys)
+			tickets.PUT("/:id/parent", a.ticketRelationHandler.SetParent)
+			tickets.POST("/:id/blockers", a.ticketRelationHandler.AddBlocker)
+			tickets.DELETE("/:id/blockers/:blocker_id", a.ticketRelationHandler.RemoveBlocker)
+			tickets.POST("/:id/merge", a.ticketRelationHandler.MergeTicket)
+			tickets.POST("/:id/split", a.ticketRelationHandler.SplitTicket)
+
+			// 工时登记与工时报表
+			tickets.GET("/:id/worklogs", a.ticketWorklogHandler.GetWorklogs)
+			tickets.POST("/:id/worklogs", a.ticketWorklogHandler.CreateWorklog)
+			tickets.PUT("/worklogs/:id", a.ticketWorklogHandler.UpdateWorklog)
+			tickets.DELETE("/worklogs/:id", a.ticketWorklogHandler.DeleteWorklog)
+			tickets.GET("/worklogs/report", a.ticketWorklogHandler.GetReport)
+			tickets.GET("/worklogs/report/export", a.ticketWorklogHandler.ExportReport)
 			
 			// 工单评论
 			tickets.GET("/:id/comments", a.ticketHandler.GetTicketComments)
@@ -347,10 +567,37 @@ func (a *App) initRouter() {
 			
 			// 工单类型
 			tickets.GET("/categories", a.ticketHandler.GetTicketCategories)
+			tickets.GET("/categories/all", a.ticketCategoryHandler.ListCategories)
+			tickets.POST("/categories", a.ticketCategoryHandler.CreateCategory)
+			tickets.GET("/categories/:id", a.ticketCategoryHandler.GetCategory)
+			tickets.PUT("/categories/:id", a.ticketCategoryHandler.UpdateCategory)
+			tickets.DELETE("/categories/:id", a.ticketCategoryHandler.DeleteCategory)
+			
+			// 自定义字段定义：?type=&category=
+			tickets.GET("/fields", a.ticketCategoryHandler.GetFieldDefinitions)
+			
+			// 工单模板：个人模板与按角色共享的模板
+			tickets.GET("/templates", a.ticketTemplateHandler.GetTemplates)
+			tickets.GET("/templates/available", a.ticketTemplateHandler.GetAvailableTemplates)
+			tickets.POST("/templates", a.ticketTemplateHandler.CreateTemplate)
+			tickets.GET("/templates/:id", a.ticketTemplateHandler.GetTemplate)
+			tickets.PUT("/templates/:id", a.ticketTemplateHandler.UpdateTemplate)
+			tickets.DELETE("/templates/:id", a.ticketTemplateHandler.DeleteTemplate)
 			
 			// 自动分配规则
-			tickets.GET("/assignment-rules", a.ticketHandler.GetAssignmentRules)
-			tickets.PUT("/assignment-rules", a.ticketHandler.UpdateAssignmentRules)
+			tickets.GET("/assignment-rules", a.ticketAssignmentHandler.ListRules)
+			tickets.POST("/assignment-rules", a.ticketAssignmentHandler.CreateRule)
+			tickets.PUT("/assignment-rules", a.ticketAssignmentHandler.ReorderRules)
+			tickets.POST("/assignment-rules/test", a.ticketAssignmentHandler.PreviewAssignment)
+			tickets.GET("/assignment-rules/:id", a.ticketAssignmentHandler.GetRule)
+			tickets.PUT("/assignment-rules/:id", a.ticketAssignmentHandler.UpdateRule)
+			tickets.DELETE("/assignment-rules/:id", a.ticketAssignmentHandler.DeleteRule)
+			
+			// 处理人技能与可用状态
+			tickets.GET("/assignees", a.ticketAssignmentHandler.ListProfiles)
+			tickets.GET("/assignees/me", a.ticketAssignmentHandler.GetMyProfile)
+			tickets.PUT("/assignees/me", a.ticketAssignmentHandler.UpdateMyProfile)
+			tickets.PUT("/assignees/:user_id", a.ticketAssignmentHandler.UpdateProfile)
 		}
 
 		// 记录类型路由
@@ -368,6 +615,14 @@ func (a *App) initRouter() {
 			recordTypes.POST("/import", a.recordTypeHandler.ImportRecordTypes)
 			recordTypes.PUT("/batch-status", a.recordTypeHandler.BatchUpdateRecordTypeStatus)
 			recordTypes.DELETE("/batch", a.recordTypeHandler.BatchDeleteRecordTypes)
+
+			// Schema版本与数据迁移
+			recordTypes.POST("/:id/schema/check", a.recordTypeHandler.CheckSchemaCompatibility)
+			recordTypes.GET("/:id/schema/versions", a.recordTypeHandler.GetSchemaVersions)
+			recordTypes.POST("/:id/migrations", a.recordTypeHandler.CreateMigrationJob)
+			recordTypes.GET("/:id/migrations", a.recordTypeHandler.ListMigrationJobs)
+			recordTypes.GET("/:id/migrations/:job_id", a.recordTypeHandler.GetMigrationJob)
+			recordTypes.GET("/:id/migrations/:job_id/errors", a.recordTypeHandler.GetMigrationJobErrors)
 		}
 
 		// 审计路由
@@ -397,6 +652,43 @@ func (a *App) initRouter() {
 			files.GET("/ocr/languages", a.ocrHandler.GetSupportedLanguages)
 		}
 
+		// 实体关联路由（记录、工单、文件之间的关联）
+		links := v1.Group("/links")
+		links.Use(middleware.AuthMiddleware(a.authService))
+		links.Use(middleware.AuditMiddleware())
+		links.Use(middleware.RecordPermissionMiddleware(a.permissionService))
+		links.Use(middleware.FilePermissionMiddleware(a.permissionService))
+		{
+			links.GET("", a.linkHandler.GetLinks)
+			links.POST("", a.linkHandler.CreateLink)
+			links.DELETE("/:id", a.linkHandler.DeleteLink)
+		}
+
+		// 标签管理
+		tags := v1.Group("/tags")
+		tags.Use(middleware.AuthMiddleware(a.authService))
+		tags.Use(middleware.AuditMiddleware())
+		{
+			tags.GET("", a.tagHandler.GetTags)
+			tags.GET("/suggest", a.tagHandler.SuggestTags)
+			tags.POST("", a.tagHandler.CreateTag)
+			tags.POST("/merge", a.tagHandler.MergeTags)
+			tags.GET("/:id", a.tagHandler.GetTag)
+			tags.PUT("/:id", a.tagHandler.UpdateTag)
+			tags.DELETE("/:id", a.tagHandler.DeleteTag)
+		}
+
+		// 回收站路由
+		recycleBin := v1.Group("/recycle-bin")
+		recycleBin.Use(middleware.AuthMiddleware(a.authService))
+		recycleBin.Use(middleware.AuditMiddleware())
+		{
+			recycleBin.GET("", a.recycleBinHandler.GetRecycleBin)
+			recycleBin.POST("/:type/:id/restore", a.recycleBinHandler.RestoreItem)
+			recycleBin.DELETE("/:type/:id", a.recycleBinHandler.PurgeItem)
+			recycleBin.POST("/purge-expired", middleware.RequireSystemPermission(a.permissionService, "admin"), a.recycleBinHandler.PurgeExpired)
+		}
+
 		// 仪表盘路由
 		dashboard := v1.Group("/dashboard")
 		dashboard.Use(middleware.AuthMiddleware(a.authService))
@@ -452,6 +744,10 @@ func (a *App) initRouter() {
 			// 通知渠道管理
 			notifications.GET("/channels", a.notificationHandler.GetNotificationChannels)
 			notifications.POST("/channels", a.notificationHandler.CreateNotificationChannel)
+
+			// 关注通知偏好
+			notifications.GET("/preferences", a.watchHandler.GetNotificationPreference)
+			notifications.PUT("/preferences", a.watchHandler.UpdateNotificationPreference)
 		}
 
 		// 告警路由
@@ -509,6 +805,10 @@ func (a *App) initRouter() {
 			config.DELETE("/:category/:key", middleware.RequireSystemPermission(a.permissionService, "admin"), a.systemHandler.DeleteConfig)
 		}
 
+		// 工单满意度调查链接（无需认证，凭一次性链接令牌访问）
+		v1.GET("/surveys/:token", a.ticketSurveyHandler.GetSurvey)
+		v1.POST("/surveys/:token", a.ticketSurveyHandler.SubmitSurvey)
+
 		// 公共公告路由（无需认证）
 		v1.GET("/announcements/public", a.systemHandler.GetPublicAnnouncements)
 
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"info-management-system/internal/services"
+)
+
+// jobSpec 后台任务定义
+type jobSpec struct {
+	name        string
+	description string
+	interval    time.Duration
+	run         services.SchedulerJobFunc
+}
+
+// registerJobs 注册后台任务，运行间隔可通过 scheduler.jobs 按任务名配置
+func (a *App) registerJobs() error {
+	cfg := &a.config.Scheduler
+	jobs := []jobSpec{
+		{"ticket_auto_progress", "推进停留在自动流转状态的工单，如审批通过后开始处理", time.Minute,
+			func(now time.Time) (string, error) {
+				count, err := a.ticketWorkflowService.AutoProgressTickets()
+				return fmt.Sprintf("推进 %d 个工单", count), err
+			}},
+		{"ticket_processing_timeout", "对处理超时的工单执行超时流转", 5 * time.Minute,
+			func(now time.Time) (string, error) {
+				count, err := a.ticketWorkflowService.CloseTimedOutTickets(now)
+				return fmt.Sprintf("超时关闭 %d 个工单", count), err
+			}},
+		{"ticket_sla_monitor", "检查工单SLA预警与违约并执行升级", time.Minute,
+			func(now time.Time) (string, error) {
+				result, err := a.ticketSLAService.CheckSLAs(now)
+				if err != nil {
+					return "", err
+				}
+				succeeded := 0
+				for _, ids := range [][]uint{result.Warned, result.Breached} {
+					for _, id := range ids {
+						if _, failed := result.Failed[id]; !failed {
+							succeeded++
+						}
+					}
+				}
+				return jobResult(fmt.Sprintf("预警 %d，违约 %d，失败 %d", len(result.Warned), len(result.Breached), len(result.Failed)), succeeded, len(result.Failed))
+			}},
+		{"record_schedule", "记录定时发布与下线", time.Minute,
+			func(now time.Time) (string, error) {
+				result, err := a.recordWorkflowService.RunScheduledTransitions(now)
+				if err != nil {
+					return "", err
+				}
+				return jobResult(fmt.Sprintf("发布 %d，下线 %d，失败 %d", len(result.Published), len(result.Archived), len(result.Failed)),
+					len(result.Published)+len(result.Archived), len(result.Failed))
+			}},
+		{"record_lock_cleanup", "清理过期的记录编辑锁", time.Minute,
+			func(now time.Time) (string, error) {
+				count, err := a.recordService.PurgeExpiredRecordLocks()
+				return fmt.Sprintf("清理 %d 个编辑锁", count), err
+			}},
+		{"recycle_bin_purge", "清理回收站中超过保留期的数据", time.Hour,
+			func(now time.Time) (string, error) {
+				count, err := a.recycleBinService.PurgeExpired()
+				return fmt.Sprintf("清理 %d 条过期数据", count), err
+			}},
+	}
+
+	if mail := &a.config.InboundMail; mail.Enabled && mail.Maildir != "" {
+		jobs = append(jobs, jobSpec{"inbound_mail_maildir", "读取 maildir 中的新邮件，创建工单或追加评论", time.Minute,
+			func(now time.Time) (string, error) {
+				result, err := a.inboundMailService.PollMaildir(mail.Maildir)
+				if err != nil {
+					return "", err
+				}
+				return jobResult(fmt.Sprintf("创建 %d，评论 %d，跳过 %d，失败 %d", result.Created, result.Commented, result.Skipped, result.Failed),
+					result.Created+result.Commented, result.Failed)
+			}})
+	}
+
+	for _, job := range jobs {
+		if err := a.schedulerService.Register(job.name, job.description, cfg.JobInterval(job.name, job.interval), job.run); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jobResult 汇总逐项处理的任务结果，有失败项且没有一项成功时任务记为失败
+func jobResult(message string, succeeded, failed int) (string, error) {
+	if failed > 0 && succeeded == 0 {
+		return message, fmt.Errorf("%d 项全部处理失败", failed)
+	}
+	return message, nil
+}
@@ -3,17 +3,20 @@ package config
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 // Config 应用配置结构
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Redis    RedisConfig    `mapstructure:"redis"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
-	Log      LogConfig      `mapstructure:"log"`
+	Server      ServerConfig      `mapstructure:"server"`
+	Database    DatabaseConfig    `mapstructure:"database"`
+	Redis       RedisConfig       `mapstructure:"redis"`
+	JWT         JWTConfig         `mapstructure:"jwt"`
+	Log         LogConfig         `mapstructure:"log"`
+	Scheduler   SchedulerConfig   `mapstructure:"scheduler"`
+	InboundMail InboundMailConfig `mapstructure:"inbound_mail"`
 }
 
 // ServerConfig 服务器配置
@@ -89,6 +92,28 @@ type LogConfig struct {
 	Compress   bool   `mapstructure:"compress"`     // 是否压缩旧日志文件
 }
 
+// SchedulerConfig 后台任务调度配置
+type SchedulerConfig struct {
+	Enabled          bool              `mapstructure:"enabled"`            // 是否在本实例运行后台任务
+	TickInterval     string            `mapstructure:"tick_interval"`      // 检查到期任务的间隔
+	LockTTL          string            `mapstructure:"lock_ttl"`           // 任务锁有效期，实例异常退出后锁在此时间后失效
+	RunRetentionDays int               `mapstructure:"run_retention_days"` // 运行日志保留天数
+	Jobs             map[string]string `mapstructure:"jobs"`               // 按任务名覆盖运行间隔，如 ticket_auto_progress: "1m"
+}
+
+// InboundMailConfig 邮件转工单配置，可从 maildir 目录读取或监听 SMTP 端口接收邮件
+type InboundMailConfig struct {
+	Enabled          bool   `mapstructure:"enabled"`
+	Address          string `mapstructure:"address"`             // 收件地址，发件人为该地址的邮件视为回环
+	Maildir          string `mapstructure:"maildir"`             // maildir 目录，为空时不读取；IMAP 邮箱可通过 fetchmail 等工具投递到该目录
+	SMTPListen       string `mapstructure:"smtp_listen"`         // SMTP 监听地址，如 ":2525"，为空时不监听
+	SMTPDomain       string `mapstructure:"smtp_domain"`         // SMTP 问候中使用的域名
+	MaxMessageSizeMB int    `mapstructure:"max_message_size_mb"` // 单封邮件大小上限
+	MaxPerSenderHour int    `mapstructure:"max_per_sender_hour"` // 同一发件人每小时最多处理的邮件数，超出视为邮件循环
+	DefaultType      string `mapstructure:"default_type"`        // 新工单类型
+	DefaultPriority  string `mapstructure:"default_priority"`    // 新工单优先级
+}
+
 // Load 加载配置
 func Load() (*Config, error) {
 	viper.SetConfigName("config")
@@ -144,6 +169,20 @@ func setDefaults() {
 	// 日志默认配置
 	viper.SetDefault("log.level", "info")
 	viper.SetDefault("log.format", "json")
+
+	// 后台任务默认配置
+	viper.SetDefault("scheduler.enabled", true)
+	viper.SetDefault("scheduler.tick_interval", "15s")
+	viper.SetDefault("scheduler.lock_ttl", "10m")
+	viper.SetDefault("scheduler.run_retention_days", 30)
+
+	// 邮件转工单默认配置
+	viper.SetDefault("inbound_mail.enabled", false)
+	viper.SetDefault("inbound_mail.smtp_domain", "localhost")
+	viper.SetDefault("inbound_mail.max_message_size_mb", 25)
+	viper.SetDefault("inbound_mail.max_per_sender_hour", 20)
+	viper.SetDefault("inbound_mail.default_type", "support")
+	viper.SetDefault("inbound_mail.default_priority", "normal")
 }
 
 // GetDSN 获取数据库连接字符串
@@ -191,3 +230,27 @@ func (c *DatabaseConfig) GetDriver() string {
 	}
 	return driver
 }
+
+// JobInterval 获取任务的运行间隔，未配置或格式错误时返回默认值
+func (c *SchedulerConfig) JobInterval(name string, fallback time.Duration) time.Duration {
+	return parseDuration(c.Jobs[name], fallback)
+}
+
+// GetTickInterval 获取检查到期任务的间隔
+func (c *SchedulerConfig) GetTickInterval() time.Duration {
+	return parseDuration(c.TickInterval, 15*time.Second)
+}
+
+// GetLockTTL 获取任务锁有效期
+func (c *SchedulerConfig) GetLockTTL() time.Duration {
+	return parseDuration(c.LockTTL, 10*time.Minute)
+}
+
+// parseDuration 解析时长配置，为空、格式错误或不为正数时返回默认值
+func parseDuration(value string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
@@ -19,6 +19,11 @@ func runCustomMigrations(db *gorm.DB) error {
 	if err := createIndexes(db); err != nil {
 		return fmt.Errorf("failed to create indexes: %w", err)
 	}
+
+	// 将记录和工单的JSON标签迁移为标签实体
+	if err := services.MigrateLegacyTags(db); err != nil {
+		return fmt.Errorf("failed to migrate legacy tags: %w", err)
+	}
 	
 	return nil
 }
@@ -86,49 +91,11 @@ func createIndexes(db *gorm.DB) error {
 
 // Migrate 执行数据库迁移
 func Migrate(db *gorm.DB) error {
-	// 先自动迁移所有模型（创建表）
-	err := db.AutoMigrate(
-		&models.User{},
-		&models.Role{},
-		&models.Permission{},
-		&models.RolePermission{},
-		&models.UserRole{},
-		&models.UserPermission{},
-		&models.RecordType{},
-		&models.Record{},
-		&models.AuditLog{},
-		&models.File{},
-		&models.ExportTemplate{},
-		&models.ExportTask{},
-		&models.ExportFile{},
-		&models.NotificationTemplate{},
-		&models.Notification{},
-		&models.NotificationChannel{},
-		&models.AlertRule{},
-		&models.AlertEvent{},
-		&models.NotificationQueue{},
-		&models.Ticket{},
-		&models.TicketComment{},
-		&models.TicketAttachment{},
-		&models.TicketHistory{},
-		&models.AIConfig{},
-		&models.AIChatSession{},
-		&models.AIChatMessage{},
-		&models.AITask{},
-		&models.AIUsageStats{},
-		&models.AIHealthCheck{},
-		&models.SystemConfig{},
-		&models.SystemConfigHistory{},
-		&models.Announcement{},
-		&models.AnnouncementView{},
-		&models.SystemHealth{},
-		&models.SystemLog{},
-		&models.SystemMetrics{},
-		&models.SystemMaintenance{},
-		&models.APIToken{},
-		&models.APITokenUsageLog{},
-	)
+	// 关注表首次创建时需要为已有数据补充默认关注者
+	backfillWatchers := !db.Migrator().HasTable(&models.Watcher{})
 
+	// 先自动迁移所有模型（创建表）
+	err := db.AutoMigrate(models.AllModels()...)
 	if err != nil {
 		return fmt.Errorf("failed to migrate database: %w", err)
 	}
@@ -138,6 +105,12 @@ func Migrate(db *gorm.DB) error {
 		return fmt.Errorf("failed to run custom migrations: %w", err)
 	}
 
+	if backfillWatchers {
+		if err := services.BackfillDefaultWatchers(db); err != nil {
+			return fmt.Errorf("failed to backfill watchers: %w", err)
+		}
+	}
+
 	// 创建初始数据
 	if err := createInitialData(db); err != nil {
 		return fmt.Errorf("failed to create initial data: %w", err)
@@ -163,6 +136,11 @@ func createInitialData(db *gorm.DB) error {
 		return err
 	}
 
+	// 创建系统自动操作使用的内置账号
+	if _, err := services.SystemUserID(db); err != nil {
+		return err
+	}
+
 	// 为管理员角色分配所有权限
 	if err := AssignAdminPermissions(db); err != nil {
 		return err
@@ -178,6 +156,11 @@ func createInitialData(db *gorm.DB) error {
 		return err
 	}
 
+	// 创建默认工单流程
+	if err := services.SeedDefaultTicketWorkflow(db); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -631,6 +614,132 @@ func createDefaultSystemConfigs(db *gorm.DB) error {
 			Version:      1,
 			UpdatedBy:    1,
 		},
+		{
+			Category:     "storage",
+			Key:          "record_attachment_gc",
+			Value:        "false",
+			DefaultValue: "false",
+			Description:  "删除记录时是否清理不再被引用的附件文件",
+			DataType:     "bool",
+			IsPublic:     false,
+			IsEditable:   true,
+			Version:      1,
+			UpdatedBy:    1,
+		},
+
+		// 回收站配置
+		{
+			Category:     "recycle_bin",
+			Key:          "retention_days",
+			Value:        "30",
+			DefaultValue: "30",
+			Description:  "回收站数据保留天数，超过后自动彻底删除，0表示不自动清理",
+			DataType:     "int",
+			IsPublic:     false,
+			IsEditable:   true,
+			Version:      1,
+			UpdatedBy:    1,
+		},
+
+		// 评论配置
+		{
+			Category:     "comments",
+			Key:          "edit_window_minutes",
+			Value:        "15",
+			DefaultValue: "15",
+			Description:  "评论发表后允许作者编辑的时限（分钟），0表示不限制",
+			DataType:     "int",
+			IsPublic:     true,
+			IsEditable:   true,
+			Version:      1,
+			UpdatedBy:    1,
+		},
+		{
+			Category:     "comments",
+			Key:          "delete_window_minutes",
+			Value:        "60",
+			DefaultValue: "60",
+			Description:  "评论发表后允许作者删除的时限（分钟），0表示不限制",
+			DataType:     "int",
+			IsPublic:     true,
+			IsEditable:   true,
+			Version:      1,
+			UpdatedBy:    1,
+		},
+
+		// 工单满意度调查配置
+		{
+			Category:     "ticket_survey",
+			Key:          "enabled",
+			Value:        "true",
+			DefaultValue: "true",
+			Description:  "工单解决或关闭时是否向创建者发送满意度调查",
+			DataType:     "bool",
+			IsPublic:     false,
+			IsEditable:   true,
+			Version:      1,
+			UpdatedBy:    1,
+		},
+		{
+			Category:     "ticket_survey",
+			Key:          "valid_days",
+			Value:        "7",
+			DefaultValue: "7",
+			Description:  "满意度调查链接有效天数",
+			DataType:     "int",
+			IsPublic:     false,
+			IsEditable:   true,
+			Version:      1,
+			UpdatedBy:    1,
+		},
+		{
+			Category:     "ticket_survey",
+			Key:          "link_base",
+			Value:        "/survey/",
+			DefaultValue: "/survey/",
+			Description:  "满意度调查链接前缀，链接为前缀加一次性令牌",
+			DataType:     "string",
+			IsPublic:     false,
+			IsEditable:   true,
+			Version:      1,
+			UpdatedBy:    1,
+		},
+		{
+			Category:     "ticket_survey",
+			Key:          "poor_rating",
+			Value:        "2",
+			DefaultValue: "2",
+			Description:  "评分不高于该值视为差评",
+			DataType:     "int",
+			IsPublic:     false,
+			IsEditable:   true,
+			Version:      1,
+			UpdatedBy:    1,
+		},
+		{
+			Category:     "ticket_survey",
+			Key:          "poor_action",
+			Value:        "notify",
+			DefaultValue: "notify",
+			Description:  "差评处理方式：none 不处理，reopen 重新打开工单（流程不支持时通知负责人），notify 通知负责人",
+			DataType:     "string",
+			IsPublic:     false,
+			IsEditable:   true,
+			Version:      1,
+			UpdatedBy:    1,
+		},
+		{
+			Category:     "ticket_survey",
+			Key:          "manager_targets",
+			Value:        "role:admin",
+			DefaultValue: "role:admin",
+			Description:  "差评通知对象，逗号分隔，支持 role:<角色名>、user:<用户ID>、assignee",
+			DataType:     "string",
+			IsPublic:     false,
+			IsEditable:   true,
+			Version:      1,
+			UpdatedBy:    1,
+		},
 
 		// 缓存配置
 		{
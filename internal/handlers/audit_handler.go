@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"strconv"
+	"strings"
 
 	"info-management-system/internal/middleware"
 	"info-management-system/internal/services"
@@ -51,6 +52,10 @@ func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
 
 	logs, err := h.auditService.GetAuditLogs(&query)
 	if err != nil {
+		if !strings.Contains(err.Error(), "失败") {
+			middleware.ValidationErrorResponse(c, err.Error(), "")
+			return
+		}
 		middleware.InternalErrorResponse(c, err)
 		return
 	}
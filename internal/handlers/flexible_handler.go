@@ -283,7 +283,7 @@ func (h *FlexibleHandler) FlexibleTicketAssign(c *gin.Context) {
 
 	err = h.ticketService.AssignTicket(uint(id), req.AssigneeID, userID, req.Reason)
 	if err != nil {
-		middleware.InternalErrorResponse(c, err)
+		handleTicketWorkflowError(c, err)
 		return
 	}
 
@@ -322,7 +322,7 @@ func (h *FlexibleHandler) FlexibleTicketReject(c *gin.Context) {
 
 	err = h.ticketService.RejectTicket(uint(id), userID, req.Reason)
 	if err != nil {
-		middleware.InternalErrorResponse(c, err)
+		handleTicketWorkflowError(c, err)
 		return
 	}
 
@@ -361,7 +361,7 @@ func (h *FlexibleHandler) FlexibleTicketStatusChange(c *gin.Context) {
 
 	err = h.ticketService.UpdateTicketStatus(uint(id), req.Status, userID, req.Comment)
 	if err != nil {
-		middleware.InternalErrorResponse(c, err)
+		handleTicketWorkflowError(c, err)
 		return
 	}
 
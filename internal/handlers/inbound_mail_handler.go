@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"strconv"
+
+	"info-management-system/internal/middleware"
+	"info-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InboundMailHandler 邮件转工单处理器
+type InboundMailHandler struct {
+	inboundMailService *services.InboundMailService
+}
+
+// NewInboundMailHandler 创建邮件转工单处理器
+func NewInboundMailHandler(inboundMailService *services.InboundMailService) *InboundMailHandler {
+	return &InboundMailHandler{
+		inboundMailService: inboundMailService,
+	}
+}
+
+// ListEmails 获取收到的邮件及处理结果，可按状态筛选
+func (h *InboundMailHandler) ListEmails(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	emails, total, err := h.inboundMailService.ListEmails(c.Query("status"), page, pageSize)
+	if err != nil {
+		middleware.InternalErrorResponse(c, err)
+		return
+	}
+
+	middleware.Success(c, gin.H{
+		"items":     emails,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"info-management-system/internal/middleware"
+	"info-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LinkHandler 实体关联处理器
+type LinkHandler struct {
+	linkService *services.LinkService
+}
+
+// NewLinkHandler 创建实体关联处理器
+func NewLinkHandler(linkService *services.LinkService) *LinkHandler {
+	return &LinkHandler{
+		linkService: linkService,
+	}
+}
+
+// GetLinks 获取实体的关联列表
+func (h *LinkHandler) GetLinks(c *gin.Context) {
+	var query services.LinkQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	links, err := h.linkService.GetEntityLinks(query.EntityType, query.EntityID, query.LinkType, linkAccessScope(c))
+	if err != nil {
+		if strings.HasSuffix(err.Error(), "不存在或无权访问") {
+			handleNotFoundError(c, err.Error())
+			return
+		}
+		middleware.InternalErrorResponse(c, err)
+		return
+	}
+
+	middleware.Success(c, links)
+}
+
+// CreateLink 创建关联
+func (h *LinkHandler) CreateLink(c *gin.Context) {
+	var req services.CreateLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	link, err := h.linkService.CreateLink(&req, linkAccessScope(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		switch {
+		case strings.HasSuffix(err.Error(), "不存在或无权访问"):
+			handleNotFoundError(c, err.Error())
+		case err.Error() == "关联已存在":
+			handleConflictError(c, err.Error())
+		case strings.HasPrefix(err.Error(), "无效的"), err.Error() == "不能关联到自身",
+			err.Error() == "附件关联的源必须是文件", err.Error() == "重复关联只能在同类实体之间建立":
+			middleware.ValidationErrorResponse(c, err.Error(), "")
+		default:
+			middleware.InternalErrorResponse(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    link,
+	})
+}
+
+// DeleteLink 删除关联
+func (h *LinkHandler) DeleteLink(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	if err := h.linkService.DeleteLink(id, linkAccessScope(c), c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		switch err.Error() {
+		case "关联不存在或无权访问":
+			handleNotFoundError(c, err.Error())
+		case "工单依赖请通过工单依赖接口删除":
+			middleware.ValidationErrorResponse(c, err.Error(), "")
+		default:
+			middleware.InternalErrorResponse(c, err)
+		}
+		return
+	}
+
+	middleware.Success(c, gin.H{"message": "关联删除成功"})
+}
+
+// linkAccessScope 根据当前用户权限构建关联访问范围
+func linkAccessScope(c *gin.Context) *services.LinkAccessScope {
+	return &services.LinkAccessScope{
+		UserID:     getUserID(c),
+		AllRecords: c.GetBool("has_all_records_permission"),
+		AllTickets: hasPermission(c, "ticket:view_all"),
+		AllFiles:   c.GetBool("has_all_files_permission"),
+	}
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"info-management-system/internal/middleware"
+	"info-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecordBulkHandler 记录批量操作处理器
+type RecordBulkHandler struct {
+	bulkService *services.RecordBulkService
+}
+
+// NewRecordBulkHandler 创建记录批量操作处理器
+func NewRecordBulkHandler(bulkService *services.RecordBulkService) *RecordBulkHandler {
+	return &RecordBulkHandler{
+		bulkService: bulkService,
+	}
+}
+
+// PreviewBulkOperation 预览筛选条件匹配的记录数
+func (h *RecordBulkHandler) PreviewBulkOperation(c *gin.Context) {
+	var req services.RecordBulkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	preview, err := h.bulkService.Preview(&req, getUserID(c), c.GetBool("has_all_records_permission"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, preview)
+}
+
+// CreateBulkJob 创建批量操作任务
+func (h *RecordBulkHandler) CreateBulkJob(c *gin.Context) {
+	var req services.RecordBulkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	job, err := h.bulkService.CreateJob(&req, getUserID(c), c.GetBool("has_all_records_permission"),
+		c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    job,
+	})
+}
+
+// ListBulkJobs 获取批量操作任务列表
+func (h *RecordBulkHandler) ListBulkJobs(c *gin.Context) {
+	jobs, err := h.bulkService.ListJobs(getUserID(c), c.GetBool("has_all_records_permission"))
+	if err != nil {
+		middleware.InternalErrorResponse(c, err)
+		return
+	}
+
+	middleware.Success(c, jobs)
+}
+
+// GetBulkJob 获取批量操作任务详情与进度
+func (h *RecordBulkHandler) GetBulkJob(c *gin.Context) {
+	jobID, err := parseUintParam(c, "job_id")
+	if err != nil {
+		return
+	}
+
+	job, err := h.bulkService.GetJob(jobID, getUserID(c), c.GetBool("has_all_records_permission"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, job)
+}
+
+// CancelBulkJob 取消批量操作任务
+func (h *RecordBulkHandler) CancelBulkJob(c *gin.Context) {
+	jobID, err := parseUintParam(c, "job_id")
+	if err != nil {
+		return
+	}
+
+	job, err := h.bulkService.CancelJob(jobID, getUserID(c), c.GetBool("has_all_records_permission"),
+		c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, job)
+}
+
+// GetBulkJobErrors 获取批量操作任务的记录错误
+func (h *RecordBulkHandler) GetBulkJobErrors(c *gin.Context) {
+	jobID, err := parseUintParam(c, "job_id")
+	if err != nil {
+		return
+	}
+
+	var query services.RecordBulkErrorQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	result, err := h.bulkService.GetJobErrors(jobID, &query, getUserID(c), c.GetBool("has_all_records_permission"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, result)
+}
+
+// handleError 将批量操作服务错误映射为HTTP响应
+func (h *RecordBulkHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "批量操作任务不存在":
+		handleNotFoundError(c, err.Error())
+	case err.Error() == "只能取消未完成的任务":
+		handleConflictError(c, err.Error())
+	case strings.Contains(err.Error(), "失败"):
+		middleware.InternalErrorResponse(c, err)
+	default:
+		middleware.ValidationErrorResponse(c, err.Error(), "")
+	}
+}
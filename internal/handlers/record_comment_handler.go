@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"info-management-system/internal/middleware"
+	"info-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecordCommentHandler 记录评论处理器
+type RecordCommentHandler struct {
+	commentService *services.RecordCommentService
+}
+
+// NewRecordCommentHandler 创建记录评论处理器
+func NewRecordCommentHandler(commentService *services.RecordCommentService) *RecordCommentHandler {
+	return &RecordCommentHandler{
+		commentService: commentService,
+	}
+}
+
+// GetComments 获取记录的评论树
+func (h *RecordCommentHandler) GetComments(c *gin.Context) {
+	recordID, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	comments, err := h.commentService.ListComments(recordID, linkAccessScope(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, comments)
+}
+
+// CreateComment 发表评论或回复
+func (h *RecordCommentHandler) CreateComment(c *gin.Context) {
+	recordID, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	var req services.CreateRecordCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	comment, err := h.commentService.CreateComment(recordID, &req, linkAccessScope(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    comment,
+	})
+}
+
+// UpdateComment 编辑评论
+func (h *RecordCommentHandler) UpdateComment(c *gin.Context) {
+	recordID, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+	commentID, err := parseUintParam(c, "comment_id")
+	if err != nil {
+		return
+	}
+
+	var req services.UpdateRecordCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	comment, err := h.commentService.UpdateComment(recordID, commentID, &req, linkAccessScope(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, comment)
+}
+
+// DeleteComment 删除评论，具备 record_comments:manage 权限时可删除任意评论
+func (h *RecordCommentHandler) DeleteComment(c *gin.Context) {
+	recordID, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+	commentID, err := parseUintParam(c, "comment_id")
+	if err != nil {
+		return
+	}
+
+	err = h.commentService.DeleteComment(recordID, commentID, linkAccessScope(c), hasPermission(c, "record_comments:manage"),
+		c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, gin.H{"message": "删除成功"})
+}
+
+// handleError 将评论服务错误映射为HTTP响应
+func (h *RecordCommentHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "不存在或无权访问"), strings.HasSuffix(err.Error(), "评论不存在"):
+		handleNotFoundError(c, err.Error())
+	case strings.HasPrefix(err.Error(), "只能"):
+		handleForbiddenError(c, err.Error())
+	case strings.Contains(err.Error(), "失败"):
+		middleware.InternalErrorResponse(c, err)
+	default:
+		middleware.ValidationErrorResponse(c, err.Error(), "")
+	}
+}
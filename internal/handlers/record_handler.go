@@ -1,10 +1,14 @@
 package handlers
 
 import (
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"info-management-system/internal/middleware"
+	"info-management-system/internal/models"
 	"info-management-system/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -12,13 +16,17 @@ import (
 
 // RecordHandler 记录处理器
 type RecordHandler struct {
-	recordService *services.RecordService
+	recordService   *services.RecordService
+	linkService     *services.LinkService
+	workflowService *services.RecordWorkflowService
 }
 
 // NewRecordHandler 创建记录处理器
-func NewRecordHandler(recordService *services.RecordService) *RecordHandler {
+func NewRecordHandler(recordService *services.RecordService, linkService *services.LinkService, workflowService *services.RecordWorkflowService) *RecordHandler {
 	return &RecordHandler{
-		recordService: recordService,
+		recordService:   recordService,
+		linkService:     linkService,
+		workflowService: workflowService,
 	}
 }
 
@@ -34,8 +42,15 @@ func (h *RecordHandler) GetRecords(c *gin.Context) {
 	userID := c.GetUint("user_id")
 	hasAllPermission := c.GetBool("has_all_records_permission")
 
+	// 发布时间窗口由服务端强制：只有有编辑权限的用户可以看到未发布和已下线的记录
+	query.VisibleOnly = !canViewUnpublishedRecords(c)
+
 	records, err := h.recordService.GetRecords(&query, userID, hasAllPermission)
 	if err != nil {
+		if !strings.Contains(err.Error(), "失败") {
+			middleware.ValidationErrorResponse(c, err.Error(), "")
+			return
+		}
 		middleware.InternalErrorResponse(c, err)
 		return
 	}
@@ -73,6 +88,25 @@ func (h *RecordHandler) GetRecordByID(c *gin.Context) {
 		return
 	}
 
+	// 未到发布时间或已下线的记录对无编辑权限的读者按不存在处理
+	if !canViewUnpublishedRecords(c) && !services.RecordVisibleTo(record, userID, time.Now()) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "RECORD_NOT_FOUND",
+				"message": "记录不存在或无权访问",
+			},
+		})
+		return
+	}
+
+	// 附加关联信息
+	if h.linkService != nil {
+		if links, err := h.linkService.GetEntityLinks(models.LinkEntityRecord, record.ID, "", linkAccessScope(c)); err == nil {
+			record.Links = links
+		}
+	}
+
 	middleware.Success(c, record)
 }
 
@@ -90,6 +124,22 @@ func (h *RecordHandler) CreateRecord(c *gin.Context) {
 
 	record, err := h.recordService.CreateRecord(&req, userID, clientIP, userAgent)
 	if err != nil {
+		if err.Error() == "下线时间必须晚于发布时间" {
+			middleware.ValidationErrorResponse(c, err.Error(), "")
+			return
+		}
+		if strings.HasPrefix(err.Error(), "记录与已有记录重复") {
+			handleConflictError(c, err.Error())
+			return
+		}
+		if err.Error() == "模板不存在或无权使用" {
+			handleNotFoundError(c, err.Error())
+			return
+		}
+		if strings.HasPrefix(err.Error(), "模板") || err.Error() == "记录标题不能为空" {
+			middleware.ValidationErrorResponse(c, err.Error(), "")
+			return
+		}
 		middleware.InternalErrorResponse(c, err)
 		return
 	}
@@ -115,6 +165,12 @@ func (h *RecordHandler) UpdateRecord(c *gin.Context) {
 		return
 	}
 
+	// 强制解除他人的编辑锁需要单独授权
+	if req.ForceBreakLock && !hasPermission(c, "records:break_lock") {
+		handleForbiddenError(c, "无权强制解除编辑锁")
+		return
+	}
+
 	// 获取用户信息和权限
 	userID := c.GetUint("user_id")
 	hasAllPermission := c.GetBool("has_all_records_permission")
@@ -123,6 +179,10 @@ func (h *RecordHandler) UpdateRecord(c *gin.Context) {
 
 	record, err := h.recordService.UpdateRecord(uint(id), &req, userID, hasAllPermission, clientIP, userAgent)
 	if err != nil {
+		if strings.HasPrefix(err.Error(), "记录已被") || strings.HasPrefix(err.Error(), "记录与已有记录重复") {
+			handleConflictError(c, err.Error())
+			return
+		}
 		if err.Error() == "记录不存在或无权修改" {
 			c.JSON(http.StatusNotFound, gin.H{
 				"success": false,
@@ -233,13 +293,13 @@ func (h *RecordHandler) BatchUpdateRecordStatus(c *gin.Context) {
 	}
 
 	userID := c.GetUint("user_id")
-	err := h.recordService.BatchUpdateRecordStatus(&req, userID)
+	result, err := h.recordService.BatchUpdateRecordStatus(&req, userID)
 	if err != nil {
 		middleware.ValidationErrorResponse(c, "批量更新记录状态失败", err.Error())
 		return
 	}
 
-	middleware.Success(c, gin.H{"message": "批量更新成功"})
+	middleware.Success(c, result)
 }
 
 // BatchDeleteRecords 批量删除记录
@@ -280,3 +340,397 @@ func (h *RecordHandler) GetRecordsByType(c *gin.Context) {
 
 	middleware.Success(c, records)
 }
+
+// GetRecordFiles 获取记录附件列表
+func (h *RecordHandler) GetRecordFiles(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	hasAllPermission := c.GetBool("has_all_records_permission")
+
+	files, err := h.recordService.GetRecordFiles(id, userID, hasAllPermission)
+	if err != nil {
+		if err.Error() == "记录不存在或无权访问" {
+			handleNotFoundError(c, err.Error())
+			return
+		}
+		middleware.InternalErrorResponse(c, err)
+		return
+	}
+
+	middleware.Success(c, files)
+}
+
+// AttachRecordFiles 添加记录附件
+func (h *RecordHandler) AttachRecordFiles(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	var req services.AttachRecordFilesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	hasAllPermission := c.GetBool("has_all_records_permission")
+
+	files, err := h.recordService.AttachFiles(id, &req, userID, hasAllPermission, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		if err.Error() == "记录不存在或无权修改" {
+			handleNotFoundError(c, err.Error())
+			return
+		}
+		if strings.HasSuffix(err.Error(), "不存在或无权访问") {
+			middleware.ValidationErrorResponse(c, err.Error(), "")
+			return
+		}
+		middleware.InternalErrorResponse(c, err)
+		return
+	}
+
+	middleware.Success(c, files)
+}
+
+// DetachRecordFile 移除记录附件
+func (h *RecordHandler) DetachRecordFile(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+	fileID, err := parseUintParam(c, "file_id")
+	if err != nil {
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	hasAllPermission := c.GetBool("has_all_records_permission")
+
+	err = h.recordService.DetachFile(id, fileID, userID, hasAllPermission, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		if err.Error() == "记录不存在或无权修改" || err.Error() == "附件不存在" {
+			handleNotFoundError(c, err.Error())
+			return
+		}
+		middleware.InternalErrorResponse(c, err)
+		return
+	}
+
+	middleware.Success(c, gin.H{"message": "附件移除成功"})
+}
+
+// FindDuplicateRecords 查找同一类型内疑似重复的记录
+func (h *RecordHandler) FindDuplicateRecords(c *gin.Context) {
+	var query services.DuplicateQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	hasAllPermission := c.GetBool("has_all_records_permission")
+
+	candidates, err := h.recordService.FindDuplicates(&query, userID, hasAllPermission)
+	if err != nil {
+		if err.Error() == "记录类型不存在" {
+			handleNotFoundError(c, err.Error())
+			return
+		}
+		middleware.InternalErrorResponse(c, err)
+		return
+	}
+
+	middleware.Success(c, candidates)
+}
+
+// MergeRecords 将重复记录合并到目标记录
+func (h *RecordHandler) MergeRecords(c *gin.Context) {
+	var req services.MergeRecordsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	hasAllPermission := c.GetBool("has_all_records_permission")
+
+	record, err := h.recordService.MergeRecords(&req, userID, hasAllPermission, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "不存在或无权修改"):
+			handleNotFoundError(c, err.Error())
+		case strings.HasPrefix(err.Error(), "记录已被"):
+			handleConflictError(c, err.Error())
+		case !strings.Contains(err.Error(), "失败"):
+			middleware.ValidationErrorResponse(c, err.Error(), "")
+		default:
+			middleware.InternalErrorResponse(c, err)
+		}
+		return
+	}
+
+	middleware.Success(c, record)
+}
+
+// ScheduleRecord 设置记录的定时发布/下线时间
+func (h *RecordHandler) ScheduleRecord(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	var req services.RecordScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	hasAllPermission := c.GetBool("has_all_records_permission")
+
+	record, err := h.recordService.ScheduleRecord(id, &req, userID, hasAllPermission, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		switch {
+		case err.Error() == "记录不存在或无权修改":
+			handleNotFoundError(c, err.Error())
+		case err.Error() == "下线时间必须晚于发布时间":
+			middleware.ValidationErrorResponse(c, err.Error(), "")
+		default:
+			middleware.InternalErrorResponse(c, err)
+		}
+		return
+	}
+
+	middleware.Success(c, record)
+}
+
+// AcquireRecordLock 获取记录编辑锁
+func (h *RecordHandler) AcquireRecordLock(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	var req services.RecordLockRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	hasAllPermission := c.GetBool("has_all_records_permission")
+
+	lock, err := h.recordService.AcquireRecordLock(id, &req, userID, hasAllPermission)
+	if err != nil {
+		h.handleLockError(c, err)
+		return
+	}
+
+	middleware.Success(c, lock)
+}
+
+// RenewRecordLock 续期记录编辑锁（心跳）
+func (h *RecordHandler) RenewRecordLock(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	var req services.RecordLockRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	lock, err := h.recordService.RenewRecordLock(id, &req, c.GetUint("user_id"))
+	if err != nil {
+		h.handleLockError(c, err)
+		return
+	}
+
+	middleware.Success(c, lock)
+}
+
+// ReleaseRecordLock 释放记录编辑锁，force=true 时强制解除他人的锁
+func (h *RecordHandler) ReleaseRecordLock(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	force := c.Query("force") == "true"
+	if force && !hasPermission(c, "records:break_lock") {
+		handleForbiddenError(c, "无权强制解除编辑锁")
+		return
+	}
+
+	err = h.recordService.ReleaseRecordLock(id, c.GetUint("user_id"), force, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.handleLockError(c, err)
+		return
+	}
+
+	middleware.Success(c, gin.H{"message": "编辑锁已释放"})
+}
+
+// handleLockError 处理编辑锁错误
+func (h *RecordHandler) handleLockError(c *gin.Context, err error) {
+	switch {
+	case strings.HasPrefix(err.Error(), "记录已被"), err.Error() == "未持有该记录的编辑锁或锁已过期":
+		handleConflictError(c, err.Error())
+	case err.Error() == "记录不存在或无权修改", err.Error() == "记录未被锁定":
+		handleNotFoundError(c, err.Error())
+	default:
+		middleware.InternalErrorResponse(c, err)
+	}
+}
+
+// GetRecordTransitions 获取当前用户可执行的状态流转
+func (h *RecordHandler) GetRecordTransitions(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	hasAllPermission := c.GetBool("has_all_records_permission")
+
+	transitions, err := h.workflowService.GetAvailableTransitions(id, userID, hasAllPermission)
+	if err != nil {
+		h.handleWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, transitions)
+}
+
+// TransitionRecord 执行记录状态流转
+func (h *RecordHandler) TransitionRecord(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	var req services.RecordTransitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	hasAllPermission := c.GetBool("has_all_records_permission")
+
+	history, err := h.workflowService.Transition(id, &req, userID, hasAllPermission, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.handleWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, history)
+}
+
+// ApproveRecord 审核通过记录
+func (h *RecordHandler) ApproveRecord(c *gin.Context) {
+	h.reviewRecord(c, h.workflowService.Approve)
+}
+
+// RejectRecord 审核驳回记录
+func (h *RecordHandler) RejectRecord(c *gin.Context) {
+	h.reviewRecord(c, h.workflowService.Reject)
+}
+
+// reviewRecord 审核操作的公共处理
+func (h *RecordHandler) reviewRecord(c *gin.Context, review func(uint, *services.RecordReviewRequest, uint, bool, string, string) (*services.RecordStatusHistoryResponse, error)) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	var req services.RecordReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	hasAllPermission := c.GetBool("has_all_records_permission")
+
+	history, err := review(id, &req, userID, hasAllPermission, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.handleWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, history)
+}
+
+// AssignRecordReviewer 指派记录审核人
+func (h *RecordHandler) AssignRecordReviewer(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	var req services.AssignReviewerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	hasAllPermission := c.GetBool("has_all_records_permission")
+
+	if err := h.workflowService.AssignReviewer(id, &req, userID, hasAllPermission, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		h.handleWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, gin.H{"message": "审核人指派成功"})
+}
+
+// GetRecordStatusHistory 获取记录状态历史
+func (h *RecordHandler) GetRecordStatusHistory(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	hasAllPermission := c.GetBool("has_all_records_permission")
+
+	histories, err := h.workflowService.GetStatusHistory(id, userID, hasAllPermission)
+	if err != nil {
+		h.handleWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, histories)
+}
+
+// handleWorkflowError 将流程错误映射为响应
+func (h *RecordHandler) handleWorkflowError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "记录不存在或无权访问", "记录不存在或无权修改":
+		handleNotFoundError(c, err.Error())
+	case "无权执行该状态流转":
+		handleForbiddenError(c, err.Error())
+	case "记录状态已被修改，请刷新后重试":
+		handleConflictError(c, err.Error())
+	default:
+		if strings.HasPrefix(err.Error(), "获取") {
+			middleware.InternalErrorResponse(c, err)
+			return
+		}
+		middleware.ValidationErrorResponse(c, err.Error(), "")
+	}
+}
+
+// canViewUnpublishedRecords 有编辑权限的用户可以看到未到发布时间和已下线的记录
+func canViewUnpublishedRecords(c *gin.Context) bool {
+	return c.GetBool("has_modify_all_records_permission") || hasPermission(c, "records:update")
+}
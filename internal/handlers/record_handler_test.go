@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPermissionTestContext(userID uint, permissions ...string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set("user_id", userID)
+	c.Set("user_roles", []string{"user"})
+	c.Set("user_permissions", permissions)
+	return c
+}
+
+func TestCanViewUnpublishedRecords(t *testing.T) {
+	// 有编辑记录权限的用户可以看到未发布的记录
+	assert.True(t, canViewUnpublishedRecords(newPermissionTestContext(2, "records:read", "records:update")))
+	assert.False(t, canViewUnpublishedRecords(newPermissionTestContext(2, "records:read")))
+
+	// 中间件标记的修改全部记录权限同样生效
+	c := newPermissionTestContext(2)
+	c.Set("has_modify_all_records_permission", true)
+	assert.True(t, canViewUnpublishedRecords(c))
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"info-management-system/internal/middleware"
+	"info-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecordImportHandler 表格导入记录处理器
+type RecordImportHandler struct {
+	importService *services.RecordImportService
+}
+
+// NewRecordImportHandler 创建表格导入记录处理器
+func NewRecordImportHandler(importService *services.RecordImportService) *RecordImportHandler {
+	return &RecordImportHandler{
+		importService: importService,
+	}
+}
+
+// PreviewImport 解析上传表格的表头并给出列映射建议
+func (h *RecordImportHandler) PreviewImport(c *gin.Context) {
+	var req services.ImportPreviewRequest
+	if err := c.ShouldBind(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	preview, err := h.importService.Preview(&req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, preview)
+}
+
+// CreateImportJob 上传表格并创建导入任务
+func (h *RecordImportHandler) CreateImportJob(c *gin.Context) {
+	var req services.CreateImportJobRequest
+	if err := c.ShouldBind(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	job, err := h.importService.CreateJob(&req, getUserID(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    job,
+	})
+}
+
+// ListImportJobs 获取导入任务列表
+func (h *RecordImportHandler) ListImportJobs(c *gin.Context) {
+	jobs, err := h.importService.ListJobs(getUserID(c), c.GetBool("has_all_records_permission"))
+	if err != nil {
+		middleware.InternalErrorResponse(c, err)
+		return
+	}
+
+	middleware.Success(c, jobs)
+}
+
+// GetImportJob 获取导入任务详情与进度
+func (h *RecordImportHandler) GetImportJob(c *gin.Context) {
+	jobID, err := parseUintParam(c, "job_id")
+	if err != nil {
+		return
+	}
+
+	job, err := h.importService.GetJob(jobID, getUserID(c), c.GetBool("has_all_records_permission"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, job)
+}
+
+// CommitImportJob 确认提交试运行任务
+func (h *RecordImportHandler) CommitImportJob(c *gin.Context) {
+	jobID, err := parseUintParam(c, "job_id")
+	if err != nil {
+		return
+	}
+
+	job, err := h.importService.CommitJob(jobID, getUserID(c), c.GetBool("has_all_records_permission"),
+		c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    job,
+	})
+}
+
+// DownloadImportErrorReport 下载导入错误报告
+func (h *RecordImportHandler) DownloadImportErrorReport(c *gin.Context) {
+	jobID, err := parseUintParam(c, "job_id")
+	if err != nil {
+		return
+	}
+
+	path, err := h.importService.GetErrorReportPath(jobID, getUserID(c), c.GetBool("has_all_records_permission"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.FileAttachment(path, "import_errors.csv")
+}
+
+// ListImportMappings 获取已保存的列映射
+func (h *RecordImportHandler) ListImportMappings(c *gin.Context) {
+	mappings, err := h.importService.ListMappings(c.Query("type"), getUserID(c))
+	if err != nil {
+		middleware.InternalErrorResponse(c, err)
+		return
+	}
+
+	middleware.Success(c, mappings)
+}
+
+// SaveImportMapping 保存列映射
+func (h *RecordImportHandler) SaveImportMapping(c *gin.Context) {
+	var req services.SaveImportMappingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	mapping, err := h.importService.SaveMapping(&req, getUserID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    mapping,
+	})
+}
+
+// DeleteImportMapping 删除列映射
+func (h *RecordImportHandler) DeleteImportMapping(c *gin.Context) {
+	id, err := parseUintParam(c, "mapping_id")
+	if err != nil {
+		return
+	}
+
+	if err := h.importService.DeleteMapping(id, getUserID(c)); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, gin.H{"message": "删除成功"})
+}
+
+// handleError 将导入服务错误映射为HTTP响应
+func (h *RecordImportHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "记录类型不存在" || err.Error() == "导入任务不存在" ||
+		err.Error() == "列映射不存在" || err.Error() == "该任务没有错误报告":
+		handleNotFoundError(c, err.Error())
+	case strings.Contains(err.Error(), "失败"):
+		middleware.InternalErrorResponse(c, err)
+	default:
+		middleware.ValidationErrorResponse(c, err.Error(), "")
+	}
+}
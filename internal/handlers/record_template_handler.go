@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"info-management-system/internal/middleware"
+	"info-management-system/internal/models"
+	"info-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecordTemplateHandler 记录模板处理器
+type RecordTemplateHandler struct {
+	templateService *services.RecordTemplateService
+}
+
+// NewRecordTemplateHandler 创建记录模板处理器
+func NewRecordTemplateHandler(templateService *services.RecordTemplateService) *RecordTemplateHandler {
+	return &RecordTemplateHandler{
+		templateService: templateService,
+	}
+}
+
+// GetTemplates 获取当前用户可用的记录模板
+func (h *RecordTemplateHandler) GetTemplates(c *gin.Context) {
+	var query services.RecordTemplateQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	templates, err := h.templateService.ListTemplates(&query, getUserID(c), hasPermission(c, "record_templates:manage"))
+	if err != nil {
+		middleware.InternalErrorResponse(c, err)
+		return
+	}
+
+	middleware.Success(c, templates)
+}
+
+// GetTemplate 获取记录模板详情
+func (h *RecordTemplateHandler) GetTemplate(c *gin.Context) {
+	id, err := parseUintParam(c, "template_id")
+	if err != nil {
+		return
+	}
+
+	template, err := h.templateService.GetTemplate(id, getUserID(c), hasPermission(c, "record_templates:manage"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, template)
+}
+
+// CreateTemplate 创建记录模板，共享模板需要模板管理权限
+func (h *RecordTemplateHandler) CreateTemplate(c *gin.Context) {
+	var req services.CreateRecordTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+	if req.Visibility == models.TemplateVisibilityShared && !hasPermission(c, "record_templates:manage") {
+		handleForbiddenError(c, "无权创建共享模板")
+		return
+	}
+
+	template, err := h.templateService.CreateTemplate(&req, getUserID(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    template,
+	})
+}
+
+// UpdateTemplate 更新记录模板
+func (h *RecordTemplateHandler) UpdateTemplate(c *gin.Context) {
+	id, err := parseUintParam(c, "template_id")
+	if err != nil {
+		return
+	}
+
+	var req services.UpdateRecordTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+	canManage := hasPermission(c, "record_templates:manage")
+	if !canManage && ((req.Visibility != nil && *req.Visibility == models.TemplateVisibilityShared) || req.RoleIDs != nil) {
+		handleForbiddenError(c, "无权共享模板")
+		return
+	}
+
+	template, err := h.templateService.UpdateTemplate(id, &req, getUserID(c), canManage, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, template)
+}
+
+// DeleteTemplate 删除记录模板
+func (h *RecordTemplateHandler) DeleteTemplate(c *gin.Context) {
+	id, err := parseUintParam(c, "template_id")
+	if err != nil {
+		return
+	}
+
+	err = h.templateService.DeleteTemplate(id, getUserID(c), hasPermission(c, "record_templates:manage"), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, gin.H{"message": "删除成功"})
+}
+
+// handleError 将模板服务错误映射为HTTP响应
+func (h *RecordTemplateHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case strings.HasPrefix(err.Error(), "模板不存在"), err.Error() == "记录类型不存在", err.Error() == "角色不存在":
+		handleNotFoundError(c, err.Error())
+	case strings.Contains(err.Error(), "失败"):
+		middleware.InternalErrorResponse(c, err)
+	default:
+		middleware.ValidationErrorResponse(c, err.Error(), "")
+	}
+}
@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"info-management-system/internal/middleware"
+	"info-management-system/internal/models"
 	"info-management-system/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -14,12 +16,14 @@ import (
 // RecordTypeHandler 记录类型处理器
 type RecordTypeHandler struct {
 	recordTypeService *services.RecordTypeService
+	migrationService  *services.RecordMigrationService
 }
 
 // NewRecordTypeHandler 创建记录类型处理器
-func NewRecordTypeHandler(recordTypeService *services.RecordTypeService) *RecordTypeHandler {
+func NewRecordTypeHandler(recordTypeService *services.RecordTypeService, migrationService *services.RecordMigrationService) *RecordTypeHandler {
 	return &RecordTypeHandler{
 		recordTypeService: recordTypeService,
+		migrationService:  migrationService,
 	}
 }
 
@@ -83,6 +87,10 @@ func (h *RecordTypeHandler) CreateRecordType(c *gin.Context) {
 			})
 			return
 		}
+		if strings.HasPrefix(err.Error(), "唯一键字段") {
+			middleware.ValidationErrorResponse(c, err.Error(), "")
+			return
+		}
 
 		middleware.InternalErrorResponse(c, err)
 		return
@@ -122,6 +130,12 @@ func (h *RecordTypeHandler) UpdateRecordType(c *gin.Context) {
 			return
 		}
 
+		// 流程定义等校验错误，数据库错误均以“失败”描述
+		if !strings.Contains(err.Error(), "失败") {
+			middleware.ValidationErrorResponse(c, err.Error(), "")
+			return
+		}
+
 		middleware.InternalErrorResponse(c, err)
 		return
 	}
@@ -219,3 +233,150 @@ func (h *RecordTypeHandler) BatchDeleteRecordTypes(c *gin.Context) {
 
 	middleware.Success(c, gin.H{"message": "批量删除成功"})
 }
+
+// CheckSchemaCompatibility 检查现有记录与新Schema的兼容性
+func (h *RecordTypeHandler) CheckSchemaCompatibility(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	var req services.CheckSchemaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	report, err := h.recordTypeService.CheckSchemaCompatibility(id, req.Schema)
+	if err != nil {
+		h.handleSchemaError(c, err)
+		return
+	}
+
+	middleware.Success(c, report)
+}
+
+// GetSchemaVersions 获取Schema历史版本
+func (h *RecordTypeHandler) GetSchemaVersions(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	versions, err := h.recordTypeService.GetSchemaVersions(id)
+	if err != nil {
+		h.handleSchemaError(c, err)
+		return
+	}
+
+	middleware.Success(c, versions)
+}
+
+// CreateMigrationJob 创建记录数据迁移任务
+func (h *RecordTypeHandler) CreateMigrationJob(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	var req services.CreateRecordMigrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	job, err := h.migrationService.CreateJob(id, &req, getUserID(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.handleSchemaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    job,
+	})
+}
+
+// ListMigrationJobs 获取记录类型的迁移任务列表
+func (h *RecordTypeHandler) ListMigrationJobs(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	jobs, err := h.migrationService.ListJobs(id)
+	if err != nil {
+		middleware.InternalErrorResponse(c, err)
+		return
+	}
+
+	middleware.Success(c, jobs)
+}
+
+// GetMigrationJob 获取迁移任务进度
+func (h *RecordTypeHandler) GetMigrationJob(c *gin.Context) {
+	job, ok := h.findMigrationJob(c)
+	if !ok {
+		return
+	}
+
+	middleware.Success(c, job)
+}
+
+// GetMigrationJobErrors 获取迁移任务的记录错误
+func (h *RecordTypeHandler) GetMigrationJobErrors(c *gin.Context) {
+	job, ok := h.findMigrationJob(c)
+	if !ok {
+		return
+	}
+
+	var query services.RecordMigrationErrorQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	result, err := h.migrationService.GetJobErrors(job.ID, &query)
+	if err != nil {
+		h.handleSchemaError(c, err)
+		return
+	}
+
+	middleware.Success(c, result)
+}
+
+// findMigrationJob 按路径参数查找属于该记录类型的迁移任务
+func (h *RecordTypeHandler) findMigrationJob(c *gin.Context) (*models.RecordMigrationJob, bool) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return nil, false
+	}
+	jobID, err := parseUintParam(c, "job_id")
+	if err != nil {
+		return nil, false
+	}
+
+	job, err := h.migrationService.GetJob(jobID)
+	if err == nil && job.RecordTypeID != id {
+		err = fmt.Errorf("迁移任务不存在")
+	}
+	if err != nil {
+		h.handleSchemaError(c, err)
+		return nil, false
+	}
+	return job, true
+}
+
+// handleSchemaError 将Schema与迁移相关错误映射为响应
+func (h *RecordTypeHandler) handleSchemaError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "记录类型不存在" || err.Error() == "迁移任务不存在":
+		handleNotFoundError(c, err.Error())
+	case err.Error() == "该记录类型已有正在执行的迁移任务":
+		handleConflictError(c, err.Error())
+	case strings.HasPrefix(err.Error(), "第 "):
+		middleware.ValidationErrorResponse(c, err.Error(), "")
+	default:
+		middleware.InternalErrorResponse(c, err)
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"strings"
+
+	"info-management-system/internal/middleware"
+	"info-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecycleBinHandler 回收站处理器
+type RecycleBinHandler struct {
+	recycleBinService *services.RecycleBinService
+}
+
+// NewRecycleBinHandler 创建回收站处理器
+func NewRecycleBinHandler(recycleBinService *services.RecycleBinService) *RecycleBinHandler {
+	return &RecycleBinHandler{
+		recycleBinService: recycleBinService,
+	}
+}
+
+// GetRecycleBin 获取回收站列表（管理员可查看全部）
+func (h *RecycleBinHandler) GetRecycleBin(c *gin.Context) {
+	var query services.RecycleBinQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	result, err := h.recycleBinService.ListDeleted(&query, getUserID(c), hasPermission(c, "recycle_bin:manage"))
+	if err != nil {
+		middleware.InternalErrorResponse(c, err)
+		return
+	}
+
+	middleware.Success(c, result)
+}
+
+// RestoreItem 恢复回收站中的数据
+func (h *RecycleBinHandler) RestoreItem(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	err = h.recycleBinService.Restore(c.Param("type"), id, getUserID(c), hasPermission(c, "recycle_bin:manage"),
+		c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, gin.H{"message": "恢复成功"})
+}
+
+// PurgeItem 彻底删除回收站中的数据
+func (h *RecycleBinHandler) PurgeItem(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	err = h.recycleBinService.Purge(c.Param("type"), id, getUserID(c), hasPermission(c, "recycle_bin:manage"),
+		c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, gin.H{"message": "彻底删除成功"})
+}
+
+// PurgeExpired 立即清理超过保留期的数据
+func (h *RecycleBinHandler) PurgeExpired(c *gin.Context) {
+	count, err := h.recycleBinService.PurgeExpired()
+	if err != nil {
+		middleware.InternalErrorResponse(c, err)
+		return
+	}
+
+	middleware.Success(c, gin.H{"purged": count})
+}
+
+// handleError 将回收站错误映射为响应
+func (h *RecycleBinHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "回收站中不存在该数据":
+		handleNotFoundError(c, err.Error())
+	case strings.HasSuffix(err.Error(), "无法恢复"):
+		handleConflictError(c, err.Error())
+	case strings.HasPrefix(err.Error(), "无效的"):
+		middleware.ValidationErrorResponse(c, err.Error(), "")
+	default:
+		middleware.InternalErrorResponse(c, err)
+	}
+}
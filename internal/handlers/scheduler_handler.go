@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"info-management-system/internal/middleware"
+	"info-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SchedulerHandler 后台任务管理处理器
+type SchedulerHandler struct {
+	schedulerService *services.SchedulerService
+}
+
+// NewSchedulerHandler 创建后台任务管理处理器
+func NewSchedulerHandler(schedulerService *services.SchedulerService) *SchedulerHandler {
+	return &SchedulerHandler{
+		schedulerService: schedulerService,
+	}
+}
+
+// ListJobs 获取后台任务列表及最近一次运行结果
+func (h *SchedulerHandler) ListJobs(c *gin.Context) {
+	jobs, err := h.schedulerService.ListJobs()
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, jobs)
+}
+
+// GetJob 获取后台任务详情
+func (h *SchedulerHandler) GetJob(c *gin.Context) {
+	job, err := h.schedulerService.GetJob(c.Param("name"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, job)
+}
+
+// ListRuns 获取后台任务的运行日志
+func (h *SchedulerHandler) ListRuns(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	runs, total, err := h.schedulerService.ListRuns(c.Param("name"), page, pageSize)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, gin.H{
+		"items":     runs,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// PauseJob 暂停后台任务
+func (h *SchedulerHandler) PauseJob(c *gin.Context) {
+	h.setPaused(c, true)
+}
+
+// ResumeJob 恢复后台任务
+func (h *SchedulerHandler) ResumeJob(c *gin.Context) {
+	h.setPaused(c, false)
+}
+
+// RunJob 立即运行后台任务并返回运行日志
+func (h *SchedulerHandler) RunJob(c *gin.Context) {
+	run, err := h.schedulerService.RunNow(c.Param("name"), getUserID(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, run)
+}
+
+// setPaused 暂停或恢复后台任务
+func (h *SchedulerHandler) setPaused(c *gin.Context, paused bool) {
+	job, err := h.schedulerService.SetPaused(c.Param("name"), paused, getUserID(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, job)
+}
+
+// handleError 将后台任务错误映射为响应
+func (h *SchedulerHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "任务不存在":
+		handleNotFoundError(c, err.Error())
+	case strings.HasPrefix(err.Error(), "任务正在运行中"):
+		handleConflictError(c, err.Error())
+	default:
+		middleware.InternalErrorResponse(c, err)
+	}
+}
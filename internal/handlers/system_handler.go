@@ -277,22 +277,29 @@ func (h *SystemHandler) GetSystemHealth(c *gin.Context) {
 func (h *SystemHandler) GetSystemLogs(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
-	level := c.Query("level")
-	category := c.Query("category")
+	query := &services.SystemLogQuery{
+		Page:       page,
+		PageSize:   pageSize,
+		Level:      c.Query("level"),
+		Category:   c.Query("category"),
+		SortBy:     c.DefaultQuery("sort_by", "created_at"),
+		SortOrder:  c.DefaultQuery("sort_order", "desc"),
+		Pagination: c.Query("pagination"),
+		Cursor:     c.Query("cursor"),
+	}
 
-	var startTime, endTime *time.Time
 	if startTimeStr := c.Query("start_time"); startTimeStr != "" {
 		if t, err := time.Parse(time.RFC3339, startTimeStr); err == nil {
-			startTime = &t
+			query.StartTime = &t
 		}
 	}
 	if endTimeStr := c.Query("end_time"); endTimeStr != "" {
 		if t, err := time.Parse(time.RFC3339, endTimeStr); err == nil {
-			endTime = &t
+			query.EndTime = &t
 		}
 	}
 
-	response, err := h.systemService.GetSystemLogs(page, pageSize, level, category, startTime, endTime)
+	response, err := h.systemService.GetSystemLogs(query)
 	if err != nil {
 		middleware.ValidationErrorResponse(c, "获取系统日志失败", err.Error())
 		return
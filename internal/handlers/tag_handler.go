@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"info-management-system/internal/middleware"
+	"info-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TagHandler 标签处理器
+type TagHandler struct {
+	tagService *services.TagService
+}
+
+// NewTagHandler 创建标签处理器
+func NewTagHandler(tagService *services.TagService) *TagHandler {
+	return &TagHandler{
+		tagService: tagService,
+	}
+}
+
+// GetTags 获取标签列表（含使用次数）
+func (h *TagHandler) GetTags(c *gin.Context) {
+	var query services.TagListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	result, err := h.tagService.ListTags(&query)
+	if err != nil {
+		middleware.InternalErrorResponse(c, err)
+		return
+	}
+
+	middleware.Success(c, result)
+}
+
+// SuggestTags 标签自动补全
+func (h *TagHandler) SuggestTags(c *gin.Context) {
+	var query services.TagSuggestQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	tags, err := h.tagService.SuggestTags(&query)
+	if err != nil {
+		middleware.InternalErrorResponse(c, err)
+		return
+	}
+
+	middleware.Success(c, tags)
+}
+
+// GetTag 获取标签详情
+func (h *TagHandler) GetTag(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	tag, err := h.tagService.GetTag(id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, tag)
+}
+
+// CreateTag 创建标签
+func (h *TagHandler) CreateTag(c *gin.Context) {
+	var req services.CreateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	tag, err := h.tagService.CreateTag(&req, getUserID(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    tag,
+	})
+}
+
+// UpdateTag 更新或重命名标签
+func (h *TagHandler) UpdateTag(c *gin.Context) {
+	if !hasPermission(c, "tags:manage") {
+		handleForbiddenError(c, "无权管理标签")
+		return
+	}
+
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	var req services.UpdateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	tag, err := h.tagService.UpdateTag(id, &req, getUserID(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, tag)
+}
+
+// MergeTags 合并标签
+func (h *TagHandler) MergeTags(c *gin.Context) {
+	if !hasPermission(c, "tags:manage") {
+		handleForbiddenError(c, "无权管理标签")
+		return
+	}
+
+	var req services.MergeTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	tag, err := h.tagService.MergeTags(&req, getUserID(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, tag)
+}
+
+// DeleteTag 删除标签
+func (h *TagHandler) DeleteTag(c *gin.Context) {
+	if !hasPermission(c, "tags:manage") {
+		handleForbiddenError(c, "无权管理标签")
+		return
+	}
+
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	if err := h.tagService.DeleteTag(id, getUserID(c), c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, gin.H{"message": "删除成功"})
+}
+
+// handleError 将标签服务错误映射为HTTP响应
+func (h *TagHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "标签不存在":
+		handleNotFoundError(c, err.Error())
+	case err.Error() == "标签已存在" || strings.HasPrefix(err.Error(), "标签名称已存在"):
+		handleConflictError(c, err.Error())
+	case strings.Contains(err.Error(), "失败"):
+		middleware.InternalErrorResponse(c, err)
+	default:
+		middleware.ValidationErrorResponse(c, err.Error(), "")
+	}
+}
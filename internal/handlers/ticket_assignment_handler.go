@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"net/http"
+
+	"info-management-system/internal/middleware"
+	"info-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TicketAssignmentHandler 工单自动分配规则处理器
+type TicketAssignmentHandler struct {
+	assignmentService *services.TicketAssignmentService
+}
+
+// NewTicketAssignmentHandler 创建工单自动分配规则处理器
+func NewTicketAssignmentHandler(assignmentService *services.TicketAssignmentService) *TicketAssignmentHandler {
+	return &TicketAssignmentHandler{
+		assignmentService: assignmentService,
+	}
+}
+
+// ListRules 按匹配顺序获取分配规则列表
+func (h *TicketAssignmentHandler) ListRules(c *gin.Context) {
+	if !h.canManage(c) {
+		return
+	}
+
+	rules, err := h.assignmentService.ListRules()
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, rules)
+}
+
+// GetRule 获取分配规则详情
+func (h *TicketAssignmentHandler) GetRule(c *gin.Context) {
+	if !h.canManage(c) {
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	rule, err := h.assignmentService.GetRule(id)
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, rule)
+}
+
+// CreateRule 创建分配规则
+func (h *TicketAssignmentHandler) CreateRule(c *gin.Context) {
+	if !h.canManage(c) {
+		return
+	}
+
+	var req services.SaveAssignmentRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	rule, err := h.assignmentService.CreateRule(&req, getUserID(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    rule,
+	})
+}
+
+// UpdateRule 更新分配规则
+func (h *TicketAssignmentHandler) UpdateRule(c *gin.Context) {
+	if !h.canManage(c) {
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	var req services.SaveAssignmentRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	rule, err := h.assignmentService.UpdateRule(id, &req, getUserID(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, rule)
+}
+
+// DeleteRule 删除分配规则
+func (h *TicketAssignmentHandler) DeleteRule(c *gin.Context) {
+	if !h.canManage(c) {
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	if err := h.assignmentService.DeleteRule(id, getUserID(c), c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, gin.H{"message": "删除成功"})
+}
+
+// ReorderRules 调整分配规则的匹配顺序
+func (h *TicketAssignmentHandler) ReorderRules(c *gin.Context) {
+	if !h.canManage(c) {
+		return
+	}
+
+	var req services.ReorderAssignmentRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	rules, err := h.assignmentService.ReorderRules(&req, getUserID(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, rules)
+}
+
+// PreviewAssignment 预览工单会被分配给谁，不实际分配
+func (h *TicketAssignmentHandler) PreviewAssignment(c *gin.Context) {
+	if !h.canManage(c) {
+		return
+	}
+
+	var req services.AssignmentPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	result, err := h.assignmentService.Preview(&req)
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, result)
+}
+
+// GetMyProfile 获取当前用户的技能与可用状态
+func (h *TicketAssignmentHandler) GetMyProfile(c *gin.Context) {
+	profile, err := h.assignmentService.GetProfile(getUserID(c))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, profile)
+}
+
+// UpdateMyProfile 更新当前用户的技能与可用状态，如设置暂离
+func (h *TicketAssignmentHandler) UpdateMyProfile(c *gin.Context) {
+	var req services.AssigneeProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	userID := getUserID(c)
+	profile, err := h.assignmentService.SaveProfile(userID, &req, userID, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, profile)
+}
+
+// ListProfiles 获取处理人的技能与可用状态列表
+func (h *TicketAssignmentHandler) ListProfiles(c *gin.Context) {
+	if !h.canManage(c) {
+		return
+	}
+
+	profiles, err := h.assignmentService.ListProfiles()
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, profiles)
+}
+
+// UpdateProfile 更新指定用户的技能与可用状态
+func (h *TicketAssignmentHandler) UpdateProfile(c *gin.Context) {
+	if !h.canManage(c) {
+		return
+	}
+	userID, err := parseUintParam(c, "user_id")
+	if err != nil {
+		return
+	}
+
+	var req services.AssigneeProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	profile, err := h.assignmentService.SaveProfile(userID, &req, getUserID(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, profile)
+}
+
+// canManage 检查分配规则管理权限，无权限时写入响应
+func (h *TicketAssignmentHandler) canManage(c *gin.Context) bool {
+	if !hasPermission(c, "ticket:assignment") {
+		handleForbiddenError(c, "无权限管理工单分配规则")
+		return false
+	}
+	return true
+}
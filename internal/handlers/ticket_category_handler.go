@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"net/http"
+
+	"info-management-system/internal/middleware"
+	"info-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TicketCategoryHandler 工单分类与自定义字段处理器
+type TicketCategoryHandler struct {
+	categoryService *services.TicketCategoryService
+}
+
+// NewTicketCategoryHandler 创建工单分类处理器
+func NewTicketCategoryHandler(categoryService *services.TicketCategoryService) *TicketCategoryHandler {
+	return &TicketCategoryHandler{
+		categoryService: categoryService,
+	}
+}
+
+// GetFieldDefinitions 获取工单类型与分类适用的自定义字段定义
+func (h *TicketCategoryHandler) GetFieldDefinitions(c *gin.Context) {
+	fields, err := h.categoryService.FieldDefinitions(c.Query("type"), c.Query("category"))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, fields)
+}
+
+// ListCategories 获取工单分类列表（含停用分类）
+func (h *TicketCategoryHandler) ListCategories(c *gin.Context) {
+	if !hasPermission(c, "ticket:category:manage") {
+		handleForbiddenError(c, "无权限管理工单分类")
+		return
+	}
+
+	categories, err := h.categoryService.ListCategories()
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, categories)
+}
+
+// GetCategory 获取工单分类详情
+func (h *TicketCategoryHandler) GetCategory(c *gin.Context) {
+	if !hasPermission(c, "ticket:category:manage") {
+		handleForbiddenError(c, "无权限管理工单分类")
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	category, err := h.categoryService.GetCategory(id)
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, category)
+}
+
+// CreateCategory 创建工单分类
+func (h *TicketCategoryHandler) CreateCategory(c *gin.Context) {
+	if !hasPermission(c, "ticket:category:manage") {
+		handleForbiddenError(c, "无权限管理工单分类")
+		return
+	}
+
+	var req services.SaveTicketCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	category, err := h.categoryService.CreateCategory(&req, getUserID(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    category,
+	})
+}
+
+// UpdateCategory 更新工单分类
+func (h *TicketCategoryHandler) UpdateCategory(c *gin.Context) {
+	if !hasPermission(c, "ticket:category:manage") {
+		handleForbiddenError(c, "无权限管理工单分类")
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	var req services.SaveTicketCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	category, err := h.categoryService.UpdateCategory(id, &req, getUserID(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, category)
+}
+
+// DeleteCategory 删除工单分类
+func (h *TicketCategoryHandler) DeleteCategory(c *gin.Context) {
+	if !hasPermission(c, "ticket:category:manage") {
+		handleForbiddenError(c, "无权限管理工单分类")
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	if err := h.categoryService.DeleteCategory(id, getUserID(c), c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, gin.H{"message": "删除成功"})
+}
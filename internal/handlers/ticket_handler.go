@@ -6,6 +6,7 @@ import (
 	"mime/multipart"
 	"net/http"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
@@ -20,15 +21,36 @@ import (
 type TicketHandler struct {
 	db                *gorm.DB
 	notificationService *services.NotificationService
+	linkService         *services.LinkService
+	watchService        *services.WatchService
+	workflowService     *services.TicketWorkflowService
+	slaService          *services.TicketSLAService
+	assignmentService   *services.TicketAssignmentService
+	surveyService       *services.TicketSurveyService
 }
 
-func NewTicketHandler(db *gorm.DB, notificationService *services.NotificationService) *TicketHandler {
+func NewTicketHandler(db *gorm.DB, notificationService *services.NotificationService, linkService *services.LinkService, workflowService *services.TicketWorkflowService, slaService *services.TicketSLAService, assignmentService *services.TicketAssignmentService, surveyService *services.TicketSurveyService) *TicketHandler {
 	return &TicketHandler{
 		db:                db,
 		notificationService: notificationService,
+		linkService:         linkService,
+		watchService:        services.NewWatchService(db, notificationService),
+		workflowService:     workflowService,
+		slaService:          slaService,
+		assignmentService:   assignmentService,
+		surveyService:       surveyService,
 	}
 }
 
+// ticketDetailResponse 工单详情响应（附带关联信息）
+type ticketDetailResponse struct {
+	models.Ticket
+	Links   []services.LinkResponse     `json:"links"`
+	SLA     *services.TicketSLAResponse `json:"sla"`
+	Surveys []models.TicketSurvey       `json:"surveys"`
+	TimeSpent services.TicketWorklogTotal `json:"time_spent"`
+}
+
 // GetTickets 获取工单列表
 func (h *TicketHandler) GetTickets(c *gin.Context) {
 	var query struct {
@@ -40,6 +62,13 @@ func (h *TicketHandler) GetTickets(c *gin.Context) {
 		Keyword    string `form:"keyword"`
 		CreatorID  uint   `form:"creator_id"`
 		AssigneeID uint   `form:"assignee_id"`
+		Tags       string `form:"tags"`
+		SLAState   string `form:"sla_state"` // running、warning、breached、met
+		Category   string `form:"category"`
+		SortBy     string `form:"sort_by,default=created_at"` // cf.<字段名> 表示按自定义字段排序
+		SortOrder  string `form:"sort_order,default=desc"`
+		Pagination string `form:"pagination"` // cursor 表示使用游标分页
+		Cursor     string `form:"cursor"`
 	}
 
 	if err := c.ShouldBindQuery(&query); err != nil {
@@ -47,6 +76,19 @@ func (h *TicketHandler) GetTickets(c *gin.Context) {
 		return
 	}
 
+	customSort, sortByCustomField := strings.CutPrefix(query.SortBy, "cf.")
+	sortBy, sortField, err := services.ResolveSortField(ticketSortFields, query.SortBy, "created_at")
+	if err != nil && !sortByCustomField {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	desc := query.SortOrder != "asc"
+	// 自定义字段过滤：cf[字段名]=值
+	customFilters := c.QueryMap("cf")
+	if query.Size <= 0 {
+		query.Size = 20
+	}
+
 	// 获取当前用户
 	userID := getUserID(c)
 	if userID == 0 {
@@ -77,6 +119,11 @@ func (h *TicketHandler) GetTickets(c *gin.Context) {
 		db = db.Where("priority = ?", query.Priority)
 	}
 
+	// 分类过滤
+	if query.Category != "" {
+		db = db.Where("category = ?", query.Category)
+	}
+
 	// 关键词搜索
 	if query.Keyword != "" {
 		keyword := "%" + strings.ToLower(query.Keyword) + "%"
@@ -93,6 +140,58 @@ func (h *TicketHandler) GetTickets(c *gin.Context) {
 		db = db.Where("assignee_id = ?", query.AssigneeID)
 	}
 
+	// 标签过滤（精确匹配）
+	if query.Tags != "" {
+		db = services.FilterByTags(db, models.TagEntityTicket, "tickets.id", query.Tags)
+	}
+
+	// SLA状态过滤
+	if query.SLAState != "" {
+		db = services.FilterBySLAState(db, "tickets.id", query.SLAState)
+	}
+
+	// 自定义字段过滤，支持 a,b 匹配任一值与 min..max 范围
+	if len(customFilters) > 0 {
+		if db, err = services.FilterByCustomFields(db, "tickets.id", customFilters); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// 游标分页：按 排序列+ID 定位，不计算总数
+	if services.UsesCursorPagination(query.Pagination, query.Cursor) {
+		if sortByCustomField {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "按自定义字段排序不支持游标分页"})
+			return
+		}
+		pager, err := services.NewKeysetPager(sortBy, sortField, "tickets.id", desc, query.Cursor, query.Size)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var tickets []models.Ticket
+		if err := pager.Apply(db.Preload("Creator").Preload("Assignee")).Find(&tickets).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "查询工单失败"})
+			return
+		}
+		tickets, nextCursor, prevCursor := services.PaginateKeyset(pager, tickets, func(ticket *models.Ticket) (interface{}, uint) {
+			return ticketSortValue(ticket, sortBy), ticket.ID
+		})
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data": gin.H{
+				"items":       tickets,
+				"size":        query.Size,
+				"next_cursor": nextCursor,
+				"prev_cursor": prevCursor,
+				"stats":       h.getTicketStats(userID),
+			},
+		})
+		return
+	}
+
 	// 获取总数 - 优化：只在第一页或需要精确计数时执行
 	var total int64
 	if query.Page == 1 {
@@ -114,6 +213,9 @@ func (h *TicketHandler) GetTickets(c *gin.Context) {
 		if query.Priority != "" {
 			countDB = countDB.Where("priority = ?", query.Priority)
 		}
+		if query.Category != "" {
+			countDB = countDB.Where("category = ?", query.Category)
+		}
 		if query.Keyword != "" {
 			keyword := "%" + strings.ToLower(query.Keyword) + "%"
 			countDB = countDB.Where("LOWER(title) LIKE ? OR LOWER(description) LIKE ?", keyword, keyword)
@@ -124,6 +226,15 @@ func (h *TicketHandler) GetTickets(c *gin.Context) {
 		if query.AssigneeID > 0 {
 			countDB = countDB.Where("assignee_id = ?", query.AssigneeID)
 		}
+		if query.Tags != "" {
+			countDB = services.FilterByTags(countDB, models.TagEntityTicket, "tickets.id", query.Tags)
+		}
+		if query.SLAState != "" {
+			countDB = services.FilterBySLAState(countDB, "tickets.id", query.SLAState)
+		}
+		if len(customFilters) > 0 {
+			countDB, _ = services.FilterByCustomFields(countDB, "tickets.id", customFilters)
+		}
 		
 		countDB.Count(&total)
 	} else {
@@ -134,10 +245,16 @@ func (h *TicketHandler) GetTickets(c *gin.Context) {
 	// 分页查询
 	var tickets []models.Ticket
 	offset := (query.Page - 1) * query.Size
-	err := db.Preload("Creator").Preload("Assignee").
-		Order("created_at DESC").
-		Offset(offset).Limit(query.Size).
-		Find(&tickets).Error
+	listDB := db.Preload("Creator").Preload("Assignee")
+	if sortByCustomField {
+		if listDB, err = services.OrderByCustomField(listDB, "tickets.id", customSort, desc); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	} else {
+		listDB = services.OrderWithTieBreaker(listDB, sortField, "tickets.id", desc)
+	}
+	err = listDB.Offset(offset).Limit(query.Size).Find(&tickets).Error
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询工单失败"})
@@ -159,6 +276,37 @@ func (h *TicketHandler) GetTickets(c *gin.Context) {
 	})
 }
 
+// ticketSortFields 工单列表允许的排序字段
+var ticketSortFields = map[string]services.CursorField{
+	"id":         {Column: "tickets.id", Kind: services.CursorKindInt},
+	"created_at": {Column: "tickets.created_at", Kind: services.CursorKindTime},
+	"updated_at": {Column: "tickets.updated_at", Kind: services.CursorKindTime},
+	"title":      {Column: "tickets.title", Kind: services.CursorKindString},
+	"status":     {Column: "tickets.status", Kind: services.CursorKindString},
+	"priority":   {Column: "tickets.priority", Kind: services.CursorKindString},
+	"type":       {Column: "tickets.type", Kind: services.CursorKindString},
+}
+
+// ticketSortValue 获取工单在排序字段上的值
+func ticketSortValue(ticket *models.Ticket, sortBy string) interface{} {
+	switch sortBy {
+	case "id":
+		return ticket.ID
+	case "updated_at":
+		return ticket.UpdatedAt
+	case "title":
+		return ticket.Title
+	case "status":
+		return string(ticket.Status)
+	case "priority":
+		return string(ticket.Priority)
+	case "type":
+		return string(ticket.Type)
+	default:
+		return ticket.CreatedAt
+	}
+}
+
 // GetTicket 获取工单详情
 func (h *TicketHandler) GetTicket(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -198,19 +346,42 @@ func (h *TicketHandler) GetTicket(c *gin.Context) {
 		return
 	}
 
+	// 附加关联信息
+	detail := ticketDetailResponse{Ticket: ticket, Links: []services.LinkResponse{}, Surveys: []models.TicketSurvey{}}
+	if h.linkService != nil {
+		if links, err := h.linkService.GetEntityLinks(models.LinkEntityTicket, ticket.ID, "", linkAccessScope(c)); err == nil {
+			detail.Links = links
+		}
+	}
+	if sla, err := h.slaService.TicketSLA(ticket.ID); err == nil {
+		detail.SLA = sla
+	}
+	if surveys, err := h.surveyService.TicketSurveys(ticket.ID, ticketActor(c)); err == nil {
+		detail.Surveys = surveys
+	}
+	if totals, err := services.TicketWorklogTotals(h.db, []uint{ticket.ID}); err == nil {
+		detail.TimeSpent = totals[ticket.ID]
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    ticket,
+		"data":    detail,
 	})
 }
 
 // CreateTicket 创建工单
 func (h *TicketHandler) CreateTicket(c *gin.Context) {
+	// 指定模板时标题、描述、类型与优先级可以省略，由模板填充
 	var req struct {
-		Title       string `json:"title" binding:"required,max=500"`
-		Description string `json:"description" binding:"required"`
-		Type        string `json:"type" binding:"required,oneof=bug feature support change custom"`
-		Priority    string `json:"priority" binding:"required,oneof=low normal high critical"`
+		TemplateID  *uint    `json:"template_id"`
+		Title       string   `json:"title" binding:"max=500"`
+		Description string   `json:"description"`
+		Type        string   `json:"type" binding:"omitempty,oneof=bug feature support change custom"`
+		Priority    string   `json:"priority" binding:"omitempty,oneof=low normal high critical"`
+		Category    string   `json:"category" binding:"max=100"`
+		Tags        []string `json:"tags"`
+		CustomFields map[string]interface{} `json:"custom_fields"`
+		OriginalEstimate *int `json:"original_estimate" binding:"omitempty,min=0"` // 原始估时（分钟），剩余估时初始与之相同
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -218,6 +389,57 @@ func (h *TicketHandler) CreateTicket(c *gin.Context) {
 		return
 	}
 
+	var metadata models.JSONB
+	if req.TemplateID != nil {
+		values := services.TicketTemplateValues{
+			Title:        req.Title,
+			Description:  req.Description,
+			Type:         req.Type,
+			Priority:     req.Priority,
+			Category:     req.Category,
+			CustomFields: req.CustomFields,
+			Tags:         req.Tags,
+		}
+		if err := services.ApplyTicketTemplate(h.db, *req.TemplateID, getUserID(c), &values); err != nil {
+			c.JSON(ticketWorkflowErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		req.Title, req.Description, req.Type, req.Priority = values.Title, values.Description, values.Type, values.Priority
+		req.Category, req.CustomFields, req.Tags = values.Category, values.CustomFields, values.Tags
+		metadata = models.JSONB{"template_id": *req.TemplateID}
+		if len(values.Checklist) > 0 {
+			checklist := make([]map[string]interface{}, len(values.Checklist))
+			for i, item := range values.Checklist {
+				checklist[i] = map[string]interface{}{"item": item, "done": false}
+			}
+			metadata["checklist"] = checklist
+		}
+	}
+	switch {
+	case strings.TrimSpace(req.Title) == "":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "标题不能为空"})
+		return
+	case strings.TrimSpace(req.Description) == "":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "描述不能为空"})
+		return
+	case req.Type == "":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "工单类型不能为空"})
+		return
+	case req.Priority == "":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "优先级不能为空"})
+		return
+	}
+	if err := services.ValidateTagNames(req.Tags); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	category := strings.TrimSpace(req.Category)
+	customFields, err := services.PrepareTicketCustomFields(h.db, req.Type, category, nil, req.CustomFields)
+	if err != nil {
+		c.JSON(ticketWorkflowErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
 	userID := getUserID(c)
 	if userID == 0 {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
@@ -229,15 +451,38 @@ func (h *TicketHandler) CreateTicket(c *gin.Context) {
 		Description: req.Description,
 		Type:        models.TicketType(req.Type),
 		Priority:    models.TicketPriority(req.Priority),
-		Status:      models.TicketStatusSubmitted, // 新工单默认为"已提交"状态
+		Status:      h.workflowService.InitialStatus(req.Type, category), // 新工单使用流程的初始状态
 		CreatorID:   userID,
+		Category:    category,
+		CustomFields: customFields,
+		Metadata:    metadata,
+		OriginalEstimate: req.OriginalEstimate,
+		RemainingEstimate: req.OriginalEstimate,
 	}
 
-	if err := h.db.Create(&ticket).Error; err != nil {
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&ticket).Error; err != nil {
+			return err
+		}
+		if err := services.SyncTicketFieldValues(tx, ticket.ID, ticket.CustomFields); err != nil {
+			return err
+		}
+		tags, err := services.SyncEntityTags(tx, models.TagEntityTicket, ticket.ID, req.Tags, userID)
+		if err != nil {
+			return err
+		}
+		ticket.Tags = tags
+		// 创建者自动关注工单
+		return services.AddWatchers(tx, models.WatchEntityTicket, ticket.ID, models.WatchReasonCreator, userID)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建工单失败"})
 		return
 	}
 
+	// 匹配SLA策略开始计时，需在自动分配前完成
+	h.applySLA(&ticket)
+
 	// 自动分配逻辑 - 根据工单类型分配给固定角色
 	go h.autoAssignTicket(&ticket)
 
@@ -245,7 +490,7 @@ func (h *TicketHandler) CreateTicket(c *gin.Context) {
 	h.addTicketHistory(ticket.ID, userID, "created", "工单已创建")
 
 	// 发送通知
-	go h.sendTicketNotification(&ticket, "created", "")
+	h.sendTicketNotification(&ticket, userID, "created", "")
 
 	// 重新加载完整数据
 	h.db.Preload("Creator").Preload("Assignee").First(&ticket, ticket.ID)
@@ -265,17 +510,28 @@ func (h *TicketHandler) UpdateTicket(c *gin.Context) {
 	}
 
 	var req struct {
-		Title       *string `json:"title,omitempty"`
-		Description *string `json:"description,omitempty"`
-		Type        *string `json:"type,omitempty"`
-		Priority    *string `json:"priority,omitempty"`
-		Status      *string `json:"status,omitempty"`
+		Title       *string   `json:"title,omitempty"`
+		Description *string   `json:"description,omitempty"`
+		Type        *string   `json:"type,omitempty"`
+		Priority    *string   `json:"priority,omitempty"`
+		Status      *string   `json:"status,omitempty"`
+		Category    *string   `json:"category,omitempty"`
+		Tags        *[]string `json:"tags,omitempty"`
+		CustomFields map[string]interface{} `json:"custom_fields,omitempty"` // 只包含要修改的字段，值为 null 表示清空
+		OriginalEstimate  *int `json:"original_estimate,omitempty" binding:"omitempty,min=0"`  // 分钟
+		RemainingEstimate *int `json:"remaining_estimate,omitempty" binding:"omitempty,min=0"` // 分钟
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if req.Tags != nil {
+		if err := services.ValidateTagNames(*req.Tags); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
 
 	userID := getUserID(c)
 	if userID == 0 {
@@ -303,8 +559,19 @@ func (h *TicketHandler) UpdateTicket(c *gin.Context) {
 		return
 	}
 
+	// 状态变更统一经由工单流程引擎，其余字段随后更新
+	if req.Status != nil && *req.Status != string(ticket.Status) {
+		transitionReq := &services.TicketTransitionRequest{ToStatus: *req.Status}
+		if _, err := h.workflowService.Transition(ticket.ID, transitionReq, ticketActor(c), c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+			c.JSON(ticketWorkflowErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		h.db.First(&ticket, ticket.ID)
+	}
+
 	// 记录变更
 	changes := []string{}
+	oldType, oldPriority, oldCategory := ticket.Type, ticket.Priority, ticket.Category
 
 	// 更新字段
 	if req.Title != nil && *req.Title != ticket.Title {
@@ -327,9 +594,47 @@ func (h *TicketHandler) UpdateTicket(c *gin.Context) {
 		ticket.Priority = models.TicketPriority(*req.Priority)
 	}
 
-	if req.Status != nil && *req.Status != string(ticket.Status) {
-		changes = append(changes, "状态: "+string(ticket.Status)+" -> "+*req.Status)
-		ticket.Status = models.TicketStatus(*req.Status)
+	if req.Category != nil && strings.TrimSpace(*req.Category) != ticket.Category {
+		category := strings.TrimSpace(*req.Category)
+		if len(category) > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "分类不能超过100个字符"})
+			return
+		}
+		changes = append(changes, "分类: "+ticket.Category+" -> "+category)
+		ticket.Category = category
+	}
+
+	// 类型或分类变更后按新的字段定义重新校验，不再适用的字段值被丢弃
+	fieldsChanged := false
+	if req.CustomFields != nil || ticket.Type != oldType || ticket.Category != oldCategory {
+		customFields, err := services.PrepareTicketCustomFields(h.db, string(ticket.Type), ticket.Category, ticket.CustomFields, req.CustomFields)
+		if err != nil {
+			c.JSON(ticketWorkflowErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		if !reflect.DeepEqual(customFields, ticket.CustomFields) {
+			changes = append(changes, "自定义字段已更新")
+			ticket.CustomFields = customFields
+			fieldsChanged = true
+		}
+	}
+
+	// 首次设置原始估时且未指定剩余估时时，剩余估时与原始估时相同
+	if req.OriginalEstimate != nil && !sameEstimate(ticket.OriginalEstimate, req.OriginalEstimate) {
+		changes = append(changes, "原始估时: "+formatEstimate(ticket.OriginalEstimate)+" -> "+formatEstimate(req.OriginalEstimate))
+		if ticket.OriginalEstimate == nil && ticket.RemainingEstimate == nil && req.RemainingEstimate == nil {
+			req.RemainingEstimate = req.OriginalEstimate
+		}
+		ticket.OriginalEstimate = req.OriginalEstimate
+	}
+	if req.RemainingEstimate != nil && !sameEstimate(ticket.RemainingEstimate, req.RemainingEstimate) {
+		changes = append(changes, "剩余估时: "+formatEstimate(ticket.RemainingEstimate)+" -> "+formatEstimate(req.RemainingEstimate))
+		ticket.RemainingEstimate = req.RemainingEstimate
+	}
+
+	oldTags := strings.Join(ticket.Tags, ", ")
+	if req.Tags != nil && !sameTags(ticket.Tags, *req.Tags) {
+		changes = append(changes, "标签: "+oldTags+" -> "+strings.Join(*req.Tags, ", "))
 	}
 
 	if len(changes) == 0 {
@@ -340,18 +645,40 @@ func (h *TicketHandler) UpdateTicket(c *gin.Context) {
 		return
 	}
 
+	// 类型、优先级或分类变更后需重新匹配SLA策略
+	slaChanged := ticket.Type != oldType || ticket.Priority != oldPriority || ticket.Category != oldCategory
+
 	// 保存更新
-	if err := h.db.Save(&ticket).Error; err != nil {
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("tags").Save(&ticket).Error; err != nil {
+			return err
+		}
+		if fieldsChanged {
+			if err := services.SyncTicketFieldValues(tx, ticket.ID, ticket.CustomFields); err != nil {
+				return err
+			}
+		}
+		if req.Tags == nil {
+			return nil
+		}
+		_, err := services.SyncEntityTags(tx, models.TagEntityTicket, ticket.ID, *req.Tags, userID)
+		return err
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新工单失败"})
 		return
 	}
 
+	if slaChanged {
+		h.applySLA(&ticket)
+	}
+
 	// 记录历史
 	changeDesc := strings.Join(changes, "; ")
 	h.addTicketHistory(ticket.ID, userID, "updated", changeDesc)
 
 	// 发送通知
-	go h.sendTicketNotification(&ticket, "updated", changeDesc)
+	h.sendTicketNotification(&ticket, userID, "updated", changeDesc)
 
 	// 重新加载完整数据
 	h.db.Preload("Creator").Preload("Assignee").First(&ticket, ticket.ID)
@@ -362,6 +689,55 @@ func (h *TicketHandler) UpdateTicket(c *gin.Context) {
 	})
 }
 
+// sameTags 按规范化名称比较两组标签是否相同
+func sameTags(current []string, next []string) bool {
+	normalize := func(tags []string) []string {
+		result := []string{}
+		seen := map[string]bool{}
+		for _, tag := range tags {
+			if n := services.NormalizeTagName(tag); n != "" && !seen[n] {
+				seen[n] = true
+				result = append(result, n)
+			}
+		}
+		return result
+	}
+	a, b := normalize(current), normalize(next)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// sameEstimate 比较两个估时（分钟），未设置视为不同于任何数值
+func sameEstimate(current, next *int) bool {
+	if current == nil || next == nil {
+		return current == next
+	}
+	return *current == *next
+}
+
+// formatEstimate 估时的历史记录文本
+func formatEstimate(minutes *int) string {
+	if minutes == nil {
+		return "未设置"
+	}
+	return services.FormatWorkMinutes(*minutes)
+}
+
+// formatEstimateHours 估时的导出文本，未设置时为空
+func formatEstimateHours(minutes *int) string {
+	if minutes == nil {
+		return ""
+	}
+	return formatWorkHours(int64(*minutes))
+}
+
 // DeleteTicket 删除工单
 func (h *TicketHandler) DeleteTicket(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -407,6 +783,12 @@ func (h *TicketHandler) DeleteTicket(c *gin.Context) {
 		return
 	}
 
+	// 关联保留到从回收站彻底删除时再清理，以便恢复
+	// 子工单不再挂在已删除的工单下
+	if err := h.db.Model(&models.Ticket{}).Where("parent_id = ?", ticket.ID).Update("parent_id", nil).Error; err != nil {
+		fmt.Printf("Warning: failed to detach children of ticket %d: %v\n", ticket.ID, err)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "工单删除成功",
@@ -444,81 +826,13 @@ func (h *TicketHandler) AssignTicket(c *gin.Context) {
 		return
 	}
 
-	// 查找工单
-	var ticket models.Ticket
-	err = h.db.First(&ticket, id).Error
+	// 分配及状态变更经由工单流程引擎
+	ticket, err := h.workflowService.Assign(uint(id), req.AssigneeID, req.AutoAccept, req.Comment, ticketActor(c), c.ClientIP(), c.GetHeader("User-Agent"))
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "工单不存在"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "查询工单失败"})
-		}
+		c.JSON(ticketWorkflowErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	// 验证处理人存在
-	var assignee models.User
-	if err := h.db.First(&assignee, req.AssigneeID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "指定的处理人不存在"})
-		return
-	}
-
-	// 更新工单
-	oldAssigneeID := ticket.AssigneeID
-	ticket.AssigneeID = &req.AssigneeID
-	
-	// 状态处理逻辑
-	if ticket.Status == models.TicketStatusSubmitted {
-		// 首次分配
-		if req.AutoAccept {
-			ticket.Status = models.TicketStatusAccepted
-		} else {
-			ticket.Status = models.TicketStatusAssigned
-		}
-	} else if oldAssigneeID != nil && *oldAssigneeID != req.AssigneeID {
-		// 重新分配给不同的人
-		if req.AutoAccept {
-			ticket.Status = models.TicketStatusAccepted
-		} else {
-			// 重新分配时，如果工单之前已经被接受或审批，保持较高的状态
-			if ticket.Status == models.TicketStatusAccepted || 
-			   ticket.Status == models.TicketStatusApproved || 
-			   ticket.Status == models.TicketStatusInProgress {
-				// 重新分配后默认自动接受，避免流程倒退
-				ticket.Status = models.TicketStatusAccepted
-			} else {
-				ticket.Status = models.TicketStatusAssigned
-			}
-		}
-	}
-	// 如果分配给同一个人，保持原状态
-
-	if err := h.db.Save(&ticket).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "分配工单失败"})
-		return
-	}
-
-	// 记录历史
-	historyDesc := "工单已分配给 " + assignee.Username
-	if req.AutoAccept {
-		historyDesc += " 并自动接受"
-	}
-	if req.Comment != "" {
-		historyDesc += "，备注：" + req.Comment
-	}
-	h.addTicketHistory(ticket.ID, userID, "assigned", historyDesc)
-
-	// 如果是自动接受，再记录一条接受历史
-	if req.AutoAccept {
-		h.addTicketHistory(ticket.ID, req.AssigneeID, "accept", "工单已自动接受")
-	}
-
-	// 发送通知
-	go h.sendTicketNotification(&ticket, "assigned", historyDesc)
-
-	// 重新加载完整数据
-	h.db.Preload("Creator").Preload("Assignee").First(&ticket, ticket.ID)
-
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    ticket,
@@ -534,8 +848,9 @@ func (h *TicketHandler) UpdateTicketStatus(c *gin.Context) {
 	}
 
 	var req struct {
-		Status  string `json:"status" binding:"required,oneof=submitted assigned accepted approved progress pending resolved closed rejected returned"`
-		Comment string `json:"comment"`
+		Status     string `json:"status" binding:"required,max=20"`
+		Comment    string `json:"comment"`
+		Resolution string `json:"resolution"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -549,75 +864,14 @@ func (h *TicketHandler) UpdateTicketStatus(c *gin.Context) {
 		return
 	}
 
-	// 查找工单
-	var ticket models.Ticket
-	query := h.db
-	if !hasPermission(c, "ticket:status") {
-		query = query.Where("creator_id = ? OR assignee_id = ?", userID, userID)
-	}
-
-	err = query.First(&ticket, id).Error
+	// 流转是否合法、操作者能否执行由工单流程决定
+	transitionReq := &services.TicketTransitionRequest{ToStatus: req.Status, Comment: req.Comment, Resolution: req.Resolution}
+	ticket, err := h.workflowService.Transition(uint(id), transitionReq, ticketActor(c), c.ClientIP(), c.GetHeader("User-Agent"))
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "工单不存在或无权限"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "查询工单失败"})
-		}
-		return
-	}
-
-	// 验证状态转换是否合法
-	if !isValidStatusTransition(ticket.Status, models.TicketStatus(req.Status)) {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "无效的状态转换",
-			"current_status": string(ticket.Status),
-			"target_status": req.Status,
-			"debug": "状态转换验证失败",
-		})
-		return
-	}
-
-	// 更新状态
-	oldStatus := ticket.Status
-	ticket.Status = models.TicketStatus(req.Status)
-
-	// 特殊状态处理
-	if req.Status == "approved" {
-		// 审批通过后自动进入处理阶段
-		go func() {
-			time.Sleep(1 * time.Second) // 延迟1秒后自动处理
-			now := time.Now()
-			ticket.Status = models.TicketStatusInProgress
-			ticket.ProcessingStartedAt = &now
-			h.db.Save(&ticket)
-			
-			// 记录历史
-			h.addTicketHistory(ticket.ID, userID, "auto_processing", "工单审批通过后自动进入处理阶段")
-		}()
-	} else if req.Status == "progress" && ticket.ProcessingStartedAt == nil {
-		// 手动进入处理阶段时记录开始时间
-		now := time.Now()
-		ticket.ProcessingStartedAt = &now
-	}
-
-	if err := h.db.Save(&ticket).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新工单状态失败"})
+		c.JSON(ticketWorkflowErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	// 记录历史
-	historyDesc := "状态: " + string(oldStatus) + " -> " + req.Status
-	if req.Comment != "" {
-		historyDesc += "，备注：" + req.Comment
-	}
-	h.addTicketHistory(ticket.ID, userID, "status_changed", historyDesc)
-
-	// 发送通知
-	go h.sendTicketNotification(&ticket, "status_changed", historyDesc)
-
-	// 重新加载完整数据
-	h.db.Preload("Creator").Preload("Assignee").First(&ticket, ticket.ID)
-
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    ticket,
@@ -738,166 +992,30 @@ func (h *TicketHandler) getTicketStats(userID uint) map[string]interface{} {
 	}
 	stats["priority"] = priorityStats
 
-	return stats
-}
-
-// isValidStatusTransition 验证状态转换是否合法
-func isValidStatusTransition(from, to models.TicketStatus) bool {
-	// 定义合法的状态转换
-	validTransitions := map[models.TicketStatus][]models.TicketStatus{
-		models.TicketStatusSubmitted: {
-			models.TicketStatusAssigned,
-			models.TicketStatusAccepted,  // 允许直接从提交状态到接受状态（用于自动接受）
-			models.TicketStatusRejected,
-		},
-		models.TicketStatusAssigned: {
-			models.TicketStatusAccepted,
-			models.TicketStatusRejected,
-			models.TicketStatusReturned,
-		},
-		models.TicketStatusAccepted: {
-			models.TicketStatusApproved,
-			models.TicketStatusRejected,
-			models.TicketStatusReturned,
-		},
-		models.TicketStatusApproved: {
-			models.TicketStatusInProgress, // "progress" - 允许从审批状态直接进入处理状态
-			models.TicketStatusReturned,
-			models.TicketStatusRejected,   // 允许审批后拒绝
-		},
-		models.TicketStatusInProgress: {
-			models.TicketStatusPending,
-			models.TicketStatusResolved,
-			models.TicketStatusReturned,
-		},
-		models.TicketStatusPending: {
-			models.TicketStatusInProgress,
-			models.TicketStatusResolved,
-		},
-		models.TicketStatusResolved: {
-			models.TicketStatusClosed,
-			models.TicketStatusReturned,
-		},
-		models.TicketStatusRejected: {
-			models.TicketStatusSubmitted, // 拒绝后可以重新提交
-		},
-		models.TicketStatusReturned: {
-			models.TicketStatusSubmitted, // 退回后可以重新提交
-		},
-		models.TicketStatusClosed: {
-			// 已关闭的工单一般不允许再次更改状态
-		},
-	}
-
-	allowedTransitions, exists := validTransitions[from]
-	if !exists {
-		return false
+	// SLA统计
+	if slaStats, err := h.slaService.Statistics(userID); err == nil {
+		stats["sla"] = slaStats
 	}
 
-	for _, allowed := range allowedTransitions {
-		if allowed == to {
-			return true
-		}
+	// 满意度统计
+	if satisfaction, err := h.surveyService.UserSummary(userID); err == nil {
+		stats["satisfaction"] = satisfaction
 	}
 
-	return false
-}
-
-// autoAssignTicket 自动分配工单
-func (h *TicketHandler) autoAssignTicket(ticket *models.Ticket) {
-	// 根据工单类型自动分配给相应角色的用户
-	var assigneeRole string
-	var defaultAssigneeID uint
-	
-	switch ticket.Type {
-	case models.TicketTypeBug:
-		assigneeRole = "developer" // 故障报告分配给开发人员
-		defaultAssigneeID = 2 // 假设用户ID 2是开发人员
-	case models.TicketTypeFeature:
-		assigneeRole = "product_manager" // 功能请求分配给产品经理
-		defaultAssigneeID = 1 // 假设用户ID 1是产品经理
-	case models.TicketTypeSupport:
-		assigneeRole = "support" // 技术支持分配给支持人员
-		defaultAssigneeID = 1 // 假设用户ID 1是支持人员
-	case models.TicketTypeChange:
-		assigneeRole = "admin" // 变更请求分配给管理员
-		defaultAssigneeID = 1 // 假设用户ID 1是管理员
-	case models.TicketTypeCustom:
-		assigneeRole = "admin" // 自定义请求分配给管理员
-		defaultAssigneeID = 1 // 假设用户ID 1是管理员
-	default:
-		assigneeRole = "admin" // 默认分配给管理员
-		defaultAssigneeID = 1
-	}
-
-	// 查找具有相应角色的用户
-	// TODO: 实际实现应该根据用户角色表查找，这里简化处理
-	var assignee models.User
-	err := h.db.Where("id = ?", defaultAssigneeID).First(&assignee).Error
-	if err != nil {
-		// 如果指定用户不存在，尝试分配给管理员（ID=1）
-		err = h.db.Where("id = ?", 1).First(&assignee).Error
-		if err != nil {
-			// 记录日志：无法找到合适的处理人员
-			return
-		}
-	}
-
-	// 更新工单分配
-	ticket.AssigneeID = &assignee.ID
-	ticket.Status = models.TicketStatusAssigned
-	ticket.AutoAssignRole = assigneeRole // 记录分配的角色
-	h.db.Save(ticket)
-
-	// 记录历史
-	h.addTicketHistory(ticket.ID, assignee.ID, "auto_assigned", "工单已自动分配给 "+assignee.Username+" (角色: "+assigneeRole+")")
-
-	// 发送通知
-	go h.sendTicketNotification(ticket, "auto_assigned", "工单已自动分配")
+	return stats
 }
 
-// autoProcessApprovedTickets 自动处理已审批的工单
-func (h *TicketHandler) autoProcessApprovedTickets() {
-	var tickets []models.Ticket
-	h.db.Where("status = ?", models.TicketStatusApproved).Find(&tickets)
-
-	for _, ticket := range tickets {
-		// 自动进入处理阶段
-		now := time.Now()
-		ticket.Status = models.TicketStatusInProgress
-		ticket.ProcessingStartedAt = &now
-		h.db.Save(&ticket)
-
-		// 记录历史
-		h.addTicketHistory(ticket.ID, 0, "auto_processing", "工单已自动进入处理阶段")
-
-		// 发送通知
-		go h.sendTicketNotification(&ticket, "auto_processing", "工单已自动进入处理阶段")
+// applySLA 为工单匹配SLA策略并计算时限
+func (h *TicketHandler) applySLA(ticket *models.Ticket) {
+	if err := h.slaService.ApplyToTicket(ticket); err != nil {
+		fmt.Printf("Warning: failed to apply SLA to ticket %d: %v\n", ticket.ID, err)
 	}
 }
 
-// checkProcessingTimeout 检查处理超时的工单
-func (h *TicketHandler) checkProcessingTimeout() {
-	var tickets []models.Ticket
-	h.db.Where("status = ? AND processing_started_at IS NOT NULL", models.TicketStatusInProgress).Find(&tickets)
-
-	for _, ticket := range tickets {
-		if ticket.ProcessingStartedAt != nil {
-			timeout := time.Duration(ticket.ProcessingTimeout) * time.Hour
-			if time.Since(*ticket.ProcessingStartedAt) > timeout {
-				// 超时自动关闭
-				now := time.Now()
-				ticket.Status = models.TicketStatusClosed
-				ticket.ClosedAt = &now
-				h.db.Save(&ticket)
-
-				// 记录历史
-				h.addTicketHistory(ticket.ID, 0, "auto_closed", "工单因处理超时已自动关闭")
-
-				// 发送通知
-				go h.sendTicketNotification(&ticket, "auto_closed", "工单因处理超时已自动关闭")
-			}
-		}
+// autoAssignTicket 按分配规则自动分配工单，未匹配规则或无可用处理人时保持未分配
+func (h *TicketHandler) autoAssignTicket(ticket *models.Ticket) {
+	if _, err := h.assignmentService.ApplyToTicket(ticket, h.workflowService); err != nil {
+		fmt.Printf("Warning: failed to auto assign ticket %d: %v\n", ticket.ID, err)
 	}
 }
 
@@ -936,58 +1054,6 @@ func (h *TicketHandler) GetTicketCategories(c *gin.Context) {
 	})
 }
 
-// GetAssignmentRules 获取自动分配规则
-func (h *TicketHandler) GetAssignmentRules(c *gin.Context) {
-	rules := map[string]interface{}{
-		"bug": map[string]interface{}{
-			"role": "developer",
-			"assignee_id": 2,
-			"assignee_name": "开发人员",
-		},
-		"feature": map[string]interface{}{
-			"role": "product_manager", 
-			"assignee_id": 1,
-			"assignee_name": "产品经理",
-		},
-		"support": map[string]interface{}{
-			"role": "support",
-			"assignee_id": 1,
-			"assignee_name": "技术支持",
-		},
-		"change": map[string]interface{}{
-			"role": "admin",
-			"assignee_id": 1,
-			"assignee_name": "系统管理员",
-		},
-		"custom": map[string]interface{}{
-			"role": "admin",
-			"assignee_id": 1,
-			"assignee_name": "系统管理员",
-		},
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    rules,
-	})
-}
-
-// UpdateAssignmentRules 更新自动分配规则
-func (h *TicketHandler) UpdateAssignmentRules(c *gin.Context) {
-	var req map[string]interface{}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// TODO: 实际实现应该将规则保存到数据库
-	// 这里简化处理，返回成功
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "分配规则更新成功",
-	})
-}
-
 func (h *TicketHandler) addTicketHistory(ticketID uint, userID uint, action, description string) {
 	history := models.TicketHistory{
 		TicketID:    ticketID,
@@ -998,10 +1064,8 @@ func (h *TicketHandler) addTicketHistory(ticketID uint, userID uint, action, des
 	h.db.Create(&history)
 }
 
-func (h *TicketHandler) sendTicketNotification(ticket *models.Ticket, action, description string) {
-	if h.notificationService == nil {
-		return
-	}
+// sendTicketNotification 通知工单关注者（创建者与处理人自动关注），actorID 为操作者，不会通知其本人
+func (h *TicketHandler) sendTicketNotification(ticket *models.Ticket, actorID uint, action, description string) {
 
 	// 构建通知内容
 	title := "工单通知"
@@ -1016,24 +1080,19 @@ func (h *TicketHandler) sendTicketNotification(ticket *models.Ticket, action, de
 		content = "工单状态已更新：" + ticket.Title
 	case "updated":
 		content = "工单已更新：" + ticket.Title
+	case "commented":
+		content = "工单有新评论：" + ticket.Title
+	case "attachment_added", "attachment_removed":
+		content = "工单附件已更新：" + ticket.Title
+	default:
+		content = "工单通知：" + ticket.Title
 	}
 
 	if description != "" {
 		content += "\n" + description
 	}
 
-	// 发送给相关人员
-	recipients := []uint{}
-	if ticket.CreatorID > 0 {
-		recipients = append(recipients, ticket.CreatorID)
-	}
-	if ticket.AssigneeID != nil && *ticket.AssigneeID > 0 {
-		recipients = append(recipients, *ticket.AssigneeID)
-	}
-
-	for _, recipientID := range recipients {
-		h.notificationService.SendSimpleNotification(recipientID, title, content, "ticket")
-	}
+	h.watchService.NotifyWatchers(models.WatchEntityTicket, ticket.ID, actorID, title, content)
 }
 
 // GetTicketComments 获取工单评论
@@ -1140,11 +1199,14 @@ func (h *TicketHandler) AddTicketComment(c *gin.Context) {
 		return
 	}
 
+	// 非创建者的回复计为首次响应
+	h.slaService.RecordResponse(&ticket, userID)
+
 	// 记录历史
 	h.addTicketHistory(uint(id), userID, "commented", "添加了评论")
 
 	// 发送通知
-	go h.sendTicketNotification(&ticket, "commented", "工单有新评论")
+	h.sendTicketNotification(&ticket, userID, "commented", "工单有新评论")
 
 	// 重新加载完整数据
 	h.db.Preload("User").First(&comment, comment.ID)
@@ -1262,6 +1324,7 @@ func (h *TicketHandler) UploadTicketAttachment(c *gin.Context) {
 
 	// 记录历史
 	h.addTicketHistory(uint(id), userID, "attachment_added", "上传了附件："+header.Filename)
+	h.sendTicketNotification(&ticket, userID, "attachment_added", "上传了附件："+header.Filename)
 
 	c.JSON(http.StatusCreated, attachment)
 }
@@ -1329,6 +1392,7 @@ func (h *TicketHandler) DeleteTicketAttachment(c *gin.Context) {
 
 	// 记录历史
 	h.addTicketHistory(uint(ticketID), userID, "attachment_deleted", "删除了附件："+attachment.FileName)
+	h.sendTicketNotification(&ticket, userID, "attachment_removed", "删除了附件："+attachment.FileName)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -1338,229 +1402,31 @@ func (h *TicketHandler) DeleteTicketAttachment(c *gin.Context) {
 
 // AcceptTicket 接受工单
 func (h *TicketHandler) AcceptTicket(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的工单ID"})
-		return
-	}
-
-	var req struct {
-		Comment string `json:"comment"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		// 如果没有请求体，也允许
-		req.Comment = ""
-	}
-
-	userID := getUserID(c)
-	if userID == 0 {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
-		return
-	}
-
-	// 查找工单
-	var ticket models.Ticket
-	err = h.db.Where("id = ? AND assignee_id = ?", id, userID).First(&ticket).Error
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "工单不存在或无权限"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "查询工单失败"})
-		}
-		return
-	}
-
-	// 检查工单状态
-	if ticket.Status != models.TicketStatusAssigned {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "只能接受已分配的工单"})
-		return
-	}
-
-	// 更新状态为已接受
-	ticket.Status = models.TicketStatusAccepted
-
-	if err := h.db.Save(&ticket).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "接受工单失败"})
-		return
-	}
-
-	// 添加历史记录
-	h.addTicketHistory(ticket.ID, userID, "accept", "工单已接受"+func() string {
-		if req.Comment != "" {
-			return "：" + req.Comment
-		}
-		return ""
-	}())
-
-	// 重新加载工单数据
-	h.db.Preload("Creator").Preload("Assignee").First(&ticket, ticket.ID)
-
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    ticket,
-		"message": "工单接受成功",
-	})
+	h.transitionTicket(c, "accept", "工单接受成功")
 }
 
 // RejectTicket 拒绝工单
 func (h *TicketHandler) RejectTicket(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的工单ID"})
-		return
-	}
-
-	var req struct {
-		Comment string `json:"comment"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		// 如果没有请求体，也允许
-		req.Comment = ""
-	}
-
-	userID := getUserID(c)
-	if userID == 0 {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
-		return
-	}
-
 	// 检查权限
 	if !hasPermission(c, "ticket:reject") {
 		c.JSON(http.StatusForbidden, gin.H{"error": "无权限拒绝工单"})
 		return
 	}
-
-	// 查找工单
-	var ticket models.Ticket
-	query := h.db
-	if !hasPermission(c, "ticket:reject_all") {
-		// 如果没有全局拒绝权限，只能拒绝分配给自己的工单
-		query = query.Where("assignee_id = ?", userID)
-	}
-	
-	err = query.First(&ticket, id).Error
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "工单不存在或无权限"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "查询工单失败"})
-		}
-		return
-	}
-
-	// 检查工单状态 - 允许拒绝已分配或已接受的工单
-	if ticket.Status != models.TicketStatusAssigned && ticket.Status != models.TicketStatusAccepted {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "只能拒绝已分配或已接受的工单"})
-		return
-	}
-
-	// 更新状态为已拒绝
-	ticket.Status = models.TicketStatusRejected
-
-	if err := h.db.Save(&ticket).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "拒绝工单失败"})
-		return
-	}
-
-	// 添加历史记录
-	h.addTicketHistory(ticket.ID, userID, "reject", "工单已拒绝"+func() string {
-		if req.Comment != "" {
-			return "：" + req.Comment
-		}
-		return ""
-	}())
-
-	// 发送通知
-	go h.sendTicketNotification(&ticket, "rejected", "工单已被拒绝")
-
-	// 重新加载工单数据
-	h.db.Preload("Creator").Preload("Assignee").First(&ticket, ticket.ID)
-
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    ticket,
-		"message": "工单拒绝成功",
-	})
+	h.transitionTicket(c, "reject", "工单拒绝成功")
 }
 
 // ReopenTicket 重新打开工单
 func (h *TicketHandler) ReopenTicket(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的工单ID"})
-		return
-	}
-
-	var req struct {
-		Comment string `json:"comment"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		// 如果没有请求体，也允许
-		req.Comment = ""
-	}
-
-	userID := getUserID(c)
-	if userID == 0 {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
-		return
-	}
-
-	// 查找工单
-	var ticket models.Ticket
-	query := h.db
-	if !hasPermission(c, "ticket:reopen_all") {
-		query = query.Where("creator_id = ? OR assignee_id = ?", userID, userID)
-	}
-
-	err = query.First(&ticket, id).Error
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "工单不存在或无权限"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "查询工单失败"})
-		}
-		return
-	}
-
-	// 检查工单状态
-	if ticket.Status != models.TicketStatusClosed {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "只能重新打开已关闭的工单"})
-		return
-	}
-
-	// 更新状态为已提交
-	ticket.Status = models.TicketStatusSubmitted
-	// 清除关闭时间
-	ticket.ClosedAt = nil
-
-	if err := h.db.Save(&ticket).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "重新打开工单失败"})
-		return
-	}
-
-	// 添加历史记录
-	h.addTicketHistory(ticket.ID, userID, "reopen", "工单已重新打开"+func() string {
-		if req.Comment != "" {
-			return "：" + req.Comment
-		}
-		return ""
-	}())
-
-	// 重新加载工单数据
-	h.db.Preload("Creator").Preload("Assignee").First(&ticket, ticket.ID)
-
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    ticket,
-		"message": "工单重新打开成功",
-	})
+	h.transitionTicket(c, "reopen", "工单重新打开成功")
 }
 
 // ResubmitTicket 重新提交工单
 func (h *TicketHandler) ResubmitTicket(c *gin.Context) {
+	h.transitionTicket(c, "resubmit", "工单重新提交成功")
+}
+
+// transitionTicket 经由工单流程引擎执行指定动作
+func (h *TicketHandler) transitionTicket(c *gin.Context, action, message string) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的工单ID"})
@@ -1568,7 +1434,8 @@ func (h *TicketHandler) ResubmitTicket(c *gin.Context) {
 	}
 
 	var req struct {
-		Comment string `json:"comment"`
+		Comment    string `json:"comment"`
+		Resolution string `json:"resolution"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -1582,54 +1449,17 @@ func (h *TicketHandler) ResubmitTicket(c *gin.Context) {
 		return
 	}
 
-	// 查找工单
-	var ticket models.Ticket
-	query := h.db
-	if !hasPermission(c, "ticket:resubmit_all") {
-		query = query.Where("creator_id = ?", userID)
-	}
-
-	err = query.First(&ticket, id).Error
+	transitionReq := &services.TicketTransitionRequest{Action: action, Comment: req.Comment, Resolution: req.Resolution}
+	ticket, err := h.workflowService.Transition(uint(id), transitionReq, ticketActor(c), c.ClientIP(), c.GetHeader("User-Agent"))
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "工单不存在或无权限"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "查询工单失败"})
-		}
-		return
-	}
-
-	// 检查工单状态
-	if ticket.Status != models.TicketStatusRejected && ticket.Status != models.TicketStatusReturned {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "只能重新提交已拒绝或已退回的工单"})
-		return
-	}
-
-	// 更新状态为已提交
-	ticket.Status = models.TicketStatusSubmitted
-	// 清除分配信息
-	ticket.AssigneeID = nil
-
-	if err := h.db.Save(&ticket).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "重新提交工单失败"})
+		c.JSON(ticketWorkflowErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	// 添加历史记录
-	h.addTicketHistory(ticket.ID, userID, "resubmit", "工单已重新提交"+func() string {
-		if req.Comment != "" {
-			return "：" + req.Comment
-		}
-		return ""
-	}())
-
-	// 重新加载工单数据
-	h.db.Preload("Creator").Preload("Assignee").First(&ticket, ticket.ID)
-
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    ticket,
-		"message": "工单重新提交成功",
+		"message": message,
 	})
 }
 
@@ -1698,89 +1528,62 @@ func (h *TicketHandler) ExportTickets(c *gin.Context) {
 		return
 	}
 
-	// 根据格式生成内容
-	var content []byte
-	var filename string
-	var contentType string
-
-	if query.Format == "xlsx" {
-		// 对于xlsx请求，我们返回CSV内容但使用Excel兼容的格式
-		csvContent := "ID,标题,类型,状态,优先级,创建人,处理人,创建时间,更新时间,描述\n"
-		for _, ticket := range tickets {
-			creatorName := ""
-			if ticket.Creator.ID != 0 {
-				creatorName = ticket.Creator.Username
-			}
-			assigneeName := ""
-			if ticket.Assignee != nil {
-				assigneeName = ticket.Assignee.Username
-			}
+	// 自定义字段按工单类型与分类汇总为额外的列
+	fieldExport, err := services.NewTicketFieldExport(h.db, tickets)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ticketIDs := make([]uint, len(tickets))
+	for i, ticket := range tickets {
+		ticketIDs[i] = ticket.ID
+	}
+	worklogTotals, err := services.TicketWorklogTotals(h.db, ticketIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-			// 转换状态和类型为中文
-			statusLabel := getStatusLabel(string(ticket.Status))
-			typeLabel := getTypeLabel(string(ticket.Type))
-			priorityLabel := getPriorityLabel(string(ticket.Priority))
-
-			csvContent += fmt.Sprintf("%d,\"%s\",\"%s\",\"%s\",\"%s\",\"%s\",\"%s\",\"%s\",\"%s\",\"%s\"\n",
-				ticket.ID,
-				ticket.Title,
-				typeLabel,
-				statusLabel,
-				priorityLabel,
-				creatorName,
-				assigneeName,
-				ticket.CreatedAt.Format("2006-01-02 15:04:05"),
-				ticket.UpdatedAt.Format("2006-01-02 15:04:05"),
-				strings.ReplaceAll(ticket.Description, "\"", "\"\""), // 转义双引号
-			)
+	// xlsx 请求同样返回 Excel 兼容的 CSV 内容
+	headers := append([]string{"ID", "标题", "类型", "状态", "优先级", "分类", "创建人", "处理人", "创建时间", "更新时间", "描述", "原始估时(小时)", "剩余估时(小时)", "已登记工时(小时)", "计费工时(小时)"}, fieldExport.Headers()...)
+	csvContent := csvLine(headers)
+	for _, ticket := range tickets {
+		creatorName := ""
+		if ticket.Creator.ID != 0 {
+			creatorName = ticket.Creator.Username
 		}
-
-		// 添加BOM以支持Excel正确显示中文
-		bomBytes := []byte{0xEF, 0xBB, 0xBF}
-		csvBytes := []byte(csvContent)
-		content = append(bomBytes, csvBytes...)
-		filename = fmt.Sprintf("tickets_export_%s.csv", time.Now().Format("20060102_150405"))
-		contentType = "text/csv; charset=utf-8"
-	} else {
-		// CSV格式
-		csvContent := "ID,标题,类型,状态,优先级,创建人,处理人,创建时间,更新时间,描述\n"
-		for _, ticket := range tickets {
-			creatorName := ""
-			if ticket.Creator.ID != 0 {
-				creatorName = ticket.Creator.Username
-			}
-			assigneeName := ""
-			if ticket.Assignee != nil {
-				assigneeName = ticket.Assignee.Username
-			}
-
-			// 转换状态和类型为中文
-			statusLabel := getStatusLabel(string(ticket.Status))
-			typeLabel := getTypeLabel(string(ticket.Type))
-			priorityLabel := getPriorityLabel(string(ticket.Priority))
-
-			csvContent += fmt.Sprintf("%d,\"%s\",\"%s\",\"%s\",\"%s\",\"%s\",\"%s\",\"%s\",\"%s\",\"%s\"\n",
-				ticket.ID,
-				ticket.Title,
-				typeLabel,
-				statusLabel,
-				priorityLabel,
-				creatorName,
-				assigneeName,
-				ticket.CreatedAt.Format("2006-01-02 15:04:05"),
-				ticket.UpdatedAt.Format("2006-01-02 15:04:05"),
-				strings.ReplaceAll(ticket.Description, "\"", "\"\""), // 转义双引号
-			)
+		assigneeName := ""
+		if ticket.Assignee != nil {
+			assigneeName = ticket.Assignee.Username
 		}
 
-		// 添加BOM以支持Excel正确显示中文
-		bomBytes := []byte{0xEF, 0xBB, 0xBF}
-		csvBytes := []byte(csvContent)
-		content = append(bomBytes, csvBytes...)
-		filename = fmt.Sprintf("tickets_export_%s.csv", time.Now().Format("20060102_150405"))
-		contentType = "text/csv; charset=utf-8"
+		// 转换状态和类型为中文
+		row := []string{
+			strconv.FormatUint(uint64(ticket.ID), 10),
+			ticket.Title,
+			getTypeLabel(string(ticket.Type)),
+			getStatusLabel(string(ticket.Status)),
+			getPriorityLabel(string(ticket.Priority)),
+			ticket.Category,
+			creatorName,
+			assigneeName,
+			ticket.CreatedAt.Format("2006-01-02 15:04:05"),
+			ticket.UpdatedAt.Format("2006-01-02 15:04:05"),
+			ticket.Description,
+			formatEstimateHours(ticket.OriginalEstimate),
+			formatEstimateHours(ticket.RemainingEstimate),
+			formatWorkHours(worklogTotals[ticket.ID].Minutes),
+			formatWorkHours(worklogTotals[ticket.ID].BillableMinutes),
+		}
+		csvContent += csvLine(append(row, fieldExport.Cells(&ticket)...))
 	}
 
+	// 添加BOM以支持Excel正确显示中文
+	bomBytes := []byte{0xEF, 0xBB, 0xBF}
+	content := append(bomBytes, []byte(csvContent)...)
+	filename := fmt.Sprintf("tickets_export_%s.csv", time.Now().Format("20060102_150405"))
+	contentType := "text/csv; charset=utf-8"
+
 	// 设置响应头
 	c.Header("Content-Type", contentType)
 	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
@@ -1790,6 +1593,19 @@ func (h *TicketHandler) ExportTickets(c *gin.Context) {
 	c.Data(http.StatusOK, contentType, content)
 }
 
+// csvLine 生成一行CSV，除ID外的列都加引号并转义双引号
+func csvLine(cells []string) string {
+	quoted := make([]string, len(cells))
+	for i, cell := range cells {
+		if i == 0 {
+			quoted[i] = cell
+			continue
+		}
+		quoted[i] = "\"" + strings.ReplaceAll(cell, "\"", "\"\"") + "\""
+	}
+	return strings.Join(quoted, ",") + "\n"
+}
+
 // ImportTickets 导入工单
 func (h *TicketHandler) ImportTickets(c *gin.Context) {
 	userID := getUserID(c)
@@ -1878,6 +1694,22 @@ func (h *TicketHandler) importFromCSV(src multipart.File, userID uint) (int, []s
 	var importedCount int
 	var errors []string
 
+	// 前四列依次为标题、类型、优先级、描述，其后的列按标题识别分类与自定义字段（字段名或显示名）
+	categoryColumn := -1
+	fieldColumns := map[int]string{}
+	for i, name := range records[0] {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		if i < 4 || name == "" {
+			continue
+		}
+		if strings.EqualFold(name, "category") || name == "分类" {
+			categoryColumn = i
+		} else {
+			fieldColumns[i] = name
+		}
+	}
+	fieldsByScope := map[[2]string][]services.TicketFieldDefinition{}
+
 	// 跳过标题行
 	for i, record := range records[1:] {
 		if len(record) < 4 { // 至少需要标题、类型、优先级、描述
@@ -1928,20 +1760,40 @@ func (h *TicketHandler) importFromCSV(src multipart.File, userID uint) (int, []s
 			modelPriority = models.TicketPriorityNormal // 默认为普通
 		}
 
+		category := ""
+		if categoryColumn >= 0 && categoryColumn < len(record) {
+			category = strings.TrimSpace(record[categoryColumn])
+		}
+		customFields, err := h.importCustomFields(fieldsByScope, string(modelType), category, fieldColumns, record)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("第%d行：%s", i+2, err.Error()))
+			continue
+		}
+
 		// 创建工单
 		ticket := models.Ticket{
 			Title:       title,
 			Description: description,
 			Type:        modelType,
 			Priority:    modelPriority,
-			Status:      models.TicketStatusSubmitted,
+			Status:      h.workflowService.InitialStatus(string(modelType), category),
 			CreatorID:   userID,
+			Category:    category,
+			CustomFields: customFields,
 		}
 
-		if err := h.db.Create(&ticket).Error; err != nil {
+		err = h.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&ticket).Error; err != nil {
+				return err
+			}
+			return services.SyncTicketFieldValues(tx, ticket.ID, ticket.CustomFields)
+		})
+		if err != nil {
 			errors = append(errors, fmt.Sprintf("第%d行：创建工单失败 - %s", i+2, err.Error()))
 			continue
 		}
+		services.AddWatchers(h.db, models.WatchEntityTicket, ticket.ID, models.WatchReasonCreator, userID)
+		h.applySLA(&ticket)
 
 		// 记录历史
 		h.addTicketHistory(ticket.ID, userID, "created", "工单通过导入创建")
@@ -1951,6 +1803,40 @@ func (h *TicketHandler) importFromCSV(src multipart.File, userID uint) (int, []s
 	return importedCount, errors
 }
 
+// importCustomFields 按列标题匹配工单适用的自定义字段并转换单元格的值，不适用于该工单的列被忽略
+func (h *TicketHandler) importCustomFields(cache map[[2]string][]services.TicketFieldDefinition, ticketType, category string, columns map[int]string, record []string) (models.JSONB, error) {
+	scope := [2]string{ticketType, category}
+	fields, ok := cache[scope]
+	if !ok {
+		var err error
+		if fields, err = services.ResolveTicketFields(h.db, ticketType, category); err != nil {
+			return nil, err
+		}
+		cache[scope] = fields
+	}
+
+	values := map[string]interface{}{}
+	for column, name := range columns {
+		if column >= len(record) {
+			continue
+		}
+		for i := range fields {
+			if !strings.EqualFold(fields[i].Name, name) && fields[i].Label != name {
+				continue
+			}
+			value, err := services.ParseTicketFieldImportValue(h.db, &fields[i], record[column])
+			if err != nil {
+				return nil, err
+			}
+			if value != nil {
+				values[fields[i].Name] = value
+			}
+			break
+		}
+	}
+	return services.PrepareTicketCustomFields(h.db, ticketType, category, nil, values)
+}
+
 // 辅助函数：获取状态标签
 func getStatusLabel(status string) string {
 	statusMap := map[string]string{
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"net/http"
+
+	"info-management-system/internal/middleware"
+	"info-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TicketRelationHandler 工单关系处理器：父子工单、阻塞依赖、合并与拆分
+type TicketRelationHandler struct {
+	relationService *services.TicketRelationService
+}
+
+// NewTicketRelationHandler 创建工单关系处理器
+func NewTicketRelationHandler(relationService *services.TicketRelationService) *TicketRelationHandler {
+	return &TicketRelationHandler{
+		relationService: relationService,
+	}
+}
+
+// GetRelations 获取工单的父子、依赖与合并关系
+func (h *TicketRelationHandler) GetRelations(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	relations, err := h.relationService.GetRelations(id, ticketActor(c))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, relations)
+}
+
+// SetParent 设置或取消父工单
+func (h *TicketRelationHandler) SetParent(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	var req services.SetParentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	ticket, err := h.relationService.SetParent(id, &req, ticketActor(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, ticket)
+}
+
+// AddBlocker 添加阻塞依赖
+func (h *TicketRelationHandler) AddBlocker(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	var req services.AddBlockerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	actor := ticketActor(c)
+	if err := h.relationService.AddBlocker(id, &req, actor, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+	h.respondRelations(c, id, actor, http.StatusCreated)
+}
+
+// RemoveBlocker 删除阻塞依赖
+func (h *TicketRelationHandler) RemoveBlocker(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+	blockerID, err := parseUintParam(c, "blocker_id")
+	if err != nil {
+		return
+	}
+
+	actor := ticketActor(c)
+	if err := h.relationService.RemoveBlocker(id, blockerID, actor, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+	h.respondRelations(c, id, actor, http.StatusOK)
+}
+
+// MergeTicket 将工单合并到目标工单，返回目标工单
+func (h *TicketRelationHandler) MergeTicket(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	var req services.MergeTicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	target, err := h.relationService.Merge(id, &req, ticketActor(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, target)
+}
+
+// SplitTicket 将选中的评论拆分为新工单
+func (h *TicketRelationHandler) SplitTicket(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	var req services.SplitTicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	ticket, err := h.relationService.Split(id, &req, ticketActor(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    ticket,
+	})
+}
+
+// respondRelations 返回工单最新的关系
+func (h *TicketRelationHandler) respondRelations(c *gin.Context, id uint, actor *services.TicketActor, status int) {
+	relations, err := h.relationService.GetRelations(id, actor)
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+	c.JSON(status, gin.H{
+		"success": true,
+		"data":    relations,
+	})
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"net/http"
+
+	"info-management-system/internal/middleware"
+	"info-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TicketSLAHandler 工单SLA处理器
+type TicketSLAHandler struct {
+	slaService *services.TicketSLAService
+}
+
+// NewTicketSLAHandler 创建工单SLA处理器
+func NewTicketSLAHandler(slaService *services.TicketSLAService) *TicketSLAHandler {
+	return &TicketSLAHandler{
+		slaService: slaService,
+	}
+}
+
+// GetTicketSLA 获取工单的SLA状态，未适用SLA时返回空
+func (h *TicketSLAHandler) GetTicketSLA(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	sla, err := h.slaService.GetTicketSLA(id, ticketActor(c))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, sla)
+}
+
+// ListPolicies 获取SLA策略列表
+func (h *TicketSLAHandler) ListPolicies(c *gin.Context) {
+	if !h.canManage(c) {
+		return
+	}
+
+	policies, err := h.slaService.ListPolicies()
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, policies)
+}
+
+// GetPolicy 获取SLA策略详情
+func (h *TicketSLAHandler) GetPolicy(c *gin.Context) {
+	if !h.canManage(c) {
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	policy, err := h.slaService.GetPolicy(id)
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, policy)
+}
+
+// CreatePolicy 创建SLA策略
+func (h *TicketSLAHandler) CreatePolicy(c *gin.Context) {
+	if !h.canManage(c) {
+		return
+	}
+
+	var req services.SaveSLAPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	policy, err := h.slaService.CreatePolicy(&req, getUserID(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    policy,
+	})
+}
+
+// UpdatePolicy 更新SLA策略
+func (h *TicketSLAHandler) UpdatePolicy(c *gin.Context) {
+	if !h.canManage(c) {
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	var req services.SaveSLAPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	policy, err := h.slaService.UpdatePolicy(id, &req, getUserID(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, policy)
+}
+
+// DeletePolicy 删除SLA策略
+func (h *TicketSLAHandler) DeletePolicy(c *gin.Context) {
+	if !h.canManage(c) {
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	if err := h.slaService.DeletePolicy(id, getUserID(c), c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, gin.H{"message": "删除成功"})
+}
+
+// ListCalendars 获取工作日历列表
+func (h *TicketSLAHandler) ListCalendars(c *gin.Context) {
+	if !h.canManage(c) {
+		return
+	}
+
+	calendars, err := h.slaService.ListCalendars()
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, calendars)
+}
+
+// GetCalendar 获取工作日历详情
+func (h *TicketSLAHandler) GetCalendar(c *gin.Context) {
+	if !h.canManage(c) {
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	calendar, err := h.slaService.GetCalendar(id)
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, calendar)
+}
+
+// CreateCalendar 创建工作日历
+func (h *TicketSLAHandler) CreateCalendar(c *gin.Context) {
+	if !h.canManage(c) {
+		return
+	}
+
+	var req services.SaveBusinessCalendarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	calendar, err := h.slaService.CreateCalendar(&req, getUserID(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    calendar,
+	})
+}
+
+// UpdateCalendar 更新工作日历
+func (h *TicketSLAHandler) UpdateCalendar(c *gin.Context) {
+	if !h.canManage(c) {
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	var req services.SaveBusinessCalendarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	calendar, err := h.slaService.UpdateCalendar(id, &req, getUserID(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, calendar)
+}
+
+// DeleteCalendar 删除工作日历
+func (h *TicketSLAHandler) DeleteCalendar(c *gin.Context) {
+	if !h.canManage(c) {
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	if err := h.slaService.DeleteCalendar(id, getUserID(c), c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, gin.H{"message": "删除成功"})
+}
+
+// canManage 检查SLA配置管理权限，无权限时写入响应
+func (h *TicketSLAHandler) canManage(c *gin.Context) bool {
+	if !hasPermission(c, "ticket:sla:manage") {
+		handleForbiddenError(c, "无权限管理SLA")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"strings"
+
+	"info-management-system/internal/middleware"
+	"info-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TicketSurveyHandler 工单满意度调查处理器
+type TicketSurveyHandler struct {
+	surveyService *services.TicketSurveyService
+}
+
+// NewTicketSurveyHandler 创建满意度调查处理器
+func NewTicketSurveyHandler(surveyService *services.TicketSurveyService) *TicketSurveyHandler {
+	return &TicketSurveyHandler{
+		surveyService: surveyService,
+	}
+}
+
+// GetSurvey 通过调查链接获取工单信息，无需登录
+func (h *TicketSurveyHandler) GetSurvey(c *gin.Context) {
+	survey, err := h.surveyService.GetSurvey(c.Param("token"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, survey)
+}
+
+// SubmitSurvey 通过调查链接提交评分，无需登录，每个链接只能提交一次
+func (h *TicketSurveyHandler) SubmitSurvey(c *gin.Context) {
+	var req services.SubmitTicketSurveyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	survey, err := h.surveyService.Submit(c.Param("token"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, survey)
+}
+
+// GetTicketSurveys 获取工单的满意度调查结果
+func (h *TicketSurveyHandler) GetTicketSurveys(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	surveys, err := h.surveyService.TicketSurveys(id, ticketActor(c))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, surveys)
+}
+
+// GetStatistics 按处理人、分类和周期统计满意度
+func (h *TicketSurveyHandler) GetStatistics(c *gin.Context) {
+	if !hasPermission(c, "ticket:statistics") {
+		handleForbiddenError(c, "无权限查看工单统计")
+		return
+	}
+
+	var query services.TicketSurveyStatisticsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	stats, err := h.surveyService.Statistics(&query)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, stats)
+}
+
+// handleError 将满意度调查服务错误映射为HTTP响应
+func (h *TicketSurveyHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "调查不存在":
+		handleNotFoundError(c, err.Error())
+	case err.Error() == "调查已提交":
+		handleConflictError(c, err.Error())
+	case strings.Contains(err.Error(), "失败"):
+		middleware.InternalErrorResponse(c, err)
+	default:
+		middleware.ValidationErrorResponse(c, err.Error(), "")
+	}
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"net/http"
+
+	"info-management-system/internal/middleware"
+	"info-management-system/internal/models"
+	"info-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TicketTemplateHandler 工单模板处理器
+type TicketTemplateHandler struct {
+	templateService *services.TicketTemplateService
+}
+
+// NewTicketTemplateHandler 创建工单模板处理器
+func NewTicketTemplateHandler(templateService *services.TicketTemplateService) *TicketTemplateHandler {
+	return &TicketTemplateHandler{
+		templateService: templateService,
+	}
+}
+
+// GetTemplates 获取当前用户可用的工单模板，模板管理员获取全部模板
+func (h *TicketTemplateHandler) GetTemplates(c *gin.Context) {
+	var query services.TicketTemplateQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	templates, err := h.templateService.ListTemplates(&query, getUserID(c), hasPermission(c, "ticket:template:manage"))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, templates)
+}
+
+// GetAvailableTemplates 获取创建工单时可用的模板，按分类分组
+func (h *TicketTemplateHandler) GetAvailableTemplates(c *gin.Context) {
+	groups, err := h.templateService.AvailableTemplates(getUserID(c))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, groups)
+}
+
+// GetTemplate 获取工单模板详情
+func (h *TicketTemplateHandler) GetTemplate(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	template, err := h.templateService.GetTemplate(id, getUserID(c), hasPermission(c, "ticket:template:manage"))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, template)
+}
+
+// CreateTemplate 创建工单模板，共享模板需要模板管理权限
+func (h *TicketTemplateHandler) CreateTemplate(c *gin.Context) {
+	var req services.CreateTicketTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+	if req.Visibility == models.TemplateVisibilityShared && !hasPermission(c, "ticket:template:manage") {
+		handleForbiddenError(c, "无权创建共享模板")
+		return
+	}
+
+	template, err := h.templateService.CreateTemplate(&req, getUserID(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    template,
+	})
+}
+
+// UpdateTemplate 更新工单模板
+func (h *TicketTemplateHandler) UpdateTemplate(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	var req services.UpdateTicketTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+	canManage := hasPermission(c, "ticket:template:manage")
+	if !canManage && ((req.Visibility != nil && *req.Visibility == models.TemplateVisibilityShared) || req.RoleIDs != nil) {
+		handleForbiddenError(c, "无权共享模板")
+		return
+	}
+
+	template, err := h.templateService.UpdateTemplate(id, &req, getUserID(c), canManage, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, template)
+}
+
+// DeleteTemplate 删除工单模板
+func (h *TicketTemplateHandler) DeleteTemplate(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	err = h.templateService.DeleteTemplate(id, getUserID(c), hasPermission(c, "ticket:template:manage"), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, gin.H{"message": "删除成功"})
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"info-management-system/internal/middleware"
+	"info-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TicketWorkflowHandler 工单流程处理器
+type TicketWorkflowHandler struct {
+	workflowService *services.TicketWorkflowService
+}
+
+// NewTicketWorkflowHandler 创建工单流程处理器
+func NewTicketWorkflowHandler(workflowService *services.TicketWorkflowService) *TicketWorkflowHandler {
+	return &TicketWorkflowHandler{
+		workflowService: workflowService,
+	}
+}
+
+// GetTicketActions 获取当前用户对工单可执行的动作
+func (h *TicketWorkflowHandler) GetTicketActions(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	actions, err := h.workflowService.AvailableActions(id, ticketActor(c))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, actions)
+}
+
+// TransitionTicket 按动作或目标状态执行工单流转
+func (h *TicketWorkflowHandler) TransitionTicket(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	var req services.TicketTransitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	ticket, err := h.workflowService.Transition(id, &req, ticketActor(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, ticket)
+}
+
+// ListWorkflows 获取工单流程列表
+func (h *TicketWorkflowHandler) ListWorkflows(c *gin.Context) {
+	if !hasPermission(c, "ticket:workflow:manage") {
+		handleForbiddenError(c, "无权限管理工单流程")
+		return
+	}
+
+	workflows, err := h.workflowService.ListWorkflows()
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, workflows)
+}
+
+// GetWorkflow 获取工单流程详情
+func (h *TicketWorkflowHandler) GetWorkflow(c *gin.Context) {
+	if !hasPermission(c, "ticket:workflow:manage") {
+		handleForbiddenError(c, "无权限管理工单流程")
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	workflow, err := h.workflowService.GetWorkflow(id)
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, workflow)
+}
+
+// CreateWorkflow 创建工单流程
+func (h *TicketWorkflowHandler) CreateWorkflow(c *gin.Context) {
+	if !hasPermission(c, "ticket:workflow:manage") {
+		handleForbiddenError(c, "无权限管理工单流程")
+		return
+	}
+
+	var req services.SaveTicketWorkflowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	workflow, err := h.workflowService.CreateWorkflow(&req, getUserID(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    workflow,
+	})
+}
+
+// UpdateWorkflow 更新工单流程
+func (h *TicketWorkflowHandler) UpdateWorkflow(c *gin.Context) {
+	if !hasPermission(c, "ticket:workflow:manage") {
+		handleForbiddenError(c, "无权限管理工单流程")
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	var req services.SaveTicketWorkflowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	workflow, err := h.workflowService.UpdateWorkflow(id, &req, getUserID(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, workflow)
+}
+
+// DeleteWorkflow 删除工单流程
+func (h *TicketWorkflowHandler) DeleteWorkflow(c *gin.Context) {
+	if !hasPermission(c, "ticket:workflow:manage") {
+		handleForbiddenError(c, "无权限管理工单流程")
+		return
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	if err := h.workflowService.DeleteWorkflow(id, getUserID(c), c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		handleTicketWorkflowError(c, err)
+		return
+	}
+
+	middleware.Success(c, gin.H{"message": "删除成功"})
+}
+
+// ticketActor 当前请求的工单流转操作者，权限按请求上下文判断
+func ticketActor(c *gin.Context) *services.TicketActor {
+	return &services.TicketActor{
+		UserID:        getUserID(c),
+		HasPermission: func(permission string) bool { return hasPermission(c, permission) },
+	}
+}
+
+// ticketWorkflowErrorStatus 工单流程、SLA、分配规则、分类与工单关系错误对应的HTTP状态码
+func ticketWorkflowErrorStatus(err error) int {
+	switch msg := err.Error(); {
+	case strings.HasPrefix(msg, "工单不存在"), strings.HasSuffix(msg, "流程不存在"),
+		strings.HasSuffix(msg, "日历不存在"), strings.HasSuffix(msg, "策略不存在"),
+		strings.HasSuffix(msg, "规则不存在"), strings.HasSuffix(msg, "分类不存在"), msg == "用户不存在", msg == "依赖不存在",
+		strings.HasPrefix(msg, "工单模板不存在"), msg == "角色不存在":
+		return http.StatusNotFound
+	case strings.HasPrefix(msg, "无权"):
+		return http.StatusForbidden
+	case strings.Contains(msg, "已存在"), strings.Contains(msg, "已被修改"), strings.Contains(msg, "仍被"):
+		return http.StatusConflict
+	case strings.Contains(msg, "失败"):
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// handleTicketWorkflowError 将工单流程、SLA与分配规则服务错误映射为HTTP响应
+func handleTicketWorkflowError(c *gin.Context, err error) {
+	switch ticketWorkflowErrorStatus(err) {
+	case http.StatusNotFound:
+		handleNotFoundError(c, err.Error())
+	case http.StatusForbidden:
+		handleForbiddenError(c, err.Error())
+	case http.StatusConflict:
+		handleConflictError(c, err.Error())
+	case http.StatusInternalServerError:
+		middleware.InternalErrorResponse(c, err)
+	default:
+		middleware.ValidationErrorResponse(c, err.Error(), "")
+	}
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"info-management-system/internal/middleware"
+	"info-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TicketWorklogHandler 工单工时处理器
+type TicketWorklogHandler struct {
+	worklogService *services.TicketWorklogService
+}
+
+// NewTicketWorklogHandler 创建工时处理器
+func NewTicketWorklogHandler(worklogService *services.TicketWorklogService) *TicketWorklogHandler {
+	return &TicketWorklogHandler{
+		worklogService: worklogService,
+	}
+}
+
+// GetWorklogs 获取工单的工时记录与估时
+func (h *TicketWorklogHandler) GetWorklogs(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	worklogs, err := h.worklogService.ListWorklogs(id, ticketActor(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, worklogs)
+}
+
+// CreateWorklog 登记工时
+func (h *TicketWorklogHandler) CreateWorklog(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	var req services.CreateTicketWorklogRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	worklog, err := h.worklogService.CreateWorklog(id, &req, ticketActor(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    worklog,
+	})
+}
+
+// UpdateWorklog 修改工时记录
+func (h *TicketWorklogHandler) UpdateWorklog(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	var req services.UpdateTicketWorklogRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	worklog, err := h.worklogService.UpdateWorklog(id, &req, ticketActor(c), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, worklog)
+}
+
+// DeleteWorklog 删除工时记录
+func (h *TicketWorklogHandler) DeleteWorklog(c *gin.Context) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	if err := h.worklogService.DeleteWorklog(id, ticketActor(c), c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, gin.H{"message": "工时记录已删除"})
+}
+
+// GetReport 按工单、用户、分类和日期汇总工时
+func (h *TicketWorklogHandler) GetReport(c *gin.Context) {
+	query, ok := h.bindReportQuery(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.worklogService.Report(query)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	middleware.Success(c, report)
+}
+
+// ExportReport 按报表条件导出工时明细
+func (h *TicketWorklogHandler) ExportReport(c *gin.Context) {
+	query, ok := h.bindReportQuery(c)
+	if !ok {
+		return
+	}
+
+	worklogs, err := h.worklogService.ReportWorklogs(query)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	csvContent := csvLine([]string{"ID", "工单ID", "工单标题", "分类", "工作人", "工作日期", "工时(小时)", "是否计费", "说明", "登记时间"})
+	for _, worklog := range worklogs {
+		billable := "否"
+		if worklog.Billable {
+			billable = "是"
+		}
+		csvContent += csvLine([]string{
+			strconv.FormatUint(uint64(worklog.ID), 10),
+			strconv.FormatUint(uint64(worklog.TicketID), 10),
+			worklog.Ticket.Title,
+			worklog.Ticket.Category,
+			worklog.User.Username,
+			worklog.WorkDate.Format("2006-01-02"),
+			formatWorkHours(int64(worklog.Minutes)),
+			billable,
+			worklog.Note,
+			worklog.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	// 添加BOM以支持Excel正确显示中文
+	content := append([]byte{0xEF, 0xBB, 0xBF}, []byte(csvContent)...)
+	filename := fmt.Sprintf("ticket_worklogs_%s.csv", time.Now().Format("20060102_150405"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", content)
+}
+
+// bindReportQuery 校验报表权限并解析查询参数
+func (h *TicketWorklogHandler) bindReportQuery(c *gin.Context) (*services.TicketWorklogReportQuery, bool) {
+	if !hasPermission(c, services.TicketWorklogReportPermission) {
+		handleForbiddenError(c, "无权限查看工时报表")
+		return nil, false
+	}
+
+	var query services.TicketWorklogReportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return nil, false
+	}
+	return &query, true
+}
+
+// handleError 将工时服务错误映射为HTTP响应
+func (h *TicketWorklogHandler) handleError(c *gin.Context, err error) {
+	if err.Error() == "工时记录不存在" {
+		handleNotFoundError(c, err.Error())
+		return
+	}
+	handleTicketWorkflowError(c, err)
+}
+
+// formatWorkHours 把分钟数格式化为保留两位小数的小时数，用于导出
+func formatWorkHours(minutes int64) string {
+	return strconv.FormatFloat(float64(minutes)/60, 'f', 2, 64)
+}
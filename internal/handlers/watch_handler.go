@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"strings"
+
+	"info-management-system/internal/middleware"
+	"info-management-system/internal/models"
+	"info-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WatchHandler 关注处理器
+type WatchHandler struct {
+	watchService *services.WatchService
+}
+
+// NewWatchHandler 创建关注处理器
+func NewWatchHandler(watchService *services.WatchService) *WatchHandler {
+	return &WatchHandler{
+		watchService: watchService,
+	}
+}
+
+// GetRecordWatchers 获取记录的关注者
+func (h *WatchHandler) GetRecordWatchers(c *gin.Context) {
+	h.handleWatch(c, models.WatchEntityRecord, h.watchService.GetWatchers)
+}
+
+// WatchRecord 关注记录
+func (h *WatchHandler) WatchRecord(c *gin.Context) {
+	h.handleWatch(c, models.WatchEntityRecord, h.watchService.Watch)
+}
+
+// UnwatchRecord 取消关注记录
+func (h *WatchHandler) UnwatchRecord(c *gin.Context) {
+	h.handleWatch(c, models.WatchEntityRecord, h.watchService.Unwatch)
+}
+
+// GetTicketWatchers 获取工单的关注者
+func (h *WatchHandler) GetTicketWatchers(c *gin.Context) {
+	h.handleWatch(c, models.WatchEntityTicket, h.watchService.GetWatchers)
+}
+
+// WatchTicket 关注工单
+func (h *WatchHandler) WatchTicket(c *gin.Context) {
+	h.handleWatch(c, models.WatchEntityTicket, h.watchService.Watch)
+}
+
+// UnwatchTicket 取消关注工单
+func (h *WatchHandler) UnwatchTicket(c *gin.Context) {
+	h.handleWatch(c, models.WatchEntityTicket, h.watchService.Unwatch)
+}
+
+// handleWatch 解析实体ID并执行关注相关操作，返回最新的关注者列表
+func (h *WatchHandler) handleWatch(c *gin.Context, entityType string,
+	action func(string, uint, *services.LinkAccessScope) (*services.WatcherListResponse, error)) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return
+	}
+
+	watchers, err := action(entityType, id, linkAccessScope(c))
+	if err != nil {
+		switch {
+		case strings.HasSuffix(err.Error(), "不存在或无权访问"):
+			handleNotFoundError(c, err.Error())
+		case strings.Contains(err.Error(), "失败"):
+			middleware.InternalErrorResponse(c, err)
+		default:
+			middleware.ValidationErrorResponse(c, err.Error(), "")
+		}
+		return
+	}
+
+	middleware.Success(c, watchers)
+}
+
+// GetNotificationPreference 获取当前用户的通知偏好
+func (h *WatchHandler) GetNotificationPreference(c *gin.Context) {
+	preference, err := h.watchService.GetPreference(getUserID(c))
+	if err != nil {
+		middleware.InternalErrorResponse(c, err)
+		return
+	}
+
+	middleware.Success(c, preference)
+}
+
+// UpdateNotificationPreference 更新当前用户的通知偏好
+func (h *WatchHandler) UpdateNotificationPreference(c *gin.Context) {
+	var req services.UpdateNotificationPreferenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.ValidationErrorResponse(c, "参数验证失败", err.Error())
+		return
+	}
+
+	preference, err := h.watchService.UpdatePreference(getUserID(c), &req)
+	if err != nil {
+		if strings.Contains(err.Error(), "失败") {
+			middleware.InternalErrorResponse(c, err)
+			return
+		}
+		middleware.ValidationErrorResponse(c, err.Error(), "")
+		return
+	}
+
+	middleware.Success(c, preference)
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+// 收件处理结果
+const (
+	InboundEmailCreated   = "created"   // 已创建工单
+	InboundEmailCommented = "commented" // 已作为工单回复
+	InboundEmailIgnored   = "ignored"   // 自动回复、退信或邮件循环，已忽略
+	InboundEmailRejected  = "rejected"  // 发件人未注册或无权回复
+	InboundEmailFailed    = "failed"    // 处理失败
+)
+
+// InboundEmail 收到的邮件及其处理结果，按 Message-ID 去重
+type InboundEmail struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	MessageID   string    `json:"message_id" gorm:"not null;size:500;uniqueIndex"`
+	Source      string    `json:"source" gorm:"size:20"` // maildir、smtp
+	FromAddress string    `json:"from_address" gorm:"size:255;index"`
+	Subject     string    `json:"subject" gorm:"size:500"`
+	Status      string    `json:"status" gorm:"not null;size:20;index"`
+	Reason      string    `json:"reason" gorm:"size:500"`
+	UserID      *uint     `json:"user_id" gorm:"index"`
+	TicketID    *uint     `json:"ticket_id" gorm:"index"`
+	CommentID   *uint     `json:"comment_id"`
+	Attachments int       `json:"attachments"`
+	ReceivedAt  time.Time `json:"received_at" gorm:"index"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+)
+
+// 关联实体类型
+const (
+	LinkEntityRecord = "record" // 记录
+	LinkEntityTicket = "ticket" // 工单
+	LinkEntityFile   = "file"   // 文件
+)
+
+// 关联类型
+const (
+	LinkTypeRelatesTo    = "relates_to"    // 相关
+	LinkTypeCausedBy     = "caused_by"     // 由...引起
+	LinkTypeAttachmentOf = "attachment_of" // 是...的附件
+	LinkTypeDuplicateOf  = "duplicate_of"  // 是...的重复项
+	LinkTypeBlocks       = "blocks"        // 阻塞（仅工单之间，由工单依赖接口维护）
+)
+
+// EntityLink 实体关联模型（记录、工单、文件之间的类型化关联）
+type EntityLink struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	SourceType  string    `json:"source_type" gorm:"not null;size:20;uniqueIndex:idx_entity_link_unique;index:idx_entity_link_source"`
+	SourceID    uint      `json:"source_id" gorm:"not null;uniqueIndex:idx_entity_link_unique;index:idx_entity_link_source"`
+	TargetType  string    `json:"target_type" gorm:"not null;size:20;uniqueIndex:idx_entity_link_unique;index:idx_entity_link_target"`
+	TargetID    uint      `json:"target_id" gorm:"not null;uniqueIndex:idx_entity_link_unique;index:idx_entity_link_target"`
+	LinkType    string    `json:"link_type" gorm:"not null;size:30;uniqueIndex:idx_entity_link_unique"`
+	Description string    `json:"description" gorm:"size:500"`
+	CreatedBy   uint      `json:"created_by" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// 关联关系
+	Creator User `json:"creator" gorm:"foreignKey:CreatedBy"`
+}
+
+// IsValidLinkEntityType 检查关联实体类型是否有效
+func IsValidLinkEntityType(entityType string) bool {
+	switch entityType {
+	case LinkEntityRecord, LinkEntityTicket, LinkEntityFile:
+		return true
+	}
+	return false
+}
+
+// IsValidLinkType 检查关联类型是否有效
+func IsValidLinkType(linkType string) bool {
+	switch linkType {
+	case LinkTypeRelatesTo, LinkTypeCausedBy, LinkTypeAttachmentOf, LinkTypeDuplicateOf:
+		return true
+	}
+	return false
+}
@@ -42,14 +42,88 @@ func (j *JSONB) Scan(value interface{}) error {
 
 // RecordType 记录类型模型
 type RecordType struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	Name        string    `json:"name" gorm:"uniqueIndex;not null;size:100"`
-	DisplayName string    `json:"display_name" gorm:"not null;size:200"`
-	Schema      JSONB     `json:"schema" gorm:"type:text"`
-	TableName   string    `json:"table_name" gorm:"not null;size:100"`
-	IsActive    bool      `json:"is_active" gorm:"default:true"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID            uint        `json:"id" gorm:"primaryKey"`
+	Name          string      `json:"name" gorm:"uniqueIndex;not null;size:100"`
+	DisplayName   string      `json:"display_name" gorm:"not null;size:200"`
+	Schema        JSONB       `json:"schema" gorm:"type:text"`
+	SchemaVersion int         `json:"schema_version" gorm:"default:1"`
+	Workflow      JSONB       `json:"workflow" gorm:"type:text"`    // 状态流转定义，为空时使用默认流程
+	UniqueKeys    StringSlice `json:"unique_keys" gorm:"type:text"` // 唯一键字段，为空表示不做重复校验
+	TableName     string      `json:"table_name" gorm:"not null;size:100"`
+	IsActive      bool        `json:"is_active" gorm:"default:true"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
+
+// RecordTypeSchemaVersion 记录类型Schema历史版本
+type RecordTypeSchemaVersion struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	RecordTypeID uint      `json:"record_type_id" gorm:"not null;uniqueIndex:idx_record_type_schema_version"`
+	Version      int       `json:"version" gorm:"not null;uniqueIndex:idx_record_type_schema_version"`
+	Schema       JSONB     `json:"schema" gorm:"type:text"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// RecordMigrationJob 记录数据迁移任务
+type RecordMigrationJob struct {
+	ID               uint       `json:"id" gorm:"primaryKey"`
+	RecordTypeID     uint       `json:"record_type_id" gorm:"not null;index"`
+	RecordType       string     `json:"record_type" gorm:"not null;size:100"`
+	SchemaVersion    int        `json:"schema_version"`                     // 创建任务时的Schema版本
+	Operations       JSONB      `json:"operations" gorm:"type:text"`        // 迁移操作列表
+	DryRun           bool       `json:"dry_run" gorm:"default:false"`       // 试运行，不写入数据
+	Status           string     `json:"status" gorm:"not null;size:50"`     // pending, processing, completed, failed
+	Progress         int        `json:"progress" gorm:"default:0"`          // 进度百分比
+	TotalRecords     int        `json:"total_records" gorm:"default:0"`     // 总记录数
+	ProcessedRecords int        `json:"processed_records" gorm:"default:0"` // 已处理记录数
+	SucceededRecords int        `json:"succeeded_records" gorm:"default:0"` // 迁移成功记录数
+	FailedRecords    int        `json:"failed_records" gorm:"default:0"`    // 迁移失败记录数
+	ErrorMessage     string     `json:"error_message" gorm:"type:text"`     // 任务级错误信息
+	StartedAt        *time.Time `json:"started_at"`
+	CompletedAt      *time.Time `json:"completed_at"`
+	CreatedBy        uint       `json:"created_by" gorm:"not null"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// RecordMigrationError 迁移任务中单条记录的错误
+type RecordMigrationError struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	JobID     uint      `json:"job_id" gorm:"not null;index"`
+	RecordID  uint      `json:"record_id" gorm:"not null"`
+	Error     string    `json:"error" gorm:"type:text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RecordBulkJob 按筛选条件批量操作记录的任务
+type RecordBulkJob struct {
+	ID               uint       `json:"id" gorm:"primaryKey"`
+	Operation        string     `json:"operation" gorm:"not null;size:50"`  // set_field, unset_field, add_tags, remove_tags, set_status, delete
+	Filter           JSONB      `json:"filter" gorm:"type:text"`            // 记录筛选条件
+	Params           JSONB      `json:"params" gorm:"type:text"`            // 操作参数
+	HasAllPermission bool       `json:"-" gorm:"default:false"`             // 创建任务时是否拥有全部记录权限
+	MaxRecordID      uint       `json:"-"`                                  // 创建任务时匹配的最大记录ID，之后新增的记录不受影响
+	Status           string     `json:"status" gorm:"not null;size:50"`     // pending, processing, completed, failed, cancelled
+	Progress         int        `json:"progress" gorm:"default:0"`          // 进度百分比
+	TotalRecords     int        `json:"total_records" gorm:"default:0"`     // 匹配记录数
+	ProcessedRecords int        `json:"processed_records" gorm:"default:0"` // 已处理记录数
+	SucceededRecords int        `json:"succeeded_records" gorm:"default:0"` // 成功记录数
+	FailedRecords    int        `json:"failed_records" gorm:"default:0"`    // 失败记录数
+	ErrorMessage     string     `json:"error_message" gorm:"type:text"`     // 任务级错误信息
+	StartedAt        *time.Time `json:"started_at"`
+	CompletedAt      *time.Time `json:"completed_at"`
+	CreatedBy        uint       `json:"created_by" gorm:"not null;index"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// RecordBulkJobError 批量操作任务中单条记录的错误
+type RecordBulkJobError struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	JobID     uint      `json:"job_id" gorm:"not null;index"`
+	RecordID  uint      `json:"record_id" gorm:"not null"`
+	Error     string    `json:"error" gorm:"type:text"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 // StringSlice 自定义字符串切片类型，用于数据库存储
@@ -85,22 +159,41 @@ func (s *StringSlice) Scan(value interface{}) error {
 
 // Record 记录模型
 type Record struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Type      string         `json:"type" gorm:"not null;size:100;index"`
-	Title     string         `json:"title" gorm:"not null;size:500"`
-	Content   JSONB          `json:"content" gorm:"type:text"`
-	Tags      StringSlice    `json:"tags" gorm:"type:text"`
-	Status    string         `json:"status" gorm:"not null;size:20;default:'draft';index"`
-	CreatedBy uint           `json:"created_by" gorm:"not null;index"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	Version   int            `json:"version" gorm:"default:1"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	Type       string         `json:"type" gorm:"not null;size:100;index"`
+	Title      string         `json:"title" gorm:"not null;size:500"`
+	Content    JSONB          `json:"content" gorm:"type:text"`
+	Tags       StringSlice    `json:"tags" gorm:"type:text"`
+	Status     string         `json:"status" gorm:"not null;size:20;default:'draft';index"`
+	ReviewerID *uint          `json:"reviewer_id" gorm:"index"`
+	PublishAt  *time.Time     `json:"publish_at" gorm:"index"`                   // 定时发布时间
+	ExpireAt   *time.Time     `json:"expire_at" gorm:"index"`                    // 定时下线（归档）时间
+	UniqueKey  string         `json:"unique_key,omitempty" gorm:"size:64;index"` // 唯一键字段值的摘要，未配置唯一键或字段缺失时为空
+	CreatedBy  uint           `json:"created_by" gorm:"not null;index"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	Version    int            `json:"version" gorm:"default:1"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// 关联关系
 	Creator User `json:"creator" gorm:"foreignKey:CreatedBy"`
 }
 
+// RecordStatusHistory 记录状态变更历史
+type RecordStatusHistory struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	RecordID   uint      `json:"record_id" gorm:"not null;index"`
+	FromStatus string    `json:"from_status" gorm:"size:20"`
+	ToStatus   string    `json:"to_status" gorm:"not null;size:20"`
+	Action     string    `json:"action" gorm:"size:50"`
+	Comment    string    `json:"comment" gorm:"type:text"`
+	UserID     uint      `json:"user_id" gorm:"not null"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	// 关联关系
+	User User `json:"user" gorm:"foreignKey:UserID"`
+}
+
 // AuditLog 审计日志模型
 type AuditLog struct {
 	ID           uint      `json:"id" gorm:"primaryKey"`
@@ -136,6 +229,19 @@ type File struct {
 	Uploader User `json:"uploader" gorm:"foreignKey:UploadedBy"`
 }
 
+// RecordFile 记录附件关联模型（记录与文件的关联表）
+type RecordFile struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	RecordID  uint      `json:"record_id" gorm:"not null;uniqueIndex:idx_record_file_unique"`
+	FileID    uint      `json:"file_id" gorm:"not null;index;uniqueIndex:idx_record_file_unique"`
+	FieldName string    `json:"field_name" gorm:"size:100;uniqueIndex:idx_record_file_unique"` // 对应Schema中的file/files字段，为空表示普通附件
+	CreatedBy uint      `json:"created_by" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// 关联关系
+	File File `json:"file" gorm:"foreignKey:FileID"`
+}
+
 // Config 配置模型
 type Config struct {
 	ID          uint      `json:"id" gorm:"primaryKey"`
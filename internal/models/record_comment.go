@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecordComment 记录评论，内容为Markdown，ParentID 不为空时为回复
+type RecordComment struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	RecordID uint   `json:"record_id" gorm:"not null;index"`
+	ParentID *uint  `json:"parent_id" gorm:"index"`
+	Content  string `json:"content" gorm:"type:text;not null"`
+	UserID   uint   `json:"user_id" gorm:"not null;index"`
+
+	// 系统字段
+	EditedAt  *time.Time     `json:"edited_at"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// 关联关系
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// RecordCommentMention 评论中 @ 提及的用户
+type RecordCommentMention struct {
+	CommentID uint `json:"comment_id" gorm:"primaryKey"`
+	UserID    uint `json:"user_id" gorm:"primaryKey;index"`
+}
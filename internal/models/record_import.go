@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+)
+
+// RecordImportMapping 已保存的导入列映射
+type RecordImportMapping struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	Name       string    `json:"name" gorm:"not null;size:200"`
+	RecordType string    `json:"record_type" gorm:"not null;size:100;index"`
+	Mapping    JSONB     `json:"mapping" gorm:"type:text"` // 表头 -> 目标字段（title、tags 或 Schema 字段名）
+	CreatedBy  uint      `json:"created_by" gorm:"not null;index"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// RecordImportJob 记录导入任务
+type RecordImportJob struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	RecordType      string     `json:"record_type" gorm:"not null;size:100;index"`
+	FileName        string     `json:"file_name" gorm:"not null;size:255"` // 上传的原始文件名
+	FilePath        string     `json:"-" gorm:"not null;size:500"`         // 上传文件存储路径
+	Mapping         JSONB      `json:"mapping" gorm:"type:text"`           // 本次使用的列映射
+	DryRun          bool       `json:"dry_run" gorm:"default:false"`       // 试运行，只校验不写入
+	OnConflict      string     `json:"on_conflict" gorm:"size:20"`         // 唯一键冲突策略：reject 或 upsert
+	SourceJobID     *uint      `json:"source_job_id"`                      // 由试运行任务确认提交时的来源任务
+	Status          string     `json:"status" gorm:"not null;size:50"`     // pending, processing, completed, failed
+	Progress        int        `json:"progress" gorm:"default:0"`          // 进度百分比
+	TotalRows       int        `json:"total_rows" gorm:"default:0"`        // 数据行数
+	ProcessedRows   int        `json:"processed_rows" gorm:"default:0"`    // 已处理行数
+	SucceededRows   int        `json:"succeeded_rows" gorm:"default:0"`    // 成功行数
+	FailedRows      int        `json:"failed_rows" gorm:"default:0"`       // 失败行数
+	ErrorReportPath string     `json:"-" gorm:"size:500"`                  // 错误报告文件路径
+	ErrorMessage    string     `json:"error_message" gorm:"type:text"`     // 任务级错误信息
+	StartedAt       *time.Time `json:"started_at"`
+	CompletedAt     *time.Time `json:"completed_at"`
+	CreatedBy       uint       `json:"created_by" gorm:"not null;index"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
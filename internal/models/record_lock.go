@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// RecordLock 记录编辑锁（建议性锁），每条记录最多一把，过期后自动失效
+type RecordLock struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	RecordID   uint      `json:"record_id" gorm:"not null;uniqueIndex"`
+	UserID     uint      `json:"user_id" gorm:"not null;index"`
+	AcquiredAt time.Time `json:"acquired_at" gorm:"not null"`
+	ExpiresAt  time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+
+	// 关联关系
+	User User `json:"user" gorm:"foreignKey:UserID"`
+}
+
+// IsExpired 检查锁是否已过期
+func (l *RecordLock) IsExpired(now time.Time) bool {
+	return !l.ExpiresAt.After(now)
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+)
+
+// 记录模板可见范围
+const (
+	TemplateVisibilityPersonal = "personal" // 仅创建者可用
+	TemplateVisibilityShared   = "shared"   // 共享给指定角色，未指定角色时所有用户可用
+)
+
+// RecordTemplate 记录模板，保存某记录类型的预填内容、标题模式与默认标签
+type RecordTemplate struct {
+	ID               uint        `json:"id" gorm:"primaryKey"`
+	RecordTypeID     uint        `json:"record_type_id" gorm:"not null;index"`
+	Name             string      `json:"name" gorm:"not null;size:200"`
+	Description      string      `json:"description" gorm:"size:500"`
+	TitlePattern     string      `json:"title_pattern" gorm:"size:500"` // 支持 {{date}}、{{time}}、{{datetime}}、{{user}}、{{type}} 占位符
+	Content          JSONB       `json:"content" gorm:"type:text"`
+	Tags             StringSlice `json:"tags" gorm:"type:text"`
+	Visibility       string      `json:"visibility" gorm:"not null;size:20;default:'personal'"`
+	SchemaVersion    int         `json:"schema_version"`                     // 最近一次校验时的Schema版本
+	IsValid          bool        `json:"is_valid" gorm:"default:true"`       // 内容是否符合当前Schema
+	ValidationErrors StringSlice `json:"validation_errors" gorm:"type:text"` // Schema变更后的校验错误
+	CreatedBy        uint        `json:"created_by" gorm:"not null;index"`
+	CreatedAt        time.Time   `json:"created_at"`
+	UpdatedAt        time.Time   `json:"updated_at"`
+
+	// 关联关系
+	RecordType RecordType `json:"-" gorm:"foreignKey:RecordTypeID"`
+	Creator    User       `json:"-" gorm:"foreignKey:CreatedBy"`
+}
+
+// RecordTemplateRole 共享模板可用的角色
+type RecordTemplateRole struct {
+	TemplateID uint `json:"template_id" gorm:"primaryKey"`
+	RoleID     uint `json:"role_id" gorm:"primaryKey;index"`
+}
+
+// IsValidTemplateVisibility 检查模板可见范围是否有效
+func IsValidTemplateVisibility(visibility string) bool {
+	return visibility == TemplateVisibilityPersonal || visibility == TemplateVisibilityShared
+}
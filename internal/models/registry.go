@@ -0,0 +1,83 @@
+package models
+
+// AllModels 返回需要自动迁移的全部模型，按依赖顺序排列
+func AllModels() []interface{} {
+	return []interface{}{
+		&User{},
+		&Role{},
+		&Permission{},
+		&RolePermission{},
+		&UserRole{},
+		&UserPermission{},
+		&RecordType{},
+		&Record{},
+		&RecordStatusHistory{},
+		&RecordLock{},
+		&RecordTypeSchemaVersion{},
+		&RecordTemplate{},
+		&RecordTemplateRole{},
+		&RecordComment{},
+		&RecordCommentMention{},
+		&RecordMigrationJob{},
+		&RecordMigrationError{},
+		&RecordImportMapping{},
+		&RecordImportJob{},
+		&RecordBulkJob{},
+		&RecordBulkJobError{},
+		&AuditLog{},
+		&File{},
+		&RecordFile{},
+		&ExportTemplate{},
+		&ExportTask{},
+		&ExportFile{},
+		&NotificationTemplate{},
+		&Notification{},
+		&NotificationChannel{},
+		&AlertRule{},
+		&AlertEvent{},
+		&NotificationQueue{},
+		&Ticket{},
+		&TicketComment{},
+		&TicketAttachment{},
+		&TicketHistory{},
+		&TicketCategory{},
+		&TicketFieldValue{},
+		&TicketTemplate{},
+		&TicketTemplateRole{},
+		&TicketSurvey{},
+		&TicketWorklog{},
+		&TicketWorkflow{},
+		&BusinessCalendar{},
+		&BusinessHoliday{},
+		&TicketSLAPolicy{},
+		&TicketSLAEscalation{},
+		&TicketSLA{},
+		&TicketAssignmentRule{},
+		&TicketAssignmentMember{},
+		&AssigneeProfile{},
+		&ScheduledJob{},
+		&ScheduledJobRun{},
+		&InboundEmail{},
+		&EntityLink{},
+		&Tag{},
+		&EntityTag{},
+		&Watcher{},
+		&NotificationPreference{},
+		&AIConfig{},
+		&AIChatSession{},
+		&AIChatMessage{},
+		&AITask{},
+		&AIUsageStats{},
+		&AIHealthCheck{},
+		&SystemConfig{},
+		&SystemConfigHistory{},
+		&Announcement{},
+		&AnnouncementView{},
+		&SystemHealth{},
+		&SystemLog{},
+		&SystemMetrics{},
+		&SystemMaintenance{},
+		&APIToken{},
+		&APITokenUsageLog{},
+	}
+}
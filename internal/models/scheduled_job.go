@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+)
+
+// 后台任务运行状态
+const (
+	JobRunStatusRunning = "running"
+	JobRunStatusSuccess = "success"
+	JobRunStatusFailed  = "failed"
+)
+
+// 后台任务触发方式
+const (
+	JobTriggerSchedule = "schedule" // 按间隔自动运行
+	JobTriggerManual   = "manual"   // 管理员手动触发
+)
+
+// ScheduledJob 后台任务，多个实例通过 LockedBy/LockedUntil 保证同一时刻只有一个实例运行该任务
+type ScheduledJob struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	Name            string     `json:"name" gorm:"not null;size:100;uniqueIndex"`
+	Description     string     `json:"description" gorm:"size:500"`
+	IntervalSeconds int        `json:"interval_seconds" gorm:"not null"`
+	Paused          bool       `json:"paused" gorm:"default:false"`
+	PausedBy        *uint      `json:"paused_by"`
+	LockedBy        string     `json:"locked_by" gorm:"size:200"` // 正在运行该任务的实例
+	LockedUntil     *time.Time `json:"locked_until"`
+	NextRunAt       *time.Time `json:"next_run_at" gorm:"index"`
+	LastRunAt       *time.Time `json:"last_run_at"`
+	LastStatus      string     `json:"last_status" gorm:"size:20"`
+	LastMessage     string     `json:"last_message" gorm:"size:1000"`
+	LastError       string     `json:"last_error" gorm:"type:text"`
+	LastDurationMs  int64      `json:"last_duration_ms"`
+	RunCount        int64      `json:"run_count"`
+	FailureCount    int64      `json:"failure_count"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// ScheduledJobRun 后台任务运行日志
+type ScheduledJobRun struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	JobID       uint       `json:"job_id" gorm:"not null;index"`
+	JobName     string     `json:"job_name" gorm:"not null;size:100"`
+	Instance    string     `json:"instance" gorm:"size:200"`
+	Trigger     string     `json:"trigger" gorm:"column:trigger_type;size:20"`
+	TriggeredBy *uint      `json:"triggered_by"`
+	Status      string     `json:"status" gorm:"size:20;index"`
+	Message     string     `json:"message" gorm:"size:1000"`
+	Error       string     `json:"error" gorm:"type:text"`
+	StartedAt   time.Time  `json:"started_at" gorm:"index"`
+	FinishedAt  *time.Time `json:"finished_at"`
+	DurationMs  int64      `json:"duration_ms"`
+}
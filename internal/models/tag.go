@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+)
+
+// 可打标签的实体类型
+const (
+	TagEntityRecord = "record" // 记录
+	TagEntityTicket = "ticket" // 工单
+)
+
+// Tag 标签模型
+type Tag struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	Name           string    `json:"name" gorm:"not null;size:50"`                   // 显示名称
+	NormalizedName string    `json:"normalized_name" gorm:"not null;size:50;unique"` // 规范化名称（去除多余空白并转小写），用于唯一性和精确匹配
+	Color          string    `json:"color" gorm:"size:20"`
+	Description    string    `json:"description" gorm:"size:500"`
+	CreatedBy      uint      `json:"created_by"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// EntityTag 实体与标签的关联
+type EntityTag struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	TagID      uint      `json:"tag_id" gorm:"not null;uniqueIndex:idx_entity_tag_unique;index"`
+	EntityType string    `json:"entity_type" gorm:"not null;size:20;uniqueIndex:idx_entity_tag_unique;index:idx_entity_tag_entity"`
+	EntityID   uint      `json:"entity_id" gorm:"not null;uniqueIndex:idx_entity_tag_unique;index:idx_entity_tag_entity"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// IsValidTagEntityType 检查标签实体类型是否有效
+func IsValidTagEntityType(entityType string) bool {
+	return entityType == TagEntityRecord || entityType == TagEntityTicket
+}
@@ -56,16 +56,27 @@ type Ticket struct {
 	CreatorID  uint  `json:"creator_id" gorm:"not null;index"`
 	AssigneeID *uint `json:"assignee_id" gorm:"index"`
 	
+	// 工单关系
+	ParentID     *uint `json:"parent_id" gorm:"index"`      // 父工单
+	MergedIntoID *uint `json:"merged_into_id" gorm:"index"` // 合并到的目标工单，合并后本工单关闭
+	
 	// 分类和标签
 	Category string      `json:"category" gorm:"size:100;index"`
 	Tags     StringSlice `json:"tags" gorm:"type:text"`
 	
+	// 自定义字段值，按工单类型与分类对应的字段定义校验
+	CustomFields JSONB `json:"custom_fields" gorm:"type:text"`
+	
 	// 时间管理
 	DueDate     *time.Time `json:"due_date"`
 	ResolvedAt  *time.Time `json:"resolved_at"`
 	ClosedAt    *time.Time `json:"closed_at"`
 	ProcessingStartedAt *time.Time `json:"processing_started_at"` // 开始处理时间
 	
+	// 工时估算（分钟），剩余估时随工时登记自动扣减
+	OriginalEstimate  *int `json:"original_estimate"`
+	RemainingEstimate *int `json:"remaining_estimate"`
+	
 	// 自动处理配置
 	AutoAssignRole string `json:"auto_assign_role" gorm:"size:50"` // 自动分配角色
 	ProcessingTimeout int `json:"processing_timeout" gorm:"default:24"` // 处理超时时间（小时）
@@ -146,14 +157,21 @@ type TicketHistory struct {
 	User   User   `json:"user" gorm:"foreignKey:UserID"`
 }
 
-// TicketTemplate 工单模板模型
+// TicketTemplate 工单模板模型，保存创建工单时的预填内容；可见范围与记录模板相同
 type TicketTemplate struct {
-	ID          uint       `json:"id" gorm:"primaryKey"`
-	Name        string     `json:"name" gorm:"not null;size:200"`
-	Description string     `json:"description" gorm:"size:500"`
-	Type        TicketType `json:"type" gorm:"not null;size:20"`
-	Template    JSONB      `json:"template" gorm:"type:text"`
-	IsActive    bool       `json:"is_active" gorm:"default:true"`
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	Name         string         `json:"name" gorm:"not null;size:200"`
+	Description  string         `json:"description" gorm:"size:500"`
+	Type         TicketType     `json:"type" gorm:"not null;size:20"`
+	Priority     TicketPriority `json:"priority" gorm:"size:20"`
+	Category     string         `json:"category" gorm:"size:100;index"`
+	TitlePattern string         `json:"title_pattern" gorm:"size:500"` // 支持 {{date}}、{{time}}、{{datetime}}、{{user}}、{{type}} 占位符
+	Content      string         `json:"content" gorm:"type:text"`      // 预填的工单描述
+	CustomFields JSONB          `json:"custom_fields" gorm:"type:text"`
+	Tags         StringSlice    `json:"tags" gorm:"type:text"`
+	Checklist    StringSlice    `json:"checklist" gorm:"type:text"` // 创建工单时写入工单元数据的检查项
+	Visibility   string         `json:"visibility" gorm:"not null;size:20;default:'personal'"`
+	IsActive     bool           `json:"is_active" gorm:"default:true"`
 	
 	// 关联用户
 	CreatedBy uint `json:"created_by" gorm:"not null;index"`
@@ -167,6 +185,12 @@ type TicketTemplate struct {
 	Creator User `json:"creator" gorm:"foreignKey:CreatedBy"`
 }
 
+// TicketTemplateRole 共享工单模板可用的角色
+type TicketTemplateRole struct {
+	TemplateID uint `json:"template_id" gorm:"primaryKey"`
+	RoleID     uint `json:"role_id" gorm:"primaryKey;index"`
+}
+
 // TicketCategory 工单分类模型
 type TicketCategory struct {
 	ID          uint   `json:"id" gorm:"primaryKey"`
@@ -176,7 +200,7 @@ type TicketCategory struct {
 	Color       string `json:"color" gorm:"size:7"` // 十六进制颜色值
 	IsActive    bool   `json:"is_active" gorm:"default:true"`
 	
-	// 自定义字段配置
+	// 自定义字段配置，格式为 {"fields": [...]}；名称与工单类型相同的分类同时作为该类型的字段定义
 	CustomFields JSONB `json:"custom_fields" gorm:"type:text"`
 	
 	// 系统字段
@@ -185,6 +209,15 @@ type TicketCategory struct {
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
+// TicketFieldValue 工单自定义字段值索引，用于按自定义字段筛选和排序；多选字段每个选项一行
+type TicketFieldValue struct {
+	ID          uint     `json:"id" gorm:"primaryKey"`
+	TicketID    uint     `json:"ticket_id" gorm:"not null;index"`
+	FieldName   string   `json:"field_name" gorm:"not null;size:50;index:idx_ticket_field_text,priority:1;index:idx_ticket_field_number,priority:1"`
+	TextValue   string   `json:"text_value" gorm:"size:255;index:idx_ticket_field_text,priority:2"`   // 文本、选项与日期（YYYY-MM-DD），数值与用户为其十进制表示
+	NumberValue *float64 `json:"number_value" gorm:"index:idx_ticket_field_number,priority:2"`        // 数值与用户ID
+}
+
 // BeforeCreate 创建前钩子
 func (t *Ticket) BeforeCreate(tx *gorm.DB) error {
 	// 设置默认值
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+)
+
+// 自动分配策略
+const (
+	AssignStrategyRoundRobin = "round_robin" // 轮流分配
+	AssignStrategyLeastOpen  = "least_open"  // 分配给未完成工单最少的成员
+	AssignStrategySkills     = "skills"      // 按技能匹配度分配，匹配度相同时取未完成工单最少的成员
+)
+
+// TicketAssignmentRule 工单自动分配规则，按排序依次匹配，条件为空表示不限
+type TicketAssignmentRule struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	Name           string         `json:"name" gorm:"not null;size:200"`
+	Description    string         `json:"description" gorm:"size:500"`
+	SortOrder      int            `json:"sort_order" gorm:"default:0;index"`
+	TicketType     TicketType     `json:"ticket_type" gorm:"size:20"`
+	Category       string         `json:"category" gorm:"size:100"`
+	TicketPriority TicketPriority `json:"ticket_priority" gorm:"size:20"`
+	Tags           StringSlice    `json:"tags" gorm:"type:text"`       // 工单具备任一标签即匹配
+	TargetRole     string         `json:"target_role" gorm:"size:100"` // 分配给该角色的成员，与 Members 至少指定一个
+	Strategy       string         `json:"strategy" gorm:"not null;size:20;default:'least_open'"`
+	Skills         StringSlice    `json:"skills" gorm:"type:text"` // skills 策略所需技能，为空时使用工单的标签与分类
+	LastAssigneeID *uint          `json:"last_assignee_id"`        // round_robin 策略上次分配的成员
+	IsActive       bool           `json:"is_active" gorm:"default:true"`
+	CreatedBy      uint           `json:"created_by" gorm:"not null;index"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+
+	Members []TicketAssignmentMember `json:"members" gorm:"foreignKey:RuleID"`
+}
+
+// TicketAssignmentMember 分配规则指定的处理团队成员
+type TicketAssignmentMember struct {
+	RuleID uint `json:"rule_id" gorm:"primaryKey"`
+	UserID uint `json:"user_id" gorm:"primaryKey;index"`
+
+	User User `json:"user" gorm:"foreignKey:UserID"`
+}
+
+// AssigneeProfile 处理人的技能与可用状态
+type AssigneeProfile struct {
+	ID             uint        `json:"id" gorm:"primaryKey"`
+	UserID         uint        `json:"user_id" gorm:"not null;uniqueIndex"`
+	Skills         StringSlice `json:"skills" gorm:"type:text"`
+	Away           bool        `json:"away" gorm:"default:false"` // 暂离，不参与自动分配
+	AwayUntil      *time.Time  `json:"away_until"`                // 暂离截止时间，为空表示直到手动恢复
+	MaxOpenTickets int         `json:"max_open_tickets"`          // 未完成工单上限，0 表示不限
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+// IsAway 判断处理人在某一时刻是否暂离
+func (p *AssigneeProfile) IsAway(now time.Time) bool {
+	return p.Away && (p.AwayUntil == nil || now.Before(*p.AwayUntil))
+}
+
+// IsValidAssignStrategy 检查自动分配策略是否有效
+func IsValidAssignStrategy(strategy string) bool {
+	switch strategy {
+	case AssignStrategyRoundRobin, AssignStrategyLeastOpen, AssignStrategySkills:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"time"
+)
+
+// SLA 计时状态
+const (
+	SLAStateRunning  = "running"  // 计时中
+	SLAStateWarning  = "warning"  // 即将超时
+	SLAStateBreached = "breached" // 已超时
+	SLAStateMet      = "met"      // 按时达成
+)
+
+// SLA 升级触发点
+const (
+	SLATriggerResponseWarning   = "response_warning"
+	SLATriggerResponseBreach    = "response_breach"
+	SLATriggerResolutionWarning = "resolution_warning"
+	SLATriggerResolutionBreach  = "resolution_breach"
+)
+
+// SLA 升级动作
+const (
+	SLAEscalationNotify        = "notify"         // 通知指定对象
+	SLAEscalationReassign      = "reassign"       // 转派给指定用户或角色中负载最低的用户
+	SLAEscalationRaisePriority = "raise_priority" // 提升优先级
+)
+
+// BusinessCalendar 工作日历，SLA 只在工作时间内计时
+type BusinessCalendar struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"uniqueIndex;not null;size:100"`
+	Description string    `json:"description" gorm:"size:500"`
+	Timezone    string    `json:"timezone" gorm:"not null;size:64;default:'Asia/Shanghai'"`
+	WorkDays    string    `json:"work_days" gorm:"not null;size:20;default:'1,2,3,4,5'"` // 工作日，1-7 表示周一至周日
+	WorkStart   string    `json:"work_start" gorm:"not null;size:5;default:'09:00'"`
+	WorkEnd     string    `json:"work_end" gorm:"not null;size:5;default:'18:00'"`
+	CreatedBy   uint      `json:"created_by" gorm:"not null;index"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	Holidays []BusinessHoliday `json:"holidays" gorm:"foreignKey:CalendarID"`
+}
+
+// BusinessHoliday 日历的节假日或调休上班日
+type BusinessHoliday struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	CalendarID uint   `json:"calendar_id" gorm:"not null;uniqueIndex:idx_calendar_date"`
+	Date       string `json:"date" gorm:"not null;size:10;uniqueIndex:idx_calendar_date"` // YYYY-MM-DD
+	Name       string `json:"name" gorm:"size:100"`
+	IsWorkday  bool   `json:"is_workday" gorm:"default:false"` // 调休上班日，该日期按工作日计时
+}
+
+// TicketSLAPolicy SLA 策略，按优先级、类型与分类匹配，为空表示不限
+type TicketSLAPolicy struct {
+	ID                   uint           `json:"id" gorm:"primaryKey"`
+	Name                 string         `json:"name" gorm:"not null;size:200"`
+	Description          string         `json:"description" gorm:"size:500"`
+	Priority             TicketPriority `json:"priority" gorm:"size:20;index"`
+	TicketType           TicketType     `json:"ticket_type" gorm:"size:20;index"`
+	Category             string         `json:"category" gorm:"size:100;index"`
+	CalendarID           *uint          `json:"calendar_id" gorm:"index"` // 为空表示 7x24 小时计时
+	FirstResponseMinutes int            `json:"first_response_minutes"`   // 首次响应时限（工作分钟），0 表示不考核
+	ResolutionMinutes    int            `json:"resolution_minutes"`       // 解决时限（工作分钟），0 表示不考核
+	WarningPercent       int            `json:"warning_percent"`          // 消耗时限达到该比例时预警，0 表示不预警
+	IsActive             bool           `json:"is_active" gorm:"default:true"`
+	CreatedBy            uint           `json:"created_by" gorm:"not null;index"`
+	CreatedAt            time.Time      `json:"created_at"`
+	UpdatedAt            time.Time      `json:"updated_at"`
+
+	Calendar    *BusinessCalendar     `json:"calendar,omitempty" gorm:"foreignKey:CalendarID"`
+	Escalations []TicketSLAEscalation `json:"escalations" gorm:"foreignKey:PolicyID"`
+}
+
+// TicketSLAEscalation SLA 预警或超时时执行的升级动作
+type TicketSLAEscalation struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	PolicyID   uint           `json:"policy_id" gorm:"not null;index"`
+	Trigger    string         `json:"trigger" gorm:"column:trigger_event;not null;size:30"` // trigger 为 SQL 保留字
+	Action     string         `json:"action" gorm:"not null;size:30"`
+	Targets    StringSlice    `json:"targets" gorm:"type:text"` // notify：creator、assignee、role:<角色名>、user:<用户ID>
+	AssigneeID *uint          `json:"assignee_id"`              // reassign：指定处理人
+	Role       string         `json:"role" gorm:"size:50"`      // reassign：从该角色中选择负载最低的用户
+	Priority   TicketPriority `json:"priority" gorm:"size:20"`  // raise_priority：目标优先级，为空时提升一级
+}
+
+// TicketSLA 工单的 SLA 计时，暂停与完成时间都按工作日历折算
+type TicketSLA struct {
+	ID                uint       `json:"id" gorm:"primaryKey"`
+	TicketID          uint       `json:"ticket_id" gorm:"not null;uniqueIndex"`
+	PolicyID          uint       `json:"policy_id" gorm:"not null;index"`
+	PolicyName        string     `json:"policy_name" gorm:"size:200"`
+	CalendarID        *uint      `json:"calendar_id"`
+	ResponseMinutes   int        `json:"response_minutes"`
+	ResolutionMinutes int        `json:"resolution_minutes"`
+	WarningPercent    int        `json:"warning_percent"`
+	StartedAt         time.Time  `json:"started_at"`
+	PausedAt          *time.Time `json:"paused_at"`      // 暂停开始时间，不为空表示计时暂停
+	PausedMinutes     int        `json:"paused_minutes"` // 累计暂停的工作分钟数
+	ResponseWarnAt    *time.Time `json:"response_warn_at" gorm:"index"`
+	ResponseDueAt     *time.Time `json:"response_due_at" gorm:"index"`
+	RespondedAt       *time.Time `json:"responded_at"`
+	ResponseState     string     `json:"response_state" gorm:"size:20;index"`
+	ResolutionWarnAt  *time.Time `json:"resolution_warn_at" gorm:"index"`
+	ResolutionDueAt   *time.Time `json:"resolution_due_at" gorm:"index"`
+	ResolvedAt        *time.Time `json:"resolved_at"`
+	ResolutionState   string     `json:"resolution_state" gorm:"size:20;index"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// IsValidSLATrigger 检查升级触发点是否有效
+func IsValidSLATrigger(trigger string) bool {
+	switch trigger {
+	case SLATriggerResponseWarning, SLATriggerResponseBreach, SLATriggerResolutionWarning, SLATriggerResolutionBreach:
+		return true
+	}
+	return false
+}
+
+// IsValidSLAEscalation 检查升级动作是否有效
+func IsValidSLAEscalation(action string) bool {
+	switch action {
+	case SLAEscalationNotify, SLAEscalationReassign, SLAEscalationRaisePriority:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// 差评后续处理
+const (
+	SurveyFollowUpReopened = "reopened" // 已重新打开工单
+	SurveyFollowUpNotified = "notified" // 已通知负责人
+)
+
+// TicketSurvey 工单满意度调查，工单解决或关闭时发送给创建者，链接一次有效且会过期
+type TicketSurvey struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	TicketID    uint       `json:"ticket_id" gorm:"not null;index"`
+	UserID      uint       `json:"user_id" gorm:"not null;index"`  // 接收调查的工单创建者
+	AssigneeID  *uint      `json:"assignee_id" gorm:"index"`       // 发送时的处理人，用于按处理人统计
+	Category    string     `json:"category" gorm:"size:100;index"` // 发送时的工单分类
+	TokenHash   string     `json:"-" gorm:"not null;size:64;uniqueIndex"`
+	Rating      *int       `json:"rating"` // 1-5 分，未提交时为空
+	Comment     string     `json:"comment" gorm:"type:text"`
+	FollowUp    string     `json:"follow_up" gorm:"size:20"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null"`
+	RespondedAt *time.Time `json:"responded_at" gorm:"index"`
+	CreatedAt   time.Time  `json:"created_at"`
+
+	// 关联关系
+	Ticket   Ticket `json:"-" gorm:"foreignKey:TicketID"`
+	User     User   `json:"-" gorm:"foreignKey:UserID"`
+	Assignee *User  `json:"-" gorm:"foreignKey:AssigneeID"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// TicketWorkflow 工单流程定义，按工单类型与分类匹配；类型与分类都为空时作为默认流程
+type TicketWorkflow struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"not null;size:200"`
+	Description string    `json:"description" gorm:"size:500"`
+	TicketType  string    `json:"ticket_type" gorm:"size:20;index"` // 为空表示适用所有类型
+	Category    string    `json:"category" gorm:"size:100;index"`   // 为空表示适用所有分类
+	Definition  JSONB     `json:"definition" gorm:"type:text"`      // 状态、流转、必填字段与进入状态时的动作
+	IsActive    bool      `json:"is_active" gorm:"default:true"`
+	CreatedBy   uint      `json:"created_by" gorm:"not null;index"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TicketWorklog 工单工时记录，按工作日期统计，用于向部门按工作量结算
+type TicketWorklog struct {
+	ID       uint      `json:"id" gorm:"primaryKey"`
+	TicketID uint      `json:"ticket_id" gorm:"not null;index"`
+	UserID   uint      `json:"user_id" gorm:"not null;index"` // 工作人
+	Minutes  int       `json:"minutes" gorm:"not null"`       // 工时（分钟）
+	WorkDate time.Time `json:"work_date" gorm:"type:date;not null;index"`
+	Note     string    `json:"note" gorm:"type:text"`
+	Billable bool      `json:"billable" gorm:"not null;index"`
+
+	// 登记人，为他人代登记时与工作人不同
+	CreatedBy uint `json:"created_by" gorm:"not null;index"`
+
+	// 系统字段
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// 关联关系
+	Ticket Ticket `json:"-" gorm:"foreignKey:TicketID"`
+	User   User   `json:"user" gorm:"foreignKey:UserID"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+)
+
+// 可关注的实体类型
+const (
+	WatchEntityRecord = "record" // 记录
+	WatchEntityTicket = "ticket" // 工单
+)
+
+// 关注来源
+const (
+	WatchReasonManual    = "manual"    // 用户手动关注
+	WatchReasonCreator   = "creator"   // 创建者自动关注
+	WatchReasonAssignee  = "assignee"  // 处理人/审核人自动关注
+	WatchReasonCommenter = "commenter" // 评论者自动关注
+)
+
+// 通知渠道偏好
+const (
+	NotifyChannelWechat = "wechat" // 企业微信（默认）
+	NotifyChannelEmail  = "email"  // 邮件
+	NotifyChannelSMS    = "sms"    // 短信
+	NotifyChannelNone   = "none"   // 不接收关注通知
+)
+
+// Watcher 实体关注者
+type Watcher struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	EntityType string    `json:"entity_type" gorm:"not null;size:20;uniqueIndex:idx_watcher_unique;index:idx_watcher_entity"`
+	EntityID   uint      `json:"entity_id" gorm:"not null;uniqueIndex:idx_watcher_unique;index:idx_watcher_entity"`
+	UserID     uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_watcher_unique;index"`
+	Reason     string    `json:"reason" gorm:"not null;size:20;default:'manual'"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	// 关联关系
+	User User `json:"user" gorm:"foreignKey:UserID"`
+}
+
+// NotificationPreference 用户通知偏好
+type NotificationPreference struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex"`
+	Channel   string    `json:"channel" gorm:"not null;size:20;default:'wechat'"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IsValidWatchEntityType 检查关注实体类型是否有效
+func IsValidWatchEntityType(entityType string) bool {
+	return entityType == WatchEntityRecord || entityType == WatchEntityTicket
+}
+
+// IsValidNotifyChannel 检查通知渠道是否有效
+func IsValidNotifyChannel(channel string) bool {
+	switch channel {
+	case NotifyChannelWechat, NotifyChannelEmail, NotifyChannelSMS, NotifyChannelNone:
+		return true
+	}
+	return false
+}
@@ -56,6 +56,16 @@ type AuditLogQuery struct {
 	EndDate      string `form:"end_date"`
 	Page         int    `form:"page,default=1"`
 	PageSize     int    `form:"page_size,default=20"`
+	SortBy       string `form:"sort_by,default=created_at"`
+	SortOrder    string `form:"sort_order,default=desc"`
+	Pagination   string `form:"pagination"` // cursor 表示使用游标分页
+	Cursor       string `form:"cursor"`
+}
+
+// auditLogSortFields 审计日志允许的排序字段
+var auditLogSortFields = map[string]CursorField{
+	"id":         {Column: "audit_logs.id", Kind: CursorKindInt},
+	"created_at": {Column: "audit_logs.created_at", Kind: CursorKindTime},
 }
 
 // AuditLogListResponse 审计日志列表响应
@@ -65,6 +75,8 @@ type AuditLogListResponse struct {
 	Page       int                `json:"page"`
 	PageSize   int                `json:"page_size"`
 	TotalPages int                `json:"total_pages"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+	PrevCursor string             `json:"prev_cursor,omitempty"`
 }
 
 // CreateAuditLog 创建审计日志
@@ -95,6 +107,15 @@ func (s *AuditService) CreateAuditLog(req *AuditLogRequest) error {
 
 // GetAuditLogs 获取审计日志列表
 func (s *AuditService) GetAuditLogs(query *AuditLogQuery) (*AuditLogListResponse, error) {
+	sortBy, sortField, err := ResolveSortField(auditLogSortFields, query.SortBy, "created_at")
+	if err != nil {
+		return nil, err
+	}
+	desc := query.SortOrder != "asc"
+	if query.PageSize <= 0 {
+		query.PageSize = 20
+	}
+
 	db := s.db.Model(&models.AuditLog{}).Preload("User")
 
 	// 用户过滤
@@ -134,23 +155,39 @@ func (s *AuditService) GetAuditLogs(query *AuditLogQuery) (*AuditLogListResponse
 		}
 	}
 
-	// 获取总数
+	var logs []models.AuditLog
 	var total int64
-	if err := db.Count(&total).Error; err != nil {
-		return nil, fmt.Errorf("获取审计日志总数失败: %w", err)
-	}
-
-	// 排序（按时间倒序）
-	db = db.Order("created_at DESC")
+	var nextCursor, prevCursor string
+	if UsesCursorPagination(query.Pagination, query.Cursor) {
+		pager, err := NewKeysetPager(sortBy, sortField, "audit_logs.id", desc, query.Cursor, query.PageSize)
+		if err != nil {
+			return nil, err
+		}
+		if err := pager.Apply(db).Find(&logs).Error; err != nil {
+			return nil, fmt.Errorf("获取审计日志列表失败: %w", err)
+		}
+		logs, nextCursor, prevCursor = PaginateKeyset(pager, logs, func(log *models.AuditLog) (interface{}, uint) {
+			if sortBy == "id" {
+				return log.ID, log.ID
+			}
+			return log.CreatedAt, log.ID
+		})
+	} else {
+		if query.Page <= 0 {
+			query.Page = 1
+		}
 
-	// 分页
-	offset := (query.Page - 1) * query.PageSize
-	db = db.Offset(offset).Limit(query.PageSize)
+		// 获取总数
+		if err := db.Count(&total).Error; err != nil {
+			return nil, fmt.Errorf("获取审计日志总数失败: %w", err)
+		}
 
-	// 查询日志
-	var logs []models.AuditLog
-	if err := db.Find(&logs).Error; err != nil {
-		return nil, fmt.Errorf("获取审计日志列表失败: %w", err)
+		// 排序与分页
+		offset := (query.Page - 1) * query.PageSize
+		db = OrderWithTieBreaker(db, sortField, "audit_logs.id", desc).Offset(offset).Limit(query.PageSize)
+		if err := db.Find(&logs).Error; err != nil {
+			return nil, fmt.Errorf("获取审计日志列表失败: %w", err)
+		}
 	}
 
 	// 转换响应
@@ -180,6 +217,8 @@ func (s *AuditService) GetAuditLogs(query *AuditLogQuery) (*AuditLogListResponse
 		Page:       query.Page,
 		PageSize:   query.PageSize,
 		TotalPages: totalPages,
+		NextCursor: nextCursor,
+		PrevCursor: prevCursor,
 	}, nil
 }
 
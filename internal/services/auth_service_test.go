@@ -1,10 +1,11 @@
-package services
+package services_test
 
 import (
 	"testing"
 
 	"info-management-system/internal/config"
 	"info-management-system/internal/database"
+	"info-management-system/internal/services"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -14,8 +15,8 @@ import (
 type AuthServiceTestSuite struct {
 	suite.Suite
 	db          *gorm.DB
-	authService *AuthService
-	userService *UserService
+	authService *services.AuthService
+	userService *services.UserService
 }
 
 func (suite *AuthServiceTestSuite) SetupSuite() {
@@ -42,8 +43,8 @@ func (suite *AuthServiceTestSuite) SetupSuite() {
 		},
 	}
 
-	suite.authService = NewAuthService(suite.db, appConfig)
-	suite.userService = NewUserService(suite.db)
+	suite.authService = services.NewAuthService(suite.db, appConfig)
+	suite.userService = services.NewUserService(suite.db)
 }
 
 func (suite *AuthServiceTestSuite) TearDownSuite() {
@@ -57,7 +58,7 @@ func (suite *AuthServiceTestSuite) SetupTest() {
 }
 
 func (suite *AuthServiceTestSuite) TestRegister() {
-	req := &RegisterRequest{
+	req := &services.RegisterRequest{
 		Username: "testuser",
 		Email:    "test@example.com",
 		Password: "password123",
@@ -74,7 +75,7 @@ func (suite *AuthServiceTestSuite) TestRegister() {
 }
 
 func (suite *AuthServiceTestSuite) TestRegisterDuplicateUsername() {
-	req := &RegisterRequest{
+	req := &services.RegisterRequest{
 		Username: "admin", // 已存在的用户名
 		Email:    "test@example.com",
 		Password: "password123",
@@ -89,7 +90,7 @@ func (suite *AuthServiceTestSuite) TestRegisterDuplicateUsername() {
 
 func (suite *AuthServiceTestSuite) TestLogin() {
 	// 先注册一个用户
-	registerReq := &RegisterRequest{
+	registerReq := &services.RegisterRequest{
 		Username: "testuser",
 		Email:    "test@example.com",
 		Password: "password123",
@@ -98,7 +99,7 @@ func (suite *AuthServiceTestSuite) TestLogin() {
 	suite.Require().NoError(err)
 
 	// 测试登录
-	loginReq := &LoginRequest{
+	loginReq := &services.LoginRequest{
 		Username: "testuser",
 		Password: "password123",
 	}
@@ -115,7 +116,7 @@ func (suite *AuthServiceTestSuite) TestLogin() {
 }
 
 func (suite *AuthServiceTestSuite) TestLoginInvalidCredentials() {
-	loginReq := &LoginRequest{
+	loginReq := &services.LoginRequest{
 		Username: "nonexistent",
 		Password: "wrongpassword",
 	}
@@ -129,7 +130,7 @@ func (suite *AuthServiceTestSuite) TestLoginInvalidCredentials() {
 
 func (suite *AuthServiceTestSuite) TestValidateToken() {
 	// 先注册并登录获取token
-	registerReq := &RegisterRequest{
+	registerReq := &services.RegisterRequest{
 		Username: "testuser",
 		Email:    "test@example.com",
 		Password: "password123",
@@ -137,7 +138,7 @@ func (suite *AuthServiceTestSuite) TestValidateToken() {
 	_, err := suite.authService.Register(registerReq)
 	suite.Require().NoError(err)
 
-	loginReq := &LoginRequest{
+	loginReq := &services.LoginRequest{
 		Username: "testuser",
 		Password: "password123",
 	}
@@ -164,7 +165,7 @@ func (suite *AuthServiceTestSuite) TestValidateInvalidToken() {
 
 func (suite *AuthServiceTestSuite) TestRefreshToken() {
 	// 先注册并登录获取refresh token
-	registerReq := &RegisterRequest{
+	registerReq := &services.RegisterRequest{
 		Username: "testuser",
 		Email:    "test@example.com",
 		Password: "password123",
@@ -172,7 +173,7 @@ func (suite *AuthServiceTestSuite) TestRefreshToken() {
 	_, err := suite.authService.Register(registerReq)
 	suite.Require().NoError(err)
 
-	loginReq := &LoginRequest{
+	loginReq := &services.LoginRequest{
 		Username: "testuser",
 		Password: "password123",
 	}
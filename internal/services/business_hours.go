@@ -0,0 +1,161 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"info-management-system/internal/models"
+)
+
+// maxBusinessDays 按工作日历推算时的最大天数，防止日历配置异常时无限循环
+const maxBusinessDays = 3660
+
+// businessHours 工作时间计算，calendar 为空时按 7x24 小时计时
+type businessHours struct {
+	location  *time.Location
+	workDays  map[time.Weekday]bool
+	start     int             // 每日开始时间（分钟）
+	end       int             // 每日结束时间（分钟）
+	overrides map[string]bool // 节假日（false）与调休上班日（true）
+	allDay    bool
+}
+
+// newBusinessHours 根据工作日历构建工作时间，calendar 为空时表示 7x24 小时
+func newBusinessHours(calendar *models.BusinessCalendar) (*businessHours, error) {
+	if calendar == nil {
+		return &businessHours{location: time.Local, allDay: true}, nil
+	}
+
+	location, err := time.LoadLocation(calendar.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("无效的时区: %s", calendar.Timezone)
+	}
+	workDays, err := parseWorkDays(calendar.WorkDays)
+	if err != nil {
+		return nil, err
+	}
+	start, err := parseClock(calendar.WorkStart)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseClock(calendar.WorkEnd)
+	if err != nil {
+		return nil, err
+	}
+	if end <= start {
+		return nil, fmt.Errorf("下班时间必须晚于上班时间")
+	}
+
+	hours := &businessHours{
+		location:  location,
+		workDays:  workDays,
+		start:     start,
+		end:       end,
+		overrides: make(map[string]bool, len(calendar.Holidays)),
+	}
+	for _, holiday := range calendar.Holidays {
+		hours.overrides[holiday.Date] = holiday.IsWorkday
+	}
+	return hours, nil
+}
+
+// parseWorkDays 解析工作日配置，如 "1,2,3,4,5"
+func parseWorkDays(value string) (map[time.Weekday]bool, error) {
+	days := make(map[time.Weekday]bool)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		day, err := strconv.Atoi(part)
+		if err != nil || day < 1 || day > 7 {
+			return nil, fmt.Errorf("无效的工作日: %s", part)
+		}
+		days[time.Weekday(day%7)] = true
+	}
+	if len(days) == 0 {
+		return nil, fmt.Errorf("工作日不能为空")
+	}
+	return days, nil
+}
+
+// parseClock 解析 HH:MM 格式的时间，返回当日分钟数
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("无效的时间: %s", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// isWorkday 判断某日是否为工作日，节假日与调休配置优先于每周工作日
+func (b *businessHours) isWorkday(day time.Time) bool {
+	if workday, ok := b.overrides[day.Format("2006-01-02")]; ok {
+		return workday
+	}
+	return b.workDays[day.Weekday()]
+}
+
+// window 返回某日的工作时间段
+func (b *businessHours) window(day time.Time) (time.Time, time.Time) {
+	midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, b.location)
+	return midnight.Add(time.Duration(b.start) * time.Minute), midnight.Add(time.Duration(b.end) * time.Minute)
+}
+
+// AddMinutes 从 from 开始累计 minutes 个工作分钟后的时间
+func (b *businessHours) AddMinutes(from time.Time, minutes int) time.Time {
+	if b.allDay || minutes <= 0 {
+		return from.Add(time.Duration(minutes) * time.Minute)
+	}
+
+	remaining := time.Duration(minutes) * time.Minute
+	current := from.In(b.location)
+	for i := 0; i < maxBusinessDays; i++ {
+		if b.isWorkday(current) {
+			open, close := b.window(current)
+			if current.Before(open) {
+				current = open
+			}
+			if current.Before(close) {
+				available := close.Sub(current)
+				if remaining <= available {
+					return current.Add(remaining)
+				}
+				remaining -= available
+			}
+		}
+		current = time.Date(current.Year(), current.Month(), current.Day()+1, 0, 0, 0, 0, b.location)
+	}
+	return current.Add(remaining)
+}
+
+// MinutesBetween 计算 from 与 to 之间的工作分钟数
+func (b *businessHours) MinutesBetween(from, to time.Time) int {
+	if !to.After(from) {
+		return 0
+	}
+	if b.allDay {
+		return int(to.Sub(from) / time.Minute)
+	}
+
+	var total time.Duration
+	current := from.In(b.location)
+	for i := 0; i < maxBusinessDays && current.Before(to); i++ {
+		if b.isWorkday(current) {
+			open, close := b.window(current)
+			if current.After(open) {
+				open = current
+			}
+			if to.Before(close) {
+				close = to
+			}
+			if close.After(open) {
+				total += close.Sub(open)
+			}
+		}
+		current = time.Date(current.Year(), current.Month(), current.Day()+1, 0, 0, 0, 0, b.location)
+	}
+	return int(total / time.Minute)
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 游标排序字段的值类型
+const (
+	CursorKindTime   = "time"
+	CursorKindString = "string"
+	CursorKindInt    = "int"
+)
+
+// CursorField 允许用于键集分页排序的字段
+type CursorField struct {
+	Column string // 带表名的列名
+	Kind   string // 值类型，决定游标中排序值的解码方式
+}
+
+// listCursor 游标内容：排序条件、排序键与ID，以及翻页方向
+type listCursor struct {
+	SortBy   string      `json:"s"`
+	Desc     bool        `json:"d"`
+	Value    interface{} `json:"v"`
+	ID       uint        `json:"i"`
+	Backward bool        `json:"b,omitempty"`
+}
+
+// KeysetPager 键集（游标）分页器，按 排序列+ID 稳定排序，不依赖 OFFSET 与 COUNT
+type KeysetPager struct {
+	sortBy   string
+	field    CursorField
+	idColumn string
+	desc     bool
+	limit    int
+	cursor   *listCursor
+}
+
+// UsesCursorPagination 判断列表请求是否使用游标分页：
+// 显式指定 pagination=cursor 或携带游标时启用
+func UsesCursorPagination(pagination, cursor string) bool {
+	return pagination == "cursor" || cursor != ""
+}
+
+// ResolveSortField 校验排序字段，为空时使用默认字段
+func ResolveSortField(fields map[string]CursorField, sortBy, defaultSortBy string) (string, CursorField, error) {
+	if sortBy == "" {
+		sortBy = defaultSortBy
+	}
+	field, ok := fields[sortBy]
+	if !ok {
+		return "", CursorField{}, fmt.Errorf("不支持的排序字段: %s", sortBy)
+	}
+	return sortBy, field, nil
+}
+
+// NewKeysetPager 创建键集分页器，sortBy/field 需先经 ResolveSortField 校验，rawCursor 为空表示第一页
+func NewKeysetPager(sortBy string, field CursorField, idColumn string, desc bool, rawCursor string, limit int) (*KeysetPager, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+
+	pager := &KeysetPager{sortBy: sortBy, field: field, idColumn: idColumn, desc: desc, limit: limit}
+	if rawCursor == "" {
+		return pager, nil
+	}
+
+	cursor, err := decodeListCursor(rawCursor)
+	if err != nil {
+		return nil, err
+	}
+	if cursor.SortBy != sortBy || cursor.Desc != desc {
+		return nil, fmt.Errorf("游标与排序条件不匹配")
+	}
+	if err := cursor.restoreValue(field.Kind); err != nil {
+		return nil, err
+	}
+	pager.cursor = cursor
+	return pager, nil
+}
+
+// decodeListCursor 解码游标
+func decodeListCursor(raw string) (*listCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("无效的游标")
+	}
+	var cursor listCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("无效的游标")
+	}
+	return &cursor, nil
+}
+
+// restoreValue 按排序字段类型还原游标中的排序值
+func (c *listCursor) restoreValue(kind string) error {
+	switch kind {
+	case CursorKindTime:
+		s, ok := c.Value.(string)
+		if !ok {
+			return fmt.Errorf("无效的游标")
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return fmt.Errorf("无效的游标")
+		}
+		c.Value = t
+	case CursorKindInt:
+		n, ok := c.Value.(float64)
+		if !ok {
+			return fmt.Errorf("无效的游标")
+		}
+		c.Value = int64(n)
+	default:
+		if _, ok := c.Value.(string); !ok {
+			return fmt.Errorf("无效的游标")
+		}
+	}
+	return nil
+}
+
+// encode 生成指向指定条目的游标
+func (p *KeysetPager) encode(value interface{}, id uint, backward bool) string {
+	if t, ok := value.(time.Time); ok {
+		value = t.Format(time.RFC3339Nano)
+	}
+	data, _ := json.Marshal(listCursor{SortBy: p.sortBy, Desc: p.desc, Value: value, ID: id, Backward: backward})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// backward 是否向前翻页
+func (p *KeysetPager) backward() bool {
+	return p.cursor != nil && p.cursor.Backward
+}
+
+// Apply 追加游标位置条件、排序与条数限制（多取一条用于判断是否还有数据）
+func (p *KeysetPager) Apply(db *gorm.DB) *gorm.DB {
+	// 向前翻页时反向扫描，取回后再恢复顺序
+	scanDesc := p.desc != p.backward()
+	op, dir := ">", "ASC"
+	if scanDesc {
+		op, dir = "<", "DESC"
+	}
+
+	if p.cursor != nil {
+		if p.field.Column == p.idColumn {
+			db = db.Where(fmt.Sprintf("%s %s ?", p.idColumn, op), p.cursor.ID)
+		} else {
+			db = db.Where(fmt.Sprintf("(%s %s ? OR (%s = ? AND %s %s ?))", p.field.Column, op, p.field.Column, p.idColumn, op),
+				p.cursor.Value, p.cursor.Value, p.cursor.ID)
+		}
+	}
+
+	if p.field.Column != p.idColumn {
+		db = db.Order(p.field.Column + " " + dir)
+	}
+	return db.Order(p.idColumn + " " + dir).Limit(p.limit + 1)
+}
+
+// PaginateKeyset 裁剪多取的条目、恢复向前翻页的顺序，并生成前后页游标；
+// key 返回条目的排序值与ID
+func PaginateKeyset[T any](p *KeysetPager, items []T, key func(item *T) (interface{}, uint)) ([]T, string, string) {
+	hasMore := len(items) > p.limit
+	if hasMore {
+		items = items[:p.limit]
+	}
+	if p.backward() {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+	if len(items) == 0 {
+		return items, "", ""
+	}
+
+	var next, prev string
+	firstValue, firstID := key(&items[0])
+	lastValue, lastID := key(&items[len(items)-1])
+	if p.backward() {
+		// 向前翻页时，当前页之后必然还有数据
+		next = p.encode(lastValue, lastID, false)
+		if hasMore {
+			prev = p.encode(firstValue, firstID, true)
+		}
+	} else {
+		if hasMore {
+			next = p.encode(lastValue, lastID, false)
+		}
+		if p.cursor != nil {
+			prev = p.encode(firstValue, firstID, true)
+		}
+	}
+	return items, next, prev
+}
+
+// OrderWithTieBreaker 按排序字段和ID排序，保证 OFFSET 分页下的顺序稳定
+func OrderWithTieBreaker(db *gorm.DB, field CursorField, idColumn string, desc bool) *gorm.DB {
+	dir := "ASC"
+	if desc {
+		dir = "DESC"
+	}
+	if field.Column != idColumn {
+		db = db.Order(field.Column + " " + dir)
+	}
+	return db.Order(idColumn + " " + dir)
+}
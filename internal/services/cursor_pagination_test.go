@@ -0,0 +1,119 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// setupCursorTest 创建游标分页测试环境：7条记录，标题和创建时间存在重复值
+func setupCursorTest(t *testing.T) (*gorm.DB, *RecordService) {
+	db := newServiceTestDB(t)
+	require.NoError(t, db.Create(&models.User{Username: "owner", Email: "owner@example.com", PasswordHash: "x", IsActive: true}).Error)
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 7; i++ {
+		require.NoError(t, db.Create(&models.Record{
+			Type: "asset", Title: fmt.Sprintf("记录%d", i/2), Status: "draft", Version: 1, CreatedBy: 1,
+			CreatedAt: base.Add(time.Duration(i/3) * time.Hour),
+		}).Error)
+	}
+
+	auditService := NewAuditService(db)
+	return db, NewRecordService(db, NewRecordTypeService(db), auditService, nil, nil)
+}
+
+// recordIDs 提取记录ID
+func recordIDs(records []RecordResponse) []uint {
+	ids := make([]uint, len(records))
+	for i, record := range records {
+		ids[i] = record.ID
+	}
+	return ids
+}
+
+func TestGetRecords_CursorPagination(t *testing.T) {
+	_, recordService := setupCursorTest(t)
+
+	for _, sortBy := range []string{"created_at", "title", "id"} {
+		for _, order := range []string{"asc", "desc"} {
+			offset, err := recordService.GetRecords(&RecordListQuery{Page: 1, PageSize: 10, SortBy: sortBy, SortOrder: order}, 1, true)
+			require.NoError(t, err)
+			expected := recordIDs(offset.Records)
+			require.Len(t, expected, 7)
+
+			// 向后逐页翻到底，结果与一次性排序一致且无重复
+			var collected []uint
+			var pages []*RecordListResponse
+			query := &RecordListQuery{PageSize: 3, SortBy: sortBy, SortOrder: order, Pagination: "cursor"}
+			for {
+				page, err := recordService.GetRecords(query, 1, true)
+				require.NoError(t, err)
+				pages = append(pages, page)
+				collected = append(collected, recordIDs(page.Records)...)
+				if page.NextCursor == "" {
+					break
+				}
+				query.Cursor = page.NextCursor
+			}
+			assert.Equal(t, expected, collected, "%s %s", sortBy, order)
+			require.Len(t, pages, 3)
+			assert.Empty(t, pages[0].PrevCursor)
+
+			// 从最后一页向前翻页回到第一页
+			back, err := recordService.GetRecords(&RecordListQuery{PageSize: 3, SortBy: sortBy, SortOrder: order, Cursor: pages[2].PrevCursor}, 1, true)
+			require.NoError(t, err)
+			assert.Equal(t, recordIDs(pages[1].Records), recordIDs(back.Records))
+			back, err = recordService.GetRecords(&RecordListQuery{PageSize: 3, SortBy: sortBy, SortOrder: order, Cursor: back.PrevCursor}, 1, true)
+			require.NoError(t, err)
+			assert.Equal(t, recordIDs(pages[0].Records), recordIDs(back.Records))
+			assert.Empty(t, back.PrevCursor)
+			assert.NotEmpty(t, back.NextCursor)
+		}
+	}
+}
+
+func TestGetRecords_CursorValidation(t *testing.T) {
+	_, recordService := setupCursorTest(t)
+
+	_, err := recordService.GetRecords(&RecordListQuery{PageSize: 3, SortBy: "content"}, 1, true)
+	assert.EqualError(t, err, "不支持的排序字段: content")
+
+	_, err = recordService.GetRecords(&RecordListQuery{PageSize: 3, Cursor: "not-a-cursor"}, 1, true)
+	assert.EqualError(t, err, "无效的游标")
+
+	page, err := recordService.GetRecords(&RecordListQuery{PageSize: 3, SortBy: "title", SortOrder: "asc", Pagination: "cursor"}, 1, true)
+	require.NoError(t, err)
+	_, err = recordService.GetRecords(&RecordListQuery{PageSize: 3, SortBy: "created_at", SortOrder: "asc", Cursor: page.NextCursor}, 1, true)
+	assert.EqualError(t, err, "游标与排序条件不匹配")
+}
+
+func TestGetAuditLogs_CursorPagination(t *testing.T) {
+	db, _ := setupCursorTest(t)
+	auditService := NewAuditService(db)
+
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, db.Create(&models.AuditLog{UserID: 1, Action: "UPDATE", ResourceType: "record", ResourceID: uint(i + 1), CreatedAt: createdAt}).Error)
+	}
+
+	first, err := auditService.GetAuditLogs(&AuditLogQuery{PageSize: 2, Pagination: "cursor"})
+	require.NoError(t, err)
+	require.Len(t, first.Logs, 2)
+	assert.Equal(t, []uint{5, 4}, []uint{first.Logs[0].ResourceID, first.Logs[1].ResourceID}, "创建时间相同时按ID倒序")
+
+	// 翻页期间插入的新日志不会造成重复或遗漏
+	require.NoError(t, db.Create(&models.AuditLog{UserID: 1, Action: "UPDATE", ResourceType: "record", ResourceID: 6, CreatedAt: createdAt.Add(time.Hour)}).Error)
+
+	second, err := auditService.GetAuditLogs(&AuditLogQuery{PageSize: 2, Cursor: first.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, second.Logs, 2)
+	assert.Equal(t, []uint{3, 2}, []uint{second.Logs[0].ResourceID, second.Logs[1].ResourceID})
+	assert.NotEmpty(t, second.NextCursor)
+}
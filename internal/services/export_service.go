@@ -1,9 +1,11 @@
 package services
 
 import (
+	"archive/zip"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -270,7 +272,7 @@ func (s *ExportService) CreateExportTask(req *ExportRequest, userID uint) (*Expo
 	}
 
 	// 异步执行导出任务
-	go s.processExportTask(task.ID, req)
+	go s.processExportTask(task.ID, req, userID)
 
 	return &ExportResponse{
 		TaskID:   task.ID,
@@ -281,7 +283,7 @@ func (s *ExportService) CreateExportTask(req *ExportRequest, userID uint) (*Expo
 }
 
 // processExportTask 处理导出任务
-func (s *ExportService) processExportTask(taskID uint, req *ExportRequest) {
+func (s *ExportService) processExportTask(taskID uint, req *ExportRequest, userID uint) {
 	// 更新任务状态为处理中
 	now := time.Now()
 	s.db.Model(&models.ExportTask{}).Where("id = ?", taskID).Updates(map[string]interface{}{
@@ -306,6 +308,16 @@ func (s *ExportService) processExportTask(taskID uint, req *ExportRequest) {
 		return
 	}
 
+	// 按需将记录附件打包为zip
+	if s.shouldBundleAttachments(req) {
+		zipPath, err := s.bundleRecordAttachments(filePath, records, userID)
+		if err != nil {
+			s.updateTaskError(taskID, fmt.Sprintf("打包附件失败: %v", err))
+			return
+		}
+		filePath = zipPath
+	}
+
 	// 获取文件大小
 	fileInfo, _ := os.Stat(filePath)
 	fileSize := int64(0)
@@ -345,6 +357,103 @@ func (s *ExportService) processExportTask(taskID uint, req *ExportRequest) {
 	}
 }
 
+// shouldBundleAttachments 是否需要打包记录附件（config.include_attachments）
+func (s *ExportService) shouldBundleAttachments(req *ExportRequest) bool {
+	if req.Config == nil {
+		return false
+	}
+	if dataType, ok := req.Config["data_type"].(string); ok && dataType != "records" {
+		return false
+	}
+	include, _ := req.Config["include_attachments"].(bool)
+	return include
+}
+
+// bundleRecordAttachments 将导出文件与记录附件打包为zip，附件按 attachments/<记录ID>/<文件ID>_<原文件名> 存放。
+// 只打包导出人有权访问的文件
+func (s *ExportService) bundleRecordAttachments(exportPath string, records []map[string]interface{}, userID uint) (string, error) {
+	var recordIDs []uint
+	for _, record := range records {
+		if id, ok := record["id"].(uint); ok {
+			recordIDs = append(recordIDs, id)
+		}
+	}
+
+	var recordFiles []models.RecordFile
+	if len(recordIDs) > 0 {
+		if err := s.db.Preload("File").Where("record_id IN ?", recordIDs).Order("record_id ASC, id ASC").Find(&recordFiles).Error; err != nil {
+			return "", fmt.Errorf("查询记录附件失败: %v", err)
+		}
+	}
+
+	zipPath := strings.TrimSuffix(exportPath, filepath.Ext(exportPath)) + ".zip"
+	zipFile, err := os.Create(zipPath)
+	if err != nil {
+		return "", fmt.Errorf("创建压缩文件失败: %v", err)
+	}
+	defer zipFile.Close()
+
+	writer := zip.NewWriter(zipFile)
+	if err := addFileToZip(writer, exportPath, filepath.Base(exportPath)); err != nil {
+		writer.Close()
+		os.Remove(zipPath)
+		return "", err
+	}
+
+	fileService := NewFileService(s.db, nil)
+	accessible := map[uint]bool{}
+	added := map[string]bool{}
+	for _, rf := range recordFiles {
+		if rf.File.ID == 0 {
+			continue
+		}
+		allowed, checked := accessible[rf.File.ID]
+		if !checked {
+			allowed = fileService.CheckFilesAccessible([]uint{rf.File.ID}, userID) == nil
+			accessible[rf.File.ID] = allowed
+		}
+		if !allowed {
+			continue
+		}
+		// 文件名加上文件ID前缀，同一记录下重名的附件不会互相覆盖
+		name := fmt.Sprintf("attachments/%d/%d_%s", rf.RecordID, rf.File.ID, filepath.Base(rf.File.OriginalName))
+		if added[name] {
+			continue
+		}
+		added[name] = true
+		if err := addFileToZip(writer, rf.File.Path, name); err != nil {
+			// 单个附件缺失不影响整体导出
+			fmt.Printf("Warning: 附件打包失败: %s, 错误: %v\n", rf.File.Path, err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		os.Remove(zipPath)
+		return "", fmt.Errorf("写入压缩文件失败: %v", err)
+	}
+
+	os.Remove(exportPath)
+	return zipPath, nil
+}
+
+// addFileToZip 将磁盘文件写入zip
+func addFileToZip(writer *zip.Writer, srcPath, name string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return fmt.Errorf("打开文件失败: %v", err)
+	}
+	defer src.Close()
+
+	dst, err := writer.Create(name)
+	if err != nil {
+		return fmt.Errorf("写入压缩条目失败: %v", err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		return fmt.Errorf("写入压缩条目失败: %v", err)
+	}
+	return nil
+}
+
 // updateTaskError 更新任务错误状态
 func (s *ExportService) updateTaskError(taskID uint, errorMsg string) {
 	s.db.Model(&models.ExportTask{}).Where("id = ?", taskID).Updates(map[string]interface{}{
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"bytes"
 	"crypto/md5"
 	"fmt"
 	"io"
@@ -165,6 +166,49 @@ func (s *FileService) UploadFile(req *UploadRequest, userID uint, ipAddress, use
 	return s.GetFileByID(file.ID, userID, true)
 }
 
+// SaveFileBytes 保存内存中的文件内容（如邮件附件），校验规则与上传相同，内容相同的文件复用已有记录
+func (s *FileService) SaveFileBytes(originalName, mimeType string, data []byte, userID uint) (*models.File, error) {
+	if int64(len(data)) > s.maxFileSize {
+		return nil, fmt.Errorf("文件大小超过限制 (%d MB)", s.maxFileSize/(1024*1024))
+	}
+	if !s.isAllowedType(mimeType) {
+		return nil, fmt.Errorf("不支持的文件类型: %s", mimeType)
+	}
+
+	hash, err := s.calculateHash(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("计算文件哈希失败: %w", err)
+	}
+	var existingFile models.File
+	if err := s.db.Where("hash = ?", hash).First(&existingFile).Error; err == nil {
+		return &existingFile, nil
+	}
+
+	if err := s.ensureUploadDir(); err != nil {
+		return nil, fmt.Errorf("创建上传目录失败: %w", err)
+	}
+	filename := s.generateFilename(originalName)
+	filePath := filepath.Join(s.uploadPath, filename)
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return nil, fmt.Errorf("保存文件失败: %w", err)
+	}
+
+	file := models.File{
+		Filename:     filename,
+		OriginalName: originalName,
+		MimeType:     mimeType,
+		Size:         int64(len(data)),
+		Path:         filePath,
+		Hash:         hash,
+		UploadedBy:   userID,
+	}
+	if err := s.db.Create(&file).Error; err != nil {
+		os.Remove(filePath)
+		return nil, fmt.Errorf("保存文件信息失败: %w", err)
+	}
+	return &file, nil
+}
+
 // GetFileByID 根据ID获取文件信息
 func (s *FileService) GetFileByID(id uint, userID uint, hasAllPermission bool) (*FileResponse, error) {
 	var file models.File
@@ -294,11 +338,7 @@ func (s *FileService) DeleteFile(id uint, userID uint, hasAllPermission bool, ip
 		return fmt.Errorf("删除文件记录失败: %w", err)
 	}
 
-	// 删除物理文件
-	if err := os.Remove(file.Path); err != nil && !os.IsNotExist(err) {
-		// 记录警告但不返回错误，因为数据库记录已删除
-		fmt.Printf("Warning: failed to delete physical file %s: %v\n", file.Path, err)
-	}
+	// 物理文件与关联保留到从回收站彻底删除时再清理，以便恢复
 
 	// 记录审计日志
 	if s.auditService != nil {
@@ -375,4 +415,120 @@ func generateRandomString(length int) string {
 // CreateFileRecord creates a file record for testing
 func (s *FileService) CreateFileRecord(file *models.File) error {
 	return s.db.Create(file).Error
-}
\ No newline at end of file
+}
+
+// CheckFilesAccessible 检查文件是否存在且当前用户可访问
+func (s *FileService) CheckFilesAccessible(fileIDs []uint, userID uint) error {
+	if len(fileIDs) == 0 {
+		return nil
+	}
+
+	var files []models.File
+	if err := s.db.Select("id", "uploaded_by").Where("id IN ?", fileIDs).Find(&files).Error; err != nil {
+		return fmt.Errorf("获取文件失败: %w", err)
+	}
+
+	found := make(map[uint]models.File, len(files))
+	for _, file := range files {
+		found[file.ID] = file
+	}
+
+	hasAllPermission := false
+	checked := false
+	for _, id := range fileIDs {
+		file, ok := found[id]
+		if !ok {
+			return fmt.Errorf("文件 %d 不存在或无权访问", id)
+		}
+		if file.UploadedBy == userID {
+			continue
+		}
+		if !checked {
+			resp, err := NewPermissionService(s.db).CheckPermission(&PermissionCheckRequest{
+				UserID:   userID,
+				Resource: "files",
+				Action:   "read",
+				Scope:    "all",
+			})
+			hasAllPermission = err == nil && resp != nil && resp.HasPermission
+			checked = true
+		}
+		if !hasAllPermission {
+			return fmt.Errorf("文件 %d 不存在或无权访问", id)
+		}
+	}
+
+	return nil
+}
+
+// DeleteUnreferencedFiles 删除不再被任何记录、工单或实体关联引用的文件，返回删除数量
+// 无全部文件权限时只删除本人上传的文件
+func (s *FileService) DeleteUnreferencedFiles(fileIDs []uint, userID uint, hasAllPermission bool, ipAddress, userAgent string) (int, error) {
+	referenced, err := s.referencedFiles(fileIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, id := range fileIDs {
+		if referenced[id] {
+			continue
+		}
+		if err := s.DeleteFile(id, userID, hasAllPermission, ipAddress, userAgent); err != nil {
+			if err.Error() == "文件不存在或无权删除" {
+				continue
+			}
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// referencedFiles 检查文件是否仍被引用：记录附件（文件字段也同步在记录附件表中，含回收站中的记录）、工单附件与实体关联
+func (s *FileService) referencedFiles(fileIDs []uint) (map[uint]bool, error) {
+	referenced := map[uint]bool{}
+	if len(fileIDs) == 0 {
+		return referenced, nil
+	}
+
+	var ids []uint
+	if err := s.db.Model(&models.RecordFile{}).Where("file_id IN ?", fileIDs).Pluck("file_id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("检查文件引用失败: %w", err)
+	}
+	for _, id := range ids {
+		referenced[id] = true
+	}
+
+	var links []models.EntityLink
+	err := s.db.Where("(source_type = ? AND source_id IN ?) OR (target_type = ? AND target_id IN ?)",
+		models.LinkEntityFile, fileIDs, models.LinkEntityFile, fileIDs).Find(&links).Error
+	if err != nil {
+		return nil, fmt.Errorf("检查文件引用失败: %w", err)
+	}
+	for _, link := range links {
+		if link.SourceType == models.LinkEntityFile {
+			referenced[link.SourceID] = true
+		}
+		if link.TargetType == models.LinkEntityFile {
+			referenced[link.TargetID] = true
+		}
+	}
+
+	// 工单附件按磁盘路径引用文件
+	var files []models.File
+	if err := s.db.Where("id IN ?", fileIDs).Find(&files).Error; err != nil {
+		return nil, fmt.Errorf("检查文件引用失败: %w", err)
+	}
+	for _, file := range files {
+		var count int64
+		if err := s.db.Model(&models.TicketAttachment{}).Where("file_path = ?", file.Path).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("检查文件引用失败: %w", err)
+		}
+		if count > 0 {
+			referenced[file.ID] = true
+		}
+	}
+
+	return referenced, nil
+}
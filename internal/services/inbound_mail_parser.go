@@ -0,0 +1,247 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// maxMIMEDepth 邮件正文嵌套层数上限，避免构造的邮件导致深度递归
+const maxMIMEDepth = 10
+
+// inboundAttachment 邮件附件
+type inboundAttachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// parsedEmail 解析后的邮件
+type parsedEmail struct {
+	Header      mail.Header
+	MessageID   string
+	From        string // 小写的发件人地址
+	Subject     string
+	ContentType string
+	Text        string
+	References  []string // In-Reply-To 与 References 中的 Message-ID
+	Attachments []inboundAttachment
+}
+
+var (
+	messageIDPattern = regexp.MustCompile(`<[^<>\s]+>`)
+	htmlDropPattern  = regexp.MustCompile(`(?is)<(script|style|head)[^>]*>.*?</(script|style|head)>`)
+	htmlBreakPattern = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|li|tr|h[1-6])>`)
+	htmlTagPattern   = regexp.MustCompile(`<[^>]*>`)
+	blankLinePattern = regexp.MustCompile(`\n{3,}`)
+)
+
+var mailWordDecoder = &mime.WordDecoder{CharsetReader: mailCharsetReader}
+
+// parseInboundEmail 解析原始邮件，提取发件人、主题、正文与附件
+func parseInboundEmail(raw []byte) (*parsedEmail, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("邮件格式错误: %w", err)
+	}
+
+	email := &parsedEmail{Header: msg.Header}
+	email.MessageID = strings.TrimSpace(msg.Header.Get("Message-ID"))
+	if email.MessageID == "" {
+		// 没有 Message-ID 时按内容生成，重复投递同一封邮件仍可去重
+		email.MessageID = contentMessageID(raw)
+	}
+	if subject, err := mailWordDecoder.DecodeHeader(msg.Header.Get("Subject")); err == nil {
+		email.Subject = strings.TrimSpace(subject)
+	} else {
+		email.Subject = strings.TrimSpace(msg.Header.Get("Subject"))
+	}
+	parser := mail.AddressParser{WordDecoder: mailWordDecoder}
+	if from, err := parser.Parse(msg.Header.Get("From")); err == nil {
+		email.From = strings.ToLower(from.Address)
+	}
+	for _, header := range []string{"In-Reply-To", "References"} {
+		email.References = append(email.References, messageIDPattern.FindAllString(msg.Header.Get(header), -1)...)
+	}
+
+	email.ContentType, _, _ = mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	var text, htmlText string
+	if err := walkMIMEPart(msg.Header, msg.Body, 0, &text, &htmlText, &email.Attachments); err != nil {
+		return nil, err
+	}
+	email.Text = strings.TrimSpace(text)
+	if email.Text == "" && htmlText != "" {
+		email.Text = htmlToText(htmlText)
+	}
+	return email, nil
+}
+
+// contentMessageID 按邮件内容生成去重标识
+func contentMessageID(raw []byte) string {
+	sum := sha256.Sum256(raw)
+	return fmt.Sprintf("<%x@inbound.local>", sum[:16])
+}
+
+// mimeHeader 邮件头与分段头的公共接口
+type mimeHeader interface {
+	Get(key string) string
+}
+
+// walkMIMEPart 递归读取邮件分段，取第一个纯文本与 HTML 正文，带文件名的分段作为附件
+func walkMIMEPart(header mimeHeader, body io.Reader, depth int, text, htmlText *string, attachments *[]inboundAttachment) error {
+	if depth > maxMIMEDepth {
+		return fmt.Errorf("邮件嵌套层数过多")
+	}
+
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("邮件格式错误: %w", err)
+			}
+			if err := walkMIMEPart(part.Header, part, depth+1, text, htmlText, attachments); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := io.ReadAll(decodeTransferEncoding(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return fmt.Errorf("邮件内容解码失败: %w", err)
+	}
+
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := dispositionParams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	if decoded, err := mailWordDecoder.DecodeHeader(filename); err == nil {
+		filename = decoded
+	}
+	filename = filepath.Base(strings.ReplaceAll(filename, "\\", "/"))
+	if filename == "." || filename == "/" {
+		filename = ""
+	}
+
+	switch {
+	case filename != "" || disposition == "attachment":
+		if filename == "" {
+			filename = "attachment"
+		}
+		contentType := mediaType
+		if contentType == "" || contentType == "application/octet-stream" {
+			if guessed := mime.TypeByExtension(filepath.Ext(filename)); guessed != "" {
+				contentType, _, _ = mime.ParseMediaType(guessed)
+			}
+		}
+		*attachments = append(*attachments, inboundAttachment{Filename: filename, ContentType: contentType, Data: data})
+	case mediaType == "text/plain" && *text == "":
+		*text = decodeCharset(data, params["charset"])
+	case mediaType == "text/html" && *htmlText == "":
+		*htmlText = decodeCharset(data, params["charset"])
+	}
+	return nil
+}
+
+// decodeTransferEncoding 按 Content-Transfer-Encoding 解码分段内容
+func decodeTransferEncoding(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	default:
+		return body
+	}
+}
+
+// mailCharsetReader 将非 UTF-8 编码（如 GBK、GB18030、Big5）转换为 UTF-8
+func mailCharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(strings.TrimSpace(charset)) {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+		return input, nil
+	}
+	encoding, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, fmt.Errorf("不支持的字符集: %s", charset)
+	}
+	return encoding.NewDecoder().Reader(input), nil
+}
+
+// decodeCharset 转换正文编码，无法识别的字符集按原样返回
+func decodeCharset(data []byte, charset string) string {
+	reader, err := mailCharsetReader(charset, bytes.NewReader(data))
+	if err != nil {
+		return string(data)
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		return string(data)
+	}
+	return string(decoded)
+}
+
+// htmlToText 将 HTML 正文转换为纯文本
+func htmlToText(content string) string {
+	content = htmlDropPattern.ReplaceAllString(content, "")
+	content = htmlBreakPattern.ReplaceAllString(content, "\n")
+	content = htmlTagPattern.ReplaceAllString(content, "")
+	content = html.UnescapeString(content)
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(blankLinePattern.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+// replyMarkers 邮件客户端引用原文前的分隔行
+var replyMarkers = []*regexp.Regexp{
+	regexp.MustCompile(`^-{2,}\s*(Original Message|原始邮件|Forwarded message|转发的邮件)\s*-{2,}`),
+	regexp.MustCompile(`(?i)^On .+wrote:$`),
+	regexp.MustCompile(`^在.+写道[：:]$`),
+	regexp.MustCompile(`^(发件人|From)[：:]\s*.+`),
+}
+
+// stripQuotedReply 去掉回复邮件中引用的原文，只保留新写的内容
+func stripQuotedReply(text string) string {
+	var kept []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		quoted := false
+		for _, marker := range replyMarkers {
+			if marker.MatchString(trimmed) {
+				quoted = true
+				break
+			}
+		}
+		if quoted {
+			break
+		}
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+		kept = append(kept, strings.TrimRight(line, " \t"))
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
@@ -0,0 +1,379 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// InboundMailOptions 邮件转工单选项
+type InboundMailOptions struct {
+	Address          string // 系统收件地址，用于识别邮件回环
+	DefaultType      string
+	DefaultPriority  string
+	MaxPerSenderHour int   // 同一发件人每小时最多处理的邮件数，0 表示不限制
+	MaxMessageSize   int64 // 单封邮件大小上限（字节），0 表示不限制
+}
+
+// InboundMailService 邮件转工单服务：新邮件创建工单，带工单编号的回复追加为评论
+type InboundMailService struct {
+	db                *gorm.DB
+	fileService       *FileService
+	workflowService   *TicketWorkflowService
+	slaService        *TicketSLAService
+	assignmentService *TicketAssignmentService
+	watchService      *WatchService
+	options           InboundMailOptions
+	now               func() time.Time
+}
+
+// NewInboundMailService 创建邮件转工单服务
+func NewInboundMailService(db *gorm.DB, fileService *FileService, workflowService *TicketWorkflowService, slaService *TicketSLAService, assignmentService *TicketAssignmentService, watchService *WatchService, options InboundMailOptions) *InboundMailService {
+	options.Address = strings.ToLower(strings.TrimSpace(options.Address))
+	switch models.TicketType(options.DefaultType) {
+	case models.TicketTypeBug, models.TicketTypeFeature, models.TicketTypeSupport, models.TicketTypeChange, models.TicketTypeCustom:
+	default:
+		options.DefaultType = string(models.TicketTypeSupport)
+	}
+	switch models.TicketPriority(options.DefaultPriority) {
+	case models.TicketPriorityLow, models.TicketPriorityNormal, models.TicketPriorityHigh, models.TicketPriorityCritical:
+	default:
+		options.DefaultPriority = string(models.TicketPriorityNormal)
+	}
+	return &InboundMailService{
+		db:                db,
+		fileService:       fileService,
+		workflowService:   workflowService,
+		slaService:        slaService,
+		assignmentService: assignmentService,
+		watchService:      watchService,
+		options:           options,
+		now:               time.Now,
+	}
+}
+
+var (
+	ticketRefPattern     = regexp.MustCompile(`(?i)(?:\[#|工单\s*#|ticket\s*#)(\d+)\]?`)
+	replyPrefixPattern   = regexp.MustCompile(`(?i)^\s*(re|fw|fwd|回复|答复|转发)\s*[:：]\s*`)
+	ticketRefStripFormat = regexp.MustCompile(`(?i)\s*\[#\d+\]\s*`)
+)
+
+// Process 处理一封原始邮件并记录处理结果；同一 Message-ID 只处理一次。
+// 仅在数据库等基础设施故障时返回错误，调用方可稍后重试
+func (s *InboundMailService) Process(raw []byte, source string) (*models.InboundEmail, error) {
+	record := &models.InboundEmail{Source: source, ReceivedAt: s.now()}
+
+	if s.options.MaxMessageSize > 0 && int64(len(raw)) > s.options.MaxMessageSize {
+		return s.finish(record, &parsedEmail{MessageID: contentMessageID(raw)}, models.InboundEmailRejected, "邮件大小超过限制")
+	}
+
+	email, err := parseInboundEmail(raw)
+	if err != nil {
+		return s.finish(record, &parsedEmail{MessageID: contentMessageID(raw)}, models.InboundEmailFailed, err.Error())
+	}
+
+	var existing models.InboundEmail
+	if err := s.db.Where("message_id = ?", email.MessageID).First(&existing).Error; err == nil {
+		return &existing, nil
+	} else if err != gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("查询邮件记录失败: %w", err)
+	}
+
+	if reason := s.loopReason(email); reason != "" {
+		return s.finish(record, email, models.InboundEmailIgnored, reason)
+	}
+
+	var user models.User
+	if err := s.db.Where("LOWER(email) = ? AND is_active = ?", email.From, true).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return s.finish(record, email, models.InboundEmailRejected, "发件人未注册或已停用: "+email.From)
+		}
+		return nil, fmt.Errorf("查询发件人失败: %w", err)
+	}
+	record.UserID = &user.ID
+
+	if s.options.MaxPerSenderHour > 0 {
+		var count int64
+		if err := s.db.Model(&models.InboundEmail{}).
+			Where("from_address = ? AND received_at > ? AND status IN ?", email.From, record.ReceivedAt.Add(-time.Hour),
+				[]string{models.InboundEmailCreated, models.InboundEmailCommented}).
+			Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("查询发件频率失败: %w", err)
+		}
+		if count >= int64(s.options.MaxPerSenderHour) {
+			return s.finish(record, email, models.InboundEmailIgnored, "发件频率过高，疑似邮件循环")
+		}
+	}
+
+	ticket, err := s.referencedTicket(email)
+	if err != nil {
+		return nil, err
+	}
+	if ticket != nil {
+		return s.addComment(record, email, ticket, &user)
+	}
+	return s.createTicket(record, email, &user)
+}
+
+// loopReason 识别自动回复、退信与邮件回环，返回忽略原因
+func (s *InboundMailService) loopReason(email *parsedEmail) string {
+	header := email.Header
+	if value := strings.ToLower(strings.TrimSpace(header.Get("Auto-Submitted"))); value != "" && value != "no" {
+		return "自动发送的邮件 (Auto-Submitted: " + value + ")"
+	}
+	if header.Get("X-Autoreply") != "" || header.Get("X-Autorespond") != "" || header.Get("X-Auto-Response-Suppress") == "All" {
+		return "自动回复邮件"
+	}
+	switch strings.ToLower(strings.TrimSpace(header.Get("Precedence"))) {
+	case "bulk", "junk", "list", "auto_reply":
+		return "批量或自动发送的邮件"
+	}
+	if strings.TrimSpace(header.Get("Return-Path")) == "<>" || email.ContentType == "multipart/report" {
+		return "退信或投递状态报告"
+	}
+	if email.From == "" {
+		return "缺少发件人"
+	}
+	switch strings.SplitN(email.From, "@", 2)[0] {
+	case "mailer-daemon", "postmaster":
+		return "退信或投递状态报告"
+	}
+	if s.options.Address != "" {
+		if email.From == s.options.Address || strings.EqualFold(strings.TrimSpace(header.Get("X-Loop")), s.options.Address) {
+			return "邮件回环"
+		}
+	}
+	return ""
+}
+
+// referencedTicket 按 X-Ticket-ID 头、主题中的工单编号或 In-Reply-To/References 查找回复的工单
+func (s *InboundMailService) referencedTicket(email *parsedEmail) (*models.Ticket, error) {
+	var ids []uint
+	if id, err := strconv.ParseUint(strings.TrimSpace(email.Header.Get("X-Ticket-ID")), 10, 32); err == nil {
+		ids = append(ids, uint(id))
+	}
+	if match := ticketRefPattern.FindStringSubmatch(email.Subject); match != nil {
+		if id, err := strconv.ParseUint(match[1], 10, 32); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	if len(email.References) > 0 {
+		var previous models.InboundEmail
+		err := s.db.Where("message_id IN ? AND ticket_id IS NOT NULL", email.References).
+			Order("received_at DESC").First(&previous).Error
+		if err == nil {
+			ids = append(ids, *previous.TicketID)
+		} else if err != gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("查询关联邮件失败: %w", err)
+		}
+	}
+
+	for _, id := range ids {
+		var ticket models.Ticket
+		err := s.db.First(&ticket, id).Error
+		if err == nil {
+			return &ticket, nil
+		}
+		if err != gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("查询工单失败: %w", err)
+		}
+	}
+	return nil, nil
+}
+
+// createTicket 以邮件主题为标题、正文为描述创建工单，发件人为创建者
+func (s *InboundMailService) createTicket(record *models.InboundEmail, email *parsedEmail, user *models.User) (*models.InboundEmail, error) {
+	title := cleanMailSubject(email.Subject)
+	if title == "" {
+		title = "(无主题)"
+	}
+	ticket := models.Ticket{
+		Title:       truncateString(title, 500),
+		Description: email.Text,
+		Type:        models.TicketType(s.options.DefaultType),
+		Priority:    models.TicketPriority(s.options.DefaultPriority),
+		Status:      s.workflowService.InitialStatus(s.options.DefaultType, ""),
+		CreatorID:   user.ID,
+		Metadata:    models.JSONB{"source": "email", "message_id": email.MessageID},
+	}
+
+	files, skipped := s.saveAttachments(email, user.ID)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&ticket).Error; err != nil {
+			return err
+		}
+		if err := AddWatchers(tx, models.WatchEntityTicket, ticket.ID, models.WatchReasonCreator, user.ID); err != nil {
+			return err
+		}
+		if err := createMailAttachments(tx, ticket.ID, user.ID, files); err != nil {
+			return err
+		}
+		return tx.Create(&models.TicketHistory{TicketID: ticket.ID, UserID: user.ID, Action: "created", Description: "通过邮件创建工单"}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建工单失败: %w", err)
+	}
+
+	if s.slaService != nil {
+		if err := s.slaService.ApplyToTicket(&ticket); err != nil {
+			fmt.Printf("Warning: failed to apply SLA to ticket %d: %v\n", ticket.ID, err)
+		}
+	}
+	if s.assignmentService != nil {
+		if _, err := s.assignmentService.ApplyToTicket(&ticket, s.workflowService); err != nil {
+			fmt.Printf("Warning: failed to auto assign ticket %d: %v\n", ticket.ID, err)
+		}
+	}
+	if s.watchService != nil {
+		s.watchService.NotifyWatchers(models.WatchEntityTicket, ticket.ID, user.ID, "工单通知", "新工单已创建："+ticket.Title)
+	}
+
+	record.TicketID = &ticket.ID
+	record.Attachments = len(files)
+	return s.finish(record, email, models.InboundEmailCreated, skipped)
+}
+
+// addComment 将回复邮件去掉引用原文后追加为工单评论，发件人需能查看该工单或已关注该工单
+func (s *InboundMailService) addComment(record *models.InboundEmail, email *parsedEmail, ticket *models.Ticket, user *models.User) (*models.InboundEmail, error) {
+	record.TicketID = &ticket.ID
+	if !canViewTicket(s.db, ticket, &TicketActor{UserID: user.ID}) && !s.isWatcher(ticket.ID, user.ID) {
+		return s.finish(record, email, models.InboundEmailRejected, "无权回复该工单")
+	}
+
+	content := stripQuotedReply(email.Text)
+	if content == "" && len(email.Attachments) == 0 {
+		return s.finish(record, email, models.InboundEmailIgnored, "回复内容为空")
+	}
+	if content == "" {
+		content = "(通过邮件上传了附件)"
+	}
+
+	files, skipped := s.saveAttachments(email, user.ID)
+	comment := models.TicketComment{TicketID: ticket.ID, UserID: user.ID, Content: content, IsPublic: true}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		if err := createMailAttachments(tx, ticket.ID, user.ID, files); err != nil {
+			return err
+		}
+		return tx.Create(&models.TicketHistory{TicketID: ticket.ID, UserID: user.ID, Action: "commented", Description: "通过邮件添加了评论"}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("添加评论失败: %w", err)
+	}
+
+	if s.slaService != nil {
+		s.slaService.RecordResponse(ticket, user.ID)
+	}
+	if s.watchService != nil {
+		s.watchService.NotifyWatchers(models.WatchEntityTicket, ticket.ID, user.ID, "工单通知", "工单有新评论："+ticket.Title+"\n通过邮件回复")
+	}
+
+	record.CommentID = &comment.ID
+	record.Attachments = len(files)
+	return s.finish(record, email, models.InboundEmailCommented, skipped)
+}
+
+// saveAttachments 保存邮件附件，类型或大小不符合上传规则的附件跳过并返回说明
+func (s *InboundMailService) saveAttachments(email *parsedEmail, userID uint) ([]*models.File, string) {
+	var files []*models.File
+	var skipped []string
+	for _, attachment := range email.Attachments {
+		file, err := s.fileService.SaveFileBytes(attachment.Filename, attachment.ContentType, attachment.Data, userID)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("附件 %s 未保存: %v", attachment.Filename, err))
+			continue
+		}
+		file.OriginalName = attachment.Filename
+		files = append(files, file)
+	}
+	return files, strings.Join(skipped, "; ")
+}
+
+// isWatcher 检查用户是否关注了工单
+func (s *InboundMailService) isWatcher(ticketID, userID uint) bool {
+	var count int64
+	s.db.Model(&models.Watcher{}).
+		Where("entity_type = ? AND entity_id = ? AND user_id = ?", models.WatchEntityTicket, ticketID, userID).
+		Count(&count)
+	return count > 0
+}
+
+// finish 保存邮件处理记录；并发投递同一封邮件时以先写入的记录为准
+func (s *InboundMailService) finish(record *models.InboundEmail, email *parsedEmail, status, reason string) (*models.InboundEmail, error) {
+	record.MessageID = truncateString(email.MessageID, 500)
+	record.FromAddress = truncateString(email.From, 255)
+	record.Subject = truncateString(email.Subject, 500)
+	record.Status = status
+	record.Reason = truncateString(reason, 500)
+	if err := s.db.Create(record).Error; err != nil {
+		var existing models.InboundEmail
+		if s.db.Where("message_id = ?", record.MessageID).First(&existing).Error == nil {
+			return &existing, nil
+		}
+		return nil, fmt.Errorf("保存邮件记录失败: %w", err)
+	}
+	return record, nil
+}
+
+// ListEmails 分页查询邮件处理记录，可按状态筛选
+func (s *InboundMailService) ListEmails(status string, page, pageSize int) ([]models.InboundEmail, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	query := s.db.Model(&models.InboundEmail{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询邮件记录失败: %w", err)
+	}
+	var emails []models.InboundEmail
+	if err := query.Order("received_at DESC, id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&emails).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询邮件记录失败: %w", err)
+	}
+	return emails, total, nil
+}
+
+// createMailAttachments 为已保存的邮件附件创建工单附件记录
+func createMailAttachments(tx *gorm.DB, ticketID, userID uint, files []*models.File) error {
+	for _, file := range files {
+		attachment := models.TicketAttachment{
+			TicketID:    ticketID,
+			FileName:    file.OriginalName,
+			FileSize:    file.Size,
+			ContentType: file.MimeType,
+			FilePath:    file.Path,
+			UploadedBy:  userID,
+		}
+		if err := tx.Create(&attachment).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cleanMailSubject 去掉回复/转发前缀与工单编号
+func cleanMailSubject(subject string) string {
+	subject = ticketRefStripFormat.ReplaceAllString(subject, " ")
+	for {
+		cleaned := replyPrefixPattern.ReplaceAllString(subject, "")
+		if cleaned == subject {
+			break
+		}
+		subject = cleaned
+	}
+	return strings.TrimSpace(subject)
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"fmt"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"info-management-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupInboundMailTest(t *testing.T) *InboundMailService {
+	workflow := setupTicketWorkflowTest(t)
+	db := workflow.db
+
+	fileService := NewFileService(db, nil)
+	fileService.uploadPath = t.TempDir()
+	return NewInboundMailService(db, fileService, workflow, workflow.slaService, NewTicketAssignmentService(db, nil), workflow.watchService, InboundMailOptions{
+		Address:          "helpdesk@example.com",
+		DefaultType:      "support",
+		MaxPerSenderHour: 5,
+	})
+}
+
+// deliverToMaildir 按 maildir 约定先写入 tmp 再移入 new
+func deliverToMaildir(t *testing.T, dir, name, raw string) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, sub), 0755))
+	}
+	tmp := filepath.Join(dir, "tmp", name)
+	require.NoError(t, os.WriteFile(tmp, []byte(strings.ReplaceAll(raw, "\n", "\r\n")), 0644))
+	require.NoError(t, os.Rename(tmp, filepath.Join(dir, "new", name)))
+}
+
+func TestInboundMailService_Maildir(t *testing.T) {
+	s := setupInboundMailTest(t)
+	dir := t.TempDir()
+
+	deliverToMaildir(t, dir, "1.eml", `From: Alice <Alice@Example.com>
+To: helpdesk@example.com
+Subject: =?UTF-8?B?5omT5Y2w5py65peg5rOV6L+e5o6l?=
+Message-ID: <m1@example.com>
+MIME-Version: 1.0
+Content-Type: multipart/mixed; boundary="b1"
+
+--b1
+Content-Type: text/plain; charset=utf-8
+Content-Transfer-Encoding: quoted-printable
+
+3=E6=A5=BC=E6=89=93=E5=8D=B0=E6=9C=BA=E6=8A=A5=E9=94=99
+--b1
+Content-Type: text/plain; name="log.txt"
+Content-Disposition: attachment; filename="log.txt"
+Content-Transfer-Encoding: base64
+
+ZXJyb3IgMHgwMQ==
+--b1
+Content-Type: application/x-msdownload
+Content-Disposition: attachment; filename="setup.exe"
+Content-Transfer-Encoding: base64
+
+TVo=
+--b1--
+`)
+	deliverToMaildir(t, dir, "2.eml", `From: alice@example.com
+To: helpdesk@example.com
+Subject: Out of office
+Message-ID: <m2@example.com>
+Auto-Submitted: auto-replied
+
+I am away.
+`)
+	deliverToMaildir(t, dir, "3.eml", `From: stranger@elsewhere.com
+To: helpdesk@example.com
+Subject: Hello
+Message-ID: <m3@example.com>
+
+Who am I?
+`)
+	deliverToMaildir(t, dir, "4.eml", `From: helpdesk@example.com
+To: helpdesk@example.com
+Subject: Loop
+Message-ID: <m4@example.com>
+
+loop
+`)
+
+	result, err := s.PollMaildir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, MaildirPollResult{Processed: 4, Created: 1, Skipped: 3}, *result)
+
+	newEntries, _ := os.ReadDir(filepath.Join(dir, "new"))
+	curEntries, _ := os.ReadDir(filepath.Join(dir, "cur"))
+	assert.Empty(t, newEntries)
+	require.Len(t, curEntries, 4)
+	assert.Equal(t, "1.eml:2,S", curEntries[0].Name())
+
+	var emails []models.InboundEmail
+	require.NoError(t, s.db.Order("id").Find(&emails).Error)
+	require.Len(t, emails, 4)
+	assert.Equal(t, models.InboundEmailCreated, emails[0].Status)
+	assert.Contains(t, emails[0].Reason, "setup.exe")
+	assert.Equal(t, 1, emails[0].Attachments)
+	assert.Equal(t, models.InboundEmailIgnored, emails[1].Status)
+	assert.Equal(t, models.InboundEmailRejected, emails[2].Status)
+	assert.Equal(t, models.InboundEmailIgnored, emails[3].Status)
+	assert.Equal(t, "邮件回环", emails[3].Reason)
+
+	var ticket models.Ticket
+	require.NoError(t, s.db.First(&ticket, *emails[0].TicketID).Error)
+	assert.Equal(t, "打印机无法连接", ticket.Title)
+	assert.Equal(t, "3楼打印机报错", ticket.Description)
+	assert.Equal(t, uint(2), ticket.CreatorID)
+	assert.Equal(t, models.TicketTypeSupport, ticket.Type)
+	assert.Equal(t, models.TicketStatusSubmitted, ticket.Status)
+
+	var attachments []models.TicketAttachment
+	require.NoError(t, s.db.Where("ticket_id = ?", ticket.ID).Find(&attachments).Error)
+	require.Len(t, attachments, 1)
+	assert.Equal(t, "log.txt", attachments[0].FileName)
+	data, err := os.ReadFile(attachments[0].FilePath)
+	require.NoError(t, err)
+	assert.Equal(t, "error 0x01", string(data))
+
+	// 回复：主题中的工单编号与 In-Reply-To 均可关联工单，引用的原文不写入评论
+	deliverToMaildir(t, dir, "5.eml", fmt.Sprintf(`From: alice@example.com
+To: helpdesk@example.com
+Subject: Re: [#%d] 打印机无法连接
+Message-ID: <m5@example.com>
+
+重启后还是不行
+
+On Mon, 1 Jan 2024, helpdesk wrote:
+> 请重启打印机
+`, ticket.ID))
+	deliverToMaildir(t, dir, "6.eml", `From: alice@example.com
+To: helpdesk@example.com
+Subject: Re: something else
+Message-ID: <m6@example.com>
+In-Reply-To: <m1@example.com>
+
+补充：型号 HP 1020
+`)
+	// bob 既不是创建者也没有查看权限，不能通过邮件回复
+	deliverToMaildir(t, dir, "7.eml", fmt.Sprintf(`From: bob@example.com
+To: helpdesk@example.com
+Subject: [#%d]
+Message-ID: <m7@example.com>
+
+插一句
+`, ticket.ID))
+	// 重复投递同一封邮件只处理一次
+	deliverToMaildir(t, dir, "8.eml", fmt.Sprintf(`From: alice@example.com
+To: helpdesk@example.com
+Subject: Re: [#%d] 打印机无法连接
+Message-ID: <m5@example.com>
+
+重启后还是不行
+`, ticket.ID))
+
+	result, err = s.PollMaildir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, MaildirPollResult{Processed: 4, Commented: 3, Skipped: 1}, *result)
+
+	var comments []models.TicketComment
+	require.NoError(t, s.db.Where("ticket_id = ?", ticket.ID).Order("id").Find(&comments).Error)
+	require.Len(t, comments, 2)
+	assert.Equal(t, "重启后还是不行", comments[0].Content)
+	assert.Equal(t, "补充：型号 HP 1020", comments[1].Content)
+
+	var rejected models.InboundEmail
+	require.NoError(t, s.db.Where("message_id = ?", "<m7@example.com>").First(&rejected).Error)
+	assert.Equal(t, models.InboundEmailRejected, rejected.Status)
+	assert.Equal(t, "无权回复该工单", rejected.Reason)
+
+	var count int64
+	s.db.Model(&models.InboundEmail{}).Count(&count)
+	assert.Equal(t, int64(7), count)
+
+	// 超过每小时发件上限视为邮件循环
+	for i := 0; i < 3; i++ {
+		deliverToMaildir(t, dir, fmt.Sprintf("burst-%d.eml", i), fmt.Sprintf(`From: alice@example.com
+Subject: burst %d
+Message-ID: <burst-%d@example.com>
+
+again
+`, i, i))
+	}
+	result, err = s.PollMaildir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Created)
+	assert.Equal(t, 1, result.Skipped)
+
+	_, err = s.PollMaildir(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestInboundMailService_SMTP(t *testing.T) {
+	s := setupInboundMailTest(t)
+	server := NewInboundSMTPServer(s, "mail.test", 1024)
+	require.NoError(t, server.Listen("127.0.0.1:0"))
+	defer server.Close()
+	addr := server.Addr().String()
+
+	msg := "From: Carol <carol@example.com>\r\n" +
+		"To: helpdesk@example.com\r\n" +
+		"Subject: VPN 无法登录\r\n" +
+		"Message-ID: <smtp-1@example.com>\r\n" +
+		"Content-Type: text/html; charset=utf-8\r\n" +
+		"\r\n" +
+		"<p>提示&nbsp;密码错误</p><p>..已重置</p>\r\n"
+	require.NoError(t, smtp.SendMail(addr, nil, "carol@example.com", []string{"helpdesk@example.com"}, []byte(msg)))
+
+	var email models.InboundEmail
+	require.NoError(t, s.db.Where("message_id = ?", "<smtp-1@example.com>").First(&email).Error)
+	assert.Equal(t, "smtp", email.Source)
+	assert.Equal(t, models.InboundEmailCreated, email.Status)
+	var ticket models.Ticket
+	require.NoError(t, s.db.First(&ticket, *email.TicketID).Error)
+	assert.Equal(t, "VPN 无法登录", ticket.Title)
+	assert.Equal(t, "提示 密码错误\n..已重置", ticket.Description)
+	assert.Equal(t, uint(4), ticket.CreatorID)
+
+	// 空信封发件人为退信
+	bounce := "From: carol@example.com\r\nSubject: Undelivered\r\nMessage-ID: <smtp-2@example.com>\r\n\r\nfailed\r\n"
+	require.NoError(t, smtp.SendMail(addr, nil, "", []string{"helpdesk@example.com"}, []byte(bounce)))
+	var bounced models.InboundEmail
+	require.NoError(t, s.db.Where("message_id = ?", "<smtp-2@example.com>").First(&bounced).Error)
+	assert.Equal(t, models.InboundEmailIgnored, bounced.Status)
+
+	// 超过大小限制的邮件被拒收
+	large := "From: carol@example.com\r\nSubject: big\r\n\r\n" + strings.Repeat("x", 2048) + "\r\n"
+	err := smtp.SendMail(addr, nil, "carol@example.com", []string{"helpdesk@example.com"}, []byte(large))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "552")
+
+	var count int64
+	s.db.Model(&models.Ticket{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestStripQuotedReply(t *testing.T) {
+	assert.Equal(t, "好的，谢谢", stripQuotedReply("好的，谢谢\n\n在 2024年1月1日 周一 10:00，helpdesk 写道：\n> 已处理"))
+	assert.Equal(t, "ok", stripQuotedReply("ok\r\n-----Original Message-----\r\nFrom: x"))
+	assert.Equal(t, "a\nb", stripQuotedReply("> quoted\na\n> more\nb"))
+	assert.Equal(t, "打印机坏了", cleanMailSubject("Re: 回复：[#12] 打印机坏了"))
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"info-management-system/internal/models"
+)
+
+// MaildirPollResult 一次读取 maildir 的处理统计
+type MaildirPollResult struct {
+	Processed int `json:"processed"`
+	Created   int `json:"created"`
+	Commented int `json:"commented"`
+	Skipped   int `json:"skipped"` // 忽略、拒绝或解析失败；重复投递的邮件按首次处理结果统计
+	Failed    int `json:"failed"`  // 处理出错，邮件保留在 new 目录等待下次重试
+}
+
+// PollMaildir 处理 maildir 中 new 目录下的邮件，处理完成后移入 cur 目录并标记为已读
+func (s *InboundMailService) PollMaildir(dir string) (*MaildirPollResult, error) {
+	newDir, curDir := filepath.Join(dir, "new"), filepath.Join(dir, "cur")
+	entries, err := os.ReadDir(newDir)
+	if err != nil {
+		return nil, fmt.Errorf("读取 maildir 失败: %w", err)
+	}
+	if err := os.MkdirAll(curDir, 0755); err != nil {
+		return nil, fmt.Errorf("创建 maildir cur 目录失败: %w", err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	result := &MaildirPollResult{}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(newDir, entry.Name())
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			result.Failed++
+			continue
+		}
+		record, err := s.Process(raw, "maildir")
+		if err != nil {
+			fmt.Printf("Warning: failed to process inbound mail %s: %v\n", entry.Name(), err)
+			result.Failed++
+			continue
+		}
+		result.Processed++
+		switch record.Status {
+		case models.InboundEmailCreated:
+			result.Created++
+		case models.InboundEmailCommented:
+			result.Commented++
+		default:
+			result.Skipped++
+		}
+
+		name := entry.Name()
+		if !strings.Contains(name, ":2,") {
+			name += ":2,S"
+		}
+		if err := os.Rename(path, filepath.Join(curDir, name)); err != nil {
+			return result, fmt.Errorf("移动邮件到 cur 目录失败: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// InboundSMTPServer 接收邮件的最小 SMTP 服务，只接收投递不转发，适合放在 MTA 之后或用于本地测试
+type InboundSMTPServer struct {
+	service  *InboundMailService
+	domain   string
+	maxSize  int64
+	timeout  time.Duration
+	listener net.Listener
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	conns    map[net.Conn]struct{}
+	closed   bool
+}
+
+// NewInboundSMTPServer 创建 SMTP 收件服务，maxSize 为单封邮件大小上限（字节）
+func NewInboundSMTPServer(service *InboundMailService, domain string, maxSize int64) *InboundSMTPServer {
+	if domain == "" {
+		domain = "localhost"
+	}
+	return &InboundSMTPServer{
+		service: service,
+		domain:  domain,
+		maxSize: maxSize,
+		timeout: 5 * time.Minute,
+		conns:   make(map[net.Conn]struct{}),
+	}
+}
+
+// Listen 开始监听并在后台接收连接
+func (s *InboundSMTPServer) Listen(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("SMTP 监听失败: %w", err)
+	}
+	s.listener = listener
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			if s.closed {
+				s.mu.Unlock()
+				conn.Close()
+				return
+			}
+			s.conns[conn] = struct{}{}
+			s.mu.Unlock()
+
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.serve(conn)
+				s.mu.Lock()
+				delete(s.conns, conn)
+				s.mu.Unlock()
+			}()
+		}
+	}()
+	return nil
+}
+
+// Addr 返回实际监听地址
+func (s *InboundSMTPServer) Addr() net.Addr {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Close 停止监听并断开现有连接
+func (s *InboundSMTPServer) Close() error {
+	s.mu.Lock()
+	if s.closed || s.listener == nil {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	err := s.listener.Close()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+// serve 处理一个 SMTP 会话
+func (s *InboundSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	reply := func(format string, args ...interface{}) bool {
+		conn.SetWriteDeadline(time.Now().Add(s.timeout))
+		return tp.PrintfLine(format, args...) == nil
+	}
+
+	var from string
+	var started bool
+	var recipients int
+	reset := func() {
+		from, started, recipients = "", false, 0
+	}
+
+	if !reply("220 %s ESMTP ready", s.domain) {
+		return
+	}
+	for {
+		conn.SetReadDeadline(time.Now().Add(s.timeout))
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		arg = strings.TrimSpace(arg)
+
+		switch strings.ToUpper(verb) {
+		case "HELO":
+			reset()
+			reply("250 %s", s.domain)
+		case "EHLO":
+			reset()
+			reply("250-%s\r\n250-SIZE %d\r\n250-8BITMIME\r\n250 SMTPUTF8", s.domain, s.maxSize)
+		case "MAIL":
+			address, params, ok := parseSMTPPath(arg, "FROM:")
+			if !ok {
+				reply("501 Syntax: MAIL FROM:<address>")
+				continue
+			}
+			if size, err := strconv.ParseInt(params["SIZE"], 10, 64); err == nil && s.maxSize > 0 && size > s.maxSize {
+				reply("552 Message size exceeds fixed limit")
+				continue
+			}
+			reset()
+			from, started = address, true
+			reply("250 OK")
+		case "RCPT":
+			if !started {
+				reply("503 Need MAIL command")
+				continue
+			}
+			if _, _, ok := parseSMTPPath(arg, "TO:"); !ok {
+				reply("501 Syntax: RCPT TO:<address>")
+				continue
+			}
+			recipients++
+			reply("250 OK")
+		case "DATA":
+			if recipients == 0 {
+				reply("503 Need RCPT command")
+				continue
+			}
+			if !reply("354 End data with <CR><LF>.<CR><LF>") {
+				return
+			}
+			if !s.receive(tp, conn, from, reply) {
+				return
+			}
+			reset()
+		case "RSET":
+			reset()
+			reply("250 OK")
+		case "NOOP":
+			reply("250 OK")
+		case "VRFY":
+			reply("252 Cannot verify user")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// receive 读取 DATA 内容并交给邮件服务处理，返回 false 表示连接已不可用
+func (s *InboundSMTPServer) receive(tp *textproto.Conn, conn net.Conn, from string, reply func(string, ...interface{}) bool) bool {
+	conn.SetReadDeadline(time.Now().Add(s.timeout))
+	dotReader := tp.DotReader()
+	reader := dotReader
+	if s.maxSize > 0 {
+		reader = io.LimitReader(dotReader, s.maxSize+1)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return false
+	}
+	if s.maxSize > 0 && int64(len(data)) > s.maxSize {
+		// 读完剩余内容以便继续会话
+		if _, err := io.Copy(io.Discard, dotReader); err != nil {
+			return false
+		}
+		return reply("552 Message size exceeds fixed limit")
+	}
+
+	// 记录信封发件人，空发件人（退信）由邮件服务识别并忽略
+	var raw bytes.Buffer
+	fmt.Fprintf(&raw, "Return-Path: <%s>\r\n", from)
+	raw.Write(data)
+	if _, err := s.service.Process(raw.Bytes(), "smtp"); err != nil {
+		fmt.Printf("Warning: failed to process inbound mail: %v\n", err)
+		return reply("451 Requested action aborted: local error in processing")
+	}
+	return reply("250 OK: message accepted")
+}
+
+// parseSMTPPath 解析 MAIL FROM:<addr> 与 RCPT TO:<addr> 参数，返回地址与扩展参数
+func parseSMTPPath(arg, prefix string) (string, map[string]string, bool) {
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", nil, false
+	}
+	rest := strings.TrimSpace(arg[len(prefix):])
+	if !strings.HasPrefix(rest, "<") {
+		return "", nil, false
+	}
+	end := strings.Index(rest, ">")
+	if end < 0 {
+		return "", nil, false
+	}
+	params := make(map[string]string)
+	for _, field := range strings.Fields(rest[end+1:]) {
+		key, value, _ := strings.Cut(field, "=")
+		params[strings.ToUpper(key)] = value
+	}
+	return rest[1:end], params, true
+}
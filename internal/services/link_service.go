@@ -0,0 +1,295 @@
+package services
+
+import (
+	"fmt"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// LinkService 实体关联服务
+type LinkService struct {
+	db           *gorm.DB
+	auditService *AuditService
+}
+
+// NewLinkService 创建实体关联服务
+func NewLinkService(db *gorm.DB, auditService *AuditService) *LinkService {
+	return &LinkService{
+		db:           db,
+		auditService: auditService,
+	}
+}
+
+// LinkAccessScope 关联访问范围（决定用户可读取哪些记录、工单、文件）
+type LinkAccessScope struct {
+	UserID     uint
+	AllRecords bool
+	AllTickets bool
+	AllFiles   bool
+}
+
+// CreateLinkRequest 创建关联请求
+type CreateLinkRequest struct {
+	SourceType  string `json:"source_type" binding:"required,oneof=record ticket file"`
+	SourceID    uint   `json:"source_id" binding:"required"`
+	TargetType  string `json:"target_type" binding:"required,oneof=record ticket file"`
+	TargetID    uint   `json:"target_id" binding:"required"`
+	LinkType    string `json:"link_type" binding:"required,oneof=relates_to caused_by attachment_of duplicate_of"`
+	Description string `json:"description" binding:"max=500"`
+}
+
+// LinkQuery 关联查询参数
+type LinkQuery struct {
+	EntityType string `form:"entity_type" binding:"required,oneof=record ticket file"`
+	EntityID   uint   `form:"entity_id" binding:"required"`
+	LinkType   string `form:"link_type"`
+}
+
+// LinkResponse 关联响应（以查询实体为视角）
+type LinkResponse struct {
+	ID          uint   `json:"id"`
+	LinkType    string `json:"link_type"`
+	Direction   string `json:"direction"` // outgoing: 当前实体为源, incoming: 当前实体为目标
+	EntityType  string `json:"entity_type"`
+	EntityID    uint   `json:"entity_id"`
+	EntityTitle string `json:"entity_title"`
+	Description string `json:"description"`
+	CreatedBy   uint   `json:"created_by"`
+	Creator     string `json:"creator"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// CreateLink 创建关联，需同时具备两端实体的读取权限
+func (s *LinkService) CreateLink(req *CreateLinkRequest, scope *LinkAccessScope, ipAddress, userAgent string) (*models.EntityLink, error) {
+	if !models.IsValidLinkEntityType(req.SourceType) || !models.IsValidLinkEntityType(req.TargetType) {
+		return nil, fmt.Errorf("无效的关联实体类型")
+	}
+	if !models.IsValidLinkType(req.LinkType) {
+		return nil, fmt.Errorf("无效的关联类型: %s", req.LinkType)
+	}
+	if req.SourceType == req.TargetType && req.SourceID == req.TargetID {
+		return nil, fmt.Errorf("不能关联到自身")
+	}
+	if req.LinkType == models.LinkTypeAttachmentOf && req.SourceType != models.LinkEntityFile {
+		return nil, fmt.Errorf("附件关联的源必须是文件")
+	}
+	if req.LinkType == models.LinkTypeDuplicateOf && req.SourceType != req.TargetType {
+		return nil, fmt.Errorf("重复关联只能在同类实体之间建立")
+	}
+
+	if _, err := s.resolveEntityTitle(req.SourceType, req.SourceID, scope); err != nil {
+		return nil, err
+	}
+	if _, err := s.resolveEntityTitle(req.TargetType, req.TargetID, scope); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	s.db.Model(&models.EntityLink{}).
+		Where("source_type = ? AND source_id = ? AND target_type = ? AND target_id = ? AND link_type = ?",
+			req.SourceType, req.SourceID, req.TargetType, req.TargetID, req.LinkType).
+		Count(&count)
+	if count > 0 {
+		return nil, fmt.Errorf("关联已存在")
+	}
+
+	link := models.EntityLink{
+		SourceType:  req.SourceType,
+		SourceID:    req.SourceID,
+		TargetType:  req.TargetType,
+		TargetID:    req.TargetID,
+		LinkType:    req.LinkType,
+		Description: req.Description,
+		CreatedBy:   scope.UserID,
+	}
+
+	if err := s.db.Create(&link).Error; err != nil {
+		return nil, fmt.Errorf("创建关联失败: %w", err)
+	}
+
+	// 记录审计日志
+	if s.auditService != nil {
+		s.auditService.CreateAuditLog(&AuditLogRequest{
+			UserID:       scope.UserID,
+			Action:       "CREATE",
+			ResourceType: "link",
+			ResourceID:   link.ID,
+			NewValues:    linkAuditValues(&link),
+			IPAddress:    ipAddress,
+			UserAgent:    userAgent,
+		})
+	}
+
+	return &link, nil
+}
+
+// GetEntityLinks 获取实体的所有关联，对端实体不可读的关联会被过滤
+func (s *LinkService) GetEntityLinks(entityType string, entityID uint, linkType string, scope *LinkAccessScope) ([]LinkResponse, error) {
+	if !models.IsValidLinkEntityType(entityType) {
+		return nil, fmt.Errorf("无效的关联实体类型")
+	}
+
+	if _, err := s.resolveEntityTitle(entityType, entityID, scope); err != nil {
+		return nil, err
+	}
+
+	query := s.db.Preload("Creator").
+		Where("(source_type = ? AND source_id = ?) OR (target_type = ? AND target_id = ?)",
+			entityType, entityID, entityType, entityID)
+	if linkType != "" {
+		query = query.Where("link_type = ?", linkType)
+	}
+
+	var links []models.EntityLink
+	if err := query.Order("created_at ASC").Find(&links).Error; err != nil {
+		return nil, fmt.Errorf("获取关联失败: %w", err)
+	}
+
+	responses := make([]LinkResponse, 0, len(links))
+	for _, link := range links {
+		direction := "outgoing"
+		peerType, peerID := link.TargetType, link.TargetID
+		if link.SourceType != entityType || link.SourceID != entityID {
+			direction = "incoming"
+			peerType, peerID = link.SourceType, link.SourceID
+		}
+
+		title, err := s.resolveEntityTitle(peerType, peerID, scope)
+		if err != nil {
+			continue
+		}
+
+		responses = append(responses, LinkResponse{
+			ID:          link.ID,
+			LinkType:    link.LinkType,
+			Direction:   direction,
+			EntityType:  peerType,
+			EntityID:    peerID,
+			EntityTitle: title,
+			Description: link.Description,
+			CreatedBy:   link.CreatedBy,
+			Creator:     link.Creator.Username,
+			CreatedAt:   link.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	return responses, nil
+}
+
+// DeleteLink 删除关联，需同时具备两端实体的读取权限
+func (s *LinkService) DeleteLink(id uint, scope *LinkAccessScope, ipAddress, userAgent string) error {
+	var link models.EntityLink
+	if err := s.db.First(&link, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("关联不存在或无权访问")
+		}
+		return fmt.Errorf("获取关联失败: %w", err)
+	}
+
+	if _, err := s.resolveEntityTitle(link.SourceType, link.SourceID, scope); err != nil {
+		return fmt.Errorf("关联不存在或无权访问")
+	}
+	if _, err := s.resolveEntityTitle(link.TargetType, link.TargetID, scope); err != nil {
+		return fmt.Errorf("关联不存在或无权访问")
+	}
+	if link.LinkType == models.LinkTypeBlocks {
+		return fmt.Errorf("工单依赖请通过工单依赖接口删除")
+	}
+
+	if err := s.db.Delete(&link).Error; err != nil {
+		return fmt.Errorf("删除关联失败: %w", err)
+	}
+
+	// 记录审计日志
+	if s.auditService != nil {
+		s.auditService.CreateAuditLog(&AuditLogRequest{
+			UserID:       scope.UserID,
+			Action:       "DELETE",
+			ResourceType: "link",
+			ResourceID:   link.ID,
+			OldValues:    linkAuditValues(&link),
+			IPAddress:    ipAddress,
+			UserAgent:    userAgent,
+		})
+	}
+
+	return nil
+}
+
+// resolveEntityTitle 检查实体可读性并返回其标题
+func (s *LinkService) resolveEntityTitle(entityType string, entityID uint, scope *LinkAccessScope) (string, error) {
+	return resolveEntityTitle(s.db, entityType, entityID, scope)
+}
+
+// resolveEntityTitle 按访问范围检查记录、工单或文件的可读性并返回其标题
+func resolveEntityTitle(db *gorm.DB, entityType string, entityID uint, scope *LinkAccessScope) (string, error) {
+	switch entityType {
+	case models.LinkEntityRecord:
+		var record models.Record
+		query := db.Select("id", "title", "created_by")
+		if !scope.AllRecords {
+			query = query.Where("created_by = ?", scope.UserID)
+		}
+		if err := query.First(&record, entityID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return "", fmt.Errorf("记录不存在或无权访问")
+			}
+			return "", fmt.Errorf("获取记录失败: %w", err)
+		}
+		return record.Title, nil
+	case models.LinkEntityTicket:
+		var ticket models.Ticket
+		query := db.Select("id", "title", "creator_id", "assignee_id")
+		if !scope.AllTickets {
+			query = query.Where("creator_id = ? OR assignee_id = ?", scope.UserID, scope.UserID)
+		}
+		if err := query.First(&ticket, entityID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return "", fmt.Errorf("工单不存在或无权访问")
+			}
+			return "", fmt.Errorf("获取工单失败: %w", err)
+		}
+		return ticket.Title, nil
+	case models.LinkEntityFile:
+		var file models.File
+		query := db.Select("id", "original_name", "uploaded_by")
+		if !scope.AllFiles {
+			query = query.Where("uploaded_by = ?", scope.UserID)
+		}
+		if err := query.First(&file, entityID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return "", fmt.Errorf("文件不存在或无权访问")
+			}
+			return "", fmt.Errorf("获取文件失败: %w", err)
+		}
+		return file.OriginalName, nil
+	}
+	return "", fmt.Errorf("无效的关联实体类型")
+}
+
+// deleteEntityLinks 删除指定实体作为源或目标的所有关联
+func deleteEntityLinks(db *gorm.DB, entityType string, entityIDs []uint) error {
+	if len(entityIDs) == 0 {
+		return nil
+	}
+	err := db.Where("(source_type = ? AND source_id IN ?) OR (target_type = ? AND target_id IN ?)",
+		entityType, entityIDs, entityType, entityIDs).
+		Delete(&models.EntityLink{}).Error
+	if err != nil {
+		return fmt.Errorf("删除关联失败: %w", err)
+	}
+	return nil
+}
+
+// linkAuditValues 关联审计字段
+func linkAuditValues(link *models.EntityLink) map[string]interface{} {
+	return map[string]interface{}{
+		"source_type": link.SourceType,
+		"source_id":   link.SourceID,
+		"target_type": link.TargetType,
+		"target_id":   link.TargetID,
+		"link_type":   link.LinkType,
+	}
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"testing"
+
+	"info-management-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// setupLinkTestDB 创建关联测试数据库
+func setupLinkTestDB(t *testing.T) *gorm.DB {
+	db := newServiceTestDB(t)
+
+	return db
+}
+
+func TestLinkService_CreateAndListLinks(t *testing.T) {
+	db := setupLinkTestDB(t)
+	service := NewLinkService(db, NewAuditService(db))
+
+	record := models.Record{Type: "test", Title: "记录A", CreatedBy: 1}
+	require.NoError(t, db.Create(&record).Error)
+	ticket := models.Ticket{Title: "工单A", Type: models.TicketTypeBug, CreatorID: 1}
+	require.NoError(t, db.Create(&ticket).Error)
+
+	scope := &LinkAccessScope{UserID: 1}
+	link, err := service.CreateLink(&CreateLinkRequest{
+		SourceType: models.LinkEntityTicket,
+		SourceID:   ticket.ID,
+		TargetType: models.LinkEntityRecord,
+		TargetID:   record.ID,
+		LinkType:   models.LinkTypeCausedBy,
+	}, scope, "", "")
+	require.NoError(t, err)
+	assert.NotZero(t, link.ID)
+
+	// 重复创建
+	_, err = service.CreateLink(&CreateLinkRequest{
+		SourceType: models.LinkEntityTicket,
+		SourceID:   ticket.ID,
+		TargetType: models.LinkEntityRecord,
+		TargetID:   record.ID,
+		LinkType:   models.LinkTypeCausedBy,
+	}, scope, "", "")
+	assert.EqualError(t, err, "关联已存在")
+
+	// 从记录视角查看为入向关联
+	links, err := service.GetEntityLinks(models.LinkEntityRecord, record.ID, "", scope)
+	require.NoError(t, err)
+	require.Len(t, links, 1)
+	assert.Equal(t, "incoming", links[0].Direction)
+	assert.Equal(t, models.LinkEntityTicket, links[0].EntityType)
+	assert.Equal(t, "工单A", links[0].EntityTitle)
+}
+
+func TestLinkService_RequiresReadPermissionOnBothEnds(t *testing.T) {
+	db := setupLinkTestDB(t)
+	service := NewLinkService(db, nil)
+
+	own := models.Record{Type: "test", Title: "我的记录", CreatedBy: 1}
+	other := models.Record{Type: "test", Title: "他人记录", CreatedBy: 2}
+	require.NoError(t, db.Create(&own).Error)
+	require.NoError(t, db.Create(&other).Error)
+
+	req := &CreateLinkRequest{
+		SourceType: models.LinkEntityRecord,
+		SourceID:   own.ID,
+		TargetType: models.LinkEntityRecord,
+		TargetID:   other.ID,
+		LinkType:   models.LinkTypeRelatesTo,
+	}
+
+	_, err := service.CreateLink(req, &LinkAccessScope{UserID: 1}, "", "")
+	assert.EqualError(t, err, "记录不存在或无权访问")
+
+	_, err = service.CreateLink(req, &LinkAccessScope{UserID: 1, AllRecords: true}, "", "")
+	require.NoError(t, err)
+
+	// 无权读取对端时关联不可见
+	links, err := service.GetEntityLinks(models.LinkEntityRecord, own.ID, "", &LinkAccessScope{UserID: 1})
+	require.NoError(t, err)
+	assert.Empty(t, links)
+}
+
+func TestLinkService_CascadeOnRecordPurge(t *testing.T) {
+	db := setupLinkTestDB(t)
+	service := NewLinkService(db, nil)
+	recordService := NewRecordService(db, NewRecordTypeService(db), nil, nil, nil)
+
+	a := models.Record{Type: "test", Title: "A", CreatedBy: 1}
+	b := models.Record{Type: "test", Title: "B", CreatedBy: 1}
+	require.NoError(t, db.Create(&a).Error)
+	require.NoError(t, db.Create(&b).Error)
+
+	_, err := service.CreateLink(&CreateLinkRequest{
+		SourceType: models.LinkEntityRecord,
+		SourceID:   b.ID,
+		TargetType: models.LinkEntityRecord,
+		TargetID:   a.ID,
+		LinkType:   models.LinkTypeDuplicateOf,
+	}, &LinkAccessScope{UserID: 1}, "", "")
+	require.NoError(t, err)
+
+	require.NoError(t, recordService.DeleteRecord(a.ID, 1, false, "", ""))
+
+	// 软删除后关联保留，但对端已删除时不再列出
+	var count int64
+	db.Model(&models.EntityLink{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+	links, err := service.GetEntityLinks(models.LinkEntityRecord, b.ID, "", &LinkAccessScope{UserID: 1})
+	require.NoError(t, err)
+	assert.Empty(t, links)
+
+	require.NoError(t, NewRecycleBinService(db, nil, nil).Purge(models.LinkEntityRecord, a.ID, 1, false, "", ""))
+	db.Model(&models.EntityLink{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
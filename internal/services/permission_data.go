@@ -33,6 +33,14 @@ func GetAllDetailedPermissions() []models.Permission {
 		{ID: 1042, Name: "system:announcements:write", DisplayName: "发布公告", Description: "创建、编辑系统公告", Resource: "system", Action: "announcements:write", Scope: "all", ParentID: uintPtr(104)},
 		{ID: 1043, Name: "system:announcements:delete", DisplayName: "删除公告", Description: "删除系统公告", Resource: "system", Action: "announcements:delete", Scope: "all", ParentID: uintPtr(104)},
 
+		// 回收站管理
+		{ID: 105, Name: "system:recycle_bin", DisplayName: "回收站管理", Description: "回收站相关权限", Resource: "system", Action: "recycle_bin", Scope: "all", ParentID: uintPtr(1)},
+		{ID: 1051, Name: "recycle_bin:manage", DisplayName: "管理回收站", Description: "查看、恢复和彻底删除所有用户删除的数据", Resource: "recycle_bin", Action: "manage", Scope: "all", ParentID: uintPtr(105)},
+
+		// 标签管理
+		{ID: 106, Name: "system:tags", DisplayName: "标签管理", Description: "记录与工单标签相关权限", Resource: "system", Action: "tags", Scope: "all", ParentID: uintPtr(1)},
+		{ID: 1061, Name: "tags:manage", DisplayName: "管理标签", Description: "重命名、合并和删除标签", Resource: "tags", Action: "manage", Scope: "all", ParentID: uintPtr(106)},
+
 		// ==================== 用户管理模块 ====================
 		{ID: 2, Name: "users", DisplayName: "用户管理", Description: "用户管理模块总权限", Resource: "users", Action: "manage", Scope: "all", ParentID: nil},
 		
@@ -169,5 +177,10 @@ func getTicketPermissions() []models.Permission {
 		{ID: 11083, Name: "ticket:workflow:manage", DisplayName: "管理工作流", Description: "管理工单工作流程", Resource: "ticket", Action: "workflow:manage", Scope: "all", ParentID: uintPtr(1108)},
 		{ID: 11084, Name: "ticket:sla:manage", DisplayName: "管理SLA", Description: "管理服务级别协议", Resource: "ticket", Action: "sla:manage", Scope: "all", ParentID: uintPtr(1108)},
 		{ID: 11085, Name: "ticket:notification:manage", DisplayName: "管理通知规则", Description: "管理工单通知规则", Resource: "ticket", Action: "notification:manage", Scope: "all", ParentID: uintPtr(1108)},
+		
+		// 工单工时管理
+		{ID: 1109, Name: "ticket:worklog", DisplayName: "工单工时管理", Description: "工单工时登记和统计权限", Resource: "ticket", Action: "worklog", Scope: "all", ParentID: uintPtr(11)},
+		{ID: 11091, Name: "ticket:worklog:manage", DisplayName: "管理工时记录", Description: "代他人登记、修改和删除他人的工时记录", Resource: "ticket", Action: "worklog:manage", Scope: "all", ParentID: uintPtr(1109)},
+		{ID: 11092, Name: "ticket:worklog:report", DisplayName: "工时报表", Description: "按工单、用户、分类和日期统计工时并导出", Resource: "ticket", Action: "worklog:report", Scope: "all", ParentID: uintPtr(1109)},
 	}
 }
\ No newline at end of file
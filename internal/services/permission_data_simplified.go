@@ -15,6 +15,8 @@ func GetSimplifiedPermissions() []models.Permission {
 		{ID: 1007, Name: "system:logs_delete", DisplayName: "删除日志", Description: "删除系统日志", Resource: "system", Action: "logs_delete", Scope: "all"},
 		{ID: 1008, Name: "system:health_read", DisplayName: "系统监控", Description: "查看系统健康状态", Resource: "system", Action: "health_read", Scope: "all"},
 		{ID: 1009, Name: "system:stats_read", DisplayName: "系统统计", Description: "查看系统统计信息", Resource: "system", Action: "stats_read", Scope: "all"},
+		{ID: 1010, Name: "recycle_bin:manage", DisplayName: "管理回收站", Description: "查看、恢复和彻底删除所有用户删除的数据", Resource: "recycle_bin", Action: "manage", Scope: "all"},
+		{ID: 1011, Name: "tags:manage", DisplayName: "管理标签", Description: "重命名、合并和删除标签", Resource: "tags", Action: "manage", Scope: "all"},
 
 		// ==================== 用户管理权限 ====================
 		{ID: 2001, Name: "users:read", DisplayName: "查看用户", Description: "查看用户列表和详情", Resource: "users", Action: "read", Scope: "all"},
@@ -61,6 +63,8 @@ func GetSimplifiedPermissions() []models.Permission {
 		{ID: 5017, Name: "ticket:statistics", DisplayName: "工单统计", Description: "查看工单统计数据", Resource: "ticket", Action: "statistics", Scope: "all"},
 		{ID: 5018, Name: "ticket:export", DisplayName: "导出工单", Description: "导出工单数据", Resource: "ticket", Action: "export", Scope: "all"},
 		{ID: 5019, Name: "ticket:import", DisplayName: "导入工单", Description: "批量导入工单", Resource: "ticket", Action: "import", Scope: "all"},
+		{ID: 5020, Name: "ticket:worklog:manage", DisplayName: "管理工时记录", Description: "代他人登记、修改和删除他人的工时记录", Resource: "ticket", Action: "worklog:manage", Scope: "all"},
+		{ID: 5021, Name: "ticket:worklog:report", DisplayName: "工时报表", Description: "按工单、用户、分类和日期统计工时并导出", Resource: "ticket", Action: "worklog:report", Scope: "all"},
 
 		// ==================== 记录管理权限 ====================
 		{ID: 6001, Name: "records:read", DisplayName: "查看记录", Description: "查看记录列表和详情", Resource: "records", Action: "read", Scope: "all"},
@@ -71,6 +75,8 @@ func GetSimplifiedPermissions() []models.Permission {
 		{ID: 6006, Name: "records:delete", DisplayName: "删除记录", Description: "删除记录", Resource: "records", Action: "delete", Scope: "all"},
 		{ID: 6007, Name: "records:delete_own", DisplayName: "删除自己的记录", Description: "只能删除自己创建的记录", Resource: "records", Action: "delete", Scope: "own"},
 		{ID: 6008, Name: "records:import", DisplayName: "导入记录", Description: "批量导入记录", Resource: "records", Action: "import", Scope: "all"},
+		{ID: 6010, Name: "record_templates:manage", DisplayName: "管理记录模板", Description: "查看、编辑和删除所有记录模板，发布共享模板", Resource: "record_templates", Action: "manage", Scope: "all"},
+		{ID: 6011, Name: "record_comments:manage", DisplayName: "管理记录评论", Description: "删除他人的记录评论", Resource: "record_comments", Action: "manage", Scope: "all"},
 
 		// ==================== 记录类型管理权限 ====================
 		{ID: 7001, Name: "record_types:read", DisplayName: "查看记录类型", Description: "查看记录类型列表和详情", Resource: "record_types", Action: "read", Scope: "all"},
@@ -122,7 +128,7 @@ func GetStandardRoles() []struct {
 			},
 			Permissions: []uint{
 				// 系统管理
-				1001, 1002, 1003, 1004, 1005, 1006, 1007, 1008, 1009,
+				1001, 1002, 1003, 1004, 1005, 1006, 1007, 1008, 1009, 1010, 1011,
 				// 用户管理
 				2001, 2002, 2003, 2004, 2005, 2006, 2007, 2008,
 				// 角色管理
@@ -130,9 +136,9 @@ func GetStandardRoles() []struct {
 				// 权限管理
 				4001, 4002, 4003, 4004, 4005,
 				// 工单管理
-				5001, 5003, 5004, 5006, 5008, 5009, 5010, 5011, 5012, 5013, 5014, 5015, 5016, 5017, 5018, 5019,
+				5001, 5003, 5004, 5006, 5008, 5009, 5010, 5011, 5012, 5013, 5014, 5015, 5016, 5017, 5018, 5019, 5020, 5021,
 				// 记录管理
-				6001, 6003, 6004, 6006, 6008,
+				6001, 6003, 6004, 6006, 6008, 6010, 6011,
 				// 记录类型管理
 				7001, 7002, 7003, 7004, 7005,
 				// 文件管理
@@ -153,7 +159,7 @@ func GetStandardRoles() []struct {
 			},
 			Permissions: []uint{
 				// 工单管理
-				5001, 5003, 5004, 5006, 5008, 5009, 5010, 5011, 5012, 5013, 5014, 5015, 5016, 5017, 5018, 5019,
+				5001, 5003, 5004, 5006, 5008, 5009, 5010, 5011, 5012, 5013, 5014, 5015, 5016, 5017, 5018, 5019, 5020, 5021,
 				// 用户查看（用于分配工单）
 				2001,
 				// 文件管理（用于附件）
@@ -200,7 +206,7 @@ func GetStandardRoles() []struct {
 			},
 			Permissions: []uint{
 				// 记录管理
-				6001, 6003, 6004, 6006, 6008,
+				6001, 6003, 6004, 6006, 6008, 6010, 6011,
 				// 记录类型管理
 				7001, 7002, 7003, 7004, 7005,
 				// 文件管理
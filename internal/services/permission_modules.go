@@ -40,6 +40,12 @@ func getRecordsPermissions() []models.Permission {
 		{ID: 4052, Name: "records:batch:export", DisplayName: "批量导出记录", Description: "批量导出记录数据", Resource: "records", Action: "batch:export", Scope: "all", ParentID: uintPtr(405)},
 		{ID: 4053, Name: "records:batch:update", DisplayName: "批量更新记录", Description: "批量更新记录状态", Resource: "records", Action: "batch:update", Scope: "all", ParentID: uintPtr(405)},
 		{ID: 4054, Name: "records:batch:delete", DisplayName: "批量删除记录", Description: "批量删除记录数据", Resource: "records", Action: "batch:delete", Scope: "all", ParentID: uintPtr(405)},
+
+		// 记录协作管理
+		{ID: 406, Name: "records:collaboration", DisplayName: "记录协作管理", Description: "记录编辑锁、模板与评论的管理权限", Resource: "records", Action: "collaboration", Scope: "all", ParentID: uintPtr(4)},
+		{ID: 4061, Name: "records:break_lock", DisplayName: "强制解除编辑锁", Description: "强制解除他人持有的记录编辑锁", Resource: "records", Action: "break_lock", Scope: "all", ParentID: uintPtr(406)},
+		{ID: 4062, Name: "record_templates:manage", DisplayName: "管理记录模板", Description: "查看、编辑和删除所有记录模板，发布共享模板", Resource: "records", Action: "templates:manage", Scope: "all", ParentID: uintPtr(406)},
+		{ID: 4063, Name: "record_comments:manage", DisplayName: "管理记录评论", Description: "删除他人的记录评论", Resource: "records", Action: "comments:manage", Scope: "all", ParentID: uintPtr(406)},
 	}
 }
 
@@ -1,10 +1,11 @@
-package services
+package services_test
 
 import (
 	"testing"
 
 	"info-management-system/internal/config"
 	"info-management-system/internal/database"
+	"info-management-system/internal/services"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -14,9 +15,9 @@ import (
 type PermissionServiceTestSuite struct {
 	suite.Suite
 	db                *gorm.DB
-	permissionService *PermissionService
-	roleService       *RoleService
-	authService       *AuthService
+	permissionService *services.PermissionService
+	roleService       *services.RoleService
+	authService       *services.AuthService
 }
 
 func (suite *PermissionServiceTestSuite) SetupSuite() {
@@ -43,9 +44,9 @@ func (suite *PermissionServiceTestSuite) SetupSuite() {
 		},
 	}
 
-	suite.permissionService = NewPermissionService(suite.db)
-	suite.roleService = NewRoleService(suite.db)
-	suite.authService = NewAuthService(suite.db, appConfig)
+	suite.permissionService = services.NewPermissionService(suite.db)
+	suite.roleService = services.NewRoleService(suite.db)
+	suite.authService = services.NewAuthService(suite.db, appConfig)
 }
 
 func (suite *PermissionServiceTestSuite) TearDownSuite() {
@@ -62,7 +63,7 @@ func (suite *PermissionServiceTestSuite) SetupTest() {
 
 func (suite *PermissionServiceTestSuite) TestCheckPermission() {
 	// 测试管理员权限
-	req := &PermissionCheckRequest{
+	req := &services.PermissionCheckRequest{
 		UserID:   1, // admin用户
 		Resource: "system",
 		Action:   "admin",
@@ -79,7 +80,7 @@ func (suite *PermissionServiceTestSuite) TestCheckPermission() {
 
 func (suite *PermissionServiceTestSuite) TestCheckPermissionDenied() {
 	// 创建一个普通用户
-	registerReq := &RegisterRequest{
+	registerReq := &services.RegisterRequest{
 		Username: "testuser",
 		Email:    "test@example.com",
 		Password: "password123",
@@ -88,7 +89,7 @@ func (suite *PermissionServiceTestSuite) TestCheckPermissionDenied() {
 	suite.Require().NoError(err)
 
 	// 测试普通用户没有管理员权限
-	req := &PermissionCheckRequest{
+	req := &services.PermissionCheckRequest{
 		UserID:   user.ID,
 		Resource: "system",
 		Action:   "admin",
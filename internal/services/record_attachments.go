@@ -0,0 +1,299 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// RecordFileResponse 记录附件响应
+type RecordFileResponse struct {
+	FileID       uint   `json:"file_id"`
+	FieldName    string `json:"field_name"`
+	OriginalName string `json:"original_name"`
+	MimeType     string `json:"mime_type"`
+	Size         int64  `json:"size"`
+	UploadedBy   uint   `json:"uploaded_by"`
+	AttachedBy   uint   `json:"attached_by"`
+	AttachedAt   string `json:"attached_at"`
+	DownloadURL  string `json:"download_url"`
+}
+
+// AttachRecordFilesRequest 添加记录附件请求
+type AttachRecordFilesRequest struct {
+	FileIDs []uint `json:"file_ids" binding:"required,min=1,max=50"`
+}
+
+// GetRecordFiles 获取记录附件列表
+func (s *RecordService) GetRecordFiles(recordID uint, userID uint, hasAllPermission bool) ([]RecordFileResponse, error) {
+	if _, err := s.findAccessibleRecord(recordID, userID, hasAllPermission, "记录不存在或无权访问"); err != nil {
+		return nil, err
+	}
+	return s.loadRecordFiles(recordID)
+}
+
+// AttachFiles 为记录添加普通附件
+func (s *RecordService) AttachFiles(recordID uint, req *AttachRecordFilesRequest, userID uint, hasAllPermission bool, ipAddress, userAgent string) ([]RecordFileResponse, error) {
+	record, err := s.findAccessibleRecord(recordID, userID, hasAllPermission, "记录不存在或无权修改")
+	if err != nil {
+		return nil, err
+	}
+
+	if s.fileService != nil {
+		if err := s.fileService.CheckFilesAccessible(req.FileIDs, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	var attached []uint
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, fileID := range req.FileIDs {
+			var count int64
+			tx.Model(&models.RecordFile{}).
+				Where("record_id = ? AND file_id = ? AND field_name = ?", record.ID, fileID, "").
+				Count(&count)
+			if count > 0 {
+				continue
+			}
+			link := models.RecordFile{RecordID: record.ID, FileID: fileID, CreatedBy: userID}
+			if err := tx.Create(&link).Error; err != nil {
+				return fmt.Errorf("添加附件失败: %w", err)
+			}
+			attached = append(attached, fileID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 记录审计日志
+	if s.auditService != nil && len(attached) > 0 {
+		s.auditService.CreateAuditLog(&AuditLogRequest{
+			UserID:       userID,
+			Action:       "ATTACH_FILE",
+			ResourceType: "record",
+			ResourceID:   record.ID,
+			NewValues:    map[string]interface{}{"file_ids": attached},
+			IPAddress:    ipAddress,
+			UserAgent:    userAgent,
+		})
+	}
+	if len(attached) > 0 {
+		s.watchService.NotifyWatchers(models.WatchEntityRecord, record.ID, userID, "记录附件通知",
+			fmt.Sprintf("记录「%s」新增了 %d 个附件", record.Title, len(attached)))
+	}
+
+	return s.loadRecordFiles(record.ID)
+}
+
+// DetachFile 移除记录附件（不删除文件本身）
+func (s *RecordService) DetachFile(recordID, fileID uint, userID uint, hasAllPermission bool, ipAddress, userAgent string) error {
+	record, err := s.findAccessibleRecord(recordID, userID, hasAllPermission, "记录不存在或无权修改")
+	if err != nil {
+		return err
+	}
+
+	result := s.db.Where("record_id = ? AND file_id = ? AND field_name = ?", record.ID, fileID, "").
+		Delete(&models.RecordFile{})
+	if result.Error != nil {
+		return fmt.Errorf("移除附件失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("附件不存在")
+	}
+
+	// 记录审计日志
+	if s.auditService != nil {
+		s.auditService.CreateAuditLog(&AuditLogRequest{
+			UserID:       userID,
+			Action:       "DETACH_FILE",
+			ResourceType: "record",
+			ResourceID:   record.ID,
+			OldValues:    map[string]interface{}{"file_id": fileID},
+			IPAddress:    ipAddress,
+			UserAgent:    userAgent,
+		})
+	}
+	s.watchService.NotifyWatchers(models.WatchEntityRecord, record.ID, userID, "记录附件通知",
+		fmt.Sprintf("记录「%s」移除了一个附件", record.Title))
+
+	return nil
+}
+
+// findAccessibleRecord 按权限查找记录
+func (s *RecordService) findAccessibleRecord(recordID uint, userID uint, hasAllPermission bool, notFoundMessage string) (*models.Record, error) {
+	var record models.Record
+	query := s.db
+	if !hasAllPermission {
+		query = query.Where("created_by = ?", userID)
+	}
+	if err := query.First(&record, recordID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("%s", notFoundMessage)
+		}
+		return nil, fmt.Errorf("获取记录失败: %w", err)
+	}
+	return &record, nil
+}
+
+// loadRecordFiles 加载记录的附件
+func (s *RecordService) loadRecordFiles(recordID uint) ([]RecordFileResponse, error) {
+	var recordFiles []models.RecordFile
+	if err := s.db.Preload("File").Where("record_id = ?", recordID).Order("id ASC").Find(&recordFiles).Error; err != nil {
+		return nil, fmt.Errorf("获取记录附件失败: %w", err)
+	}
+
+	responses := make([]RecordFileResponse, 0, len(recordFiles))
+	for _, rf := range recordFiles {
+		if rf.File.ID == 0 {
+			continue
+		}
+		responses = append(responses, RecordFileResponse{
+			FileID:       rf.FileID,
+			FieldName:    rf.FieldName,
+			OriginalName: rf.File.OriginalName,
+			MimeType:     rf.File.MimeType,
+			Size:         rf.File.Size,
+			UploadedBy:   rf.File.UploadedBy,
+			AttachedBy:   rf.CreatedBy,
+			AttachedAt:   rf.CreatedAt.Format("2006-01-02 15:04:05"),
+			DownloadURL:  fmt.Sprintf("/api/v1/files/%d", rf.FileID),
+		})
+	}
+	return responses, nil
+}
+
+// validateFieldFiles 校验Schema中 file/files 字段引用的文件，返回字段与文件ID的映射
+// recordID 不为0时，已关联到该记录同一字段的文件无需再次校验访问权限
+func (s *RecordService) validateFieldFiles(recordType string, content map[string]interface{}, recordID uint, userID uint) (map[string][]uint, error) {
+	rt, err := s.recordTypeService.GetRecordTypeByName(recordType)
+	if err != nil {
+		return nil, err
+	}
+
+	fieldFiles := map[string][]uint{}
+	var toCheck []uint
+	for _, field := range ParseSchemaFields(rt.Schema) {
+		if field.Type != "file" && field.Type != "files" {
+			continue
+		}
+
+		value, exists := content[field.Name]
+		if !exists || value == nil {
+			fieldFiles[field.Name] = nil
+			continue
+		}
+
+		var ids []uint
+		if field.Type == "file" {
+			id, ok := parseFileRef(value)
+			if !ok {
+				return nil, fmt.Errorf("字段 %s 的文件引用无效", field.Name)
+			}
+			ids = []uint{id}
+		} else {
+			list, ok := value.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("字段 %s 必须是文件ID数组", field.Name)
+			}
+			for _, item := range list {
+				id, ok := parseFileRef(item)
+				if !ok {
+					return nil, fmt.Errorf("字段 %s 的文件引用无效", field.Name)
+				}
+				ids = append(ids, id)
+			}
+		}
+		fieldFiles[field.Name] = ids
+
+		existing := map[uint]bool{}
+		if recordID > 0 {
+			var attached []uint
+			s.db.Model(&models.RecordFile{}).
+				Where("record_id = ? AND field_name = ?", recordID, field.Name).
+				Pluck("file_id", &attached)
+			for _, id := range attached {
+				existing[id] = true
+			}
+		}
+		for _, id := range ids {
+			if !existing[id] {
+				toCheck = append(toCheck, id)
+			}
+		}
+	}
+
+	if len(toCheck) > 0 && s.fileService != nil {
+		if err := s.fileService.CheckFilesAccessible(toCheck, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return fieldFiles, nil
+}
+
+// syncFieldFiles 同步Schema文件字段与记录附件关联
+func syncFieldFiles(tx *gorm.DB, recordID uint, fieldFiles map[string][]uint, userID uint) error {
+	for fieldName, ids := range fieldFiles {
+		if err := tx.Where("record_id = ? AND field_name = ?", recordID, fieldName).Delete(&models.RecordFile{}).Error; err != nil {
+			return fmt.Errorf("更新记录附件失败: %w", err)
+		}
+		seen := map[uint]bool{}
+		for _, id := range ids {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			link := models.RecordFile{RecordID: recordID, FileID: id, FieldName: fieldName, CreatedBy: userID}
+			if err := tx.Create(&link).Error; err != nil {
+				return fmt.Errorf("更新记录附件失败: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// detachRecordFiles 解除记录的全部附件关联，返回被解除的文件ID
+func detachRecordFiles(tx *gorm.DB, recordIDs []uint) ([]uint, error) {
+	var fileIDs []uint
+	if err := tx.Model(&models.RecordFile{}).Where("record_id IN ?", recordIDs).Distinct().Pluck("file_id", &fileIDs).Error; err != nil {
+		return nil, fmt.Errorf("获取记录附件失败: %w", err)
+	}
+	if len(fileIDs) == 0 {
+		return nil, nil
+	}
+	if err := tx.Where("record_id IN ?", recordIDs).Delete(&models.RecordFile{}).Error; err != nil {
+		return nil, fmt.Errorf("解除记录附件失败: %w", err)
+	}
+	return fileIDs, nil
+}
+
+// parseFileRef 解析文件引用，支持数字、数字字符串和包含id的对象
+func parseFileRef(value interface{}) (uint, bool) {
+	switch v := value.(type) {
+	case float64:
+		if v > 0 && v == float64(uint(v)) {
+			return uint(v), true
+		}
+	case int:
+		if v > 0 {
+			return uint(v), true
+		}
+	case uint:
+		return v, v > 0
+	case string:
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err == nil && id > 0 {
+			return uint(id), true
+		}
+	case map[string]interface{}:
+		if id, ok := v["id"]; ok {
+			return parseFileRef(id)
+		}
+	}
+	return 0, false
+}
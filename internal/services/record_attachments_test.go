@@ -0,0 +1,226 @@
+package services
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"info-management-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// setupAttachmentTest 创建附件测试环境
+func setupAttachmentTest(t *testing.T) (*gorm.DB, *RecordService) {
+	return seedAttachmentTest(t, newServiceTestDB(t))
+}
+
+// seedAttachmentTest 在指定数据库中创建用户 owner(1)、other(2) 与带文件字段的 contract 类型
+func seedAttachmentTest(t *testing.T, db *gorm.DB) (*gorm.DB, *RecordService) {
+	require.NoError(t, db.Create(&models.User{Username: "owner", Email: "owner@example.com", PasswordHash: "x", IsActive: true}).Error)
+	require.NoError(t, db.Create(&models.User{Username: "other", Email: "other@example.com", PasswordHash: "x", IsActive: true}).Error)
+
+	recordType := models.RecordType{
+		Name:        "contract",
+		DisplayName: "合同",
+		TableName:   "records_contract",
+		IsActive:    true,
+		Schema: models.JSONB{
+			"fields": []interface{}{
+				map[string]interface{}{"name": "scan", "type": "file"},
+				map[string]interface{}{"name": "appendix", "type": "files"},
+			},
+		},
+	}
+	require.NoError(t, db.Create(&recordType).Error)
+
+	auditService := NewAuditService(db)
+	fileService := NewFileService(db, auditService)
+	return db, NewRecordService(db, NewRecordTypeService(db), auditService, fileService, nil)
+}
+
+func createAttachmentTestFile(t *testing.T, db *gorm.DB, uploadedBy uint, name string) models.File {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(name), 0644))
+	file := models.File{
+		Filename:     name,
+		OriginalName: name,
+		MimeType:     "text/plain",
+		Size:         int64(len(name)),
+		Path:         path,
+		UploadedBy:   uploadedBy,
+	}
+	require.NoError(t, db.Create(&file).Error)
+	return file
+}
+
+func TestRecordService_FileFieldsValidated(t *testing.T) {
+	db, service := setupAttachmentTest(t)
+	own := createAttachmentTestFile(t, db, 1, "own.txt")
+	foreign := createAttachmentTestFile(t, db, 2, "foreign.txt")
+
+	record, err := service.CreateRecord(&CreateRecordRequest{
+		Type:    "contract",
+		Title:   "合同A",
+		Content: map[string]interface{}{"scan": float64(own.ID), "appendix": []interface{}{float64(own.ID)}},
+	}, 1, "", "")
+	require.NoError(t, err)
+	assert.Len(t, record.Attachments, 2)
+
+	_, err = service.CreateRecord(&CreateRecordRequest{
+		Type:    "contract",
+		Title:   "合同B",
+		Content: map[string]interface{}{"scan": float64(foreign.ID)},
+	}, 1, "", "")
+	assert.Error(t, err)
+
+	_, err = service.CreateRecord(&CreateRecordRequest{
+		Type:    "contract",
+		Title:   "合同C",
+		Content: map[string]interface{}{"appendix": "not-a-list"},
+	}, 1, "", "")
+	assert.Error(t, err)
+}
+
+func TestRecordService_BatchAndImportSyncFileFields(t *testing.T) {
+	// 批量创建在事务中读取记录类型，需要多个连接共享数据
+	db, service := seedAttachmentTest(t, newFileServiceTestDB(t))
+	service.auditService = nil // 审计日志在事务外写入，SQLite 文件库会等待写锁
+	own := createAttachmentTestFile(t, db, 1, "own.txt")
+	foreign := createAttachmentTestFile(t, db, 2, "foreign.txt")
+
+	// 引用无权访问的文件时整批拒绝
+	_, err := service.BatchCreateRecords(&BatchCreateRequest{Records: []CreateRecordRequest{
+		{Type: "contract", Title: "合同A", Content: map[string]interface{}{"scan": float64(own.ID)}},
+		{Type: "contract", Title: "合同B", Content: map[string]interface{}{"scan": float64(foreign.ID)}},
+	}}, 1, "", "")
+	assert.Error(t, err)
+
+	created, err := service.BatchCreateRecords(&BatchCreateRequest{Records: []CreateRecordRequest{
+		{Type: "contract", Title: "合同A", Content: map[string]interface{}{"scan": float64(own.ID)}},
+	}}, 1, "", "")
+	require.NoError(t, err)
+	require.Len(t, created, 1)
+	// 批量创建的记录使用流程初始状态
+	assert.Equal(t, service.workflowService.InitialStatus("contract"), created[0].Status)
+	var stored models.Record
+	require.NoError(t, db.First(&stored, created[0].ID).Error)
+	assert.Equal(t, created[0].Status, stored.Status)
+	var refs int64
+	db.Model(&models.RecordFile{}).Where("record_id = ? AND field_name = ? AND file_id = ?", created[0].ID, "scan", own.ID).Count(&refs)
+	assert.Equal(t, int64(1), refs)
+
+	// 导入同样校验文件访问权限并建立附件关联
+	imported, err := service.ImportRecords(&ImportRecordsRequest{Type: "contract", Records: []map[string]interface{}{
+		{"title": "合同C", "appendix": []interface{}{float64(own.ID)}},
+		{"title": "合同D", "scan": float64(foreign.ID)},
+	}}, 1, "", "")
+	assert.Error(t, err)
+	require.Len(t, imported, 1)
+	assert.Equal(t, service.workflowService.InitialStatus("contract"), imported[0].Status)
+	db.Model(&models.RecordFile{}).Where("record_id = ? AND field_name = ? AND file_id = ?", imported[0].ID, "appendix", own.ID).Count(&refs)
+	assert.Equal(t, int64(1), refs)
+}
+
+func TestRecycleBinService_PurgeDetachesAndCollectsFiles(t *testing.T) {
+	db, service := setupAttachmentTest(t)
+	file := createAttachmentTestFile(t, db, 1, "gc.txt")
+	shared := createAttachmentTestFile(t, db, 1, "shared.txt")
+
+	first, err := service.CreateRecord(&CreateRecordRequest{Type: "contract", Title: "A", Content: map[string]interface{}{"scan": float64(file.ID)}}, 1, "", "")
+	require.NoError(t, err)
+	_, err = service.AttachFiles(first.ID, &AttachRecordFilesRequest{FileIDs: []uint{shared.ID}}, 1, false, "", "")
+	require.NoError(t, err)
+	second, err := service.CreateRecord(&CreateRecordRequest{Type: "contract", Title: "B", Content: map[string]interface{}{"scan": float64(shared.ID)}}, 1, "", "")
+	require.NoError(t, err)
+
+	require.NoError(t, db.Create(&models.SystemConfig{Category: "storage", Key: "record_attachment_gc", Value: "true", DataType: "bool"}).Error)
+	require.NoError(t, service.DeleteRecord(first.ID, 1, false, "", ""))
+
+	// 软删除保留附件关联，彻底删除时才解除
+	var count int64
+	db.Model(&models.RecordFile{}).Where("record_id = ?", first.ID).Count(&count)
+	assert.Equal(t, int64(2), count)
+	assert.NoError(t, db.First(&models.File{}, file.ID).Error)
+
+	recycleBin := NewRecycleBinService(db, service.auditService, service.fileService)
+	require.NoError(t, recycleBin.Purge(models.LinkEntityRecord, first.ID, 1, false, "", ""))
+	db.Model(&models.RecordFile{}).Where("record_id = ?", first.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	// 无引用的文件被清理（移入回收站，磁盘文件保留到彻底删除），仍被其他记录引用的文件保留
+	assert.Error(t, db.First(&models.File{}, file.ID).Error)
+	assert.FileExists(t, file.Path)
+	assert.NoError(t, db.First(&models.File{}, shared.ID).Error)
+
+	files, err := service.GetRecordFiles(second.ID, 1, false)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestExportService_BundlesSameNameAttachments(t *testing.T) {
+	db, service := setupAttachmentTest(t)
+	first := createAttachmentTestFile(t, db, 1, "scan.txt")
+	second := createAttachmentTestFile(t, db, 1, "scan.txt")
+	record, err := service.CreateRecord(&CreateRecordRequest{Type: "contract", Title: "A", Content: map[string]interface{}{"no": "C-1"}}, 1, "", "")
+	require.NoError(t, err)
+	_, err = service.AttachFiles(record.ID, &AttachRecordFilesRequest{FileIDs: []uint{first.ID, second.ID}}, 1, false, "", "")
+	require.NoError(t, err)
+
+	// 其他用户的文件，导出人无权访问
+	foreign := createAttachmentTestFile(t, db, 2, "foreign.txt")
+	require.NoError(t, db.Create(&models.RecordFile{RecordID: record.ID, FileID: foreign.ID, CreatedBy: 2}).Error)
+
+	exportPath := filepath.Join(t.TempDir(), "records.csv")
+	require.NoError(t, os.WriteFile(exportPath, []byte("id\n"), 0644))
+	exportService := &ExportService{db: db}
+	zipPath, err := exportService.bundleRecordAttachments(exportPath, []map[string]interface{}{{"id": record.ID}}, 1)
+	require.NoError(t, err)
+
+	// 同一记录下重名的附件都被打包，无权访问的附件不打包
+	reader, err := zip.OpenReader(zipPath)
+	require.NoError(t, err)
+	defer reader.Close()
+	var names []string
+	for _, entry := range reader.File {
+		names = append(names, entry.Name)
+	}
+	assert.ElementsMatch(t, []string{
+		"records.csv",
+		fmt.Sprintf("attachments/%d/%d_scan.txt", record.ID, first.ID),
+		fmt.Sprintf("attachments/%d/%d_scan.txt", record.ID, second.ID),
+	}, names)
+}
+
+func TestFileService_DeleteUnreferencedFilesChecksReferences(t *testing.T) {
+	db, service := setupAttachmentTest(t)
+	free := createAttachmentTestFile(t, db, 1, "free.txt")
+	others := createAttachmentTestFile(t, db, 2, "others.txt")
+	ticketFile := createAttachmentTestFile(t, db, 1, "ticket.txt")
+	fieldFile := createAttachmentTestFile(t, db, 1, "field.txt")
+
+	require.NoError(t, db.Create(&models.TicketAttachment{TicketID: 1, FileName: "ticket.txt", FileSize: 1, FilePath: ticketFile.Path, UploadedBy: 1}).Error)
+	// 回收站中的记录仍通过文件字段引用文件
+	record, err := service.CreateRecord(&CreateRecordRequest{Type: "contract", Title: "旧合同", Content: map[string]interface{}{"no": "C-1", "appendix": []interface{}{fieldFile.ID}}}, 1, "", "")
+	require.NoError(t, err)
+	require.NoError(t, db.Delete(&models.Record{}, record.ID).Error)
+
+	ids := []uint{free.ID, others.ID, ticketFile.ID, fieldFile.ID}
+	deleted, err := service.fileService.DeleteUnreferencedFiles(ids, 1, false, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	assert.Error(t, db.First(&models.File{}, free.ID).Error)
+	for _, id := range []uint{others.ID, ticketFile.ID, fieldFile.ID} {
+		assert.NoError(t, db.First(&models.File{}, id).Error)
+	}
+
+	// 有全部文件权限时可清理他人上传的文件
+	deleted, err = service.fileService.DeleteUnreferencedFiles(ids, 1, true, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	assert.Error(t, db.First(&models.File{}, others.ID).Error)
+}
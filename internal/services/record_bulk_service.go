@@ -0,0 +1,486 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// 批量操作类型
+const (
+	BulkOpSetField   = "set_field"
+	BulkOpUnsetField = "unset_field"
+	BulkOpAddTags    = "add_tags"
+	BulkOpRemoveTags = "remove_tags"
+	BulkOpSetStatus  = "set_status"
+	BulkOpDelete     = "delete"
+)
+
+// bulkBatchSize 批量操作任务每批处理的记录数
+const bulkBatchSize = 100
+
+// bulkPreviewSamples 预览返回的样例记录数
+const bulkPreviewSamples = 10
+
+// RecordBulkService 按筛选条件批量操作记录服务
+type RecordBulkService struct {
+	db              *gorm.DB
+	recordService   *RecordService
+	workflowService *RecordWorkflowService
+	auditService    *AuditService
+	runAsync        func(func())
+}
+
+// NewRecordBulkService 创建批量操作记录服务
+func NewRecordBulkService(db *gorm.DB, recordService *RecordService, workflowService *RecordWorkflowService, auditService *AuditService) *RecordBulkService {
+	return &RecordBulkService{
+		db:              db,
+		recordService:   recordService,
+		workflowService: workflowService,
+		auditService:    auditService,
+		runAsync:        func(f func()) { go f() },
+	}
+}
+
+// RecordBulkRequest 批量操作请求，筛选条件与记录列表一致
+type RecordBulkRequest struct {
+	Filter    RecordListQuery `json:"filter"`
+	Operation string          `json:"operation" binding:"required,oneof=set_field unset_field add_tags remove_tags set_status delete"`
+	Field     string          `json:"field"`  // set_field、unset_field 的内容字段
+	Value     interface{}     `json:"value"`  // set_field 的值
+	Tags      []string        `json:"tags"`   // add_tags、remove_tags 的标签
+	Status    string          `json:"status"` // set_status 的目标状态
+	Comment   string          `json:"comment" binding:"max=2000"`
+}
+
+// RecordBulkPreview 批量操作预览
+type RecordBulkPreview struct {
+	Total   int64              `json:"total"`
+	Samples []RecordBulkSample `json:"samples"`
+}
+
+// RecordBulkSample 预览中的样例记录
+type RecordBulkSample struct {
+	ID     uint   `json:"id"`
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status string `json:"status"`
+}
+
+// RecordBulkErrorQuery 批量操作错误查询参数
+type RecordBulkErrorQuery struct {
+	Page     int `form:"page,default=1"`
+	PageSize int `form:"page_size,default=50"`
+}
+
+// RecordBulkErrorListResponse 批量操作错误列表响应
+type RecordBulkErrorListResponse struct {
+	Errors   []models.RecordBulkJobError `json:"errors"`
+	Total    int64                       `json:"total"`
+	Page     int                         `json:"page"`
+	PageSize int                         `json:"page_size"`
+}
+
+// validateBulkRequest 校验批量操作参数
+func validateBulkRequest(req *RecordBulkRequest) error {
+	f := req.Filter
+	if f.Type == "" && f.Search == "" && f.Tags == "" && f.CreatedBy == 0 {
+		return fmt.Errorf("批量操作必须指定筛选条件")
+	}
+
+	switch req.Operation {
+	case BulkOpSetField:
+		if req.Field == "" {
+			return fmt.Errorf("必须指定字段")
+		}
+		if req.Value == nil {
+			return fmt.Errorf("必须指定字段值")
+		}
+	case BulkOpUnsetField:
+		if req.Field == "" {
+			return fmt.Errorf("必须指定字段")
+		}
+	case BulkOpAddTags, BulkOpRemoveTags:
+		if len(req.Tags) == 0 {
+			return fmt.Errorf("必须指定标签")
+		}
+	case BulkOpSetStatus:
+		if req.Status == "" {
+			return fmt.Errorf("必须指定目标状态")
+		}
+	case BulkOpDelete:
+	default:
+		return fmt.Errorf("无效的批量操作: %s", req.Operation)
+	}
+	return nil
+}
+
+// Preview 统计筛选条件匹配的记录数并返回样例
+func (s *RecordBulkService) Preview(req *RecordBulkRequest, userID uint, hasAllPermission bool) (*RecordBulkPreview, error) {
+	if err := validateBulkRequest(req); err != nil {
+		return nil, err
+	}
+
+	query := applyRecordListFilter(s.db.Model(&models.Record{}), &req.Filter, userID, hasAllPermission)
+
+	preview := &RecordBulkPreview{Samples: []RecordBulkSample{}}
+	if err := query.Count(&preview.Total).Error; err != nil {
+		return nil, fmt.Errorf("统计记录失败: %w", err)
+	}
+
+	var records []models.Record
+	err := applyRecordListFilter(s.db.Model(&models.Record{}), &req.Filter, userID, hasAllPermission).
+		Select("id", "type", "title", "status").Order("id ASC").Limit(bulkPreviewSamples).Find(&records).Error
+	if err != nil {
+		return nil, fmt.Errorf("获取记录失败: %w", err)
+	}
+	for _, r := range records {
+		preview.Samples = append(preview.Samples, RecordBulkSample{ID: r.ID, Type: r.Type, Title: r.Title, Status: r.Status})
+	}
+	return preview, nil
+}
+
+// CreateJob 创建并在后台执行批量操作任务
+func (s *RecordBulkService) CreateJob(req *RecordBulkRequest, userID uint, hasAllPermission bool, ipAddress, userAgent string) (*models.RecordBulkJob, error) {
+	if err := validateBulkRequest(req); err != nil {
+		return nil, err
+	}
+
+	query := applyRecordListFilter(s.db.Model(&models.Record{}), &req.Filter, userID, hasAllPermission)
+	var stats struct {
+		Total int64
+		MaxID uint
+	}
+	if err := query.Select("COUNT(*) AS total, COALESCE(MAX(id), 0) AS max_id").Scan(&stats).Error; err != nil {
+		return nil, fmt.Errorf("统计记录失败: %w", err)
+	}
+	if stats.Total == 0 {
+		return nil, fmt.Errorf("没有匹配筛选条件的记录")
+	}
+
+	job := models.RecordBulkJob{
+		Operation:        req.Operation,
+		Filter:           models.JSONB{"type": req.Filter.Type, "search": req.Filter.Search, "tags": req.Filter.Tags, "created_by": req.Filter.CreatedBy},
+		Params:           models.JSONB{"field": req.Field, "value": req.Value, "tags": req.Tags, "status": req.Status, "comment": req.Comment},
+		HasAllPermission: hasAllPermission,
+		MaxRecordID:      stats.MaxID,
+		Status:           "pending",
+		TotalRecords:     int(stats.Total),
+		CreatedBy:        userID,
+	}
+	if err := s.db.Create(&job).Error; err != nil {
+		return nil, fmt.Errorf("创建批量操作任务失败: %w", err)
+	}
+
+	s.auditJob(&job, "CREATE_BULK_JOB", ipAddress, userAgent)
+
+	bulkReq := *req
+	s.runAsync(func() { s.runJob(job.ID, &bulkReq) })
+	return &job, nil
+}
+
+// GetJob 获取批量操作任务
+func (s *RecordBulkService) GetJob(jobID uint, userID uint, hasAllPermission bool) (*models.RecordBulkJob, error) {
+	var job models.RecordBulkJob
+	query := s.db
+	if !hasAllPermission {
+		query = query.Where("created_by = ?", userID)
+	}
+	if err := query.First(&job, jobID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("批量操作任务不存在")
+		}
+		return nil, fmt.Errorf("获取批量操作任务失败: %w", err)
+	}
+	return &job, nil
+}
+
+// ListJobs 获取批量操作任务列表
+func (s *RecordBulkService) ListJobs(userID uint, hasAllPermission bool) ([]models.RecordBulkJob, error) {
+	var jobs []models.RecordBulkJob
+	query := s.db.Order("id DESC").Limit(100)
+	if !hasAllPermission {
+		query = query.Where("created_by = ?", userID)
+	}
+	if err := query.Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("获取批量操作任务失败: %w", err)
+	}
+	return jobs, nil
+}
+
+// CancelJob 取消未完成的批量操作任务，已处理的记录不回滚
+func (s *RecordBulkService) CancelJob(jobID uint, userID uint, hasAllPermission bool, ipAddress, userAgent string) (*models.RecordBulkJob, error) {
+	job, err := s.GetJob(jobID, userID, hasAllPermission)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := s.db.Model(&models.RecordBulkJob{}).
+		Where("id = ? AND status IN ?", job.ID, []string{"pending", "processing"}).
+		Updates(map[string]interface{}{"status": "cancelled", "completed_at": &now})
+	if result.Error != nil {
+		return nil, fmt.Errorf("取消批量操作任务失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("只能取消未完成的任务")
+	}
+
+	s.auditJob(job, "CANCEL_BULK_JOB", ipAddress, userAgent)
+	return s.GetJob(jobID, userID, hasAllPermission)
+}
+
+// GetJobErrors 分页获取批量操作任务的记录错误
+func (s *RecordBulkService) GetJobErrors(jobID uint, query *RecordBulkErrorQuery, userID uint, hasAllPermission bool) (*RecordBulkErrorListResponse, error) {
+	if _, err := s.GetJob(jobID, userID, hasAllPermission); err != nil {
+		return nil, err
+	}
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.PageSize <= 0 || query.PageSize > 500 {
+		query.PageSize = 50
+	}
+
+	var total int64
+	s.db.Model(&models.RecordBulkJobError{}).Where("job_id = ?", jobID).Count(&total)
+
+	var errs []models.RecordBulkJobError
+	err := s.db.Where("job_id = ?", jobID).Order("record_id ASC").
+		Offset((query.Page - 1) * query.PageSize).Limit(query.PageSize).
+		Find(&errs).Error
+	if err != nil {
+		return nil, fmt.Errorf("获取批量操作错误失败: %w", err)
+	}
+
+	return &RecordBulkErrorListResponse{Errors: errs, Total: total, Page: query.Page, PageSize: query.PageSize}, nil
+}
+
+// runJob 按记录ID分批执行批量操作，每批结束时检查是否已取消
+func (s *RecordBulkService) runJob(jobID uint, req *RecordBulkRequest) {
+	var job models.RecordBulkJob
+	if err := s.db.First(&job, jobID).Error; err != nil {
+		return
+	}
+
+	now := time.Now()
+	result := s.db.Model(&job).Where("status = ?", "pending").
+		Updates(map[string]interface{}{"status": "processing", "started_at": &now})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return
+	}
+
+	fieldsByType := map[string][]SchemaField{}
+	processed, succeeded, failed := 0, 0, 0
+	var lastID uint
+	for {
+		var ids []uint
+		err := applyRecordListFilter(s.db.Model(&models.Record{}), &req.Filter, job.CreatedBy, job.HasAllPermission).
+			Where("id > ? AND id <= ?", lastID, job.MaxRecordID).
+			Order("id ASC").Limit(bulkBatchSize).Pluck("id", &ids).Error
+		if err != nil {
+			s.failJob(&job, fmt.Sprintf("获取记录失败: %v", err))
+			return
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		for _, id := range ids {
+			lastID = id
+			processed++
+			if err := s.applyToRecord(id, req, &job, fieldsByType); err != nil {
+				failed++
+				s.db.Create(&models.RecordBulkJobError{JobID: job.ID, RecordID: id, Error: err.Error()})
+				continue
+			}
+			succeeded++
+		}
+
+		progress := 99
+		if job.TotalRecords > processed {
+			progress = processed * 100 / job.TotalRecords
+		}
+		result := s.db.Model(&models.RecordBulkJob{}).Where("id = ? AND status = ?", job.ID, "processing").
+			Updates(map[string]interface{}{
+				"processed_records": processed,
+				"succeeded_records": succeeded,
+				"failed_records":    failed,
+				"progress":          progress,
+			})
+		if result.RowsAffected == 0 {
+			// 任务已被取消，保存已处理的统计后停止
+			s.db.Model(&models.RecordBulkJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+				"processed_records": processed,
+				"succeeded_records": succeeded,
+				"failed_records":    failed,
+			})
+			job.SucceededRecords, job.FailedRecords = succeeded, failed
+			s.auditJob(&job, "BULK_UPDATE_RECORDS", "", "")
+			return
+		}
+	}
+
+	completedAt := time.Now()
+	s.db.Model(&models.RecordBulkJob{}).Where("id = ? AND status = ?", job.ID, "processing").
+		Updates(map[string]interface{}{
+			"status":            "completed",
+			"progress":          100,
+			"processed_records": processed,
+			"succeeded_records": succeeded,
+			"failed_records":    failed,
+			"completed_at":      &completedAt,
+		})
+
+	job.SucceededRecords, job.FailedRecords = succeeded, failed
+	s.auditJob(&job, "BULK_UPDATE_RECORDS", "", "")
+}
+
+// applyToRecord 对单条记录执行操作，复用单条接口的权限校验与审计
+func (s *RecordBulkService) applyToRecord(id uint, req *RecordBulkRequest, job *models.RecordBulkJob, fieldsByType map[string][]SchemaField) error {
+	userID, hasAll := job.CreatedBy, job.HasAllPermission
+
+	switch req.Operation {
+	case BulkOpSetStatus:
+		_, err := s.workflowService.Transition(id, &RecordTransitionRequest{ToStatus: req.Status, Comment: req.Comment}, userID, hasAll, "", "")
+		return err
+	case BulkOpDelete:
+		return s.recordService.DeleteRecord(id, userID, hasAll, "", "")
+	}
+
+	var record models.Record
+	query := s.db
+	if !hasAll {
+		query = query.Where("created_by = ?", userID)
+	}
+	if err := query.First(&record, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("记录不存在或无权修改")
+		}
+		return fmt.Errorf("获取记录失败: %w", err)
+	}
+
+	update := &UpdateRecordRequest{}
+	switch req.Operation {
+	case BulkOpSetField, BulkOpUnsetField:
+		fields, ok := fieldsByType[record.Type]
+		if !ok {
+			rt, err := s.recordService.recordTypeService.GetRecordTypeByName(record.Type)
+			if err != nil {
+				return err
+			}
+			fields = ParseSchemaFields(rt.Schema)
+			fieldsByType[record.Type] = fields
+		}
+		for _, field := range fields {
+			if field.Name != req.Field {
+				continue
+			}
+			if req.Operation == BulkOpUnsetField && field.Required {
+				return fmt.Errorf("字段 %s 为必填项", field.Name)
+			}
+			if req.Operation == BulkOpSetField && !schemaValueMatchesType(field.Type, req.Value) {
+				return fmt.Errorf("字段 %s 的值与类型 %s 不匹配", field.Name, field.Type)
+			}
+		}
+
+		content := make(map[string]interface{}, len(record.Content)+1)
+		for k, v := range record.Content {
+			content[k] = v
+		}
+		if req.Operation == BulkOpSetField {
+			content[req.Field] = req.Value
+		} else {
+			if _, exists := content[req.Field]; !exists {
+				return nil
+			}
+			delete(content, req.Field)
+		}
+		update.Content = content
+	case BulkOpAddTags, BulkOpRemoveTags:
+		tags, changed := applyBulkTags(record.Tags, req.Tags, req.Operation == BulkOpAddTags)
+		if !changed {
+			return nil
+		}
+		update.Tags = tags
+	}
+
+	_, err := s.recordService.UpdateRecord(id, update, userID, hasAll, "", "")
+	return err
+}
+
+// applyBulkTags 添加或移除标签（按规范化名称比较），返回新标签列表与是否有变化
+func applyBulkTags(current []string, tags []string, add bool) ([]string, bool) {
+	target := make(map[string]string, len(tags))
+	order := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		normalized := NormalizeTagName(tag)
+		if normalized == "" {
+			continue
+		}
+		if _, exists := target[normalized]; !exists {
+			order = append(order, normalized)
+		}
+		target[normalized] = tag
+	}
+
+	result := make([]string, 0, len(current)+len(tags))
+	changed := false
+	for _, tag := range current {
+		normalized := NormalizeTagName(tag)
+		if _, exists := target[normalized]; exists {
+			if add {
+				delete(target, normalized)
+			} else {
+				changed = true
+				continue
+			}
+		}
+		result = append(result, tag)
+	}
+	if add {
+		for _, normalized := range order {
+			if tag, exists := target[normalized]; exists {
+				result = append(result, tag)
+				changed = true
+			}
+		}
+	}
+	return result, changed
+}
+
+// failJob 标记任务失败
+func (s *RecordBulkService) failJob(job *models.RecordBulkJob, message string) {
+	now := time.Now()
+	s.db.Model(job).Updates(map[string]interface{}{
+		"status":        "failed",
+		"error_message": message,
+		"completed_at":  &now,
+	})
+}
+
+// auditJob 记录批量操作任务审计日志
+func (s *RecordBulkService) auditJob(job *models.RecordBulkJob, action, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	s.auditService.CreateAuditLog(&AuditLogRequest{
+		UserID:       job.CreatedBy,
+		Action:       action,
+		ResourceType: "record_bulk_job",
+		ResourceID:   job.ID,
+		NewValues: map[string]interface{}{
+			"operation": job.Operation,
+			"filter":    job.Filter,
+			"params":    job.Params,
+			"total":     job.TotalRecords,
+			"succeeded": job.SucceededRecords,
+			"failed":    job.FailedRecords,
+		},
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+	})
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+
+	"info-management-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// setupBulkTest 创建批量操作测试环境：用户1有5条记录，用户2有1条
+func setupBulkTest(t *testing.T) (*gorm.DB, *RecordBulkService) {
+	db := newServiceTestDB(t)
+
+	for _, name := range []string{"owner", "other"} {
+		require.NoError(t, db.Create(&models.User{Username: name, Email: name + "@example.com", PasswordHash: "x", IsActive: true}).Error)
+	}
+	require.NoError(t, db.Create(&models.RecordType{
+		Name:        "asset",
+		DisplayName: "资产",
+		TableName:   "records_asset",
+		IsActive:    true,
+		Schema:      models.JSONB{"fields": []interface{}{map[string]interface{}{"name": "count", "type": "integer", "required": true}}},
+	}).Error)
+
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, db.Create(&models.Record{
+			Type: "asset", Title: fmt.Sprintf("资产%d", i), Status: "draft", Version: 1, CreatedBy: 1,
+			Content: models.JSONB{"count": float64(i), "note": "x"}, Tags: models.StringSlice{"old"},
+		}).Error)
+	}
+	require.NoError(t, db.Create(&models.Record{
+		Type: "asset", Title: "他人资产", Status: "draft", Version: 1, CreatedBy: 2,
+		Content: models.JSONB{"count": float64(9)},
+	}).Error)
+
+	auditService := NewAuditService(db)
+	typeService := NewRecordTypeService(db)
+	workflowService := NewRecordWorkflowService(db, typeService, auditService, nil)
+	recordService := NewRecordService(db, typeService, auditService, NewFileService(db, auditService), workflowService)
+	bulkService := NewRecordBulkService(db, recordService, workflowService, auditService)
+	bulkService.runAsync = func(f func()) { f() }
+	return db, bulkService
+}
+
+func TestRecordBulkService_Preview(t *testing.T) {
+	_, bulkService := setupBulkTest(t)
+
+	_, err := bulkService.Preview(&RecordBulkRequest{Operation: BulkOpDelete}, 1, true)
+	assert.EqualError(t, err, "批量操作必须指定筛选条件")
+
+	_, err = bulkService.Preview(&RecordBulkRequest{Filter: RecordListQuery{Type: "asset"}, Operation: BulkOpAddTags}, 1, true)
+	assert.EqualError(t, err, "必须指定标签")
+
+	preview, err := bulkService.Preview(&RecordBulkRequest{Filter: RecordListQuery{Type: "asset"}, Operation: BulkOpDelete}, 1, true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), preview.Total)
+
+	// 无全部记录权限时只统计自己的记录
+	preview, err = bulkService.Preview(&RecordBulkRequest{Filter: RecordListQuery{Type: "asset"}, Operation: BulkOpDelete}, 2, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), preview.Total)
+	require.Len(t, preview.Samples, 1)
+	assert.Equal(t, "他人资产", preview.Samples[0].Title)
+}
+
+func TestRecordBulkService_Operations(t *testing.T) {
+	db, bulkService := setupBulkTest(t)
+	filter := RecordListQuery{Type: "asset"}
+
+	run := func(req *RecordBulkRequest) *models.RecordBulkJob {
+		job, err := bulkService.CreateJob(req, 1, false, "", "")
+		require.NoError(t, err)
+		job, err = bulkService.GetJob(job.ID, 1, false)
+		require.NoError(t, err)
+		assert.Equal(t, "completed", job.Status)
+		assert.Equal(t, 5, job.TotalRecords, "只处理有权限的记录")
+		return job
+	}
+
+	job := run(&RecordBulkRequest{Filter: filter, Operation: BulkOpSetField, Field: "location", Value: "仓库A"})
+	assert.Equal(t, 5, job.SucceededRecords)
+
+	job = run(&RecordBulkRequest{Filter: filter, Operation: BulkOpSetField, Field: "count", Value: "many"})
+	assert.Equal(t, 5, job.FailedRecords)
+	errs, err := bulkService.GetJobErrors(job.ID, &RecordBulkErrorQuery{}, 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), errs.Total)
+	assert.Equal(t, "字段 count 的值与类型 integer 不匹配", errs.Errors[0].Error)
+
+	job = run(&RecordBulkRequest{Filter: filter, Operation: BulkOpUnsetField, Field: "note"})
+	assert.Equal(t, 5, job.SucceededRecords)
+
+	job = run(&RecordBulkRequest{Filter: filter, Operation: BulkOpAddTags, Tags: []string{"盘点", "old"}})
+	assert.Equal(t, 5, job.SucceededRecords)
+	job = run(&RecordBulkRequest{Filter: filter, Operation: BulkOpRemoveTags, Tags: []string{"old"}})
+	assert.Equal(t, 5, job.SucceededRecords)
+
+	var record models.Record
+	require.NoError(t, db.Where("title = ?", "资产1").First(&record).Error)
+	assert.Equal(t, "仓库A", record.Content["location"])
+	assert.NotContains(t, record.Content, "note")
+	assert.Equal(t, models.StringSlice{"盘点"}, record.Tags)
+
+	job = run(&RecordBulkRequest{Filter: filter, Operation: BulkOpSetStatus, Status: "published"})
+	assert.Equal(t, 5, job.SucceededRecords)
+	var published int64
+	db.Model(&models.Record{}).Where("status = ?", "published").Count(&published)
+	assert.Equal(t, int64(5), published)
+
+	// 每条记录都有审计日志
+	var audits int64
+	db.Model(&models.AuditLog{}).Where("resource_type = ? AND resource_id = ?", "record", record.ID).Count(&audits)
+	assert.GreaterOrEqual(t, audits, int64(5))
+
+	job = run(&RecordBulkRequest{Filter: filter, Operation: BulkOpDelete})
+	assert.Equal(t, 5, job.SucceededRecords)
+	var remaining []models.Record
+	require.NoError(t, db.Find(&remaining).Error)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, uint(2), remaining[0].CreatedBy)
+}
+
+func TestRecordBulkService_Cancel(t *testing.T) {
+	db, bulkService := setupBulkTest(t)
+
+	var pending func()
+	bulkService.runAsync = func(f func()) { pending = f }
+
+	job, err := bulkService.CreateJob(&RecordBulkRequest{Filter: RecordListQuery{Type: "asset"}, Operation: BulkOpDelete}, 1, true, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, 6, job.TotalRecords)
+
+	_, err = bulkService.CancelJob(job.ID, 2, false, "", "")
+	assert.EqualError(t, err, "批量操作任务不存在")
+
+	cancelled, err := bulkService.CancelJob(job.ID, 1, false, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", cancelled.Status)
+
+	// 已取消的任务不会再执行
+	pending()
+	var count int64
+	db.Model(&models.Record{}).Count(&count)
+	assert.Equal(t, int64(6), count)
+
+	_, err = bulkService.CancelJob(job.ID, 1, false, "", "")
+	assert.EqualError(t, err, "只能取消未完成的任务")
+}
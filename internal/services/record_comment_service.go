@@ -0,0 +1,531 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// RecordCommentService 记录评论服务
+type RecordCommentService struct {
+	db           *gorm.DB
+	auditService *AuditService
+	watchService *WatchService
+}
+
+// NewRecordCommentService 创建记录评论服务
+func NewRecordCommentService(db *gorm.DB, auditService *AuditService, watchService *WatchService) *RecordCommentService {
+	return &RecordCommentService{
+		db:           db,
+		auditService: auditService,
+		watchService: watchService,
+	}
+}
+
+// 评论编辑/删除时限的默认值（分钟），可通过系统配置 comments.edit_window_minutes、
+// comments.delete_window_minutes 调整，0 表示不限制
+const (
+	defaultCommentEditWindowMinutes   = 15
+	defaultCommentDeleteWindowMinutes = 60
+)
+
+// mentionPattern 匹配 @用户名，要求 @ 前不是字母数字，避免误识别邮箱地址
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.@])@([\p{L}\p{N}_.\-]+)`)
+
+// CreateRecordCommentRequest 创建评论请求
+type CreateRecordCommentRequest struct {
+	Content  string `json:"content" binding:"required,max=10000"` // Markdown 内容
+	ParentID *uint  `json:"parent_id"`                            // 回复的评论ID
+}
+
+// UpdateRecordCommentRequest 编辑评论请求
+type UpdateRecordCommentRequest struct {
+	Content string `json:"content" binding:"required,max=10000"`
+}
+
+// CommentMentionResponse 被提及用户
+type CommentMentionResponse struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+}
+
+// RecordCommentResponse 评论响应，Replies 为按时间排序的回复
+type RecordCommentResponse struct {
+	ID          uint                     `json:"id"`
+	RecordID    uint                     `json:"record_id"`
+	ParentID    *uint                    `json:"parent_id"`
+	Content     string                   `json:"content"`
+	UserID      uint                     `json:"user_id"`
+	Username    string                   `json:"username"`
+	DisplayName string                   `json:"display_name"`
+	Mentions    []CommentMentionResponse `json:"mentions"`
+	Deleted     bool                     `json:"deleted"` // 已删除但仍有回复的评论保留占位
+	EditedAt    *time.Time               `json:"edited_at"`
+	CreatedAt   string                   `json:"created_at"`
+	Replies     []RecordCommentResponse  `json:"replies"`
+}
+
+// RecordCommentListResponse 评论列表响应
+type RecordCommentListResponse struct {
+	Comments []RecordCommentResponse `json:"comments"`
+	Total    int64                   `json:"total"` // 未删除的评论数（含回复）
+}
+
+// ListComments 获取记录的评论树
+func (s *RecordCommentService) ListComments(recordID uint, scope *LinkAccessScope) (*RecordCommentListResponse, error) {
+	if _, err := s.findRecord(recordID, scope); err != nil {
+		return nil, err
+	}
+
+	var comments []models.RecordComment
+	if err := s.db.Unscoped().Preload("User").Where("record_id = ?", recordID).
+		Order("created_at ASC, id ASC").Find(&comments).Error; err != nil {
+		return nil, fmt.Errorf("获取评论失败: %w", err)
+	}
+	mentions, err := loadCommentMentions(s.db, comments)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[uint][]*models.RecordComment)
+	var roots []*models.RecordComment
+	var total int64
+	for i := range comments {
+		comment := &comments[i]
+		if !comment.DeletedAt.Valid {
+			total++
+		}
+		if comment.ParentID == nil {
+			roots = append(roots, comment)
+		} else {
+			children[*comment.ParentID] = append(children[*comment.ParentID], comment)
+		}
+	}
+
+	var build func(list []*models.RecordComment) []RecordCommentResponse
+	build = func(list []*models.RecordComment) []RecordCommentResponse {
+		responses := make([]RecordCommentResponse, 0, len(list))
+		for _, comment := range list {
+			replies := build(children[comment.ID])
+			// 已删除且没有保留回复的评论不再显示
+			if comment.DeletedAt.Valid && len(replies) == 0 {
+				continue
+			}
+			response := toRecordCommentResponse(comment, mentions[comment.ID])
+			response.Replies = replies
+			responses = append(responses, *response)
+		}
+		return responses
+	}
+
+	return &RecordCommentListResponse{Comments: build(roots), Total: total}, nil
+}
+
+// CreateComment 发表评论或回复，@ 提及的用户必须能查看该记录
+func (s *RecordCommentService) CreateComment(recordID uint, req *CreateRecordCommentRequest, scope *LinkAccessScope, ipAddress, userAgent string) (*RecordCommentResponse, error) {
+	record, err := s.findRecord(recordID, scope)
+	if err != nil {
+		return nil, err
+	}
+	content := strings.TrimSpace(req.Content)
+	if content == "" {
+		return nil, fmt.Errorf("评论内容不能为空")
+	}
+	if req.ParentID != nil {
+		var parent models.RecordComment
+		if err := s.db.Where("id = ? AND record_id = ?", *req.ParentID, recordID).First(&parent).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, fmt.Errorf("回复的评论不存在")
+			}
+			return nil, fmt.Errorf("获取评论失败: %w", err)
+		}
+	}
+	mentioned, err := resolveMentions(s.db, record, content)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := models.RecordComment{
+		RecordID: recordID,
+		ParentID: req.ParentID,
+		Content:  content,
+		UserID:   scope.UserID,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return fmt.Errorf("发表评论失败: %w", err)
+		}
+		if err := saveCommentMentions(tx, comment.ID, mentioned); err != nil {
+			return err
+		}
+		return AddWatchers(tx, models.WatchEntityRecord, recordID, models.WatchReasonCommenter, scope.UserID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mentionIDs := mentionUserIDs(mentioned)
+	s.audit("CREATE", &comment, nil, map[string]interface{}{
+		"record_id": comment.RecordID,
+		"parent_id": comment.ParentID,
+		"content":   comment.Content,
+		"mentions":  mentionIDs,
+	}, scope.UserID, ipAddress, userAgent)
+	s.notify(record, &comment, mentionIDs, scope.UserID, false)
+
+	return s.getComment(comment.ID)
+}
+
+// UpdateComment 编辑评论，仅作者本人可在编辑时限内修改
+func (s *RecordCommentService) UpdateComment(recordID, commentID uint, req *UpdateRecordCommentRequest, scope *LinkAccessScope, ipAddress, userAgent string) (*RecordCommentResponse, error) {
+	record, err := s.findRecord(recordID, scope)
+	if err != nil {
+		return nil, err
+	}
+	comment, err := s.findComment(recordID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.UserID != scope.UserID {
+		return nil, fmt.Errorf("只能编辑自己的评论")
+	}
+	window := getConfigInt(s.db, "comments", "edit_window_minutes", defaultCommentEditWindowMinutes)
+	if commentWindowExpired(comment.CreatedAt, window) {
+		return nil, fmt.Errorf("评论发表超过 %d 分钟，不能再编辑", window)
+	}
+	content := strings.TrimSpace(req.Content)
+	if content == "" {
+		return nil, fmt.Errorf("评论内容不能为空")
+	}
+	mentioned, err := resolveMentions(s.db, record, content)
+	if err != nil {
+		return nil, err
+	}
+
+	var previous []uint
+	if err := s.db.Model(&models.RecordCommentMention{}).Where("comment_id = ?", comment.ID).
+		Pluck("user_id", &previous).Error; err != nil {
+		return nil, fmt.Errorf("获取提及用户失败: %w", err)
+	}
+
+	oldContent := comment.Content
+	now := time.Now()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(comment).Updates(map[string]interface{}{"content": content, "edited_at": now}).Error; err != nil {
+			return fmt.Errorf("编辑评论失败: %w", err)
+		}
+		if err := tx.Where("comment_id = ?", comment.ID).Delete(&models.RecordCommentMention{}).Error; err != nil {
+			return fmt.Errorf("更新提及用户失败: %w", err)
+		}
+		return saveCommentMentions(tx, comment.ID, mentioned)
+	})
+	if err != nil {
+		return nil, err
+	}
+	comment.Content = content
+
+	// 仅通知新增的提及用户
+	mentionIDs := mentionUserIDs(mentioned)
+	notified := make(map[uint]bool, len(previous))
+	for _, id := range previous {
+		notified[id] = true
+	}
+	var added []uint
+	for _, id := range mentionIDs {
+		if !notified[id] {
+			added = append(added, id)
+		}
+	}
+	s.audit("UPDATE", comment, map[string]interface{}{"content": oldContent, "mentions": previous},
+		map[string]interface{}{"content": content, "mentions": mentionIDs}, scope.UserID, ipAddress, userAgent)
+	s.notify(record, comment, added, scope.UserID, true)
+
+	return s.getComment(comment.ID)
+}
+
+// DeleteComment 删除评论；作者可在删除时限内删除，评论管理员不受限制
+func (s *RecordCommentService) DeleteComment(recordID, commentID uint, scope *LinkAccessScope, canManage bool, ipAddress, userAgent string) error {
+	if _, err := s.findRecord(recordID, scope); err != nil {
+		return err
+	}
+	comment, err := s.findComment(recordID, commentID)
+	if err != nil {
+		return err
+	}
+	if !canManage {
+		if comment.UserID != scope.UserID {
+			return fmt.Errorf("只能删除自己的评论")
+		}
+		window := getConfigInt(s.db, "comments", "delete_window_minutes", defaultCommentDeleteWindowMinutes)
+		if commentWindowExpired(comment.CreatedAt, window) {
+			return fmt.Errorf("评论发表超过 %d 分钟，不能再删除", window)
+		}
+	}
+
+	if err := s.db.Delete(comment).Error; err != nil {
+		return fmt.Errorf("删除评论失败: %w", err)
+	}
+
+	s.audit("DELETE", comment, map[string]interface{}{
+		"record_id": comment.RecordID,
+		"parent_id": comment.ParentID,
+		"content":   comment.Content,
+		"user_id":   comment.UserID,
+	}, nil, scope.UserID, ipAddress, userAgent)
+	return nil
+}
+
+// findRecord 获取用户可查看的记录，审核人可查看待其审核的记录
+func (s *RecordCommentService) findRecord(recordID uint, scope *LinkAccessScope) (*models.Record, error) {
+	var record models.Record
+	query := s.db.Select("id", "title", "created_by", "reviewer_id")
+	if !scope.AllRecords {
+		query = query.Where("created_by = ? OR reviewer_id = ?", scope.UserID, scope.UserID)
+	}
+	if err := query.First(&record, recordID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("记录不存在或无权访问")
+		}
+		return nil, fmt.Errorf("获取记录失败: %w", err)
+	}
+	return &record, nil
+}
+
+// findComment 获取记录下未删除的评论
+func (s *RecordCommentService) findComment(recordID, commentID uint) (*models.RecordComment, error) {
+	var comment models.RecordComment
+	if err := s.db.Where("id = ? AND record_id = ?", commentID, recordID).First(&comment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("评论不存在")
+		}
+		return nil, fmt.Errorf("获取评论失败: %w", err)
+	}
+	return &comment, nil
+}
+
+// getComment 获取评论响应（不含回复）
+func (s *RecordCommentService) getComment(commentID uint) (*RecordCommentResponse, error) {
+	var comment models.RecordComment
+	if err := s.db.Preload("User").First(&comment, commentID).Error; err != nil {
+		return nil, fmt.Errorf("获取评论失败: %w", err)
+	}
+	mentions, err := loadCommentMentions(s.db, []models.RecordComment{comment})
+	if err != nil {
+		return nil, err
+	}
+	return toRecordCommentResponse(&comment, mentions[comment.ID]), nil
+}
+
+// audit 记录评论操作审计日志
+func (s *RecordCommentService) audit(action string, comment *models.RecordComment, oldValues, newValues map[string]interface{}, userID uint, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	s.auditService.CreateAuditLog(&AuditLogRequest{
+		UserID:       userID,
+		Action:       action,
+		ResourceType: "record_comment",
+		ResourceID:   comment.ID,
+		OldValues:    oldValues,
+		NewValues:    newValues,
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+	})
+}
+
+// notify 通知被提及的用户；新评论同时通知记录的其他关注者（已收到提及通知的除外）
+func (s *RecordCommentService) notify(record *models.Record, comment *models.RecordComment, mentionIDs []uint, actorID uint, edited bool) {
+	var actor models.User
+	s.db.Select("id", "username").First(&actor, actorID)
+
+	s.watchService.NotifyUsers(mentionIDs, actorID, "评论提及通知",
+		fmt.Sprintf("%s 在记录「%s」的评论中提到了你：%s", actor.Username, record.Title, commentExcerpt(comment.Content)))
+	if edited {
+		return
+	}
+	s.watchService.NotifyWatchers(models.WatchEntityRecord, record.ID, actorID, "记录评论通知",
+		fmt.Sprintf("%s 评论了记录「%s」：%s", actor.Username, record.Title, commentExcerpt(comment.Content)), mentionIDs...)
+}
+
+// resolveMentions 解析评论中的 @用户名，忽略不存在或已停用的用户；
+// 被提及的用户无权查看记录时拒绝，避免通过提及泄露记录
+func resolveMentions(db *gorm.DB, record *models.Record, content string) ([]models.User, error) {
+	var usernames []string
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		username := strings.TrimRight(match[1], ".-")
+		if username != "" && !seen[username] {
+			seen[username] = true
+			usernames = append(usernames, username)
+		}
+	}
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+
+	var users []models.User
+	if err := db.Select("id", "username").Where("username IN ? AND is_active = ?", usernames, true).
+		Order("id ASC").Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("查询提及用户失败: %w", err)
+	}
+	for _, user := range users {
+		if !userCanReadRecord(db, user.ID, record) {
+			return nil, fmt.Errorf("用户 %s 无权查看该记录，不能提及", user.Username)
+		}
+	}
+	return users, nil
+}
+
+// userCanReadRecord 判断用户能否查看记录：创建者、审核人、管理员或拥有 records:read:all 权限
+func userCanReadRecord(db *gorm.DB, userID uint, record *models.Record) bool {
+	if userID == 1 || record.CreatedBy == userID || (record.ReviewerID != nil && *record.ReviewerID == userID) {
+		return true
+	}
+	for _, role := range getUserRoleNames(db, userID) {
+		if role == "admin" || role == "系统管理员" || role == "administrator" {
+			return true
+		}
+	}
+
+	var count int64
+	db.Table("permissions").
+		Where("permissions.resource = ? AND permissions.action = ? AND permissions.scope = ?", "records", "read", "all").
+		Where("permissions.id IN (?) OR permissions.id IN (?)",
+			db.Table("role_permissions").Select("role_permissions.permission_id").
+				Joins("JOIN user_roles ON user_roles.role_id = role_permissions.role_id").
+				Where("user_roles.user_id = ?", userID),
+			db.Table("user_permissions").Select("permission_id").Where("user_id = ?", userID)).
+		Count(&count)
+	return count > 0
+}
+
+// saveCommentMentions 保存评论提及的用户
+func saveCommentMentions(tx *gorm.DB, commentID uint, users []models.User) error {
+	for _, user := range users {
+		if err := tx.Create(&models.RecordCommentMention{CommentID: commentID, UserID: user.ID}).Error; err != nil {
+			return fmt.Errorf("保存提及用户失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// loadCommentMentions 批量加载评论提及的用户
+func loadCommentMentions(db *gorm.DB, comments []models.RecordComment) (map[uint][]CommentMentionResponse, error) {
+	result := make(map[uint][]CommentMentionResponse)
+	if len(comments) == 0 {
+		return result, nil
+	}
+	ids := make([]uint, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.ID
+	}
+
+	var rows []struct {
+		CommentID uint
+		UserID    uint
+		Username  string
+	}
+	if err := db.Table("record_comment_mentions").
+		Select("record_comment_mentions.comment_id, record_comment_mentions.user_id, users.username").
+		Joins("JOIN users ON users.id = record_comment_mentions.user_id").
+		Where("record_comment_mentions.comment_id IN ?", ids).
+		Order("record_comment_mentions.user_id ASC").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("获取提及用户失败: %w", err)
+	}
+	for _, row := range rows {
+		result[row.CommentID] = append(result[row.CommentID], CommentMentionResponse{UserID: row.UserID, Username: row.Username})
+	}
+	return result, nil
+}
+
+// countRecordComments 统计记录的未删除评论数
+func countRecordComments(db *gorm.DB, recordIDs []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64)
+	if len(recordIDs) == 0 {
+		return counts, nil
+	}
+	var rows []struct {
+		RecordID uint
+		Count    int64
+	}
+	if err := db.Model(&models.RecordComment{}).Select("record_id, COUNT(*) AS count").
+		Where("record_id IN ?", recordIDs).Group("record_id").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("统计评论数失败: %w", err)
+	}
+	for _, row := range rows {
+		counts[row.RecordID] = row.Count
+	}
+	return counts, nil
+}
+
+// deleteRecordComments 彻底删除记录的评论及提及
+func deleteRecordComments(tx *gorm.DB, recordIDs []uint) error {
+	if len(recordIDs) == 0 {
+		return nil
+	}
+	if err := tx.Where("comment_id IN (?)", tx.Unscoped().Model(&models.RecordComment{}).Select("id").
+		Where("record_id IN ?", recordIDs)).Delete(&models.RecordCommentMention{}).Error; err != nil {
+		return fmt.Errorf("删除评论提及失败: %w", err)
+	}
+	if err := tx.Unscoped().Where("record_id IN ?", recordIDs).Delete(&models.RecordComment{}).Error; err != nil {
+		return fmt.Errorf("删除记录评论失败: %w", err)
+	}
+	return nil
+}
+
+// toRecordCommentResponse 转换评论响应，已删除的评论隐藏内容
+func toRecordCommentResponse(comment *models.RecordComment, mentions []CommentMentionResponse) *RecordCommentResponse {
+	response := &RecordCommentResponse{
+		ID:          comment.ID,
+		RecordID:    comment.RecordID,
+		ParentID:    comment.ParentID,
+		Content:     comment.Content,
+		UserID:      comment.UserID,
+		Username:    comment.User.Username,
+		DisplayName: comment.User.DisplayName,
+		Mentions:    mentions,
+		EditedAt:    comment.EditedAt,
+		CreatedAt:   comment.CreatedAt.Format("2006-01-02 15:04:05"),
+		Replies:     []RecordCommentResponse{},
+	}
+	if response.Mentions == nil {
+		response.Mentions = []CommentMentionResponse{}
+	}
+	if comment.DeletedAt.Valid {
+		response.Deleted = true
+		response.Content = ""
+		response.Mentions = []CommentMentionResponse{}
+	}
+	return response
+}
+
+// mentionUserIDs 提取被提及用户的ID（升序）
+func mentionUserIDs(users []models.User) []uint {
+	ids := make([]uint, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// commentWindowExpired 判断评论是否已超过编辑/删除时限，window 为 0 时不限制
+func commentWindowExpired(createdAt time.Time, windowMinutes int) bool {
+	return windowMinutes > 0 && time.Since(createdAt) > time.Duration(windowMinutes)*time.Minute
+}
+
+// commentExcerpt 截取评论摘要用于通知
+func commentExcerpt(content string) string {
+	runes := []rune(content)
+	if len(runes) > 100 {
+		return string(runes[:100]) + "..."
+	}
+	return content
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupCommentTest 在关注测试环境基础上创建一条由 author 创建、reviewer 审核的记录
+func setupCommentTest(t *testing.T) (*RecordService, *RecordCommentService, uint) {
+	recordService, workflowService, watchService := setupWatchTest(t)
+	db := watchService.db
+
+	record, err := recordService.CreateRecord(&CreateRecordRequest{Type: "review", Title: "季度报告", Content: map[string]interface{}{"summary": "季度"}}, 1, "", "")
+	require.NoError(t, err)
+	_, err = workflowService.Transition(record.ID, &RecordTransitionRequest{Action: "submit"}, 1, false, "", "")
+	require.NoError(t, err)
+	require.NoError(t, workflowService.AssignReviewer(record.ID, &AssignReviewerRequest{ReviewerID: 2}, 1, false, "", ""))
+
+	return recordService, NewRecordCommentService(db, NewAuditService(db), watchService), record.ID
+}
+
+func TestRecordCommentService_ThreadsAndMentions(t *testing.T) {
+	recordService, commentService, recordID := setupCommentTest(t)
+	db := commentService.db
+	author, reviewer, outsider := &LinkAccessScope{UserID: 1}, &LinkAccessScope{UserID: 2}, &LinkAccessScope{UserID: 3}
+
+	// 无权查看记录的用户不能评论，也不能被提及
+	_, err := commentService.CreateComment(recordID, &CreateRecordCommentRequest{Content: "看一下"}, outsider, "", "")
+	assert.EqualError(t, err, "记录不存在或无权访问")
+	_, err = commentService.CreateComment(recordID, &CreateRecordCommentRequest{Content: "@outsider 请看"}, author, "", "")
+	assert.EqualError(t, err, "用户 outsider 无权查看该记录，不能提及")
+
+	// 审核人可以被提及；不存在的用户名和邮箱地址被忽略
+	root, err := commentService.CreateComment(recordID, &CreateRecordCommentRequest{
+		Content: "**请审核** @reviewer，抄送 @nobody 和 mail@outsider.com"}, author, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, []CommentMentionResponse{{UserID: 2, Username: "reviewer"}}, root.Mentions)
+	assert.Equal(t, "author", root.Username)
+
+	// 授予查看全部记录的权限后可以被提及
+	permission := models.Permission{Name: "records:read:all", DisplayName: "查看全部记录", Resource: "records", Action: "read", Scope: "all"}
+	require.NoError(t, db.Create(&permission).Error)
+	role := models.Role{Name: "auditor", DisplayName: "审计员"}
+	require.NoError(t, db.Create(&role).Error)
+	require.NoError(t, db.Create(&models.RolePermission{RoleID: role.ID, PermissionID: permission.ID}).Error)
+	require.NoError(t, db.Create(&models.UserRole{UserID: 3, RoleID: role.ID}).Error)
+
+	var before int64
+	db.Model(&models.Notification{}).Count(&before)
+	reply, err := commentService.CreateComment(recordID, &CreateRecordCommentRequest{Content: "已看，@outsider 复核", ParentID: &root.ID}, reviewer, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, &root.ID, reply.ParentID)
+
+	// 被提及者收到提及通知，其他关注者（作者）收到评论通知，回复者本人不收通知
+	var notifications []models.Notification
+	require.NoError(t, db.Where("id > ?", before).Order("id").Find(&notifications).Error)
+	subjects := map[string]string{}
+	for _, n := range notifications {
+		subjects[n.Recipients] = n.Subject
+	}
+	assert.Equal(t, map[string]string{`["outsider"]`: "评论提及通知", `["author"]`: "记录评论通知"}, subjects)
+
+	// 评论者自动关注记录
+	var watcher models.Watcher
+	require.NoError(t, db.Where("entity_type = ? AND entity_id = ? AND user_id = ?", models.WatchEntityRecord, recordID, 2).First(&watcher).Error)
+
+	_, err = commentService.CreateComment(recordID, &CreateRecordCommentRequest{Content: "另起", ParentID: &reply.ID}, reviewer, "", "")
+	require.NoError(t, err)
+	other, err := commentService.CreateComment(recordID, &CreateRecordCommentRequest{Content: "第二条"}, author, "", "")
+	require.NoError(t, err)
+	missing := uint(999)
+	_, err = commentService.CreateComment(recordID, &CreateRecordCommentRequest{Content: "x", ParentID: &missing}, author, "", "")
+	assert.EqualError(t, err, "回复的评论不存在")
+
+	list, err := commentService.ListComments(recordID, reviewer)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), list.Total)
+	require.Len(t, list.Comments, 2)
+	assert.Equal(t, root.ID, list.Comments[0].ID)
+	require.Len(t, list.Comments[0].Replies, 1)
+	require.Len(t, list.Comments[0].Replies[0].Replies, 1)
+	assert.Equal(t, other.ID, list.Comments[1].ID)
+
+	// 评论数出现在记录列表与详情中
+	records, err := recordService.GetRecords(&RecordListQuery{}, 1, false)
+	require.NoError(t, err)
+	require.Len(t, records.Records, 1)
+	assert.Equal(t, int64(4), records.Records[0].CommentCount)
+
+	// 删除有回复的评论后保留占位
+	require.NoError(t, commentService.DeleteComment(recordID, root.ID, author, false, "", ""))
+	list, err = commentService.ListComments(recordID, author)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), list.Total)
+	assert.True(t, list.Comments[0].Deleted)
+	assert.Empty(t, list.Comments[0].Content)
+	assert.Len(t, list.Comments[0].Replies, 1)
+	detail, err := recordService.GetRecordByID(recordID, 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), detail.CommentCount)
+
+	var audits int64
+	db.Model(&models.AuditLog{}).Where("resource_type = ?", "record_comment").Count(&audits)
+	assert.Equal(t, int64(5), audits)
+}
+
+func TestRecordCommentService_EditAndDeleteWindows(t *testing.T) {
+	_, commentService, recordID := setupCommentTest(t)
+	db := commentService.db
+	author, reviewer := &LinkAccessScope{UserID: 1}, &LinkAccessScope{UserID: 2}
+
+	comment, err := commentService.CreateComment(recordID, &CreateRecordCommentRequest{Content: "初稿"}, author, "", "")
+	require.NoError(t, err)
+
+	_, err = commentService.UpdateComment(recordID, comment.ID, &UpdateRecordCommentRequest{Content: "改"}, reviewer, "", "")
+	assert.EqualError(t, err, "只能编辑自己的评论")
+
+	var before int64
+	db.Model(&models.Notification{}).Count(&before)
+	updated, err := commentService.UpdateComment(recordID, comment.ID, &UpdateRecordCommentRequest{Content: "定稿 @reviewer"}, author, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "定稿 @reviewer", updated.Content)
+	assert.NotNil(t, updated.EditedAt)
+	assert.Len(t, updated.Mentions, 1)
+
+	// 编辑只通知新增的提及用户
+	var after int64
+	db.Model(&models.Notification{}).Count(&after)
+	assert.Equal(t, before+1, after)
+	_, err = commentService.UpdateComment(recordID, comment.ID, &UpdateRecordCommentRequest{Content: "定稿 @reviewer !"}, author, "", "")
+	require.NoError(t, err)
+	db.Model(&models.Notification{}).Count(&before)
+	assert.Equal(t, after, before)
+
+	// 超过编辑时限后不能再编辑，配置为0时不限制
+	require.NoError(t, db.Model(&models.RecordComment{}).Where("id = ?", comment.ID).
+		Update("created_at", time.Now().Add(-30*time.Minute)).Error)
+	_, err = commentService.UpdateComment(recordID, comment.ID, &UpdateRecordCommentRequest{Content: "再改"}, author, "", "")
+	assert.EqualError(t, err, "评论发表超过 15 分钟，不能再编辑")
+	require.NoError(t, db.Create(&models.SystemConfig{Category: "comments", Key: "edit_window_minutes", Value: "0"}).Error)
+	_, err = commentService.UpdateComment(recordID, comment.ID, &UpdateRecordCommentRequest{Content: "再改"}, author, "", "")
+	require.NoError(t, err)
+
+	// 删除：他人不能删除，超过删除时限后作者也不能删除，评论管理员不受限制
+	assert.EqualError(t, commentService.DeleteComment(recordID, comment.ID, reviewer, false, "", ""), "只能删除自己的评论")
+	require.NoError(t, db.Model(&models.RecordComment{}).Where("id = ?", comment.ID).
+		Update("created_at", time.Now().Add(-2*time.Hour)).Error)
+	assert.EqualError(t, commentService.DeleteComment(recordID, comment.ID, author, false, "", ""), "评论发表超过 60 分钟，不能再删除")
+	require.NoError(t, commentService.DeleteComment(recordID, comment.ID, reviewer, true, "", ""))
+	assert.EqualError(t, commentService.DeleteComment(recordID, comment.ID, author, true, "", ""), "评论不存在")
+
+	list, err := commentService.ListComments(recordID, author)
+	require.NoError(t, err)
+	assert.Empty(t, list.Comments)
+	assert.Equal(t, int64(0), list.Total)
+}
@@ -0,0 +1,551 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// 导入时唯一键冲突的处理策略
+const (
+	DuplicatePolicyReject = "reject" // 拒绝重复记录（默认）
+	DuplicatePolicyUpsert = "upsert" // 覆盖已有记录的标题、内容与标签
+)
+
+// 查找疑似重复记录的默认参数
+const (
+	defaultDuplicateThreshold = 0.8
+	defaultDuplicateLimit     = 50
+	duplicateScanLimit        = 2000 // 单次最多比对的记录数（按最近更新）
+)
+
+// DuplicateQuery 查找疑似重复记录查询参数
+type DuplicateQuery struct {
+	Type      string  `form:"type" binding:"required"`
+	Threshold float64 `form:"threshold" binding:"omitempty,gt=0,lte=1"`
+	Limit     int     `form:"limit" binding:"omitempty,min=1,max=200"`
+}
+
+// DuplicateCandidate 疑似重复的记录对
+type DuplicateCandidate struct {
+	RecordID       uint    `json:"record_id"`
+	Title          string  `json:"title"`
+	DuplicateID    uint    `json:"duplicate_id"`
+	DuplicateTitle string  `json:"duplicate_title"`
+	Score          float64 `json:"score"`
+	TitleScore     float64 `json:"title_score"`
+	ContentScore   float64 `json:"content_score"`
+	SameUniqueKey  bool    `json:"same_unique_key"`
+}
+
+// MergeRecordsRequest 合并重复记录请求
+type MergeRecordsRequest struct {
+	TargetID  uint   `json:"target_id" binding:"required"`
+	SourceIDs []uint `json:"source_ids" binding:"required,min=1,max=50"`
+	Comment   string `json:"comment" binding:"max=500"`
+}
+
+// IsValidDuplicatePolicy 检查唯一键冲突策略是否有效，空值表示默认策略
+func IsValidDuplicatePolicy(policy string) bool {
+	return policy == "" || policy == DuplicatePolicyReject || policy == DuplicatePolicyUpsert
+}
+
+// validateUniqueKeys 校验唯一键只能引用Schema中的非文件字段
+func validateUniqueKeys(keys []string, schema models.JSONB) error {
+	fieldTypes := map[string]string{}
+	for _, field := range ParseSchemaFields(schema) {
+		fieldTypes[field.Name] = field.Type
+	}
+	seen := map[string]bool{}
+	for _, key := range keys {
+		fieldType, ok := fieldTypes[key]
+		if !ok {
+			return fmt.Errorf("唯一键字段 %s 不在Schema中", key)
+		}
+		if fieldType == "file" || fieldType == "files" {
+			return fmt.Errorf("唯一键字段 %s 不能是文件字段", key)
+		}
+		if seen[key] {
+			return fmt.Errorf("唯一键字段 %s 重复", key)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// computeRecordUniqueKey 计算记录唯一键：各键字段值规范化后取摘要，任一字段缺失时返回空（不参与重复校验）
+func computeRecordUniqueKey(keys []string, content map[string]interface{}) string {
+	if len(keys) == 0 {
+		return ""
+	}
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		value, ok := content[key]
+		if !ok || value == nil {
+			return ""
+		}
+		normalized := normalizeDuplicateText(fmt.Sprint(value))
+		if normalized == "" {
+			return ""
+		}
+		values[i] = normalized
+	}
+	sum := sha256.Sum256([]byte(strings.Join(values, "\x1f")))
+	return hex.EncodeToString(sum[:])
+}
+
+// recordUniqueKey 按记录类型的唯一键定义计算记录唯一键，db 可以是调用方的事务
+func recordUniqueKey(db *gorm.DB, recordType string, content map[string]interface{}) (string, error) {
+	var rt models.RecordType
+	if err := db.Select("unique_keys").Where("name = ?", recordType).First(&rt).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return "", fmt.Errorf("记录类型不存在")
+		}
+		return "", fmt.Errorf("获取记录类型失败: %w", err)
+	}
+	return computeRecordUniqueKey(rt.UniqueKeys, content), nil
+}
+
+// findDuplicateRecord 查找唯一键相同的已有记录，excludeID 用于更新时排除自身
+func findDuplicateRecord(tx *gorm.DB, recordType, uniqueKey string, excludeID uint) (*models.Record, error) {
+	if uniqueKey == "" {
+		return nil, nil
+	}
+	var existing models.Record
+	err := tx.Where("type = ? AND unique_key = ? AND id <> ?", recordType, uniqueKey, excludeID).
+		Order("id ASC").First(&existing).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("检查重复记录失败: %w", err)
+	}
+	return &existing, nil
+}
+
+// duplicateRecordError 记录与已有记录重复的错误
+func duplicateRecordError(existing *models.Record) error {
+	return fmt.Errorf("记录与已有记录重复（ID: %d，标题: %s）", existing.ID, existing.Title)
+}
+
+// upsertRecord 按唯一键覆盖已有记录，仅允许覆盖本人创建且未被他人锁定的记录；
+// 返回覆盖前的记录用于审计
+func upsertRecord(tx *gorm.DB, existing *models.Record, title string, content map[string]interface{}, tags []string, userID uint) (*models.Record, error) {
+	if err := checkUpsertAllowed(existing, userID); err != nil {
+		return nil, err
+	}
+	if _, err := checkRecordLock(tx, existing.ID, userID, false); err != nil {
+		return nil, err
+	}
+
+	oldRecord := *existing
+	existing.Title = title
+	existing.Content = models.JSONB(content)
+	existing.Version++
+	if err := tx.Save(existing).Error; err != nil {
+		return nil, fmt.Errorf("覆盖记录失败: %w", err)
+	}
+	if tags != nil {
+		synced, err := SyncEntityTags(tx, models.TagEntityRecord, existing.ID, tags, userID)
+		if err != nil {
+			return nil, err
+		}
+		existing.Tags = synced
+	}
+	return &oldRecord, nil
+}
+
+// checkUpsertAllowed 只能覆盖本人创建的记录
+func checkUpsertAllowed(existing *models.Record, userID uint) error {
+	if existing.CreatedBy != userID {
+		return fmt.Errorf("记录与已有记录重复（ID: %d），无权覆盖他人的记录", existing.ID)
+	}
+	return nil
+}
+
+// recomputeUniqueKeys 唯一键定义变更后重新计算该类型所有记录（含回收站）的唯一键
+func recomputeUniqueKeys(tx *gorm.DB, recordType *models.RecordType) error {
+	var records []models.Record
+	err := tx.Unscoped().Select("id", "content").Where("type = ?", recordType.Name).
+		FindInBatches(&records, 200, func(batch *gorm.DB, _ int) error {
+			for _, record := range records {
+				key := computeRecordUniqueKey(recordType.UniqueKeys, record.Content)
+				if err := tx.Unscoped().Model(&models.Record{}).Where("id = ?", record.ID).UpdateColumn("unique_key", key).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return fmt.Errorf("重新计算记录唯一键失败: %w", err)
+	}
+	return nil
+}
+
+// FindDuplicates 在同一类型内查找疑似重复的记录：唯一键相同视为完全重复，
+// 否则按规范化标题与内容字段的相似度打分
+func (s *RecordService) FindDuplicates(query *DuplicateQuery, userID uint, hasAllPermission bool) ([]DuplicateCandidate, error) {
+	if _, err := s.recordTypeService.GetRecordTypeByName(query.Type); err != nil {
+		return nil, err
+	}
+	threshold := query.Threshold
+	if threshold <= 0 {
+		threshold = defaultDuplicateThreshold
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultDuplicateLimit
+	}
+
+	db := s.db.Where("type = ?", query.Type)
+	if !hasAllPermission {
+		db = db.Where("created_by = ?", userID)
+	}
+	var records []models.Record
+	if err := db.Order("updated_at DESC").Limit(duplicateScanLimit).Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("获取记录失败: %w", err)
+	}
+
+	type fingerprint struct {
+		title   map[string]bool
+		content map[string]map[string]bool
+	}
+	prints := make([]fingerprint, len(records))
+	for i, record := range records {
+		content := map[string]map[string]bool{}
+		for key, value := range record.Content {
+			if value == nil {
+				continue
+			}
+			content[key] = textBigrams(normalizeDuplicateText(fmt.Sprint(value)))
+		}
+		prints[i] = fingerprint{title: textBigrams(normalizeDuplicateText(record.Title)), content: content}
+	}
+
+	candidates := []DuplicateCandidate{}
+	for i := range records {
+		for j := i + 1; j < len(records); j++ {
+			a, b := &records[i], &records[j]
+			candidate := DuplicateCandidate{RecordID: a.ID, Title: a.Title, DuplicateID: b.ID, DuplicateTitle: b.Title}
+			if a.ID > b.ID {
+				candidate = DuplicateCandidate{RecordID: b.ID, Title: b.Title, DuplicateID: a.ID, DuplicateTitle: a.Title}
+			}
+
+			candidate.TitleScore = jaccard(prints[i].title, prints[j].title)
+			candidate.ContentScore = contentSimilarity(prints[i].content, prints[j].content)
+			if a.UniqueKey != "" && a.UniqueKey == b.UniqueKey {
+				candidate.SameUniqueKey = true
+				candidate.Score = 1
+			} else if len(prints[i].content)+len(prints[j].content) == 0 {
+				candidate.Score = candidate.TitleScore
+			} else {
+				candidate.Score = 0.6*candidate.TitleScore + 0.4*candidate.ContentScore
+			}
+			if candidate.Score < threshold {
+				continue
+			}
+			candidate.Score = roundScore(candidate.Score)
+			candidate.TitleScore = roundScore(candidate.TitleScore)
+			candidate.ContentScore = roundScore(candidate.ContentScore)
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		if candidates[i].RecordID != candidates[j].RecordID {
+			return candidates[i].RecordID < candidates[j].RecordID
+		}
+		return candidates[i].DuplicateID < candidates[j].DuplicateID
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates, nil
+}
+
+// MergeRecords 将重复记录合并到目标记录：关联、附件、状态历史、标签与关注者并入目标记录，
+// 被合并的记录移入回收站
+func (s *RecordService) MergeRecords(req *MergeRecordsRequest, userID uint, hasAllPermission bool, ipAddress, userAgent string) (*RecordResponse, error) {
+	sourceIDs := uniqueUints(req.SourceIDs)
+	target, err := s.findAccessibleRecord(req.TargetID, userID, hasAllPermission, "目标记录不存在或无权修改")
+	if err != nil {
+		return nil, err
+	}
+	sources := make([]*models.Record, 0, len(sourceIDs))
+	for _, id := range sourceIDs {
+		if id == target.ID {
+			return nil, fmt.Errorf("不能将记录合并到自身")
+		}
+		source, err := s.findAccessibleRecord(id, userID, hasAllPermission, fmt.Sprintf("记录 %d 不存在或无权修改", id))
+		if err != nil {
+			return nil, err
+		}
+		if source.Type != target.Type {
+			return nil, fmt.Errorf("只能合并同一类型的记录")
+		}
+		sources = append(sources, source)
+	}
+
+	oldTarget := *target
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, id := range append([]uint{target.ID}, sourceIDs...) {
+			if _, err := checkRecordLock(tx, id, userID, false); err != nil {
+				return err
+			}
+		}
+		if err := mergeEntityLinks(tx, models.LinkEntityRecord, target.ID, sourceIDs); err != nil {
+			return err
+		}
+		if err := mergeRecordFiles(tx, target.ID, sourceIDs); err != nil {
+			return err
+		}
+
+		// 状态历史并入目标记录，并追加一条合并记录
+		if err := tx.Model(&models.RecordStatusHistory{}).Where("record_id IN ?", sourceIDs).
+			Update("record_id", target.ID).Error; err != nil {
+			return fmt.Errorf("合并状态历史失败: %w", err)
+		}
+		comment := fmt.Sprintf("合并重复记录 %s", joinUints(sourceIDs))
+		if req.Comment != "" {
+			comment += "：" + req.Comment
+		}
+		history := models.RecordStatusHistory{
+			RecordID:   target.ID,
+			FromStatus: target.Status,
+			ToStatus:   target.Status,
+			Action:     "merge",
+			Comment:    comment,
+			UserID:     userID,
+		}
+		if err := tx.Create(&history).Error; err != nil {
+			return fmt.Errorf("记录合并历史失败: %w", err)
+		}
+
+		// 评论并入目标记录
+		if err := tx.Unscoped().Model(&models.RecordComment{}).Where("record_id IN ?", sourceIDs).
+			Update("record_id", target.ID).Error; err != nil {
+			return fmt.Errorf("合并评论失败: %w", err)
+		}
+
+		// 标签取并集
+		tags := append([]string{}, target.Tags...)
+		for _, source := range sources {
+			tags = append(tags, source.Tags...)
+		}
+		synced, err := SyncEntityTags(tx, models.TagEntityRecord, target.ID, tags, userID)
+		if err != nil {
+			return err
+		}
+		target.Tags = synced
+		target.Version++
+		if err := tx.Model(target).Updates(map[string]interface{}{"tags": target.Tags, "version": target.Version}).Error; err != nil {
+			return fmt.Errorf("更新目标记录失败: %w", err)
+		}
+
+		// 关注者并入目标记录
+		var watchers []models.Watcher
+		if err := tx.Where("entity_type = ? AND entity_id IN ?", models.WatchEntityRecord, sourceIDs).Find(&watchers).Error; err != nil {
+			return fmt.Errorf("获取关注者失败: %w", err)
+		}
+		for _, watcher := range watchers {
+			if err := AddWatchers(tx, models.WatchEntityRecord, target.ID, watcher.Reason, watcher.UserID); err != nil {
+				return err
+			}
+		}
+		if err := deleteEntityWatchers(tx, models.WatchEntityRecord, sourceIDs); err != nil {
+			return err
+		}
+
+		if err := tx.Where("record_id IN ?", sourceIDs).Delete(&models.RecordLock{}).Error; err != nil {
+			return fmt.Errorf("解除编辑锁失败: %w", err)
+		}
+		if err := tx.Where("id IN ?", sourceIDs).Delete(&models.Record{}).Error; err != nil {
+			return fmt.Errorf("删除被合并记录失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.auditService != nil {
+		s.auditService.CreateAuditLog(&AuditLogRequest{
+			UserID:       userID,
+			Action:       "MERGE",
+			ResourceType: "record",
+			ResourceID:   target.ID,
+			OldValues:    map[string]interface{}{"tags": oldTarget.Tags, "version": oldTarget.Version},
+			NewValues:    map[string]interface{}{"merged_ids": sourceIDs, "tags": target.Tags, "version": target.Version},
+			IPAddress:    ipAddress,
+			UserAgent:    userAgent,
+		})
+	}
+	s.watchService.NotifyWatchers(models.WatchEntityRecord, target.ID, userID, "记录合并通知",
+		fmt.Sprintf("记录 %s 已合并到记录「%s」", joinUints(sourceIDs), target.Title))
+
+	return s.GetRecordByID(target.ID, userID, hasAllPermission)
+}
+
+// mergeEntityLinks 将被合并记录或工单的关联改指向目标，去除重复关联和指向自身的关联
+func mergeEntityLinks(tx *gorm.DB, entityType string, targetID uint, sourceIDs []uint) error {
+	var links []models.EntityLink
+	err := tx.Where("(source_type = ? AND source_id IN ?) OR (target_type = ? AND target_id IN ?)",
+		entityType, sourceIDs, entityType, sourceIDs).
+		Order("id ASC").Find(&links).Error
+	if err != nil {
+		return fmt.Errorf("获取关联失败: %w", err)
+	}
+
+	merged := map[uint]bool{}
+	for _, id := range sourceIDs {
+		merged[id] = true
+	}
+	repoint := func(linkEntityType string, id uint) uint {
+		if linkEntityType == entityType && merged[id] {
+			return targetID
+		}
+		return id
+	}
+
+	for _, link := range links {
+		sourceID, targetLinkID := repoint(link.SourceType, link.SourceID), repoint(link.TargetType, link.TargetID)
+		selfLink := link.SourceType == link.TargetType && sourceID == targetLinkID
+
+		var exists int64
+		if !selfLink {
+			if err := tx.Model(&models.EntityLink{}).
+				Where("source_type = ? AND source_id = ? AND target_type = ? AND target_id = ? AND link_type = ?",
+					link.SourceType, sourceID, link.TargetType, targetLinkID, link.LinkType).
+				Count(&exists).Error; err != nil {
+				return fmt.Errorf("合并关联失败: %w", err)
+			}
+		}
+		if selfLink || exists > 0 {
+			if err := tx.Delete(&models.EntityLink{}, link.ID).Error; err != nil {
+				return fmt.Errorf("合并关联失败: %w", err)
+			}
+			continue
+		}
+		if err := tx.Model(&models.EntityLink{}).Where("id = ?", link.ID).
+			Updates(map[string]interface{}{"source_id": sourceID, "target_id": targetLinkID}).Error; err != nil {
+			return fmt.Errorf("合并关联失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// mergeRecordFiles 将被合并记录的附件转为目标记录的普通附件，目标记录已有的文件不重复关联
+func mergeRecordFiles(tx *gorm.DB, targetID uint, sourceIDs []uint) error {
+	var files []models.RecordFile
+	if err := tx.Where("record_id IN ?", sourceIDs).Order("id ASC").Find(&files).Error; err != nil {
+		return fmt.Errorf("获取记录附件失败: %w", err)
+	}
+	for _, file := range files {
+		var exists int64
+		if err := tx.Model(&models.RecordFile{}).Where("record_id = ? AND file_id = ? AND field_name = ?", targetID, file.FileID, "").
+			Count(&exists).Error; err != nil {
+			return fmt.Errorf("合并记录附件失败: %w", err)
+		}
+		if exists > 0 {
+			if err := tx.Delete(&models.RecordFile{}, file.ID).Error; err != nil {
+				return fmt.Errorf("合并记录附件失败: %w", err)
+			}
+			continue
+		}
+		if err := tx.Model(&models.RecordFile{}).Where("id = ?", file.ID).
+			Updates(map[string]interface{}{"record_id": targetID, "field_name": ""}).Error; err != nil {
+			return fmt.Errorf("合并记录附件失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// normalizeDuplicateText 规范化文本：转小写、去除标点符号、合并空白
+func normalizeDuplicateText(text string) string {
+	mapped := strings.Map(func(r rune) rune {
+		if unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			return ' '
+		}
+		return unicode.ToLower(r)
+	}, text)
+	return strings.Join(strings.Fields(mapped), " ")
+}
+
+// textBigrams 文本的字符二元组集合（忽略空白），单字符文本返回其本身
+func textBigrams(text string) map[string]bool {
+	runes := []rune(strings.ReplaceAll(text, " ", ""))
+	set := map[string]bool{}
+	if len(runes) == 1 {
+		set[string(runes)] = true
+	}
+	for i := 0; i+1 < len(runes); i++ {
+		set[string(runes[i:i+2])] = true
+	}
+	return set
+}
+
+// jaccard 两个集合的Jaccard相似度，均为空时视为不相似
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	intersection := 0
+	for item := range a {
+		if b[item] {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(a)+len(b)-intersection)
+}
+
+// contentSimilarity 按字段比较内容相似度，取所有出现字段相似度的平均值，一侧缺失的字段记为0
+func contentSimilarity(a, b map[string]map[string]bool) float64 {
+	fields := map[string]bool{}
+	for key := range a {
+		fields[key] = true
+	}
+	for key := range b {
+		fields[key] = true
+	}
+	if len(fields) == 0 {
+		return 0
+	}
+	total := 0.0
+	for key := range fields {
+		left, okLeft := a[key]
+		right, okRight := b[key]
+		if !okLeft || !okRight {
+			continue
+		}
+		if len(left) == 0 && len(right) == 0 {
+			total++
+			continue
+		}
+		total += jaccard(left, right)
+	}
+	return total / float64(len(fields))
+}
+
+// roundScore 相似度保留三位小数
+func roundScore(score float64) float64 {
+	return float64(int(score*1000+0.5)) / 1000
+}
+
+// joinUints 将ID列表格式化为 "#1, #2"
+func joinUints(ids []uint) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = fmt.Sprintf("#%d", id)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"testing"
+
+	"info-management-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupDuplicateTest(t *testing.T) (*gorm.DB, *RecordService) {
+	db, service := setupAttachmentTest(t)
+	require.NoError(t, db.Create(&models.RecordType{
+		Name:        "customer",
+		DisplayName: "客户",
+		TableName:   "records_customer",
+		IsActive:    true,
+		Schema: models.JSONB{"fields": []interface{}{
+			map[string]interface{}{"name": "code", "type": "string"},
+			map[string]interface{}{"name": "city", "type": "string"},
+			map[string]interface{}{"name": "scan", "type": "file"},
+		}},
+	}).Error)
+	return db, service
+}
+
+func customerRequest(title, code, city string) *CreateRecordRequest {
+	content := map[string]interface{}{"city": city}
+	if code != "" {
+		content["code"] = code
+	}
+	return &CreateRecordRequest{Type: "customer", Title: title, Content: content}
+}
+
+func TestRecordDuplicates_UniqueKeys(t *testing.T) {
+	db, service := setupDuplicateTest(t)
+	typeService := NewRecordTypeService(db)
+	var recordType models.RecordType
+	require.NoError(t, db.Where("name = ?", "customer").First(&recordType).Error)
+
+	// 已有重复数据时也可以启用唯一键
+	first, err := service.CreateRecord(customerRequest("甲公司", "C-001", "上海"), 1, "", "")
+	require.NoError(t, err)
+	_, err = service.CreateRecord(customerRequest("甲公司（旧）", "c-001 ", "上海"), 1, "", "")
+	require.NoError(t, err)
+
+	_, err = typeService.UpdateRecordType(recordType.ID, &UpdateRecordTypeRequest{UniqueKeys: []string{"scan"}})
+	assert.EqualError(t, err, "唯一键字段 scan 不能是文件字段")
+	_, err = typeService.UpdateRecordType(recordType.ID, &UpdateRecordTypeRequest{UniqueKeys: []string{"missing"}})
+	assert.EqualError(t, err, "唯一键字段 missing 不在Schema中")
+	updated, err := typeService.UpdateRecordType(recordType.ID, &UpdateRecordTypeRequest{UniqueKeys: []string{"code"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"code"}, updated.UniqueKeys)
+
+	var keys []string
+	db.Model(&models.Record{}).Where("type = ?", "customer").Pluck("unique_key", &keys)
+	require.Len(t, keys, 2)
+	assert.NotEmpty(t, keys[0])
+	assert.Equal(t, keys[0], keys[1], "唯一键按规范化后的值计算")
+
+	// 创建与更新时拒绝重复，缺少键字段的记录不参与校验
+	_, err = service.CreateRecord(customerRequest("甲公司", "C-001", "北京"), 2, "", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "记录与已有记录重复（ID: 1")
+	_, err = service.CreateRecord(customerRequest("无编码", "", "北京"), 1, "", "")
+	require.NoError(t, err)
+	second, err := service.CreateRecord(customerRequest("乙公司", "C-002", "北京"), 1, "", "")
+	require.NoError(t, err)
+	_, err = service.UpdateRecord(second.ID, &UpdateRecordRequest{Content: map[string]interface{}{"code": "C-001"}}, 1, false, "", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "记录与已有记录重复")
+	// 唯一键未变化时，已存在的历史重复不影响更新
+	_, err = service.UpdateRecord(first.ID, &UpdateRecordRequest{Content: map[string]interface{}{"code": "C-001", "city": "杭州"}}, 1, false, "", "")
+	require.NoError(t, err)
+}
+
+func TestRecordDuplicates_ImportPolicies(t *testing.T) {
+	db, service := setupDuplicateTest(t)
+	require.NoError(t, db.Model(&models.RecordType{}).Where("name = ?", "customer").
+		Update("unique_keys", models.StringSlice{"code"}).Error)
+
+	existing, err := service.CreateRecord(customerRequest("甲公司", "C-001", "上海"), 1, "", "")
+	require.NoError(t, err)
+
+	rows := []map[string]interface{}{
+		{"title": "甲公司（新）", "code": "C-001", "city": "杭州"},
+		{"title": "乙公司", "code": "C-002", "city": "北京"},
+	}
+
+	// 默认拒绝：重复行报错，其余行正常导入
+	results, err := service.ImportRecords(&ImportRecordsRequest{Type: "customer", Records: rows}, 1, "", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "记录 1: 记录与已有记录重复")
+	require.Len(t, results, 1)
+	assert.Equal(t, "乙公司", results[0].Title)
+
+	// 覆盖：更新已有记录，不产生新记录；批次内重复以后出现的行为准
+	rows = append(rows, map[string]interface{}{"title": "乙公司（更正）", "code": "C-002", "city": "天津"})
+	results, err = service.ImportRecords(&ImportRecordsRequest{Type: "customer", Records: rows, OnConflict: DuplicatePolicyUpsert}, 1, "", "")
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+
+	var count int64
+	db.Model(&models.Record{}).Where("type = ?", "customer").Count(&count)
+	assert.Equal(t, int64(2), count)
+	var record models.Record
+	require.NoError(t, db.First(&record, existing.ID).Error)
+	assert.Equal(t, "甲公司（新）", record.Title)
+	assert.Equal(t, "杭州", record.Content["city"])
+	assert.Equal(t, 2, record.Version)
+	var corrected models.Record
+	require.NoError(t, db.Where("title = ?", "乙公司（更正）").First(&corrected).Error)
+	assert.Equal(t, "天津", corrected.Content["city"])
+
+	var audits int64
+	db.Model(&models.AuditLog{}).Where("action = ?", "UPSERT").Count(&audits)
+	assert.Equal(t, int64(2), audits)
+
+	// 不能覆盖他人的记录
+	_, err = service.ImportRecords(&ImportRecordsRequest{Type: "customer", Records: rows[:1], OnConflict: DuplicatePolicyUpsert}, 2, "", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "无权覆盖他人的记录")
+}
+
+func TestRecordDuplicates_FindDuplicates(t *testing.T) {
+	db, service := setupDuplicateTest(t)
+
+	a, err := service.CreateRecord(customerRequest("上海 甲贸易有限公司", "C-001", "上海"), 1, "", "")
+	require.NoError(t, err)
+	b, err := service.CreateRecord(customerRequest("上海甲贸易有限公司！", "C-001", "上海"), 1, "", "")
+	require.NoError(t, err)
+	_, err = service.CreateRecord(customerRequest("北京乙科技公司", "C-002", "北京"), 1, "", "")
+	require.NoError(t, err)
+	c, err := service.CreateRecord(customerRequest("上海甲贸易有限公司", "C-009", "上海"), 2, "", "")
+	require.NoError(t, err)
+
+	candidates, err := service.FindDuplicates(&DuplicateQuery{Type: "customer"}, 1, true)
+	require.NoError(t, err)
+	require.Len(t, candidates, 3)
+	assert.Equal(t, a.ID, candidates[0].RecordID)
+	assert.Equal(t, b.ID, candidates[0].DuplicateID)
+	assert.Equal(t, 1.0, candidates[0].Score, "规范化后标题与内容完全相同")
+	for _, candidate := range candidates {
+		assert.NotEqual(t, "北京乙科技公司", candidate.Title)
+		assert.NotEqual(t, "北京乙科技公司", candidate.DuplicateTitle)
+	}
+
+	// 唯一键相同视为完全重复
+	require.NoError(t, db.Model(&models.Record{}).Where("id IN ?", []uint{a.ID, c.ID}).Update("unique_key", "same").Error)
+	candidates, err = service.FindDuplicates(&DuplicateQuery{Type: "customer", Threshold: 0.99}, 1, true)
+	require.NoError(t, err)
+	require.Len(t, candidates, 2)
+	assert.True(t, candidates[0].SameUniqueKey || candidates[1].SameUniqueKey)
+
+	// 无全部权限时只比对本人的记录
+	candidates, err = service.FindDuplicates(&DuplicateQuery{Type: "customer"}, 2, false)
+	require.NoError(t, err)
+	assert.Empty(t, candidates)
+
+	_, err = service.FindDuplicates(&DuplicateQuery{Type: "unknown"}, 1, true)
+	assert.EqualError(t, err, "记录类型不存在")
+}
+
+func TestRecordDuplicates_Merge(t *testing.T) {
+	db, service := setupDuplicateTest(t)
+
+	target, err := service.CreateRecord(&CreateRecordRequest{Type: "customer", Title: "甲公司", Tags: []string{"vip"},
+		Content: map[string]interface{}{"code": "C-001"}}, 1, "", "")
+	require.NoError(t, err)
+	source, err := service.CreateRecord(&CreateRecordRequest{Type: "customer", Title: "甲公司（重复）", Tags: []string{"华东"},
+		Content: map[string]interface{}{"code": "C-001"}}, 1, "", "")
+	require.NoError(t, err)
+	other, err := service.CreateRecord(customerRequest("乙公司", "C-002", "北京"), 1, "", "")
+	require.NoError(t, err)
+	contract, err := service.CreateRecord(&CreateRecordRequest{Type: "contract", Title: "合同", Content: map[string]interface{}{"note": "x"}}, 1, "", "")
+	require.NoError(t, err)
+
+	// 关联：源记录指向他处的关联改指向目标，重复与自指向的关联被移除
+	links := []models.EntityLink{
+		{SourceType: "record", SourceID: source.ID, TargetType: "record", TargetID: other.ID, LinkType: models.LinkTypeRelatesTo, CreatedBy: 1},
+		{SourceType: "record", SourceID: target.ID, TargetType: "record", TargetID: other.ID, LinkType: models.LinkTypeRelatesTo, CreatedBy: 1},
+		{SourceType: "record", SourceID: source.ID, TargetType: "record", TargetID: target.ID, LinkType: models.LinkTypeDuplicateOf, CreatedBy: 1},
+		{SourceType: "ticket", SourceID: 7, TargetType: "record", TargetID: source.ID, LinkType: models.LinkTypeCausedBy, CreatedBy: 1},
+	}
+	require.NoError(t, db.Create(&links).Error)
+
+	// 附件：源记录的附件转为目标记录的普通附件，已有的文件不重复
+	shared := createAttachmentTestFile(t, db, 1, "shared.txt")
+	extra := createAttachmentTestFile(t, db, 1, "extra.txt")
+	require.NoError(t, db.Create(&[]models.RecordFile{
+		{RecordID: target.ID, FileID: shared.ID, CreatedBy: 1},
+		{RecordID: source.ID, FileID: shared.ID, CreatedBy: 1},
+		{RecordID: source.ID, FileID: extra.ID, FieldName: "scan", CreatedBy: 1},
+	}).Error)
+
+	require.NoError(t, db.Create(&models.RecordStatusHistory{RecordID: source.ID, FromStatus: "draft", ToStatus: "published", Action: "publish", UserID: 1}).Error)
+	require.NoError(t, AddWatchers(db, models.WatchEntityRecord, source.ID, models.WatchReasonManual, 2))
+
+	_, err = service.MergeRecords(&MergeRecordsRequest{TargetID: target.ID, SourceIDs: []uint{contract.ID}}, 1, false, "", "")
+	assert.EqualError(t, err, "只能合并同一类型的记录")
+	_, err = service.MergeRecords(&MergeRecordsRequest{TargetID: target.ID, SourceIDs: []uint{target.ID}}, 1, false, "", "")
+	assert.EqualError(t, err, "不能将记录合并到自身")
+	_, err = service.MergeRecords(&MergeRecordsRequest{TargetID: target.ID, SourceIDs: []uint{source.ID}}, 2, false, "", "")
+	assert.EqualError(t, err, "目标记录不存在或无权修改")
+
+	merged, err := service.MergeRecords(&MergeRecordsRequest{TargetID: target.ID, SourceIDs: []uint{source.ID}, Comment: "客户去重"}, 1, false, "", "")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"vip", "华东"}, merged.Tags)
+	assert.Equal(t, 2, merged.Version)
+
+	var remaining []models.EntityLink
+	require.NoError(t, db.Order("id ASC").Find(&remaining).Error)
+	require.Len(t, remaining, 2)
+	assert.Equal(t, target.ID, remaining[0].SourceID)
+	assert.Equal(t, other.ID, remaining[0].TargetID)
+	assert.Equal(t, "ticket", remaining[1].SourceType)
+	assert.Equal(t, target.ID, remaining[1].TargetID)
+
+	var files []models.RecordFile
+	require.NoError(t, db.Where("record_id = ?", target.ID).Order("file_id ASC").Find(&files).Error)
+	require.Len(t, files, 2)
+	assert.Equal(t, extra.ID, files[1].FileID)
+	assert.Equal(t, "", files[1].FieldName)
+	var sourceFiles int64
+	db.Model(&models.RecordFile{}).Where("record_id = ?", source.ID).Count(&sourceFiles)
+	assert.Zero(t, sourceFiles)
+
+	var history []models.RecordStatusHistory
+	require.NoError(t, db.Where("record_id = ?", target.ID).Order("id ASC").Find(&history).Error)
+	require.Len(t, history, 2)
+	assert.Equal(t, "publish", history[0].Action)
+	assert.Equal(t, "merge", history[1].Action)
+	assert.Contains(t, history[1].Comment, "客户去重")
+
+	var watcher models.Watcher
+	assert.NoError(t, db.Where("entity_type = ? AND entity_id = ? AND user_id = ?", models.WatchEntityRecord, target.ID, 2).First(&watcher).Error)
+
+	// 被合并的记录移入回收站
+	var deleted models.Record
+	assert.Error(t, db.First(&deleted, source.ID).Error)
+	require.NoError(t, db.Unscoped().First(&deleted, source.ID).Error)
+	assert.True(t, deleted.DeletedAt.Valid)
+
+	var audits int64
+	db.Model(&models.AuditLog{}).Where("action = ? AND resource_id = ?", "MERGE", target.ID).Count(&audits)
+	assert.Equal(t, int64(1), audits)
+}
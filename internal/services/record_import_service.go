@@ -0,0 +1,773 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// 列映射中的内置目标
+const (
+	ImportTargetTitle = "title"
+	ImportTargetTags  = "tags"
+)
+
+// importProgressInterval 导入任务每处理多少行更新一次进度
+const importProgressInterval = 100
+
+// importPreviewRows 预览返回的样例行数
+const importPreviewRows = 10
+
+// RecordImportService 表格导入记录服务
+type RecordImportService struct {
+	db                *gorm.DB
+	recordService     *RecordService
+	recordTypeService *RecordTypeService
+	auditService      *AuditService
+	runAsync          func(func())
+}
+
+// NewRecordImportService 创建表格导入记录服务
+func NewRecordImportService(db *gorm.DB, recordService *RecordService, recordTypeService *RecordTypeService, auditService *AuditService) *RecordImportService {
+	return &RecordImportService{
+		db:                db,
+		recordService:     recordService,
+		recordTypeService: recordTypeService,
+		auditService:      auditService,
+		runAsync:          func(f func()) { go f() },
+	}
+}
+
+// ImportPreviewRequest 导入预览请求
+type ImportPreviewRequest struct {
+	File *multipart.FileHeader `form:"file" binding:"required"`
+	Type string                `form:"type" binding:"required"`
+}
+
+// ImportPreviewResponse 导入预览响应
+type ImportPreviewResponse struct {
+	Headers          []string          `json:"headers"`
+	SuggestedMapping map[string]string `json:"suggested_mapping"`
+	Fields           []SchemaField     `json:"fields"`
+	SampleRows       [][]string        `json:"sample_rows"`
+}
+
+// CreateImportJobRequest 创建导入任务请求
+type CreateImportJobRequest struct {
+	File      *multipart.FileHeader `form:"file" binding:"required"`
+	Type      string                `form:"type" binding:"required"`
+	Mapping   string                `form:"mapping"`    // JSON对象：表头 -> 目标字段，为空时使用已保存映射或自动建议
+	MappingID uint                  `form:"mapping_id"` // 已保存的列映射
+	DryRun    bool                  `form:"dry_run"`
+	// 唯一键冲突策略：reject（默认）拒绝重复记录，upsert 覆盖本人创建的已有记录
+	OnConflict string `form:"on_conflict" binding:"omitempty,oneof=reject upsert"`
+}
+
+// SaveImportMappingRequest 保存列映射请求
+type SaveImportMappingRequest struct {
+	Name    string            `json:"name" binding:"required,max=200"`
+	Type    string            `json:"type" binding:"required"`
+	Mapping map[string]string `json:"mapping" binding:"required"`
+}
+
+// importRowError 导入行错误
+type importRowError struct {
+	Row    int
+	Error  string
+	Values []string
+}
+
+// Preview 解析表头并给出列映射建议
+func (s *RecordImportService) Preview(req *ImportPreviewRequest) (*ImportPreviewResponse, error) {
+	fields, err := s.importFields(req.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := req.File.Open()
+	if err != nil {
+		return nil, fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer src.Close()
+
+	rows, err := openSpreadsheet(src, req.File.Filename)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	headers, err := rows.Next()
+	if err != nil {
+		return nil, fmt.Errorf("文件缺少表头")
+	}
+
+	preview := &ImportPreviewResponse{
+		Headers:          headers,
+		SuggestedMapping: SuggestImportMapping(headers, fields),
+		Fields:           fields,
+		SampleRows:       [][]string{},
+	}
+	for len(preview.SampleRows) < importPreviewRows {
+		row, err := rows.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取文件失败: %w", err)
+		}
+		preview.SampleRows = append(preview.SampleRows, row)
+	}
+	return preview, nil
+}
+
+// SuggestImportMapping 根据表头名称匹配标题、标签和Schema字段（名称或显示名）
+func SuggestImportMapping(headers []string, fields []SchemaField) map[string]string {
+	mapping := make(map[string]string, len(headers))
+	used := map[string]bool{}
+	for _, header := range headers {
+		key := normalizeImportHeader(header)
+		target := ""
+		switch key {
+		case "title", "标题":
+			target = ImportTargetTitle
+		case "tags", "tag", "标签":
+			target = ImportTargetTags
+		default:
+			for _, field := range fields {
+				if key == strings.ToLower(field.Name) || (field.Label != "" && key == strings.ToLower(field.Label)) {
+					target = field.Name
+					break
+				}
+			}
+		}
+		if target != "" && used[target] {
+			target = ""
+		}
+		if target != "" {
+			used[target] = true
+		}
+		mapping[header] = target
+	}
+	return mapping
+}
+
+// normalizeImportHeader 去除表头中的必填标记与空白
+func normalizeImportHeader(header string) string {
+	header = strings.TrimPrefix(header, "\ufeff")
+	header = strings.Trim(strings.TrimSpace(header), "*＊")
+	return strings.ToLower(strings.TrimSpace(header))
+}
+
+// CreateJob 保存上传文件并创建导入任务
+func (s *RecordImportService) CreateJob(req *CreateImportJobRequest, userID uint, ipAddress, userAgent string) (*models.RecordImportJob, error) {
+	if !isSupportedSpreadsheet(req.File.Filename) {
+		return nil, fmt.Errorf("只支持CSV、XLSX格式文件")
+	}
+	fields, err := s.importFields(req.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	var mapping map[string]string
+	switch {
+	case req.Mapping != "":
+		if err := json.Unmarshal([]byte(req.Mapping), &mapping); err != nil {
+			return nil, fmt.Errorf("列映射格式错误: %v", err)
+		}
+	case req.MappingID > 0:
+		saved, err := s.getMapping(req.MappingID, userID)
+		if err != nil {
+			return nil, err
+		}
+		mapping = mappingFromJSONB(saved.Mapping)
+	}
+
+	path, err := s.saveUpload(req.File)
+	if err != nil {
+		return nil, err
+	}
+
+	// 未指定映射时按表头自动建议
+	if mapping == nil {
+		headers, err := readSpreadsheetHeaders(path, req.File.Filename)
+		if err != nil {
+			os.Remove(path)
+			return nil, err
+		}
+		mapping = SuggestImportMapping(headers, fields)
+	}
+	if err := validateImportMapping(mapping, fields); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+
+	job := models.RecordImportJob{
+		RecordType: req.Type,
+		FileName:   filepath.Base(req.File.Filename),
+		FilePath:   path,
+		Mapping:    mappingToJSONB(mapping),
+		DryRun:     req.DryRun,
+		OnConflict: importConflictPolicy(req.OnConflict),
+		Status:     "pending",
+		CreatedBy:  userID,
+	}
+	if err := s.db.Create(&job).Error; err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("创建导入任务失败: %w", err)
+	}
+
+	s.auditJob(&job, "CREATE_IMPORT", ipAddress, userAgent)
+	s.runAsync(func() { s.runJob(job.ID) })
+	return &job, nil
+}
+
+// CommitJob 确认提交已完成的试运行任务，使用相同文件和映射正式导入
+func (s *RecordImportService) CommitJob(jobID uint, userID uint, hasAllPermission bool, ipAddress, userAgent string) (*models.RecordImportJob, error) {
+	source, err := s.GetJob(jobID, userID, hasAllPermission)
+	if err != nil {
+		return nil, err
+	}
+	if !source.DryRun || source.Status != "completed" {
+		return nil, fmt.Errorf("只有已完成的试运行任务可以提交")
+	}
+
+	job := models.RecordImportJob{
+		RecordType:  source.RecordType,
+		FileName:    source.FileName,
+		FilePath:    source.FilePath,
+		Mapping:     source.Mapping,
+		OnConflict:  source.OnConflict,
+		SourceJobID: &source.ID,
+		Status:      "pending",
+		CreatedBy:   userID,
+	}
+	if err := s.db.Create(&job).Error; err != nil {
+		return nil, fmt.Errorf("创建导入任务失败: %w", err)
+	}
+
+	s.auditJob(&job, "CREATE_IMPORT", ipAddress, userAgent)
+	s.runAsync(func() { s.runJob(job.ID) })
+	return &job, nil
+}
+
+// GetJob 获取导入任务
+func (s *RecordImportService) GetJob(jobID uint, userID uint, hasAllPermission bool) (*models.RecordImportJob, error) {
+	var job models.RecordImportJob
+	query := s.db
+	if !hasAllPermission {
+		query = query.Where("created_by = ?", userID)
+	}
+	if err := query.First(&job, jobID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("导入任务不存在")
+		}
+		return nil, fmt.Errorf("获取导入任务失败: %w", err)
+	}
+	return &job, nil
+}
+
+// ListJobs 获取当前用户的导入任务
+func (s *RecordImportService) ListJobs(userID uint, hasAllPermission bool) ([]models.RecordImportJob, error) {
+	var jobs []models.RecordImportJob
+	query := s.db.Order("id DESC").Limit(100)
+	if !hasAllPermission {
+		query = query.Where("created_by = ?", userID)
+	}
+	if err := query.Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("获取导入任务失败: %w", err)
+	}
+	return jobs, nil
+}
+
+// GetErrorReportPath 获取导入任务的错误报告文件
+func (s *RecordImportService) GetErrorReportPath(jobID uint, userID uint, hasAllPermission bool) (string, error) {
+	job, err := s.GetJob(jobID, userID, hasAllPermission)
+	if err != nil {
+		return "", err
+	}
+	if job.ErrorReportPath == "" {
+		return "", fmt.Errorf("该任务没有错误报告")
+	}
+	if _, err := os.Stat(job.ErrorReportPath); err != nil {
+		return "", fmt.Errorf("该任务没有错误报告")
+	}
+	return job.ErrorReportPath, nil
+}
+
+// SaveMapping 保存列映射
+func (s *RecordImportService) SaveMapping(req *SaveImportMappingRequest, userID uint) (*models.RecordImportMapping, error) {
+	fields, err := s.importFields(req.Type)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateImportMapping(req.Mapping, fields); err != nil {
+		return nil, err
+	}
+
+	mapping := models.RecordImportMapping{
+		Name:       req.Name,
+		RecordType: req.Type,
+		Mapping:    mappingToJSONB(req.Mapping),
+		CreatedBy:  userID,
+	}
+	if err := s.db.Create(&mapping).Error; err != nil {
+		return nil, fmt.Errorf("保存列映射失败: %w", err)
+	}
+	return &mapping, nil
+}
+
+// ListMappings 获取当前用户保存的列映射
+func (s *RecordImportService) ListMappings(recordType string, userID uint) ([]models.RecordImportMapping, error) {
+	var mappings []models.RecordImportMapping
+	query := s.db.Where("created_by = ?", userID).Order("updated_at DESC")
+	if recordType != "" {
+		query = query.Where("record_type = ?", recordType)
+	}
+	if err := query.Find(&mappings).Error; err != nil {
+		return nil, fmt.Errorf("获取列映射失败: %w", err)
+	}
+	return mappings, nil
+}
+
+// DeleteMapping 删除列映射
+func (s *RecordImportService) DeleteMapping(id uint, userID uint) error {
+	result := s.db.Where("id = ? AND created_by = ?", id, userID).Delete(&models.RecordImportMapping{})
+	if result.Error != nil {
+		return fmt.Errorf("删除列映射失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("列映射不存在")
+	}
+	return nil
+}
+
+// getMapping 获取当前用户的列映射
+func (s *RecordImportService) getMapping(id uint, userID uint) (*models.RecordImportMapping, error) {
+	var mapping models.RecordImportMapping
+	if err := s.db.Where("id = ? AND created_by = ?", id, userID).First(&mapping).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("列映射不存在")
+		}
+		return nil, fmt.Errorf("获取列映射失败: %w", err)
+	}
+	return &mapping, nil
+}
+
+// importFields 获取可导入的记录类型字段
+func (s *RecordImportService) importFields(recordType string) ([]SchemaField, error) {
+	rt, err := s.recordTypeService.GetRecordTypeByName(recordType)
+	if err != nil {
+		return nil, err
+	}
+	if !rt.IsActive {
+		return nil, fmt.Errorf("记录类型已禁用")
+	}
+	return ParseSchemaFields(rt.Schema), nil
+}
+
+// validateImportMapping 校验映射目标，标题列必须存在
+func validateImportMapping(mapping map[string]string, fields []SchemaField) error {
+	valid := map[string]bool{ImportTargetTitle: true, ImportTargetTags: true}
+	for _, field := range fields {
+		valid[field.Name] = true
+	}
+
+	hasTitle := false
+	seen := map[string]bool{}
+	for header, target := range mapping {
+		if target == "" {
+			continue
+		}
+		if !valid[target] {
+			return fmt.Errorf("列 %s 映射的字段 %s 不存在", header, target)
+		}
+		if seen[target] {
+			return fmt.Errorf("字段 %s 被多个列映射", target)
+		}
+		seen[target] = true
+		if target == ImportTargetTitle {
+			hasTitle = true
+		}
+	}
+	if !hasTitle {
+		return fmt.Errorf("列映射中必须包含标题列")
+	}
+	return nil
+}
+
+// importDir 导入文件与错误报告存放目录
+func (s *RecordImportService) importDir() (string, error) {
+	dir := filepath.Join(getConfigValue(s.db, "storage", "upload_path", "./uploads"), "imports")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("创建导入目录失败: %w", err)
+	}
+	return dir, nil
+}
+
+// saveUpload 保存上传的导入文件
+func (s *RecordImportService) saveUpload(header *multipart.FileHeader) (string, error) {
+	dir, err := s.importDir()
+	if err != nil {
+		return "", err
+	}
+
+	src, err := header.Open()
+	if err != nil {
+		return "", fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer src.Close()
+
+	path := filepath.Join(dir, fmt.Sprintf("%d%s", time.Now().UnixNano(), strings.ToLower(filepath.Ext(header.Filename))))
+	dst, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("保存文件失败: %w", err)
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("保存文件失败: %w", err)
+	}
+	return path, nil
+}
+
+// runJob 逐行执行导入任务
+func (s *RecordImportService) runJob(jobID uint) {
+	var job models.RecordImportJob
+	if err := s.db.First(&job, jobID).Error; err != nil {
+		return
+	}
+
+	now := time.Now()
+	s.db.Model(&job).Updates(map[string]interface{}{"status": "processing", "started_at": &now})
+
+	fields, err := s.importFields(job.RecordType)
+	if err != nil {
+		s.failJob(&job, err.Error())
+		return
+	}
+	fieldTypes := make(map[string]string, len(fields))
+	for _, field := range fields {
+		fieldTypes[field.Name] = field.Type
+	}
+
+	total, err := countSpreadsheetRows(job.FilePath)
+	if err != nil {
+		s.failJob(&job, err.Error())
+		return
+	}
+	s.db.Model(&job).Update("total_rows", total)
+
+	file, err := os.Open(job.FilePath)
+	if err != nil {
+		s.failJob(&job, fmt.Sprintf("打开导入文件失败: %v", err))
+		return
+	}
+	defer file.Close()
+	rows, err := openSpreadsheet(file, job.FilePath)
+	if err != nil {
+		s.failJob(&job, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	headers, err := rows.Next()
+	if err != nil {
+		s.failJob(&job, "文件缺少表头")
+		return
+	}
+	mapping := mappingFromJSONB(job.Mapping)
+	targets := make([]string, len(headers))
+	for i, header := range headers {
+		targets[i] = mapping[header]
+	}
+
+	report := &importErrorReport{headers: headers}
+	defer report.close()
+
+	processed, succeeded, failed := 0, 0, 0
+	for rowNumber := 2; ; rowNumber++ {
+		values, err := rows.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			s.failJob(&job, fmt.Sprintf("读取第%d行失败: %v", rowNumber, err))
+			return
+		}
+		if isBlankRow(values) {
+			continue
+		}
+
+		processed++
+		if err := s.importRow(&job, values, targets, fields, fieldTypes); err != nil {
+			failed++
+			if reportErr := report.add(s, &job, importRowError{Row: rowNumber, Error: err.Error(), Values: values}); reportErr != nil {
+				s.failJob(&job, reportErr.Error())
+				return
+			}
+		} else {
+			succeeded++
+		}
+
+		if processed%importProgressInterval == 0 {
+			progress := 99
+			if total > 0 && processed*100/total < 99 {
+				progress = processed * 100 / total
+			}
+			s.db.Model(&job).Updates(map[string]interface{}{
+				"processed_rows": processed,
+				"succeeded_rows": succeeded,
+				"failed_rows":    failed,
+				"progress":       progress,
+			})
+		}
+	}
+
+	completedAt := time.Now()
+	s.db.Model(&job).Updates(map[string]interface{}{
+		"status":            "completed",
+		"progress":          100,
+		"processed_rows":    processed,
+		"succeeded_rows":    succeeded,
+		"failed_rows":       failed,
+		"error_report_path": report.path,
+		"completed_at":      &completedAt,
+	})
+
+	if !job.DryRun {
+		job.SucceededRows, job.FailedRows = succeeded, failed
+		s.auditJob(&job, "IMPORT_RECORDS", "", "")
+	}
+}
+
+// importRow 转换并导入单行，试运行时只校验
+func (s *RecordImportService) importRow(job *models.RecordImportJob, values []string, targets []string, fields []SchemaField, fieldTypes map[string]string) error {
+	req := &CreateRecordRequest{Type: job.RecordType, Content: map[string]interface{}{}}
+	var errs []string
+	for i, target := range targets {
+		if target == "" || i >= len(values) {
+			continue
+		}
+		raw := strings.TrimSpace(values[i])
+		switch target {
+		case ImportTargetTitle:
+			req.Title = raw
+		case ImportTargetTags:
+			for _, tag := range splitImportList(raw) {
+				req.Tags = append(req.Tags, tag.(string))
+			}
+		default:
+			value, err := coerceCellValue(raw, fieldTypes[target])
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("字段 %s: %v", target, err))
+				continue
+			}
+			if value != nil {
+				req.Content[target] = value
+			}
+		}
+	}
+
+	if req.Title == "" {
+		errs = append(errs, "标题不能为空")
+	} else if len([]rune(req.Title)) > 500 {
+		errs = append(errs, "标题长度不能超过500")
+	}
+	errs = append(errs, ValidateContentAgainstSchema(fields, req.Content)...)
+	if len(req.Content) == 0 {
+		errs = append(errs, "记录内容不能为空")
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+
+	if job.DryRun {
+		// 试运行同样校验文件字段引用和唯一键冲突
+		if _, err := s.recordService.validateFieldFiles(req.Type, req.Content, 0, job.CreatedBy); err != nil {
+			return err
+		}
+		uniqueKey, err := recordUniqueKey(s.db, req.Type, req.Content)
+		if err != nil {
+			return err
+		}
+		existing, err := findDuplicateRecord(s.db, req.Type, uniqueKey, 0)
+		if err != nil || existing == nil {
+			return err
+		}
+		if job.OnConflict != DuplicatePolicyUpsert {
+			return duplicateRecordError(existing)
+		}
+		return checkUpsertAllowed(existing, job.CreatedBy)
+	}
+	_, err := s.recordService.createRecord(req, importConflictPolicy(job.OnConflict), job.CreatedBy, "", "")
+	return err
+}
+
+// importConflictPolicy 解析导入的唯一键冲突策略，默认拒绝
+func importConflictPolicy(policy string) string {
+	if policy == DuplicatePolicyUpsert {
+		return DuplicatePolicyUpsert
+	}
+	return DuplicatePolicyReject
+}
+
+// failJob 标记任务失败
+func (s *RecordImportService) failJob(job *models.RecordImportJob, message string) {
+	now := time.Now()
+	s.db.Model(job).Updates(map[string]interface{}{
+		"status":        "failed",
+		"error_message": message,
+		"completed_at":  &now,
+	})
+}
+
+// auditJob 记录导入任务审计日志
+func (s *RecordImportService) auditJob(job *models.RecordImportJob, action, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	s.auditService.CreateAuditLog(&AuditLogRequest{
+		UserID:       job.CreatedBy,
+		Action:       action,
+		ResourceType: "record_import",
+		ResourceID:   job.ID,
+		NewValues: map[string]interface{}{
+			"record_type": job.RecordType,
+			"file_name":   job.FileName,
+			"dry_run":     job.DryRun,
+			"on_conflict": job.OnConflict,
+			"succeeded":   job.SucceededRows,
+			"failed":      job.FailedRows,
+		},
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+	})
+}
+
+// importErrorReport 按需创建的CSV错误报告
+type importErrorReport struct {
+	headers []string
+	path    string
+	file    *os.File
+	writer  *csv.Writer
+}
+
+// add 写入一行错误，首次写入时创建报告文件
+func (r *importErrorReport) add(s *RecordImportService, job *models.RecordImportJob, rowErr importRowError) error {
+	if r.writer == nil {
+		dir, err := s.importDir()
+		if err != nil {
+			return err
+		}
+		r.path = filepath.Join(dir, fmt.Sprintf("import_%d_errors.csv", job.ID))
+		r.file, err = os.Create(r.path)
+		if err != nil {
+			return fmt.Errorf("创建错误报告失败: %w", err)
+		}
+		r.file.WriteString("\xef\xbb\xbf")
+		r.writer = csv.NewWriter(r.file)
+		r.writer.Write(append([]string{"行号", "错误"}, r.headers...))
+	}
+	return r.writer.Write(append([]string{strconv.Itoa(rowErr.Row), rowErr.Error}, rowErr.Values...))
+}
+
+func (r *importErrorReport) close() {
+	if r.writer != nil {
+		r.writer.Flush()
+		r.file.Close()
+	}
+}
+
+// readSpreadsheetHeaders 读取表头
+func readSpreadsheetHeaders(path, filename string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开导入文件失败: %w", err)
+	}
+	defer file.Close()
+
+	rows, err := openSpreadsheet(file, filename)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	headers, err := rows.Next()
+	if err != nil {
+		return nil, fmt.Errorf("文件缺少表头")
+	}
+	return headers, nil
+}
+
+// countSpreadsheetRows 统计数据行数（不含表头与空行）
+func countSpreadsheetRows(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("打开导入文件失败: %w", err)
+	}
+	defer file.Close()
+
+	rows, err := openSpreadsheet(file, path)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	count := -1
+	for {
+		values, err := rows.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("读取导入文件失败: %w", err)
+		}
+		if count < 0 || !isBlankRow(values) {
+			count++
+		}
+	}
+	if count < 0 {
+		return 0, nil
+	}
+	return count, nil
+}
+
+// isBlankRow 是否为空行
+func isBlankRow(values []string) bool {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func mappingToJSONB(mapping map[string]string) models.JSONB {
+	result := make(models.JSONB, len(mapping))
+	for k, v := range mapping {
+		result[k] = v
+	}
+	return result
+}
+
+func mappingFromJSONB(data models.JSONB) map[string]string {
+	result := make(map[string]string, len(data))
+	for k, v := range data {
+		if s, ok := v.(string); ok {
+			result[k] = s
+		}
+	}
+	return result
+}
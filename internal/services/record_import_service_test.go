@@ -0,0 +1,231 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"mime/multipart"
+	"os"
+	"testing"
+
+	"info-management-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// setupImportTest 创建表格导入测试环境，导入任务同步执行
+func setupImportTest(t *testing.T) (*gorm.DB, *RecordImportService) {
+	db := newServiceTestDB(t)
+
+	require.NoError(t, db.Create(&models.SystemConfig{Category: "storage", Key: "upload_path", Value: t.TempDir()}).Error)
+	require.NoError(t, db.Create(&models.RecordType{
+		Name:        "asset",
+		DisplayName: "资产",
+		TableName:   "records_asset",
+		IsActive:    true,
+		Schema: models.JSONB{"fields": []interface{}{
+			map[string]interface{}{"name": "price", "label": "价格", "type": "number", "required": true},
+			map[string]interface{}{"name": "bought_on", "label": "购买日期", "type": "date"},
+			map[string]interface{}{"name": "in_use", "type": "boolean"},
+		}},
+	}).Error)
+
+	auditService := NewAuditService(db)
+	typeService := NewRecordTypeService(db)
+	recordService := NewRecordService(db, typeService, auditService, NewFileService(db, auditService), nil)
+	importService := NewRecordImportService(db, recordService, typeService, auditService)
+	importService.runAsync = func(f func()) { f() }
+	return db, importService
+}
+
+// newImportUpload 构造内存中的CSV上传文件
+func newImportUpload(t *testing.T, filename string, rows [][]string) *multipart.FileHeader {
+	var data bytes.Buffer
+	require.NoError(t, csv.NewWriter(&data).WriteAll(rows))
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, err = part.Write(data.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	require.NoError(t, err)
+	return form.File["file"][0]
+}
+
+func TestCoerceCellValue(t *testing.T) {
+	tests := []struct {
+		raw       string
+		fieldType string
+		want      interface{}
+		wantErr   bool
+	}{
+		{"1,234.5", "number", 1234.5, false},
+		{"abc", "number", nil, true},
+		{"3", "integer", float64(3), false},
+		{"3.5", "integer", nil, true},
+		{"是", "boolean", true, false},
+		{"No", "boolean", false, false},
+		{"maybe", "boolean", nil, true},
+		{"2024/3/5", "date", "2024-03-05", false},
+		{"45356", "date", "2024-03-05", false},
+		{"2024-03-05 08:30", "datetime", "2024-03-05 08:30:00", false},
+		{"a, b；c", "array", []interface{}{"a", "b", "c"}, false},
+		{"", "number", nil, false},
+		{" text ", "string", "text", false},
+	}
+
+	for _, tt := range tests {
+		got, err := coerceCellValue(tt.raw, tt.fieldType)
+		if tt.wantErr {
+			assert.Error(t, err, tt.raw)
+			continue
+		}
+		require.NoError(t, err, tt.raw)
+		assert.Equal(t, tt.want, got, tt.raw)
+	}
+}
+
+func TestSuggestImportMapping(t *testing.T) {
+	fields := []SchemaField{{Name: "price", Label: "价格", Type: "number"}, {Name: "bought_on", Type: "date"}}
+	mapping := SuggestImportMapping([]string{"标题*", "Tags", "价格", "BOUGHT_ON", "备注", "title"}, fields)
+
+	assert.Equal(t, map[string]string{
+		"标题*":       ImportTargetTitle,
+		"Tags":      ImportTargetTags,
+		"价格":        "price",
+		"BOUGHT_ON": "bought_on",
+		"备注":        "",
+		"title":     "",
+	}, mapping)
+}
+
+func TestRecordImportService_DryRunAndCommit(t *testing.T) {
+	db, importService := setupImportTest(t)
+
+	rows := [][]string{
+		{"标题*", "价格", "购买日期", "in_use", "标签"},
+		{"笔记本", "5,999", "2024/1/2", "是", "电子,办公"},
+		{"", "10", "", "", ""},
+		{"显示器", "abc", "2024-01-03", "否", ""},
+		{"", "", "", "", ""},
+		{"椅子", "", "2024-01-04", "", ""},
+	}
+
+	job, err := importService.CreateJob(&CreateImportJobRequest{
+		File:   newImportUpload(t, "assets.csv", rows),
+		Type:   "asset",
+		DryRun: true,
+	}, 1, "", "")
+	require.NoError(t, err)
+
+	job, err = importService.GetJob(job.ID, 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, "completed", job.Status)
+	assert.Equal(t, 4, job.TotalRows)
+	assert.Equal(t, 4, job.ProcessedRows)
+	assert.Equal(t, 1, job.SucceededRows)
+	assert.Equal(t, 3, job.FailedRows)
+
+	var count int64
+	db.Model(&models.Record{}).Count(&count)
+	assert.Equal(t, int64(0), count, "试运行不应写入记录")
+
+	// 错误报告包含行号、错误与原始列
+	reportPath, err := importService.GetErrorReportPath(job.ID, 1, false)
+	require.NoError(t, err)
+	file, err := os.Open(reportPath)
+	require.NoError(t, err)
+	defer file.Close()
+	report, err := openSpreadsheet(file, reportPath)
+	require.NoError(t, err)
+	header, err := report.Next()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"行号", "错误", "标题*", "价格", "购买日期", "in_use", "标签"}, header)
+	first, err := report.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "3", first[0])
+	assert.Contains(t, first[1], "标题不能为空")
+
+	// 其他用户无法查看或提交
+	_, err = importService.CommitJob(job.ID, 2, false, "", "")
+	assert.EqualError(t, err, "导入任务不存在")
+
+	committed, err := importService.CommitJob(job.ID, 1, false, "", "")
+	require.NoError(t, err)
+	require.NotNil(t, committed.SourceJobID)
+	assert.Equal(t, job.ID, *committed.SourceJobID)
+
+	committed, err = importService.GetJob(committed.ID, 1, false)
+	require.NoError(t, err)
+	assert.False(t, committed.DryRun)
+	assert.Equal(t, 1, committed.SucceededRows)
+	assert.Equal(t, 3, committed.FailedRows)
+
+	var record models.Record
+	require.NoError(t, db.Where("title = ?", "笔记本").First(&record).Error)
+	assert.Equal(t, float64(5999), record.Content["price"])
+	assert.Equal(t, "2024-01-02", record.Content["bought_on"])
+	assert.Equal(t, true, record.Content["in_use"])
+	assert.Equal(t, models.StringSlice{"电子", "办公"}, record.Tags)
+
+	// 正式任务不可再次提交
+	_, err = importService.CommitJob(committed.ID, 1, false, "", "")
+	assert.EqualError(t, err, "只有已完成的试运行任务可以提交")
+}
+
+func TestRecordImportService_Mappings(t *testing.T) {
+	db, importService := setupImportTest(t)
+
+	_, err := importService.SaveMapping(&SaveImportMappingRequest{Name: "无标题", Type: "asset", Mapping: map[string]string{"金额": "price"}}, 1)
+	assert.EqualError(t, err, "列映射中必须包含标题列")
+
+	_, err = importService.SaveMapping(&SaveImportMappingRequest{Name: "错误字段", Type: "asset", Mapping: map[string]string{"名称": "title", "x": "unknown"}}, 1)
+	assert.EqualError(t, err, "列 x 映射的字段 unknown 不存在")
+
+	saved, err := importService.SaveMapping(&SaveImportMappingRequest{
+		Name:    "供应商模板",
+		Type:    "asset",
+		Mapping: map[string]string{"名称": "title", "金额": "price"},
+	}, 1)
+	require.NoError(t, err)
+
+	mappings, err := importService.ListMappings("asset", 1)
+	require.NoError(t, err)
+	require.Len(t, mappings, 1)
+
+	// 使用已保存映射导入
+	job, err := importService.CreateJob(&CreateImportJobRequest{
+		File:      newImportUpload(t, "vendor.csv", [][]string{{"名称", "金额"}, {"服务器", "20000"}}),
+		Type:      "asset",
+		MappingID: saved.ID,
+	}, 1, "", "")
+	require.NoError(t, err)
+	job, err = importService.GetJob(job.ID, 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, job.SucceededRows)
+
+	var count int64
+	db.Model(&models.Record{}).Where("title = ?", "服务器").Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	_, err = importService.CreateJob(&CreateImportJobRequest{
+		File:      newImportUpload(t, "vendor.csv", [][]string{{"名称"}}),
+		Type:      "asset",
+		MappingID: saved.ID,
+	}, 2, "", "")
+	assert.EqualError(t, err, "列映射不存在")
+
+	assert.EqualError(t, importService.DeleteMapping(saved.ID, 2), "列映射不存在")
+	require.NoError(t, importService.DeleteMapping(saved.ID, 1))
+
+	_, err = importService.CreateJob(&CreateImportJobRequest{
+		File: newImportUpload(t, "assets.txt", [][]string{{"标题"}}),
+		Type: "asset",
+	}, 1, "", "")
+	assert.EqualError(t, err, "只支持CSV、XLSX格式文件")
+}
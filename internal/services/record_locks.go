@@ -0,0 +1,202 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 编辑锁有效期
+const (
+	DefaultRecordLockTTL = 5 * time.Minute
+	MaxRecordLockTTL     = 30 * time.Minute
+)
+
+// RecordLockRequest 获取/续期编辑锁请求
+type RecordLockRequest struct {
+	TTLSeconds int `json:"ttl_seconds" binding:"omitempty,min=30,max=1800"`
+}
+
+// RecordLockResponse 编辑锁响应
+type RecordLockResponse struct {
+	RecordID   uint   `json:"record_id"`
+	UserID     uint   `json:"user_id"`
+	Username   string `json:"username"`
+	AcquiredAt string `json:"acquired_at"`
+	ExpiresAt  string `json:"expires_at"`
+	IsOwner    bool   `json:"is_owner"`
+}
+
+// lockTTL 解析锁有效期
+func (r *RecordLockRequest) lockTTL() time.Duration {
+	if r == nil || r.TTLSeconds <= 0 {
+		return DefaultRecordLockTTL
+	}
+	ttl := time.Duration(r.TTLSeconds) * time.Second
+	if ttl > MaxRecordLockTTL {
+		return MaxRecordLockTTL
+	}
+	return ttl
+}
+
+// AcquireRecordLock 获取记录编辑锁；已持有时等同于续期，被他人持有时返回冲突
+func (s *RecordService) AcquireRecordLock(recordID uint, req *RecordLockRequest, userID uint, hasAllPermission bool) (*RecordLockResponse, error) {
+	record, err := s.findAccessibleRecord(recordID, userID, hasAllPermission, "记录不存在或无权修改")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(req.lockTTL())
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// 先清除该记录上已过期的锁
+		if err := tx.Where("record_id = ? AND expires_at <= ?", record.ID, now).Delete(&models.RecordLock{}).Error; err != nil {
+			return fmt.Errorf("获取编辑锁失败: %w", err)
+		}
+
+		lock := models.RecordLock{RecordID: record.ID, UserID: userID, AcquiredAt: now, ExpiresAt: expiresAt}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&lock)
+		if result.Error != nil {
+			return fmt.Errorf("获取编辑锁失败: %w", result.Error)
+		}
+		if result.RowsAffected > 0 {
+			return nil
+		}
+
+		// 锁已存在：本人持有则续期，否则冲突
+		var existing models.RecordLock
+		if err := tx.Preload("User").Where("record_id = ?", record.ID).First(&existing).Error; err != nil {
+			return fmt.Errorf("获取编辑锁失败: %w", err)
+		}
+		if existing.UserID != userID {
+			return recordLockedError(&existing)
+		}
+		if err := tx.Model(&existing).Update("expires_at", expiresAt).Error; err != nil {
+			return fmt.Errorf("获取编辑锁失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetRecordLock(record.ID, userID)
+}
+
+// RenewRecordLock 续期（心跳）编辑锁，仅锁持有者可以续期未过期的锁
+func (s *RecordService) RenewRecordLock(recordID uint, req *RecordLockRequest, userID uint) (*RecordLockResponse, error) {
+	now := time.Now()
+	result := s.db.Model(&models.RecordLock{}).
+		Where("record_id = ? AND user_id = ? AND expires_at > ?", recordID, userID, now).
+		Update("expires_at", now.Add(req.lockTTL()))
+	if result.Error != nil {
+		return nil, fmt.Errorf("续期编辑锁失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("未持有该记录的编辑锁或锁已过期")
+	}
+	return s.GetRecordLock(recordID, userID)
+}
+
+// ReleaseRecordLock 释放编辑锁；force 为 true 时可解除他人持有的锁（调用方需校验权限），并记录审计日志
+func (s *RecordService) ReleaseRecordLock(recordID uint, userID uint, force bool, ipAddress, userAgent string) error {
+	var lock models.RecordLock
+	err := s.db.Preload("User").Where("record_id = ? AND expires_at > ?", recordID, time.Now()).First(&lock).Error
+	if err == gorm.ErrRecordNotFound {
+		return fmt.Errorf("记录未被锁定")
+	}
+	if err != nil {
+		return fmt.Errorf("获取编辑锁失败: %w", err)
+	}
+
+	if lock.UserID != userID && !force {
+		return recordLockedError(&lock)
+	}
+	if err := s.db.Delete(&lock).Error; err != nil {
+		return fmt.Errorf("释放编辑锁失败: %w", err)
+	}
+	if lock.UserID != userID {
+		s.logRecordLockBreak(&lock, userID, ipAddress, userAgent)
+	}
+	return nil
+}
+
+// GetRecordLock 获取记录当前有效的编辑锁，未锁定时返回 nil
+func (s *RecordService) GetRecordLock(recordID uint, userID uint) (*RecordLockResponse, error) {
+	var lock models.RecordLock
+	err := s.db.Preload("User").Where("record_id = ? AND expires_at > ?", recordID, time.Now()).First(&lock).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取编辑锁失败: %w", err)
+	}
+	return &RecordLockResponse{
+		RecordID:   lock.RecordID,
+		UserID:     lock.UserID,
+		Username:   lock.User.Username,
+		AcquiredAt: lock.AcquiredAt.Format("2006-01-02 15:04:05"),
+		ExpiresAt:  lock.ExpiresAt.Format("2006-01-02 15:04:05"),
+		IsOwner:    lock.UserID == userID,
+	}, nil
+}
+
+// checkRecordLock 写入前检查编辑锁：无锁或本人持有时放行，他人持有时仅在强制解除时放行；
+// 返回被强制解除的锁，由调用方在事务提交后记录审计日志
+func checkRecordLock(tx *gorm.DB, recordID uint, userID uint, forceBreak bool) (*models.RecordLock, error) {
+	var lock models.RecordLock
+	err := tx.Preload("User").Where("record_id = ? AND expires_at > ?", recordID, time.Now()).First(&lock).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取编辑锁失败: %w", err)
+	}
+	if lock.UserID == userID {
+		return nil, nil
+	}
+	if !forceBreak {
+		return nil, recordLockedError(&lock)
+	}
+	if err := tx.Delete(&lock).Error; err != nil {
+		return nil, fmt.Errorf("解除编辑锁失败: %w", err)
+	}
+	return &lock, nil
+}
+
+// logRecordLockBreak 记录强制解除编辑锁的审计日志
+func (s *RecordService) logRecordLockBreak(lock *models.RecordLock, userID uint, ipAddress, userAgent string) {
+	if s.auditService != nil {
+		s.auditService.CreateAuditLog(&AuditLogRequest{
+			UserID:       userID,
+			Action:       "BREAK_LOCK",
+			ResourceType: "record",
+			ResourceID:   lock.RecordID,
+			OldValues: map[string]interface{}{
+				"lock_user_id": lock.UserID,
+				"acquired_at":  lock.AcquiredAt,
+				"expires_at":   lock.ExpiresAt,
+			},
+			IPAddress: ipAddress,
+			UserAgent: userAgent,
+		})
+	}
+}
+
+// PurgeExpiredRecordLocks 清理已过期的编辑锁
+func (s *RecordService) PurgeExpiredRecordLocks() (int64, error) {
+	result := s.db.Where("expires_at <= ?", time.Now()).Delete(&models.RecordLock{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("清理编辑锁失败: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// recordLockedError 记录被他人锁定的错误
+func recordLockedError(lock *models.RecordLock) error {
+	return fmt.Errorf("记录已被 %s 锁定编辑，锁将于 %s 过期", lock.User.Username, lock.ExpiresAt.Format("2006-01-02 15:04:05"))
+}
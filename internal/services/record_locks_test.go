@@ -0,0 +1,116 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordLocks_AcquireRenewRelease(t *testing.T) {
+	db, service := setupAttachmentTest(t)
+
+	record, err := service.CreateRecord(&CreateRecordRequest{Type: "contract", Title: "长文档", Content: map[string]interface{}{"note": "v1"}}, 1, "", "")
+	require.NoError(t, err)
+	assert.Nil(t, record.Lock)
+
+	lock, err := service.AcquireRecordLock(record.ID, &RecordLockRequest{TTLSeconds: 60}, 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, "owner", lock.Username)
+	assert.True(t, lock.IsOwner)
+
+	// 他人不能获取，详情中展示持有者与过期时间
+	_, err = service.AcquireRecordLock(record.ID, nil, 2, true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "记录已被 owner 锁定编辑")
+	detail, err := service.GetRecordByID(record.ID, 2, true)
+	require.NoError(t, err)
+	require.NotNil(t, detail.Lock)
+	assert.Equal(t, uint(1), detail.Lock.UserID)
+	assert.False(t, detail.Lock.IsOwner)
+	assert.Equal(t, lock.ExpiresAt, detail.Lock.ExpiresAt)
+
+	// 只有持有者可以续期
+	_, err = service.RenewRecordLock(record.ID, nil, 2)
+	assert.EqualError(t, err, "未持有该记录的编辑锁或锁已过期")
+	renewed, err := service.RenewRecordLock(record.ID, &RecordLockRequest{TTLSeconds: 600}, 1)
+	require.NoError(t, err)
+	assert.Greater(t, renewed.ExpiresAt, lock.ExpiresAt)
+
+	// 再次获取等同于续期
+	_, err = service.AcquireRecordLock(record.ID, nil, 1, false)
+	require.NoError(t, err)
+
+	assert.Error(t, service.ReleaseRecordLock(record.ID, 2, false, "", ""))
+	require.NoError(t, service.ReleaseRecordLock(record.ID, 1, false, "", ""))
+	assert.EqualError(t, service.ReleaseRecordLock(record.ID, 1, false, "", ""), "记录未被锁定")
+
+	_, err = service.AcquireRecordLock(record.ID, nil, 2, true)
+	require.NoError(t, err)
+
+	var count int64
+	db.Model(&models.RecordLock{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestRecordLocks_UpdateRequiresHolderOrForce(t *testing.T) {
+	db, service := setupAttachmentTest(t)
+
+	record, err := service.CreateRecord(&CreateRecordRequest{Type: "contract", Title: "长文档", Content: map[string]interface{}{"note": "v1"}}, 1, "", "")
+	require.NoError(t, err)
+	_, err = service.AcquireRecordLock(record.ID, nil, 1, false)
+	require.NoError(t, err)
+
+	// 持有者可以写入，锁保持
+	updated, err := service.UpdateRecord(record.ID, &UpdateRecordRequest{Title: "长文档v2"}, 1, false, "", "")
+	require.NoError(t, err)
+	require.NotNil(t, updated.Lock)
+
+	// 非持有者被拒绝
+	_, err = service.UpdateRecord(record.ID, &UpdateRecordRequest{Title: "他人修改"}, 2, true, "", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "记录已被 owner 锁定编辑")
+
+	// 强制解除后写入成功，并记录审计日志
+	updated, err = service.UpdateRecord(record.ID, &UpdateRecordRequest{Title: "他人修改", ForceBreakLock: true}, 2, true, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "他人修改", updated.Title)
+	assert.Nil(t, updated.Lock)
+
+	var audit models.AuditLog
+	require.NoError(t, db.Where("action = ? AND resource_id = ?", "BREAK_LOCK", record.ID).First(&audit).Error)
+	assert.Equal(t, uint(2), audit.UserID)
+}
+
+func TestRecordLocks_ExpiredLocks(t *testing.T) {
+	db, service := setupAttachmentTest(t)
+
+	record, err := service.CreateRecord(&CreateRecordRequest{Type: "contract", Title: "长文档", Content: map[string]interface{}{"note": "v1"}}, 1, "", "")
+	require.NoError(t, err)
+	_, err = service.AcquireRecordLock(record.ID, nil, 1, false)
+	require.NoError(t, err)
+	require.NoError(t, db.Model(&models.RecordLock{}).Where("record_id = ?", record.ID).
+		Update("expires_at", time.Now().Add(-time.Second)).Error)
+
+	// 过期的锁不再生效，也不能续期
+	detail, err := service.GetRecordByID(record.ID, 1, false)
+	require.NoError(t, err)
+	assert.Nil(t, detail.Lock)
+	_, err = service.RenewRecordLock(record.ID, nil, 1)
+	assert.EqualError(t, err, "未持有该记录的编辑锁或锁已过期")
+	_, err = service.UpdateRecord(record.ID, &UpdateRecordRequest{Title: "他人修改"}, 2, true, "", "")
+	require.NoError(t, err)
+
+	purged, err := service.PurgeExpiredRecordLocks()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	// 他人可以接管过期的锁
+	_, err = service.AcquireRecordLock(record.ID, nil, 2, true)
+	require.NoError(t, err)
+	_, err = service.AcquireRecordLock(record.ID, nil, 1, false)
+	assert.Error(t, err)
+}
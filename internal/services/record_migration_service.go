@@ -0,0 +1,379 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// 迁移操作类型
+const (
+	MigrationOpRenameField = "rename_field"
+	MigrationOpSetDefault  = "set_default"
+	MigrationOpConvertType = "convert_type"
+	MigrationOpDropField   = "drop_field"
+)
+
+// migrationBatchSize 迁移任务每批处理的记录数
+const migrationBatchSize = 100
+
+// RecordMigrationService 记录数据迁移服务
+type RecordMigrationService struct {
+	db           *gorm.DB
+	auditService *AuditService
+	runAsync     func(func())
+}
+
+// NewRecordMigrationService 创建记录数据迁移服务
+func NewRecordMigrationService(db *gorm.DB, auditService *AuditService) *RecordMigrationService {
+	return &RecordMigrationService{
+		db:           db,
+		auditService: auditService,
+		runAsync:     func(f func()) { go f() },
+	}
+}
+
+// RecordMigrationOperation 迁移操作
+type RecordMigrationOperation struct {
+	Op    string      `json:"op" binding:"required,oneof=rename_field set_default convert_type drop_field"`
+	Field string      `json:"field" binding:"required"`
+	To    string      `json:"to,omitempty"`    // rename_field 的新字段名，convert_type 的目标类型
+	Value interface{} `json:"value,omitempty"` // set_default 的默认值
+}
+
+// CreateRecordMigrationRequest 创建迁移任务请求
+type CreateRecordMigrationRequest struct {
+	Operations     []RecordMigrationOperation `json:"operations" binding:"required,min=1,dive"`
+	DryRun         bool                       `json:"dry_run"`
+	SkipValidation bool                       `json:"skip_validation"` // 迁移后不再按当前Schema校验
+}
+
+// RecordMigrationErrorQuery 迁移错误查询参数
+type RecordMigrationErrorQuery struct {
+	Page     int `form:"page,default=1"`
+	PageSize int `form:"page_size,default=50"`
+}
+
+// RecordMigrationErrorListResponse 迁移错误列表响应
+type RecordMigrationErrorListResponse struct {
+	Errors   []models.RecordMigrationError `json:"errors"`
+	Total    int64                         `json:"total"`
+	Page     int                           `json:"page"`
+	PageSize int                           `json:"page_size"`
+}
+
+// validateMigrationOperations 校验迁移操作参数
+func validateMigrationOperations(ops []RecordMigrationOperation) error {
+	for i, op := range ops {
+		switch op.Op {
+		case MigrationOpRenameField:
+			if op.To == "" || op.To == op.Field {
+				return fmt.Errorf("第 %d 个操作的新字段名无效", i+1)
+			}
+		case MigrationOpSetDefault:
+			if op.Value == nil {
+				return fmt.Errorf("第 %d 个操作缺少默认值", i+1)
+			}
+		case MigrationOpConvertType:
+			switch op.To {
+			case "string", "number", "integer", "boolean", "array":
+			default:
+				return fmt.Errorf("第 %d 个操作的目标类型无效: %s", i+1, op.To)
+			}
+		case MigrationOpDropField:
+		default:
+			return fmt.Errorf("第 %d 个操作类型无效: %s", i+1, op.Op)
+		}
+	}
+	return nil
+}
+
+// CreateJob 创建并在后台执行迁移任务
+func (s *RecordMigrationService) CreateJob(recordTypeID uint, req *CreateRecordMigrationRequest, userID uint, ipAddress, userAgent string) (*models.RecordMigrationJob, error) {
+	if err := validateMigrationOperations(req.Operations); err != nil {
+		return nil, err
+	}
+
+	var recordType models.RecordType
+	if err := s.db.First(&recordType, recordTypeID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("记录类型不存在")
+		}
+		return nil, fmt.Errorf("获取记录类型失败: %w", err)
+	}
+
+	var running int64
+	s.db.Model(&models.RecordMigrationJob{}).
+		Where("record_type_id = ? AND dry_run = ? AND status IN ?", recordTypeID, false, []string{"pending", "processing"}).
+		Count(&running)
+	if running > 0 && !req.DryRun {
+		return nil, fmt.Errorf("该记录类型已有正在执行的迁移任务")
+	}
+
+	var total int64
+	s.db.Model(&models.Record{}).Where("type = ?", recordType.Name).Count(&total)
+
+	data, err := json.Marshal(req.Operations)
+	if err != nil {
+		return nil, fmt.Errorf("迁移操作格式错误: %w", err)
+	}
+	var operations []interface{}
+	json.Unmarshal(data, &operations)
+
+	job := models.RecordMigrationJob{
+		RecordTypeID:  recordType.ID,
+		RecordType:    recordType.Name,
+		SchemaVersion: recordType.SchemaVersion,
+		Operations:    models.JSONB{"operations": operations, "skip_validation": req.SkipValidation},
+		DryRun:        req.DryRun,
+		Status:        "pending",
+		TotalRecords:  int(total),
+		CreatedBy:     userID,
+	}
+	if err := s.db.Create(&job).Error; err != nil {
+		return nil, fmt.Errorf("创建迁移任务失败: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.CreateAuditLog(&AuditLogRequest{
+			UserID:       userID,
+			Action:       "CREATE_MIGRATION",
+			ResourceType: "record_type",
+			ResourceID:   recordType.ID,
+			NewValues:    map[string]interface{}{"job_id": job.ID, "operations": operations, "dry_run": req.DryRun},
+			IPAddress:    ipAddress,
+			UserAgent:    userAgent,
+		})
+	}
+
+	ops := req.Operations
+	skipValidation := req.SkipValidation
+	s.runAsync(func() { s.runJob(job.ID, ops, skipValidation) })
+
+	return &job, nil
+}
+
+// GetJob 获取迁移任务
+func (s *RecordMigrationService) GetJob(jobID uint) (*models.RecordMigrationJob, error) {
+	var job models.RecordMigrationJob
+	if err := s.db.First(&job, jobID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("迁移任务不存在")
+		}
+		return nil, fmt.Errorf("获取迁移任务失败: %w", err)
+	}
+	return &job, nil
+}
+
+// ListJobs 获取记录类型的迁移任务列表
+func (s *RecordMigrationService) ListJobs(recordTypeID uint) ([]models.RecordMigrationJob, error) {
+	var jobs []models.RecordMigrationJob
+	if err := s.db.Where("record_type_id = ?", recordTypeID).Order("id DESC").Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("获取迁移任务失败: %w", err)
+	}
+	return jobs, nil
+}
+
+// GetJobErrors 分页获取迁移任务的记录错误
+func (s *RecordMigrationService) GetJobErrors(jobID uint, query *RecordMigrationErrorQuery) (*RecordMigrationErrorListResponse, error) {
+	if _, err := s.GetJob(jobID); err != nil {
+		return nil, err
+	}
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.PageSize <= 0 || query.PageSize > 500 {
+		query.PageSize = 50
+	}
+
+	var total int64
+	s.db.Model(&models.RecordMigrationError{}).Where("job_id = ?", jobID).Count(&total)
+
+	var errs []models.RecordMigrationError
+	err := s.db.Where("job_id = ?", jobID).Order("record_id ASC").
+		Offset((query.Page - 1) * query.PageSize).Limit(query.PageSize).
+		Find(&errs).Error
+	if err != nil {
+		return nil, fmt.Errorf("获取迁移错误失败: %w", err)
+	}
+
+	return &RecordMigrationErrorListResponse{Errors: errs, Total: total, Page: query.Page, PageSize: query.PageSize}, nil
+}
+
+// runJob 分批执行迁移任务
+func (s *RecordMigrationService) runJob(jobID uint, ops []RecordMigrationOperation, skipValidation bool) {
+	job, err := s.GetJob(jobID)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	s.db.Model(job).Updates(map[string]interface{}{"status": "processing", "started_at": &now})
+
+	var fields []SchemaField
+	if !skipValidation {
+		var recordType models.RecordType
+		if err := s.db.First(&recordType, job.RecordTypeID).Error; err != nil {
+			s.failJob(job, fmt.Sprintf("获取记录类型失败: %v", err))
+			return
+		}
+		fields = ParseSchemaFields(recordType.Schema)
+	}
+
+	processed, succeeded, failed := 0, 0, 0
+	var lastID uint
+	for {
+		var records []models.Record
+		err := s.db.Where("type = ? AND id > ?", job.RecordType, lastID).
+			Order("id ASC").Limit(migrationBatchSize).Find(&records).Error
+		if err != nil {
+			s.failJob(job, fmt.Sprintf("获取记录失败: %v", err))
+			return
+		}
+		if len(records) == 0 {
+			break
+		}
+
+		for i := range records {
+			record := &records[i]
+			lastID = record.ID
+			processed++
+			if err := s.migrateRecord(record, ops, fields, skipValidation, job.DryRun); err != nil {
+				failed++
+				s.db.Create(&models.RecordMigrationError{JobID: job.ID, RecordID: record.ID, Error: err.Error()})
+				continue
+			}
+			succeeded++
+		}
+
+		progress := 100
+		if job.TotalRecords > processed {
+			progress = processed * 100 / job.TotalRecords
+		}
+		s.db.Model(job).Updates(map[string]interface{}{
+			"processed_records": processed,
+			"succeeded_records": succeeded,
+			"failed_records":    failed,
+			"progress":          progress,
+		})
+	}
+
+	completedAt := time.Now()
+	s.db.Model(job).Updates(map[string]interface{}{
+		"status":            "completed",
+		"progress":          100,
+		"total_records":     processed,
+		"processed_records": processed,
+		"succeeded_records": succeeded,
+		"failed_records":    failed,
+		"completed_at":      &completedAt,
+	})
+
+	if s.auditService != nil && !job.DryRun {
+		s.auditService.CreateAuditLog(&AuditLogRequest{
+			UserID:       job.CreatedBy,
+			Action:       "MIGRATE_RECORDS",
+			ResourceType: "record_type",
+			ResourceID:   job.RecordTypeID,
+			NewValues:    map[string]interface{}{"job_id": job.ID, "succeeded": succeeded, "failed": failed},
+		})
+	}
+}
+
+// migrateRecord 对单条记录执行迁移，试运行时不写入
+func (s *RecordMigrationService) migrateRecord(record *models.Record, ops []RecordMigrationOperation, fields []SchemaField, skipValidation, dryRun bool) error {
+	content := make(map[string]interface{}, len(record.Content))
+	for k, v := range record.Content {
+		content[k] = v
+	}
+
+	if err := applyMigrationOperations(content, ops); err != nil {
+		return err
+	}
+	if !skipValidation {
+		if errs := ValidateContentAgainstSchema(fields, content); len(errs) > 0 {
+			return fmt.Errorf("迁移后仍不符合Schema: %s", errs[0])
+		}
+	}
+	if dryRun {
+		return nil
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Record{}).
+			Where("id = ? AND version = ?", record.ID, record.Version).
+			Updates(map[string]interface{}{"content": models.JSONB(content), "version": record.Version + 1})
+		if result.Error != nil {
+			return fmt.Errorf("更新记录失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("记录已被修改，跳过迁移")
+		}
+
+		// 同步Schema文件字段的附件关联
+		for _, op := range ops {
+			switch op.Op {
+			case MigrationOpRenameField:
+				if err := tx.Model(&models.RecordFile{}).
+					Where("record_id = ? AND field_name = ?", record.ID, op.Field).
+					Update("field_name", op.To).Error; err != nil {
+					return fmt.Errorf("更新记录附件失败: %w", err)
+				}
+			case MigrationOpDropField:
+				if err := tx.Where("record_id = ? AND field_name = ?", record.ID, op.Field).
+					Delete(&models.RecordFile{}).Error; err != nil {
+					return fmt.Errorf("更新记录附件失败: %w", err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// applyMigrationOperations 按顺序对记录内容执行迁移操作
+func applyMigrationOperations(content map[string]interface{}, ops []RecordMigrationOperation) error {
+	for _, op := range ops {
+		value, exists := content[op.Field]
+		switch op.Op {
+		case MigrationOpRenameField:
+			if !exists {
+				continue
+			}
+			if existing, ok := content[op.To]; ok && existing != nil {
+				return fmt.Errorf("字段 %s 已存在，无法将 %s 重命名", op.To, op.Field)
+			}
+			content[op.To] = value
+			delete(content, op.Field)
+		case MigrationOpSetDefault:
+			if !exists || value == nil || value == "" {
+				content[op.Field] = op.Value
+			}
+		case MigrationOpConvertType:
+			if !exists {
+				continue
+			}
+			converted, err := convertSchemaValue(value, op.To)
+			if err != nil {
+				return fmt.Errorf("字段 %s: %w", op.Field, err)
+			}
+			content[op.Field] = converted
+		case MigrationOpDropField:
+			delete(content, op.Field)
+		}
+	}
+	return nil
+}
+
+// failJob 标记任务失败
+func (s *RecordMigrationService) failJob(job *models.RecordMigrationJob, message string) {
+	now := time.Now()
+	s.db.Model(job).Updates(map[string]interface{}{
+		"status":        "failed",
+		"error_message": message,
+		"completed_at":  &now,
+	})
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"testing"
+
+	"info-management-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// setupMigrationTest 创建Schema迁移测试环境，迁移任务同步执行
+func setupMigrationTest(t *testing.T) (*gorm.DB, *RecordTypeService, *RecordMigrationService) {
+	db := newServiceTestDB(t)
+
+	migrationService := NewRecordMigrationService(db, NewAuditService(db))
+	migrationService.runAsync = func(f func()) { f() }
+	return db, NewRecordTypeService(db), migrationService
+}
+
+func TestRecordTypeService_SchemaVersionsAndCompatibility(t *testing.T) {
+	db, typeService, _ := setupMigrationTest(t)
+
+	created, err := typeService.CreateRecordType(&CreateRecordTypeRequest{
+		Name:        "asset",
+		DisplayName: "资产",
+		Schema:      map[string]interface{}{"fields": []interface{}{map[string]interface{}{"name": "name", "type": "string"}}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, created.SchemaVersion)
+
+	require.NoError(t, db.Create(&models.Record{Type: "asset", Title: "A", Content: models.JSONB{"name": "a", "price": "12.5"}, CreatedBy: 1}).Error)
+	require.NoError(t, db.Create(&models.Record{Type: "asset", Title: "B", Content: models.JSONB{"name": "b", "price": 3.0}, CreatedBy: 1}).Error)
+
+	newSchema := map[string]interface{}{"fields": []interface{}{
+		map[string]interface{}{"name": "name", "type": "string"},
+		map[string]interface{}{"name": "price", "type": "number", "required": true},
+	}}
+
+	report, err := typeService.CheckSchemaCompatibility(created.ID, newSchema)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), report.TotalRecords)
+	assert.Equal(t, int64(1), report.Incompatible)
+	assert.Equal(t, "A", report.Samples[0].Title)
+
+	updated, err := typeService.UpdateRecordType(created.ID, &UpdateRecordTypeRequest{Schema: newSchema})
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.SchemaVersion)
+	require.NotNil(t, updated.Compatibility)
+	assert.Equal(t, int64(1), updated.Compatibility.Incompatible)
+
+	// 相同Schema不产生新版本
+	updated, err = typeService.UpdateRecordType(created.ID, &UpdateRecordTypeRequest{Schema: newSchema})
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.SchemaVersion)
+	assert.Nil(t, updated.Compatibility)
+
+	versions, err := typeService.GetSchemaVersions(created.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, 2, versions[0].Version)
+}
+
+func TestRecordMigrationService_RunJob(t *testing.T) {
+	db, typeService, migrationService := setupMigrationTest(t)
+
+	rt, err := typeService.CreateRecordType(&CreateRecordTypeRequest{
+		Name:        "device",
+		DisplayName: "设备",
+		Schema: map[string]interface{}{"fields": []interface{}{
+			map[string]interface{}{"name": "serial", "type": "string", "required": true},
+			map[string]interface{}{"name": "count", "type": "integer"},
+			map[string]interface{}{"name": "manual", "type": "file"},
+		}},
+	})
+	require.NoError(t, err)
+
+	ok := models.Record{Type: "device", Title: "OK", Content: models.JSONB{"sn": "001", "count": "3", "legacy": true, "doc": float64(7)}, CreatedBy: 1, Version: 1}
+	bad := models.Record{Type: "device", Title: "BAD", Content: models.JSONB{"sn": "002", "count": "many"}, CreatedBy: 1, Version: 1}
+	require.NoError(t, db.Create(&ok).Error)
+	require.NoError(t, db.Create(&bad).Error)
+	require.NoError(t, db.Create(&models.RecordFile{RecordID: ok.ID, FileID: 7, FieldName: "doc", CreatedBy: 1}).Error)
+
+	req := &CreateRecordMigrationRequest{
+		Operations: []RecordMigrationOperation{
+			{Op: MigrationOpRenameField, Field: "sn", To: "serial"},
+			{Op: MigrationOpRenameField, Field: "doc", To: "manual"},
+			{Op: MigrationOpConvertType, Field: "count", To: "integer"},
+			{Op: MigrationOpDropField, Field: "legacy"},
+		},
+		DryRun: true,
+	}
+
+	// 试运行不修改数据
+	job, err := migrationService.CreateJob(rt.ID, req, 1, "", "")
+	require.NoError(t, err)
+	job, err = migrationService.GetJob(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "completed", job.Status)
+	assert.Equal(t, 2, job.ProcessedRecords)
+	assert.Equal(t, 1, job.SucceededRecords)
+	assert.Equal(t, 1, job.FailedRecords)
+	var unchanged models.Record
+	require.NoError(t, db.First(&unchanged, ok.ID).Error)
+	assert.Contains(t, unchanged.Content, "sn")
+
+	req.DryRun = false
+	job, err = migrationService.CreateJob(rt.ID, req, 1, "", "")
+	require.NoError(t, err)
+
+	var migrated models.Record
+	require.NoError(t, db.First(&migrated, ok.ID).Error)
+	assert.Equal(t, "001", migrated.Content["serial"])
+	assert.Equal(t, float64(3), migrated.Content["count"])
+	assert.NotContains(t, migrated.Content, "legacy")
+	assert.Equal(t, 2, migrated.Version)
+
+	var recordFile models.RecordFile
+	require.NoError(t, db.Where("record_id = ?", ok.ID).First(&recordFile).Error)
+	assert.Equal(t, "manual", recordFile.FieldName)
+
+	errs, err := migrationService.GetJobErrors(job.ID, &RecordMigrationErrorQuery{})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), errs.Total)
+	assert.Equal(t, bad.ID, errs.Errors[0].RecordID)
+
+	var untouched models.Record
+	require.NoError(t, db.First(&untouched, bad.ID).Error)
+	assert.Equal(t, "many", untouched.Content["count"])
+}
+
+func TestRecordMigrationService_ValidateOperations(t *testing.T) {
+	_, typeService, migrationService := setupMigrationTest(t)
+	rt, err := typeService.CreateRecordType(&CreateRecordTypeRequest{Name: "x1", DisplayName: "测试", Schema: map[string]interface{}{}})
+	require.NoError(t, err)
+
+	_, err = migrationService.CreateJob(rt.ID, &CreateRecordMigrationRequest{
+		Operations: []RecordMigrationOperation{{Op: MigrationOpConvertType, Field: "a", To: "date"}},
+	}, 1, "", "")
+	assert.EqualError(t, err, "第 1 个操作的目标类型无效: date")
+
+	_, err = migrationService.CreateJob(999, &CreateRecordMigrationRequest{
+		Operations: []RecordMigrationOperation{{Op: MigrationOpDropField, Field: "a"}},
+	}, 1, "", "")
+	assert.EqualError(t, err, "记录类型不存在")
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"info-management-system/internal/models"
+)
+
+// 定时发布/下线对应的流程状态
+const (
+	ScheduleDraftStatus     = "draft"
+	SchedulePublishedStatus = "published"
+	ScheduleArchivedStatus  = "archived"
+)
+
+// RecordScheduleRequest 设置定时发布/下线请求，字段为空表示取消对应的定时
+type RecordScheduleRequest struct {
+	PublishAt *time.Time `json:"publish_at"`
+	ExpireAt  *time.Time `json:"expire_at"`
+}
+
+// ScheduledTransitionResult 定时流转执行结果
+type ScheduledTransitionResult struct {
+	Published []uint          `json:"published"`
+	Archived  []uint          `json:"archived"`
+	Failed    map[uint]string `json:"failed"`
+}
+
+// validateRecordSchedule 校验定时发布/下线时间
+func validateRecordSchedule(publishAt, expireAt *time.Time) error {
+	if publishAt != nil && expireAt != nil && !expireAt.After(*publishAt) {
+		return fmt.Errorf("下线时间必须晚于发布时间")
+	}
+	return nil
+}
+
+// ScheduleRecord 设置记录的定时发布/下线时间
+func (s *RecordService) ScheduleRecord(id uint, req *RecordScheduleRequest, userID uint, hasAllPermission bool, ipAddress, userAgent string) (*RecordResponse, error) {
+	if err := validateRecordSchedule(req.PublishAt, req.ExpireAt); err != nil {
+		return nil, err
+	}
+
+	record, err := s.findAccessibleRecord(id, userID, hasAllPermission, "记录不存在或无权修改")
+	if err != nil {
+		return nil, err
+	}
+
+	oldValues := map[string]interface{}{"publish_at": record.PublishAt, "expire_at": record.ExpireAt}
+	err = s.db.Model(record).Updates(map[string]interface{}{
+		"publish_at": req.PublishAt,
+		"expire_at":  req.ExpireAt,
+	}).Error
+	if err != nil {
+		return nil, fmt.Errorf("设置定时发布失败: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.CreateAuditLog(&AuditLogRequest{
+			UserID:       userID,
+			Action:       "SCHEDULE",
+			ResourceType: "record",
+			ResourceID:   record.ID,
+			OldValues:    oldValues,
+			NewValues:    map[string]interface{}{"publish_at": req.PublishAt, "expire_at": req.ExpireAt},
+			IPAddress:    ipAddress,
+			UserAgent:    userAgent,
+		})
+	}
+
+	return s.GetRecordByID(record.ID, userID, hasAllPermission)
+}
+
+// RecordVisibleTo 记录在 now 时是否对用户可见：创建者与审核人始终可见，其他用户只能看到已到发布时间且未下线的记录
+func RecordVisibleTo(record *RecordResponse, userID uint, now time.Time) bool {
+	if record.CreatedBy == userID || (record.ReviewerID != nil && *record.ReviewerID == userID) {
+		return true
+	}
+	if record.PublishAt != nil && record.PublishAt.After(now) {
+		return false
+	}
+	return record.ExpireAt == nil || record.ExpireAt.After(now)
+}
+
+// RunScheduledTransitions 执行到期的定时流转：先将到达发布时间的草稿发布，再将到达下线时间的已发布记录归档
+func (s *RecordWorkflowService) RunScheduledTransitions(now time.Time) (*ScheduledTransitionResult, error) {
+	result := &ScheduledTransitionResult{Published: []uint{}, Archived: []uint{}, Failed: map[uint]string{}}
+	systemUserID, err := SystemUserID(s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	phases := []struct {
+		from, to, column, comment string
+		done                      *[]uint
+	}{
+		{ScheduleDraftStatus, SchedulePublishedStatus, "publish_at", "定时发布", &result.Published},
+		{SchedulePublishedStatus, ScheduleArchivedStatus, "expire_at", "定时下线", &result.Archived},
+	}
+	for _, phase := range phases {
+		var records []models.Record
+		err := s.db.Where("status = ? AND "+phase.column+" IS NOT NULL AND "+phase.column+" <= ?", phase.from, now).
+			Order("id ASC").Find(&records).Error
+		if err != nil {
+			return nil, fmt.Errorf("查询定时记录失败: %w", err)
+		}
+
+		for i := range records {
+			record := &records[i]
+			history, err := s.applyScheduledTransition(record, phase.to, phase.column, phase.comment, systemUserID)
+			if err != nil {
+				result.Failed[record.ID] = err.Error()
+				continue
+			}
+			s.afterTransition(record, history, systemUserID, "", "")
+			*phase.done = append(*phase.done, record.ID)
+		}
+	}
+	return result, nil
+}
+
+// applyScheduledTransition 由系统账号执行定时流转，需记录类型的流程允许该流转，不做用户权限校验
+// 流程不允许该流转时清除对应的定时时间，避免每次调度重复尝试
+func (s *RecordWorkflowService) applyScheduledTransition(record *models.Record, toStatus, column, comment string, systemUserID uint) (*models.RecordStatusHistory, error) {
+	workflow, err := s.GetWorkflow(record.Type)
+	if err != nil {
+		return nil, err
+	}
+	transition := workflow.FindTransition(record.Status, "", toStatus)
+	if transition == nil {
+		if err := s.db.Model(record).Update(column, nil).Error; err != nil {
+			return nil, fmt.Errorf("取消定时失败: %w", err)
+		}
+		return nil, fmt.Errorf("当前状态 %s 不允许执行该流转，已取消定时", record.Status)
+	}
+	return s.commitTransition(record, transition, comment, systemUserID)
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordWorkflowService_RunScheduledTransitions(t *testing.T) {
+	db, _ := setupWorkflowTest(t)
+	require.NoError(t, db.Create(&models.RecordType{Name: "notice", DisplayName: "通知", TableName: "records_notice", IsActive: true,
+		Schema: models.JSONB{"fields": []interface{}{}}}).Error)
+	service := NewRecordWorkflowService(db, NewRecordTypeService(db), NewAuditService(db), nil)
+
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Hour)
+	create := func(recordType, status string, publishAt, expireAt *time.Time) uint {
+		record := models.Record{Type: recordType, Title: "公告", Status: status, CreatedBy: 1, PublishAt: publishAt, ExpireAt: expireAt}
+		require.NoError(t, db.Create(&record).Error)
+		return record.ID
+	}
+	due := create("notice", "draft", &past, nil)
+	notYet := create("notice", "draft", &future, nil)
+	expired := create("notice", "published", nil, &past)
+	missed := create("notice", "draft", &past, &past)
+	unsupported := create("review", "draft", &past, nil)
+	create("notice", "archived", &past, &past)
+
+	result, err := service.RunScheduledTransitions(now)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{due, missed}, result.Published)
+	assert.Equal(t, []uint{expired, missed}, result.Archived, "错过发布窗口的草稿发布后立即下线")
+	assert.Contains(t, result.Failed, unsupported, "流程不支持的记录类型不会被发布")
+	assert.Len(t, result.Failed, 1)
+
+	var record models.Record
+	require.NoError(t, db.First(&record, notYet).Error)
+	assert.Equal(t, "draft", record.Status)
+
+	// 写入状态历史与审计日志
+	history, err := service.GetStatusHistory(due, 1, true)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, "publish", history[0].Action)
+	assert.Equal(t, "定时发布", history[0].Comment)
+	systemUserID, err := SystemUserID(db)
+	require.NoError(t, err)
+	assert.Equal(t, systemUserID, history[0].UserID)
+
+	var audits int64
+	db.Model(&models.AuditLog{}).Where("action = ?", "STATUS_CHANGE").Count(&audits)
+	assert.Equal(t, int64(4), audits)
+
+	// 流程不允许的定时被取消
+	var rejected models.Record
+	require.NoError(t, db.First(&rejected, unsupported).Error)
+	assert.Equal(t, "draft", rejected.Status)
+	assert.Nil(t, rejected.PublishAt)
+
+	// 再次执行不会重复流转或重复尝试
+	result, err = service.RunScheduledTransitions(now)
+	require.NoError(t, err)
+	assert.Empty(t, result.Published)
+	assert.Empty(t, result.Archived)
+	assert.Empty(t, result.Failed)
+}
+
+func TestRecordWorkflowService_RunScheduledTransitionsWithForeignKeys(t *testing.T) {
+	db := newForeignKeyTestDB(t)
+	owner := models.User{Username: "owner", Email: "owner@example.com", PasswordHash: "x", IsActive: true}
+	require.NoError(t, db.Create(&owner).Error)
+	require.NoError(t, db.Create(&models.RecordType{Name: "notice", DisplayName: "通知", TableName: "records_notice", IsActive: true,
+		Schema: models.JSONB{"fields": []interface{}{}}}).Error)
+	service := NewRecordWorkflowService(db, NewRecordTypeService(db), NewAuditService(db), nil)
+	service.watchService.runAsync = func(fn func()) { fn() }
+
+	past := time.Now().Add(-time.Minute)
+	record := models.Record{Type: "notice", Title: "公告", Status: "draft", CreatedBy: owner.ID, PublishAt: &past}
+	require.NoError(t, db.Create(&record).Error)
+
+	// 系统流转的历史与审计记在系统账号下，满足操作人外键
+	result, err := service.RunScheduledTransitions(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, []uint{record.ID}, result.Published)
+	assert.Empty(t, result.Failed)
+	var audits int64
+	db.Model(&models.AuditLog{}).Where("action = ? AND resource_id = ?", "STATUS_CHANGE", record.ID).Count(&audits)
+	assert.Equal(t, int64(1), audits)
+}
+
+func TestRecordService_ScheduleAndVisibility(t *testing.T) {
+	db, _ := setupWorkflowTest(t)
+	require.NoError(t, db.Create(&models.RecordType{Name: "notice", DisplayName: "通知", TableName: "records_notice", IsActive: true,
+		Schema: models.JSONB{"fields": []interface{}{}}}).Error)
+	service := NewRecordService(db, NewRecordTypeService(db), NewAuditService(db), nil, nil)
+
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	_, err := service.CreateRecord(&CreateRecordRequest{Type: "notice", Title: "无效", Content: map[string]interface{}{"body": "x"},
+		PublishAt: &future, ExpireAt: &past}, 1, "", "")
+	assert.EqualError(t, err, "下线时间必须晚于发布时间")
+
+	upcoming, err := service.CreateRecord(&CreateRecordRequest{Type: "notice", Title: "预告", Content: map[string]interface{}{"body": "x"},
+		PublishAt: &future}, 1, "", "")
+	require.NoError(t, err)
+	require.NotNil(t, upcoming.PublishAt)
+	live, err := service.CreateRecord(&CreateRecordRequest{Type: "notice", Title: "当前", Content: map[string]interface{}{"body": "x"}}, 1, "", "")
+	require.NoError(t, err)
+	ended, err := service.CreateRecord(&CreateRecordRequest{Type: "notice", Title: "已结束", Content: map[string]interface{}{"body": "x"}}, 1, "", "")
+	require.NoError(t, err)
+
+	_, err = service.ScheduleRecord(ended.ID, &RecordScheduleRequest{ExpireAt: &past}, 2, false, "", "")
+	assert.EqualError(t, err, "记录不存在或无权修改")
+	scheduled, err := service.ScheduleRecord(ended.ID, &RecordScheduleRequest{ExpireAt: &past}, 1, false, "", "")
+	require.NoError(t, err)
+	require.NotNil(t, scheduled.ExpireAt)
+	assert.Nil(t, scheduled.PublishAt)
+
+	// 读者只能看到已发布时间范围内的记录，创建者不受影响
+	list, err := service.GetRecords(&RecordListQuery{PageSize: 10, SortBy: "id", SortOrder: "asc", VisibleOnly: true}, 2, true)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{live.ID}, recordIDs(list.Records))
+
+	list, err = service.GetRecords(&RecordListQuery{PageSize: 10, SortBy: "id", SortOrder: "asc", VisibleOnly: true}, 1, true)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{upcoming.ID, live.ID, ended.ID}, recordIDs(list.Records))
+
+	list, err = service.GetRecords(&RecordListQuery{PageSize: 10, SortBy: "id", SortOrder: "asc"}, 2, true)
+	require.NoError(t, err)
+	assert.Len(t, list.Records, 3)
+
+	// 详情按同样规则判断，审核人也不受发布时间限制
+	now := time.Now()
+	assert.False(t, RecordVisibleTo(upcoming, 2, now))
+	assert.True(t, RecordVisibleTo(upcoming, 1, now))
+	assert.True(t, RecordVisibleTo(live, 2, now))
+	assert.False(t, RecordVisibleTo(scheduled, 2, now))
+	reviewer := uint(2)
+	upcoming.ReviewerID = &reviewer
+	assert.True(t, RecordVisibleTo(upcoming, 2, now))
+	require.NoError(t, db.Model(&models.Record{}).Where("id = ?", upcoming.ID).Update("reviewer_id", reviewer).Error)
+	list, err = service.GetRecords(&RecordListQuery{PageSize: 10, SortBy: "id", SortOrder: "asc", VisibleOnly: true}, 2, true)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{upcoming.ID, live.ID}, recordIDs(list.Records))
+
+	var audits int64
+	db.Model(&models.AuditLog{}).Where("action = ? AND resource_id = ?", "SCHEDULE", ended.ID).Count(&audits)
+	assert.Equal(t, int64(1), audits)
+}
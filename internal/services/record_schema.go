@@ -0,0 +1,332 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// schemaSampleLimit 兼容性报告中最多返回的不兼容记录样例数
+const schemaSampleLimit = 20
+
+// SchemaViolation 单条记录的Schema校验错误
+type SchemaViolation struct {
+	RecordID uint     `json:"record_id"`
+	Title    string   `json:"title"`
+	Errors   []string `json:"errors"`
+}
+
+// SchemaCompatibilityReport Schema兼容性报告
+type SchemaCompatibilityReport struct {
+	SchemaVersion int               `json:"schema_version"`
+	TotalRecords  int64             `json:"total_records"`
+	Incompatible  int64             `json:"incompatible"`
+	Samples       []SchemaViolation `json:"samples"`
+	// 不符合该Schema的记录模板数
+	InvalidTemplates int64 `json:"invalid_templates"`
+}
+
+// CheckSchemaRequest Schema兼容性检查请求
+type CheckSchemaRequest struct {
+	Schema map[string]interface{} `json:"schema" binding:"required"`
+}
+
+// SchemaVersionResponse Schema历史版本响应
+type SchemaVersionResponse struct {
+	Version   int                    `json:"version"`
+	Schema    map[string]interface{} `json:"schema"`
+	CreatedAt string                 `json:"created_at"`
+}
+
+// CheckSchemaCompatibility 检查现有记录在指定Schema下能否通过校验
+func (s *RecordTypeService) CheckSchemaCompatibility(id uint, schema models.JSONB) (*SchemaCompatibilityReport, error) {
+	var recordType models.RecordType
+	if err := s.db.First(&recordType, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("记录类型不存在")
+		}
+		return nil, fmt.Errorf("获取记录类型失败: %w", err)
+	}
+	report, err := checkRecordsAgainstSchema(s.db, recordType.Name, schema, recordType.SchemaVersion)
+	if err != nil {
+		return nil, err
+	}
+	report.InvalidTemplates, err = checkTemplatesAgainstSchema(s.db, &recordType, schema, recordType.SchemaVersion, false)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// GetSchemaVersions 获取记录类型的Schema历史版本
+func (s *RecordTypeService) GetSchemaVersions(id uint) ([]SchemaVersionResponse, error) {
+	var count int64
+	if err := s.db.Model(&models.RecordType{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("获取记录类型失败: %w", err)
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("记录类型不存在")
+	}
+
+	var versions []models.RecordTypeSchemaVersion
+	if err := s.db.Where("record_type_id = ?", id).Order("version DESC").Find(&versions).Error; err != nil {
+		return nil, fmt.Errorf("获取Schema版本失败: %w", err)
+	}
+
+	responses := make([]SchemaVersionResponse, len(versions))
+	for i, v := range versions {
+		responses[i] = SchemaVersionResponse{
+			Version:   v.Version,
+			Schema:    v.Schema,
+			CreatedAt: v.CreatedAt.Format("2006-01-02 15:04:05"),
+		}
+	}
+	return responses, nil
+}
+
+// checkRecordsAgainstSchema 分批校验某类型的全部记录
+func checkRecordsAgainstSchema(db *gorm.DB, recordType string, schema models.JSONB, version int) (*SchemaCompatibilityReport, error) {
+	fields := ParseSchemaFields(schema)
+	report := &SchemaCompatibilityReport{SchemaVersion: version, Samples: []SchemaViolation{}}
+
+	var records []models.Record
+	err := db.Select("id", "title", "content").Where("type = ?", recordType).
+		FindInBatches(&records, 200, func(tx *gorm.DB, batch int) error {
+			for _, record := range records {
+				report.TotalRecords++
+				errs := ValidateContentAgainstSchema(fields, record.Content)
+				if len(errs) == 0 {
+					continue
+				}
+				report.Incompatible++
+				if len(report.Samples) < schemaSampleLimit {
+					report.Samples = append(report.Samples, SchemaViolation{RecordID: record.ID, Title: record.Title, Errors: errs})
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return nil, fmt.Errorf("检查记录兼容性失败: %w", err)
+	}
+	return report, nil
+}
+
+// ValidateContentAgainstSchema 按Schema字段校验记录内容，返回全部错误
+func ValidateContentAgainstSchema(fields []SchemaField, content map[string]interface{}) []string {
+	var errs []string
+	for _, field := range fields {
+		value, exists := content[field.Name]
+		if !exists || value == nil || value == "" {
+			if field.Required {
+				errs = append(errs, fmt.Sprintf("字段 %s 为必填项", field.Name))
+			}
+			continue
+		}
+		if !schemaValueMatchesType(field.Type, value) {
+			errs = append(errs, fmt.Sprintf("字段 %s 的值与类型 %s 不匹配", field.Name, field.Type))
+		}
+	}
+	return errs
+}
+
+// schemaValueMatchesType 检查值是否符合字段类型，未知类型不做限制
+func schemaValueMatchesType(fieldType string, value interface{}) bool {
+	switch fieldType {
+	case "string", "text", "textarea", "email", "url", "select", "date", "datetime":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := toFloat(value)
+		return ok
+	case "integer":
+		f, ok := toFloat(value)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array", "files", "multiselect", "tags":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "file":
+		_, ok := parseFileRef(value)
+		return ok
+	}
+	return true
+}
+
+// convertSchemaValue 将值转换为目标类型，nil 保持不变
+func convertSchemaValue(value interface{}, toType string) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	switch toType {
+	case "string":
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		}
+	case "number":
+		if f, ok := toFloat(value); ok {
+			return f, nil
+		}
+		switch v := value.(type) {
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return f, nil
+			}
+		case bool:
+			if v {
+				return float64(1), nil
+			}
+			return float64(0), nil
+		}
+	case "integer":
+		converted, err := convertSchemaValue(value, "number")
+		if err == nil {
+			f := converted.(float64)
+			if f == math.Trunc(f) {
+				return f, nil
+			}
+		}
+	case "boolean":
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case float64:
+			return v != 0, nil
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return b, nil
+			}
+		}
+	case "array":
+		if list, ok := value.([]interface{}); ok {
+			return list, nil
+		}
+		return []interface{}{value}, nil
+	default:
+		return nil, fmt.Errorf("不支持的目标类型: %s", toType)
+	}
+	return nil, fmt.Errorf("无法将值 %v 转换为 %s", value, toType)
+}
+
+// toFloat 数值类型转 float64
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// 导入时可识别的日期与时间格式
+var (
+	importDateLayouts     = []string{"2006-01-02", "2006/01/02", "2006-1-2", "2006/1/2", "2006.01.02", "20060102", "2006年1月2日"}
+	importDateTimeLayouts = []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006/01/02 15:04:05", "2006/01/02 15:04", "2006/1/2 15:04", time.RFC3339}
+)
+
+// excelEpoch Excel 日期序列号的起点
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// coerceCellValue 将表格单元格文本转换为Schema字段类型的值，空单元格返回 nil
+func coerceCellValue(raw string, fieldType string) (interface{}, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	switch fieldType {
+	case "number":
+		f, err := strconv.ParseFloat(strings.ReplaceAll(raw, ",", ""), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q 不是有效的数字", raw)
+		}
+		return f, nil
+	case "integer":
+		f, err := strconv.ParseFloat(strings.ReplaceAll(raw, ",", ""), 64)
+		if err != nil || f != math.Trunc(f) {
+			return nil, fmt.Errorf("%q 不是有效的整数", raw)
+		}
+		return f, nil
+	case "boolean":
+		switch strings.ToLower(raw) {
+		case "true", "1", "yes", "y", "是", "对":
+			return true, nil
+		case "false", "0", "no", "n", "否", "错":
+			return false, nil
+		}
+		return nil, fmt.Errorf("%q 不是有效的布尔值", raw)
+	case "date":
+		t, err := parseImportTime(raw, importDateLayouts)
+		if err != nil {
+			return nil, err
+		}
+		return t.Format("2006-01-02"), nil
+	case "datetime":
+		t, err := parseImportTime(raw, append(importDateTimeLayouts, importDateLayouts...))
+		if err != nil {
+			return nil, err
+		}
+		return t.Format("2006-01-02 15:04:05"), nil
+	case "array", "multiselect", "tags", "files":
+		return splitImportList(raw), nil
+	case "file":
+		if _, ok := parseFileRef(raw); !ok {
+			return nil, fmt.Errorf("%q 不是有效的文件ID", raw)
+		}
+		return raw, nil
+	}
+	return raw, nil
+}
+
+// parseImportTime 按候选格式解析时间，兼容 Excel 日期序列号
+func parseImportTime(raw string, layouts []string) (time.Time, error) {
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, raw, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if serial, err := strconv.ParseFloat(raw, 64); err == nil && serial > 0 && serial < 2958466 {
+		days := math.Floor(serial)
+		seconds := math.Round((serial - days) * 86400)
+		t := excelEpoch.AddDate(0, 0, int(days)).Add(time.Duration(seconds) * time.Second)
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local), nil
+	}
+	return time.Time{}, fmt.Errorf("%q 不是有效的日期", raw)
+}
+
+// splitImportList 拆分以逗号、分号或顿号分隔的列表
+func splitImportList(raw string) []interface{} {
+	parts := strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || r == ';' || r == '，' || r == '；' || r == '、'
+	})
+	list := make([]interface{}, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+	return list
+}
@@ -16,23 +16,37 @@ type RecordService struct {
 	db                *gorm.DB
 	recordTypeService *RecordTypeService
 	auditService      *AuditService
+	fileService       *FileService
+	workflowService   *RecordWorkflowService
+	watchService      *WatchService
 }
 
 // NewRecordService 创建记录服务
-func NewRecordService(db *gorm.DB, recordTypeService *RecordTypeService, auditService *AuditService) *RecordService {
+func NewRecordService(db *gorm.DB, recordTypeService *RecordTypeService, auditService *AuditService, fileService *FileService, workflowService *RecordWorkflowService) *RecordService {
+	if workflowService == nil {
+		workflowService = NewRecordWorkflowService(db, recordTypeService, auditService, nil)
+	}
 	return &RecordService{
 		db:                db,
 		recordTypeService: recordTypeService,
 		auditService:      auditService,
+		fileService:       fileService,
+		workflowService:   workflowService,
+		watchService:      workflowService.watchService,
 	}
 }
 
 // CreateRecordRequest 创建记录请求
 type CreateRecordRequest struct {
-	Type    string                 `json:"type" binding:"required"`
-	Title   string                 `json:"title" binding:"required,min=1,max=500"`
-	Content map[string]interface{} `json:"content" binding:"required"`
+	Type    string                 `json:"type" binding:"required_without=TemplateID"`
+	Title   string                 `json:"title" binding:"required_without=TemplateID,max=500"`
+	Content map[string]interface{} `json:"content" binding:"required_without=TemplateID"`
 	Tags    []string               `json:"tags"`
+	// 使用模板创建：类型、预填内容、标题模式和默认标签取自模板，请求中的字段优先
+	TemplateID *uint `json:"template_id"`
+	// 定时发布/下线时间（可选）
+	PublishAt *time.Time `json:"publish_at"`
+	ExpireAt  *time.Time `json:"expire_at"`
 }
 
 // UpdateRecordRequest 更新记录请求
@@ -40,6 +54,8 @@ type UpdateRecordRequest struct {
 	Title   string                 `json:"title" binding:"omitempty,min=1,max=500"`
 	Content map[string]interface{} `json:"content"`
 	Tags    []string               `json:"tags"`
+	// 强制解除他人持有的编辑锁（需要 records:break_lock 权限）
+	ForceBreakLock bool `json:"force_break_lock"`
 }
 
 // BatchCreateRequest 批量创建请求
@@ -51,12 +67,14 @@ type BatchCreateRequest struct {
 type ImportRecordsRequest struct {
 	Type    string                   `json:"type" binding:"required"`
 	Records []map[string]interface{} `json:"records" binding:"required,min=1,max=100"`
+	// 唯一键冲突策略：reject（默认）拒绝重复记录，upsert 覆盖本人创建的已有记录
+	OnConflict string `json:"on_conflict" binding:"omitempty,oneof=reject upsert"`
 }
 
 // BatchUpdateRecordStatusRequest 批量更新记录状态请求
 type BatchUpdateRecordStatusRequest struct {
 	RecordIDs []uint `json:"record_ids" binding:"required"`
-	Status    string `json:"status" binding:"required,oneof=draft published archived"`
+	Status    string `json:"status" binding:"required,max=20"`
 }
 
 // BatchDeleteRecordsRequest 批量删除记录请求
@@ -77,18 +95,61 @@ type RecordResponse struct {
 	Version   int                    `json:"version"`
 	CreatedAt string                 `json:"created_at"`
 	UpdatedAt string                 `json:"updated_at"`
+	ReviewerID  *uint                  `json:"reviewer_id,omitempty"`
+	PublishAt   *time.Time             `json:"publish_at,omitempty"`
+	ExpireAt    *time.Time             `json:"expire_at,omitempty"`
+	Links       []LinkResponse         `json:"links,omitempty"`
+	Attachments []RecordFileResponse   `json:"attachments,omitempty"`
+	Lock        *RecordLockResponse    `json:"lock,omitempty"`
+	CommentCount int64                 `json:"comment_count"`
 }
 
 // RecordListQuery 记录列表查询参数
 type RecordListQuery struct {
-	Type      string `form:"type"`
-	Search    string `form:"search"`
-	Tags      string `form:"tags"`
-	CreatedBy uint   `form:"created_by"`
+	Type      string `form:"type" json:"type"`
+	Search    string `form:"search" json:"search"`
+	Tags      string `form:"tags" json:"tags"`
+	CreatedBy uint   `form:"created_by" json:"created_by"`
 	Page      int    `form:"page,default=1"`
 	PageSize  int    `form:"page_size,default=20"`
 	SortBy    string `form:"sort_by,default=created_at"`
 	SortOrder string `form:"sort_order,default=desc"`
+	// 游标分页：pagination=cursor 或携带 cursor 时启用，不再计算总数
+	Pagination string `form:"pagination"`
+	Cursor     string `form:"cursor"`
+	// 仅显示已到发布时间且未下线的记录（自己创建或待自己审核的记录不受影响）
+	VisibleOnly bool `form:"visible_only" json:"visible_only"`
+}
+
+// recordSortFields 记录列表允许的排序字段
+var recordSortFields = map[string]CursorField{
+	"id":         {Column: "records.id", Kind: CursorKindInt},
+	"created_at": {Column: "records.created_at", Kind: CursorKindTime},
+	"updated_at": {Column: "records.updated_at", Kind: CursorKindTime},
+	"title":      {Column: "records.title", Kind: CursorKindString},
+	"type":       {Column: "records.type", Kind: CursorKindString},
+	"status":     {Column: "records.status", Kind: CursorKindString},
+	"version":    {Column: "records.version", Kind: CursorKindInt},
+}
+
+// recordSortValue 获取记录在排序字段上的值
+func recordSortValue(record *models.Record, sortBy string) interface{} {
+	switch sortBy {
+	case "id":
+		return record.ID
+	case "updated_at":
+		return record.UpdatedAt
+	case "title":
+		return record.Title
+	case "type":
+		return record.Type
+	case "status":
+		return record.Status
+	case "version":
+		return record.Version
+	default:
+		return record.CreatedAt
+	}
 }
 
 // RecordListResponse 记录列表响应
@@ -98,67 +159,53 @@ type RecordListResponse struct {
 	Page       int              `json:"page"`
 	PageSize   int              `json:"page_size"`
 	TotalPages int              `json:"total_pages"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+	PrevCursor string           `json:"prev_cursor,omitempty"`
 }
 
 // GetRecords 获取记录列表
 func (s *RecordService) GetRecords(query *RecordListQuery, userID uint, hasAllPermission bool) (*RecordListResponse, error) {
-	db := s.db.Model(&models.Record{}).Preload("Creator")
-
-	// 权限过滤：如果没有查看所有记录的权限，只能查看自己的记录
-	if !hasAllPermission {
-		db = db.Where("created_by = ?", userID)
-	}
-
-	// 类型过滤
-	if query.Type != "" {
-		db = db.Where("type = ?", query.Type)
-	}
-
-	// 创建者过滤
-	if query.CreatedBy > 0 {
-		db = db.Where("created_by = ?", query.CreatedBy)
+	sortBy, sortField, err := ResolveSortField(recordSortFields, query.SortBy, "created_at")
+	if err != nil {
+		return nil, err
 	}
-
-	// 搜索过滤
-	if query.Search != "" {
-		searchTerm := "%" + query.Search + "%"
-		db = db.Where("title LIKE ? OR content LIKE ?", searchTerm, searchTerm)
+	desc := query.SortOrder == "desc"
+	if query.PageSize <= 0 {
+		query.PageSize = 20
 	}
 
-	// 标签过滤
-	if query.Tags != "" {
-		tags := strings.Split(query.Tags, ",")
-		for _, tag := range tags {
-			tag = strings.TrimSpace(tag)
-			if tag != "" {
-				db = db.Where("tags LIKE ?", "%"+tag+"%")
-			}
-		}
-	}
+	db := applyRecordListFilter(s.db.Model(&models.Record{}).Preload("Creator"), query, userID, hasAllPermission)
 
-	// 获取总数
+	var records []models.Record
 	var total int64
-	if err := db.Count(&total).Error; err != nil {
-		return nil, fmt.Errorf("获取记录总数失败: %w", err)
-	}
-
-	// 排序
-	orderBy := query.SortBy
-	if query.SortOrder == "desc" {
-		orderBy += " DESC"
+	var nextCursor, prevCursor string
+	if UsesCursorPagination(query.Pagination, query.Cursor) {
+		pager, err := NewKeysetPager(sortBy, sortField, "records.id", desc, query.Cursor, query.PageSize)
+		if err != nil {
+			return nil, err
+		}
+		if err := pager.Apply(db).Find(&records).Error; err != nil {
+			return nil, fmt.Errorf("获取记录列表失败: %w", err)
+		}
+		records, nextCursor, prevCursor = PaginateKeyset(pager, records, func(record *models.Record) (interface{}, uint) {
+			return recordSortValue(record, sortBy), record.ID
+		})
 	} else {
-		orderBy += " ASC"
-	}
-	db = db.Order(orderBy)
+		if query.Page <= 0 {
+			query.Page = 1
+		}
 
-	// 分页
-	offset := (query.Page - 1) * query.PageSize
-	db = db.Offset(offset).Limit(query.PageSize)
+		// 获取总数
+		if err := db.Count(&total).Error; err != nil {
+			return nil, fmt.Errorf("获取记录总数失败: %w", err)
+		}
 
-	// 查询记录
-	var records []models.Record
-	if err := db.Find(&records).Error; err != nil {
-		return nil, fmt.Errorf("获取记录列表失败: %w", err)
+		// 排序与分页
+		offset := (query.Page - 1) * query.PageSize
+		db = OrderWithTieBreaker(db, sortField, "records.id", desc).Offset(offset).Limit(query.PageSize)
+		if err := db.Find(&records).Error; err != nil {
+			return nil, fmt.Errorf("获取记录列表失败: %w", err)
+		}
 	}
 
 	// 转换响应
@@ -169,15 +216,32 @@ func (s *RecordService) GetRecords(query *RecordListQuery, userID uint, hasAllPe
 			Type:      record.Type,
 			Title:     record.Title,
 			Content:   record.Content,
-			Tags:      []string(record.Tags),
-			CreatedBy: record.CreatedBy,
-			Creator:   record.Creator.Username,
-			Version:   record.Version,
-			CreatedAt: record.CreatedAt.Format("2006-01-02 15:04:05"),
-			UpdatedAt: record.UpdatedAt.Format("2006-01-02 15:04:05"),
+			Tags:       []string(record.Tags),
+			Status:     record.Status,
+			CreatedBy:  record.CreatedBy,
+			Creator:    record.Creator.Username,
+			Version:    record.Version,
+			CreatedAt:  record.CreatedAt.Format("2006-01-02 15:04:05"),
+			UpdatedAt:  record.UpdatedAt.Format("2006-01-02 15:04:05"),
+			ReviewerID: record.ReviewerID,
+			PublishAt:  record.PublishAt,
+			ExpireAt:   record.ExpireAt,
 		}
 	}
 
+	// 评论数
+	recordIDs := make([]uint, len(records))
+	for i, record := range records {
+		recordIDs[i] = record.ID
+	}
+	commentCounts, err := countRecordComments(s.db, recordIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range recordResponses {
+		recordResponses[i].CommentCount = commentCounts[recordResponses[i].ID]
+	}
+
 	// 计算总页数
 	totalPages := int((total + int64(query.PageSize) - 1) / int64(query.PageSize))
 
@@ -187,17 +251,57 @@ func (s *RecordService) GetRecords(query *RecordListQuery, userID uint, hasAllPe
 		Page:       query.Page,
 		PageSize:   query.PageSize,
 		TotalPages: totalPages,
+		NextCursor: nextCursor,
+		PrevCursor: prevCursor,
 	}, nil
 }
 
+// applyRecordListFilter 按列表筛选条件与权限范围过滤记录
+func applyRecordListFilter(db *gorm.DB, query *RecordListQuery, userID uint, hasAllPermission bool) *gorm.DB {
+	// 权限过滤：如果没有查看所有记录的权限，只能查看自己的记录
+	if !hasAllPermission {
+		db = db.Where("created_by = ?", userID)
+	}
+
+	// 类型过滤
+	if query.Type != "" {
+		db = db.Where("type = ?", query.Type)
+	}
+
+	// 创建者过滤
+	if query.CreatedBy > 0 {
+		db = db.Where("created_by = ?", query.CreatedBy)
+	}
+
+	// 搜索过滤
+	if query.Search != "" {
+		searchTerm := "%" + query.Search + "%"
+		db = db.Where("title LIKE ? OR content LIKE ?", searchTerm, searchTerm)
+	}
+
+	// 标签过滤（精确匹配，多个标签需同时具备）
+	if query.Tags != "" {
+		db = FilterByTags(db, models.TagEntityRecord, "records.id", query.Tags)
+	}
+
+	// 隐藏未到发布时间和已过下线时间的记录，与 RecordVisibleTo 保持一致
+	if query.VisibleOnly {
+		now := time.Now()
+		db = db.Where("created_by = ? OR reviewer_id = ? OR ((publish_at IS NULL OR publish_at <= ?) AND (expire_at IS NULL OR expire_at > ?))",
+			userID, userID, now, now)
+	}
+
+	return db
+}
+
 // GetRecordByID 根据ID获取记录
 func (s *RecordService) GetRecordByID(id uint, userID uint, hasAllPermission bool) (*RecordResponse, error) {
 	var record models.Record
 	query := s.db.Preload("Creator")
 
-	// 权限过滤
+	// 权限过滤（审核人可查看待其审核的记录）
 	if !hasAllPermission {
-		query = query.Where("created_by = ?", userID)
+		query = query.Where("created_by = ? OR reviewer_id = ?", userID, userID)
 	}
 
 	if err := query.First(&record, id).Error; err != nil {
@@ -207,43 +311,129 @@ func (s *RecordService) GetRecordByID(id uint, userID uint, hasAllPermission boo
 		return nil, fmt.Errorf("获取记录失败: %w", err)
 	}
 
+	attachments, err := s.loadRecordFiles(record.ID)
+	if err != nil {
+		return nil, err
+	}
+	lock, err := s.GetRecordLock(record.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	commentCounts, err := countRecordComments(s.db, []uint{record.ID})
+	if err != nil {
+		return nil, err
+	}
+
 	return &RecordResponse{
-		ID:        record.ID,
-		Type:      record.Type,
-		Title:     record.Title,
-		Content:   record.Content,
-		Tags:      []string(record.Tags),
-		CreatedBy: record.CreatedBy,
-		Creator:   record.Creator.Username,
-		Version:   record.Version,
-		CreatedAt: record.CreatedAt.Format("2006-01-02 15:04:05"),
-		UpdatedAt: record.UpdatedAt.Format("2006-01-02 15:04:05"),
+		ID:          record.ID,
+		Type:        record.Type,
+		Title:       record.Title,
+		Content:     record.Content,
+		Tags:        []string(record.Tags),
+		Status:      record.Status,
+		CreatedBy:   record.CreatedBy,
+		Creator:     record.Creator.Username,
+		Version:     record.Version,
+		CreatedAt:   record.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:   record.UpdatedAt.Format("2006-01-02 15:04:05"),
+		ReviewerID:  record.ReviewerID,
+		PublishAt:   record.PublishAt,
+		ExpireAt:    record.ExpireAt,
+		Attachments: attachments,
+		Lock:        lock,
+		CommentCount: commentCounts[record.ID],
 	}, nil
 }
 
-// CreateRecord 创建记录
+// CreateRecord 创建记录，与已有记录唯一键重复时拒绝
 func (s *RecordService) CreateRecord(req *CreateRecordRequest, userID uint, ipAddress, userAgent string) (*RecordResponse, error) {
+	return s.createRecord(req, DuplicatePolicyReject, userID, ipAddress, userAgent)
+}
+
+// createRecord 创建记录，按唯一键冲突策略拒绝或覆盖重复记录
+func (s *RecordService) createRecord(req *CreateRecordRequest, policy string, userID uint, ipAddress, userAgent string) (*RecordResponse, error) {
+	if req.TemplateID != nil {
+		if err := applyRecordTemplate(s.db, req, userID); err != nil {
+			return nil, err
+		}
+		if req.Title == "" {
+			return nil, fmt.Errorf("记录标题不能为空")
+		}
+	}
+
 	// 验证记录类型和数据
 	if err := s.recordTypeService.ValidateRecordData(req.Type, req.Content); err != nil {
 		return nil, fmt.Errorf("数据验证失败: %w", err)
 	}
 
+	// 验证文件字段引用
+	fieldFiles, err := s.validateFieldFiles(req.Type, req.Content, 0, userID)
+	if err != nil {
+		return nil, fmt.Errorf("数据验证失败: %w", err)
+	}
+	if err := validateRecordSchedule(req.PublishAt, req.ExpireAt); err != nil {
+		return nil, err
+	}
+	uniqueKey, err := recordUniqueKey(s.db, req.Type, req.Content)
+	if err != nil {
+		return nil, err
+	}
+
 	record := models.Record{
 		Type:      req.Type,
 		Title:     req.Title,
 		Content:   models.JSONB(req.Content),
 		Tags:      models.StringSlice(req.Tags),
+		Status:    s.workflowService.InitialStatus(req.Type),
+		PublishAt: req.PublishAt,
+		ExpireAt:  req.ExpireAt,
+		UniqueKey: uniqueKey,
 		CreatedBy: userID,
 		Version:   1,
 	}
 
-	if err := s.db.Create(&record).Error; err != nil {
-		return nil, fmt.Errorf("创建记录失败: %w", err)
+	var upserted *models.Record
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		existing, err := findDuplicateRecord(tx, req.Type, uniqueKey, 0)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			if policy != DuplicatePolicyUpsert {
+				return duplicateRecordError(existing)
+			}
+			old, err := upsertRecord(tx, existing, req.Title, req.Content, req.Tags, userID)
+			if err != nil {
+				return err
+			}
+			record, upserted = *existing, old
+			return syncFieldFiles(tx, record.ID, fieldFiles, userID)
+		}
+
+		if err := tx.Create(&record).Error; err != nil {
+			return fmt.Errorf("创建记录失败: %w", err)
+		}
+		tags, err := SyncEntityTags(tx, models.TagEntityRecord, record.ID, req.Tags, userID)
+		if err != nil {
+			return err
+		}
+		record.Tags = tags
+		if err := AddWatchers(tx, models.WatchEntityRecord, record.ID, models.WatchReasonCreator, userID); err != nil {
+			return err
+		}
+		return syncFieldFiles(tx, record.ID, fieldFiles, userID)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// 记录审计日志
 	if s.auditService != nil {
-		s.auditService.LogRecordOperation(userID, "CREATE", record.ID, nil, &record, ipAddress, userAgent)
+		if upserted != nil {
+			s.auditService.LogRecordOperation(userID, "UPSERT", record.ID, upserted, &record, ipAddress, userAgent)
+		} else {
+			s.auditService.LogRecordOperation(userID, "CREATE", record.ID, nil, &record, ipAddress, userAgent)
+		}
 	}
 
 	// 重新获取记录（包含关联数据）
@@ -271,11 +461,22 @@ func (s *RecordService) UpdateRecord(id uint, req *UpdateRecordRequest, userID u
 	oldRecord := record
 
 	// 验证数据（如果有内容更新）
+	var fieldFiles map[string][]uint
 	if req.Content != nil {
 		if err := s.recordTypeService.ValidateRecordData(record.Type, req.Content); err != nil {
 			return nil, fmt.Errorf("数据验证失败: %w", err)
 		}
+		files, err := s.validateFieldFiles(record.Type, req.Content, record.ID, userID)
+		if err != nil {
+			return nil, fmt.Errorf("数据验证失败: %w", err)
+		}
+		fieldFiles = files
 		record.Content = models.JSONB(req.Content)
+		uniqueKey, err := recordUniqueKey(s.db, record.Type, req.Content)
+		if err != nil {
+			return nil, err
+		}
+		record.UniqueKey = uniqueKey
 	}
 
 	// 更新字段
@@ -290,14 +491,49 @@ func (s *RecordService) UpdateRecord(id uint, req *UpdateRecordRequest, userID u
 	// 增加版本号
 	record.Version++
 
-	if err := s.db.Save(&record).Error; err != nil {
-		return nil, fmt.Errorf("更新记录失败: %w", err)
+	var brokenLock *models.RecordLock
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 他人持有编辑锁时拒绝写入
+		lock, err := checkRecordLock(tx, record.ID, userID, req.ForceBreakLock)
+		if err != nil {
+			return err
+		}
+		brokenLock = lock
+		// 唯一键变化时校验重复，启用唯一键前已存在的重复记录仍可正常编辑
+		if record.UniqueKey != oldRecord.UniqueKey {
+			existing, err := findDuplicateRecord(tx, record.Type, record.UniqueKey, record.ID)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				return duplicateRecordError(existing)
+			}
+		}
+		if err := tx.Save(&record).Error; err != nil {
+			return fmt.Errorf("更新记录失败: %w", err)
+		}
+		if req.Tags != nil {
+			tags, err := SyncEntityTags(tx, models.TagEntityRecord, record.ID, req.Tags, userID)
+			if err != nil {
+				return err
+			}
+			record.Tags = tags
+		}
+		return syncFieldFiles(tx, record.ID, fieldFiles, userID)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// 记录审计日志
+	if brokenLock != nil {
+		s.logRecordLockBreak(brokenLock, userID, ipAddress, userAgent)
+	}
 	if s.auditService != nil {
 		s.auditService.LogRecordOperation(userID, "UPDATE", record.ID, &oldRecord, &record, ipAddress, userAgent)
 	}
+	s.watchService.NotifyWatchers(models.WatchEntityRecord, record.ID, userID, "记录更新通知",
+		fmt.Sprintf("记录「%s」已更新（版本 %d）", record.Title, record.Version))
 
 	// 重新获取记录
 	return s.GetRecordByID(record.ID, userID, hasAllPermission)
@@ -320,6 +556,7 @@ func (s *RecordService) DeleteRecord(id uint, userID uint, hasAllPermission bool
 		return fmt.Errorf("获取记录失败: %w", err)
 	}
 
+	// 软删除只隐藏记录，附件与关联保留到从回收站彻底删除时再清理，以便恢复
 	if err := s.db.Delete(&record).Error; err != nil {
 		return fmt.Errorf("删除记录失败: %w", err)
 	}
@@ -328,6 +565,8 @@ func (s *RecordService) DeleteRecord(id uint, userID uint, hasAllPermission bool
 	if s.auditService != nil {
 		s.auditService.LogRecordOperation(userID, "DELETE", record.ID, &record, nil, ipAddress, userAgent)
 	}
+	s.watchService.NotifyWatchers(models.WatchEntityRecord, record.ID, userID, "记录删除通知",
+		fmt.Sprintf("记录「%s」已被删除", record.Title))
 
 	return nil
 }
@@ -336,6 +575,7 @@ func (s *RecordService) DeleteRecord(id uint, userID uint, hasAllPermission bool
 func (s *RecordService) BatchCreateRecords(req *BatchCreateRequest, userID uint, ipAddress, userAgent string) ([]RecordResponse, error) {
 	var results []RecordResponse
 	var errors []string
+	seenKeys := map[string]int{} // 类型+唯一键 -> 批次内首次出现的序号
 
 	// 开始事务
 	tx := s.db.Begin()
@@ -352,11 +592,43 @@ func (s *RecordService) BatchCreateRecords(req *BatchCreateRequest, userID uint,
 			continue
 		}
 
+		// 验证文件字段引用
+		fieldFiles, err := s.validateFieldFiles(recordReq.Type, recordReq.Content, 0, userID)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("记录 %d: %v", i+1, err))
+			continue
+		}
+
+		// 唯一键重复校验（批次内与已有记录）
+		uniqueKey, err := recordUniqueKey(tx, recordReq.Type, recordReq.Content)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("记录 %d: %v", i+1, err))
+			continue
+		}
+		if uniqueKey != "" {
+			if first, ok := seenKeys[recordReq.Type+"\x00"+uniqueKey]; ok {
+				errors = append(errors, fmt.Sprintf("记录 %d: 与本批次记录 %d 重复", i+1, first))
+				continue
+			}
+			seenKeys[recordReq.Type+"\x00"+uniqueKey] = i + 1
+		}
+		existing, err := findDuplicateRecord(tx, recordReq.Type, uniqueKey, 0)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("记录 %d: %v", i+1, err))
+			continue
+		}
+		if existing != nil {
+			errors = append(errors, fmt.Sprintf("记录 %d: %v", i+1, duplicateRecordError(existing)))
+			continue
+		}
+
 		record := models.Record{
 			Type:      recordReq.Type,
 			Title:     recordReq.Title,
 			Content:   models.JSONB(recordReq.Content),
 			Tags:      recordReq.Tags,
+			Status:    s.workflowService.InitialStatus(recordReq.Type),
+			UniqueKey: uniqueKey,
 			CreatedBy: userID,
 			Version:   1,
 		}
@@ -365,6 +637,20 @@ func (s *RecordService) BatchCreateRecords(req *BatchCreateRequest, userID uint,
 			errors = append(errors, fmt.Sprintf("记录 %d: 创建失败 - %v", i+1, err))
 			continue
 		}
+		tags, err := SyncEntityTags(tx, models.TagEntityRecord, record.ID, recordReq.Tags, userID)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("记录 %d: %v", i+1, err))
+			continue
+		}
+		record.Tags = tags
+		if err := AddWatchers(tx, models.WatchEntityRecord, record.ID, models.WatchReasonCreator, userID); err != nil {
+			errors = append(errors, fmt.Sprintf("记录 %d: %v", i+1, err))
+			continue
+		}
+		if err := syncFieldFiles(tx, record.ID, fieldFiles, userID); err != nil {
+			errors = append(errors, fmt.Sprintf("记录 %d: %v", i+1, err))
+			continue
+		}
 
 		// 记录审计日志
 		if s.auditService != nil {
@@ -381,6 +667,7 @@ func (s *RecordService) BatchCreateRecords(req *BatchCreateRequest, userID uint,
 			Title:     record.Title,
 			Content:   recordReq.Content,
 			Tags:      []string(record.Tags),
+			Status:    record.Status,
 			CreatedBy: record.CreatedBy,
 			Creator:   user.Username,
 			Version:   record.Version,
@@ -475,7 +762,7 @@ func (s *RecordService) ImportRecords(req *ImportRecordsRequest, userID uint, ip
 		var batchErrors []string
 		
 		for retry := 0; retry < maxRetries; retry++ {
-			batchResults, batchErrors = s.importRecordBatchOptimized(batch, req.Type, userID, ipAddress, userAgent, i+1)
+			batchResults, batchErrors = s.importRecordBatchOptimized(batch, req.Type, req.OnConflict, userID, ipAddress, userAgent, i+1)
 			
 			// 如果成功或者不是数据库锁定错误，跳出重试
 			if len(batchErrors) == 0 || !s.isDatabaseBusyError(batchErrors) {
@@ -532,16 +819,24 @@ func max(a, b int) int {
 }
 
 // importRecordBatchOptimized 优化的批次导入方法
-func (s *RecordService) importRecordBatchOptimized(records []map[string]interface{}, recordType string, userID uint, ipAddress, userAgent string, startIndex int) ([]RecordResponse, []string) {
+func (s *RecordService) importRecordBatchOptimized(records []map[string]interface{}, recordType string, policy string, userID uint, ipAddress, userAgent string, startIndex int) ([]RecordResponse, []string) {
 	var results []RecordResponse
 	var errors []string
 
+	rt, err := s.recordTypeService.GetRecordTypeByName(recordType)
+	if err != nil {
+		return results, []string{fmt.Sprintf("批次导入失败: %v", err)}
+	}
+
 	// 使用更短的超时时间，适合SQLite
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
 	// 预处理和验证所有记录
 	validRecords := make([]models.Record, 0, len(records))
+	rowNumbers := make([]int, 0, len(records))               // validRecords 对应的原始行号
+	fieldFiles := make([]map[string][]uint, 0, len(records)) // validRecords 对应的文件字段引用
+	seenKeys := map[string]int{}                             // 唯一键 -> validRecords 下标
 	for i, recordData := range records {
 		// 提取标题
 		title, ok := recordData["title"].(string)
@@ -564,6 +859,13 @@ func (s *RecordService) importRecordBatchOptimized(records []map[string]interfac
 			continue
 		}
 
+		// 验证文件字段引用
+		files, err := s.validateFieldFiles(recordType, content, 0, userID)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("记录 %d: %v", startIndex+i, err))
+			continue
+		}
+
 		// 提取标签
 		var tags []string
 		if tagsData, exists := recordData["tags"]; exists && tagsData != nil {
@@ -581,12 +883,25 @@ func (s *RecordService) importRecordBatchOptimized(records []map[string]interfac
 			Title:     title,
 			Content:   models.JSONB(content),
 			Tags:      tags,
+			UniqueKey: computeRecordUniqueKey(rt.UniqueKeys, content),
 			CreatedBy: userID,
-			Status:    "draft",
+			Status:    s.workflowService.InitialStatus(recordType),
 			Version:   1,
 		}
 
+		// 批次内唯一键重复：拒绝策略报错，覆盖策略以后出现的行为准
+		if index, ok := seenKeys[record.UniqueKey]; ok && record.UniqueKey != "" {
+			if policy != DuplicatePolicyUpsert {
+				errors = append(errors, fmt.Sprintf("记录 %d: 与本批次记录 %d 重复", startIndex+i, rowNumbers[index]))
+				continue
+			}
+			validRecords[index], rowNumbers[index], fieldFiles[index] = record, startIndex+i, files
+			continue
+		}
+		seenKeys[record.UniqueKey] = len(validRecords)
 		validRecords = append(validRecords, record)
+		rowNumbers = append(rowNumbers, startIndex+i)
+		fieldFiles = append(fieldFiles, files)
 	}
 
 	// 如果没有有效记录，直接返回
@@ -608,11 +923,64 @@ func (s *RecordService) importRecordBatchOptimized(records []map[string]interfac
 		}
 	}()
 
+	// 与已有记录唯一键重复的行按策略拒绝或覆盖
+	newRecords := make([]models.Record, 0, len(validRecords))
+	newFiles := make([]map[string][]uint, 0, len(validRecords))
+	var upserted []models.Record
+	var upsertedOld []*models.Record
+	for i := range validRecords {
+		record := validRecords[i]
+		existing, err := findDuplicateRecord(tx, recordType, record.UniqueKey, 0)
+		if err != nil {
+			tx.Rollback()
+			return results, append(errors, fmt.Sprintf("批次导入失败: %v", err))
+		}
+		if existing == nil {
+			newRecords = append(newRecords, record)
+			newFiles = append(newFiles, fieldFiles[i])
+			continue
+		}
+		if policy != DuplicatePolicyUpsert {
+			errors = append(errors, fmt.Sprintf("记录 %d: %v", rowNumbers[i], duplicateRecordError(existing)))
+			continue
+		}
+		old, err := upsertRecord(tx, existing, record.Title, record.Content, record.Tags, userID)
+		if err == nil {
+			err = syncFieldFiles(tx, existing.ID, fieldFiles[i], userID)
+		}
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("记录 %d: %v", rowNumbers[i], err))
+			continue
+		}
+		upserted = append(upserted, *existing)
+		upsertedOld = append(upsertedOld, old)
+	}
+	validRecords = newRecords
+
 	// 批量创建记录
-	if err := tx.Create(&validRecords).Error; err != nil {
-		tx.Rollback()
-		errors = append(errors, fmt.Sprintf("批次导入失败: %v", err))
-		return results, errors
+	if len(validRecords) > 0 {
+		if err := tx.Create(&validRecords).Error; err != nil {
+			tx.Rollback()
+			errors = append(errors, fmt.Sprintf("批次导入失败: %v", err))
+			return results, errors
+		}
+	}
+
+	// 建立标签与附件关联，创建者自动关注
+	for i := range validRecords {
+		tags, err := SyncEntityTags(tx, models.TagEntityRecord, validRecords[i].ID, validRecords[i].Tags, userID)
+		if err == nil {
+			err = AddWatchers(tx, models.WatchEntityRecord, validRecords[i].ID, models.WatchReasonCreator, userID)
+		}
+		if err == nil {
+			err = syncFieldFiles(tx, validRecords[i].ID, newFiles[i], userID)
+		}
+		if err != nil {
+			tx.Rollback()
+			errors = append(errors, fmt.Sprintf("批次导入失败: %v", err))
+			return results, errors
+		}
+		validRecords[i].Tags = tags
 	}
 
 	// 提交事务
@@ -621,8 +989,14 @@ func (s *RecordService) importRecordBatchOptimized(records []map[string]interfac
 		return results, errors
 	}
 
+	if s.auditService != nil {
+		for i := range upserted {
+			s.auditService.LogRecordOperation(userID, "UPSERT", upserted[i].ID, upsertedOld[i], &upserted[i], ipAddress, userAgent)
+		}
+	}
+
 	// 构建返回结果
-	for _, record := range validRecords {
+	for _, record := range append(validRecords, upserted...) {
 		results = append(results, RecordResponse{
 			ID:        record.ID,
 			Type:      record.Type,
@@ -695,10 +1069,18 @@ func (s *RecordService) importRecordBatch(records []map[string]interface{}, reco
 				continue
 			}
 
+			// 验证文件字段引用
+			files, err := s.validateFieldFiles(recordType, content, 0, userID)
+			if err != nil {
+				errors = append(errors, fmt.Sprintf("记录 %d: %v", i+1, err))
+				continue
+			}
+
 			// 添加处理后的数据
 			processedRecord := make(map[string]interface{})
 			processedRecord["title"] = title
 			processedRecord["content"] = content
+			processedRecord["files"] = files
 			processedRecord["tags"] = recordData["tags"]
 			processedRecord["index"] = i + 1
 			validRecords = append(validRecords, processedRecord)
@@ -708,6 +1090,7 @@ func (s *RecordService) importRecordBatch(records []map[string]interface{}, reco
 		for _, processedRecord := range validRecords {
 			title := processedRecord["title"].(string)
 			content := processedRecord["content"].(map[string]interface{})
+			files := processedRecord["files"].(map[string][]uint)
 			index := processedRecord["index"].(int)
 
 			// 提取标签
@@ -727,6 +1110,7 @@ func (s *RecordService) importRecordBatch(records []map[string]interface{}, reco
 				Title:     title,
 				Content:   models.JSONB(content),
 				Tags:      tags,
+				Status:    s.workflowService.InitialStatus(recordType),
 				CreatedBy: userID,
 				Version:   1,
 			}
@@ -735,6 +1119,20 @@ func (s *RecordService) importRecordBatch(records []map[string]interface{}, reco
 				errors = append(errors, fmt.Sprintf("记录 %d: 创建失败 - %v", index, err))
 				continue
 			}
+			synced, err := SyncEntityTags(tx, models.TagEntityRecord, record.ID, tags, userID)
+			if err != nil {
+				errors = append(errors, fmt.Sprintf("记录 %d: %v", index, err))
+				continue
+			}
+			record.Tags = synced
+			if err := AddWatchers(tx, models.WatchEntityRecord, record.ID, models.WatchReasonCreator, userID); err != nil {
+				errors = append(errors, fmt.Sprintf("记录 %d: %v", index, err))
+				continue
+			}
+			if err := syncFieldFiles(tx, record.ID, files, userID); err != nil {
+				errors = append(errors, fmt.Sprintf("记录 %d: %v", index, err))
+				continue
+			}
 
 			// 异步记录审计日志，避免阻塞事务
 			go func(recordID uint) {
@@ -749,6 +1147,7 @@ func (s *RecordService) importRecordBatch(records []map[string]interface{}, reco
 				Title:     record.Title,
 				Content:   content,
 				Tags:      []string(record.Tags),
+				Status:    record.Status,
 				CreatedBy: record.CreatedBy,
 				Creator:   user.Username,
 				Version:   record.Version,
@@ -792,60 +1191,15 @@ func (s *RecordService) GetRecordOwner(recordID uint) (uint, error) {
 	return record.CreatedBy, nil
 }
 
-// BatchUpdateRecordStatus 批量更新记录状态
-func (s *RecordService) BatchUpdateRecordStatus(req *BatchUpdateRecordStatusRequest, userID uint) error {
+// BatchUpdateRecordStatus 批量更新记录状态，每条记录都按其类型的流程校验并记录历史
+func (s *RecordService) BatchUpdateRecordStatus(req *BatchUpdateRecordStatusRequest, userID uint) (*BatchTransitionResult, error) {
 	// 验证请求参数
 	if len(req.RecordIDs) == 0 {
-		return fmt.Errorf("无效的记录ID")
-	}
-
-	// 检查记录是否存在并且用户有权限更新
-	var existingRecords []models.Record
-	query := s.db.Where("id IN ?", req.RecordIDs)
-
-	// 如果不是管理员，只能更新自己的记录
-	// 这里简化处理，实际应该通过权限服务检查
-	query = query.Where("created_by = ?", userID)
-
-	if err := query.Find(&existingRecords).Error; err != nil {
-		return fmt.Errorf("查询记录失败: %w", err)
-	}
-
-	if len(existingRecords) == 0 {
-		return fmt.Errorf("没有找到可更新的记录，请检查记录ID和权限")
-	}
-
-	// 获取要更新的记录ID
-	var recordIDsToUpdate []uint
-	for _, record := range existingRecords {
-		recordIDsToUpdate = append(recordIDsToUpdate, record.ID)
-	}
-
-	// 使用事务进行批量更新
-	err := s.db.Transaction(func(tx *gorm.DB) error {
-		// 执行批量更新
-		result := tx.Model(&models.Record{}).Where("id IN ?", recordIDsToUpdate).Update("status", req.Status)
-		if result.Error != nil {
-			return fmt.Errorf("批量更新记录状态失败: %w", result.Error)
-		}
-
-		return nil
-	})
-
-	if err != nil {
-		return err
+		return nil, fmt.Errorf("无效的记录ID")
 	}
 
-	// 异步记录审计日志，避免阻塞主流程
-	if s.auditService != nil {
-		go func() {
-			for _, recordID := range recordIDsToUpdate {
-				s.auditService.LogRecordOperation(userID, "BATCH_UPDATE_STATUS", recordID, nil, nil, "", "")
-			}
-		}()
-	}
-
-	return nil
+	// 这里简化处理，只能更新自己创建或由自己审核的记录
+	return s.workflowService.BatchTransition(req.RecordIDs, req.Status, userID, false)
 }
 
 // BatchDeleteRecords 批量删除记录
@@ -877,19 +1231,9 @@ func (s *RecordService) BatchDeleteRecords(req *BatchDeleteRecordsRequest, userI
 		recordIDsToDelete = append(recordIDsToDelete, record.ID)
 	}
 
-	// 使用事务进行批量删除
-	err := s.db.Transaction(func(tx *gorm.DB) error {
-		// 执行软删除
-		result := tx.Where("id IN ?", recordIDsToDelete).Delete(&models.Record{})
-		if result.Error != nil {
-			return fmt.Errorf("批量删除记录失败: %w", result.Error)
-		}
-
-		return nil
-	})
-
-	if err != nil {
-		return err
+	// 执行软删除，附件与关联保留到彻底删除时再清理
+	if err := s.db.Where("id IN ?", recordIDsToDelete).Delete(&models.Record{}).Error; err != nil {
+		return fmt.Errorf("批量删除记录失败: %w", err)
 	}
 
 	// 异步记录审计日志，避免阻塞主流程
@@ -931,6 +1275,8 @@ func (s *RecordService) GetRecordsByType(recordType string, userID uint, hasAllP
 			Version:   record.Version,
 			CreatedAt: record.CreatedAt.Format("2006-01-02 15:04:05"),
 			UpdatedAt: record.UpdatedAt.Format("2006-01-02 15:04:05"),
+			PublishAt: record.PublishAt,
+			ExpireAt:  record.ExpireAt,
 		}
 	}
 
@@ -35,7 +35,16 @@ func (suite *RecordServiceTestSuite) SetupSuite() {
 		&models.Permission{},
 		&models.RecordType{},
 		&models.Record{},
+		&models.RecordComment{},
+		&models.RecordCommentMention{},
+		&models.Tag{},
+		&models.EntityTag{},
+		&models.Watcher{},
+		&models.RecordLock{},
 		&models.AuditLog{},
+		&models.File{},
+		&models.RecordFile{},
+		&models.EntityLink{},
 	)
 	suite.Require().NoError(err)
 
@@ -44,7 +53,7 @@ func (suite *RecordServiceTestSuite) SetupSuite() {
 	// 创建服务实例
 	suite.auditService = NewAuditService(db)
 	suite.recordTypeService = NewRecordTypeService(db)
-	suite.recordService = NewRecordService(db, suite.recordTypeService, suite.auditService)
+	suite.recordService = NewRecordService(db, suite.recordTypeService, suite.auditService, nil, nil)
 
 	// 创建测试用户
 	testUser := &models.User{
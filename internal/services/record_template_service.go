@@ -0,0 +1,540 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// RecordTemplateService 记录模板服务
+type RecordTemplateService struct {
+	db           *gorm.DB
+	auditService *AuditService
+}
+
+// NewRecordTemplateService 创建记录模板服务
+func NewRecordTemplateService(db *gorm.DB, auditService *AuditService) *RecordTemplateService {
+	return &RecordTemplateService{
+		db:           db,
+		auditService: auditService,
+	}
+}
+
+// RecordTemplateQuery 记录模板列表查询参数
+type RecordTemplateQuery struct {
+	Type string `form:"type"` // 记录类型名称，为空时返回全部类型
+}
+
+// CreateRecordTemplateRequest 创建记录模板请求
+type CreateRecordTemplateRequest struct {
+	RecordType   string                 `json:"record_type" binding:"required"`
+	Name         string                 `json:"name" binding:"required,max=200"`
+	Description  string                 `json:"description" binding:"max=500"`
+	TitlePattern string                 `json:"title_pattern" binding:"max=500"`
+	Content      map[string]interface{} `json:"content"`
+	Tags         []string               `json:"tags"`
+	Visibility   string                 `json:"visibility" binding:"omitempty,oneof=personal shared"`
+	RoleIDs      []uint                 `json:"role_ids"` // 共享给的角色，为空时共享给所有用户
+}
+
+// UpdateRecordTemplateRequest 更新记录模板请求，字段为 nil 时不修改
+type UpdateRecordTemplateRequest struct {
+	Name         *string                `json:"name" binding:"omitempty,min=1,max=200"`
+	Description  *string                `json:"description" binding:"omitempty,max=500"`
+	TitlePattern *string                `json:"title_pattern" binding:"omitempty,max=500"`
+	Content      map[string]interface{} `json:"content"`
+	Tags         []string               `json:"tags"`
+	Visibility   *string                `json:"visibility" binding:"omitempty,oneof=personal shared"`
+	RoleIDs      []uint                 `json:"role_ids"`
+}
+
+// RecordTemplateResponse 记录模板响应
+type RecordTemplateResponse struct {
+	ID               uint                   `json:"id"`
+	RecordTypeID     uint                   `json:"record_type_id"`
+	RecordType       string                 `json:"record_type"`
+	Name             string                 `json:"name"`
+	Description      string                 `json:"description"`
+	TitlePattern     string                 `json:"title_pattern"`
+	Content          map[string]interface{} `json:"content"`
+	Tags             []string               `json:"tags"`
+	Visibility       string                 `json:"visibility"`
+	RoleIDs          []uint                 `json:"role_ids"`
+	SchemaVersion    int                    `json:"schema_version"`
+	IsValid          bool                   `json:"is_valid"`
+	ValidationErrors []string               `json:"validation_errors"`
+	CreatedBy        uint                   `json:"created_by"`
+	Creator          string                 `json:"creator"`
+	CreatedAt        string                 `json:"created_at"`
+	UpdatedAt        string                 `json:"updated_at"`
+}
+
+// ListTemplates 获取当前用户可用的模板：本人创建的模板与共享给其角色的模板；canManage 时返回全部模板
+func (s *RecordTemplateService) ListTemplates(query *RecordTemplateQuery, userID uint, canManage bool) ([]RecordTemplateResponse, error) {
+	db := s.db.Preload("RecordType").Preload("Creator")
+	if query.Type != "" {
+		db = db.Where("record_type_id IN (?)", s.db.Model(&models.RecordType{}).Select("id").Where("name = ?", query.Type))
+	}
+	if !canManage {
+		db = templateAccessScope(db, userID)
+	}
+
+	var templates []models.RecordTemplate
+	if err := db.Order("name ASC, id ASC").Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("获取记录模板失败: %w", err)
+	}
+
+	roles, err := templateRoleIDs(s.db, templateIDs(templates))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]RecordTemplateResponse, len(templates))
+	for i := range templates {
+		result[i] = newRecordTemplateResponse(&templates[i], roles[templates[i].ID])
+	}
+	return result, nil
+}
+
+// GetTemplate 获取模板详情
+func (s *RecordTemplateService) GetTemplate(id uint, userID uint, canManage bool) (*RecordTemplateResponse, error) {
+	template, err := findUsableTemplate(s.db, id, userID, canManage)
+	if err != nil {
+		return nil, err
+	}
+	return s.templateResponse(template)
+}
+
+// CreateTemplate 创建记录模板；共享模板需要调用方校验权限
+func (s *RecordTemplateService) CreateTemplate(req *CreateRecordTemplateRequest, userID uint, ipAddress, userAgent string) (*RecordTemplateResponse, error) {
+	var recordType models.RecordType
+	if err := s.db.Where("name = ?", req.RecordType).First(&recordType).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("记录类型不存在")
+		}
+		return nil, fmt.Errorf("获取记录类型失败: %w", err)
+	}
+	if errs := validateTemplateContent(ParseSchemaFields(recordType.Schema), req.Content); len(errs) > 0 {
+		return nil, fmt.Errorf("模板内容不符合Schema: %s", strings.Join(errs, "; "))
+	}
+	if err := ValidateTagNames(req.Tags); err != nil {
+		return nil, err
+	}
+
+	template := models.RecordTemplate{
+		RecordTypeID:  recordType.ID,
+		Name:          strings.TrimSpace(req.Name),
+		Description:   req.Description,
+		TitlePattern:  req.TitlePattern,
+		Content:       models.JSONB(req.Content),
+		Tags:          models.StringSlice(req.Tags),
+		Visibility:    req.Visibility,
+		SchemaVersion: recordType.SchemaVersion,
+		IsValid:       true,
+		CreatedBy:     userID,
+	}
+	if template.Visibility == "" {
+		template.Visibility = models.TemplateVisibilityPersonal
+	}
+	if template.Name == "" {
+		return nil, fmt.Errorf("模板名称不能为空")
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&template).Error; err != nil {
+			return fmt.Errorf("创建记录模板失败: %w", err)
+		}
+		return saveTemplateRoles(tx, &template, req.RoleIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(userID, "CREATE", template.ID, nil, templateAuditValues(&template), ipAddress, userAgent)
+	return s.templateResponse(&template)
+}
+
+// UpdateTemplate 更新记录模板，只有创建者或模板管理员可以修改
+func (s *RecordTemplateService) UpdateTemplate(id uint, req *UpdateRecordTemplateRequest, userID uint, canManage bool, ipAddress, userAgent string) (*RecordTemplateResponse, error) {
+	template, err := s.findEditableTemplate(id, userID, canManage)
+	if err != nil {
+		return nil, err
+	}
+	oldValues := templateAuditValues(template)
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, fmt.Errorf("模板名称不能为空")
+		}
+		template.Name = name
+	}
+	if req.Description != nil {
+		template.Description = *req.Description
+	}
+	if req.TitlePattern != nil {
+		template.TitlePattern = *req.TitlePattern
+	}
+	if req.Tags != nil {
+		if err := ValidateTagNames(req.Tags); err != nil {
+			return nil, err
+		}
+		template.Tags = models.StringSlice(req.Tags)
+	}
+	if req.Visibility != nil {
+		template.Visibility = *req.Visibility
+	}
+	if req.Content != nil {
+		// 更新内容时按当前Schema重新校验
+		if errs := validateTemplateContent(ParseSchemaFields(template.RecordType.Schema), req.Content); len(errs) > 0 {
+			return nil, fmt.Errorf("模板内容不符合Schema: %s", strings.Join(errs, "; "))
+		}
+		template.Content = models.JSONB(req.Content)
+		template.SchemaVersion = template.RecordType.SchemaVersion
+		template.IsValid = true
+		template.ValidationErrors = nil
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("RecordType", "Creator").Save(template).Error; err != nil {
+			return fmt.Errorf("更新记录模板失败: %w", err)
+		}
+		if req.RoleIDs == nil && template.Visibility == models.TemplateVisibilityShared {
+			return nil
+		}
+		return saveTemplateRoles(tx, template, req.RoleIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(userID, "UPDATE", template.ID, oldValues, templateAuditValues(template), ipAddress, userAgent)
+	return s.templateResponse(template)
+}
+
+// DeleteTemplate 删除记录模板，只有创建者或模板管理员可以删除
+func (s *RecordTemplateService) DeleteTemplate(id uint, userID uint, canManage bool, ipAddress, userAgent string) error {
+	template, err := s.findEditableTemplate(id, userID, canManage)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("template_id = ?", template.ID).Delete(&models.RecordTemplateRole{}).Error; err != nil {
+			return fmt.Errorf("删除记录模板失败: %w", err)
+		}
+		if err := tx.Delete(&models.RecordTemplate{}, template.ID).Error; err != nil {
+			return fmt.Errorf("删除记录模板失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit(userID, "DELETE", template.ID, templateAuditValues(template), nil, ipAddress, userAgent)
+	return nil
+}
+
+// findEditableTemplate 查找当前用户可修改的模板
+func (s *RecordTemplateService) findEditableTemplate(id uint, userID uint, canManage bool) (*models.RecordTemplate, error) {
+	var template models.RecordTemplate
+	query := s.db.Preload("RecordType").Preload("Creator")
+	if !canManage {
+		query = query.Where("created_by = ?", userID)
+	}
+	if err := query.First(&template, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("模板不存在或无权修改")
+		}
+		return nil, fmt.Errorf("获取记录模板失败: %w", err)
+	}
+	return &template, nil
+}
+
+// templateResponse 构建单个模板响应
+func (s *RecordTemplateService) templateResponse(template *models.RecordTemplate) (*RecordTemplateResponse, error) {
+	if template.RecordType.ID == 0 || template.Creator.ID == 0 {
+		if err := s.db.Preload("RecordType").Preload("Creator").First(template, template.ID).Error; err != nil {
+			return nil, fmt.Errorf("获取记录模板失败: %w", err)
+		}
+	}
+	roles, err := templateRoleIDs(s.db, []uint{template.ID})
+	if err != nil {
+		return nil, err
+	}
+	response := newRecordTemplateResponse(template, roles[template.ID])
+	return &response, nil
+}
+
+// audit 记录模板审计日志
+func (s *RecordTemplateService) audit(userID uint, action string, templateID uint, oldValues, newValues map[string]interface{}, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	s.auditService.CreateAuditLog(&AuditLogRequest{
+		UserID:       userID,
+		Action:       action,
+		ResourceType: "record_template",
+		ResourceID:   templateID,
+		OldValues:    oldValues,
+		NewValues:    newValues,
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+	})
+}
+
+func templateAuditValues(template *models.RecordTemplate) map[string]interface{} {
+	return map[string]interface{}{
+		"record_type_id": template.RecordTypeID,
+		"name":           template.Name,
+		"title_pattern":  template.TitlePattern,
+		"content":        template.Content,
+		"tags":           template.Tags,
+		"visibility":     template.Visibility,
+	}
+}
+
+// newRecordTemplateResponse 转换模板响应
+func newRecordTemplateResponse(template *models.RecordTemplate, roleIDs []uint) RecordTemplateResponse {
+	if roleIDs == nil {
+		roleIDs = []uint{}
+	}
+	validationErrors := []string(template.ValidationErrors)
+	if validationErrors == nil {
+		validationErrors = []string{}
+	}
+	tags := []string(template.Tags)
+	if tags == nil {
+		tags = []string{}
+	}
+	return RecordTemplateResponse{
+		ID:               template.ID,
+		RecordTypeID:     template.RecordTypeID,
+		RecordType:       template.RecordType.Name,
+		Name:             template.Name,
+		Description:      template.Description,
+		TitlePattern:     template.TitlePattern,
+		Content:          template.Content,
+		Tags:             tags,
+		Visibility:       template.Visibility,
+		RoleIDs:          roleIDs,
+		SchemaVersion:    template.SchemaVersion,
+		IsValid:          template.IsValid,
+		ValidationErrors: validationErrors,
+		CreatedBy:        template.CreatedBy,
+		Creator:          template.Creator.Username,
+		CreatedAt:        template.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:        template.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// saveTemplateRoles 保存共享模板的角色；个人模板清空角色
+func saveTemplateRoles(tx *gorm.DB, template *models.RecordTemplate, roleIDs []uint) error {
+	if err := tx.Where("template_id = ?", template.ID).Delete(&models.RecordTemplateRole{}).Error; err != nil {
+		return fmt.Errorf("保存模板角色失败: %w", err)
+	}
+	if template.Visibility != models.TemplateVisibilityShared {
+		return nil
+	}
+
+	roleIDs = uniqueUints(roleIDs)
+	if len(roleIDs) == 0 {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&models.Role{}).Where("id IN ?", roleIDs).Count(&count).Error; err != nil {
+		return fmt.Errorf("保存模板角色失败: %w", err)
+	}
+	if count != int64(len(roleIDs)) {
+		return fmt.Errorf("角色不存在")
+	}
+	rows := make([]models.RecordTemplateRole, len(roleIDs))
+	for i, roleID := range roleIDs {
+		rows[i] = models.RecordTemplateRole{TemplateID: template.ID, RoleID: roleID}
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return fmt.Errorf("保存模板角色失败: %w", err)
+	}
+	return nil
+}
+
+// templateRoleIDs 批量获取模板共享的角色ID
+func templateRoleIDs(db *gorm.DB, ids []uint) (map[uint][]uint, error) {
+	result := map[uint][]uint{}
+	if len(ids) == 0 {
+		return result, nil
+	}
+	var rows []models.RecordTemplateRole
+	if err := db.Where("template_id IN ?", ids).Order("role_id ASC").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("获取模板角色失败: %w", err)
+	}
+	for _, row := range rows {
+		result[row.TemplateID] = append(result[row.TemplateID], row.RoleID)
+	}
+	return result, nil
+}
+
+func templateIDs(templates []models.RecordTemplate) []uint {
+	ids := make([]uint, len(templates))
+	for i, template := range templates {
+		ids[i] = template.ID
+	}
+	return ids
+}
+
+// templateAccessScope 限定为用户可用的模板：本人创建，或共享且未限定角色/共享给用户所属角色
+func templateAccessScope(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Where(`record_templates.created_by = ? OR (record_templates.visibility = ? AND (
+		NOT EXISTS (SELECT 1 FROM record_template_roles rtr WHERE rtr.template_id = record_templates.id) OR
+		EXISTS (SELECT 1 FROM record_template_roles rtr JOIN user_roles ur ON ur.role_id = rtr.role_id
+			WHERE rtr.template_id = record_templates.id AND ur.user_id = ?)))`,
+		userID, models.TemplateVisibilityShared, userID)
+}
+
+// findUsableTemplate 查找用户可用的模板
+func findUsableTemplate(db *gorm.DB, id uint, userID uint, canManage bool) (*models.RecordTemplate, error) {
+	query := db.Preload("RecordType").Preload("Creator")
+	if !canManage {
+		query = templateAccessScope(query, userID)
+	}
+	var template models.RecordTemplate
+	if err := query.First(&template, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("模板不存在或无权使用")
+		}
+		return nil, fmt.Errorf("获取记录模板失败: %w", err)
+	}
+	return &template, nil
+}
+
+// applyRecordTemplate 用模板填充创建请求：请求中的内容字段覆盖模板预填值，未指定标题和标签时使用模板的标题模式和默认标签
+func applyRecordTemplate(db *gorm.DB, req *CreateRecordRequest, userID uint) error {
+	template, err := findUsableTemplate(db, *req.TemplateID, userID, false)
+	if err != nil {
+		return err
+	}
+	if req.Type != "" && req.Type != template.RecordType.Name {
+		return fmt.Errorf("模板不属于记录类型 %s", req.Type)
+	}
+	if !template.IsValid {
+		return fmt.Errorf("模板内容不符合当前Schema，请先更新模板")
+	}
+	req.Type = template.RecordType.Name
+
+	content := map[string]interface{}{}
+	for key, value := range template.Content {
+		content[key] = value
+	}
+	for key, value := range req.Content {
+		content[key] = value
+	}
+	req.Content = content
+
+	if strings.TrimSpace(req.Title) == "" {
+		var user models.User
+		db.Select("id", "username").First(&user, userID)
+		req.Title = renderTitlePattern(template.TitlePattern, time.Now(), user.Username, template.RecordType.DisplayName)
+	}
+	if req.Tags == nil {
+		req.Tags = append([]string{}, template.Tags...)
+	}
+	return nil
+}
+
+// renderTitlePattern 替换标题模式中的占位符，未知占位符保持原样
+func renderTitlePattern(pattern string, now time.Time, username, typeName string) string {
+	replacer := strings.NewReplacer(
+		"{{date}}", now.Format("2006-01-02"),
+		"{{time}}", now.Format("15:04"),
+		"{{datetime}}", now.Format("2006-01-02 15:04"),
+		"{{user}}", username,
+		"{{type}}", typeName,
+	)
+	return strings.TrimSpace(replacer.Replace(pattern))
+}
+
+// validateTemplateContent 校验模板预填内容：字段须在Schema中且类型匹配，文件字段不能预填；必填项可以留空
+func validateTemplateContent(fields []SchemaField, content map[string]interface{}) []string {
+	fieldTypes := map[string]string{}
+	for _, field := range fields {
+		fieldTypes[field.Name] = field.Type
+	}
+
+	keys := make([]string, 0, len(content))
+	for key := range content {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []string
+	for _, key := range keys {
+		value := content[key]
+		fieldType, ok := fieldTypes[key]
+		if !ok {
+			if len(fields) > 0 {
+				errs = append(errs, fmt.Sprintf("字段 %s 不在Schema中", key))
+			}
+			continue
+		}
+		if fieldType == "file" || fieldType == "files" {
+			errs = append(errs, fmt.Sprintf("字段 %s 为文件字段，不能预填", key))
+			continue
+		}
+		if value == nil || value == "" {
+			continue
+		}
+		if !schemaValueMatchesType(fieldType, value) {
+			errs = append(errs, fmt.Sprintf("字段 %s 的值与类型 %s 不匹配", key, fieldType))
+		}
+	}
+	return errs
+}
+
+// deleteRecordTypeTemplates 删除记录类型下的全部模板
+func deleteRecordTypeTemplates(tx *gorm.DB, recordTypeIDs []uint) error {
+	if len(recordTypeIDs) == 0 {
+		return nil
+	}
+	ids := tx.Model(&models.RecordTemplate{}).Select("id").Where("record_type_id IN ?", recordTypeIDs)
+	if err := tx.Where("template_id IN (?)", ids).Delete(&models.RecordTemplateRole{}).Error; err != nil {
+		return fmt.Errorf("删除记录模板失败: %w", err)
+	}
+	if err := tx.Where("record_type_id IN ?", recordTypeIDs).Delete(&models.RecordTemplate{}).Error; err != nil {
+		return fmt.Errorf("删除记录模板失败: %w", err)
+	}
+	return nil
+}
+
+// checkTemplatesAgainstSchema 按Schema校验某记录类型的全部模板，返回不符合的模板数；save 为 true 时保存校验结果
+func checkTemplatesAgainstSchema(db *gorm.DB, recordType *models.RecordType, schema models.JSONB, version int, save bool) (int64, error) {
+	var templates []models.RecordTemplate
+	if err := db.Where("record_type_id = ?", recordType.ID).Find(&templates).Error; err != nil {
+		return 0, fmt.Errorf("校验记录模板失败: %w", err)
+	}
+
+	fields := ParseSchemaFields(schema)
+	var invalid int64
+	for _, template := range templates {
+		errs := validateTemplateContent(fields, template.Content)
+		if len(errs) > 0 {
+			invalid++
+		}
+		if !save {
+			continue
+		}
+		err := db.Model(&models.RecordTemplate{}).Where("id = ?", template.ID).Updates(map[string]interface{}{
+			"schema_version":    version,
+			"is_valid":          len(errs) == 0,
+			"validation_errors": models.StringSlice(errs),
+		}).Error
+		if err != nil {
+			return 0, fmt.Errorf("保存模板校验结果失败: %w", err)
+		}
+	}
+	return invalid, nil
+}
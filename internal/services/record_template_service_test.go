@@ -0,0 +1,185 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupTemplateTest(t *testing.T) (*gorm.DB, *RecordService, *RecordTemplateService) {
+	db, recordService := setupAttachmentTest(t)
+	require.NoError(t, db.Create(&models.RecordType{
+		Name:          "meeting",
+		DisplayName:   "会议纪要",
+		TableName:     "records_meeting",
+		IsActive:      true,
+		SchemaVersion: 1,
+		Schema: models.JSONB{"fields": []interface{}{
+			map[string]interface{}{"name": "topic", "type": "string", "required": true},
+			map[string]interface{}{"name": "room", "type": "string"},
+			map[string]interface{}{"name": "duration", "type": "number"},
+			map[string]interface{}{"name": "minutes", "type": "file"},
+		}},
+	}).Error)
+	return db, recordService, NewRecordTemplateService(db, NewAuditService(db))
+}
+
+func TestRecordTemplateService_Visibility(t *testing.T) {
+	db, _, service := setupTemplateTest(t)
+	role := models.Role{Name: "pm", DisplayName: "项目经理"}
+	require.NoError(t, db.Create(&role).Error)
+
+	personal, err := service.CreateTemplate(&CreateRecordTemplateRequest{RecordType: "meeting", Name: "我的周会"}, 1, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, models.TemplateVisibilityPersonal, personal.Visibility)
+	forRole, err := service.CreateTemplate(&CreateRecordTemplateRequest{RecordType: "meeting", Name: "项目例会",
+		Visibility: models.TemplateVisibilityShared, RoleIDs: []uint{role.ID}}, 1, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, []uint{role.ID}, forRole.RoleIDs)
+	forAll, err := service.CreateTemplate(&CreateRecordTemplateRequest{RecordType: "meeting", Name: "全员大会",
+		Visibility: models.TemplateVisibilityShared}, 1, "", "")
+	require.NoError(t, err)
+
+	_, err = service.CreateTemplate(&CreateRecordTemplateRequest{RecordType: "meeting", Name: "无效角色",
+		Visibility: models.TemplateVisibilityShared, RoleIDs: []uint{99}}, 1, "", "")
+	assert.EqualError(t, err, "角色不存在")
+
+	names := func(userID uint) []string {
+		list, err := service.ListTemplates(&RecordTemplateQuery{Type: "meeting"}, userID, false)
+		require.NoError(t, err)
+		result := []string{}
+		for _, item := range list {
+			result = append(result, item.Name)
+		}
+		return result
+	}
+	assert.Equal(t, []string{"全员大会", "我的周会", "项目例会"}, names(1))
+	assert.Equal(t, []string{"全员大会"}, names(2))
+
+	// 加入角色后可以使用共享给该角色的模板
+	require.NoError(t, db.Create(&models.UserRole{UserID: 2, RoleID: role.ID}).Error)
+	assert.Equal(t, []string{"全员大会", "项目例会"}, names(2))
+
+	_, err = service.GetTemplate(personal.ID, 2, false)
+	assert.EqualError(t, err, "模板不存在或无权使用")
+	_, err = service.GetTemplate(forAll.ID, 2, false)
+	require.NoError(t, err)
+
+	// 只有创建者可以修改和删除；改为个人模板后清空共享角色
+	_, err = service.UpdateTemplate(forRole.ID, &UpdateRecordTemplateRequest{Name: strPtr("改名")}, 2, false, "", "")
+	assert.EqualError(t, err, "模板不存在或无权修改")
+	updated, err := service.UpdateTemplate(forRole.ID, &UpdateRecordTemplateRequest{Visibility: strPtr(models.TemplateVisibilityPersonal)}, 1, false, "", "")
+	require.NoError(t, err)
+	assert.Empty(t, updated.RoleIDs)
+	assert.Equal(t, []string{"全员大会"}, names(2))
+
+	assert.EqualError(t, service.DeleteTemplate(forAll.ID, 2, false, "", ""), "模板不存在或无权修改")
+	require.NoError(t, service.DeleteTemplate(forAll.ID, 2, true, "", ""))
+	assert.Empty(t, names(2))
+}
+
+func TestRecordTemplateService_ContentValidation(t *testing.T) {
+	_, _, service := setupTemplateTest(t)
+
+	_, err := service.CreateTemplate(&CreateRecordTemplateRequest{RecordType: "meeting", Name: "错误",
+		Content: map[string]interface{}{"duration": "一小时", "unknown": 1, "minutes": 3}}, 1, "", "")
+	assert.EqualError(t, err, "模板内容不符合Schema: 字段 duration 的值与类型 number 不匹配; 字段 minutes 为文件字段，不能预填; 字段 unknown 不在Schema中")
+
+	// 必填字段可以留空，由创建记录时补全
+	template, err := service.CreateTemplate(&CreateRecordTemplateRequest{RecordType: "meeting", Name: "周会",
+		Content: map[string]interface{}{"room": "A101"}}, 1, "", "")
+	require.NoError(t, err)
+	assert.True(t, template.IsValid)
+	assert.Equal(t, 1, template.SchemaVersion)
+
+	_, err = service.CreateTemplate(&CreateRecordTemplateRequest{RecordType: "unknown", Name: "x"}, 1, "", "")
+	assert.EqualError(t, err, "记录类型不存在")
+}
+
+func TestRecordTemplateService_CreateRecordFromTemplate(t *testing.T) {
+	db, recordService, service := setupTemplateTest(t)
+
+	template, err := service.CreateTemplate(&CreateRecordTemplateRequest{RecordType: "meeting", Name: "周会",
+		TitlePattern: "{{type}} {{date}} {{user}} {{unknown}}", Tags: []string{"周会"},
+		Content: map[string]interface{}{"room": "A101", "duration": 30}}, 1, "", "")
+	require.NoError(t, err)
+
+	record, err := recordService.CreateRecord(&CreateRecordRequest{TemplateID: &template.ID,
+		Content: map[string]interface{}{"topic": "进度同步", "duration": 45}}, 1, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "meeting", record.Type)
+	assert.Equal(t, "会议纪要 "+time.Now().Format("2006-01-02")+" owner {{unknown}}", record.Title)
+	assert.Equal(t, "A101", record.Content["room"])
+	assert.EqualValues(t, 45, record.Content["duration"], "请求中的内容覆盖模板预填值")
+	assert.Equal(t, []string{"周会"}, record.Tags)
+
+	// 请求中的标题和标签优先
+	record, err = recordService.CreateRecord(&CreateRecordRequest{TemplateID: &template.ID, Title: "临时会议", Tags: []string{},
+		Content: map[string]interface{}{"topic": "复盘"}}, 1, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "临时会议", record.Title)
+	assert.Empty(t, record.Tags)
+
+	_, err = recordService.CreateRecord(&CreateRecordRequest{TemplateID: &template.ID, Type: "contract"}, 1, "", "")
+	assert.EqualError(t, err, "模板不属于记录类型 contract")
+	_, err = recordService.CreateRecord(&CreateRecordRequest{TemplateID: &template.ID}, 2, "", "")
+	assert.EqualError(t, err, "模板不存在或无权使用")
+
+	var count int64
+	db.Model(&models.Record{}).Where("type = ?", "meeting").Count(&count)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestRecordTemplateService_RevalidateOnSchemaChange(t *testing.T) {
+	db, recordService, service := setupTemplateTest(t)
+	typeService := NewRecordTypeService(db)
+	var recordType models.RecordType
+	require.NoError(t, db.Where("name = ?", "meeting").First(&recordType).Error)
+
+	template, err := service.CreateTemplate(&CreateRecordTemplateRequest{RecordType: "meeting", Name: "周会", TitlePattern: "周会",
+		Content: map[string]interface{}{"room": "A101", "duration": 30}}, 1, "", "")
+	require.NoError(t, err)
+
+	// duration 改为字符串类型后模板不再有效
+	newSchema := map[string]interface{}{"fields": []interface{}{
+		map[string]interface{}{"name": "topic", "type": "string"},
+		map[string]interface{}{"name": "room", "type": "string"},
+		map[string]interface{}{"name": "duration", "type": "string"},
+	}}
+	report, err := typeService.CheckSchemaCompatibility(recordType.ID, newSchema)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), report.InvalidTemplates)
+
+	updated, err := typeService.UpdateRecordType(recordType.ID, &UpdateRecordTypeRequest{Schema: newSchema})
+	require.NoError(t, err)
+	require.NotNil(t, updated.Compatibility)
+	assert.Equal(t, int64(1), updated.Compatibility.InvalidTemplates)
+
+	detail, err := service.GetTemplate(template.ID, 1, false)
+	require.NoError(t, err)
+	assert.False(t, detail.IsValid)
+	assert.Equal(t, 2, detail.SchemaVersion)
+	assert.Equal(t, []string{"字段 duration 的值与类型 string 不匹配"}, detail.ValidationErrors)
+
+	_, err = recordService.CreateRecord(&CreateRecordRequest{TemplateID: &template.ID, Content: map[string]interface{}{"topic": "x"}}, 1, "", "")
+	require.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "模板内容不符合当前Schema"))
+
+	// 修正内容后恢复可用
+	detail, err = service.UpdateTemplate(template.ID, &UpdateRecordTemplateRequest{Content: map[string]interface{}{"room": "A101", "duration": "30分钟"}}, 1, false, "", "")
+	require.NoError(t, err)
+	assert.True(t, detail.IsValid)
+	assert.Empty(t, detail.ValidationErrors)
+	_, err = recordService.CreateRecord(&CreateRecordRequest{TemplateID: &template.ID, Content: map[string]interface{}{"topic": "x"}}, 1, "", "")
+	require.NoError(t, err)
+}
+
+func strPtr(value string) *string {
+	return &value
+}
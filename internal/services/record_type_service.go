@@ -3,6 +3,8 @@ package services
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
 
 	"info-management-system/internal/models"
 
@@ -24,26 +26,34 @@ type CreateRecordTypeRequest struct {
 	Name        string                 `json:"name" binding:"required,min=2,max=100"`
 	DisplayName string                 `json:"display_name" binding:"required,min=2,max=200"`
 	Schema      map[string]interface{} `json:"schema" binding:"required"`
+	Workflow    map[string]interface{} `json:"workflow"`
+	UniqueKeys  []string               `json:"unique_keys"` // 唯一键字段，用于创建和导入时的重复校验
 }
 
 // UpdateRecordTypeRequest 更新记录类型请求
 type UpdateRecordTypeRequest struct {
 	DisplayName string                 `json:"display_name" binding:"omitempty,min=2,max=200"`
 	Schema      map[string]interface{} `json:"schema"`
+	Workflow    map[string]interface{} `json:"workflow"`
+	UniqueKeys  []string               `json:"unique_keys"` // 为 nil 时不修改，空数组表示取消唯一键
 	IsActive    *bool                  `json:"is_active"`
 }
 
 // RecordTypeResponse 记录类型响应
 type RecordTypeResponse struct {
-	ID          uint                   `json:"id"`
-	Name        string                 `json:"name"`
-	DisplayName string                 `json:"display_name"`
-	Schema      map[string]interface{} `json:"schema"`
-	TableName   string                 `json:"table_name"`
-	IsActive    bool                   `json:"is_active"`
-	RecordCount int64                  `json:"record_count"`
-	CreatedAt   string                 `json:"created_at"`
-	UpdatedAt   string                 `json:"updated_at"`
+	ID            uint                       `json:"id"`
+	Name          string                     `json:"name"`
+	DisplayName   string                     `json:"display_name"`
+	Schema        map[string]interface{}     `json:"schema"`
+	SchemaVersion int                        `json:"schema_version"`
+	Workflow      map[string]interface{}     `json:"workflow"`
+	UniqueKeys    []string                   `json:"unique_keys"`
+	TableName     string                     `json:"table_name"`
+	IsActive      bool                       `json:"is_active"`
+	RecordCount   int64                      `json:"record_count"`
+	CreatedAt     string                     `json:"created_at"`
+	UpdatedAt     string                     `json:"updated_at"`
+	Compatibility *SchemaCompatibilityReport `json:"compatibility,omitempty"` // Schema变更时现有记录的兼容性
 }
 
 // GetAllRecordTypes 获取所有记录类型
@@ -60,15 +70,18 @@ func (s *RecordTypeService) GetAllRecordTypes() ([]RecordTypeResponse, error) {
 		s.db.Model(&models.Record{}).Where("type = ?", recordType.Name).Count(&recordCount)
 
 		result[i] = RecordTypeResponse{
-			ID:          recordType.ID,
-			Name:        recordType.Name,
-			DisplayName: recordType.DisplayName,
-			Schema:      recordType.Schema,
-			TableName:   recordType.TableName,
-			IsActive:    recordType.IsActive,
-			RecordCount: recordCount,
-			CreatedAt:   recordType.CreatedAt.Format("2006-01-02 15:04:05"),
-			UpdatedAt:   recordType.UpdatedAt.Format("2006-01-02 15:04:05"),
+			ID:            recordType.ID,
+			Name:          recordType.Name,
+			DisplayName:   recordType.DisplayName,
+			Schema:        recordType.Schema,
+			SchemaVersion: recordType.SchemaVersion,
+			Workflow:      recordType.Workflow,
+			UniqueKeys:    uniqueKeysOrEmpty(recordType.UniqueKeys),
+			TableName:     recordType.TableName,
+			IsActive:      recordType.IsActive,
+			RecordCount:   recordCount,
+			CreatedAt:     recordType.CreatedAt.Format("2006-01-02 15:04:05"),
+			UpdatedAt:     recordType.UpdatedAt.Format("2006-01-02 15:04:05"),
 		}
 	}
 
@@ -90,15 +103,18 @@ func (s *RecordTypeService) GetRecordTypeByID(id uint) (*RecordTypeResponse, err
 	s.db.Model(&models.Record{}).Where("type = ?", recordType.Name).Count(&recordCount)
 
 	return &RecordTypeResponse{
-		ID:          recordType.ID,
-		Name:        recordType.Name,
-		DisplayName: recordType.DisplayName,
-		Schema:      recordType.Schema,
-		TableName:   recordType.TableName,
-		IsActive:    recordType.IsActive,
-		RecordCount: recordCount,
-		CreatedAt:   recordType.CreatedAt.Format("2006-01-02 15:04:05"),
-		UpdatedAt:   recordType.UpdatedAt.Format("2006-01-02 15:04:05"),
+		ID:            recordType.ID,
+		Name:          recordType.Name,
+		DisplayName:   recordType.DisplayName,
+		Schema:        recordType.Schema,
+		SchemaVersion: recordType.SchemaVersion,
+		Workflow:      recordType.Workflow,
+		UniqueKeys:    uniqueKeysOrEmpty(recordType.UniqueKeys),
+		TableName:     recordType.TableName,
+		IsActive:      recordType.IsActive,
+		RecordCount:   recordCount,
+		CreatedAt:     recordType.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:     recordType.UpdatedAt.Format("2006-01-02 15:04:05"),
 	}, nil
 }
 
@@ -125,31 +141,51 @@ func (s *RecordTypeService) CreateRecordType(req *CreateRecordTypeRequest) (*Rec
 		return nil, fmt.Errorf("记录类型名称已存在")
 	}
 
+	// 校验状态流程定义
+	if _, err := ParseRecordWorkflow(models.JSONB(req.Workflow)); err != nil {
+		return nil, err
+	}
+	if err := validateUniqueKeys(req.UniqueKeys, models.JSONB(req.Schema)); err != nil {
+		return nil, err
+	}
+
 	// 生成表名
 	tableName := fmt.Sprintf("records_%s", req.Name)
 
 	recordType := models.RecordType{
-		Name:        req.Name,
-		DisplayName: req.DisplayName,
-		Schema:      models.JSONB(req.Schema),
-		TableName:   tableName,
-		IsActive:    true,
+		Name:          req.Name,
+		DisplayName:   req.DisplayName,
+		Schema:        models.JSONB(req.Schema),
+		SchemaVersion: 1,
+		Workflow:      models.JSONB(req.Workflow),
+		UniqueKeys:    models.StringSlice(req.UniqueKeys),
+		TableName:     tableName,
+		IsActive:      true,
 	}
 
-	if err := s.db.Create(&recordType).Error; err != nil {
-		return nil, fmt.Errorf("创建记录类型失败: %w", err)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&recordType).Error; err != nil {
+			return fmt.Errorf("创建记录类型失败: %w", err)
+		}
+		return saveSchemaVersion(tx, &recordType)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &RecordTypeResponse{
-		ID:          recordType.ID,
-		Name:        recordType.Name,
-		DisplayName: recordType.DisplayName,
-		Schema:      req.Schema,
-		TableName:   recordType.TableName,
-		IsActive:    recordType.IsActive,
-		RecordCount: 0,
-		CreatedAt:   recordType.CreatedAt.Format("2006-01-02 15:04:05"),
-		UpdatedAt:   recordType.UpdatedAt.Format("2006-01-02 15:04:05"),
+		ID:            recordType.ID,
+		Name:          recordType.Name,
+		DisplayName:   recordType.DisplayName,
+		Schema:        req.Schema,
+		SchemaVersion: recordType.SchemaVersion,
+		Workflow:      req.Workflow,
+		UniqueKeys:    uniqueKeysOrEmpty(recordType.UniqueKeys),
+		TableName:     recordType.TableName,
+		IsActive:      recordType.IsActive,
+		RecordCount:   0,
+		CreatedAt:     recordType.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:     recordType.UpdatedAt.Format("2006-01-02 15:04:05"),
 	}, nil
 }
 
@@ -168,20 +204,107 @@ func (s *RecordTypeService) UpdateRecordType(id uint, req *UpdateRecordTypeReque
 		recordType.DisplayName = req.DisplayName
 	}
 
-	if req.Schema != nil {
+	schemaChanged := false
+	if req.Schema != nil && !reflect.DeepEqual(map[string]interface{}(recordType.Schema), req.Schema) {
 		recordType.Schema = models.JSONB(req.Schema)
+		recordType.SchemaVersion++
+		schemaChanged = true
+	}
+
+	if req.Workflow != nil {
+		workflow, err := ParseRecordWorkflow(models.JSONB(req.Workflow))
+		if err != nil {
+			return nil, err
+		}
+		// 已有记录的状态必须仍在新流程中
+		var orphaned int64
+		s.db.Model(&models.Record{}).Where("type = ? AND status NOT IN ?", recordType.Name, workflow.States).Count(&orphaned)
+		if orphaned > 0 {
+			return nil, fmt.Errorf("有 %d 条记录的状态不在新流程中，无法更新流程", orphaned)
+		}
+		recordType.Workflow = models.JSONB(req.Workflow)
 	}
 
 	if req.IsActive != nil {
 		recordType.IsActive = *req.IsActive
 	}
 
-	if err := s.db.Save(&recordType).Error; err != nil {
-		return nil, fmt.Errorf("更新记录类型失败: %w", err)
+	// 唯一键必须在（更新后的）Schema中，变更后重新计算已有记录的唯一键
+	uniqueKeysChanged := false
+	if req.UniqueKeys != nil && !reflect.DeepEqual([]string(recordType.UniqueKeys), req.UniqueKeys) {
+		if len(req.UniqueKeys) > 0 || len(recordType.UniqueKeys) > 0 {
+			uniqueKeysChanged = true
+		}
+		recordType.UniqueKeys = models.StringSlice(req.UniqueKeys)
+	}
+	if uniqueKeysChanged || schemaChanged {
+		if err := validateUniqueKeys(recordType.UniqueKeys, recordType.Schema); err != nil {
+			return nil, err
+		}
+	}
+
+	var invalidTemplates int64
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&recordType).Error; err != nil {
+			return fmt.Errorf("更新记录类型失败: %w", err)
+		}
+		if uniqueKeysChanged {
+			if err := recomputeUniqueKeys(tx, &recordType); err != nil {
+				return err
+			}
+		}
+		if schemaChanged {
+			// 按新Schema重新校验该类型的模板
+			invalid, err := checkTemplatesAgainstSchema(tx, &recordType, recordType.Schema, recordType.SchemaVersion, true)
+			if err != nil {
+				return err
+			}
+			invalidTemplates = invalid
+			return saveSchemaVersion(tx, &recordType)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// 重新获取记录类型详情
-	return s.GetRecordTypeByID(id)
+	response, err := s.GetRecordTypeByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Schema变更后报告现有记录的兼容情况
+	if schemaChanged {
+		report, err := checkRecordsAgainstSchema(s.db, recordType.Name, recordType.Schema, recordType.SchemaVersion)
+		if err != nil {
+			return nil, err
+		}
+		report.InvalidTemplates = invalidTemplates
+		response.Compatibility = report
+	}
+	return response, nil
+}
+
+// uniqueKeysOrEmpty 未配置唯一键时返回空数组
+func uniqueKeysOrEmpty(keys models.StringSlice) []string {
+	if keys == nil {
+		return []string{}
+	}
+	return []string(keys)
+}
+
+// saveSchemaVersion 保存当前Schema为历史版本
+func saveSchemaVersion(tx *gorm.DB, recordType *models.RecordType) error {
+	version := models.RecordTypeSchemaVersion{
+		RecordTypeID: recordType.ID,
+		Version:      recordType.SchemaVersion,
+		Schema:       recordType.Schema,
+	}
+	if err := tx.Create(&version).Error; err != nil {
+		return fmt.Errorf("保存Schema版本失败: %w", err)
+	}
+	return nil
 }
 
 // DeleteRecordType 删除记录类型
@@ -201,11 +324,15 @@ func (s *RecordTypeService) DeleteRecordType(id uint) error {
 		return fmt.Errorf("该记录类型正在被 %d 条记录使用，无法删除", recordCount)
 	}
 
-	if err := s.db.Delete(&recordType).Error; err != nil {
-		return fmt.Errorf("删除记录类型失败: %w", err)
-	}
-
-	return nil
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := deleteRecordTypeTemplates(tx, []uint{recordType.ID}); err != nil {
+			return err
+		}
+		if err := tx.Delete(&recordType).Error; err != nil {
+			return fmt.Errorf("删除记录类型失败: %w", err)
+		}
+		return nil
+	})
 }
 
 // ValidateRecordData 验证记录数据是否符合类型定义
@@ -325,14 +452,21 @@ func (s *RecordTypeService) ImportRecordTypes(req *ImportRecordTypesRequest) ([]
 
 		// 创建记录类型
 		recordType := models.RecordType{
-			Name:        data.Name,
-			DisplayName: data.DisplayName,
-			Schema:      models.JSONB(schema),
-			TableName:   tableName,
-			IsActive:    isActive,
+			Name:          data.Name,
+			DisplayName:   data.DisplayName,
+			Schema:        models.JSONB(schema),
+			SchemaVersion: 1,
+			TableName:     tableName,
+			IsActive:      isActive,
 		}
 
-		if err := s.db.Create(&recordType).Error; err != nil {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&recordType).Error; err != nil {
+				return err
+			}
+			return saveSchemaVersion(tx, &recordType)
+		})
+		if err != nil {
 			result.Error = "创建记录类型失败"
 			results = append(results, result)
 			continue
@@ -367,5 +501,69 @@ func (s *RecordTypeService) BatchDeleteRecordTypes(req *BatchDeleteRecordTypesRe
 		}
 	}
 
-	return s.db.Where("id IN ?", req.RecordTypeIDs).Delete(&models.RecordType{}).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := deleteRecordTypeTemplates(tx, req.RecordTypeIDs); err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", req.RecordTypeIDs).Delete(&models.RecordType{}).Error
+	})
+}
+
+// SchemaField Schema字段定义
+type SchemaField struct {
+	Name     string      `json:"name"`
+	Label    string      `json:"label,omitempty"`
+	Type     string      `json:"type"`
+	Required bool        `json:"required"`
+	Default  interface{} `json:"default,omitempty"`
+}
+
+// ParseSchemaFields 解析Schema字段，兼容 fields 数组与 properties 对象两种格式
+func ParseSchemaFields(schema models.JSONB) []SchemaField {
+	var fields []SchemaField
+	if schema == nil {
+		return fields
+	}
+
+	if list, ok := schema["fields"].([]interface{}); ok {
+		for _, item := range list {
+			def, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := def["name"].(string)
+			if name == "" {
+				continue
+			}
+			fieldType, _ := def["type"].(string)
+			label, _ := def["label"].(string)
+			required, _ := def["required"].(bool)
+			fields = append(fields, SchemaField{Name: name, Label: label, Type: fieldType, Required: required, Default: def["default"]})
+		}
+		return fields
+	}
+
+	if properties, ok := schema["properties"].(map[string]interface{}); ok {
+		required := map[string]bool{}
+		if list, ok := schema["required"].([]interface{}); ok {
+			for _, item := range list {
+				if name, ok := item.(string); ok {
+					required[name] = true
+				}
+			}
+		}
+		for name, item := range properties {
+			def, _ := item.(map[string]interface{})
+			fieldType, _ := def["type"].(string)
+			// 兼容 {"type":"string","format":"file"} 写法
+			if format, _ := def["format"].(string); format == "file" || format == "files" {
+				fieldType = format
+			}
+			label, _ := def["title"].(string)
+			fields = append(fields, SchemaField{Name: name, Label: label, Type: fieldType, Required: required[name], Default: def["default"]})
+		}
+		sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	}
+
+	return fields
 }
@@ -25,7 +25,7 @@ func (suite *RecordTypeServiceTestSuite) SetupSuite() {
 	suite.Require().NoError(err)
 
 	// 自动迁移
-	err = db.AutoMigrate(&models.RecordType{}, &models.Record{})
+	err = db.AutoMigrate(&models.RecordType{}, &models.Record{}, &models.RecordTypeSchemaVersion{}, &models.RecordTemplate{}, &models.RecordTemplateRole{})
 	suite.Require().NoError(err)
 
 	suite.db = db
@@ -0,0 +1,561 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// RecordWorkflowService 记录状态流程服务
+type RecordWorkflowService struct {
+	db                  *gorm.DB
+	recordTypeService   *RecordTypeService
+	auditService        *AuditService
+	notificationService *NotificationService
+	watchService        *WatchService
+}
+
+// NewRecordWorkflowService 创建记录状态流程服务
+func NewRecordWorkflowService(db *gorm.DB, recordTypeService *RecordTypeService, auditService *AuditService, notificationService *NotificationService) *RecordWorkflowService {
+	return &RecordWorkflowService{
+		db:                  db,
+		recordTypeService:   recordTypeService,
+		auditService:        auditService,
+		notificationService: notificationService,
+		watchService:        NewWatchService(db, notificationService),
+	}
+}
+
+// 流程角色中的特殊值
+const (
+	WorkflowRoleCreator  = "creator"  // 记录创建者
+	WorkflowRoleReviewer = "reviewer" // 已指派的审核人
+)
+
+// WorkflowStates 状态列表，JSON中既可以是字符串也可以是数组
+type WorkflowStates []string
+
+// UnmarshalJSON 兼容 "draft" 与 ["draft","rejected"] 两种写法
+func (w *WorkflowStates) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*w = WorkflowStates{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*w = WorkflowStates(list)
+	return nil
+}
+
+// RecordWorkflow 记录状态流程定义
+type RecordWorkflow struct {
+	InitialState string                     `json:"initial_state"`
+	States       []string                   `json:"states"`
+	Transitions  []RecordWorkflowTransition `json:"transitions"`
+}
+
+// RecordWorkflowTransition 状态流转定义
+type RecordWorkflowTransition struct {
+	Action         string         `json:"action"`          // 动作标识，如 submit、approve、reject、publish
+	From           WorkflowStates `json:"from"`            // 允许的源状态，"*" 表示任意状态
+	To             string         `json:"to"`              // 目标状态
+	Roles          []string       `json:"roles"`           // 可执行的角色，支持 creator、reviewer；为空表示有编辑权限即可
+	RequireComment bool           `json:"require_comment"` // 是否必须填写意见
+}
+
+// RecordTransitionRequest 记录状态流转请求
+type RecordTransitionRequest struct {
+	Action   string `json:"action"`
+	ToStatus string `json:"to_status"`
+	Comment  string `json:"comment" binding:"max=2000"`
+}
+
+// RecordReviewRequest 审核请求
+type RecordReviewRequest struct {
+	Comment string `json:"comment" binding:"max=2000"`
+}
+
+// AssignReviewerRequest 指派审核人请求
+type AssignReviewerRequest struct {
+	ReviewerID uint   `json:"reviewer_id" binding:"required"`
+	Comment    string `json:"comment" binding:"max=2000"`
+}
+
+// RecordStatusHistoryResponse 记录状态历史响应
+type RecordStatusHistoryResponse struct {
+	ID         uint   `json:"id"`
+	RecordID   uint   `json:"record_id"`
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status"`
+	Action     string `json:"action"`
+	Comment    string `json:"comment"`
+	UserID     uint   `json:"user_id"`
+	Username   string `json:"username"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// BatchTransitionResult 批量状态变更结果
+type BatchTransitionResult struct {
+	Updated []uint          `json:"updated"`
+	Failed  map[uint]string `json:"failed"`
+}
+
+// DefaultRecordWorkflow 默认流程：draft/published/archived 之间自由切换
+func DefaultRecordWorkflow() *RecordWorkflow {
+	return &RecordWorkflow{
+		InitialState: "draft",
+		States:       []string{"draft", "published", "archived"},
+		Transitions: []RecordWorkflowTransition{
+			{Action: "publish", From: WorkflowStates{"draft", "archived"}, To: "published"},
+			{Action: "archive", From: WorkflowStates{"draft", "published"}, To: "archived"},
+			{Action: "draft", From: WorkflowStates{"published", "archived"}, To: "draft"},
+		},
+	}
+}
+
+// ParseRecordWorkflow 解析并校验流程定义，为空时返回默认流程
+func ParseRecordWorkflow(raw models.JSONB) (*RecordWorkflow, error) {
+	if len(raw) == 0 {
+		return DefaultRecordWorkflow(), nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("流程定义格式错误: %w", err)
+	}
+	var workflow RecordWorkflow
+	if err := json.Unmarshal(data, &workflow); err != nil {
+		return nil, fmt.Errorf("流程定义格式错误: %w", err)
+	}
+
+	if len(workflow.States) == 0 {
+		return nil, fmt.Errorf("流程定义必须包含状态列表")
+	}
+	states := make(map[string]bool, len(workflow.States))
+	for _, state := range workflow.States {
+		if state == "" || len(state) > 20 {
+			return nil, fmt.Errorf("无效的状态名: %q", state)
+		}
+		states[state] = true
+	}
+	if workflow.InitialState == "" {
+		workflow.InitialState = workflow.States[0]
+	}
+	if !states[workflow.InitialState] {
+		return nil, fmt.Errorf("初始状态 %s 不在状态列表中", workflow.InitialState)
+	}
+	for i, t := range workflow.Transitions {
+		if !states[t.To] {
+			return nil, fmt.Errorf("第 %d 个流转的目标状态 %s 不在状态列表中", i+1, t.To)
+		}
+		if len(t.From) == 0 {
+			return nil, fmt.Errorf("第 %d 个流转缺少源状态", i+1)
+		}
+		for _, from := range t.From {
+			if from != "*" && !states[from] {
+				return nil, fmt.Errorf("第 %d 个流转的源状态 %s 不在状态列表中", i+1, from)
+			}
+		}
+		if t.Action == "" {
+			workflow.Transitions[i].Action = t.To
+		}
+	}
+
+	return &workflow, nil
+}
+
+// HasState 状态是否在流程中
+func (w *RecordWorkflow) HasState(state string) bool {
+	for _, s := range w.States {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// FindTransition 查找从当前状态出发、匹配动作或目标状态的流转
+func (w *RecordWorkflow) FindTransition(from, action, to string) *RecordWorkflowTransition {
+	for i, t := range w.Transitions {
+		if action != "" && t.Action != action {
+			continue
+		}
+		if action == "" && t.To != to {
+			continue
+		}
+		if t.AllowsFrom(from) {
+			return &w.Transitions[i]
+		}
+	}
+	return nil
+}
+
+// AllowsFrom 流转是否允许从指定状态发起
+func (t *RecordWorkflowTransition) AllowsFrom(status string) bool {
+	for _, f := range t.From {
+		if f == "*" || f == status {
+			return true
+		}
+	}
+	return false
+}
+
+// GetWorkflow 获取记录类型的流程定义
+func (s *RecordWorkflowService) GetWorkflow(recordType string) (*RecordWorkflow, error) {
+	rt, err := s.recordTypeService.GetRecordTypeByName(recordType)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRecordWorkflow(rt.Workflow)
+}
+
+// InitialStatus 获取记录类型的初始状态
+func (s *RecordWorkflowService) InitialStatus(recordType string) string {
+	workflow, err := s.GetWorkflow(recordType)
+	if err != nil {
+		return "draft"
+	}
+	return workflow.InitialState
+}
+
+// GetAvailableTransitions 获取当前用户对记录可执行的流转
+func (s *RecordWorkflowService) GetAvailableTransitions(recordID uint, userID uint, hasAllPermission bool) ([]RecordWorkflowTransition, error) {
+	record, err := s.findRecord(recordID, userID, hasAllPermission)
+	if err != nil {
+		return nil, err
+	}
+	workflow, err := s.GetWorkflow(record.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := getUserRoleNames(s.db, userID)
+	available := []RecordWorkflowTransition{}
+	for i := range workflow.Transitions {
+		t := &workflow.Transitions[i]
+		if t.AllowsFrom(record.Status) && canPerformTransition(t, record, userID, roles, hasAllPermission) {
+			available = append(available, *t)
+		}
+	}
+	return available, nil
+}
+
+// Transition 执行记录状态流转
+func (s *RecordWorkflowService) Transition(recordID uint, req *RecordTransitionRequest, userID uint, hasAllPermission bool, ipAddress, userAgent string) (*RecordStatusHistoryResponse, error) {
+	if req.Action == "" && req.ToStatus == "" {
+		return nil, fmt.Errorf("必须指定动作或目标状态")
+	}
+
+	record, err := s.findRecord(recordID, userID, hasAllPermission)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.applyTransition(record, req, userID, hasAllPermission)
+	if err != nil {
+		return nil, err
+	}
+
+	s.afterTransition(record, history, userID, ipAddress, userAgent)
+	return toStatusHistoryResponse(history, ""), nil
+}
+
+// Approve 审核通过
+func (s *RecordWorkflowService) Approve(recordID uint, req *RecordReviewRequest, userID uint, hasAllPermission bool, ipAddress, userAgent string) (*RecordStatusHistoryResponse, error) {
+	return s.Transition(recordID, &RecordTransitionRequest{Action: "approve", Comment: req.Comment}, userID, hasAllPermission, ipAddress, userAgent)
+}
+
+// Reject 审核驳回
+func (s *RecordWorkflowService) Reject(recordID uint, req *RecordReviewRequest, userID uint, hasAllPermission bool, ipAddress, userAgent string) (*RecordStatusHistoryResponse, error) {
+	return s.Transition(recordID, &RecordTransitionRequest{Action: "reject", Comment: req.Comment}, userID, hasAllPermission, ipAddress, userAgent)
+}
+
+// BatchTransition 批量变更状态，逐条按流程校验
+func (s *RecordWorkflowService) BatchTransition(recordIDs []uint, toStatus string, userID uint, hasAllPermission bool) (*BatchTransitionResult, error) {
+	if len(recordIDs) == 0 {
+		return nil, fmt.Errorf("无效的记录ID")
+	}
+
+	query := s.db.Where("id IN ?", recordIDs)
+	if !hasAllPermission {
+		query = query.Where("created_by = ? OR reviewer_id = ?", userID, userID)
+	}
+	var records []models.Record
+	if err := query.Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("查询记录失败: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("没有找到可更新的记录，请检查记录ID和权限")
+	}
+
+	result := &BatchTransitionResult{Updated: []uint{}, Failed: map[uint]string{}}
+	found := make(map[uint]bool, len(records))
+	for i := range records {
+		record := &records[i]
+		found[record.ID] = true
+		history, err := s.applyTransition(record, &RecordTransitionRequest{ToStatus: toStatus}, userID, hasAllPermission)
+		if err != nil {
+			result.Failed[record.ID] = err.Error()
+			continue
+		}
+		s.afterTransition(record, history, userID, "", "")
+		result.Updated = append(result.Updated, record.ID)
+	}
+	for _, id := range recordIDs {
+		if !found[id] {
+			result.Failed[id] = "记录不存在或无权访问"
+		}
+	}
+
+	return result, nil
+}
+
+// AssignReviewer 指派审核人
+func (s *RecordWorkflowService) AssignReviewer(recordID uint, req *AssignReviewerRequest, userID uint, hasAllPermission bool, ipAddress, userAgent string) error {
+	var record models.Record
+	query := s.db
+	if !hasAllPermission {
+		query = query.Where("created_by = ?", userID)
+	}
+	if err := query.First(&record, recordID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("记录不存在或无权修改")
+		}
+		return fmt.Errorf("获取记录失败: %w", err)
+	}
+
+	var reviewer models.User
+	if err := s.db.Select("id", "username", "is_active").First(&reviewer, req.ReviewerID).Error; err != nil {
+		return fmt.Errorf("审核人不存在")
+	}
+	if !reviewer.IsActive {
+		return fmt.Errorf("审核人已停用")
+	}
+
+	oldReviewer := record.ReviewerID
+	if err := s.db.Model(&record).Update("reviewer_id", reviewer.ID).Error; err != nil {
+		return fmt.Errorf("指派审核人失败: %w", err)
+	}
+	// 审核人自动关注记录
+	if err := AddWatchers(s.db, models.WatchEntityRecord, record.ID, models.WatchReasonAssignee, reviewer.ID); err != nil {
+		return err
+	}
+
+	comment := fmt.Sprintf("指派审核人: %s", reviewer.Username)
+	if req.Comment != "" {
+		comment += "\n" + req.Comment
+	}
+	history := models.RecordStatusHistory{
+		RecordID:   record.ID,
+		FromStatus: record.Status,
+		ToStatus:   record.Status,
+		Action:     "assign_reviewer",
+		Comment:    comment,
+		UserID:     userID,
+	}
+	s.db.Create(&history)
+
+	if s.auditService != nil {
+		s.auditService.CreateAuditLog(&AuditLogRequest{
+			UserID:       userID,
+			Action:       "ASSIGN_REVIEWER",
+			ResourceType: "record",
+			ResourceID:   record.ID,
+			OldValues:    map[string]interface{}{"reviewer_id": oldReviewer},
+			NewValues:    map[string]interface{}{"reviewer_id": reviewer.ID},
+			IPAddress:    ipAddress,
+			UserAgent:    userAgent,
+		})
+	}
+
+	// 与关注通知共用异步执行方式，测试中可同步等待
+	if s.notificationService != nil && reviewer.ID != userID {
+		s.watchService.runAsync(func() {
+			s.notificationService.SendSimpleNotification(reviewer.ID, "记录审核通知",
+				fmt.Sprintf("您被指派为记录「%s」的审核人", record.Title), "record")
+		})
+	}
+
+	return nil
+}
+
+// GetStatusHistory 获取记录状态历史
+func (s *RecordWorkflowService) GetStatusHistory(recordID uint, userID uint, hasAllPermission bool) ([]RecordStatusHistoryResponse, error) {
+	if _, err := s.findRecord(recordID, userID, hasAllPermission); err != nil {
+		return nil, err
+	}
+
+	var histories []models.RecordStatusHistory
+	if err := s.db.Preload("User").Where("record_id = ?", recordID).Order("created_at ASC, id ASC").Find(&histories).Error; err != nil {
+		return nil, fmt.Errorf("获取状态历史失败: %w", err)
+	}
+
+	responses := make([]RecordStatusHistoryResponse, len(histories))
+	for i := range histories {
+		responses[i] = *toStatusHistoryResponse(&histories[i], histories[i].User.Username)
+	}
+	return responses, nil
+}
+
+// findRecord 查找当前用户可参与流程的记录（创建者、审核人或拥有全部权限）
+func (s *RecordWorkflowService) findRecord(recordID uint, userID uint, hasAllPermission bool) (*models.Record, error) {
+	var record models.Record
+	query := s.db
+	if !hasAllPermission {
+		query = query.Where("created_by = ? OR reviewer_id = ?", userID, userID)
+	}
+	if err := query.First(&record, recordID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("记录不存在或无权访问")
+		}
+		return nil, fmt.Errorf("获取记录失败: %w", err)
+	}
+	return &record, nil
+}
+
+// applyTransition 校验并写入状态流转及历史
+func (s *RecordWorkflowService) applyTransition(record *models.Record, req *RecordTransitionRequest, userID uint, hasAllPermission bool) (*models.RecordStatusHistory, error) {
+	workflow, err := s.GetWorkflow(record.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Action == "" && !workflow.HasState(req.ToStatus) {
+		return nil, fmt.Errorf("状态 %s 不在记录类型的流程中", req.ToStatus)
+	}
+
+	transition := workflow.FindTransition(record.Status, req.Action, req.ToStatus)
+	if transition == nil {
+		return nil, fmt.Errorf("当前状态 %s 不允许执行该流转", record.Status)
+	}
+	if !canPerformTransition(transition, record, userID, getUserRoleNames(s.db, userID), hasAllPermission) {
+		return nil, fmt.Errorf("无权执行该状态流转")
+	}
+	if transition.RequireComment && strings.TrimSpace(req.Comment) == "" {
+		return nil, fmt.Errorf("该操作需要填写意见")
+	}
+
+	return s.commitTransition(record, transition, req.Comment, userID)
+}
+
+// commitTransition 写入状态流转及历史（乐观校验当前状态）
+func (s *RecordWorkflowService) commitTransition(record *models.Record, transition *RecordWorkflowTransition, comment string, userID uint) (*models.RecordStatusHistory, error) {
+	history := &models.RecordStatusHistory{
+		RecordID:   record.ID,
+		FromStatus: record.Status,
+		ToStatus:   transition.To,
+		Action:     transition.Action,
+		Comment:    comment,
+		UserID:     userID,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Record{}).
+			Where("id = ? AND status = ?", record.ID, record.Status).
+			Update("status", transition.To)
+		if result.Error != nil {
+			return fmt.Errorf("更新记录状态失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("记录状态已被修改，请刷新后重试")
+		}
+		if err := tx.Create(history).Error; err != nil {
+			return fmt.Errorf("记录状态历史失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	record.Status = transition.To
+	return history, nil
+}
+
+// afterTransition 流转后的审计与通知
+func (s *RecordWorkflowService) afterTransition(record *models.Record, history *models.RecordStatusHistory, userID uint, ipAddress, userAgent string) {
+	if s.auditService != nil {
+		s.auditService.CreateAuditLog(&AuditLogRequest{
+			UserID:       userID,
+			Action:       "STATUS_CHANGE",
+			ResourceType: "record",
+			ResourceID:   record.ID,
+			OldValues:    map[string]interface{}{"status": history.FromStatus},
+			NewValues:    map[string]interface{}{"status": history.ToStatus, "action": history.Action, "comment": history.Comment},
+			IPAddress:    ipAddress,
+			UserAgent:    userAgent,
+		})
+	}
+
+	content := fmt.Sprintf("记录「%s」状态变更: %s -> %s", record.Title, history.FromStatus, history.ToStatus)
+	if history.Comment != "" {
+		content += "\n意见: " + history.Comment
+	}
+	// 创建者与审核人已自动关注，统一通知关注者
+	s.watchService.NotifyWatchers(models.WatchEntityRecord, record.ID, userID, "记录状态通知", content)
+}
+
+// canPerformTransition 检查用户是否可执行流转
+func canPerformTransition(t *RecordWorkflowTransition, record *models.Record, userID uint, roles []string, hasAllPermission bool) bool {
+	for _, role := range roles {
+		if role == "admin" {
+			return true
+		}
+	}
+
+	if len(t.Roles) == 0 {
+		return hasAllPermission || record.CreatedBy == userID
+	}
+
+	for _, allowed := range t.Roles {
+		switch allowed {
+		case WorkflowRoleCreator:
+			if record.CreatedBy == userID {
+				return true
+			}
+		case WorkflowRoleReviewer:
+			if record.ReviewerID != nil && *record.ReviewerID == userID {
+				return true
+			}
+		default:
+			for _, role := range roles {
+				if role == allowed {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// getUserRoleNames 获取用户的角色名列表
+func getUserRoleNames(db *gorm.DB, userID uint) []string {
+	var names []string
+	db.Table("roles").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Pluck("roles.name", &names)
+	return names
+}
+
+// toStatusHistoryResponse 转换状态历史响应
+func toStatusHistoryResponse(h *models.RecordStatusHistory, username string) *RecordStatusHistoryResponse {
+	return &RecordStatusHistoryResponse{
+		ID:         h.ID,
+		RecordID:   h.RecordID,
+		FromStatus: h.FromStatus,
+		ToStatus:   h.ToStatus,
+		Action:     h.Action,
+		Comment:    h.Comment,
+		UserID:     h.UserID,
+		Username:   username,
+		CreatedAt:  h.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
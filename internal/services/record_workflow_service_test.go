@@ -0,0 +1,132 @@
+package services
+
+import (
+	"testing"
+
+	"info-management-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// setupWorkflowTest 创建流程测试环境，review 类型需要审核人审核
+func setupWorkflowTest(t *testing.T) (*gorm.DB, *RecordWorkflowService) {
+	db := newServiceTestDB(t)
+
+	for _, name := range []string{"author", "reviewer", "outsider"} {
+		require.NoError(t, db.Create(&models.User{Username: name, Email: name + "@example.com", PasswordHash: "x", IsActive: true}).Error)
+	}
+
+	recordType := models.RecordType{
+		Name:        "review",
+		DisplayName: "需审核",
+		TableName:   "records_review",
+		IsActive:    true,
+		Schema:      models.JSONB{"fields": []interface{}{}},
+		Workflow: models.JSONB{
+			"initial_state": "draft",
+			"states":        []interface{}{"draft", "pending", "approved", "rejected"},
+			"transitions": []interface{}{
+				map[string]interface{}{"action": "submit", "from": []interface{}{"draft", "rejected"}, "to": "pending", "roles": []interface{}{"creator"}},
+				map[string]interface{}{"action": "approve", "from": "pending", "to": "approved", "roles": []interface{}{"reviewer"}},
+				map[string]interface{}{"action": "reject", "from": "pending", "to": "rejected", "roles": []interface{}{"reviewer"}, "require_comment": true},
+			},
+		},
+	}
+	require.NoError(t, db.Create(&recordType).Error)
+
+	return db, NewRecordWorkflowService(db, NewRecordTypeService(db), nil, nil)
+}
+
+func TestParseRecordWorkflow(t *testing.T) {
+	workflow, err := ParseRecordWorkflow(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "draft", workflow.InitialState)
+	assert.NotNil(t, workflow.FindTransition("draft", "", "published"))
+
+	_, err = ParseRecordWorkflow(models.JSONB{
+		"states":      []interface{}{"a", "b"},
+		"transitions": []interface{}{map[string]interface{}{"from": "a", "to": "c"}},
+	})
+	assert.Error(t, err)
+
+	workflow, err = ParseRecordWorkflow(models.JSONB{
+		"states":      []interface{}{"a", "b"},
+		"transitions": []interface{}{map[string]interface{}{"from": "*", "to": "b"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "a", workflow.InitialState)
+	assert.Equal(t, "b", workflow.Transitions[0].Action)
+}
+
+func TestRecordWorkflowService_ReviewFlow(t *testing.T) {
+	db, service := setupWorkflowTest(t)
+	assert.Equal(t, "draft", service.InitialStatus("review"))
+
+	record := models.Record{Type: "review", Title: "报告", Status: "draft", CreatedBy: 1}
+	require.NoError(t, db.Create(&record).Error)
+
+	// 审核前需提交
+	_, err := service.Approve(record.ID, &RecordReviewRequest{}, 1, false, "", "")
+	assert.EqualError(t, err, "当前状态 draft 不允许执行该流转")
+
+	_, err = service.Transition(record.ID, &RecordTransitionRequest{Action: "submit"}, 1, false, "", "")
+	require.NoError(t, err)
+	// 停用的用户不能指派为审核人
+	require.NoError(t, db.Model(&models.User{}).Where("id = ?", 3).Update("is_active", false).Error)
+	assert.EqualError(t, service.AssignReviewer(record.ID, &AssignReviewerRequest{ReviewerID: 3}, 1, false, "", ""), "审核人已停用")
+	require.NoError(t, service.AssignReviewer(record.ID, &AssignReviewerRequest{ReviewerID: 2}, 1, false, "", ""))
+
+	// 创建者不能审核自己的记录，无关用户看不到记录
+	_, err = service.Approve(record.ID, &RecordReviewRequest{}, 1, false, "", "")
+	assert.EqualError(t, err, "无权执行该状态流转")
+	_, err = service.Approve(record.ID, &RecordReviewRequest{}, 3, false, "", "")
+	assert.EqualError(t, err, "记录不存在或无权访问")
+
+	// 驳回需要意见
+	_, err = service.Reject(record.ID, &RecordReviewRequest{}, 2, false, "", "")
+	assert.EqualError(t, err, "该操作需要填写意见")
+	_, err = service.Reject(record.ID, &RecordReviewRequest{Comment: "缺少数据"}, 2, false, "", "")
+	require.NoError(t, err)
+
+	_, err = service.Transition(record.ID, &RecordTransitionRequest{ToStatus: "pending"}, 1, false, "", "")
+	require.NoError(t, err)
+	_, err = service.Approve(record.ID, &RecordReviewRequest{Comment: "通过"}, 2, false, "", "")
+	require.NoError(t, err)
+
+	history, err := service.GetStatusHistory(record.ID, 1, false)
+	require.NoError(t, err)
+	require.Len(t, history, 5)
+	assert.Equal(t, "submit", history[0].Action)
+	assert.Equal(t, "assign_reviewer", history[1].Action)
+	assert.Equal(t, "reject", history[2].Action)
+	assert.Equal(t, "缺少数据", history[2].Comment)
+	assert.Equal(t, "approved", history[4].ToStatus)
+	assert.Equal(t, "reviewer", history[4].Username)
+}
+
+func TestRecordWorkflowService_BatchTransition(t *testing.T) {
+	db, service := setupWorkflowTest(t)
+
+	draft := models.Record{Type: "review", Title: "A", Status: "draft", CreatedBy: 1}
+	approved := models.Record{Type: "review", Title: "B", Status: "approved", CreatedBy: 1}
+	foreign := models.Record{Type: "review", Title: "C", Status: "draft", CreatedBy: 3}
+	for _, r := range []*models.Record{&draft, &approved, &foreign} {
+		require.NoError(t, db.Create(r).Error)
+	}
+
+	result, err := service.BatchTransition([]uint{draft.ID, approved.ID, foreign.ID}, "pending", 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{draft.ID}, result.Updated)
+	assert.Len(t, result.Failed, 2)
+	assert.Contains(t, result.Failed, approved.ID)
+	assert.Contains(t, result.Failed, foreign.ID)
+
+	var histories int64
+	db.Model(&models.RecordStatusHistory{}).Count(&histories)
+	assert.Equal(t, int64(1), histories)
+
+	_, err = service.BatchTransition([]uint{draft.ID}, "unknown", 1, false)
+	require.NoError(t, err)
+}
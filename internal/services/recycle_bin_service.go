@@ -0,0 +1,488 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// RecycleBinService 回收站服务（软删除实体的查看、恢复与彻底删除）
+type RecycleBinService struct {
+	db           *gorm.DB
+	auditService *AuditService
+	fileService  *FileService
+}
+
+// NewRecycleBinService 创建回收站服务
+func NewRecycleBinService(db *gorm.DB, auditService *AuditService, fileService *FileService) *RecycleBinService {
+	return &RecycleBinService{
+		db:           db,
+		auditService: auditService,
+		fileService:  fileService,
+	}
+}
+
+// 回收站支持的实体类型，与实体关联使用相同的类型标识
+var recycleBinEntityTypes = []string{models.LinkEntityRecord, models.LinkEntityTicket, models.LinkEntityFile}
+
+// RecycleBinQuery 回收站查询参数
+type RecycleBinQuery struct {
+	EntityType string `form:"entity_type" binding:"omitempty,oneof=record ticket file"`
+	Page       int    `form:"page,default=1"`
+	PageSize   int    `form:"page_size,default=20"`
+}
+
+// RecycleBinItem 回收站条目
+type RecycleBinItem struct {
+	EntityType string `json:"entity_type"`
+	EntityID   uint   `json:"entity_id"`
+	Title      string `json:"title"`
+	OwnerID    uint   `json:"owner_id"`
+	DeletedAt  string `json:"deleted_at"`
+	PurgeAt    string `json:"purge_at,omitempty"` // 到期自动清理时间，未启用自动清理时为空
+
+	deletedAt time.Time
+}
+
+// RecycleBinListResponse 回收站列表响应
+type RecycleBinListResponse struct {
+	Items         []RecycleBinItem `json:"items"`
+	Total         int64            `json:"total"`
+	Page          int              `json:"page"`
+	PageSize      int              `json:"page_size"`
+	RetentionDays int              `json:"retention_days"`
+}
+
+// recycleBinRow 各实体表查询的统一结构
+type recycleBinRow struct {
+	ID        uint
+	Title     string
+	OwnerID   uint
+	DeletedAt time.Time
+}
+
+// recycleBinSpec 实体在回收站中的表结构描述
+type recycleBinSpec struct {
+	model       interface{}
+	table       string
+	titleColumn string
+	ownerColumn string
+}
+
+func recycleBinSpecFor(entityType string) (*recycleBinSpec, error) {
+	switch entityType {
+	case models.LinkEntityRecord:
+		return &recycleBinSpec{model: &models.Record{}, table: "records", titleColumn: "title", ownerColumn: "created_by"}, nil
+	case models.LinkEntityTicket:
+		return &recycleBinSpec{model: &models.Ticket{}, table: "tickets", titleColumn: "title", ownerColumn: "creator_id"}, nil
+	case models.LinkEntityFile:
+		return &recycleBinSpec{model: &models.File{}, table: "files", titleColumn: "original_name", ownerColumn: "uploaded_by"}, nil
+	}
+	return nil, fmt.Errorf("无效的实体类型: %s", entityType)
+}
+
+// deletedQuery 构建已删除实体查询，非管理员只能看到自己的数据
+func (s *RecycleBinService) deletedQuery(spec *recycleBinSpec, userID uint, hasAllPermission bool) *gorm.DB {
+	query := s.db.Unscoped().Model(spec.model).Where("deleted_at IS NOT NULL")
+	if !hasAllPermission {
+		query = query.Where(spec.ownerColumn+" = ?", userID)
+	}
+	return query
+}
+
+// ListDeleted 列出回收站中的实体
+func (s *RecycleBinService) ListDeleted(query *RecycleBinQuery, userID uint, hasAllPermission bool) (*RecycleBinListResponse, error) {
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.PageSize <= 0 || query.PageSize > 100 {
+		query.PageSize = 20
+	}
+
+	entityTypes := recycleBinEntityTypes
+	if query.EntityType != "" {
+		entityTypes = []string{query.EntityType}
+	}
+
+	// 每类实体最多取前 page*pageSize 条，合并排序后再分页
+	limit := query.Page * query.PageSize
+	var total int64
+	var items []RecycleBinItem
+	for _, entityType := range entityTypes {
+		spec, err := recycleBinSpecFor(entityType)
+		if err != nil {
+			return nil, err
+		}
+
+		var count int64
+		if err := s.deletedQuery(spec, userID, hasAllPermission).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("获取回收站数据失败: %w", err)
+		}
+		total += count
+		if count == 0 {
+			continue
+		}
+
+		var rows []recycleBinRow
+		err = s.deletedQuery(spec, userID, hasAllPermission).
+			Select(fmt.Sprintf("id, %s AS title, %s AS owner_id, deleted_at", spec.titleColumn, spec.ownerColumn)).
+			Order("deleted_at DESC").
+			Limit(limit).
+			Scan(&rows).Error
+		if err != nil {
+			return nil, fmt.Errorf("获取回收站数据失败: %w", err)
+		}
+		for _, row := range rows {
+			items = append(items, RecycleBinItem{
+				EntityType: entityType,
+				EntityID:   row.ID,
+				Title:      row.Title,
+				OwnerID:    row.OwnerID,
+				deletedAt:  row.DeletedAt,
+			})
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].deletedAt.After(items[j].deletedAt)
+	})
+
+	retentionDays := s.RetentionDays()
+	start := (query.Page - 1) * query.PageSize
+	page := []RecycleBinItem{}
+	for i := start; i < len(items) && i < start+query.PageSize; i++ {
+		item := items[i]
+		item.DeletedAt = item.deletedAt.Format("2006-01-02 15:04:05")
+		if retentionDays > 0 {
+			item.PurgeAt = item.deletedAt.AddDate(0, 0, retentionDays).Format("2006-01-02 15:04:05")
+		}
+		page = append(page, item)
+	}
+
+	return &RecycleBinListResponse{
+		Items:         page,
+		Total:         total,
+		Page:          query.Page,
+		PageSize:      query.PageSize,
+		RetentionDays: retentionDays,
+	}, nil
+}
+
+// Restore 恢复已删除的实体
+func (s *RecycleBinService) Restore(entityType string, id uint, userID uint, hasAllPermission bool, ipAddress, userAgent string) error {
+	spec, err := recycleBinSpecFor(entityType)
+	if err != nil {
+		return err
+	}
+
+	var row recycleBinRow
+	if err := s.findDeleted(spec, id, userID, hasAllPermission, &row); err != nil {
+		return err
+	}
+
+	if err := s.checkRestoreConflict(entityType, id); err != nil {
+		return err
+	}
+
+	result := s.db.Unscoped().Model(spec.model).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return fmt.Errorf("恢复失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("回收站中不存在该数据")
+	}
+
+	s.audit(userID, "RESTORE", entityType, id, row.Title, ipAddress, userAgent)
+	return nil
+}
+
+// Purge 彻底删除回收站中的实体（包括磁盘文件）
+func (s *RecycleBinService) Purge(entityType string, id uint, userID uint, hasAllPermission bool, ipAddress, userAgent string) error {
+	spec, err := recycleBinSpecFor(entityType)
+	if err != nil {
+		return err
+	}
+
+	var row recycleBinRow
+	if err := s.findDeleted(spec, id, userID, hasAllPermission, &row); err != nil {
+		return err
+	}
+
+	if err := s.purgeEntity(entityType, id, userID, hasAllPermission, ipAddress, userAgent); err != nil {
+		return err
+	}
+
+	s.audit(userID, "PURGE", entityType, id, row.Title, ipAddress, userAgent)
+	return nil
+}
+
+// RetentionDays 回收站保留天数，0 表示不自动清理
+func (s *RecycleBinService) RetentionDays() int {
+	days := getConfigInt(s.db, "recycle_bin", "retention_days", 30)
+	if days < 0 {
+		return 0
+	}
+	return days
+}
+
+// PurgeExpired 彻底删除超过保留期的实体，返回清理数量
+func (s *RecycleBinService) PurgeExpired() (int, error) {
+	retentionDays := s.RetentionDays()
+	if retentionDays == 0 {
+		return 0, nil
+	}
+	cutoff := time.Now().AddDate(0, 0, -retentionDays)
+	systemUserID, err := SystemUserID(s.db)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, entityType := range recycleBinEntityTypes {
+		spec, _ := recycleBinSpecFor(entityType)
+
+		var rows []recycleBinRow
+		err := s.deletedQuery(spec, 0, true).
+			Select(fmt.Sprintf("id, %s AS title, %s AS owner_id, deleted_at", spec.titleColumn, spec.ownerColumn)).
+			Where("deleted_at < ?", cutoff).
+			Scan(&rows).Error
+		if err != nil {
+			return purged, fmt.Errorf("获取过期数据失败: %w", err)
+		}
+
+		for _, row := range rows {
+			// 系统自动清理不受上传者限制
+			if err := s.purgeEntity(entityType, row.ID, systemUserID, true, "", ""); err != nil {
+				return purged, err
+			}
+			purged++
+			if err := s.audit(systemUserID, "AUTO_PURGE", entityType, row.ID, row.Title, "", ""); err != nil {
+				return purged, err
+			}
+		}
+	}
+	return purged, nil
+}
+
+// findDeleted 查找回收站中的实体
+func (s *RecycleBinService) findDeleted(spec *recycleBinSpec, id uint, userID uint, hasAllPermission bool, row *recycleBinRow) error {
+	err := s.deletedQuery(spec, userID, hasAllPermission).
+		Select(fmt.Sprintf("id, %s AS title, %s AS owner_id, deleted_at", spec.titleColumn, spec.ownerColumn)).
+		Where("id = ?", id).
+		Limit(1).
+		Scan(row).Error
+	if err != nil {
+		return fmt.Errorf("获取回收站数据失败: %w", err)
+	}
+	if row.ID == 0 {
+		return fmt.Errorf("回收站中不存在该数据")
+	}
+	return nil
+}
+
+// checkRestoreConflict 恢复前的冲突检查
+func (s *RecycleBinService) checkRestoreConflict(entityType string, id uint) error {
+	switch entityType {
+	case models.LinkEntityRecord:
+		var record models.Record
+		if err := s.db.Unscoped().First(&record, id).Error; err != nil {
+			return fmt.Errorf("获取记录失败: %w", err)
+		}
+		var recordType models.RecordType
+		if err := s.db.Where("name = ?", record.Type).First(&recordType).Error; err != nil {
+			return fmt.Errorf("记录类型 %s 已不存在，无法恢复", record.Type)
+		}
+		if !recordType.IsActive {
+			return fmt.Errorf("记录类型 %s 已禁用，无法恢复", record.Type)
+		}
+		existing, err := findDuplicateRecord(s.db, record.Type, record.UniqueKey, record.ID)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return fmt.Errorf("与现有记录 %d 唯一键重复，无法恢复", existing.ID)
+		}
+	case models.LinkEntityTicket:
+		var ticket models.Ticket
+		if err := s.db.Unscoped().First(&ticket, id).Error; err != nil {
+			return fmt.Errorf("获取工单失败: %w", err)
+		}
+		var creatorCount int64
+		s.db.Model(&models.User{}).Where("id = ?", ticket.CreatorID).Count(&creatorCount)
+		if creatorCount == 0 {
+			return fmt.Errorf("工单创建者已不存在，无法恢复")
+		}
+	case models.LinkEntityFile:
+		var file models.File
+		if err := s.db.Unscoped().First(&file, id).Error; err != nil {
+			return fmt.Errorf("获取文件失败: %w", err)
+		}
+		if _, err := os.Stat(file.Path); err != nil {
+			return fmt.Errorf("文件已不在磁盘上，无法恢复")
+		}
+	}
+	return nil
+}
+
+// purgeEntity 彻底删除实体及其从属数据；实体软删除时保留的附件与关联在此一并清理
+func (s *RecycleBinService) purgeEntity(entityType string, id uint, userID uint, hasAllPermission bool, ipAddress, userAgent string) error {
+	var diskFiles []string
+	var detachedFiles []uint
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := deleteEntityLinks(tx, entityType, []uint{id}); err != nil {
+			return err
+		}
+		if models.IsValidTagEntityType(entityType) {
+			if err := deleteEntityTags(tx, entityType, []uint{id}); err != nil {
+				return err
+			}
+		}
+		if models.IsValidWatchEntityType(entityType) {
+			if err := deleteEntityWatchers(tx, entityType, []uint{id}); err != nil {
+				return err
+			}
+		}
+
+		switch entityType {
+		case models.LinkEntityRecord:
+			fileIDs, err := detachRecordFiles(tx, []uint{id})
+			if err != nil {
+				return err
+			}
+			detachedFiles = fileIDs
+			if err := tx.Where("record_id = ?", id).Delete(&models.RecordStatusHistory{}).Error; err != nil {
+				return fmt.Errorf("删除记录状态历史失败: %w", err)
+			}
+			if err := deleteRecordComments(tx, []uint{id}); err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Delete(&models.Record{}, id).Error; err != nil {
+				return fmt.Errorf("彻底删除记录失败: %w", err)
+			}
+		case models.LinkEntityTicket:
+			var attachments []models.TicketAttachment
+			tx.Where("ticket_id = ?", id).Find(&attachments)
+			for _, attachment := range attachments {
+				if attachment.FilePath != "" {
+					diskFiles = append(diskFiles, attachment.FilePath)
+				}
+			}
+			dependents := []interface{}{&models.TicketAttachment{}, &models.TicketHistory{}, &models.TicketSLA{}, &models.TicketSurvey{}, &models.TicketFieldValue{}}
+			for _, dependent := range dependents {
+				if err := tx.Where("ticket_id = ?", id).Delete(dependent).Error; err != nil {
+					return fmt.Errorf("删除工单数据失败: %w", err)
+				}
+			}
+			// 来信保留，仅解除与工单及其评论的关联
+			err := tx.Model(&models.InboundEmail{}).Where("ticket_id = ?", id).
+				Updates(map[string]interface{}{"ticket_id": nil, "comment_id": nil}).Error
+			if err != nil {
+				return fmt.Errorf("解除来信关联失败: %w", err)
+			}
+			if err := tx.Unscoped().Where("ticket_id = ?", id).Delete(&models.TicketComment{}).Error; err != nil {
+				return fmt.Errorf("删除工单评论失败: %w", err)
+			}
+			if err := tx.Unscoped().Where("ticket_id = ?", id).Delete(&models.TicketWorklog{}).Error; err != nil {
+				return fmt.Errorf("删除工单工时失败: %w", err)
+			}
+			if err := tx.Unscoped().Delete(&models.Ticket{}, id).Error; err != nil {
+				return fmt.Errorf("彻底删除工单失败: %w", err)
+			}
+		case models.LinkEntityFile:
+			var file models.File
+			if err := tx.Unscoped().First(&file, id).Error; err != nil {
+				return fmt.Errorf("获取文件失败: %w", err)
+			}
+			diskFiles = append(diskFiles, file.Path)
+			if err := tx.Where("file_id = ?", id).Delete(&models.RecordFile{}).Error; err != nil {
+				return fmt.Errorf("删除记录附件关联失败: %w", err)
+			}
+			if err := tx.Unscoped().Delete(&models.File{}, id).Error; err != nil {
+				return fmt.Errorf("彻底删除文件失败: %w", err)
+			}
+		}
+
+		// 相同内容的文件共用磁盘路径，仍被其他文件或工单附件引用时保留
+		paths, err := unreferencedPaths(tx, diskFiles)
+		if err != nil {
+			return err
+		}
+		diskFiles = paths
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 数据库删除成功后再删除磁盘文件
+	for _, path := range diskFiles {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("Warning: failed to delete physical file %s: %v\n", path, err)
+		}
+	}
+
+	// 按配置清理记录解除关联后不再被引用的附件
+	s.collectDetachedFiles(detachedFiles, userID, hasAllPermission, ipAddress, userAgent)
+	return nil
+}
+
+// unreferencedPaths 过滤出不再被文件（含回收站中的文件）或工单附件引用的磁盘路径
+func unreferencedPaths(tx *gorm.DB, paths []string) ([]string, error) {
+	var result []string
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		var files, attachments int64
+		if err := tx.Unscoped().Model(&models.File{}).Where("path = ?", path).Count(&files).Error; err != nil {
+			return nil, fmt.Errorf("检查文件引用失败: %w", err)
+		}
+		if err := tx.Model(&models.TicketAttachment{}).Where("file_path = ?", path).Count(&attachments).Error; err != nil {
+			return nil, fmt.Errorf("检查文件引用失败: %w", err)
+		}
+		if files == 0 && attachments == 0 {
+			result = append(result, path)
+		}
+	}
+	return result, nil
+}
+
+// collectDetachedFiles 按配置清理已解除关联且不再被引用的文件
+func (s *RecycleBinService) collectDetachedFiles(fileIDs []uint, userID uint, hasAllPermission bool, ipAddress, userAgent string) {
+	if len(fileIDs) == 0 || s.fileService == nil {
+		return
+	}
+	if !getConfigBool(s.db, "storage", "record_attachment_gc", false) {
+		return
+	}
+	if _, err := s.fileService.DeleteUnreferencedFiles(fileIDs, userID, hasAllPermission, ipAddress, userAgent); err != nil {
+		fmt.Printf("Warning: failed to collect detached files: %v\n", err)
+	}
+}
+
+// audit 记录回收站操作审计日志
+func (s *RecycleBinService) audit(userID uint, action, entityType string, id uint, title, ipAddress, userAgent string) error {
+	if s.auditService == nil {
+		return nil
+	}
+	return s.auditService.CreateAuditLog(&AuditLogRequest{
+		UserID:       userID,
+		Action:       action,
+		ResourceType: entityType,
+		ResourceID:   id,
+		OldValues:    map[string]interface{}{"title": title},
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+	})
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// setupRecycleBinTest 创建回收站测试环境
+func setupRecycleBinTest(t *testing.T) (*gorm.DB, *RecycleBinService) {
+	db := newServiceTestDB(t)
+
+	require.NoError(t, db.Create(&models.User{Username: "owner", Email: "owner@example.com", PasswordHash: "x", IsActive: true}).Error)
+	require.NoError(t, db.Create(&models.RecordType{Name: "note", DisplayName: "笔记", TableName: "records_note", IsActive: true}).Error)
+
+	auditService := NewAuditService(db)
+	return db, NewRecycleBinService(db, auditService, NewFileService(db, auditService))
+}
+
+func TestRecycleBinService_ListAndRestore(t *testing.T) {
+	db, service := setupRecycleBinTest(t)
+
+	mine := models.Record{Type: "note", Title: "我的记录", CreatedBy: 1}
+	others := models.Record{Type: "note", Title: "他人记录", CreatedBy: 2}
+	orphan := models.Record{Type: "removed", Title: "类型已删除", CreatedBy: 1}
+	ticket := models.Ticket{Title: "工单", Type: models.TicketTypeBug, CreatorID: 1}
+	for _, v := range []interface{}{&mine, &others, &orphan, &ticket} {
+		require.NoError(t, db.Create(v).Error)
+		require.NoError(t, db.Delete(v).Error)
+	}
+
+	list, err := service.ListDeleted(&RecycleBinQuery{}, 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), list.Total)
+	assert.Equal(t, 30, list.RetentionDays)
+	assert.NotEmpty(t, list.Items[0].PurgeAt)
+
+	list, err = service.ListDeleted(&RecycleBinQuery{EntityType: models.LinkEntityRecord}, 1, true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), list.Total)
+
+	// 非所有者无法恢复
+	assert.EqualError(t, service.Restore(models.LinkEntityRecord, others.ID, 1, false, "", ""), "回收站中不存在该数据")
+
+	// 记录类型已不存在时冲突
+	assert.EqualError(t, service.Restore(models.LinkEntityRecord, orphan.ID, 1, false, "", ""), "记录类型 removed 已不存在，无法恢复")
+
+	require.NoError(t, service.Restore(models.LinkEntityRecord, mine.ID, 1, false, "", ""))
+	assert.NoError(t, db.First(&models.Record{}, mine.ID).Error)
+
+	var audits int64
+	db.Model(&models.AuditLog{}).Where("action = ? AND resource_type = ?", "RESTORE", models.LinkEntityRecord).Count(&audits)
+	assert.Equal(t, int64(1), audits)
+}
+
+func TestRecycleBinService_RestoreKeepsAttachmentsAndLinks(t *testing.T) {
+	db, service := setupRecycleBinTest(t)
+	recordService := NewRecordService(db, NewRecordTypeService(db), nil, nil, nil)
+
+	record := models.Record{Type: "note", Title: "带附件的记录", CreatedBy: 1}
+	other := models.Record{Type: "note", Title: "关联记录", CreatedBy: 1}
+	require.NoError(t, db.Create(&record).Error)
+	require.NoError(t, db.Create(&other).Error)
+	file := models.File{Filename: "a.txt", OriginalName: "a.txt", MimeType: "text/plain", Path: filepath.Join(t.TempDir(), "a.txt"), UploadedBy: 1}
+	require.NoError(t, db.Create(&file).Error)
+	require.NoError(t, db.Create(&models.RecordFile{RecordID: record.ID, FileID: file.ID, CreatedBy: 1}).Error)
+	_, err := NewLinkService(db, nil).CreateLink(&CreateLinkRequest{
+		SourceType: models.LinkEntityRecord,
+		SourceID:   record.ID,
+		TargetType: models.LinkEntityRecord,
+		TargetID:   other.ID,
+		LinkType:   models.LinkTypeRelatesTo,
+	}, &LinkAccessScope{UserID: 1}, "", "")
+	require.NoError(t, err)
+
+	require.NoError(t, recordService.DeleteRecord(record.ID, 1, false, "", ""))
+	require.NoError(t, service.Restore(models.LinkEntityRecord, record.ID, 1, false, "", ""))
+
+	files, err := recordService.GetRecordFiles(record.ID, 1, false)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, file.ID, files[0].FileID)
+	links, err := NewLinkService(db, nil).GetEntityLinks(models.LinkEntityRecord, record.ID, "", &LinkAccessScope{UserID: 1})
+	require.NoError(t, err)
+	require.Len(t, links, 1)
+	assert.Equal(t, other.ID, links[0].EntityID)
+}
+
+func TestRecycleBinService_PurgeRemovesFileFromDisk(t *testing.T) {
+	db, service := setupRecycleBinTest(t)
+
+	path := filepath.Join(t.TempDir(), "data.txt")
+	require.NoError(t, os.WriteFile(path, []byte("data"), 0644))
+	file := models.File{Filename: "data.txt", OriginalName: "data.txt", MimeType: "text/plain", Size: 4, Path: path, UploadedBy: 1}
+	require.NoError(t, db.Create(&file).Error)
+	require.NoError(t, db.Create(&models.RecordFile{RecordID: 99, FileID: file.ID, CreatedBy: 1}).Error)
+	require.NoError(t, db.Delete(&file).Error)
+
+	// 未删除的数据不能彻底删除
+	live := models.Record{Type: "note", Title: "正常记录", CreatedBy: 1}
+	require.NoError(t, db.Create(&live).Error)
+	assert.EqualError(t, service.Purge(models.LinkEntityRecord, live.ID, 1, false, "", ""), "回收站中不存在该数据")
+
+	require.NoError(t, service.Purge(models.LinkEntityFile, file.ID, 1, false, "", ""))
+
+	assert.Error(t, db.Unscoped().First(&models.File{}, file.ID).Error)
+	_, statErr := os.Stat(path)
+	assert.True(t, os.IsNotExist(statErr))
+	var refs int64
+	db.Model(&models.RecordFile{}).Where("file_id = ?", file.ID).Count(&refs)
+	assert.Equal(t, int64(0), refs)
+}
+
+func TestRecycleBinService_FileLinksKeptUntilPurge(t *testing.T) {
+	db, service := setupRecycleBinTest(t)
+
+	record := models.Record{Type: "note", Title: "记录", CreatedBy: 1}
+	require.NoError(t, db.Create(&record).Error)
+	file := models.File{Filename: "b.txt", OriginalName: "b.txt", MimeType: "text/plain", Path: filepath.Join(t.TempDir(), "b.txt"), UploadedBy: 1}
+	require.NoError(t, db.Create(&file).Error)
+	require.NoError(t, db.Create(&models.EntityLink{SourceType: models.LinkEntityRecord, SourceID: record.ID,
+		TargetType: models.LinkEntityFile, TargetID: file.ID, LinkType: models.LinkTypeRelatesTo, CreatedBy: 1}).Error)
+
+	// 软删除保留关联，彻底删除时一并清理
+	require.NoError(t, NewFileService(db, nil).DeleteFile(file.ID, 1, false, "", ""))
+	var count int64
+	db.Model(&models.EntityLink{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	require.NoError(t, service.Purge(models.LinkEntityFile, file.ID, 1, false, "", ""))
+	db.Model(&models.EntityLink{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestRecycleBinService_PurgeKeepsSharedDiskFiles(t *testing.T) {
+	db, service := setupRecycleBinTest(t)
+
+	shared := filepath.Join(t.TempDir(), "shared.txt")
+	own := filepath.Join(t.TempDir(), "own.txt")
+	for _, path := range []string{shared, own} {
+		require.NoError(t, os.WriteFile(path, []byte("data"), 0644))
+	}
+	file := models.File{Filename: "shared.txt", OriginalName: "shared.txt", MimeType: "text/plain", Size: 4, Path: shared, UploadedBy: 1}
+	require.NoError(t, db.Create(&file).Error)
+	ticket := models.Ticket{Title: "工单", Type: models.TicketTypeBug, CreatorID: 1}
+	other := models.Ticket{Title: "其他工单", Type: models.TicketTypeBug, CreatorID: 1}
+	require.NoError(t, db.Create(&ticket).Error)
+	require.NoError(t, db.Create(&other).Error)
+	for _, attachment := range []models.TicketAttachment{
+		{TicketID: ticket.ID, FileName: "shared.txt", FilePath: shared, UploadedBy: 1},
+		{TicketID: ticket.ID, FileName: "own.txt", FilePath: own, UploadedBy: 1},
+		{TicketID: other.ID, FileName: "own.txt", FilePath: own, UploadedBy: 1},
+	} {
+		require.NoError(t, db.Create(&attachment).Error)
+	}
+
+	// 路径仍被文件或其他工单附件引用时保留磁盘文件
+	require.NoError(t, db.Delete(&ticket).Error)
+	require.NoError(t, service.Purge(models.LinkEntityTicket, ticket.ID, 1, false, "", ""))
+	_, err := os.Stat(shared)
+	assert.NoError(t, err)
+	_, err = os.Stat(own)
+	assert.NoError(t, err)
+
+	// 最后一个引用清理后删除磁盘文件
+	require.NoError(t, db.Delete(&other).Error)
+	require.NoError(t, service.Purge(models.LinkEntityTicket, other.ID, 1, false, "", ""))
+	_, err = os.Stat(own)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(shared)
+	assert.NoError(t, err)
+}
+
+func TestRecycleBinService_PurgeTicketRemovesDependents(t *testing.T) {
+	db, service := setupRecycleBinTest(t)
+
+	ticket := models.Ticket{Title: "工单", Type: models.TicketTypeBug, CreatorID: 1}
+	require.NoError(t, db.Create(&ticket).Error)
+	comment := models.TicketComment{TicketID: ticket.ID, UserID: 1, Content: "邮件回复"}
+	require.NoError(t, db.Create(&comment).Error)
+	require.NoError(t, db.Create(&models.TicketSLA{TicketID: ticket.ID, PolicyID: 1, StartedAt: time.Now()}).Error)
+	require.NoError(t, db.Create(&models.TicketSurvey{TicketID: ticket.ID, UserID: 1, TokenHash: "hash", ExpiresAt: time.Now()}).Error)
+	require.NoError(t, db.Create(&models.TicketFieldValue{TicketID: ticket.ID, FieldName: "env", TextValue: "prod"}).Error)
+	mail := models.InboundEmail{MessageID: "<a@example.com>", Status: "processed", TicketID: &ticket.ID, CommentID: &comment.ID}
+	require.NoError(t, db.Create(&mail).Error)
+
+	require.NoError(t, db.Delete(&ticket).Error)
+	require.NoError(t, service.Purge(models.LinkEntityTicket, ticket.ID, 1, false, "", ""))
+
+	for _, dependent := range []interface{}{&models.TicketSLA{}, &models.TicketSurvey{}, &models.TicketFieldValue{}} {
+		var count int64
+		db.Model(dependent).Where("ticket_id = ?", ticket.ID).Count(&count)
+		assert.Equal(t, int64(0), count)
+	}
+	// 来信保留，但不再指向已删除的工单
+	require.NoError(t, db.First(&mail, mail.ID).Error)
+	assert.Nil(t, mail.TicketID)
+	assert.Nil(t, mail.CommentID)
+}
+
+func TestRecycleBinService_PurgeExpired(t *testing.T) {
+	db, service := setupRecycleBinTest(t)
+
+	expired := models.Record{Type: "note", Title: "过期", CreatedBy: 1}
+	recent := models.Record{Type: "note", Title: "最近", CreatedBy: 1}
+	require.NoError(t, db.Create(&expired).Error)
+	require.NoError(t, db.Create(&recent).Error)
+	require.NoError(t, db.Delete(&recent).Error)
+	require.NoError(t, db.Unscoped().Model(&expired).Update("deleted_at", time.Now().AddDate(0, 0, -8)).Error)
+
+	require.NoError(t, db.Create(&models.SystemConfig{Category: "recycle_bin", Key: "retention_days", Value: "7", DataType: "int"}).Error)
+
+	count, err := service.PurgeExpired()
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Error(t, db.Unscoped().First(&models.Record{}, expired.ID).Error)
+	assert.NoError(t, db.Unscoped().First(&models.Record{}, recent.ID).Error)
+
+	// 自动清理记在系统账号下
+	systemUserID, err := SystemUserID(db)
+	require.NoError(t, err)
+	var audit models.AuditLog
+	require.NoError(t, db.Where("action = ?", "AUTO_PURGE").First(&audit).Error)
+	assert.Equal(t, systemUserID, audit.UserID)
+
+	// 保留天数为0时不自动清理
+	db.Model(&models.SystemConfig{}).Where("category = ? AND key = ?", "recycle_bin", "retention_days").Update("value", "0")
+	require.NoError(t, db.Unscoped().Model(&recent).Update("deleted_at", time.Now().AddDate(-1, 0, 0)).Error)
+	count, err = service.PurgeExpired()
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestRecycleBinService_PurgeExpiredWithForeignKeys(t *testing.T) {
+	db := newForeignKeyTestDB(t)
+	service := NewRecycleBinService(db, NewAuditService(db), NewFileService(db, NewAuditService(db)))
+	owner := models.User{Username: "owner", Email: "owner@example.com", PasswordHash: "x", IsActive: true}
+	require.NoError(t, db.Create(&owner).Error)
+	require.NoError(t, db.Create(&models.SystemConfig{Category: "recycle_bin", Key: "retention_days", Value: "7", DataType: "int", UpdatedBy: owner.ID}).Error)
+
+	record := models.Record{Type: "note", Title: "过期记录", CreatedBy: owner.ID}
+	ticket := models.Ticket{Title: "过期工单", Type: models.TicketTypeBug, CreatorID: owner.ID}
+	require.NoError(t, db.Create(&record).Error)
+	require.NoError(t, db.Create(&ticket).Error)
+	expiredAt := time.Now().AddDate(0, 0, -8)
+	require.NoError(t, db.Unscoped().Model(&record).Update("deleted_at", expiredAt).Error)
+	require.NoError(t, db.Unscoped().Model(&ticket).Update("deleted_at", expiredAt).Error)
+
+	count, err := service.PurgeExpired()
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	var audits int64
+	db.Model(&models.AuditLog{}).Where("action = ?", "AUTO_PURGE").Count(&audits)
+	assert.Equal(t, int64(2), audits)
+}
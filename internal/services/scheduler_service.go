@@ -0,0 +1,440 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SchedulerJobFunc 后台任务的执行函数，返回本次运行的结果摘要
+type SchedulerJobFunc func(now time.Time) (string, error)
+
+// schedulerJob 本实例注册的后台任务
+type schedulerJob struct {
+	name        string
+	description string
+	interval    time.Duration
+	run         SchedulerJobFunc
+}
+
+// SchedulerService 后台任务调度服务：按间隔运行已注册的任务，通过数据库中的任务锁保证多实例部署时同一任务只在一个实例上运行
+type SchedulerService struct {
+	db           *gorm.DB
+	auditService *AuditService
+	instance     string
+	lockTTL      time.Duration
+	heartbeat    time.Duration
+	retention    time.Duration
+
+	mu   sync.Mutex
+	jobs []*schedulerJob
+	stop chan struct{}
+	now  func() time.Time
+}
+
+// NewSchedulerService 创建后台任务调度服务，retentionDays 为运行日志保留天数，不大于 0 时不清理
+func NewSchedulerService(db *gorm.DB, auditService *AuditService, lockTTL time.Duration, retentionDays int) *SchedulerService {
+	host, _ := os.Hostname()
+	return &SchedulerService{
+		db:           db,
+		auditService: auditService,
+		instance:     fmt.Sprintf("%s-%d-%d", host, os.Getpid(), time.Now().UnixNano()),
+		lockTTL:      lockTTL,
+		heartbeat:    lockTTL / 3,
+		retention:    time.Duration(retentionDays) * 24 * time.Hour,
+		now:          time.Now,
+	}
+}
+
+// Register 注册后台任务并同步任务定义到数据库，已有任务保留其暂停状态与运行记录
+func (s *SchedulerService) Register(name, description string, interval time.Duration, run SchedulerJobFunc) error {
+	if interval < time.Second {
+		return fmt.Errorf("任务 %s 的运行间隔不能小于1秒", name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range s.jobs {
+		if job.name == name {
+			return fmt.Errorf("任务 %s 已注册", name)
+		}
+	}
+
+	now := s.now()
+	nextRunAt := now.Add(interval)
+	var job models.ScheduledJob
+	err := s.db.Where("name = ?", name).First(&job).Error
+	if err == gorm.ErrRecordNotFound {
+		job = models.ScheduledJob{
+			Name:            name,
+			Description:     description,
+			IntervalSeconds: int(interval / time.Second),
+			NextRunAt:       &nextRunAt,
+		}
+		// 其他实例可能同时创建，创建失败时重新读取
+		if err := s.db.Create(&job).Error; err != nil {
+			if err := s.db.Where("name = ?", name).First(&job).Error; err != nil {
+				return fmt.Errorf("创建任务 %s 失败: %w", name, err)
+			}
+		}
+	} else if err != nil {
+		return fmt.Errorf("获取任务 %s 失败: %w", name, err)
+	}
+
+	updates := map[string]interface{}{
+		"description":      description,
+		"interval_seconds": int(interval / time.Second),
+	}
+	// 运行间隔缩短后不必等到按原间隔计算的时间
+	if job.NextRunAt == nil || job.NextRunAt.After(nextRunAt) {
+		updates["next_run_at"] = nextRunAt
+	}
+	if err := s.db.Model(&models.ScheduledJob{}).Where("id = ?", job.ID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("更新任务 %s 失败: %w", name, err)
+	}
+
+	s.jobs = append(s.jobs, &schedulerJob{name: name, description: description, interval: interval, run: run})
+	return nil
+}
+
+// Start 启动后台调度，每隔 tick 检查并运行到期的任务
+func (s *SchedulerService) Start(tick time.Duration) {
+	s.mu.Lock()
+	if s.stop != nil {
+		s.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	s.stop = stop
+	s.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				s.RunDue()
+			}
+		}
+	}()
+}
+
+// Stop 停止后台调度，正在运行的任务会执行完毕
+func (s *SchedulerService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// RunDue 运行所有到期且未暂停的任务，已被其他实例锁定的任务跳过，返回本实例的运行日志
+func (s *SchedulerService) RunDue() []models.ScheduledJobRun {
+	runs := []models.ScheduledJobRun{}
+	for _, job := range s.registered() {
+		run, err := s.runJob(job, models.JobTriggerSchedule, nil)
+		if err != nil {
+			fmt.Printf("Warning: failed to run scheduled job %s: %v\n", job.name, err)
+			continue
+		}
+		if run != nil {
+			runs = append(runs, *run)
+		}
+	}
+	return runs
+}
+
+// RunNow 立即在本实例运行任务，暂停的任务也可手动运行
+func (s *SchedulerService) RunNow(name string, userID uint, ipAddress, userAgent string) (*models.ScheduledJobRun, error) {
+	job := s.findJob(name)
+	if job == nil {
+		return nil, fmt.Errorf("任务不存在")
+	}
+	run, err := s.runJob(job, models.JobTriggerManual, &userID)
+	if err != nil {
+		return nil, err
+	}
+	if run == nil {
+		return nil, fmt.Errorf("任务正在运行中，请稍后重试")
+	}
+
+	s.audit("RUN", run.JobID, name, nil, map[string]interface{}{"run_id": run.ID, "status": run.Status}, userID, ipAddress, userAgent)
+	return run, nil
+}
+
+// ListJobs 按注册顺序获取本实例注册的任务
+func (s *SchedulerService) ListJobs() ([]models.ScheduledJob, error) {
+	registered := s.registered()
+	names := make([]string, 0, len(registered))
+	for _, job := range registered {
+		names = append(names, job.name)
+	}
+
+	var rows []models.ScheduledJob
+	if err := s.db.Where("name IN ?", names).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("获取任务列表失败: %w", err)
+	}
+	byName := make(map[string]models.ScheduledJob, len(rows))
+	for _, row := range rows {
+		byName[row.Name] = row
+	}
+	jobs := make([]models.ScheduledJob, 0, len(rows))
+	for _, name := range names {
+		if row, ok := byName[name]; ok {
+			jobs = append(jobs, row)
+		}
+	}
+	return jobs, nil
+}
+
+// GetJob 获取任务
+func (s *SchedulerService) GetJob(name string) (*models.ScheduledJob, error) {
+	if s.findJob(name) == nil {
+		return nil, fmt.Errorf("任务不存在")
+	}
+	var job models.ScheduledJob
+	if err := s.db.Where("name = ?", name).First(&job).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("任务不存在")
+		}
+		return nil, fmt.Errorf("获取任务失败: %w", err)
+	}
+	return &job, nil
+}
+
+// ListRuns 分页获取任务的运行日志，最近的在前
+func (s *SchedulerService) ListRuns(name string, page, pageSize int) ([]models.ScheduledJobRun, int64, error) {
+	job, err := s.GetJob(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	query := s.db.Model(&models.ScheduledJobRun{}).Where("job_id = ?", job.ID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取运行日志失败: %w", err)
+	}
+	var runs []models.ScheduledJobRun
+	if err := query.Order("started_at DESC, id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&runs).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取运行日志失败: %w", err)
+	}
+	return runs, total, nil
+}
+
+// SetPaused 暂停或恢复任务，暂停对所有实例生效
+func (s *SchedulerService) SetPaused(name string, paused bool, userID uint, ipAddress, userAgent string) (*models.ScheduledJob, error) {
+	job, err := s.GetJob(name)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{"paused": paused, "paused_by": nil}
+	if paused {
+		updates["paused_by"] = userID
+	} else if job.NextRunAt == nil || job.NextRunAt.Before(s.now()) {
+		// 恢复后按间隔重新计时，不立即补跑暂停期间错过的运行
+		updates["next_run_at"] = s.now().Add(time.Duration(job.IntervalSeconds) * time.Second)
+	}
+	if err := s.db.Model(&models.ScheduledJob{}).Where("id = ?", job.ID).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("更新任务状态失败: %w", err)
+	}
+
+	action := "RESUME"
+	if paused {
+		action = "PAUSE"
+	}
+	s.audit(action, job.ID, name, map[string]interface{}{"paused": job.Paused}, map[string]interface{}{"paused": paused}, userID, ipAddress, userAgent)
+	return s.GetJob(name)
+}
+
+// runJob 获取任务锁并运行任务；任务已被锁定，或按计划运行时任务未到期或已暂停，返回 nil
+func (s *SchedulerService) runJob(job *schedulerJob, trigger string, userID *uint) (*models.ScheduledJobRun, error) {
+	startedAt := s.now()
+	claim := s.db.Model(&models.ScheduledJob{}).
+		Where("name = ? AND (locked_until IS NULL OR locked_until < ?)", job.name, startedAt)
+	if trigger == models.JobTriggerSchedule {
+		claim = claim.Where("paused = ? AND (next_run_at IS NULL OR next_run_at <= ?)", false, startedAt)
+	}
+	result := claim.Updates(map[string]interface{}{
+		"locked_by":    s.instance,
+		"locked_until": startedAt.Add(s.lockTTL),
+	})
+	if result.Error != nil {
+		return nil, fmt.Errorf("获取任务锁失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	var row models.ScheduledJob
+	if err := s.db.Where("name = ?", job.name).First(&row).Error; err != nil {
+		s.release(job.name)
+		return nil, fmt.Errorf("获取任务失败: %w", err)
+	}
+	run := models.ScheduledJobRun{
+		JobID:       row.ID,
+		JobName:     job.name,
+		Instance:    s.instance,
+		Trigger:     trigger,
+		TriggeredBy: userID,
+		Status:      models.JobRunStatusRunning,
+		StartedAt:   startedAt,
+	}
+	if err := s.db.Create(&run).Error; err != nil {
+		s.release(job.name)
+		return nil, fmt.Errorf("记录运行日志失败: %w", err)
+	}
+
+	// 运行期间定期续期任务锁，避免耗时超过锁有效期的任务被其他实例重复运行
+	done := make(chan struct{})
+	go s.keepLock(row.ID, done)
+	message, runErr := safeRunJob(job.run, startedAt)
+	close(done)
+	finishedAt := s.now()
+	run.FinishedAt = &finishedAt
+	run.DurationMs = finishedAt.Sub(startedAt).Milliseconds()
+	run.Message = truncateString(message, 1000)
+	run.Status = models.JobRunStatusSuccess
+	failures := 0
+	if runErr != nil {
+		run.Status = models.JobRunStatusFailed
+		run.Error = runErr.Error()
+		failures = 1
+		fmt.Printf("Warning: scheduled job %s failed: %v\n", job.name, runErr)
+	}
+	if err := s.db.Save(&run).Error; err != nil {
+		fmt.Printf("Warning: failed to save run log of job %s: %v\n", job.name, err)
+	}
+
+	result = s.db.Model(&models.ScheduledJob{}).
+		Where("id = ? AND locked_by = ?", row.ID, s.instance).
+		Updates(map[string]interface{}{
+			"locked_by":        "",
+			"locked_until":     nil,
+			"next_run_at":      startedAt.Add(job.interval),
+			"last_run_at":      startedAt,
+			"last_status":      run.Status,
+			"last_message":     run.Message,
+			"last_error":       run.Error,
+			"last_duration_ms": run.DurationMs,
+			"run_count":        gorm.Expr("run_count + ?", 1),
+			"failure_count":    gorm.Expr("failure_count + ?", failures),
+		})
+	if result.Error != nil {
+		return nil, fmt.Errorf("更新任务状态失败: %w", result.Error)
+	}
+	// 锁已被其他实例接管时不覆盖其锁与任务状态
+	if result.RowsAffected == 0 {
+		fmt.Printf("Warning: lock of scheduled job %s was taken over by another instance\n", job.name)
+	}
+
+	if s.retention > 0 {
+		s.db.Where("job_id = ? AND started_at < ?", row.ID, startedAt.Add(-s.retention)).Delete(&models.ScheduledJobRun{})
+	}
+	return &run, nil
+}
+
+// keepLock 按心跳间隔延长本实例持有的任务锁，直到 done 关闭或锁已被其他实例接管
+func (s *SchedulerService) keepLock(jobID uint, done <-chan struct{}) {
+	if s.heartbeat <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			result := s.db.Model(&models.ScheduledJob{}).
+				Where("id = ? AND locked_by = ?", jobID, s.instance).
+				Update("locked_until", s.now().Add(s.lockTTL))
+			if result.Error != nil {
+				fmt.Printf("Warning: failed to renew lock of scheduled job %d: %v\n", jobID, result.Error)
+				continue
+			}
+			if result.RowsAffected == 0 {
+				return
+			}
+		}
+	}
+}
+
+// release 释放本实例持有的任务锁
+func (s *SchedulerService) release(name string) {
+	s.db.Model(&models.ScheduledJob{}).
+		Where("name = ? AND locked_by = ?", name, s.instance).
+		Updates(map[string]interface{}{"locked_by": "", "locked_until": nil})
+}
+
+// registered 本实例注册的任务快照
+func (s *SchedulerService) registered() []*schedulerJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*schedulerJob{}, s.jobs...)
+}
+
+// findJob 按名称查找本实例注册的任务
+func (s *SchedulerService) findJob(name string) *schedulerJob {
+	for _, job := range s.registered() {
+		if job.name == name {
+			return job
+		}
+	}
+	return nil
+}
+
+// audit 记录任务管理操作的审计日志
+func (s *SchedulerService) audit(action string, jobID uint, name string, oldValues, newValues map[string]interface{}, userID uint, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	if newValues == nil {
+		newValues = map[string]interface{}{}
+	}
+	newValues["name"] = name
+	s.auditService.CreateAuditLog(&AuditLogRequest{
+		UserID:       userID,
+		Action:       action,
+		ResourceType: "scheduled_job",
+		ResourceID:   jobID,
+		OldValues:    oldValues,
+		NewValues:    newValues,
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+	})
+}
+
+// safeRunJob 运行任务，任务 panic 时转换为错误，避免中断调度
+func safeRunJob(run SchedulerJobFunc, now time.Time) (message string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("任务异常: %v", r)
+		}
+	}()
+	return run(now)
+}
+
+// truncateString 按字符截断字符串
+func truncateString(value string, max int) string {
+	runes := []rune(value)
+	if len(runes) <= max {
+		return value
+	}
+	return string(runes[:max])
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// newTestScheduler 创建使用固定时间的调度服务，模拟一个实例
+func newTestScheduler(db *gorm.DB, instance string, clock *time.Time) *SchedulerService {
+	s := NewSchedulerService(db, NewAuditService(db), 10*time.Minute, 30)
+	s.instance = instance
+	s.now = func() time.Time { return *clock }
+	return s
+}
+
+func TestSchedulerService_RunDue(t *testing.T) {
+	db := newServiceTestDB(t)
+	clock := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	calls := 0
+	job := func(now time.Time) (string, error) {
+		calls++
+		return "完成", nil
+	}
+
+	// 两个实例注册同一任务
+	a := newTestScheduler(db, "a", &clock)
+	b := newTestScheduler(db, "b", &clock)
+	require.NoError(t, a.Register("cleanup", "清理", time.Minute, job))
+	require.NoError(t, b.Register("cleanup", "清理", time.Minute, job))
+	assert.EqualError(t, a.Register("cleanup", "清理", time.Minute, job), "任务 cleanup 已注册")
+	var count int64
+	db.Model(&models.ScheduledJob{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	// 未到期不运行
+	assert.Empty(t, a.RunDue())
+
+	// 到期后只有一个实例运行
+	clock = clock.Add(time.Minute)
+	runs := a.RunDue()
+	require.Len(t, runs, 1)
+	assert.Equal(t, models.JobRunStatusSuccess, runs[0].Status)
+	assert.Equal(t, models.JobTriggerSchedule, runs[0].Trigger)
+	assert.Equal(t, "完成", runs[0].Message)
+	assert.Empty(t, b.RunDue())
+	assert.Equal(t, 1, calls)
+
+	jobRow, err := a.GetJob("cleanup")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), jobRow.RunCount)
+	assert.Equal(t, "", jobRow.LockedBy)
+	assert.Nil(t, jobRow.LockedUntil)
+	assert.True(t, jobRow.NextRunAt.Equal(clock.Add(time.Minute)))
+
+	// 其他实例持有未过期的锁时跳过，锁过期后可接管
+	clock = clock.Add(time.Minute)
+	lockedUntil := clock.Add(5 * time.Minute)
+	require.NoError(t, db.Model(&models.ScheduledJob{}).Where("name = ?", "cleanup").Updates(map[string]interface{}{"locked_by": "b", "locked_until": lockedUntil}).Error)
+	assert.Empty(t, a.RunDue())
+	_, err = a.RunNow("cleanup", 1, "", "")
+	assert.EqualError(t, err, "任务正在运行中，请稍后重试")
+	clock = clock.Add(6 * time.Minute)
+	require.Len(t, a.RunDue(), 1)
+	assert.Equal(t, 2, calls)
+}
+
+func TestSchedulerService_Management(t *testing.T) {
+	db := newServiceTestDB(t)
+	clock := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	s := newTestScheduler(db, "a", &clock)
+	fail := true
+	require.NoError(t, s.Register("sync", "同步", time.Minute, func(now time.Time) (string, error) {
+		if fail {
+			return "", errors.New("连接超时")
+		}
+		return "同步 3 条", nil
+	}))
+	require.NoError(t, s.Register("broken", "异常", time.Hour, func(now time.Time) (string, error) {
+		panic("nil map")
+	}))
+	_, err := s.GetJob("missing")
+	assert.EqualError(t, err, "任务不存在")
+
+	jobs, err := s.ListJobs()
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	assert.Equal(t, "sync", jobs[0].Name)
+	assert.Equal(t, 3600, jobs[1].IntervalSeconds)
+
+	// 失败与 panic 都记录在运行日志中
+	clock = clock.Add(time.Hour)
+	runs := s.RunDue()
+	require.Len(t, runs, 2)
+	assert.Equal(t, models.JobRunStatusFailed, runs[0].Status)
+	assert.Equal(t, "连接超时", runs[0].Error)
+	assert.Equal(t, "任务异常: nil map", runs[1].Error)
+
+	// 暂停后不再按计划运行，但可以手动运行
+	job, err := s.SetPaused("sync", true, 1, "", "")
+	require.NoError(t, err)
+	assert.True(t, job.Paused)
+	clock = clock.Add(time.Hour)
+	runs = s.RunDue()
+	require.Len(t, runs, 1)
+	assert.Equal(t, "broken", runs[0].JobName)
+
+	fail = false
+	run, err := s.RunNow("sync", 1, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, models.JobTriggerManual, run.Trigger)
+	assert.Equal(t, uint(1), *run.TriggeredBy)
+	assert.Equal(t, "同步 3 条", run.Message)
+
+	job, err = s.GetJob("sync")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), job.RunCount)
+	assert.Equal(t, int64(1), job.FailureCount)
+	assert.Equal(t, models.JobRunStatusSuccess, job.LastStatus)
+	assert.Equal(t, "", job.LastError)
+
+	// 恢复后按间隔重新计时
+	clock = clock.Add(time.Hour)
+	job, err = s.SetPaused("sync", false, 1, "", "")
+	require.NoError(t, err)
+	assert.False(t, job.Paused)
+	assert.True(t, job.NextRunAt.Equal(clock.Add(time.Minute)))
+
+	history, total, err := s.ListRuns("sync", 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, models.JobTriggerManual, history[0].Trigger)
+
+	// 超过保留期的运行日志被清理
+	clock = clock.AddDate(0, 0, 31)
+	_, err = s.RunNow("sync", 1, "", "")
+	require.NoError(t, err)
+	_, total, err = s.ListRuns("sync", 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	var audits int64
+	db.Model(&models.AuditLog{}).Where("resource_type = ?", "scheduled_job").Count(&audits)
+	assert.Equal(t, int64(4), audits)
+}
+
+func TestSchedulerService_LockHeartbeat(t *testing.T) {
+	db := newServiceTestDB(t)
+	// 心跳在后台协程中写库，内存库需共用同一连接
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	var mu sync.Mutex
+	clock := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	s := newTestScheduler(db, "a", &clock)
+	s.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return clock
+	}
+	s.heartbeat = 10 * time.Millisecond
+
+	lockedUntil := func() time.Time {
+		var job models.ScheduledJob
+		require.NoError(t, db.Where("name = ?", "report").First(&job).Error)
+		if job.LockedUntil == nil {
+			return time.Time{}
+		}
+		return *job.LockedUntil
+	}
+
+	// 运行超过锁有效期的任务期间锁被续期
+	takeOver := false
+	require.NoError(t, s.Register("report", "报表", time.Minute, func(now time.Time) (string, error) {
+		mu.Lock()
+		clock = clock.Add(15 * time.Minute)
+		renewed := clock.Add(10 * time.Minute)
+		mu.Unlock()
+		assert.Eventually(t, func() bool { return lockedUntil().Equal(renewed) }, time.Second, 5*time.Millisecond)
+		if takeOver {
+			require.NoError(t, db.Model(&models.ScheduledJob{}).Where("name = ?", "report").Updates(map[string]interface{}{"locked_by": "b", "locked_until": renewed}).Error)
+		}
+		return "完成", nil
+	}))
+	run, err := s.RunNow("report", 1, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, models.JobRunStatusSuccess, run.Status)
+	job, err := s.GetJob("report")
+	require.NoError(t, err)
+	assert.Equal(t, "", job.LockedBy)
+	assert.Equal(t, int64(1), job.RunCount)
+
+	// 锁被其他实例接管后不再释放或覆盖其锁
+	takeOver = true
+	_, err = s.RunNow("report", 1, "", "")
+	require.NoError(t, err)
+	job, err = s.GetJob("report")
+	require.NoError(t, err)
+	assert.Equal(t, "b", job.LockedBy)
+	assert.Equal(t, int64(1), job.RunCount)
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// spreadsheetRows 逐行读取表格文件，读完时返回 io.EOF
+type spreadsheetRows interface {
+	Next() ([]string, error)
+	Close() error
+}
+
+// isSupportedSpreadsheet 是否为支持导入的表格格式
+func isSupportedSpreadsheet(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	return ext == ".csv" || ext == ".xlsx"
+}
+
+// openSpreadsheet 按扩展名打开表格，XLSX 只读取第一个工作表
+func openSpreadsheet(r io.Reader, filename string) (spreadsheetRows, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return newCSVRows(r), nil
+	case ".xlsx":
+		return newXLSXRows(r)
+	}
+	return nil, fmt.Errorf("只支持CSV、XLSX格式文件")
+}
+
+// csvRows CSV 行读取器
+type csvRows struct {
+	reader *csv.Reader
+}
+
+func newCSVRows(r io.Reader) *csvRows {
+	buffered := bufio.NewReader(r)
+	// 跳过 Excel 导出的 UTF-8 BOM
+	if bom, err := buffered.Peek(3); err == nil && string(bom) == "\xef\xbb\xbf" {
+		buffered.Discard(3)
+	}
+	reader := csv.NewReader(buffered)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	return &csvRows{reader: reader}
+}
+
+func (r *csvRows) Next() ([]string, error) {
+	return r.reader.Read()
+}
+
+func (r *csvRows) Close() error {
+	return nil
+}
+
+// xlsxRows XLSX 行读取器（流式读取，适合大文件）
+type xlsxRows struct {
+	file *excelize.File
+	rows *excelize.Rows
+}
+
+func newXLSXRows(r io.Reader) (*xlsxRows, error) {
+	file, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("读取XLSX文件失败: %w", err)
+	}
+	rows, err := file.Rows(file.GetSheetName(0))
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("读取XLSX文件失败: %w", err)
+	}
+	return &xlsxRows{file: file, rows: rows}, nil
+}
+
+func (r *xlsxRows) Next() ([]string, error) {
+	if !r.rows.Next() {
+		if err := r.rows.Error(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	return r.rows.Columns()
+}
+
+func (r *xlsxRows) Close() error {
+	r.rows.Close()
+	return r.file.Close()
+}
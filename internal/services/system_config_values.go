@@ -0,0 +1,45 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// getConfigValue 读取系统配置值，配置不存在或为空时返回默认值
+func getConfigValue(db *gorm.DB, category, key, defaultValue string) string {
+	var config models.SystemConfig
+	if err := db.Where("category = ? AND key = ?", category, key).First(&config).Error; err != nil {
+		return defaultValue
+	}
+	if config.Value == "" {
+		if config.DefaultValue != "" {
+			return config.DefaultValue
+		}
+		return defaultValue
+	}
+	return config.Value
+}
+
+// getConfigBool 读取布尔类型系统配置
+func getConfigBool(db *gorm.DB, category, key string, defaultValue bool) bool {
+	value := getConfigValue(db, category, key, strconv.FormatBool(defaultValue))
+	parsed, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		return defaultValue
+	}
+	return parsed
+}
+
+// getConfigInt 读取整数类型系统配置
+func getConfigInt(db *gorm.DB, category, key string, defaultValue int) int {
+	value := getConfigValue(db, category, key, strconv.Itoa(defaultValue))
+	parsed, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return defaultValue
+	}
+	return parsed
+}
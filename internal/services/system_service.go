@@ -120,10 +120,32 @@ type SystemHealthSummary struct {
 }
 
 type SystemLogListResponse struct {
-	Logs     []models.SystemLog `json:"logs"`
-	Total    int64              `json:"total"`
-	Page     int                `json:"page"`
-	PageSize int                `json:"page_size"`
+	Logs       []models.SystemLog `json:"logs"`
+	Total      int64              `json:"total"`
+	Page       int                `json:"page"`
+	PageSize   int                `json:"page_size"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+	PrevCursor string             `json:"prev_cursor,omitempty"`
+}
+
+// SystemLogQuery 系统日志查询参数
+type SystemLogQuery struct {
+	Page       int
+	PageSize   int
+	Level      string
+	Category   string
+	StartTime  *time.Time
+	EndTime    *time.Time
+	SortBy     string
+	SortOrder  string
+	Pagination string // cursor 表示使用游标分页
+	Cursor     string
+}
+
+// systemLogSortFields 系统日志允许的排序字段
+var systemLogSortFields = map[string]CursorField{
+	"id":         {Column: "system_logs.id", Kind: CursorKindInt},
+	"created_at": {Column: "system_logs.created_at", Kind: CursorKindTime},
 }
 
 type SystemMetricsResponse struct {
@@ -814,28 +836,64 @@ func (s *SystemService) calculateOverallStatus(healthChecks []models.SystemHealt
 }
 
 // GetSystemLogs 获取系统日志
-func (s *SystemService) GetSystemLogs(page, pageSize int, level, category string, startTime, endTime *time.Time) (*SystemLogListResponse, error) {
+func (s *SystemService) GetSystemLogs(params *SystemLogQuery) (*SystemLogListResponse, error) {
+	sortBy, sortField, err := ResolveSortField(systemLogSortFields, params.SortBy, "created_at")
+	if err != nil {
+		return nil, err
+	}
+	desc := params.SortOrder != "asc"
+	if params.PageSize <= 0 {
+		params.PageSize = 50
+	}
+
 	var logs []models.SystemLog
 	var total int64
 
 	query := s.db.Model(&models.SystemLog{})
 
 	// 级别过滤
-	if level != "" {
-		query = query.Where("level = ?", level)
+	if params.Level != "" {
+		query = query.Where("level = ?", params.Level)
 	}
 
 	// 分类过滤
-	if category != "" {
-		query = query.Where("category = ?", category)
+	if params.Category != "" {
+		query = query.Where("category = ?", params.Category)
 	}
 
 	// 时间范围过滤
-	if startTime != nil {
-		query = query.Where("created_at >= ?", *startTime)
+	if params.StartTime != nil {
+		query = query.Where("created_at >= ?", *params.StartTime)
 	}
-	if endTime != nil {
-		query = query.Where("created_at <= ?", *endTime)
+	if params.EndTime != nil {
+		query = query.Where("created_at <= ?", *params.EndTime)
+	}
+
+	// 游标分页：不计算总数
+	if UsesCursorPagination(params.Pagination, params.Cursor) {
+		pager, err := NewKeysetPager(sortBy, sortField, "system_logs.id", desc, params.Cursor, params.PageSize)
+		if err != nil {
+			return nil, err
+		}
+		if err := pager.Apply(query.Preload("User")).Find(&logs).Error; err != nil {
+			return nil, fmt.Errorf("获取日志列表失败: %v", err)
+		}
+		logs, nextCursor, prevCursor := PaginateKeyset(pager, logs, func(log *models.SystemLog) (interface{}, uint) {
+			if sortBy == "id" {
+				return log.ID, log.ID
+			}
+			return log.CreatedAt, log.ID
+		})
+		return &SystemLogListResponse{
+			Logs:       logs,
+			PageSize:   params.PageSize,
+			NextCursor: nextCursor,
+			PrevCursor: prevCursor,
+		}, nil
+	}
+
+	if params.Page <= 0 {
+		params.Page = 1
 	}
 
 	// 计算总数
@@ -844,11 +902,10 @@ func (s *SystemService) GetSystemLogs(page, pageSize int, level, category string
 	}
 
 	// 分页查询
-	offset := (page - 1) * pageSize
-	if err := query.Preload("User").
+	offset := (params.Page - 1) * params.PageSize
+	if err := OrderWithTieBreaker(query.Preload("User"), sortField, "system_logs.id", desc).
 		Offset(offset).
-		Limit(pageSize).
-		Order("created_at DESC").
+		Limit(params.PageSize).
 		Find(&logs).Error; err != nil {
 		return nil, fmt.Errorf("获取日志列表失败: %v", err)
 	}
@@ -856,8 +913,8 @@ func (s *SystemService) GetSystemLogs(page, pageSize int, level, category string
 	return &SystemLogListResponse{
 		Logs:     logs,
 		Total:    total,
-		Page:     page,
-		PageSize: pageSize,
+		Page:     params.Page,
+		PageSize: params.PageSize,
 	}, nil
 }
 
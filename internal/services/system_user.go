@@ -0,0 +1,49 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SystemUsername 定时任务、自动流转等系统操作使用的内置账号
+const SystemUsername = "system"
+
+// SystemUserID 返回内置系统账号的ID，不存在时创建。
+// 历史、审计等表的操作人字段有外键约束，系统操作统一记在该账号下；账号已禁用且没有可用密码，无法登录
+func SystemUserID(db *gorm.DB) (uint, error) {
+	var user models.User
+	err := db.Unscoped().Where("username = ?", SystemUsername).First(&user).Error
+	if err == nil {
+		return user.ID, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, fmt.Errorf("获取系统账号失败: %w", err)
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		user = models.User{
+			Username:     SystemUsername,
+			Email:        "system@localhost",
+			DisplayName:  "系统",
+			PasswordHash: "!",
+			Status:       "disabled",
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		// is_active 列默认 true，创建时的零值会被忽略
+		return tx.Model(&user).Update("is_active", false).Error
+	})
+	if err != nil {
+		// 其他实例可能已同时创建
+		if lookupErr := db.Unscoped().Where("username = ?", SystemUsername).First(&user).Error; lookupErr == nil {
+			return user.ID, nil
+		}
+		return 0, fmt.Errorf("创建系统账号失败: %w", err)
+	}
+	return user.ID, nil
+}
@@ -0,0 +1,627 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxTagNameLength 标签名称最大长度（字符数）
+const maxTagNameLength = 50
+
+// tagRewriteBatchSize 重写实体标签列时每批处理的实体数
+const tagRewriteBatchSize = 500
+
+// taggedEntityRow 批量读取实体标签列时使用的行结构
+type taggedEntityRow struct {
+	ID   uint
+	Tags models.StringSlice `gorm:"type:text"`
+}
+
+// TagService 标签服务
+type TagService struct {
+	db           *gorm.DB
+	auditService *AuditService
+}
+
+// NewTagService 创建标签服务
+func NewTagService(db *gorm.DB, auditService *AuditService) *TagService {
+	return &TagService{
+		db:           db,
+		auditService: auditService,
+	}
+}
+
+// TagListQuery 标签列表查询参数
+type TagListQuery struct {
+	Search   string `form:"search"`
+	SortBy   string `form:"sort_by,default=usage"` // usage, name, created_at
+	Page     int    `form:"page,default=1"`
+	PageSize int    `form:"page_size,default=50"`
+}
+
+// TagSuggestQuery 标签自动补全查询参数
+type TagSuggestQuery struct {
+	Q     string `form:"q"`
+	Limit int    `form:"limit,default=10"`
+}
+
+// CreateTagRequest 创建标签请求
+type CreateTagRequest struct {
+	Name        string `json:"name" binding:"required,max=50"`
+	Color       string `json:"color" binding:"max=20"`
+	Description string `json:"description" binding:"max=500"`
+}
+
+// UpdateTagRequest 更新标签请求，修改名称即重命名
+type UpdateTagRequest struct {
+	Name        *string `json:"name" binding:"omitempty,max=50"`
+	Color       *string `json:"color" binding:"omitempty,max=20"`
+	Description *string `json:"description" binding:"omitempty,max=500"`
+}
+
+// MergeTagsRequest 合并标签请求
+type MergeTagsRequest struct {
+	SourceIDs []uint `json:"source_ids" binding:"required,min=1"`
+	TargetID  uint   `json:"target_id" binding:"required"`
+}
+
+// TagResponse 标签响应（含使用次数）
+type TagResponse struct {
+	models.Tag
+	RecordCount int64 `json:"record_count"`
+	TicketCount int64 `json:"ticket_count"`
+	UsageCount  int64 `json:"usage_count"`
+}
+
+// TagListResponse 标签列表响应
+type TagListResponse struct {
+	Tags     []TagResponse `json:"tags"`
+	Total    int64         `json:"total"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"page_size"`
+}
+
+// NormalizeTagName 规范化标签名称：合并空白并转小写
+func NormalizeTagName(name string) string {
+	return strings.ToLower(cleanTagName(name))
+}
+
+// cleanTagName 去除首尾与重复空白，保留大小写作为显示名称
+func cleanTagName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// validateTagName 校验并返回清理后的标签名称
+func validateTagName(name string) (string, error) {
+	cleaned := cleanTagName(name)
+	if cleaned == "" {
+		return "", fmt.Errorf("标签名称不能为空")
+	}
+	if len([]rune(cleaned)) > maxTagNameLength {
+		return "", fmt.Errorf("标签名称不能超过%d个字符", maxTagNameLength)
+	}
+	return cleaned, nil
+}
+
+// 标签使用次数子查询，已删除的记录和工单不计入
+const (
+	tagRecordCountSQL = `(SELECT COUNT(*) FROM entity_tags et JOIN records r ON r.id = et.entity_id
+		WHERE et.tag_id = tags.id AND et.entity_type = 'record' AND r.deleted_at IS NULL)`
+	tagTicketCountSQL = `(SELECT COUNT(*) FROM entity_tags et JOIN tickets t ON t.id = et.entity_id
+		WHERE et.tag_id = tags.id AND et.entity_type = 'ticket' AND t.deleted_at IS NULL)`
+)
+
+// tagUsageSelect 带使用次数的标签查询字段
+const tagUsageSelect = "tags.*, " + tagRecordCountSQL + " AS record_count, " + tagTicketCountSQL + " AS ticket_count, " +
+	tagRecordCountSQL + " + " + tagTicketCountSQL + " AS usage_count"
+
+// ListTags 获取标签列表
+func (s *TagService) ListTags(query *TagListQuery) (*TagListResponse, error) {
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.PageSize <= 0 || query.PageSize > 200 {
+		query.PageSize = 50
+	}
+
+	db := s.db.Model(&models.Tag{})
+	if search := NormalizeTagName(query.Search); search != "" {
+		db = db.Where("normalized_name LIKE ?", "%"+search+"%")
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("获取标签总数失败: %w", err)
+	}
+
+	switch query.SortBy {
+	case "name":
+		db = db.Order("normalized_name ASC")
+	case "created_at":
+		db = db.Order("created_at DESC")
+	default:
+		db = db.Order("usage_count DESC").Order("normalized_name ASC")
+	}
+
+	var tags []TagResponse
+	err := db.Select(tagUsageSelect).
+		Offset((query.Page - 1) * query.PageSize).Limit(query.PageSize).
+		Scan(&tags).Error
+	if err != nil {
+		return nil, fmt.Errorf("获取标签列表失败: %w", err)
+	}
+
+	return &TagListResponse{Tags: tags, Total: total, Page: query.Page, PageSize: query.PageSize}, nil
+}
+
+// SuggestTags 标签自动补全：前缀匹配优先，其次按使用次数排序
+func (s *TagService) SuggestTags(query *TagSuggestQuery) ([]TagResponse, error) {
+	if query.Limit <= 0 || query.Limit > 50 {
+		query.Limit = 10
+	}
+
+	db := s.db.Model(&models.Tag{})
+	order := clause.Expr{SQL: "usage_count DESC, normalized_name ASC"}
+	prefix := NormalizeTagName(query.Q)
+	if prefix != "" {
+		db = db.Where("normalized_name LIKE ?", "%"+prefix+"%")
+		order = clause.Expr{
+			SQL:  "CASE WHEN normalized_name LIKE ? THEN 0 ELSE 1 END, " + order.SQL,
+			Vars: []interface{}{prefix + "%"},
+		}
+	}
+
+	var tags []TagResponse
+	err := db.Select(tagUsageSelect).
+		Order(clause.OrderBy{Expression: order}).
+		Limit(query.Limit).Scan(&tags).Error
+	if err != nil {
+		return nil, fmt.Errorf("获取标签建议失败: %w", err)
+	}
+	return tags, nil
+}
+
+// GetTag 获取标签详情
+func (s *TagService) GetTag(id uint) (*TagResponse, error) {
+	var tags []TagResponse
+	if err := s.db.Model(&models.Tag{}).Select(tagUsageSelect).Where("tags.id = ?", id).Scan(&tags).Error; err != nil {
+		return nil, fmt.Errorf("获取标签失败: %w", err)
+	}
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("标签不存在")
+	}
+	return &tags[0], nil
+}
+
+// CreateTag 创建标签
+func (s *TagService) CreateTag(req *CreateTagRequest, userID uint, ipAddress, userAgent string) (*TagResponse, error) {
+	name, err := validateTagName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var count int64
+	s.db.Model(&models.Tag{}).Where("normalized_name = ?", NormalizeTagName(name)).Count(&count)
+	if count > 0 {
+		return nil, fmt.Errorf("标签已存在")
+	}
+
+	tag := models.Tag{
+		Name:           name,
+		NormalizedName: NormalizeTagName(name),
+		Color:          req.Color,
+		Description:    req.Description,
+		CreatedBy:      userID,
+	}
+	if err := s.db.Create(&tag).Error; err != nil {
+		return nil, fmt.Errorf("创建标签失败: %w", err)
+	}
+
+	s.audit(userID, "CREATE", tag.ID, nil, tagAuditValues(&tag), ipAddress, userAgent)
+	return s.GetTag(tag.ID)
+}
+
+// UpdateTag 更新标签；重命名时同步更新所有使用该标签的记录和工单
+func (s *TagService) UpdateTag(id uint, req *UpdateTagRequest, userID uint, ipAddress, userAgent string) (*TagResponse, error) {
+	var tag models.Tag
+	if err := s.db.First(&tag, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("标签不存在")
+		}
+		return nil, fmt.Errorf("获取标签失败: %w", err)
+	}
+	oldValues := tagAuditValues(&tag)
+	oldNormalized := tag.NormalizedName
+
+	if req.Name != nil {
+		name, err := validateTagName(*req.Name)
+		if err != nil {
+			return nil, err
+		}
+		if normalized := NormalizeTagName(name); normalized != tag.NormalizedName {
+			var count int64
+			s.db.Model(&models.Tag{}).Where("normalized_name = ? AND id <> ?", normalized, tag.ID).Count(&count)
+			if count > 0 {
+				return nil, fmt.Errorf("标签名称已存在，请使用合并操作")
+			}
+		}
+		tag.Name = name
+		tag.NormalizedName = NormalizeTagName(name)
+	}
+	if req.Color != nil {
+		tag.Color = *req.Color
+	}
+	if req.Description != nil {
+		tag.Description = *req.Description
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&tag).Error; err != nil {
+			return fmt.Errorf("更新标签失败: %w", err)
+		}
+		if tag.Name == oldValues["name"] {
+			return nil
+		}
+		return rewriteEntityTagColumns(tx, []uint{tag.ID}, map[string]string{oldNormalized: tag.Name})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(userID, "UPDATE", tag.ID, oldValues, tagAuditValues(&tag), ipAddress, userAgent)
+	return s.GetTag(tag.ID)
+}
+
+// MergeTags 将源标签合并到目标标签，源标签被删除
+func (s *TagService) MergeTags(req *MergeTagsRequest, userID uint, ipAddress, userAgent string) (*TagResponse, error) {
+	var target models.Tag
+	if err := s.db.First(&target, req.TargetID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("标签不存在")
+		}
+		return nil, fmt.Errorf("获取标签失败: %w", err)
+	}
+
+	var sources []models.Tag
+	for _, sourceID := range req.SourceIDs {
+		if sourceID == target.ID {
+			return nil, fmt.Errorf("不能将标签合并到自身")
+		}
+	}
+	if err := s.db.Where("id IN ?", req.SourceIDs).Find(&sources).Error; err != nil {
+		return nil, fmt.Errorf("获取标签失败: %w", err)
+	}
+	if len(sources) != len(uniqueUints(req.SourceIDs)) {
+		return nil, fmt.Errorf("标签不存在")
+	}
+
+	replace := map[string]string{target.NormalizedName: target.Name}
+	sourceIDs := make([]uint, len(sources))
+	sourceNames := make([]string, len(sources))
+	for i, source := range sources {
+		sourceIDs[i] = source.ID
+		sourceNames[i] = source.Name
+		replace[source.NormalizedName] = target.Name
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var assignments []models.EntityTag
+		if err := tx.Where("tag_id IN ?", sourceIDs).Find(&assignments).Error; err != nil {
+			return fmt.Errorf("获取标签关联失败: %w", err)
+		}
+		for _, a := range assignments {
+			moved := models.EntityTag{TagID: target.ID, EntityType: a.EntityType, EntityID: a.EntityID}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&moved).Error; err != nil {
+				return fmt.Errorf("合并标签关联失败: %w", err)
+			}
+		}
+		if err := tx.Where("tag_id IN ?", sourceIDs).Delete(&models.EntityTag{}).Error; err != nil {
+			return fmt.Errorf("合并标签关联失败: %w", err)
+		}
+		if err := tx.Delete(&models.Tag{}, sourceIDs).Error; err != nil {
+			return fmt.Errorf("删除源标签失败: %w", err)
+		}
+		return rewriteEntityTagColumns(tx, []uint{target.ID}, replace)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(userID, "MERGE", target.ID,
+		map[string]interface{}{"source_ids": sourceIDs, "source_names": sourceNames},
+		tagAuditValues(&target), ipAddress, userAgent)
+	return s.GetTag(target.ID)
+}
+
+// DeleteTag 删除标签并从所有记录和工单中移除
+func (s *TagService) DeleteTag(id uint, userID uint, ipAddress, userAgent string) error {
+	var tag models.Tag
+	if err := s.db.First(&tag, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("标签不存在")
+		}
+		return fmt.Errorf("获取标签失败: %w", err)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 先按关联重写实体标签列，再删除关联
+		if err := rewriteEntityTagColumns(tx, []uint{tag.ID}, map[string]string{tag.NormalizedName: ""}); err != nil {
+			return err
+		}
+		if err := tx.Where("tag_id = ?", tag.ID).Delete(&models.EntityTag{}).Error; err != nil {
+			return fmt.Errorf("删除标签关联失败: %w", err)
+		}
+		if err := tx.Delete(&tag).Error; err != nil {
+			return fmt.Errorf("删除标签失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit(userID, "DELETE", tag.ID, tagAuditValues(&tag), nil, ipAddress, userAgent)
+	return nil
+}
+
+// audit 记录标签审计日志
+func (s *TagService) audit(userID uint, action string, tagID uint, oldValues, newValues map[string]interface{}, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	s.auditService.CreateAuditLog(&AuditLogRequest{
+		UserID:       userID,
+		Action:       action,
+		ResourceType: "tag",
+		ResourceID:   tagID,
+		OldValues:    oldValues,
+		NewValues:    newValues,
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+	})
+}
+
+func tagAuditValues(tag *models.Tag) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        tag.Name,
+		"color":       tag.Color,
+		"description": tag.Description,
+	}
+}
+
+// ValidateTagNames 校验一组标签名称，空白标签会被忽略
+func ValidateTagNames(names []string) error {
+	for _, name := range names {
+		if cleanTagName(name) == "" {
+			continue
+		}
+		if _, err := validateTagName(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureTags 按名称查找或创建标签，按输入顺序去重返回
+func ensureTags(tx *gorm.DB, names []string, userID uint) ([]models.Tag, error) {
+	tags := make([]models.Tag, 0, len(names))
+	seen := map[string]bool{}
+	for _, raw := range names {
+		name := cleanTagName(raw)
+		if name == "" {
+			continue
+		}
+		name, err := validateTagName(name)
+		if err != nil {
+			return nil, err
+		}
+		normalized := NormalizeTagName(name)
+		if seen[normalized] {
+			continue
+		}
+		seen[normalized] = true
+
+		var tag models.Tag
+		err = tx.Where(models.Tag{NormalizedName: normalized}).
+			Attrs(models.Tag{Name: name, CreatedBy: userID}).
+			FirstOrCreate(&tag).Error
+		if err != nil {
+			return nil, fmt.Errorf("保存标签失败: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// tagEntityModel 标签实体对应的模型
+func tagEntityModel(entityType string) interface{} {
+	if entityType == models.TagEntityTicket {
+		return &models.Ticket{}
+	}
+	return &models.Record{}
+}
+
+// SyncEntityTags 用给定标签替换实体的标签，并把标签显示名称写回实体的 tags 列
+func SyncEntityTags(tx *gorm.DB, entityType string, entityID uint, names []string, userID uint) (models.StringSlice, error) {
+	if !models.IsValidTagEntityType(entityType) {
+		return nil, fmt.Errorf("无效的标签实体类型")
+	}
+
+	tags, err := ensureTags(tx, names, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, len(tags))
+	display := make(models.StringSlice, len(tags))
+	for i, tag := range tags {
+		ids[i] = tag.ID
+		display[i] = tag.Name
+	}
+
+	remove := tx.Where("entity_type = ? AND entity_id = ?", entityType, entityID)
+	if len(ids) > 0 {
+		remove = remove.Where("tag_id NOT IN ?", ids)
+	}
+	if err := remove.Delete(&models.EntityTag{}).Error; err != nil {
+		return nil, fmt.Errorf("更新标签关联失败: %w", err)
+	}
+	for _, id := range ids {
+		assignment := models.EntityTag{TagID: id, EntityType: entityType, EntityID: entityID}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&assignment).Error; err != nil {
+			return nil, fmt.Errorf("更新标签关联失败: %w", err)
+		}
+	}
+
+	if err := tx.Model(tagEntityModel(entityType)).Unscoped().Where("id = ?", entityID).
+		UpdateColumn("tags", display).Error; err != nil {
+		return nil, fmt.Errorf("更新标签失败: %w", err)
+	}
+	return display, nil
+}
+
+// FilterByTags 按逗号分隔的标签精确过滤实体，多个标签需同时具备
+func FilterByTags(db *gorm.DB, entityType, idColumn, tags string) *gorm.DB {
+	for _, tag := range strings.Split(tags, ",") {
+		normalized := NormalizeTagName(tag)
+		if normalized == "" {
+			continue
+		}
+		sub := db.Session(&gorm.Session{NewDB: true}).Table("entity_tags").
+			Select("entity_tags.entity_id").
+			Joins("JOIN tags ON tags.id = entity_tags.tag_id").
+			Where("entity_tags.entity_type = ? AND tags.normalized_name = ?", entityType, normalized)
+		db = db.Where(idColumn+" IN (?)", sub)
+	}
+	return db
+}
+
+// deleteEntityTags 删除实体的全部标签关联
+func deleteEntityTags(tx *gorm.DB, entityType string, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := tx.Where("entity_type = ? AND entity_id IN ?", entityType, ids).Delete(&models.EntityTag{}).Error; err != nil {
+		return fmt.Errorf("删除标签关联失败: %w", err)
+	}
+	return nil
+}
+
+// rewriteEntityTagColumns 按替换表重写使用指定标签的实体 tags 列，
+// 替换值为空表示移除，保持原有顺序并按规范化名称去重
+func rewriteEntityTagColumns(tx *gorm.DB, tagIDs []uint, replace map[string]string) error {
+	for _, entityType := range []string{models.TagEntityRecord, models.TagEntityTicket} {
+		var lastID uint
+		for {
+			var ids []uint
+			err := tx.Model(&models.EntityTag{}).
+				Where("tag_id IN ? AND entity_type = ? AND entity_id > ?", tagIDs, entityType, lastID).
+				Distinct("entity_id").Order("entity_id ASC").Limit(tagRewriteBatchSize).
+				Pluck("entity_id", &ids).Error
+			if err != nil {
+				return fmt.Errorf("获取标签关联失败: %w", err)
+			}
+			if len(ids) == 0 {
+				break
+			}
+			lastID = ids[len(ids)-1]
+
+			var rows []taggedEntityRow
+			if err := tx.Model(tagEntityModel(entityType)).Unscoped().Select("id", "tags").
+				Where("id IN ?", ids).Scan(&rows).Error; err != nil {
+				return fmt.Errorf("获取标签失败: %w", err)
+			}
+			for _, row := range rows {
+				updated := replaceTagNames(row.Tags, replace)
+				if err := tx.Model(tagEntityModel(entityType)).Unscoped().Where("id = ?", row.ID).
+					UpdateColumn("tags", updated).Error; err != nil {
+					return fmt.Errorf("更新标签失败: %w", err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// replaceTagNames 按规范化名称替换标签名称并去重
+func replaceTagNames(tags []string, replace map[string]string) models.StringSlice {
+	result := make(models.StringSlice, 0, len(tags))
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		if replacement, ok := replace[NormalizeTagName(tag)]; ok {
+			if replacement == "" {
+				continue
+			}
+			tag = replacement
+		}
+		normalized := NormalizeTagName(tag)
+		if normalized == "" || seen[normalized] {
+			continue
+		}
+		seen[normalized] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+// uniqueUints 去重
+func uniqueUints(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	result := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// MigrateLegacyTags 将记录和工单中尚未关联标签实体的 JSON 标签迁移为标签关联，可重复执行。
+// 每条记录在单独的事务中迁移，新建的标签记在系统账号下
+func MigrateLegacyTags(db *gorm.DB) error {
+	var systemUserID uint
+	for _, entityType := range []string{models.TagEntityRecord, models.TagEntityTicket} {
+		table := "records"
+		if entityType == models.TagEntityTicket {
+			table = "tickets"
+		}
+
+		var lastID uint
+		for {
+			var rows []taggedEntityRow
+			err := db.Model(tagEntityModel(entityType)).Unscoped().Select("id", "tags").
+				Where("id > ?", lastID).
+				Where("tags IS NOT NULL AND tags <> '' AND tags <> '[]' AND tags <> 'null'").
+				Where("NOT EXISTS (SELECT 1 FROM entity_tags WHERE entity_tags.entity_type = ? AND entity_tags.entity_id = "+table+".id)", entityType).
+				Order("id ASC").Limit(tagRewriteBatchSize).Scan(&rows).Error
+			if err != nil {
+				return fmt.Errorf("获取待迁移标签失败: %w", err)
+			}
+			if len(rows) == 0 {
+				break
+			}
+			if systemUserID == 0 {
+				if systemUserID, err = SystemUserID(db); err != nil {
+					return err
+				}
+			}
+			for _, row := range rows {
+				lastID = row.ID
+				err := db.Transaction(func(tx *gorm.DB) error {
+					_, err := SyncEntityTags(tx, entityType, row.ID, row.Tags, systemUserID)
+					return err
+				})
+				if err != nil {
+					return fmt.Errorf("迁移%s %d 的标签失败: %w", table, row.ID, err)
+				}
+			}
+		}
+	}
+	return nil
+}
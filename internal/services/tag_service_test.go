@@ -0,0 +1,175 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"info-management-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// setupTagTest 创建标签测试环境
+func setupTagTest(t *testing.T) (*gorm.DB, *TagService) {
+	db := newServiceTestDB(t)
+	require.NoError(t, db.Create(&models.User{Username: "owner", Email: "owner@example.com", PasswordHash: "x", IsActive: true}).Error)
+
+	return db, NewTagService(db, NewAuditService(db))
+}
+
+// createTaggedRecord 创建带标签的记录
+func createTaggedRecord(t *testing.T, db *gorm.DB, title string, tags ...string) *models.Record {
+	record := &models.Record{Type: "asset", Title: title, Status: "draft", Version: 1, CreatedBy: 1}
+	require.NoError(t, db.Create(record).Error)
+	synced, err := SyncEntityTags(db, models.TagEntityRecord, record.ID, tags, 1)
+	require.NoError(t, err)
+	record.Tags = synced
+	return record
+}
+
+func TestNormalizeTagName(t *testing.T) {
+	assert.Equal(t, "dev ops", NormalizeTagName("  Dev   OPS "))
+	assert.Equal(t, "", NormalizeTagName("   "))
+
+	assert.NoError(t, ValidateTagNames([]string{"ops", "运维"}))
+	assert.EqualError(t, ValidateTagNames([]string{strings.Repeat("标", 51)}), "标签名称不能超过50个字符")
+	assert.NoError(t, ValidateTagNames([]string{" "}), "空白标签被忽略")
+}
+
+func TestSyncEntityTags_DedupesAndFiltersExactly(t *testing.T) {
+	db, _ := setupTagTest(t)
+
+	ops := createTaggedRecord(t, db, "ops记录", "Ops", " ops ", "backend")
+	assert.Equal(t, models.StringSlice{"Ops", "backend"}, ops.Tags)
+	createTaggedRecord(t, db, "devops记录", "devops")
+
+	var tagCount int64
+	db.Model(&models.Tag{}).Count(&tagCount)
+	assert.Equal(t, int64(3), tagCount)
+
+	// "ops" 不应匹配 "devops"
+	var titles []string
+	require.NoError(t, FilterByTags(db.Model(&models.Record{}), models.TagEntityRecord, "records.id", "OPS").
+		Pluck("title", &titles).Error)
+	assert.Equal(t, []string{"ops记录"}, titles)
+
+	// 多个标签需同时具备
+	titles = nil
+	require.NoError(t, FilterByTags(db.Model(&models.Record{}), models.TagEntityRecord, "records.id", "ops,devops").
+		Pluck("title", &titles).Error)
+	assert.Empty(t, titles)
+}
+
+func TestTagService_RenameUpdatesEntities(t *testing.T) {
+	db, tagService := setupTagTest(t)
+
+	record := createTaggedRecord(t, db, "记录", "ops", "backend")
+	ticket := &models.Ticket{Title: "工单", Type: models.TicketTypeBug, CreatorID: 1}
+	require.NoError(t, db.Create(ticket).Error)
+	_, err := SyncEntityTags(db, models.TagEntityTicket, ticket.ID, []string{"ops"}, 1)
+	require.NoError(t, err)
+
+	var tag models.Tag
+	require.NoError(t, db.Where("normalized_name = ?", "ops").First(&tag).Error)
+
+	name := "backend"
+	_, err = tagService.UpdateTag(tag.ID, &UpdateTagRequest{Name: &name}, 1, "", "")
+	assert.EqualError(t, err, "标签名称已存在，请使用合并操作")
+
+	name = "Operations"
+	renamed, err := tagService.UpdateTag(tag.ID, &UpdateTagRequest{Name: &name}, 1, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "operations", renamed.NormalizedName)
+	assert.Equal(t, int64(2), renamed.UsageCount)
+
+	require.NoError(t, db.First(record, record.ID).Error)
+	assert.Equal(t, models.StringSlice{"Operations", "backend"}, record.Tags)
+	require.NoError(t, db.First(ticket, ticket.ID).Error)
+	assert.Equal(t, models.StringSlice{"Operations"}, ticket.Tags)
+}
+
+func TestTagService_MergeTags(t *testing.T) {
+	db, tagService := setupTagTest(t)
+
+	both := createTaggedRecord(t, db, "两者", "k8s", "kubernetes")
+	only := createTaggedRecord(t, db, "仅k8s", "k8s")
+
+	var source, target models.Tag
+	require.NoError(t, db.Where("normalized_name = ?", "k8s").First(&source).Error)
+	require.NoError(t, db.Where("normalized_name = ?", "kubernetes").First(&target).Error)
+
+	_, err := tagService.MergeTags(&MergeTagsRequest{SourceIDs: []uint{target.ID}, TargetID: target.ID}, 1, "", "")
+	assert.EqualError(t, err, "不能将标签合并到自身")
+
+	merged, err := tagService.MergeTags(&MergeTagsRequest{SourceIDs: []uint{source.ID}, TargetID: target.ID}, 1, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), merged.RecordCount)
+
+	_, err = tagService.GetTag(source.ID)
+	assert.EqualError(t, err, "标签不存在")
+
+	require.NoError(t, db.First(both, both.ID).Error)
+	assert.Equal(t, models.StringSlice{"kubernetes"}, both.Tags)
+	require.NoError(t, db.First(only, only.ID).Error)
+	assert.Equal(t, models.StringSlice{"kubernetes"}, only.Tags)
+
+	var assignments int64
+	db.Model(&models.EntityTag{}).Count(&assignments)
+	assert.Equal(t, int64(2), assignments)
+}
+
+func TestTagService_ListAndSuggest(t *testing.T) {
+	db, tagService := setupTagTest(t)
+
+	createTaggedRecord(t, db, "a", "ops", "devops")
+	createTaggedRecord(t, db, "b", "devops")
+	deleted := createTaggedRecord(t, db, "c", "devops")
+	require.NoError(t, db.Delete(deleted).Error)
+
+	_, err := tagService.CreateTag(&CreateTagRequest{Name: "OPS"}, 1, "", "")
+	assert.EqualError(t, err, "标签已存在")
+
+	list, err := tagService.ListTags(&TagListQuery{SortBy: "usage"})
+	require.NoError(t, err)
+	require.Len(t, list.Tags, 2)
+	assert.Equal(t, "devops", list.Tags[0].Name)
+	assert.Equal(t, int64(2), list.Tags[0].UsageCount, "已删除的记录不计入使用次数")
+
+	suggestions, err := tagService.SuggestTags(&TagSuggestQuery{Q: "Op"})
+	require.NoError(t, err)
+	require.Len(t, suggestions, 2)
+	assert.Equal(t, "ops", suggestions[0].Name, "前缀匹配优先")
+}
+
+func TestMigrateLegacyTags(t *testing.T) {
+	db, _ := setupTagTest(t)
+
+	require.NoError(t, db.Create(&models.Record{Type: "asset", Title: "旧记录", Status: "draft", Version: 1, CreatedBy: 1,
+		Tags: models.StringSlice{"Ops", "ops", "devops"}}).Error)
+	require.NoError(t, db.Create(&models.Ticket{Title: "旧工单", Type: models.TicketTypeBug, CreatorID: 1,
+		Tags: models.StringSlice{"ops"}}).Error)
+
+	require.NoError(t, MigrateLegacyTags(db))
+	require.NoError(t, MigrateLegacyTags(db))
+
+	var tags, assignments int64
+	db.Model(&models.Tag{}).Count(&tags)
+	db.Model(&models.EntityTag{}).Count(&assignments)
+	assert.Equal(t, int64(2), tags)
+	assert.Equal(t, int64(3), assignments)
+
+	// 迁移创建的标签记在系统账号下
+	systemUserID, err := SystemUserID(db)
+	require.NoError(t, err)
+	var created []models.Tag
+	require.NoError(t, db.Find(&created).Error)
+	for _, tag := range created {
+		assert.Equal(t, systemUserID, tag.CreatedBy)
+	}
+
+	var record models.Record
+	require.NoError(t, db.First(&record).Error)
+	assert.Equal(t, models.StringSlice{"Ops", "devops"}, record.Tags)
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+
+	"info-management-system/internal/models"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newServiceTestDB 创建迁移了全部模型的内存数据库，服务测试共用
+func newServiceTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(models.AllModels()...))
+	return db
+}
+
+// newForeignKeyTestDB 创建启用外键约束的内存数据库，用于验证写入在 MySQL/PostgreSQL 上同样满足约束
+func newForeignKeyTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:?_foreign_keys=1"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(models.AllModels()...))
+	return db
+}
+
+// newFileServiceTestDB 创建基于临时文件的数据库，事务进行期间其他连接也能读到同一份数据
+func newFileServiceTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(models.AllModels()...))
+	return db
+}
@@ -0,0 +1,689 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// TicketAssignmentService 工单自动分配服务：按规则匹配工单，在角色或团队成员中按策略选择处理人
+type TicketAssignmentService struct {
+	db           *gorm.DB
+	auditService *AuditService
+	now          func() time.Time
+}
+
+// NewTicketAssignmentService 创建工单自动分配服务
+func NewTicketAssignmentService(db *gorm.DB, auditService *AuditService) *TicketAssignmentService {
+	return &TicketAssignmentService{
+		db:           db,
+		auditService: auditService,
+		now:          time.Now,
+	}
+}
+
+// SaveAssignmentRuleRequest 保存分配规则请求
+type SaveAssignmentRuleRequest struct {
+	Name           string   `json:"name" binding:"required,max=200"`
+	Description    string   `json:"description" binding:"max=500"`
+	SortOrder      int      `json:"sort_order"`
+	TicketType     string   `json:"ticket_type"`
+	Category       string   `json:"category" binding:"max=100"`
+	TicketPriority string   `json:"ticket_priority"`
+	Tags           []string `json:"tags"`
+	TargetRole     string   `json:"target_role" binding:"max=100"`
+	MemberIDs      []uint   `json:"member_ids"`
+	Strategy       string   `json:"strategy"`
+	Skills         []string `json:"skills"`
+	IsActive       *bool    `json:"is_active"`
+}
+
+// ReorderAssignmentRulesRequest 调整分配规则顺序请求，按数组顺序重新编号
+type ReorderAssignmentRulesRequest struct {
+	RuleIDs []uint `json:"rule_ids" binding:"required,min=1"`
+}
+
+// AssigneeProfileRequest 更新处理人技能与可用状态请求
+type AssigneeProfileRequest struct {
+	Skills         []string   `json:"skills"`
+	Away           bool       `json:"away"`
+	AwayUntil      *time.Time `json:"away_until"`
+	MaxOpenTickets int        `json:"max_open_tickets" binding:"min=0"`
+}
+
+// AssignmentPreviewRequest 分配预览请求，指定工单ID时按该工单预览，否则按给定条件构造工单
+type AssignmentPreviewRequest struct {
+	TicketID uint     `json:"ticket_id"`
+	RuleID   uint     `json:"rule_id"` // 指定时只预览该规则，不做条件匹配
+	Type     string   `json:"type"`
+	Category string   `json:"category"`
+	Priority string   `json:"priority"`
+	Tags     []string `json:"tags"`
+}
+
+// AssignmentCandidate 分配候选人，不可用时 Reason 说明原因
+type AssignmentCandidate struct {
+	UserID      uint   `json:"user_id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	OpenTickets int64  `json:"open_tickets"`
+	SkillScore  int    `json:"skill_score"`
+	Available   bool   `json:"available"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// AssignmentResult 分配结果，未匹配规则或无可用候选人时 AssigneeID 为 0
+type AssignmentResult struct {
+	Matched    bool                  `json:"matched"`
+	RuleID     uint                  `json:"rule_id"`
+	RuleName   string                `json:"rule_name"`
+	Strategy   string                `json:"strategy"`
+	TargetRole string                `json:"target_role"`
+	AssigneeID uint                  `json:"assignee_id"`
+	Assignee   string                `json:"assignee"`
+	Candidates []AssignmentCandidate `json:"candidates"`
+}
+
+// MatchRule 按排序返回第一条匹配工单的启用规则，没有匹配时返回 nil
+func (s *TicketAssignmentService) MatchRule(ticket *models.Ticket) (*models.TicketAssignmentRule, error) {
+	var rules []models.TicketAssignmentRule
+	if err := s.db.Preload("Members").Where("is_active = ?", true).Order("sort_order ASC, id ASC").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("查询分配规则失败: %w", err)
+	}
+	for i := range rules {
+		if assignmentRuleMatches(&rules[i], ticket) {
+			return &rules[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// AutoAssign 为工单选择处理人，轮流分配策略会推进规则的分配位置；未匹配规则或无可用候选人时返回 nil
+func (s *TicketAssignmentService) AutoAssign(ticket *models.Ticket) (*AssignmentResult, error) {
+	rule, err := s.MatchRule(ticket)
+	if err != nil || rule == nil {
+		return nil, err
+	}
+	result, err := s.evaluate(rule, ticket)
+	if err != nil || result.AssigneeID == 0 {
+		return nil, err
+	}
+
+	if rule.Strategy == models.AssignStrategyRoundRobin {
+		if err := s.db.Model(&models.TicketAssignmentRule{}).Where("id = ?", rule.ID).
+			Update("last_assignee_id", result.AssigneeID).Error; err != nil {
+			return nil, fmt.Errorf("更新分配规则失败: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// ApplyToTicket 按分配规则为新工单选择处理人，并经由流程引擎执行分配；流程中没有可用的分配动作时保持原状态
+func (s *TicketAssignmentService) ApplyToTicket(ticket *models.Ticket, workflowService *TicketWorkflowService) (*AssignmentResult, error) {
+	result, err := s.AutoAssign(ticket)
+	if err != nil || result == nil {
+		return nil, err
+	}
+
+	// 记录分配的角色
+	if err := s.db.Model(&models.Ticket{}).Where("id = ?", ticket.ID).Update("auto_assign_role", result.TargetRole).Error; err != nil {
+		return nil, fmt.Errorf("更新工单失败: %w", err)
+	}
+	actor, err := workflowService.SystemActor()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := workflowService.Transition(ticket.ID, &TicketTransitionRequest{
+		Action:     "assign",
+		Comment:    "自动分配 (规则: " + result.RuleName + ")",
+		AssigneeID: &result.AssigneeID,
+	}, actor, "", ""); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Preview 预览工单会分配给谁，不改变轮流分配的位置
+func (s *TicketAssignmentService) Preview(req *AssignmentPreviewRequest) (*AssignmentResult, error) {
+	ticket := &models.Ticket{
+		Type:     models.TicketType(req.Type),
+		Category: req.Category,
+		Priority: models.TicketPriority(req.Priority),
+		Tags:     models.StringSlice(req.Tags),
+	}
+	if req.TicketID != 0 {
+		if err := s.db.First(ticket, req.TicketID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, fmt.Errorf("工单不存在")
+			}
+			return nil, fmt.Errorf("获取工单失败: %w", err)
+		}
+	}
+
+	var rule *models.TicketAssignmentRule
+	if req.RuleID != 0 {
+		found, err := s.GetRule(req.RuleID)
+		if err != nil {
+			return nil, err
+		}
+		rule = found
+	} else {
+		found, err := s.MatchRule(ticket)
+		if err != nil {
+			return nil, err
+		}
+		if found == nil {
+			return &AssignmentResult{Candidates: []AssignmentCandidate{}}, nil
+		}
+		rule = found
+	}
+	return s.evaluate(rule, ticket)
+}
+
+// evaluate 计算规则的候选人并按策略选出处理人
+func (s *TicketAssignmentService) evaluate(rule *models.TicketAssignmentRule, ticket *models.Ticket) (*AssignmentResult, error) {
+	candidates, err := s.candidates(rule, ticket)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AssignmentResult{
+		Matched:    true,
+		RuleID:     rule.ID,
+		RuleName:   rule.Name,
+		Strategy:   rule.Strategy,
+		TargetRole: rule.TargetRole,
+		Candidates: candidates,
+	}
+	if picked := pickAssignmentCandidate(rule, candidates); picked != nil {
+		result.AssigneeID = picked.UserID
+		result.Assignee = picked.Username
+	}
+	return result, nil
+}
+
+// candidates 列出规则的角色成员与团队成员，并标注停用、暂离或达到工单上限的成员
+func (s *TicketAssignmentService) candidates(rule *models.TicketAssignmentRule, ticket *models.Ticket) ([]AssignmentCandidate, error) {
+	var userIDs []uint
+	for _, member := range rule.Members {
+		userIDs = append(userIDs, member.UserID)
+	}
+	if rule.TargetRole != "" {
+		var roleUserIDs []uint
+		if err := s.db.Table("user_roles").
+			Joins("JOIN roles ON roles.id = user_roles.role_id").
+			Where("roles.name = ?", rule.TargetRole).
+			Pluck("user_roles.user_id", &roleUserIDs).Error; err != nil {
+			return nil, fmt.Errorf("查询角色成员失败: %w", err)
+		}
+		userIDs = append(userIDs, roleUserIDs...)
+	}
+	userIDs = uniqueUints(userIDs)
+	candidates := []AssignmentCandidate{}
+	if len(userIDs) == 0 {
+		return candidates, nil
+	}
+
+	var users []models.User
+	if err := s.db.Where("id IN ?", userIDs).Order("id ASC").Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("查询候选人失败: %w", err)
+	}
+	var profiles []models.AssigneeProfile
+	if err := s.db.Where("user_id IN ?", userIDs).Find(&profiles).Error; err != nil {
+		return nil, fmt.Errorf("查询处理人状态失败: %w", err)
+	}
+	profileByUser := make(map[uint]*models.AssigneeProfile, len(profiles))
+	for i := range profiles {
+		profileByUser[profiles[i].UserID] = &profiles[i]
+	}
+	openTickets, err := countOpenTickets(s.db, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	required := assignmentRequiredSkills(rule, ticket)
+	now := s.now()
+	for _, user := range users {
+		candidate := AssignmentCandidate{
+			UserID:      user.ID,
+			Username:    user.Username,
+			DisplayName: user.DisplayName,
+			OpenTickets: openTickets[user.ID],
+			Available:   true,
+		}
+		profile := profileByUser[user.ID]
+		if profile != nil {
+			candidate.SkillScore = skillScore(profile.Skills, required)
+		}
+
+		switch {
+		case !user.IsActive:
+			candidate.Available, candidate.Reason = false, "账号已停用"
+		case profile != nil && profile.IsAway(now):
+			candidate.Available, candidate.Reason = false, "暂离"
+		case profile != nil && profile.MaxOpenTickets > 0 && candidate.OpenTickets >= int64(profile.MaxOpenTickets):
+			candidate.Available, candidate.Reason = false, fmt.Sprintf("未完成工单已达上限 %d", profile.MaxOpenTickets)
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
+
+// pickAssignmentCandidate 按规则的策略在可用候选人中选择处理人，候选人按用户ID升序排列
+func pickAssignmentCandidate(rule *models.TicketAssignmentRule, candidates []AssignmentCandidate) *AssignmentCandidate {
+	var available []*AssignmentCandidate
+	for i := range candidates {
+		if candidates[i].Available {
+			available = append(available, &candidates[i])
+		}
+	}
+	if len(available) == 0 {
+		return nil
+	}
+
+	switch rule.Strategy {
+	case models.AssignStrategyRoundRobin:
+		if rule.LastAssigneeID != nil {
+			for _, candidate := range available {
+				if candidate.UserID > *rule.LastAssigneeID {
+					return candidate
+				}
+			}
+		}
+		return available[0]
+	case models.AssignStrategySkills:
+		best := 0
+		for _, candidate := range available {
+			if candidate.SkillScore > best {
+				best = candidate.SkillScore
+			}
+		}
+		// 无人具备所需技能时退化为按未完成工单数分配
+		if best > 0 {
+			var skilled []*AssignmentCandidate
+			for _, candidate := range available {
+				if candidate.SkillScore == best {
+					skilled = append(skilled, candidate)
+				}
+			}
+			available = skilled
+		}
+	}
+
+	sort.SliceStable(available, func(i, j int) bool {
+		return available[i].OpenTickets < available[j].OpenTickets
+	})
+	return available[0]
+}
+
+// assignmentRuleMatches 检查工单是否满足规则条件
+func assignmentRuleMatches(rule *models.TicketAssignmentRule, ticket *models.Ticket) bool {
+	if rule.TicketType != "" && rule.TicketType != ticket.Type {
+		return false
+	}
+	if rule.Category != "" && rule.Category != ticket.Category {
+		return false
+	}
+	if rule.TicketPriority != "" && rule.TicketPriority != ticket.Priority {
+		return false
+	}
+	if len(rule.Tags) == 0 {
+		return true
+	}
+	return skillScore(ticket.Tags, rule.Tags) > 0
+}
+
+// assignmentRequiredSkills 规则所需技能，未配置时使用工单的标签与分类
+func assignmentRequiredSkills(rule *models.TicketAssignmentRule, ticket *models.Ticket) []string {
+	if len(rule.Skills) > 0 {
+		return rule.Skills
+	}
+	required := append([]string{}, ticket.Tags...)
+	if ticket.Category != "" {
+		required = append(required, ticket.Category)
+	}
+	return required
+}
+
+// skillScore 统计 required 中有多少项出现在 have 中，按标签规则忽略大小写与多余空白
+func skillScore(have, required []string) int {
+	owned := make(map[string]bool, len(have))
+	for _, name := range have {
+		owned[NormalizeTagName(name)] = true
+	}
+	score := 0
+	seen := make(map[string]bool, len(required))
+	for _, name := range required {
+		normalized := NormalizeTagName(name)
+		if normalized == "" || seen[normalized] {
+			continue
+		}
+		seen[normalized] = true
+		if owned[normalized] {
+			score++
+		}
+	}
+	return score
+}
+
+// countOpenTickets 统计用户处理中的未完成工单数
+func countOpenTickets(db *gorm.DB, userIDs []uint) (map[uint]int64, error) {
+	var rows []struct {
+		AssigneeID uint
+		Count      int64
+	}
+	err := db.Model(&models.Ticket{}).
+		Select("assignee_id, COUNT(*) AS count").
+		Where("assignee_id IN ? AND status NOT IN ?", userIDs,
+			[]string{string(models.TicketStatusResolved), string(models.TicketStatusClosed), string(models.TicketStatusRejected)}).
+		Group("assignee_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("统计未完成工单失败: %w", err)
+	}
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.AssigneeID] = row.Count
+	}
+	return counts, nil
+}
+
+// ListRules 按匹配顺序获取全部分配规则
+func (s *TicketAssignmentService) ListRules() ([]models.TicketAssignmentRule, error) {
+	var rules []models.TicketAssignmentRule
+	if err := s.db.Preload("Members.User").Order("sort_order ASC, id ASC").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("获取分配规则失败: %w", err)
+	}
+	return rules, nil
+}
+
+// GetRule 获取分配规则
+func (s *TicketAssignmentService) GetRule(id uint) (*models.TicketAssignmentRule, error) {
+	var rule models.TicketAssignmentRule
+	if err := s.db.Preload("Members.User").First(&rule, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("分配规则不存在")
+		}
+		return nil, fmt.Errorf("获取分配规则失败: %w", err)
+	}
+	return &rule, nil
+}
+
+// CreateRule 创建分配规则
+func (s *TicketAssignmentService) CreateRule(req *SaveAssignmentRuleRequest, userID uint, ipAddress, userAgent string) (*models.TicketAssignmentRule, error) {
+	rule := models.TicketAssignmentRule{CreatedBy: userID, IsActive: true}
+	if err := s.saveRule(&rule, req); err != nil {
+		return nil, err
+	}
+
+	s.auditRule("CREATE", rule.ID, nil, map[string]interface{}{"name": rule.Name, "strategy": rule.Strategy}, userID, ipAddress, userAgent)
+	return s.GetRule(rule.ID)
+}
+
+// UpdateRule 更新分配规则
+func (s *TicketAssignmentService) UpdateRule(id uint, req *SaveAssignmentRuleRequest, userID uint, ipAddress, userAgent string) (*models.TicketAssignmentRule, error) {
+	rule, err := s.GetRule(id)
+	if err != nil {
+		return nil, err
+	}
+	oldValues := map[string]interface{}{"name": rule.Name, "strategy": rule.Strategy, "target_role": rule.TargetRole, "is_active": rule.IsActive}
+	if err := s.saveRule(rule, req); err != nil {
+		return nil, err
+	}
+
+	s.auditRule("UPDATE", rule.ID, oldValues, map[string]interface{}{"name": rule.Name, "strategy": rule.Strategy}, userID, ipAddress, userAgent)
+	return s.GetRule(rule.ID)
+}
+
+// DeleteRule 删除分配规则
+func (s *TicketAssignmentService) DeleteRule(id uint, userID uint, ipAddress, userAgent string) error {
+	rule, err := s.GetRule(id)
+	if err != nil {
+		return err
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("rule_id = ?", id).Delete(&models.TicketAssignmentMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.TicketAssignmentRule{}, id).Error
+	})
+	if err != nil {
+		return fmt.Errorf("删除分配规则失败: %w", err)
+	}
+
+	s.auditRule("DELETE", id, map[string]interface{}{"name": rule.Name}, nil, userID, ipAddress, userAgent)
+	return nil
+}
+
+// ReorderRules 按给定顺序重新编号分配规则，未列出的规则排在其后并保持原有顺序
+func (s *TicketAssignmentService) ReorderRules(req *ReorderAssignmentRulesRequest, userID uint, ipAddress, userAgent string) ([]models.TicketAssignmentRule, error) {
+	rules, err := s.ListRules()
+	if err != nil {
+		return nil, err
+	}
+	position := make(map[uint]int, len(req.RuleIDs))
+	for i, id := range req.RuleIDs {
+		if _, ok := position[id]; ok {
+			return nil, fmt.Errorf("分配规则 %d 重复", id)
+		}
+		position[id] = i
+	}
+	exists := make(map[uint]bool, len(rules))
+	for _, rule := range rules {
+		exists[rule.ID] = true
+	}
+	for _, id := range req.RuleIDs {
+		if !exists[id] {
+			return nil, fmt.Errorf("分配规则不存在")
+		}
+	}
+
+	sort.SliceStable(rules, func(i, j int) bool {
+		pi, iListed := position[rules[i].ID]
+		pj, jListed := position[rules[j].ID]
+		if iListed != jListed {
+			return iListed
+		}
+		return iListed && pi < pj
+	})
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for i := range rules {
+			rules[i].SortOrder = (i + 1) * 10
+			if err := tx.Model(&models.TicketAssignmentRule{}).Where("id = ?", rules[i].ID).Update("sort_order", rules[i].SortOrder).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("调整分配规则顺序失败: %w", err)
+	}
+
+	s.auditRule("REORDER", 0, nil, map[string]interface{}{"rule_ids": req.RuleIDs}, userID, ipAddress, userAgent)
+	return rules, nil
+}
+
+// saveRule 校验请求并保存分配规则与团队成员
+func (s *TicketAssignmentService) saveRule(rule *models.TicketAssignmentRule, req *SaveAssignmentRuleRequest) error {
+	rule.Name = strings.TrimSpace(req.Name)
+	rule.Description = req.Description
+	rule.SortOrder = req.SortOrder
+	rule.TicketType = models.TicketType(strings.TrimSpace(req.TicketType))
+	rule.Category = strings.TrimSpace(req.Category)
+	rule.TicketPriority = models.TicketPriority(strings.TrimSpace(req.TicketPriority))
+	rule.Tags = cleanNames(req.Tags)
+	rule.TargetRole = strings.TrimSpace(req.TargetRole)
+	rule.Strategy = strings.TrimSpace(req.Strategy)
+	rule.Skills = cleanNames(req.Skills)
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+	if rule.Strategy == "" {
+		rule.Strategy = models.AssignStrategyLeastOpen
+	}
+	memberIDs := uniqueUints(req.MemberIDs)
+
+	if rule.Name == "" {
+		return fmt.Errorf("规则名称不能为空")
+	}
+	if rule.TicketType != "" && !isValidTicketType(rule.TicketType) {
+		return fmt.Errorf("无效的工单类型: %s", rule.TicketType)
+	}
+	if rule.TicketPriority != "" && !isValidTicketPriority(rule.TicketPriority) {
+		return fmt.Errorf("无效的优先级: %s", rule.TicketPriority)
+	}
+	if !models.IsValidAssignStrategy(rule.Strategy) {
+		return fmt.Errorf("无效的分配策略: %s", rule.Strategy)
+	}
+	if rule.TargetRole == "" && len(memberIDs) == 0 {
+		return fmt.Errorf("请指定分配角色或团队成员")
+	}
+	if rule.TargetRole != "" {
+		var count int64
+		if err := s.db.Model(&models.Role{}).Where("name = ?", rule.TargetRole).Count(&count).Error; err != nil {
+			return fmt.Errorf("检查角色失败: %w", err)
+		}
+		if count == 0 {
+			return fmt.Errorf("角色不存在: %s", rule.TargetRole)
+		}
+	}
+	if len(memberIDs) > 0 {
+		var count int64
+		if err := s.db.Model(&models.User{}).Where("id IN ?", memberIDs).Count(&count).Error; err != nil {
+			return fmt.Errorf("检查团队成员失败: %w", err)
+		}
+		if int(count) != len(memberIDs) {
+			return fmt.Errorf("团队成员中存在不存在的用户")
+		}
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		rule.Members = nil
+		if rule.ID == 0 {
+			// 布尔字段有默认值，创建后会被回填为 true，false 需要单独更新
+			inactive := !rule.IsActive
+			if err := tx.Create(rule).Error; err != nil {
+				return err
+			}
+			if inactive {
+				if err := tx.Model(rule).Update("is_active", false).Error; err != nil {
+					return err
+				}
+			}
+		} else {
+			if err := tx.Omit("created_by", "created_at").Save(rule).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("rule_id = ?", rule.ID).Delete(&models.TicketAssignmentMember{}).Error; err != nil {
+				return err
+			}
+		}
+		for _, userID := range memberIDs {
+			if err := tx.Create(&models.TicketAssignmentMember{RuleID: rule.ID, UserID: userID}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("保存分配规则失败: %w", err)
+	}
+	return nil
+}
+
+// GetProfile 获取处理人的技能与可用状态，未设置时返回默认值
+func (s *TicketAssignmentService) GetProfile(userID uint) (*models.AssigneeProfile, error) {
+	profile := models.AssigneeProfile{UserID: userID, Skills: models.StringSlice{}}
+	if err := s.db.Where("user_id = ?", userID).First(&profile).Error; err != nil && err != gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("获取处理人状态失败: %w", err)
+	}
+	return &profile, nil
+}
+
+// ListProfiles 获取已设置技能或可用状态的处理人
+func (s *TicketAssignmentService) ListProfiles() ([]models.AssigneeProfile, error) {
+	var profiles []models.AssigneeProfile
+	if err := s.db.Order("user_id ASC").Find(&profiles).Error; err != nil {
+		return nil, fmt.Errorf("获取处理人状态失败: %w", err)
+	}
+	return profiles, nil
+}
+
+// SaveProfile 更新处理人的技能与可用状态
+func (s *TicketAssignmentService) SaveProfile(userID uint, req *AssigneeProfileRequest, operatorID uint, ipAddress, userAgent string) (*models.AssigneeProfile, error) {
+	var count int64
+	if err := s.db.Model(&models.User{}).Where("id = ?", userID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("检查用户失败: %w", err)
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("用户不存在")
+	}
+	if req.AwayUntil != nil && !req.Away {
+		return nil, fmt.Errorf("未设置暂离时不能指定暂离截止时间")
+	}
+
+	profile, err := s.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+	oldValues := map[string]interface{}{"skills": profile.Skills, "away": profile.Away, "max_open_tickets": profile.MaxOpenTickets}
+	profile.Skills = cleanNames(req.Skills)
+	profile.Away = req.Away
+	profile.AwayUntil = req.AwayUntil
+	profile.MaxOpenTickets = req.MaxOpenTickets
+	if err := s.db.Save(profile).Error; err != nil {
+		return nil, fmt.Errorf("保存处理人状态失败: %w", err)
+	}
+
+	if s.auditService != nil {
+		s.auditService.CreateAuditLog(&AuditLogRequest{
+			UserID:       operatorID,
+			Action:       "UPDATE",
+			ResourceType: "assignee_profile",
+			ResourceID:   userID,
+			OldValues:    oldValues,
+			NewValues:    map[string]interface{}{"skills": profile.Skills, "away": profile.Away, "max_open_tickets": profile.MaxOpenTickets},
+			IPAddress:    ipAddress,
+			UserAgent:    userAgent,
+		})
+	}
+	return profile, nil
+}
+
+// auditRule 记录分配规则变更审计日志
+func (s *TicketAssignmentService) auditRule(action string, ruleID uint, oldValues, newValues map[string]interface{}, userID uint, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	s.auditService.CreateAuditLog(&AuditLogRequest{
+		UserID:       userID,
+		Action:       action,
+		ResourceType: "ticket_assignment_rule",
+		ResourceID:   ruleID,
+		OldValues:    oldValues,
+		NewValues:    newValues,
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+	})
+}
+
+// cleanNames 去除空白与重复项（忽略大小写），保留首次出现的写法
+func cleanNames(names []string) models.StringSlice {
+	cleaned := models.StringSlice{}
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		display := cleanTagName(name)
+		normalized := strings.ToLower(display)
+		if display == "" || seen[normalized] {
+			continue
+		}
+		seen[normalized] = true
+		cleaned = append(cleaned, display)
+	}
+	return cleaned
+}
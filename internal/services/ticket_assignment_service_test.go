@@ -0,0 +1,151 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTicketAssignmentService_Rules(t *testing.T) {
+	db := setupTicketWorkflowTest(t).db
+	s := NewTicketAssignmentService(db, NewAuditService(db))
+	grantRole(t, db, 2, "support")
+	grantRole(t, db, 3, "support")
+	grantRole(t, db, 4, "support")
+
+	_, err := s.CreateRule(&SaveAssignmentRuleRequest{Name: "无目标"}, 1, "", "")
+	assert.EqualError(t, err, "请指定分配角色或团队成员")
+	_, err = s.CreateRule(&SaveAssignmentRuleRequest{Name: "未知角色", TargetRole: "ghost"}, 1, "", "")
+	assert.EqualError(t, err, "角色不存在: ghost")
+	_, err = s.CreateRule(&SaveAssignmentRuleRequest{Name: "错误策略", TargetRole: "support", Strategy: "random"}, 1, "", "")
+	assert.EqualError(t, err, "无效的分配策略: random")
+
+	inactive := false
+	disabled, err := s.CreateRule(&SaveAssignmentRuleRequest{Name: "停用", SortOrder: 1, TargetRole: "support", IsActive: &inactive}, 1, "", "")
+	require.NoError(t, err)
+	assert.False(t, disabled.IsActive)
+	network, err := s.CreateRule(&SaveAssignmentRuleRequest{Name: "网络", SortOrder: 20, Tags: []string{"VPN", "网络"}, MemberIDs: []uint{4}, Strategy: models.AssignStrategyRoundRobin}, 1, "", "")
+	require.NoError(t, err)
+	support, err := s.CreateRule(&SaveAssignmentRuleRequest{Name: "技术支持", SortOrder: 10, TicketType: "support", TargetRole: "support", Strategy: models.AssignStrategyRoundRobin}, 1, "", "")
+	require.NoError(t, err)
+
+	// 停用的规则不参与匹配，按排序先匹配技术支持规则，标签忽略大小写
+	ticket := &models.Ticket{Type: models.TicketTypeSupport, Tags: models.StringSlice{"vpn"}}
+	rule, err := s.MatchRule(ticket)
+	require.NoError(t, err)
+	assert.Equal(t, support.ID, rule.ID)
+	rule, err = s.MatchRule(&models.Ticket{Type: models.TicketTypeBug, Tags: models.StringSlice{"vpn"}})
+	require.NoError(t, err)
+	assert.Equal(t, network.ID, rule.ID)
+	rule, err = s.MatchRule(&models.Ticket{Type: models.TicketTypeBug})
+	require.NoError(t, err)
+	assert.Nil(t, rule)
+
+	// 调整顺序后网络规则优先
+	rules, err := s.ReorderRules(&ReorderAssignmentRulesRequest{RuleIDs: []uint{network.ID, support.ID}}, 1, "", "")
+	require.NoError(t, err)
+	require.Len(t, rules, 3)
+	assert.Equal(t, []uint{network.ID, support.ID, disabled.ID}, []uint{rules[0].ID, rules[1].ID, rules[2].ID})
+	rule, err = s.MatchRule(ticket)
+	require.NoError(t, err)
+	assert.Equal(t, network.ID, rule.ID)
+
+	// 轮流分配：预览不推进分配位置，自动分配依次轮换
+	preview, err := s.Preview(&AssignmentPreviewRequest{RuleID: support.ID})
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), preview.AssigneeID)
+	assert.Len(t, preview.Candidates, 3)
+	var assigned []uint
+	for i := 0; i < 4; i++ {
+		result, err := s.AutoAssign(&models.Ticket{Type: models.TicketTypeSupport})
+		require.NoError(t, err)
+		require.NotNil(t, result)
+		assigned = append(assigned, result.AssigneeID)
+	}
+	assert.Equal(t, []uint{2, 3, 4, 2}, assigned)
+
+	// 停用账号与暂离成员被跳过
+	require.NoError(t, db.Model(&models.User{}).Where("id = ?", 3).Update("is_active", false).Error)
+	_, err = s.SaveProfile(4, &AssigneeProfileRequest{Away: true}, 4, "", "")
+	require.NoError(t, err)
+	preview, err = s.Preview(&AssignmentPreviewRequest{Type: "support"})
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), preview.AssigneeID)
+	reasons := map[uint]string{}
+	for _, candidate := range preview.Candidates {
+		reasons[candidate.UserID] = candidate.Reason
+	}
+	assert.Equal(t, map[uint]string{2: "", 3: "账号已停用", 4: "暂离"}, reasons)
+
+	// 网络规则只有暂离的成员时无人可分配
+	result, err := s.AutoAssign(ticket)
+	require.NoError(t, err)
+	assert.Nil(t, result)
+
+	// 暂离到期后恢复分配
+	past := time.Now().Add(-time.Hour)
+	_, err = s.SaveProfile(4, &AssigneeProfileRequest{Away: true, AwayUntil: &past}, 4, "", "")
+	require.NoError(t, err)
+	result, err = s.AutoAssign(ticket)
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, uint(4), result.AssigneeID)
+
+	require.NoError(t, s.DeleteRule(network.ID, 1, "", ""))
+	_, err = s.GetRule(network.ID)
+	assert.EqualError(t, err, "分配规则不存在")
+}
+
+func TestTicketAssignmentService_Strategies(t *testing.T) {
+	db := setupTicketWorkflowTest(t).db
+	s := NewTicketAssignmentService(db, nil)
+	grantRole(t, db, 2, "developer")
+	grantRole(t, db, 3, "developer")
+	grantRole(t, db, 4, "developer")
+
+	// alice 有两张未完成工单，bob 有一张，carol 的工单已关闭
+	for _, ticket := range []models.Ticket{
+		{Title: "a1", Type: models.TicketTypeBug, Status: models.TicketStatusInProgress, CreatorID: 1, AssigneeID: uintPtr(2)},
+		{Title: "a2", Type: models.TicketTypeBug, Status: models.TicketStatusAssigned, CreatorID: 1, AssigneeID: uintPtr(2)},
+		{Title: "b1", Type: models.TicketTypeBug, Status: models.TicketStatusInProgress, CreatorID: 1, AssigneeID: uintPtr(3)},
+		{Title: "c1", Type: models.TicketTypeBug, Status: models.TicketStatusClosed, CreatorID: 1, AssigneeID: uintPtr(4)},
+	} {
+		require.NoError(t, db.Create(&ticket).Error)
+	}
+
+	least, err := s.CreateRule(&SaveAssignmentRuleRequest{Name: "负载", TargetRole: "developer"}, 1, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, models.AssignStrategyLeastOpen, least.Strategy)
+	result, err := s.Preview(&AssignmentPreviewRequest{RuleID: least.ID})
+	require.NoError(t, err)
+	assert.Equal(t, uint(4), result.AssigneeID)
+
+	// carol 达到未完成工单上限后分配给 bob
+	_, err = s.SaveProfile(4, &AssigneeProfileRequest{MaxOpenTickets: 1}, 1, "", "")
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&models.Ticket{Title: "c2", Type: models.TicketTypeBug, Status: models.TicketStatusInProgress, CreatorID: 1, AssigneeID: uintPtr(4)}).Error)
+	result, err = s.Preview(&AssignmentPreviewRequest{RuleID: least.ID})
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), result.AssigneeID)
+
+	// 技能匹配优先于负载，未配置技能时使用工单标签与分类
+	skills, err := s.CreateRule(&SaveAssignmentRuleRequest{Name: "技能", TargetRole: "developer", Strategy: models.AssignStrategySkills}, 1, "", "")
+	require.NoError(t, err)
+	_, err = s.SaveProfile(2, &AssigneeProfileRequest{Skills: []string{"Database", " 网络 ", "database"}}, 1, "", "")
+	require.NoError(t, err)
+	profile, err := s.GetProfile(2)
+	require.NoError(t, err)
+	assert.Equal(t, models.StringSlice{"Database", "网络"}, profile.Skills)
+	result, err = s.Preview(&AssignmentPreviewRequest{RuleID: skills.ID, Category: "网络", Tags: []string{"DATABASE"}})
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), result.AssigneeID)
+
+	// 无人具备所需技能时按负载分配
+	result, err = s.Preview(&AssignmentPreviewRequest{RuleID: skills.ID, Tags: []string{"前端"}})
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), result.AssigneeID)
+}
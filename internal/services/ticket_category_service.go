@@ -0,0 +1,192 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ticketCategoryColorPattern 分类颜色为 #RRGGBB
+var ticketCategoryColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// TicketCategoryService 工单分类及其自定义字段定义管理
+type TicketCategoryService struct {
+	db           *gorm.DB
+	auditService *AuditService
+}
+
+// NewTicketCategoryService 创建工单分类服务
+func NewTicketCategoryService(db *gorm.DB, auditService *AuditService) *TicketCategoryService {
+	return &TicketCategoryService{
+		db:           db,
+		auditService: auditService,
+	}
+}
+
+// SaveTicketCategoryRequest 创建或更新工单分类请求，名称创建后不能修改
+type SaveTicketCategoryRequest struct {
+	Name         string                 `json:"name" binding:"required,max=100"`
+	DisplayName  string                 `json:"display_name" binding:"required,max=200"`
+	Description  string                 `json:"description" binding:"max=500"`
+	Color        string                 `json:"color" binding:"max=7"`
+	CustomFields map[string]interface{} `json:"custom_fields"` // {"fields": [...]}
+	IsActive     *bool                  `json:"is_active"`
+}
+
+// ListCategories 获取全部工单分类，包括停用的分类
+func (s *TicketCategoryService) ListCategories() ([]models.TicketCategory, error) {
+	var categories []models.TicketCategory
+	if err := s.db.Order("name ASC").Find(&categories).Error; err != nil {
+		return nil, fmt.Errorf("获取工单分类失败: %w", err)
+	}
+	return categories, nil
+}
+
+// GetCategory 获取工单分类
+func (s *TicketCategoryService) GetCategory(id uint) (*models.TicketCategory, error) {
+	var category models.TicketCategory
+	if err := s.db.First(&category, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("工单分类不存在")
+		}
+		return nil, fmt.Errorf("获取工单分类失败: %w", err)
+	}
+	return &category, nil
+}
+
+// CreateCategory 创建工单分类
+func (s *TicketCategoryService) CreateCategory(req *SaveTicketCategoryRequest, userID uint, ipAddress, userAgent string) (*models.TicketCategory, error) {
+	category := models.TicketCategory{Name: strings.TrimSpace(req.Name), IsActive: true}
+	if category.Name == "" {
+		return nil, fmt.Errorf("分类名称不能为空")
+	}
+	if err := s.applyCategoryRequest(&category, req); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.Unscoped().Model(&models.TicketCategory{}).Where("name = ?", category.Name).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("检查工单分类失败: %w", err)
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("工单分类 %s 已存在", category.Name)
+	}
+
+	// 布尔字段有默认值，创建后会被回填为 true，false 需要单独更新
+	inactive := !category.IsActive
+	if err := s.db.Create(&category).Error; err != nil {
+		return nil, fmt.Errorf("创建工单分类失败: %w", err)
+	}
+	if inactive {
+		s.db.Model(&category).Update("is_active", false)
+	}
+
+	s.auditCategory("CREATE", &category, nil, userID, ipAddress, userAgent)
+	return &category, nil
+}
+
+// UpdateCategory 更新工单分类，已有工单的自定义字段值在下次编辑时按新定义校验
+func (s *TicketCategoryService) UpdateCategory(id uint, req *SaveTicketCategoryRequest, userID uint, ipAddress, userAgent string) (*models.TicketCategory, error) {
+	category, err := s.GetCategory(id)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.Name) != category.Name {
+		return nil, fmt.Errorf("分类名称创建后不能修改")
+	}
+	oldValues := categoryAuditValues(category)
+	if err := s.applyCategoryRequest(category, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.Omit("created_at").Save(category).Error; err != nil {
+		return nil, fmt.Errorf("更新工单分类失败: %w", err)
+	}
+
+	s.auditCategory("UPDATE", category, oldValues, userID, ipAddress, userAgent)
+	return category, nil
+}
+
+// DeleteCategory 删除工单分类，已有工单保留分类名与字段值
+func (s *TicketCategoryService) DeleteCategory(id uint, userID uint, ipAddress, userAgent string) error {
+	category, err := s.GetCategory(id)
+	if err != nil {
+		return err
+	}
+	// 分类名唯一，彻底删除以便重新创建同名分类
+	if err := s.db.Unscoped().Delete(category).Error; err != nil {
+		return fmt.Errorf("删除工单分类失败: %w", err)
+	}
+
+	s.auditCategory("DELETE", category, nil, userID, ipAddress, userAgent)
+	return nil
+}
+
+// FieldDefinitions 获取工单类型与分类适用的自定义字段，供创建与编辑表单使用
+func (s *TicketCategoryService) FieldDefinitions(ticketType, category string) ([]TicketFieldDefinition, error) {
+	fields, err := ResolveTicketFields(s.db, ticketType, category)
+	if err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		fields = []TicketFieldDefinition{}
+	}
+	return fields, nil
+}
+
+// applyCategoryRequest 校验请求并写入分类字段
+func (s *TicketCategoryService) applyCategoryRequest(category *models.TicketCategory, req *SaveTicketCategoryRequest) error {
+	fields, err := ParseTicketFieldDefinitions(models.JSONB(req.CustomFields))
+	if err != nil {
+		return err
+	}
+	raw, err := ticketFieldDefinitionsJSONB(fields)
+	if err != nil {
+		return err
+	}
+	if req.Color != "" && !ticketCategoryColorPattern.MatchString(req.Color) {
+		return fmt.Errorf("分类颜色格式应为 #RRGGBB")
+	}
+
+	category.DisplayName = strings.TrimSpace(req.DisplayName)
+	category.Description = req.Description
+	category.Color = req.Color
+	category.CustomFields = raw
+	if req.IsActive != nil {
+		category.IsActive = *req.IsActive
+	}
+	if category.DisplayName == "" {
+		return fmt.Errorf("分类显示名称不能为空")
+	}
+	return nil
+}
+
+// categoryAuditValues 审计日志中记录的分类字段
+func categoryAuditValues(category *models.TicketCategory) map[string]interface{} {
+	return map[string]interface{}{
+		"name":          category.Name,
+		"display_name":  category.DisplayName,
+		"is_active":     category.IsActive,
+		"custom_fields": category.CustomFields,
+	}
+}
+
+// auditCategory 记录分类配置变更审计日志
+func (s *TicketCategoryService) auditCategory(action string, category *models.TicketCategory, oldValues map[string]interface{}, userID uint, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	s.auditService.CreateAuditLog(&AuditLogRequest{
+		UserID:       userID,
+		Action:       action,
+		ResourceType: "ticket_category",
+		ResourceID:   category.ID,
+		OldValues:    oldValues,
+		NewValues:    categoryAuditValues(category),
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+	})
+}
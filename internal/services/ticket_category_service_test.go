@@ -0,0 +1,229 @@
+package services
+
+import (
+	"testing"
+
+	"info-management-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTicketCategoryTest(t *testing.T) *TicketCategoryService {
+	workflow := setupTicketWorkflowTest(t)
+	return NewTicketCategoryService(workflow.db, workflow.auditService)
+}
+
+func fieldConfig(fields ...map[string]interface{}) map[string]interface{} {
+	list := make([]interface{}, len(fields))
+	for i, field := range fields {
+		list[i] = field
+	}
+	return map[string]interface{}{"fields": list}
+}
+
+func TestTicketCategoryService_Definitions(t *testing.T) {
+	s := setupTicketCategoryTest(t)
+
+	invalid := []struct {
+		field map[string]interface{}
+		err   string
+	}{
+		{map[string]interface{}{"name": "1os", "type": "text"}, "自定义字段名 \"1os\" 无效"},
+		{map[string]interface{}{"name": "os", "type": "checkbox"}, "类型 \"checkbox\" 无效"},
+		{map[string]interface{}{"name": "os", "type": "select"}, "缺少可选值"},
+		{map[string]interface{}{"name": "os", "type": "select", "options": []interface{}{"win"}, "default": "mac"}, "默认值无效"},
+		{map[string]interface{}{"name": "owner", "type": "user", "default": 1}, "不支持默认值"},
+	}
+	for _, tc := range invalid {
+		_, err := s.CreateCategory(&SaveTicketCategoryRequest{Name: "bad", DisplayName: "Bad", CustomFields: fieldConfig(tc.field)}, 1, "", "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), tc.err)
+	}
+
+	bug, err := s.CreateCategory(&SaveTicketCategoryRequest{Name: "bug", DisplayName: "故障", CustomFields: fieldConfig(
+		map[string]interface{}{"name": "os", "label": "操作系统", "type": "select", "options": []interface{}{"windows", "linux", " linux "}, "required": true},
+		map[string]interface{}{"name": "version", "type": "text", "default": "latest"},
+	)}, 1, "", "")
+	require.NoError(t, err)
+	fields, err := ParseTicketFieldDefinitions(bug.CustomFields)
+	require.NoError(t, err)
+	require.Len(t, fields, 2)
+	assert.Equal(t, []string{"windows", "linux"}, fields[0].Options)
+	assert.Equal(t, "version", fields[1].Label)
+
+	_, err = s.CreateCategory(&SaveTicketCategoryRequest{Name: "bug", DisplayName: "重复"}, 1, "", "")
+	assert.EqualError(t, err, "工单分类 bug 已存在")
+
+	active, inactive := true, false
+	_, err = s.CreateCategory(&SaveTicketCategoryRequest{Name: "network", DisplayName: "网络", IsActive: &inactive, CustomFields: fieldConfig(
+		map[string]interface{}{"name": "version", "label": "固件版本", "type": "number", "required": true},
+		map[string]interface{}{"name": "sites", "type": "multi-select", "options": []interface{}{"北京", "上海"}},
+		map[string]interface{}{"name": "due", "type": "date"},
+		map[string]interface{}{"name": "owner", "type": "user"},
+	)}, 1, "", "")
+	require.NoError(t, err)
+
+	// 停用的分类不生效
+	fields, err = s.FieldDefinitions("bug", "network")
+	require.NoError(t, err)
+	require.Len(t, fields, 2)
+
+	categories, err := s.ListCategories()
+	require.NoError(t, err)
+	require.Len(t, categories, 2)
+	network := categories[1]
+	assert.False(t, network.IsActive)
+	_, err = s.UpdateCategory(network.ID, &SaveTicketCategoryRequest{Name: "net", DisplayName: "网络"}, 1, "", "")
+	assert.EqualError(t, err, "分类名称创建后不能修改")
+	_, err = s.UpdateCategory(network.ID, &SaveTicketCategoryRequest{Name: "network", DisplayName: "网络", Color: "red", CustomFields: network.CustomFields}, 1, "", "")
+	assert.EqualError(t, err, "分类颜色格式应为 #RRGGBB")
+	_, err = s.UpdateCategory(network.ID, &SaveTicketCategoryRequest{Name: "network", DisplayName: "网络", Color: "#409eff", CustomFields: network.CustomFields, IsActive: &active}, 1, "", "")
+	require.NoError(t, err)
+
+	// 与类型同名的分类先生效，工单分类中的同名字段覆盖类型的定义
+	fields, err = s.FieldDefinitions("bug", "network")
+	require.NoError(t, err)
+	names := []string{}
+	for _, field := range fields {
+		names = append(names, field.Name+":"+field.Type)
+	}
+	assert.Equal(t, []string{"os:select", "version:number", "sites:multiselect", "due:date", "owner:user"}, names)
+
+	var logs int64
+	s.db.Model(&models.AuditLog{}).Where("resource_type = ?", "ticket_category").Count(&logs)
+	assert.Equal(t, int64(3), logs)
+
+	require.NoError(t, s.DeleteCategory(network.ID, 1, "", ""))
+	_, err = s.GetCategory(network.ID)
+	assert.EqualError(t, err, "工单分类不存在")
+	_, err = s.CreateCategory(&SaveTicketCategoryRequest{Name: "network", DisplayName: "网络"}, 1, "", "")
+	assert.NoError(t, err)
+}
+
+func TestPrepareTicketCustomFields(t *testing.T) {
+	s := setupTicketCategoryTest(t)
+	_, err := s.CreateCategory(&SaveTicketCategoryRequest{Name: "bug", DisplayName: "故障", CustomFields: fieldConfig(
+		map[string]interface{}{"name": "os", "label": "操作系统", "type": "select", "options": []interface{}{"windows", "linux"}, "required": true},
+		map[string]interface{}{"name": "cost", "type": "number"},
+		map[string]interface{}{"name": "sites", "type": "multiselect", "options": []interface{}{"北京", "上海"}},
+		map[string]interface{}{"name": "due", "type": "date"},
+		map[string]interface{}{"name": "owner", "type": "user"},
+		map[string]interface{}{"name": "env", "type": "text", "default": "prod"},
+	)}, 1, "", "")
+	require.NoError(t, err)
+
+	_, err = PrepareTicketCustomFields(s.db, "bug", "", nil, map[string]interface{}{
+		"cost": "abc", "sites": []interface{}{"广州"}, "due": "2024/13/01", "owner": float64(99), "color": "red",
+	})
+	require.Error(t, err)
+	assert.Equal(t, "自定义字段无效: 未定义的字段 color; 字段 操作系统 为必填项; 字段 cost 必须是数字; 字段 sites 广州 不是可选值; "+
+		"字段 due 日期格式应为 YYYY-MM-DD; 字段 owner 的用户 99 不存在或已停用", err.Error())
+
+	values, err := PrepareTicketCustomFields(s.db, "bug", "", nil, map[string]interface{}{
+		"os": "linux", "cost": "12.5", "sites": []interface{}{"上海", "北京", "上海"}, "due": "2024-03-01T08:00:00Z", "owner": float64(2),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.JSONB{
+		"os": "linux", "cost": 12.5, "sites": []interface{}{"上海", "北京"}, "due": "2024-03-01", "owner": float64(2), "env": "prod",
+	}, values)
+
+	// 更新只修改提供的字段，null 表示清空
+	values, err = PrepareTicketCustomFields(s.db, "bug", "", values, map[string]interface{}{"cost": nil, "env": "test"})
+	require.NoError(t, err)
+	assert.NotContains(t, values, "cost")
+	assert.Equal(t, "test", values["env"])
+	assert.Equal(t, "linux", values["os"])
+
+	_, err = PrepareTicketCustomFields(s.db, "bug", "", values, map[string]interface{}{"os": nil})
+	assert.EqualError(t, err, "自定义字段无效: 字段 操作系统 为必填项")
+
+	// 没有字段定义的类型丢弃原有值
+	values, err = PrepareTicketCustomFields(s.db, "feature", "", values, nil)
+	require.NoError(t, err)
+	assert.Nil(t, values)
+}
+
+func TestTicketCustomFields_FilterSortImportExport(t *testing.T) {
+	s := setupTicketCategoryTest(t)
+	_, err := s.CreateCategory(&SaveTicketCategoryRequest{Name: "bug", DisplayName: "故障", CustomFields: fieldConfig(
+		map[string]interface{}{"name": "os", "label": "操作系统", "type": "select", "options": []interface{}{"windows", "linux"}},
+		map[string]interface{}{"name": "cost", "label": "费用", "type": "number"},
+		map[string]interface{}{"name": "sites", "label": "地点", "type": "multiselect", "options": []interface{}{"北京", "上海"}},
+		map[string]interface{}{"name": "due", "label": "期限", "type": "date"},
+		map[string]interface{}{"name": "owner", "label": "负责人", "type": "user"},
+	)}, 1, "", "")
+	require.NoError(t, err)
+
+	rows := []map[string]interface{}{
+		{"os": "linux", "cost": float64(100), "sites": []interface{}{"北京"}, "due": "2024-01-10"},
+		{"os": "windows", "cost": float64(20), "sites": []interface{}{"北京", "上海"}, "due": "2024-02-10", "owner": float64(3)},
+		{"os": "linux", "cost": float64(5)},
+		{},
+	}
+	tickets := make([]models.Ticket, len(rows))
+	for i, row := range rows {
+		values, err := PrepareTicketCustomFields(s.db, "bug", "", nil, row)
+		require.NoError(t, err)
+		tickets[i] = models.Ticket{Title: "t", Type: "bug", CreatorID: 2, CustomFields: values}
+		require.NoError(t, s.db.Create(&tickets[i]).Error)
+		require.NoError(t, SyncTicketFieldValues(s.db, tickets[i].ID, values))
+	}
+
+	find := func(filters map[string]string) []uint {
+		db, err := FilterByCustomFields(s.db.Model(&models.Ticket{}), "tickets.id", filters)
+		require.NoError(t, err)
+		var ids []uint
+		require.NoError(t, db.Order("id").Pluck("id", &ids).Error)
+		return ids
+	}
+	ids := func(list ...int) []uint {
+		result := []uint{}
+		for _, i := range list {
+			result = append(result, tickets[i].ID)
+		}
+		return result
+	}
+	assert.Equal(t, ids(0, 2), find(map[string]string{"os": "linux"}))
+	assert.Equal(t, ids(0, 1), find(map[string]string{"sites": "北京"}))
+	assert.Equal(t, ids(1), find(map[string]string{"sites": "上海,广州", "os": "windows"}))
+	assert.Equal(t, ids(1, 2), find(map[string]string{"cost": "..20"}))
+	assert.Equal(t, ids(0, 1), find(map[string]string{"cost": "20.0..100"}))
+	assert.Equal(t, ids(1), find(map[string]string{"due": "2024-02-01..2024-02-29"}))
+	assert.Equal(t, ids(1), find(map[string]string{"owner": "3"}))
+	_, err = FilterByCustomFields(s.db, "tickets.id", map[string]string{"os = 1 --": "x"})
+	assert.Error(t, err)
+
+	sorted := func(name string, desc bool) []uint {
+		db, err := OrderByCustomField(s.db.Model(&models.Ticket{}), "tickets.id", name, desc)
+		require.NoError(t, err)
+		var result []uint
+		require.NoError(t, db.Pluck("id", &result).Error)
+		return result
+	}
+	assert.Equal(t, ids(3, 2, 1, 0), sorted("cost", false))
+	assert.Equal(t, ids(0, 1, 2, 3), sorted("cost", true))
+	assert.Equal(t, ids(3, 0, 2, 1), sorted("os", false))
+
+	// 导入：选项不区分大小写，多选支持多种分隔符，用户可填用户名
+	fields, err := s.FieldDefinitions("bug", "")
+	require.NoError(t, err)
+	imported := map[string]interface{}{}
+	for i, cell := range []string{"Linux", "3.5", "北京；上海", "2024/3/5", "bob"} {
+		value, err := ParseTicketFieldImportValue(s.db, &fields[i], cell)
+		require.NoError(t, err)
+		imported[fields[i].Name] = value
+	}
+	values, err := PrepareTicketCustomFields(s.db, "bug", "", nil, imported)
+	require.NoError(t, err)
+	assert.Equal(t, models.JSONB{"os": "linux", "cost": 3.5, "sites": []interface{}{"北京", "上海"}, "due": "2024-03-05", "owner": float64(3)}, values)
+	_, err = ParseTicketFieldImportValue(s.db, &fields[4], "nobody")
+	assert.EqualError(t, err, "字段 负责人 的用户 nobody 不存在")
+
+	// 导出：多选以分号连接，用户显示用户名
+	export, err := NewTicketFieldExport(s.db, tickets)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"操作系统", "费用", "地点", "期限", "负责人"}, export.Headers())
+	assert.Equal(t, []string{"windows", "20", "北京; 上海", "2024-02-10", "bob"}, export.Cells(&tickets[1]))
+	assert.Equal(t, []string{"", "", "", "", ""}, export.Cells(&tickets[3]))
+}
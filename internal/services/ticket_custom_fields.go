@@ -0,0 +1,626 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 工单自定义字段类型
+const (
+	TicketFieldTypeText        = "text"
+	TicketFieldTypeNumber      = "number"
+	TicketFieldTypeSelect      = "select"
+	TicketFieldTypeMultiSelect = "multiselect"
+	TicketFieldTypeDate        = "date"
+	TicketFieldTypeUser        = "user"
+)
+
+// ticketFieldTextMaxLength 文本字段最大长度（字符）
+const ticketFieldTextMaxLength = 2000
+
+// ticketFieldNamePattern 字段名只能包含字母、数字和下划线且以字母开头，同时用于筛选参数
+var ticketFieldNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,49}$`)
+
+// TicketFieldDefinition 工单自定义字段定义
+type TicketFieldDefinition struct {
+	Name     string      `json:"name"`
+	Label    string      `json:"label"`
+	Type     string      `json:"type"`
+	Required bool        `json:"required"`
+	Default  interface{} `json:"default,omitempty"`
+	Options  []string    `json:"options,omitempty"` // 单选与多选字段的可选值
+}
+
+// ParseTicketFieldDefinitions 解析并校验分类的自定义字段配置 {"fields": [...]}，为空时返回空列表
+func ParseTicketFieldDefinitions(raw models.JSONB) ([]TicketFieldDefinition, error) {
+	if len(raw) == 0 || raw["fields"] == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("自定义字段配置格式错误: %w", err)
+	}
+	var config struct {
+		Fields []TicketFieldDefinition `json:"fields"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("自定义字段配置格式错误: %w", err)
+	}
+
+	seen := make(map[string]bool, len(config.Fields))
+	for i := range config.Fields {
+		field := &config.Fields[i]
+		field.Name = strings.TrimSpace(field.Name)
+		field.Label = strings.TrimSpace(field.Label)
+		if !ticketFieldNamePattern.MatchString(field.Name) {
+			return nil, fmt.Errorf("自定义字段名 %q 无效，只能包含字母、数字和下划线且以字母开头", field.Name)
+		}
+		if seen[field.Name] {
+			return nil, fmt.Errorf("自定义字段 %s 重复", field.Name)
+		}
+		seen[field.Name] = true
+		if field.Label == "" {
+			field.Label = field.Name
+		}
+
+		switch field.Type {
+		case "multi-select", "multi_select":
+			field.Type = TicketFieldTypeMultiSelect
+		case TicketFieldTypeText, TicketFieldTypeNumber, TicketFieldTypeSelect, TicketFieldTypeMultiSelect,
+			TicketFieldTypeDate, TicketFieldTypeUser:
+		default:
+			return nil, fmt.Errorf("自定义字段 %s 的类型 %q 无效", field.Name, field.Type)
+		}
+
+		if field.Type == TicketFieldTypeSelect || field.Type == TicketFieldTypeMultiSelect {
+			options := make([]string, 0, len(field.Options))
+			optionSeen := make(map[string]bool, len(field.Options))
+			for _, option := range field.Options {
+				option = strings.TrimSpace(option)
+				if option == "" || optionSeen[option] {
+					continue
+				}
+				optionSeen[option] = true
+				options = append(options, option)
+			}
+			if len(options) == 0 {
+				return nil, fmt.Errorf("自定义字段 %s 缺少可选值", field.Name)
+			}
+			field.Options = options
+		} else {
+			field.Options = nil
+		}
+
+		if isEmptyTicketFieldValue(field.Default) {
+			field.Default = nil
+			continue
+		}
+		if field.Type == TicketFieldTypeUser {
+			return nil, fmt.Errorf("用户类型的自定义字段 %s 不支持默认值", field.Name)
+		}
+		value, err := field.normalize(field.Default)
+		if err != nil {
+			return nil, fmt.Errorf("自定义字段 %s 的默认值无效: %v", field.Name, err)
+		}
+		field.Default = value
+	}
+	return config.Fields, nil
+}
+
+// ticketFieldDefinitionsJSONB 将字段定义转换为分类的存储格式
+func ticketFieldDefinitionsJSONB(fields []TicketFieldDefinition) (models.JSONB, error) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(map[string]interface{}{"fields": fields})
+	if err != nil {
+		return nil, fmt.Errorf("自定义字段配置格式错误: %w", err)
+	}
+	var raw models.JSONB
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("自定义字段配置格式错误: %w", err)
+	}
+	return raw, nil
+}
+
+// normalize 校验并规范化字段值：数值与用户为 float64，日期为 YYYY-MM-DD，多选为去重后的字符串数组
+func (f *TicketFieldDefinition) normalize(value interface{}) (interface{}, error) {
+	switch f.Type {
+	case TicketFieldTypeText:
+		text, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("必须是文本")
+		}
+		text = strings.TrimSpace(text)
+		if utf8.RuneCountInString(text) > ticketFieldTextMaxLength {
+			return nil, fmt.Errorf("不能超过 %d 个字符", ticketFieldTextMaxLength)
+		}
+		return text, nil
+	case TicketFieldTypeNumber:
+		number, err := convertSchemaValue(value, "number")
+		if err != nil {
+			return nil, fmt.Errorf("必须是数字")
+		}
+		return number, nil
+	case TicketFieldTypeSelect:
+		option, ok := value.(string)
+		if !ok || !slices.Contains(f.Options, strings.TrimSpace(option)) {
+			return nil, fmt.Errorf("%v 不是可选值", value)
+		}
+		return strings.TrimSpace(option), nil
+	case TicketFieldTypeMultiSelect:
+		var items []interface{}
+		switch v := value.(type) {
+		case []interface{}:
+			items = v
+		case []string:
+			for _, item := range v {
+				items = append(items, item)
+			}
+		default:
+			return nil, fmt.Errorf("必须是选项数组")
+		}
+		selected := []interface{}{}
+		seen := make(map[string]bool, len(items))
+		for _, item := range items {
+			option, ok := item.(string)
+			option = strings.TrimSpace(option)
+			if !ok || !slices.Contains(f.Options, option) {
+				return nil, fmt.Errorf("%v 不是可选值", item)
+			}
+			if !seen[option] {
+				seen[option] = true
+				selected = append(selected, option)
+			}
+		}
+		return selected, nil
+	case TicketFieldTypeDate:
+		text, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("必须是日期")
+		}
+		text = strings.TrimSpace(text)
+		if date, err := time.Parse("2006-01-02", text); err == nil {
+			return date.Format("2006-01-02"), nil
+		}
+		if date, err := time.Parse(time.RFC3339, text); err == nil {
+			return date.Format("2006-01-02"), nil
+		}
+		return nil, fmt.Errorf("日期格式应为 YYYY-MM-DD")
+	case TicketFieldTypeUser:
+		id, err := convertSchemaValue(value, "integer")
+		if err != nil || id.(float64) <= 0 {
+			return nil, fmt.Errorf("必须是用户ID")
+		}
+		return id, nil
+	}
+	return nil, fmt.Errorf("不支持的字段类型 %s", f.Type)
+}
+
+// isEmptyTicketFieldValue 空值视为未填写
+func isEmptyTicketFieldValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []interface{}:
+		return len(v) == 0
+	case []string:
+		return len(v) == 0
+	}
+	return false
+}
+
+// ResolveTicketFields 获取工单类型与分类适用的自定义字段：先取与类型同名的分类，再取工单分类，同名字段以工单分类为准
+func ResolveTicketFields(db *gorm.DB, ticketType, category string) ([]TicketFieldDefinition, error) {
+	names := []string{}
+	if ticketType != "" {
+		names = append(names, ticketType)
+	}
+	if category != "" && category != ticketType {
+		names = append(names, category)
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	var categories []models.TicketCategory
+	if err := db.Where("is_active = ? AND name IN ?", true, names).Find(&categories).Error; err != nil {
+		return nil, fmt.Errorf("获取工单分类失败: %w", err)
+	}
+	byName := make(map[string]*models.TicketCategory, len(categories))
+	for i := range categories {
+		byName[categories[i].Name] = &categories[i]
+	}
+
+	var fields []TicketFieldDefinition
+	index := map[string]int{}
+	for _, name := range names {
+		category, ok := byName[name]
+		if !ok {
+			continue
+		}
+		defs, err := ParseTicketFieldDefinitions(category.CustomFields)
+		if err != nil {
+			return nil, fmt.Errorf("工单分类 %s 的自定义字段配置无效: %v", name, err)
+		}
+		for _, def := range defs {
+			if i, exists := index[def.Name]; exists {
+				fields[i] = def
+				continue
+			}
+			index[def.Name] = len(fields)
+			fields = append(fields, def)
+		}
+	}
+	return fields, nil
+}
+
+// PrepareTicketCustomFields 合并现有值与本次修改并按字段定义校验，未修改的字段沿用现有值，仍为空的字段使用默认值；
+// 修改中值为 null 表示清空，不再适用的字段值会被丢弃
+func PrepareTicketCustomFields(db *gorm.DB, ticketType, category string, current models.JSONB, changes map[string]interface{}) (models.JSONB, error) {
+	return prepareTicketCustomFields(db, ticketType, category, current, changes, false)
+}
+
+// PrepareTemplateCustomFields 校验工单模板的预填值：字段须已定义且值有效，必填项可以留空，不填充默认值
+func PrepareTemplateCustomFields(db *gorm.DB, ticketType, category string, values map[string]interface{}) (models.JSONB, error) {
+	return prepareTicketCustomFields(db, ticketType, category, nil, values, true)
+}
+
+func prepareTicketCustomFields(db *gorm.DB, ticketType, category string, current models.JSONB, changes map[string]interface{}, prefill bool) (models.JSONB, error) {
+	fields, err := ResolveTicketFields(db, ticketType, category)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []string
+	defined := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		defined[field.Name] = true
+	}
+	unknown := []string{}
+	for name := range changes {
+		if !defined[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		errs = append(errs, fmt.Sprintf("未定义的字段 %s", name))
+	}
+
+	result := models.JSONB{}
+	userFields := map[uint][]string{}
+	for i := range fields {
+		field := &fields[i]
+		value, changed := changes[field.Name]
+		if !changed {
+			var exists bool
+			if value, exists = current[field.Name]; !exists && !prefill {
+				value = field.Default
+			}
+		}
+		if isEmptyTicketFieldValue(value) {
+			if field.Required && !prefill {
+				errs = append(errs, fmt.Sprintf("字段 %s 为必填项", field.Label))
+			}
+			continue
+		}
+		normalized, err := field.normalize(value)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("字段 %s %v", field.Label, err))
+			continue
+		}
+		if field.Type == TicketFieldTypeUser {
+			id := uint(normalized.(float64))
+			userFields[id] = append(userFields[id], field.Label)
+		}
+		result[field.Name] = normalized
+	}
+
+	if len(userFields) > 0 {
+		ids := make([]uint, 0, len(userFields))
+		for id := range userFields {
+			ids = append(ids, id)
+		}
+		var existing []uint
+		if err := db.Model(&models.User{}).Where("id IN ? AND is_active = ?", ids, true).Pluck("id", &existing).Error; err != nil {
+			return nil, fmt.Errorf("查询用户失败: %w", err)
+		}
+		found := make(map[uint]bool, len(existing))
+		for _, id := range existing {
+			found[id] = true
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		for _, id := range ids {
+			if !found[id] {
+				errs = append(errs, fmt.Sprintf("字段 %s 的用户 %d 不存在或已停用", strings.Join(userFields[id], "、"), id))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("自定义字段无效: %s", strings.Join(errs, "; "))
+	}
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return result, nil
+}
+
+// SyncTicketFieldValues 按工单的自定义字段值重建筛选索引
+func SyncTicketFieldValues(tx *gorm.DB, ticketID uint, values models.JSONB) error {
+	if err := tx.Where("ticket_id = ?", ticketID).Delete(&models.TicketFieldValue{}).Error; err != nil {
+		return fmt.Errorf("更新自定义字段索引失败: %w", err)
+	}
+
+	var rows []models.TicketFieldValue
+	for name, value := range values {
+		items, ok := value.([]interface{})
+		if !ok {
+			items = []interface{}{value}
+		}
+		for _, item := range items {
+			row := models.TicketFieldValue{TicketID: ticketID, FieldName: name}
+			switch v := item.(type) {
+			case string:
+				row.TextValue = truncateString(v, 255)
+			case float64:
+				number := v
+				row.NumberValue = &number
+				row.TextValue = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				continue
+			}
+			rows = append(rows, row)
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].FieldName < rows[j].FieldName })
+	if err := tx.Create(&rows).Error; err != nil {
+		return fmt.Errorf("更新自定义字段索引失败: %w", err)
+	}
+	return nil
+}
+
+// FilterByCustomFields 按自定义字段筛选，条件之间为且关系。值支持：
+//   - a,b 匹配任一值（多选字段匹配包含任一选项的工单）
+//   - min..max 数值或日期范围，可省略一端
+func FilterByCustomFields(db *gorm.DB, idColumn string, filters map[string]string) (*gorm.DB, error) {
+	names := make([]string, 0, len(filters))
+	for name := range filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !ticketFieldNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("无效的自定义字段名: %s", name)
+		}
+		value := strings.TrimSpace(filters[name])
+		if value == "" {
+			continue
+		}
+
+		sub := db.Session(&gorm.Session{NewDB: true}).Model(&models.TicketFieldValue{}).
+			Select("ticket_id").Where("field_name = ?", name)
+		if lower, upper, isRange := strings.Cut(value, ".."); isRange {
+			lower, upper = strings.TrimSpace(lower), strings.TrimSpace(upper)
+			if lower == "" && upper == "" {
+				return nil, fmt.Errorf("自定义字段 %s 的范围条件无效", name)
+			}
+			column := "number_value"
+			bounds := []interface{}{}
+			for _, bound := range []string{lower, upper} {
+				if bound == "" {
+					bounds = append(bounds, nil)
+					continue
+				}
+				number, err := strconv.ParseFloat(bound, 64)
+				if err != nil {
+					column = "text_value"
+				}
+				bounds = append(bounds, number)
+			}
+			if column == "text_value" {
+				bounds = []interface{}{lower, upper}
+			}
+			if lower != "" {
+				sub = sub.Where(column+" >= ?", bounds[0])
+			}
+			if upper != "" {
+				sub = sub.Where(column+" <= ?", bounds[1])
+			}
+		} else {
+			var texts []string
+			var numbers []float64
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item == "" {
+					continue
+				}
+				texts = append(texts, item)
+				if number, err := strconv.ParseFloat(item, 64); err == nil {
+					numbers = append(numbers, number)
+				}
+			}
+			if len(numbers) > 0 {
+				sub = sub.Where("text_value IN ? OR number_value IN ?", texts, numbers)
+			} else {
+				sub = sub.Where("text_value IN ?", texts)
+			}
+		}
+		db = db.Where(idColumn+" IN (?)", sub)
+	}
+	return db, nil
+}
+
+// OrderByCustomField 按自定义字段排序，数值与用户字段按数值、其他字段按文本排序，ID 作为次级排序
+func OrderByCustomField(db *gorm.DB, idColumn, name string, desc bool) (*gorm.DB, error) {
+	if !ticketFieldNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("无效的自定义字段名: %s", name)
+	}
+	dir := "ASC"
+	if desc {
+		dir = "DESC"
+	}
+	value := func(column string) string {
+		return fmt.Sprintf("(SELECT MIN(ticket_field_values.%s) FROM ticket_field_values WHERE ticket_field_values.ticket_id = %s AND ticket_field_values.field_name = ?) %s",
+			column, idColumn, dir)
+	}
+	return db.Order(clause.OrderBy{Expression: clause.Expr{
+		SQL:  value("number_value") + ", " + value("text_value") + ", " + idColumn + " " + dir,
+		Vars: []interface{}{name, name},
+	}}), nil
+}
+
+// ParseTicketFieldImportValue 将导入文件中的单元格转换为字段值：多选以逗号、分号或竖线分隔，日期支持常见格式，用户可填ID或用户名
+func ParseTicketFieldImportValue(db *gorm.DB, field *TicketFieldDefinition, cell string) (interface{}, error) {
+	cell = strings.TrimSpace(cell)
+	if cell == "" {
+		return nil, nil
+	}
+
+	switch field.Type {
+	case TicketFieldTypeSelect:
+		for _, option := range field.Options {
+			if strings.EqualFold(option, cell) {
+				return option, nil
+			}
+		}
+		return cell, nil
+	case TicketFieldTypeMultiSelect:
+		items := []interface{}{}
+		for _, item := range strings.FieldsFunc(cell, func(r rune) bool { return strings.ContainsRune(",;|，；、", r) }) {
+			item = strings.TrimSpace(item)
+			for _, option := range field.Options {
+				if strings.EqualFold(option, item) {
+					item = option
+					break
+				}
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case TicketFieldTypeDate:
+		for _, layout := range append(importDateLayouts, importDateTimeLayouts...) {
+			if date, err := time.ParseInLocation(layout, cell, time.Local); err == nil {
+				return date.Format("2006-01-02"), nil
+			}
+		}
+		return nil, fmt.Errorf("字段 %s 的日期 %s 无法识别", field.Label, cell)
+	case TicketFieldTypeUser:
+		if id, err := strconv.ParseUint(cell, 10, 32); err == nil {
+			return float64(id), nil
+		}
+		var user models.User
+		if err := db.Select("id").Where("username = ?", cell).First(&user).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, fmt.Errorf("字段 %s 的用户 %s 不存在", field.Label, cell)
+			}
+			return nil, fmt.Errorf("查询用户失败: %w", err)
+		}
+		return float64(user.ID), nil
+	}
+	return cell, nil
+}
+
+// TicketFieldExport 工单导出时的自定义字段列
+type TicketFieldExport struct {
+	Fields    []TicketFieldDefinition
+	usernames map[uint]string
+}
+
+// NewTicketFieldExport 汇总导出工单适用的自定义字段，按首次出现的顺序排列，同名字段只导出一列
+func NewTicketFieldExport(db *gorm.DB, tickets []models.Ticket) (*TicketFieldExport, error) {
+	export := &TicketFieldExport{usernames: map[uint]string{}}
+	scopes := map[[2]string]bool{}
+	columns := map[string]bool{}
+	var userIDs []uint
+	for _, ticket := range tickets {
+		scope := [2]string{string(ticket.Type), ticket.Category}
+		if !scopes[scope] {
+			scopes[scope] = true
+			fields, err := ResolveTicketFields(db, scope[0], scope[1])
+			if err != nil {
+				return nil, err
+			}
+			for _, field := range fields {
+				if !columns[field.Name] {
+					columns[field.Name] = true
+					export.Fields = append(export.Fields, field)
+				}
+			}
+		}
+	}
+	for _, field := range export.Fields {
+		if field.Type != TicketFieldTypeUser {
+			continue
+		}
+		for _, ticket := range tickets {
+			if id, ok := ticket.CustomFields[field.Name].(float64); ok {
+				userIDs = append(userIDs, uint(id))
+			}
+		}
+	}
+	if len(userIDs) > 0 {
+		var users []models.User
+		if err := db.Select("id", "username").Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+			return nil, fmt.Errorf("查询用户失败: %w", err)
+		}
+		for _, user := range users {
+			export.usernames[user.ID] = user.Username
+		}
+	}
+	return export, nil
+}
+
+// Headers 自定义字段列标题
+func (e *TicketFieldExport) Headers() []string {
+	headers := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		headers[i] = field.Label
+	}
+	return headers
+}
+
+// Cells 工单在自定义字段列上的值，多选以分号连接，用户显示用户名
+func (e *TicketFieldExport) Cells(ticket *models.Ticket) []string {
+	cells := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		switch v := ticket.CustomFields[field.Name].(type) {
+		case string:
+			cells[i] = v
+		case float64:
+			if field.Type == TicketFieldTypeUser {
+				if name, ok := e.usernames[uint(v)]; ok {
+					cells[i] = name
+					continue
+				}
+			}
+			cells[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		case []interface{}:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			cells[i] = strings.Join(items, "; ")
+		}
+	}
+	return cells
+}
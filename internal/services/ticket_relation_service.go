@@ -0,0 +1,750 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// TicketRelationService 工单关系服务：父子工单、阻塞依赖、合并与拆分，所有操作记入工单历史
+type TicketRelationService struct {
+	db              *gorm.DB
+	auditService    *AuditService
+	workflowService *TicketWorkflowService
+	slaService      *TicketSLAService
+	watchService    *WatchService
+}
+
+// NewTicketRelationService 创建工单关系服务
+func NewTicketRelationService(db *gorm.DB, auditService *AuditService, workflowService *TicketWorkflowService, slaService *TicketSLAService, watchService *WatchService) *TicketRelationService {
+	return &TicketRelationService{
+		db:              db,
+		auditService:    auditService,
+		workflowService: workflowService,
+		slaService:      slaService,
+		watchService:    watchService,
+	}
+}
+
+// 子工单汇总状态
+const (
+	TicketRollupOpen       = "open"        // 子工单均未开始处理
+	TicketRollupInProgress = "in_progress" // 部分子工单处理中或已完成
+	TicketRollupDone       = "done"        // 子工单全部完成
+)
+
+// TicketRelationItem 关联工单摘要
+type TicketRelationItem struct {
+	ID         uint                  `json:"id"`
+	Title      string                `json:"title"`
+	Status     models.TicketStatus   `json:"status"`
+	Priority   models.TicketPriority `json:"priority"`
+	AssigneeID *uint                 `json:"assignee_id"`
+}
+
+// TicketRollup 子工单汇总，按直接子工单统计
+type TicketRollup struct {
+	Total   int    `json:"total"`
+	Open    int    `json:"open"`
+	Done    int    `json:"done"`
+	Percent int    `json:"percent"`
+	Status  string `json:"status"`
+}
+
+// TicketRelations 工单的全部关系，当前用户无权查看的关联工单不返回
+type TicketRelations struct {
+	TicketID   uint                 `json:"ticket_id"`
+	Parent     *TicketRelationItem  `json:"parent"`
+	Children   []TicketRelationItem `json:"children"`
+	Rollup     *TicketRollup        `json:"rollup"`
+	Blocks     []TicketRelationItem `json:"blocks"`     // 本工单阻塞的工单
+	BlockedBy  []TicketRelationItem `json:"blocked_by"` // 阻塞本工单的工单
+	MergedInto *TicketRelationItem  `json:"merged_into"`
+	MergedFrom []TicketRelationItem `json:"merged_from"`
+}
+
+// SetParentRequest 设置父工单请求，parent_id 为空表示取消父工单
+type SetParentRequest struct {
+	ParentID *uint `json:"parent_id"`
+}
+
+// AddBlockerRequest 添加阻塞依赖请求
+type AddBlockerRequest struct {
+	BlockerID uint `json:"blocker_id" binding:"required"`
+}
+
+// MergeTicketRequest 合并工单请求
+type MergeTicketRequest struct {
+	TargetID uint   `json:"target_id" binding:"required"`
+	Comment  string `json:"comment" binding:"max=500"`
+}
+
+// SplitTicketRequest 拆分工单请求，选中的评论移入新工单
+type SplitTicketRequest struct {
+	Title       string `json:"title" binding:"required,max=500"`
+	Description string `json:"description"`
+	Type        string `json:"type" binding:"omitempty,oneof=bug feature support change custom"`
+	Priority    string `json:"priority" binding:"omitempty,oneof=low normal high critical"`
+	CommentIDs  []uint `json:"comment_ids" binding:"required,min=1"`
+	AsChild     bool   `json:"as_child"` // 新工单作为原工单的子工单
+}
+
+// GetRelations 获取工单的父子、依赖与合并关系及子工单汇总
+func (s *TicketRelationService) GetRelations(ticketID uint, actor *TicketActor) (*TicketRelations, error) {
+	ticket, err := s.findTicket(ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if !canViewTicket(s.db, ticket, actor) {
+		return nil, fmt.Errorf("工单不存在或无权查看")
+	}
+
+	relations := &TicketRelations{
+		TicketID:   ticket.ID,
+		Children:   []TicketRelationItem{},
+		Blocks:     []TicketRelationItem{},
+		BlockedBy:  []TicketRelationItem{},
+		MergedFrom: []TicketRelationItem{},
+	}
+	if ticket.ParentID != nil {
+		relations.Parent = s.relationItem(*ticket.ParentID, actor)
+	}
+	if ticket.MergedIntoID != nil {
+		relations.MergedInto = s.relationItem(*ticket.MergedIntoID, actor)
+	}
+
+	var children []models.Ticket
+	if err := s.db.Where("parent_id = ?", ticket.ID).Order("id ASC").Find(&children).Error; err != nil {
+		return nil, fmt.Errorf("获取子工单失败: %w", err)
+	}
+	relations.Children = s.visibleItems(children, actor)
+	relations.Rollup = ticketRollup(children)
+
+	var merged []models.Ticket
+	if err := s.db.Where("merged_into_id = ?", ticket.ID).Order("id ASC").Find(&merged).Error; err != nil {
+		return nil, fmt.Errorf("获取合并工单失败: %w", err)
+	}
+	relations.MergedFrom = s.visibleItems(merged, actor)
+
+	var blocks, blockedBy []models.Ticket
+	if err := s.db.Where("id IN (?)", s.db.Model(&models.EntityLink{}).Select("target_id").
+		Where("link_type = ? AND source_type = ? AND source_id = ? AND target_type = ?",
+			models.LinkTypeBlocks, models.LinkEntityTicket, ticket.ID, models.LinkEntityTicket)).
+		Order("id ASC").Find(&blocks).Error; err != nil {
+		return nil, fmt.Errorf("获取工单依赖失败: %w", err)
+	}
+	if err := s.db.Where("id IN (?)", blockerIDsQuery(s.db, ticket.ID)).Order("id ASC").Find(&blockedBy).Error; err != nil {
+		return nil, fmt.Errorf("获取工单依赖失败: %w", err)
+	}
+	relations.Blocks = s.visibleItems(blocks, actor)
+	relations.BlockedBy = s.visibleItems(blockedBy, actor)
+	return relations, nil
+}
+
+// SetParent 设置或取消父工单，不允许形成环
+func (s *TicketRelationService) SetParent(ticketID uint, req *SetParentRequest, actor *TicketActor, ipAddress, userAgent string) (*models.Ticket, error) {
+	ticket, err := s.findEditableTicket(ticketID, actor)
+	if err != nil {
+		return nil, err
+	}
+	if sameUintPtr(ticket.ParentID, req.ParentID) {
+		return ticket, nil
+	}
+
+	if req.ParentID != nil {
+		if *req.ParentID == ticket.ID {
+			return nil, fmt.Errorf("不能将工单设为自身的子工单")
+		}
+		parent, err := s.findTicket(*req.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if !canViewTicket(s.db, parent, actor) {
+			return nil, fmt.Errorf("无权查看工单 #%d", parent.ID)
+		}
+		if parent.MergedIntoID != nil {
+			return nil, fmt.Errorf("工单 #%d 已合并，不能添加子工单", parent.ID)
+		}
+		// 父工单的祖先链中不能包含当前工单
+		visited := map[uint]bool{}
+		for id := parent.ParentID; id != nil && !visited[*id]; {
+			if *id == ticket.ID {
+				return nil, fmt.Errorf("不能将工单设为其子工单的子工单")
+			}
+			visited[*id] = true
+			var ancestor models.Ticket
+			if err := s.db.Select("id", "parent_id").First(&ancestor, *id).Error; err != nil {
+				break
+			}
+			id = ancestor.ParentID
+		}
+	}
+
+	oldParentID := ticket.ParentID
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Ticket{}).Where("id = ?", ticket.ID).Update("parent_id", req.ParentID).Error; err != nil {
+			return fmt.Errorf("更新父工单失败: %w", err)
+		}
+		var histories []models.TicketHistory
+		if oldParentID != nil {
+			histories = append(histories,
+				models.TicketHistory{TicketID: ticket.ID, UserID: actor.UserID, Action: "parent_removed", Description: fmt.Sprintf("移除父工单 #%d", *oldParentID)},
+				models.TicketHistory{TicketID: *oldParentID, UserID: actor.UserID, Action: "child_removed", Description: fmt.Sprintf("移除子工单 #%d", ticket.ID)})
+		}
+		if req.ParentID != nil {
+			histories = append(histories,
+				models.TicketHistory{TicketID: ticket.ID, UserID: actor.UserID, Action: "parent_set", Description: fmt.Sprintf("设置父工单 #%d", *req.ParentID)},
+				models.TicketHistory{TicketID: *req.ParentID, UserID: actor.UserID, Action: "child_added", Description: fmt.Sprintf("添加子工单 #%d", ticket.ID)})
+		}
+		if err := tx.Create(&histories).Error; err != nil {
+			return fmt.Errorf("记录工单历史失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit("UPDATE", ticket.ID, map[string]interface{}{"parent_id": oldParentID}, map[string]interface{}{"parent_id": req.ParentID}, actor, ipAddress, userAgent)
+	return s.findTicket(ticket.ID)
+}
+
+// AddBlocker 添加阻塞依赖：blocker 完成前，工单不能解决或关闭
+func (s *TicketRelationService) AddBlocker(ticketID uint, req *AddBlockerRequest, actor *TicketActor, ipAddress, userAgent string) error {
+	ticket, err := s.findEditableTicket(ticketID, actor)
+	if err != nil {
+		return err
+	}
+	if req.BlockerID == ticket.ID {
+		return fmt.Errorf("工单不能阻塞自身")
+	}
+	blocker, err := s.findTicket(req.BlockerID)
+	if err != nil {
+		return err
+	}
+	if !canViewTicket(s.db, blocker, actor) {
+		return fmt.Errorf("无权查看工单 #%d", blocker.ID)
+	}
+
+	var count int64
+	s.db.Model(&models.EntityLink{}).
+		Where("link_type = ? AND source_type = ? AND source_id = ? AND target_type = ? AND target_id = ?",
+			models.LinkTypeBlocks, models.LinkEntityTicket, blocker.ID, models.LinkEntityTicket, ticket.ID).
+		Count(&count)
+	if count > 0 {
+		return fmt.Errorf("依赖已存在")
+	}
+	// 当前工单直接或间接阻塞 blocker 时会形成循环依赖
+	blocked, err := s.blockedTickets(ticket.ID)
+	if err != nil {
+		return err
+	}
+	if blocked[blocker.ID] {
+		return fmt.Errorf("工单 #%d 已直接或间接被当前工单阻塞，不能形成循环依赖", blocker.ID)
+	}
+
+	link := models.EntityLink{
+		SourceType: models.LinkEntityTicket,
+		SourceID:   blocker.ID,
+		TargetType: models.LinkEntityTicket,
+		TargetID:   ticket.ID,
+		LinkType:   models.LinkTypeBlocks,
+		CreatedBy:  actor.UserID,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&link).Error; err != nil {
+			return fmt.Errorf("创建依赖失败: %w", err)
+		}
+		histories := []models.TicketHistory{
+			{TicketID: ticket.ID, UserID: actor.UserID, Action: "blocker_added", Description: fmt.Sprintf("被工单 #%d 阻塞", blocker.ID)},
+			{TicketID: blocker.ID, UserID: actor.UserID, Action: "blocking_added", Description: fmt.Sprintf("阻塞工单 #%d", ticket.ID)},
+		}
+		if err := tx.Create(&histories).Error; err != nil {
+			return fmt.Errorf("记录工单历史失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit("UPDATE", ticket.ID, nil, map[string]interface{}{"blocked_by": blocker.ID}, actor, ipAddress, userAgent)
+	return nil
+}
+
+// RemoveBlocker 删除阻塞依赖
+func (s *TicketRelationService) RemoveBlocker(ticketID, blockerID uint, actor *TicketActor, ipAddress, userAgent string) error {
+	ticket, err := s.findEditableTicket(ticketID, actor)
+	if err != nil {
+		return err
+	}
+
+	var link models.EntityLink
+	if err := s.db.Where("link_type = ? AND source_type = ? AND source_id = ? AND target_type = ? AND target_id = ?",
+		models.LinkTypeBlocks, models.LinkEntityTicket, blockerID, models.LinkEntityTicket, ticket.ID).
+		First(&link).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("依赖不存在")
+		}
+		return fmt.Errorf("获取工单依赖失败: %w", err)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&link).Error; err != nil {
+			return fmt.Errorf("删除依赖失败: %w", err)
+		}
+		histories := []models.TicketHistory{
+			{TicketID: ticket.ID, UserID: actor.UserID, Action: "blocker_removed", Description: fmt.Sprintf("解除工单 #%d 的阻塞", blockerID)},
+			{TicketID: blockerID, UserID: actor.UserID, Action: "blocking_removed", Description: fmt.Sprintf("不再阻塞工单 #%d", ticket.ID)},
+		}
+		if err := tx.Create(&histories).Error; err != nil {
+			return fmt.Errorf("记录工单历史失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit("UPDATE", ticket.ID, map[string]interface{}{"blocked_by": blockerID}, nil, actor, ipAddress, userAgent)
+	return nil
+}
+
+// Merge 将重复工单合并到目标工单：评论、附件、关注者、子工单与关联并入目标工单，
+// 原工单关闭并指向目标工单
+func (s *TicketRelationService) Merge(sourceID uint, req *MergeTicketRequest, actor *TicketActor, ipAddress, userAgent string) (*models.Ticket, error) {
+	if sourceID == req.TargetID {
+		return nil, fmt.Errorf("不能将工单合并到自身")
+	}
+	source, err := s.findEditableTicket(sourceID, actor)
+	if err != nil {
+		return nil, err
+	}
+	if source.MergedIntoID != nil {
+		return nil, fmt.Errorf("工单已合并到工单 #%d", *source.MergedIntoID)
+	}
+	target, err := s.findEditableTicket(req.TargetID, actor)
+	if err != nil {
+		return nil, err
+	}
+	if target.MergedIntoID != nil {
+		return nil, fmt.Errorf("目标工单已合并到工单 #%d", *target.MergedIntoID)
+	}
+	cycle, err := s.mergeCreatesBlockingCycle(source.ID, target.ID)
+	if err != nil {
+		return nil, err
+	}
+	if cycle {
+		return nil, fmt.Errorf("合并后工单 #%d 会直接或间接阻塞自身，不能形成循环依赖，请先解除相关阻塞", target.ID)
+	}
+
+	fromStatus := source.Status
+	var commentCount, attachmentCount int64
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Model(&models.TicketComment{}).Where("ticket_id = ?", source.ID).Update("ticket_id", target.ID)
+		if result.Error != nil {
+			return fmt.Errorf("合并评论失败: %w", result.Error)
+		}
+		commentCount = result.RowsAffected
+		result = tx.Model(&models.TicketAttachment{}).Where("ticket_id = ?", source.ID).Update("ticket_id", target.ID)
+		if result.Error != nil {
+			return fmt.Errorf("合并附件失败: %w", result.Error)
+		}
+		attachmentCount = result.RowsAffected
+
+		// 关注者并入目标工单
+		var watchers []models.Watcher
+		if err := tx.Where("entity_type = ? AND entity_id = ?", models.WatchEntityTicket, source.ID).Find(&watchers).Error; err != nil {
+			return fmt.Errorf("获取关注者失败: %w", err)
+		}
+		for _, watcher := range watchers {
+			if err := AddWatchers(tx, models.WatchEntityTicket, target.ID, watcher.Reason, watcher.UserID); err != nil {
+				return err
+			}
+		}
+		if err := deleteEntityWatchers(tx, models.WatchEntityTicket, []uint{source.ID}); err != nil {
+			return err
+		}
+
+		// 子工单改挂到目标工单；目标工单原为被合并工单的子工单时继承其父工单
+		if err := tx.Model(&models.Ticket{}).Where("parent_id = ? AND id <> ?", source.ID, target.ID).
+			Update("parent_id", target.ID).Error; err != nil {
+			return fmt.Errorf("合并子工单失败: %w", err)
+		}
+		if target.ParentID != nil && *target.ParentID == source.ID {
+			if err := tx.Model(&models.Ticket{}).Where("id = ?", target.ID).Update("parent_id", source.ParentID).Error; err != nil {
+				return fmt.Errorf("合并子工单失败: %w", err)
+			}
+		}
+
+		if err := mergeEntityLinks(tx, models.LinkEntityTicket, target.ID, []uint{source.ID}); err != nil {
+			return err
+		}
+		if err := tx.Create(&models.EntityLink{
+			SourceType:  models.LinkEntityTicket,
+			SourceID:    source.ID,
+			TargetType:  models.LinkEntityTicket,
+			TargetID:    target.ID,
+			LinkType:    models.LinkTypeDuplicateOf,
+			Description: "工单合并",
+			CreatedBy:   actor.UserID,
+		}).Error; err != nil {
+			return fmt.Errorf("创建合并关联失败: %w", err)
+		}
+
+		result = tx.Model(&models.Ticket{}).Where("id = ? AND status = ? AND merged_into_id IS NULL", source.ID, fromStatus).
+			Updates(map[string]interface{}{"status": models.TicketStatusClosed, "closed_at": time.Now(), "merged_into_id": target.ID})
+		if result.Error != nil {
+			return fmt.Errorf("关闭被合并工单失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("工单状态已被修改，请刷新后重试")
+		}
+
+		sourceNote := fmt.Sprintf("合并到工单 #%d", target.ID)
+		targetNote := fmt.Sprintf("合并了工单 #%d（%d 条评论，%d 个附件）", source.ID, commentCount, attachmentCount)
+		if req.Comment != "" {
+			sourceNote += "：" + req.Comment
+			targetNote += "：" + req.Comment
+		}
+		histories := []models.TicketHistory{
+			{TicketID: source.ID, UserID: actor.UserID, Action: "merged", Description: sourceNote},
+			{TicketID: target.ID, UserID: actor.UserID, Action: "merged", Description: targetNote},
+		}
+		if err := tx.Create(&histories).Error; err != nil {
+			return fmt.Errorf("记录工单历史失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	closed, err := s.findTicket(source.ID)
+	if err == nil {
+		s.slaService.OnStatusChange(closed, fromStatus, actor)
+		notifyParentRollup(s.db, s.watchService, closed, actor.UserID)
+	}
+	s.audit("MERGE", target.ID, nil, map[string]interface{}{"merged_id": source.ID, "comments": commentCount, "attachments": attachmentCount}, actor, ipAddress, userAgent)
+	s.watchService.NotifyWatchers(models.WatchEntityTicket, target.ID, actor.UserID, "工单通知",
+		fmt.Sprintf("工单 #%d「%s」已合并到工单：%s", source.ID, source.Title, target.Title))
+
+	return s.findTicket(target.ID)
+}
+
+// Split 将选中的评论拆分为新工单，新工单与原工单关联，可作为原工单的子工单
+func (s *TicketRelationService) Split(sourceID uint, req *SplitTicketRequest, actor *TicketActor, ipAddress, userAgent string) (*models.Ticket, error) {
+	source, err := s.findEditableTicket(sourceID, actor)
+	if err != nil {
+		return nil, err
+	}
+	if source.MergedIntoID != nil {
+		return nil, fmt.Errorf("工单已合并到工单 #%d，不能拆分", *source.MergedIntoID)
+	}
+	commentIDs := uniqueUints(req.CommentIDs)
+	if len(commentIDs) == 0 {
+		return nil, fmt.Errorf("请选择要拆分的评论")
+	}
+	var comments []models.TicketComment
+	if err := s.db.Where("id IN ? AND ticket_id = ?", commentIDs, source.ID).Order("id ASC").Find(&comments).Error; err != nil {
+		return nil, fmt.Errorf("获取评论失败: %w", err)
+	}
+	if len(comments) != len(commentIDs) {
+		return nil, fmt.Errorf("选中的评论不属于该工单")
+	}
+
+	ticketType := models.TicketType(req.Type)
+	if ticketType == "" {
+		ticketType = source.Type
+	}
+	priority := models.TicketPriority(req.Priority)
+	if priority == "" {
+		priority = source.Priority
+	}
+	description := strings.TrimSpace(req.Description)
+	if description == "" {
+		parts := make([]string, 0, len(comments))
+		for _, comment := range comments {
+			parts = append(parts, comment.Content)
+		}
+		description = strings.Join(parts, "\n\n")
+	}
+	ticket := models.Ticket{
+		Title:       strings.TrimSpace(req.Title),
+		Description: description,
+		Type:        ticketType,
+		Priority:    priority,
+		Category:    source.Category,
+		Status:      s.workflowService.InitialStatus(string(ticketType), source.Category),
+		CreatorID:   actor.UserID,
+	}
+	if req.AsChild {
+		ticket.ParentID = &source.ID
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&ticket).Error; err != nil {
+			return fmt.Errorf("创建工单失败: %w", err)
+		}
+		if err := tx.Model(&models.TicketComment{}).Where("id IN ?", commentIDs).Update("ticket_id", ticket.ID).Error; err != nil {
+			return fmt.Errorf("移动评论失败: %w", err)
+		}
+		if err := AddWatchers(tx, models.WatchEntityTicket, ticket.ID, models.WatchReasonCreator, actor.UserID); err != nil {
+			return err
+		}
+		for _, comment := range comments {
+			if err := AddWatchers(tx, models.WatchEntityTicket, ticket.ID, models.WatchReasonCommenter, comment.UserID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&models.EntityLink{
+			SourceType:  models.LinkEntityTicket,
+			SourceID:    ticket.ID,
+			TargetType:  models.LinkEntityTicket,
+			TargetID:    source.ID,
+			LinkType:    models.LinkTypeRelatesTo,
+			Description: fmt.Sprintf("拆分自工单 #%d", source.ID),
+			CreatedBy:   actor.UserID,
+		}).Error; err != nil {
+			return fmt.Errorf("创建拆分关联失败: %w", err)
+		}
+		histories := []models.TicketHistory{
+			{TicketID: ticket.ID, UserID: actor.UserID, Action: "created", Description: fmt.Sprintf("从工单 #%d 拆分创建", source.ID)},
+			{TicketID: source.ID, UserID: actor.UserID, Action: "split", Description: fmt.Sprintf("拆分出工单 #%d（%d 条评论）", ticket.ID, len(comments))},
+		}
+		if err := tx.Create(&histories).Error; err != nil {
+			return fmt.Errorf("记录工单历史失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.slaService.ApplyToTicket(&ticket); err != nil {
+		fmt.Printf("Warning: failed to apply SLA to ticket %d: %v\n", ticket.ID, err)
+	}
+	s.audit("SPLIT", source.ID, nil, map[string]interface{}{"new_ticket_id": ticket.ID, "comment_ids": commentIDs}, actor, ipAddress, userAgent)
+	s.watchService.NotifyWatchers(models.WatchEntityTicket, source.ID, actor.UserID, "工单通知",
+		fmt.Sprintf("工单「%s」拆分出新工单 #%d：%s", source.Title, ticket.ID, ticket.Title))
+
+	return s.findTicket(ticket.ID)
+}
+
+// blockedTickets 返回被给定工单直接或间接阻塞的全部工单
+func (s *TicketRelationService) blockedTickets(ticketIDs ...uint) (map[uint]bool, error) {
+	blocked := map[uint]bool{}
+	queue := append([]uint{}, ticketIDs...)
+	for len(queue) > 0 {
+		var targets []uint
+		if err := s.db.Model(&models.EntityLink{}).
+			Where("link_type = ? AND source_type = ? AND source_id IN ? AND target_type = ?",
+				models.LinkTypeBlocks, models.LinkEntityTicket, queue, models.LinkEntityTicket).
+			Pluck("target_id", &targets).Error; err != nil {
+			return nil, fmt.Errorf("获取工单依赖失败: %w", err)
+		}
+		queue = queue[:0]
+		for _, id := range targets {
+			if !blocked[id] {
+				blocked[id] = true
+				queue = append(queue, id)
+			}
+		}
+	}
+	return blocked, nil
+}
+
+// mergeCreatesBlockingCycle 合并后两个工单视为同一工单：二者阻塞的其他工单又直接或间接阻塞二者之一时会形成循环依赖，
+// 二者之间的直接阻塞在合并时作为自关联丢弃，不构成循环
+func (s *TicketRelationService) mergeCreatesBlockingCycle(sourceID, targetID uint) (bool, error) {
+	merged := []uint{sourceID, targetID}
+	var next []uint
+	if err := s.db.Model(&models.EntityLink{}).
+		Where("link_type = ? AND source_type = ? AND source_id IN ? AND target_type = ? AND target_id NOT IN ?",
+			models.LinkTypeBlocks, models.LinkEntityTicket, merged, models.LinkEntityTicket, merged).
+		Pluck("target_id", &next).Error; err != nil {
+		return false, fmt.Errorf("获取工单依赖失败: %w", err)
+	}
+	if len(next) == 0 {
+		return false, nil
+	}
+	blocked, err := s.blockedTickets(next...)
+	if err != nil {
+		return false, err
+	}
+	return blocked[sourceID] || blocked[targetID], nil
+}
+
+// findTicket 查找工单
+func (s *TicketRelationService) findTicket(ticketID uint) (*models.Ticket, error) {
+	var ticket models.Ticket
+	if err := s.db.First(&ticket, ticketID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("工单不存在 (#%d)", ticketID)
+		}
+		return nil, fmt.Errorf("获取工单失败: %w", err)
+	}
+	return &ticket, nil
+}
+
+// findEditableTicket 查找当前用户可修改关系的工单：创建者、处理人或具备编辑权限的用户
+func (s *TicketRelationService) findEditableTicket(ticketID uint, actor *TicketActor) (*models.Ticket, error) {
+	ticket, err := s.findTicket(ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if !canViewTicket(s.db, ticket, actor) {
+		return nil, fmt.Errorf("工单不存在或无权查看")
+	}
+	if !actor.System && ticket.CreatorID != actor.UserID && (ticket.AssigneeID == nil || *ticket.AssigneeID != actor.UserID) &&
+		!actorHasPermission(s.db, actor, "ticket:update") && !actorHasPermission(s.db, actor, "ticket:admin") {
+		return nil, fmt.Errorf("无权修改工单 #%d", ticket.ID)
+	}
+	return ticket, nil
+}
+
+// relationItem 获取单个关联工单摘要，不可见时返回空
+func (s *TicketRelationService) relationItem(ticketID uint, actor *TicketActor) *TicketRelationItem {
+	var ticket models.Ticket
+	if err := s.db.First(&ticket, ticketID).Error; err != nil || !canViewTicket(s.db, &ticket, actor) {
+		return nil
+	}
+	item := toTicketRelationItem(&ticket)
+	return &item
+}
+
+// visibleItems 过滤当前用户无权查看的工单
+func (s *TicketRelationService) visibleItems(tickets []models.Ticket, actor *TicketActor) []TicketRelationItem {
+	items := make([]TicketRelationItem, 0, len(tickets))
+	for i := range tickets {
+		if canViewTicket(s.db, &tickets[i], actor) {
+			items = append(items, toTicketRelationItem(&tickets[i]))
+		}
+	}
+	return items
+}
+
+// audit 记录工单关系变更审计日志
+func (s *TicketRelationService) audit(action string, ticketID uint, oldValues, newValues map[string]interface{}, actor *TicketActor, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	s.auditService.CreateAuditLog(&AuditLogRequest{
+		UserID:       actor.UserID,
+		Action:       action,
+		ResourceType: "ticket",
+		ResourceID:   ticketID,
+		OldValues:    oldValues,
+		NewValues:    newValues,
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+	})
+}
+
+// toTicketRelationItem 转换为关联工单摘要
+func toTicketRelationItem(ticket *models.Ticket) TicketRelationItem {
+	return TicketRelationItem{
+		ID:         ticket.ID,
+		Title:      ticket.Title,
+		Status:     ticket.Status,
+		Priority:   ticket.Priority,
+		AssigneeID: ticket.AssigneeID,
+	}
+}
+
+// ticketRollup 汇总子工单状态，没有子工单时返回空
+func ticketRollup(children []models.Ticket) *TicketRollup {
+	if len(children) == 0 {
+		return nil
+	}
+	rollup := &TicketRollup{Total: len(children), Status: TicketRollupOpen}
+	started := false
+	for _, child := range children {
+		switch {
+		case isTicketDone(child.Status):
+			rollup.Done++
+		case child.Status != models.TicketStatusSubmitted && child.Status != models.TicketStatusAssigned:
+			started = true
+		}
+	}
+	rollup.Open = rollup.Total - rollup.Done
+	rollup.Percent = rollup.Done * 100 / rollup.Total
+	switch {
+	case rollup.Done == rollup.Total:
+		rollup.Status = TicketRollupDone
+	case rollup.Done > 0 || started:
+		rollup.Status = TicketRollupInProgress
+	}
+	return rollup
+}
+
+// doneTicketStatuses 已解决、已关闭或已拒绝的工单视为完成
+var doneTicketStatuses = []models.TicketStatus{models.TicketStatusResolved, models.TicketStatusClosed, models.TicketStatusRejected}
+
+// isTicketDone 检查工单是否已完成
+func isTicketDone(status models.TicketStatus) bool {
+	for _, done := range doneTicketStatuses {
+		if status == done {
+			return true
+		}
+	}
+	return false
+}
+
+// blockerIDsQuery 阻塞指定工单的工单ID子查询
+func blockerIDsQuery(db *gorm.DB, ticketID uint) *gorm.DB {
+	return db.Model(&models.EntityLink{}).Select("source_id").
+		Where("link_type = ? AND target_type = ? AND target_id = ? AND source_type = ?",
+			models.LinkTypeBlocks, models.LinkEntityTicket, ticketID, models.LinkEntityTicket)
+}
+
+// checkTicketBlockers 工单被未完成的工单阻塞时不能解决或关闭
+func checkTicketBlockers(db *gorm.DB, ticketID uint) error {
+	var blockers []models.Ticket
+	if err := db.Select("id").Where("id IN (?) AND status NOT IN ?", blockerIDsQuery(db, ticketID), doneTicketStatuses).
+		Order("id ASC").Find(&blockers).Error; err != nil {
+		return fmt.Errorf("获取工单依赖失败: %w", err)
+	}
+	if len(blockers) == 0 {
+		return nil
+	}
+	refs := make([]string, 0, len(blockers))
+	for _, blocker := range blockers {
+		refs = append(refs, fmt.Sprintf("#%d", blocker.ID))
+	}
+	return fmt.Errorf("工单仍被未完成的工单 %s 阻塞，不能解决或关闭", strings.Join(refs, ", "))
+}
+
+// notifyParentRollup 子工单完成后，若父工单的子工单已全部完成则记入父工单历史并通知其关注者
+func notifyParentRollup(db *gorm.DB, watchService *WatchService, child *models.Ticket, actorID uint) {
+	if child.ParentID == nil || !isTicketDone(child.Status) {
+		return
+	}
+	var open int64
+	db.Model(&models.Ticket{}).
+		Where("parent_id = ? AND status NOT IN ?", *child.ParentID, doneTicketStatuses).
+		Count(&open)
+	if open > 0 {
+		return
+	}
+	var parent models.Ticket
+	if err := db.First(&parent, *child.ParentID).Error; err != nil {
+		return
+	}
+	db.Create(&models.TicketHistory{TicketID: parent.ID, UserID: actorID, Action: "children_completed", Description: "所有子工单已完成"})
+	if watchService != nil {
+		watchService.NotifyWatchers(models.WatchEntityTicket, parent.ID, actorID, "工单通知", "所有子工单已完成："+parent.Title)
+	}
+}
+
+// sameUintPtr 比较两个可空ID是否相同
+func sameUintPtr(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
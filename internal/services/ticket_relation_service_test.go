@@ -0,0 +1,207 @@
+package services
+
+import (
+	"testing"
+
+	"info-management-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTicketRelationTest(t *testing.T) *TicketRelationService {
+	workflow := setupTicketWorkflowTest(t)
+	return NewTicketRelationService(workflow.db, workflow.auditService, workflow, workflow.slaService, workflow.watchService)
+}
+
+// createRelationTicket 创建 alice 提交、bob 处理中的工单
+func createRelationTicket(t *testing.T, s *TicketRelationService, title string) *models.Ticket {
+	bob := uint(3)
+	ticket := models.Ticket{Title: title, Type: "bug", Status: models.TicketStatusInProgress, CreatorID: 2, AssigneeID: &bob}
+	require.NoError(t, s.db.Create(&ticket).Error)
+	require.NoError(t, AddWatchers(s.db, models.WatchEntityTicket, ticket.ID, models.WatchReasonCreator, 2))
+	return &ticket
+}
+
+func historyActions(t *testing.T, s *TicketRelationService, ticketID uint) []string {
+	var actions []string
+	require.NoError(t, s.db.Model(&models.TicketHistory{}).Where("ticket_id = ?", ticketID).Order("id").Pluck("action", &actions).Error)
+	return actions
+}
+
+func TestTicketRelationService_ParentAndBlockers(t *testing.T) {
+	s := setupTicketRelationTest(t)
+	alice, bob, carol := actorWith(2), actorWith(3), actorWith(4)
+	parent := createRelationTicket(t, s, "机房断电")
+	child1 := createRelationTicket(t, s, "恢复交换机")
+	child2 := createRelationTicket(t, s, "恢复存储")
+
+	_, err := s.SetParent(child1.ID, &SetParentRequest{ParentID: &parent.ID}, alice, "", "")
+	require.NoError(t, err)
+	_, err = s.SetParent(child2.ID, &SetParentRequest{ParentID: &parent.ID}, bob, "", "")
+	require.NoError(t, err)
+	_, err = s.SetParent(child1.ID, &SetParentRequest{ParentID: &parent.ID}, carol, "", "")
+	assert.EqualError(t, err, "工单不存在或无权查看")
+	_, err = s.SetParent(parent.ID, &SetParentRequest{ParentID: &child1.ID}, alice, "", "")
+	assert.EqualError(t, err, "不能将工单设为其子工单的子工单")
+	_, err = s.SetParent(parent.ID, &SetParentRequest{ParentID: &parent.ID}, alice, "", "")
+	assert.Error(t, err)
+
+	relations, err := s.GetRelations(parent.ID, alice)
+	require.NoError(t, err)
+	require.Len(t, relations.Children, 2)
+	assert.Equal(t, TicketRollup{Total: 2, Open: 2, Done: 0, Percent: 0, Status: TicketRollupInProgress}, *relations.Rollup)
+	assert.Equal(t, []string{"child_added", "child_added"}, historyActions(t, s, parent.ID))
+
+	// child2 阻塞 child1：child2 完成前 child1 不能解决
+	require.NoError(t, s.AddBlocker(child1.ID, &AddBlockerRequest{BlockerID: child2.ID}, alice, "", ""))
+	assert.EqualError(t, s.AddBlocker(child1.ID, &AddBlockerRequest{BlockerID: child2.ID}, alice, "", ""), "依赖已存在")
+	err = s.AddBlocker(child2.ID, &AddBlockerRequest{BlockerID: child1.ID}, alice, "", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "循环依赖")
+
+	relations, err = s.GetRelations(child1.ID, bob)
+	require.NoError(t, err)
+	require.Len(t, relations.BlockedBy, 1)
+	assert.Equal(t, child2.ID, relations.BlockedBy[0].ID)
+	assert.Equal(t, parent.ID, relations.Parent.ID)
+
+	_, err = s.workflowService.Transition(child1.ID, &TicketTransitionRequest{Action: "resolve"}, bob, "", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "仍被未完成的工单")
+
+	_, err = s.workflowService.Transition(child2.ID, &TicketTransitionRequest{Action: "resolve"}, bob, "", "")
+	require.NoError(t, err)
+	_, err = s.workflowService.Transition(child1.ID, &TicketTransitionRequest{Action: "resolve"}, bob, "", "")
+	require.NoError(t, err)
+
+	// 最后一个子工单完成后父工单记录汇总历史
+	relations, err = s.GetRelations(parent.ID, alice)
+	require.NoError(t, err)
+	assert.Equal(t, TicketRollupDone, relations.Rollup.Status)
+	assert.Equal(t, 100, relations.Rollup.Percent)
+	assert.Equal(t, []string{"child_added", "child_added", "children_completed"}, historyActions(t, s, parent.ID))
+
+	require.NoError(t, s.RemoveBlocker(child1.ID, child2.ID, alice, "", ""))
+	assert.EqualError(t, s.RemoveBlocker(child1.ID, child2.ID, alice, "", ""), "依赖不存在")
+	assert.Contains(t, historyActions(t, s, child2.ID), "blocking_removed")
+
+	_, err = s.SetParent(child1.ID, &SetParentRequest{}, alice, "", "")
+	require.NoError(t, err)
+	var reloaded models.Ticket
+	require.NoError(t, s.db.First(&reloaded, child1.ID).Error)
+	assert.Nil(t, reloaded.ParentID)
+}
+
+func TestTicketRelationService_MergeAndSplit(t *testing.T) {
+	s := setupTicketRelationTest(t)
+	alice, bob := actorWith(2), actorWith(3)
+	target := createRelationTicket(t, s, "邮箱无法登录")
+	source := createRelationTicket(t, s, "邮箱登录失败")
+	other := createRelationTicket(t, s, "邮件延迟")
+
+	comments := []models.TicketComment{
+		{TicketID: source.ID, UserID: 2, Content: "登录提示密码错误", IsPublic: true},
+		{TicketID: source.ID, UserID: 4, Content: "我也一样", IsPublic: true},
+	}
+	require.NoError(t, s.db.Create(&comments).Error)
+	require.NoError(t, s.db.Create(&models.TicketAttachment{TicketID: source.ID, FileName: "error.png", FileSize: 10, UploadedBy: 2}).Error)
+	require.NoError(t, AddWatchers(s.db, models.WatchEntityTicket, source.ID, models.WatchReasonCommenter, 4))
+	require.NoError(t, s.AddBlocker(other.ID, &AddBlockerRequest{BlockerID: source.ID}, alice, "", ""))
+
+	_, err := s.Merge(source.ID, &MergeTicketRequest{TargetID: source.ID}, alice, "", "")
+	assert.EqualError(t, err, "不能将工单合并到自身")
+	_, err = s.Merge(source.ID, &MergeTicketRequest{TargetID: target.ID}, actorWith(4), "", "")
+	assert.EqualError(t, err, "工单不存在或无权查看")
+
+	merged, err := s.Merge(source.ID, &MergeTicketRequest{TargetID: target.ID, Comment: "重复提交"}, bob, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, target.ID, merged.ID)
+
+	var closed models.Ticket
+	require.NoError(t, s.db.First(&closed, source.ID).Error)
+	assert.Equal(t, models.TicketStatusClosed, closed.Status)
+	require.NotNil(t, closed.MergedIntoID)
+	assert.Equal(t, target.ID, *closed.MergedIntoID)
+
+	var count int64
+	s.db.Model(&models.TicketComment{}).Where("ticket_id = ?", target.ID).Count(&count)
+	assert.Equal(t, int64(2), count)
+	s.db.Model(&models.TicketAttachment{}).Where("ticket_id = ?", target.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+	s.db.Model(&models.Watcher{}).Where("entity_type = ? AND entity_id = ? AND user_id = ?", models.WatchEntityTicket, target.ID, 4).Count(&count)
+	assert.Equal(t, int64(1), count)
+	s.db.Model(&models.Watcher{}).Where("entity_type = ? AND entity_id = ?", models.WatchEntityTicket, source.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	// 被合并工单的阻塞关系转到目标工单
+	relations, err := s.GetRelations(other.ID, alice)
+	require.NoError(t, err)
+	require.Len(t, relations.BlockedBy, 1)
+	assert.Equal(t, target.ID, relations.BlockedBy[0].ID)
+	relations, err = s.GetRelations(target.ID, alice)
+	require.NoError(t, err)
+	require.Len(t, relations.MergedFrom, 1)
+	assert.Equal(t, source.ID, relations.MergedFrom[0].ID)
+
+	var history models.TicketHistory
+	require.NoError(t, s.db.Where("ticket_id = ? AND action = ?", target.ID, "merged").First(&history).Error)
+	assert.Equal(t, "合并了工单 #2（2 条评论，1 个附件）：重复提交", history.Description)
+
+	_, err = s.Merge(source.ID, &MergeTicketRequest{TargetID: other.ID}, bob, "", "")
+	assert.EqualError(t, err, "工单已合并到工单 #1")
+	_, err = s.workflowService.Transition(source.ID, &TicketTransitionRequest{Action: "reopen"}, alice, "", "")
+	assert.EqualError(t, err, "工单已合并到工单 #1，不能再流转")
+
+	// 拆分：选中的评论移入新工单，新工单作为子工单
+	_, err = s.Split(target.ID, &SplitTicketRequest{Title: "x", CommentIDs: []uint{comments[0].ID, 999}}, alice, "", "")
+	assert.EqualError(t, err, "选中的评论不属于该工单")
+
+	split, err := s.Split(target.ID, &SplitTicketRequest{Title: "共享邮箱权限", CommentIDs: []uint{comments[1].ID}, AsChild: true}, alice, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "我也一样", split.Description)
+	assert.Equal(t, models.TicketTypeBug, split.Type)
+	assert.Equal(t, models.TicketStatusSubmitted, split.Status)
+	require.NotNil(t, split.ParentID)
+	assert.Equal(t, target.ID, *split.ParentID)
+
+	var moved models.TicketComment
+	require.NoError(t, s.db.First(&moved, comments[1].ID).Error)
+	assert.Equal(t, split.ID, moved.TicketID)
+	assert.Equal(t, []string{"created"}, historyActions(t, s, split.ID))
+	assert.Contains(t, historyActions(t, s, target.ID), "split")
+
+	var link models.EntityLink
+	require.NoError(t, s.db.Where("source_id = ? AND target_id = ? AND link_type = ?", split.ID, target.ID, models.LinkTypeRelatesTo).First(&link).Error)
+}
+
+func TestTicketRelationService_MergeRejectsBlockingCycle(t *testing.T) {
+	s := setupTicketRelationTest(t)
+	alice, bob := actorWith(2), actorWith(3)
+	source := createRelationTicket(t, s, "VPN断开")
+	target := createRelationTicket(t, s, "VPN无法连接")
+	middle := createRelationTicket(t, s, "更换证书")
+
+	// source 阻塞 middle，middle 阻塞 target：合并后 target 会间接阻塞自身
+	require.NoError(t, s.AddBlocker(middle.ID, &AddBlockerRequest{BlockerID: source.ID}, alice, "", ""))
+	require.NoError(t, s.AddBlocker(target.ID, &AddBlockerRequest{BlockerID: middle.ID}, alice, "", ""))
+	_, err := s.Merge(source.ID, &MergeTicketRequest{TargetID: target.ID}, bob, "", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "循环依赖")
+	var reloaded models.Ticket
+	require.NoError(t, s.db.First(&reloaded, source.ID).Error)
+	assert.Nil(t, reloaded.MergedIntoID)
+
+	// 两个工单之间的直接阻塞在合并时丢弃，不视为循环
+	require.NoError(t, s.RemoveBlocker(target.ID, middle.ID, alice, "", ""))
+	require.NoError(t, s.AddBlocker(target.ID, &AddBlockerRequest{BlockerID: source.ID}, alice, "", ""))
+	_, err = s.Merge(source.ID, &MergeTicketRequest{TargetID: target.ID}, bob, "", "")
+	require.NoError(t, err)
+	relations, err := s.GetRelations(middle.ID, alice)
+	require.NoError(t, err)
+	require.Len(t, relations.BlockedBy, 1)
+	assert.Equal(t, target.ID, relations.BlockedBy[0].ID)
+	relations, err = s.GetRelations(target.ID, alice)
+	require.NoError(t, err)
+	assert.Empty(t, relations.BlockedBy)
+}
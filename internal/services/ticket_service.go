@@ -10,15 +10,17 @@ import (
 
 // TicketService 工单服务
 type TicketService struct {
-	db            *gorm.DB
-	wechatService *WechatService
+	db              *gorm.DB
+	wechatService   *WechatService
+	workflowService *TicketWorkflowService
 }
 
 // NewTicketService 创建工单服务
-func NewTicketService(db *gorm.DB, wechatService *WechatService) *TicketService {
+func NewTicketService(db *gorm.DB, wechatService *WechatService, workflowService *TicketWorkflowService) *TicketService {
 	return &TicketService{
-		db:            db,
-		wechatService: wechatService,
+		db:              db,
+		wechatService:   wechatService,
+		workflowService: workflowService,
 	}
 }
 
@@ -328,46 +330,18 @@ func (s *TicketService) InitializeTicketPermissions() error {
 
 // AssignTicket assigns a ticket to a user
 func (s *TicketService) AssignTicket(ticketID, assigneeID, userID uint, reason string) error {
-	var ticket models.Ticket
-	if err := s.db.First(&ticket, ticketID).Error; err != nil {
-		return fmt.Errorf("工单不存在: %w", err)
-	}
-
-	// 更新分配人
-	updates := map[string]interface{}{
-		"assignee_id": assigneeID,
-		"status":      "assigned",
-	}
-
-	return s.db.Model(&ticket).Updates(updates).Error
+	_, err := s.workflowService.Assign(ticketID, assigneeID, false, reason, &TicketActor{UserID: userID}, "", "")
+	return err
 }
 
 // RejectTicket 拒绝工单
 func (s *TicketService) RejectTicket(ticketID, userID uint, reason string) error {
-	var ticket models.Ticket
-	if err := s.db.First(&ticket, ticketID).Error; err != nil {
-		return fmt.Errorf("工单不存在: %w", err)
-	}
-
-	// 更新状态为拒绝
-	updates := map[string]interface{}{
-		"status": "rejected",
-	}
-
-	return s.db.Model(&ticket).Updates(updates).Error
+	_, err := s.workflowService.Transition(ticketID, &TicketTransitionRequest{Action: "reject", Comment: reason}, &TicketActor{UserID: userID}, "", "")
+	return err
 }
 
 // UpdateTicketStatus 更新工单状态
 func (s *TicketService) UpdateTicketStatus(ticketID uint, status string, userID uint, comment string) error {
-	var ticket models.Ticket
-	if err := s.db.First(&ticket, ticketID).Error; err != nil {
-		return fmt.Errorf("工单不存在: %w", err)
-	}
-
-	// 更新状态
-	updates := map[string]interface{}{
-		"status": status,
-	}
-
-	return s.db.Model(&ticket).Updates(updates).Error
+	_, err := s.workflowService.Transition(ticketID, &TicketTransitionRequest{ToStatus: status, Comment: comment}, &TicketActor{UserID: userID}, "", "")
+	return err
 }
\ No newline at end of file
@@ -0,0 +1,444 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SaveBusinessCalendarRequest 保存工作日历请求，节假日整体替换
+type SaveBusinessCalendarRequest struct {
+	Name        string                   `json:"name" binding:"required,max=100"`
+	Description string                   `json:"description" binding:"max=500"`
+	Timezone    string                   `json:"timezone" binding:"max=64"`
+	WorkDays    string                   `json:"work_days" binding:"max=20"`
+	WorkStart   string                   `json:"work_start" binding:"max=5"`
+	WorkEnd     string                   `json:"work_end" binding:"max=5"`
+	Holidays    []BusinessHolidayRequest `json:"holidays"`
+}
+
+// BusinessHolidayRequest 节假日或调休上班日
+type BusinessHolidayRequest struct {
+	Date      string `json:"date" binding:"required"`
+	Name      string `json:"name" binding:"max=100"`
+	IsWorkday bool   `json:"is_workday"`
+}
+
+// SaveSLAPolicyRequest 保存 SLA 策略请求，升级规则整体替换
+type SaveSLAPolicyRequest struct {
+	Name                 string                 `json:"name" binding:"required,max=200"`
+	Description          string                 `json:"description" binding:"max=500"`
+	Priority             string                 `json:"priority"`
+	TicketType           string                 `json:"ticket_type"`
+	Category             string                 `json:"category" binding:"max=100"`
+	CalendarID           *uint                  `json:"calendar_id"`
+	FirstResponseMinutes int                    `json:"first_response_minutes" binding:"min=0"`
+	ResolutionMinutes    int                    `json:"resolution_minutes" binding:"min=0"`
+	WarningPercent       *int                   `json:"warning_percent"`
+	IsActive             *bool                  `json:"is_active"`
+	Escalations          []SLAEscalationRequest `json:"escalations"`
+}
+
+// SLAEscalationRequest SLA 升级规则
+type SLAEscalationRequest struct {
+	Trigger    string   `json:"trigger" binding:"required"`
+	Action     string   `json:"action" binding:"required"`
+	Targets    []string `json:"targets"`
+	AssigneeID *uint    `json:"assignee_id"`
+	Role       string   `json:"role" binding:"max=50"`
+	Priority   string   `json:"priority"`
+}
+
+// defaultSLAWarningPercent 未指定时的预警比例
+const defaultSLAWarningPercent = 80
+
+// ListCalendars 获取全部工作日历
+func (s *TicketSLAService) ListCalendars() ([]models.BusinessCalendar, error) {
+	var calendars []models.BusinessCalendar
+	if err := s.db.Preload("Holidays", func(db *gorm.DB) *gorm.DB { return db.Order("date ASC") }).
+		Order("id ASC").Find(&calendars).Error; err != nil {
+		return nil, fmt.Errorf("获取工作日历失败: %w", err)
+	}
+	return calendars, nil
+}
+
+// GetCalendar 获取工作日历
+func (s *TicketSLAService) GetCalendar(id uint) (*models.BusinessCalendar, error) {
+	var calendar models.BusinessCalendar
+	err := s.db.Preload("Holidays", func(db *gorm.DB) *gorm.DB { return db.Order("date ASC") }).First(&calendar, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("工作日历不存在")
+		}
+		return nil, fmt.Errorf("获取工作日历失败: %w", err)
+	}
+	return &calendar, nil
+}
+
+// CreateCalendar 创建工作日历
+func (s *TicketSLAService) CreateCalendar(req *SaveBusinessCalendarRequest, userID uint, ipAddress, userAgent string) (*models.BusinessCalendar, error) {
+	calendar := models.BusinessCalendar{CreatedBy: userID}
+	if err := s.saveCalendar(&calendar, req); err != nil {
+		return nil, err
+	}
+
+	s.auditConfig("CREATE", "business_calendar", calendar.ID, nil, map[string]interface{}{"name": calendar.Name}, userID, ipAddress, userAgent)
+	return s.GetCalendar(calendar.ID)
+}
+
+// UpdateCalendar 更新工作日历，计时中的工单在下次暂停恢复或策略重算时按新日历计算
+func (s *TicketSLAService) UpdateCalendar(id uint, req *SaveBusinessCalendarRequest, userID uint, ipAddress, userAgent string) (*models.BusinessCalendar, error) {
+	calendar, err := s.GetCalendar(id)
+	if err != nil {
+		return nil, err
+	}
+	oldValues := map[string]interface{}{"name": calendar.Name, "work_days": calendar.WorkDays, "work_start": calendar.WorkStart, "work_end": calendar.WorkEnd}
+	if err := s.saveCalendar(calendar, req); err != nil {
+		return nil, err
+	}
+
+	s.auditConfig("UPDATE", "business_calendar", calendar.ID, oldValues, map[string]interface{}{"name": calendar.Name}, userID, ipAddress, userAgent)
+	return s.GetCalendar(calendar.ID)
+}
+
+// DeleteCalendar 删除工作日历，被 SLA 策略引用时不允许删除
+func (s *TicketSLAService) DeleteCalendar(id uint, userID uint, ipAddress, userAgent string) error {
+	calendar, err := s.GetCalendar(id)
+	if err != nil {
+		return err
+	}
+	var count int64
+	if err := s.db.Model(&models.TicketSLAPolicy{}).Where("calendar_id = ?", id).Count(&count).Error; err != nil {
+		return fmt.Errorf("检查SLA策略失败: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("工作日历仍被 %d 个SLA策略使用", count)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("calendar_id = ?", id).Delete(&models.BusinessHoliday{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(calendar).Error
+	})
+	if err != nil {
+		return fmt.Errorf("删除工作日历失败: %w", err)
+	}
+
+	s.auditConfig("DELETE", "business_calendar", id, map[string]interface{}{"name": calendar.Name}, nil, userID, ipAddress, userAgent)
+	return nil
+}
+
+// saveCalendar 校验请求并保存工作日历与节假日
+func (s *TicketSLAService) saveCalendar(calendar *models.BusinessCalendar, req *SaveBusinessCalendarRequest) error {
+	calendar.Name = strings.TrimSpace(req.Name)
+	calendar.Description = req.Description
+	calendar.Timezone = strings.TrimSpace(req.Timezone)
+	calendar.WorkDays = strings.TrimSpace(req.WorkDays)
+	calendar.WorkStart = strings.TrimSpace(req.WorkStart)
+	calendar.WorkEnd = strings.TrimSpace(req.WorkEnd)
+	// 未指定时默认北京时间周一至周五 09:00-18:00
+	if calendar.Timezone == "" {
+		calendar.Timezone = "Asia/Shanghai"
+	}
+	if calendar.WorkDays == "" {
+		calendar.WorkDays = "1,2,3,4,5"
+	}
+	if calendar.WorkStart == "" {
+		calendar.WorkStart = "09:00"
+	}
+	if calendar.WorkEnd == "" {
+		calendar.WorkEnd = "18:00"
+	}
+	if calendar.Name == "" {
+		return fmt.Errorf("日历名称不能为空")
+	}
+
+	holidays := make([]models.BusinessHoliday, 0, len(req.Holidays))
+	seen := make(map[string]bool, len(req.Holidays))
+	for _, holiday := range req.Holidays {
+		date := strings.TrimSpace(holiday.Date)
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return fmt.Errorf("无效的节假日日期: %s", holiday.Date)
+		}
+		if seen[date] {
+			return fmt.Errorf("节假日日期重复: %s", date)
+		}
+		seen[date] = true
+		holidays = append(holidays, models.BusinessHoliday{Date: date, Name: holiday.Name, IsWorkday: holiday.IsWorkday})
+	}
+	if _, err := newBusinessHours(calendar); err != nil {
+		return err
+	}
+
+	var count int64
+	query := s.db.Model(&models.BusinessCalendar{}).Where("name = ?", calendar.Name)
+	if calendar.ID != 0 {
+		query = query.Where("id <> ?", calendar.ID)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return fmt.Errorf("检查工作日历失败: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("工作日历名称已存在")
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		calendar.Holidays = nil
+		if calendar.ID == 0 {
+			if err := tx.Create(calendar).Error; err != nil {
+				return err
+			}
+		} else {
+			if err := tx.Omit("created_by", "created_at").Save(calendar).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("calendar_id = ?", calendar.ID).Delete(&models.BusinessHoliday{}).Error; err != nil {
+				return err
+			}
+		}
+		for i := range holidays {
+			holidays[i].CalendarID = calendar.ID
+		}
+		if len(holidays) > 0 {
+			return tx.Create(&holidays).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("保存工作日历失败: %w", err)
+	}
+	return nil
+}
+
+// ListPolicies 获取全部 SLA 策略
+func (s *TicketSLAService) ListPolicies() ([]models.TicketSLAPolicy, error) {
+	var policies []models.TicketSLAPolicy
+	if err := s.db.Preload("Calendar").Preload("Escalations").Order("id ASC").Find(&policies).Error; err != nil {
+		return nil, fmt.Errorf("获取SLA策略失败: %w", err)
+	}
+	return policies, nil
+}
+
+// GetPolicy 获取 SLA 策略
+func (s *TicketSLAService) GetPolicy(id uint) (*models.TicketSLAPolicy, error) {
+	var policy models.TicketSLAPolicy
+	if err := s.db.Preload("Calendar").Preload("Escalations").First(&policy, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("SLA策略不存在")
+		}
+		return nil, fmt.Errorf("获取SLA策略失败: %w", err)
+	}
+	return &policy, nil
+}
+
+// CreatePolicy 创建 SLA 策略，只对之后创建或重新匹配的工单生效
+func (s *TicketSLAService) CreatePolicy(req *SaveSLAPolicyRequest, userID uint, ipAddress, userAgent string) (*models.TicketSLAPolicy, error) {
+	policy := models.TicketSLAPolicy{CreatedBy: userID, IsActive: true, WarningPercent: defaultSLAWarningPercent}
+	if err := s.savePolicy(&policy, req); err != nil {
+		return nil, err
+	}
+
+	s.auditConfig("CREATE", "ticket_sla_policy", policy.ID, nil, map[string]interface{}{"name": policy.Name}, userID, ipAddress, userAgent)
+	return s.GetPolicy(policy.ID)
+}
+
+// UpdatePolicy 更新 SLA 策略，已开始计时的工单保留原时限，直到优先级、类型或分类变更后重新匹配
+func (s *TicketSLAService) UpdatePolicy(id uint, req *SaveSLAPolicyRequest, userID uint, ipAddress, userAgent string) (*models.TicketSLAPolicy, error) {
+	policy, err := s.GetPolicy(id)
+	if err != nil {
+		return nil, err
+	}
+	oldValues := map[string]interface{}{
+		"name":                   policy.Name,
+		"first_response_minutes": policy.FirstResponseMinutes,
+		"resolution_minutes":     policy.ResolutionMinutes,
+		"is_active":              policy.IsActive,
+	}
+	if err := s.savePolicy(policy, req); err != nil {
+		return nil, err
+	}
+
+	s.auditConfig("UPDATE", "ticket_sla_policy", policy.ID, oldValues, map[string]interface{}{"name": policy.Name}, userID, ipAddress, userAgent)
+	return s.GetPolicy(policy.ID)
+}
+
+// DeletePolicy 删除 SLA 策略，已开始计时的工单保留原时限
+func (s *TicketSLAService) DeletePolicy(id uint, userID uint, ipAddress, userAgent string) error {
+	policy, err := s.GetPolicy(id)
+	if err != nil {
+		return err
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("policy_id = ?", id).Delete(&models.TicketSLAEscalation{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.TicketSLAPolicy{}, id).Error
+	})
+	if err != nil {
+		return fmt.Errorf("删除SLA策略失败: %w", err)
+	}
+
+	s.auditConfig("DELETE", "ticket_sla_policy", id, map[string]interface{}{"name": policy.Name}, nil, userID, ipAddress, userAgent)
+	return nil
+}
+
+// savePolicy 校验请求并保存 SLA 策略与升级规则
+func (s *TicketSLAService) savePolicy(policy *models.TicketSLAPolicy, req *SaveSLAPolicyRequest) error {
+	policy.Name = strings.TrimSpace(req.Name)
+	policy.Description = req.Description
+	policy.Priority = models.TicketPriority(strings.TrimSpace(req.Priority))
+	policy.TicketType = models.TicketType(strings.TrimSpace(req.TicketType))
+	policy.Category = strings.TrimSpace(req.Category)
+	policy.CalendarID = req.CalendarID
+	policy.FirstResponseMinutes = req.FirstResponseMinutes
+	policy.ResolutionMinutes = req.ResolutionMinutes
+	if req.WarningPercent != nil {
+		policy.WarningPercent = *req.WarningPercent
+	}
+	if req.IsActive != nil {
+		policy.IsActive = *req.IsActive
+	}
+
+	if policy.Name == "" {
+		return fmt.Errorf("策略名称不能为空")
+	}
+	if policy.Priority != "" && !isValidTicketPriority(policy.Priority) {
+		return fmt.Errorf("无效的优先级: %s", policy.Priority)
+	}
+	if policy.TicketType != "" && !isValidTicketType(policy.TicketType) {
+		return fmt.Errorf("无效的工单类型: %s", policy.TicketType)
+	}
+	if policy.FirstResponseMinutes <= 0 && policy.ResolutionMinutes <= 0 {
+		return fmt.Errorf("首次响应时限与解决时限不能同时为空")
+	}
+	if policy.WarningPercent < 0 || policy.WarningPercent > 99 {
+		return fmt.Errorf("预警比例必须在 0 到 99 之间")
+	}
+	if policy.CalendarID != nil {
+		if _, err := s.GetCalendar(*policy.CalendarID); err != nil {
+			return err
+		}
+	}
+
+	escalations := make([]models.TicketSLAEscalation, 0, len(req.Escalations))
+	for i, item := range req.Escalations {
+		escalation, err := buildSLAEscalation(i+1, &item)
+		if err != nil {
+			return err
+		}
+		escalations = append(escalations, *escalation)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		policy.Calendar = nil
+		policy.Escalations = nil
+		if policy.ID == 0 {
+			// 布尔字段有默认值，创建后会被回填为 true，false 需要单独更新
+			inactive := !policy.IsActive
+			if err := tx.Create(policy).Error; err != nil {
+				return err
+			}
+			if inactive {
+				if err := tx.Model(policy).Update("is_active", false).Error; err != nil {
+					return err
+				}
+			}
+		} else {
+			if err := tx.Omit("created_by", "created_at").Save(policy).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("policy_id = ?", policy.ID).Delete(&models.TicketSLAEscalation{}).Error; err != nil {
+				return err
+			}
+		}
+		for i := range escalations {
+			escalations[i].PolicyID = policy.ID
+		}
+		if len(escalations) > 0 {
+			return tx.Create(&escalations).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("保存SLA策略失败: %w", err)
+	}
+	return nil
+}
+
+// buildSLAEscalation 校验并构建第 index 条升级规则
+func buildSLAEscalation(index int, req *SLAEscalationRequest) (*models.TicketSLAEscalation, error) {
+	if !models.IsValidSLATrigger(req.Trigger) {
+		return nil, fmt.Errorf("第 %d 条升级规则的触发点 %s 无效", index, req.Trigger)
+	}
+	if !models.IsValidSLAEscalation(req.Action) {
+		return nil, fmt.Errorf("第 %d 条升级规则的动作 %s 无效", index, req.Action)
+	}
+
+	escalation := &models.TicketSLAEscalation{Trigger: req.Trigger, Action: req.Action}
+	switch req.Action {
+	case models.SLAEscalationNotify:
+		if len(req.Targets) == 0 {
+			return nil, fmt.Errorf("第 %d 条升级规则需要指定通知对象", index)
+		}
+		for _, target := range req.Targets {
+			if !isValidTicketTarget(target) {
+				return nil, fmt.Errorf("第 %d 条升级规则的通知对象 %s 无效", index, target)
+			}
+		}
+		escalation.Targets = models.StringSlice(req.Targets)
+	case models.SLAEscalationReassign:
+		if (req.AssigneeID == nil || *req.AssigneeID == 0) && strings.TrimSpace(req.Role) == "" {
+			return nil, fmt.Errorf("第 %d 条升级规则需要指定处理人或角色", index)
+		}
+		escalation.AssigneeID = req.AssigneeID
+		escalation.Role = strings.TrimSpace(req.Role)
+	case models.SLAEscalationRaisePriority:
+		priority := models.TicketPriority(req.Priority)
+		if priority != "" && !isValidTicketPriority(priority) {
+			return nil, fmt.Errorf("第 %d 条升级规则的优先级 %s 无效", index, req.Priority)
+		}
+		escalation.Priority = priority
+	}
+	return escalation, nil
+}
+
+// auditConfig 记录 SLA 配置变更审计日志
+func (s *TicketSLAService) auditConfig(action, resourceType string, resourceID uint, oldValues, newValues map[string]interface{}, userID uint, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	s.auditService.CreateAuditLog(&AuditLogRequest{
+		UserID:       userID,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		OldValues:    oldValues,
+		NewValues:    newValues,
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+	})
+}
+
+// isValidTicketPriority 检查工单优先级是否有效
+func isValidTicketPriority(priority models.TicketPriority) bool {
+	for _, level := range ticketPriorityLevels {
+		if level == priority {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidTicketType 检查工单类型是否有效
+func isValidTicketType(ticketType models.TicketType) bool {
+	switch ticketType {
+	case models.TicketTypeBug, models.TicketTypeFeature, models.TicketTypeSupport, models.TicketTypeChange, models.TicketTypeCustom:
+		return true
+	}
+	return false
+}
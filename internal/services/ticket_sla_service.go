@@ -0,0 +1,600 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// TicketSLAService 工单 SLA 服务：匹配策略、按工作日历计时、预警与超时升级
+type TicketSLAService struct {
+	db           *gorm.DB
+	auditService *AuditService
+	watchService *WatchService
+	now          func() time.Time
+}
+
+// NewTicketSLAService 创建工单 SLA 服务
+func NewTicketSLAService(db *gorm.DB, auditService *AuditService, watchService *WatchService) *TicketSLAService {
+	return &TicketSLAService{
+		db:           db,
+		auditService: auditService,
+		watchService: watchService,
+		now:          time.Now,
+	}
+}
+
+// slaStoppedStatuses 进入这些状态时解决计时结束，重新打开后继续计时
+var slaStoppedStatuses = map[models.TicketStatus]bool{
+	models.TicketStatusResolved: true,
+	models.TicketStatusClosed:   true,
+	models.TicketStatusRejected: true,
+}
+
+// ticketPriorityLevels 工单优先级由低到高
+var ticketPriorityLevels = []models.TicketPriority{
+	models.TicketPriorityLow,
+	models.TicketPriorityNormal,
+	models.TicketPriorityHigh,
+	models.TicketPriorityCritical,
+}
+
+// slaTriggerLabels 升级触发点说明
+var slaTriggerLabels = map[string]string{
+	models.SLATriggerResponseWarning:   "首次响应即将超时",
+	models.SLATriggerResponseBreach:    "首次响应已超时",
+	models.SLATriggerResolutionWarning: "解决即将超时",
+	models.SLATriggerResolutionBreach:  "解决已超时",
+}
+
+// TicketSLAResponse 工单 SLA 状态，剩余时间为工作分钟，负数表示已超出
+type TicketSLAResponse struct {
+	models.TicketSLA
+	Paused                     bool `json:"paused"`
+	ResponseRemainingMinutes   *int `json:"response_remaining_minutes"`
+	ResolutionRemainingMinutes *int `json:"resolution_remaining_minutes"`
+}
+
+// SLACheckResult SLA 检查结果
+type SLACheckResult struct {
+	Warned   []uint          `json:"warned"`
+	Breached []uint          `json:"breached"`
+	Failed   map[uint]string `json:"failed"`
+}
+
+// SLAStatistics SLA 统计，达成率为按时达成数占已考核数的百分比，无考核数据时为 100
+type SLAStatistics struct {
+	Total                    int64   `json:"total"`
+	Active                   int64   `json:"active"`
+	Paused                   int64   `json:"paused"`
+	Warning                  int64   `json:"warning"`
+	Breached                 int64   `json:"breached"`
+	ResponseMet              int64   `json:"response_met"`
+	ResponseBreached         int64   `json:"response_breached"`
+	ResolutionMet            int64   `json:"resolution_met"`
+	ResolutionBreached       int64   `json:"resolution_breached"`
+	ResponseComplianceRate   float64 `json:"response_compliance_rate"`
+	ResolutionComplianceRate float64 `json:"resolution_compliance_rate"`
+}
+
+// MatchPolicy 匹配工单的 SLA 策略：优先级最优先，其次类型、分类，同等匹配度取最早创建的策略
+func (s *TicketSLAService) MatchPolicy(ticket *models.Ticket) (*models.TicketSLAPolicy, error) {
+	var policies []models.TicketSLAPolicy
+	err := s.db.Where("is_active = ? AND priority IN ? AND ticket_type IN ? AND category IN ?", true,
+		[]string{"", string(ticket.Priority)}, []string{"", string(ticket.Type)}, []string{"", ticket.Category}).
+		Order("id ASC").Find(&policies).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询SLA策略失败: %w", err)
+	}
+
+	var best *models.TicketSLAPolicy
+	bestScore := -1
+	for i := range policies {
+		score := 0
+		if policies[i].Priority != "" {
+			score += 4
+		}
+		if policies[i].TicketType != "" {
+			score += 2
+		}
+		if policies[i].Category != "" {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = &policies[i], score
+		}
+	}
+	return best, nil
+}
+
+// ApplyToTicket 为工单匹配 SLA 策略并计算时限；已有计时的工单保留已耗时间，按新策略重新计算时限，不再匹配任何策略时移除计时
+func (s *TicketSLAService) ApplyToTicket(ticket *models.Ticket) error {
+	if s == nil {
+		return nil
+	}
+	policy, err := s.MatchPolicy(ticket)
+	if err != nil {
+		return err
+	}
+
+	var sla models.TicketSLA
+	err = s.db.Where("ticket_id = ?", ticket.ID).First(&sla).Error
+	exists := err == nil
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return fmt.Errorf("获取工单SLA失败: %w", err)
+	}
+	if policy == nil {
+		if exists {
+			if err := s.db.Delete(&sla).Error; err != nil {
+				return fmt.Errorf("移除工单SLA失败: %w", err)
+			}
+		}
+		return nil
+	}
+
+	now := s.now()
+	if !exists {
+		sla = models.TicketSLA{TicketID: ticket.ID, StartedAt: ticket.CreatedAt}
+		if sla.StartedAt.IsZero() {
+			sla.StartedAt = now
+		}
+		if ticket.Status == models.TicketStatusPending || slaStoppedStatuses[ticket.Status] {
+			sla.PausedAt = &now
+		}
+	}
+	sla.PolicyID = policy.ID
+	sla.PolicyName = policy.Name
+	sla.CalendarID = policy.CalendarID
+	sla.ResponseMinutes = policy.FirstResponseMinutes
+	sla.ResolutionMinutes = policy.ResolutionMinutes
+	sla.WarningPercent = policy.WarningPercent
+	sla.ResponseState = initialSLAState(sla.ResponseState, sla.ResponseMinutes)
+	sla.ResolutionState = initialSLAState(sla.ResolutionState, sla.ResolutionMinutes)
+
+	hours, err := s.loadHours(sla.CalendarID)
+	if err != nil {
+		return err
+	}
+	scheduleTicketSLA(&sla, hours)
+	if err := s.db.Save(&sla).Error; err != nil {
+		return fmt.Errorf("保存工单SLA失败: %w", err)
+	}
+	return nil
+}
+
+// OnStatusChange 工单流转后更新计时：非创建者的人工操作视为首次响应，pending 暂停计时，解决、关闭或拒绝时完成解决计时
+func (s *TicketSLAService) OnStatusChange(ticket *models.Ticket, fromStatus models.TicketStatus, actor *TicketActor) {
+	if s == nil {
+		return
+	}
+	s.update(ticket.ID, func(sla *models.TicketSLA, hours *businessHours, now time.Time) {
+		if !actor.System && actor.UserID != ticket.CreatorID {
+			markSLAResponded(sla, now)
+		}
+
+		switch {
+		case slaStoppedStatuses[ticket.Status]:
+			if sla.ResolvedAt == nil {
+				markSLAResponded(sla, now)
+				sla.ResolvedAt = &now
+				sla.ResolutionState = completedSLAState(sla.ResolutionState, sla.ResolutionDueAt, now)
+				pauseTicketSLA(sla, now)
+			}
+		case ticket.Status == models.TicketStatusPending:
+			pauseTicketSLA(sla, now)
+		default:
+			if sla.ResolvedAt != nil {
+				// 重新打开，已解决到重新打开之间不计时
+				sla.ResolvedAt = nil
+				if sla.ResolutionState == models.SLAStateMet {
+					sla.ResolutionState = models.SLAStateRunning
+				}
+			}
+			resumeTicketSLA(sla, hours, now)
+		}
+	})
+}
+
+// RecordResponse 非创建者回复工单时记录首次响应
+func (s *TicketSLAService) RecordResponse(ticket *models.Ticket, userID uint) {
+	if s == nil || userID == ticket.CreatorID {
+		return
+	}
+	s.update(ticket.ID, func(sla *models.TicketSLA, hours *businessHours, now time.Time) {
+		markSLAResponded(sla, now)
+	})
+}
+
+// update 读取工单 SLA 并保存修改，未适用 SLA 的工单忽略
+func (s *TicketSLAService) update(ticketID uint, apply func(sla *models.TicketSLA, hours *businessHours, now time.Time)) {
+	var sla models.TicketSLA
+	if err := s.db.Where("ticket_id = ?", ticketID).First(&sla).Error; err != nil {
+		return
+	}
+	hours, err := s.loadHours(sla.CalendarID)
+	if err != nil {
+		fmt.Printf("Warning: failed to load SLA calendar for ticket %d: %v\n", ticketID, err)
+		return
+	}
+
+	apply(&sla, hours, s.now())
+	if err := s.db.Save(&sla).Error; err != nil {
+		fmt.Printf("Warning: failed to update SLA for ticket %d: %v\n", ticketID, err)
+	}
+}
+
+// GetTicketSLA 获取工单的 SLA 状态，未适用 SLA 时返回 nil
+func (s *TicketSLAService) GetTicketSLA(ticketID uint, actor *TicketActor) (*TicketSLAResponse, error) {
+	var ticket models.Ticket
+	if err := s.db.First(&ticket, ticketID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("工单不存在")
+		}
+		return nil, fmt.Errorf("获取工单失败: %w", err)
+	}
+	if !canViewTicket(s.db, &ticket, actor) {
+		return nil, fmt.Errorf("工单不存在或无权访问")
+	}
+	return s.TicketSLA(ticketID)
+}
+
+// TicketSLA 获取工单的 SLA 状态，不做权限检查，未适用 SLA 时返回 nil
+func (s *TicketSLAService) TicketSLA(ticketID uint) (*TicketSLAResponse, error) {
+	if s == nil {
+		return nil, nil
+	}
+	var sla models.TicketSLA
+	if err := s.db.Where("ticket_id = ?", ticketID).First(&sla).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("获取工单SLA失败: %w", err)
+	}
+	hours, err := s.loadHours(sla.CalendarID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &TicketSLAResponse{TicketSLA: sla, Paused: sla.PausedAt != nil}
+	reference := s.now()
+	if sla.PausedAt != nil {
+		reference = *sla.PausedAt
+	}
+	if sla.RespondedAt == nil && sla.ResponseDueAt != nil {
+		remaining := remainingBusinessMinutes(hours, reference, *sla.ResponseDueAt)
+		response.ResponseRemainingMinutes = &remaining
+	}
+	if sla.ResolvedAt == nil && sla.ResolutionDueAt != nil {
+		remaining := remainingBusinessMinutes(hours, reference, *sla.ResolutionDueAt)
+		response.ResolutionRemainingMinutes = &remaining
+	}
+	return response, nil
+}
+
+// FilterBySLAState 按 SLA 状态过滤工单，首次响应或解决任一计时处于该状态即匹配
+func FilterBySLAState(db *gorm.DB, column, state string) *gorm.DB {
+	return db.Where(column+" IN (?)", db.Session(&gorm.Session{NewDB: true}).
+		Model(&models.TicketSLA{}).Select("ticket_id").
+		Where("response_state = ? OR resolution_state = ?", state, state))
+}
+
+// Statistics 统计用户创建或处理的工单的 SLA 情况
+func (s *TicketSLAService) Statistics(userID uint) (*SLAStatistics, error) {
+	scoped := func() *gorm.DB {
+		return s.db.Model(&models.TicketSLA{}).
+			Joins("JOIN tickets ON tickets.id = ticket_slas.ticket_id AND tickets.deleted_at IS NULL").
+			Where("tickets.creator_id = ? OR tickets.assignee_id = ?", userID, userID)
+	}
+
+	stats := &SLAStatistics{}
+	counts := []struct {
+		target *int64
+		where  string
+		args   []interface{}
+	}{
+		{&stats.Total, "1 = 1", nil},
+		{&stats.Active, "ticket_slas.resolved_at IS NULL", nil},
+		{&stats.Paused, "ticket_slas.resolved_at IS NULL AND ticket_slas.paused_at IS NOT NULL", nil},
+		{&stats.Warning, "ticket_slas.resolved_at IS NULL AND (ticket_slas.response_state = ? OR ticket_slas.resolution_state = ?)", []interface{}{models.SLAStateWarning, models.SLAStateWarning}},
+		{&stats.Breached, "ticket_slas.response_state = ? OR ticket_slas.resolution_state = ?", []interface{}{models.SLAStateBreached, models.SLAStateBreached}},
+		{&stats.ResponseMet, "ticket_slas.response_state = ?", []interface{}{models.SLAStateMet}},
+		{&stats.ResponseBreached, "ticket_slas.response_state = ?", []interface{}{models.SLAStateBreached}},
+		{&stats.ResolutionMet, "ticket_slas.resolution_state = ?", []interface{}{models.SLAStateMet}},
+		{&stats.ResolutionBreached, "ticket_slas.resolution_state = ?", []interface{}{models.SLAStateBreached}},
+	}
+	for _, count := range counts {
+		if err := scoped().Where(count.where, count.args...).Count(count.target).Error; err != nil {
+			return nil, fmt.Errorf("统计SLA失败: %w", err)
+		}
+	}
+
+	stats.ResponseComplianceRate = complianceRate(stats.ResponseMet, stats.ResponseBreached)
+	stats.ResolutionComplianceRate = complianceRate(stats.ResolutionMet, stats.ResolutionBreached)
+	return stats, nil
+}
+
+// CheckSLAs 检查计时中的工单，到达预警或超时时间时更新状态并执行升级动作
+func (s *TicketSLAService) CheckSLAs(now time.Time) (*SLACheckResult, error) {
+	result := &SLACheckResult{Warned: []uint{}, Breached: []uint{}, Failed: map[uint]string{}}
+
+	running := []string{models.SLAStateRunning, models.SLAStateWarning}
+	var slas []models.TicketSLA
+	err := s.db.Where("paused_at IS NULL").
+		Where(s.db.Where("response_state = ? AND response_warn_at <= ?", models.SLAStateRunning, now).
+			Or("response_state IN ? AND response_due_at <= ?", running, now).
+			Or("resolution_state = ? AND resolution_warn_at <= ?", models.SLAStateRunning, now).
+			Or("resolution_state IN ? AND resolution_due_at <= ?", running, now)).
+		Order("id ASC").Find(&slas).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询工单SLA失败: %w", err)
+	}
+
+	for i := range slas {
+		sla := &slas[i]
+		var triggers []string
+		if sla.RespondedAt == nil {
+			triggers = append(triggers, advanceSLAState(&sla.ResponseState, sla.ResponseWarnAt, sla.ResponseDueAt, now,
+				models.SLATriggerResponseWarning, models.SLATriggerResponseBreach)...)
+		}
+		triggers = append(triggers, advanceSLAState(&sla.ResolutionState, sla.ResolutionWarnAt, sla.ResolutionDueAt, now,
+			models.SLATriggerResolutionWarning, models.SLATriggerResolutionBreach)...)
+		if len(triggers) == 0 {
+			continue
+		}
+
+		err := s.db.Model(&models.TicketSLA{}).Where("id = ?", sla.ID).Updates(map[string]interface{}{
+			"response_state":   sla.ResponseState,
+			"resolution_state": sla.ResolutionState,
+		}).Error
+		if err != nil {
+			result.Failed[sla.TicketID] = fmt.Sprintf("更新SLA状态失败: %v", err)
+			continue
+		}
+
+		breached := false
+		for _, trigger := range triggers {
+			if err := s.escalate(sla, trigger); err != nil {
+				result.Failed[sla.TicketID] = err.Error()
+			}
+			breached = breached || strings.HasSuffix(trigger, "_breach")
+		}
+		if breached {
+			result.Breached = append(result.Breached, sla.TicketID)
+		} else {
+			result.Warned = append(result.Warned, sla.TicketID)
+		}
+	}
+	return result, nil
+}
+
+// escalate 执行策略中该触发点的升级动作，并在工单历史中记录
+func (s *TicketSLAService) escalate(sla *models.TicketSLA, trigger string) error {
+	var ticket models.Ticket
+	if err := s.db.First(&ticket, sla.TicketID).Error; err != nil {
+		return fmt.Errorf("获取工单失败: %w", err)
+	}
+	var escalations []models.TicketSLAEscalation
+	if err := s.db.Where("policy_id = ? AND trigger_event = ?", sla.PolicyID, trigger).Order("id ASC").Find(&escalations).Error; err != nil {
+		return fmt.Errorf("获取SLA升级规则失败: %w", err)
+	}
+
+	due := sla.ResolutionDueAt
+	if strings.HasPrefix(trigger, "response_") {
+		due = sla.ResponseDueAt
+	}
+	label := slaTriggerLabels[trigger]
+	content := fmt.Sprintf("工单「%s」%s", ticket.Title, label)
+	if due != nil {
+		content += "，截止时间：" + due.Format("2006-01-02 15:04")
+	}
+
+	systemUserID, err := SystemUserID(s.db)
+	if err != nil {
+		return err
+	}
+
+	steps := []string{label}
+	notified := map[uint]bool{}
+	var notifyIDs []uint
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, escalation := range escalations {
+			switch escalation.Action {
+			case models.SLAEscalationNotify:
+				for _, id := range resolveTicketTargets(tx, &ticket, escalation.Targets) {
+					if !notified[id] {
+						notified[id] = true
+						notifyIDs = append(notifyIDs, id)
+					}
+				}
+				steps = append(steps, "通知："+strings.Join(escalation.Targets, "、"))
+			case models.SLAEscalationReassign:
+				entry := &TicketEntryAction{Role: escalation.Role}
+				if escalation.AssigneeID != nil {
+					entry.UserID = *escalation.AssigneeID
+				}
+				assigneeID := pickTicketAssignee(tx, entry)
+				if assigneeID == 0 || (ticket.AssigneeID != nil && *ticket.AssigneeID == assigneeID) {
+					continue
+				}
+				if err := tx.Model(&ticket).Update("assignee_id", assigneeID).Error; err != nil {
+					return fmt.Errorf("转派工单失败: %w", err)
+				}
+				if err := AddWatchers(tx, models.WatchEntityTicket, ticket.ID, models.WatchReasonAssignee, assigneeID); err != nil {
+					return err
+				}
+				if !notified[assigneeID] {
+					notified[assigneeID] = true
+					notifyIDs = append(notifyIDs, assigneeID)
+				}
+				steps = append(steps, fmt.Sprintf("转派给用户 %d", assigneeID))
+			case models.SLAEscalationRaisePriority:
+				priority := raisedTicketPriority(ticket.Priority, escalation.Priority)
+				if priority == ticket.Priority {
+					continue
+				}
+				steps = append(steps, fmt.Sprintf("优先级：%s -> %s", ticket.Priority, priority))
+				if err := tx.Model(&ticket).Update("priority", priority).Error; err != nil {
+					return fmt.Errorf("提升工单优先级失败: %w", err)
+				}
+			}
+		}
+
+		history := models.TicketHistory{
+			TicketID:    ticket.ID,
+			UserID:      systemUserID,
+			Action:      "sla_" + trigger,
+			Description: strings.Join(steps, "；"),
+		}
+		if err := tx.Create(&history).Error; err != nil {
+			return fmt.Errorf("记录SLA升级历史失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.watchService.NotifyUsers(notifyIDs, 0, "SLA提醒", content)
+	return nil
+}
+
+// loadHours 加载工作日历，未指定或日历已删除时按 7x24 小时计时
+func (s *TicketSLAService) loadHours(calendarID *uint) (*businessHours, error) {
+	if calendarID == nil {
+		return newBusinessHours(nil)
+	}
+	var calendar models.BusinessCalendar
+	if err := s.db.Preload("Holidays").First(&calendar, *calendarID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return newBusinessHours(nil)
+		}
+		return nil, fmt.Errorf("获取工作日历失败: %w", err)
+	}
+	return newBusinessHours(&calendar)
+}
+
+// scheduleTicketSLA 根据开始时间、时限与累计暂停时间计算预警与截止时间
+func scheduleTicketSLA(sla *models.TicketSLA, hours *businessHours) {
+	sla.ResponseWarnAt, sla.ResponseDueAt = slaDeadlines(hours, sla.StartedAt, sla.ResponseMinutes, sla.WarningPercent, sla.PausedMinutes)
+	sla.ResolutionWarnAt, sla.ResolutionDueAt = slaDeadlines(hours, sla.StartedAt, sla.ResolutionMinutes, sla.WarningPercent, sla.PausedMinutes)
+}
+
+// slaDeadlines 计算单个计时的预警与截止时间，时限为 0 表示不考核
+func slaDeadlines(hours *businessHours, start time.Time, target, warningPercent, pausedMinutes int) (*time.Time, *time.Time) {
+	if target <= 0 {
+		return nil, nil
+	}
+	due := hours.AddMinutes(start, target+pausedMinutes)
+	if warningPercent <= 0 || warningPercent >= 100 {
+		return nil, &due
+	}
+	warn := hours.AddMinutes(start, target*warningPercent/100+pausedMinutes)
+	return &warn, &due
+}
+
+// initialSLAState 时限为 0 的计时不考核，其余保留已有状态
+func initialSLAState(state string, target int) string {
+	if target <= 0 {
+		return ""
+	}
+	if state == "" {
+		return models.SLAStateRunning
+	}
+	return state
+}
+
+// completedSLAState 计时完成时的状态，已超时的保持超时
+func completedSLAState(state string, due *time.Time, now time.Time) string {
+	if state == "" || state == models.SLAStateBreached {
+		return state
+	}
+	if due != nil && now.After(*due) {
+		return models.SLAStateBreached
+	}
+	return models.SLAStateMet
+}
+
+// markSLAResponded 记录首次响应
+func markSLAResponded(sla *models.TicketSLA, now time.Time) {
+	if sla.RespondedAt != nil {
+		return
+	}
+	sla.RespondedAt = &now
+	sla.ResponseState = completedSLAState(sla.ResponseState, sla.ResponseDueAt, now)
+}
+
+// pauseTicketSLA 暂停计时
+func pauseTicketSLA(sla *models.TicketSLA, now time.Time) {
+	if sla.PausedAt == nil {
+		sla.PausedAt = &now
+	}
+}
+
+// resumeTicketSLA 恢复计时，暂停期间的工作时间顺延到截止时间
+func resumeTicketSLA(sla *models.TicketSLA, hours *businessHours, now time.Time) {
+	if sla.PausedAt == nil {
+		return
+	}
+	sla.PausedMinutes += hours.MinutesBetween(*sla.PausedAt, now)
+	sla.PausedAt = nil
+	scheduleTicketSLA(sla, hours)
+}
+
+// advanceSLAState 到达预警或截止时间时推进计时状态，返回触发的升级触发点
+func advanceSLAState(state *string, warnAt, dueAt *time.Time, now time.Time, warnTrigger, breachTrigger string) []string {
+	var triggers []string
+	if *state == models.SLAStateRunning && warnAt != nil && !now.Before(*warnAt) {
+		*state = models.SLAStateWarning
+		triggers = append(triggers, warnTrigger)
+	}
+	if (*state == models.SLAStateRunning || *state == models.SLAStateWarning) && dueAt != nil && !now.Before(*dueAt) {
+		*state = models.SLAStateBreached
+		triggers = append(triggers, breachTrigger)
+	}
+	return triggers
+}
+
+// remainingBusinessMinutes 距截止时间的工作分钟数，已超出时为负数
+func remainingBusinessMinutes(hours *businessHours, from, due time.Time) int {
+	if due.Before(from) {
+		return -hours.MinutesBetween(due, from)
+	}
+	return hours.MinutesBetween(from, due)
+}
+
+// raisedTicketPriority 计算升级后的优先级，只升不降；未指定目标时提升一级
+func raisedTicketPriority(current, target models.TicketPriority) models.TicketPriority {
+	currentLevel := ticketPriorityLevel(current)
+	if target == "" {
+		if currentLevel+1 < len(ticketPriorityLevels) {
+			return ticketPriorityLevels[currentLevel+1]
+		}
+		return current
+	}
+	if ticketPriorityLevel(target) > currentLevel {
+		return target
+	}
+	return current
+}
+
+// ticketPriorityLevel 优先级的级别，未知优先级按普通处理
+func ticketPriorityLevel(priority models.TicketPriority) int {
+	for i, level := range ticketPriorityLevels {
+		if level == priority {
+			return i
+		}
+	}
+	return 1
+}
+
+// complianceRate 计算达成率百分比
+func complianceRate(met, breached int64) float64 {
+	if met+breached == 0 {
+		return 100
+	}
+	return float64(met) * 100 / float64(met+breached)
+}
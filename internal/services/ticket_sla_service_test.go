@@ -0,0 +1,230 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBusinessHours(t *testing.T) {
+	hours, err := newBusinessHours(&models.BusinessCalendar{
+		Timezone:  "Asia/Shanghai",
+		WorkDays:  "1,2,3,4,5",
+		WorkStart: "09:00",
+		WorkEnd:   "18:00",
+		Holidays: []models.BusinessHoliday{
+			{Date: "2026-10-01", Name: "国庆节"},
+			{Date: "2026-10-10", Name: "调休", IsWorkday: true},
+		},
+	})
+	require.NoError(t, err)
+	location, _ := time.LoadLocation("Asia/Shanghai")
+	at := func(day, hour, minute int) time.Time { return time.Date(2026, 10, day, hour, minute, 0, 0, location) }
+
+	// 周三 17:00 起 120 分钟：当日 60 分钟，周四节假日跳过，周五 10:00 到期
+	assert.True(t, at(2, 10, 0).Equal(hours.AddMinutes(time.Date(2026, 9, 30, 17, 0, 0, 0, location), 120)))
+	assert.Equal(t, 120, hours.MinutesBetween(time.Date(2026, 9, 30, 17, 0, 0, 0, location), at(2, 10, 0)))
+
+	// 下班后开始从下一个工作日上班时间计时，周六调休上班
+	assert.True(t, at(10, 9, 30).Equal(hours.AddMinutes(at(9, 17, 30), 60)))
+	assert.True(t, at(12, 9, 30).Equal(hours.AddMinutes(at(10, 20, 0), 30)))
+	assert.Equal(t, 0, hours.MinutesBetween(at(11, 8, 0), at(11, 20, 0)), "周日不计时")
+
+	_, err = newBusinessHours(&models.BusinessCalendar{Timezone: "Asia/Shanghai", WorkDays: "1,8", WorkStart: "09:00", WorkEnd: "18:00"})
+	assert.EqualError(t, err, "无效的工作日: 8")
+	_, err = newBusinessHours(&models.BusinessCalendar{Timezone: "Asia/Shanghai", WorkDays: "1", WorkStart: "18:00", WorkEnd: "09:00"})
+	assert.EqualError(t, err, "下班时间必须晚于上班时间")
+}
+
+func TestTicketSLAService_Policies(t *testing.T) {
+	s := setupTicketWorkflowTest(t).slaService
+
+	_, err := s.CreatePolicy(&SaveSLAPolicyRequest{Name: "空策略"}, 1, "", "")
+	assert.EqualError(t, err, "首次响应时限与解决时限不能同时为空")
+	_, err = s.CreatePolicy(&SaveSLAPolicyRequest{Name: "无效", ResolutionMinutes: 60, Escalations: []SLAEscalationRequest{
+		{Trigger: models.SLATriggerResolutionBreach, Action: models.SLAEscalationNotify, Targets: []string{"everyone"}},
+	}}, 1, "", "")
+	assert.EqualError(t, err, "第 1 条升级规则的通知对象 everyone 无效")
+	_, err = s.CreatePolicy(&SaveSLAPolicyRequest{Name: "无日历", ResolutionMinutes: 60, CalendarID: uintPtr(99)}, 1, "", "")
+	assert.EqualError(t, err, "工作日历不存在")
+
+	general, err := s.CreatePolicy(&SaveSLAPolicyRequest{Name: "通用", ResolutionMinutes: 2880}, 1, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, defaultSLAWarningPercent, general.WarningPercent)
+	inactive := false
+	critical, err := s.CreatePolicy(&SaveSLAPolicyRequest{Name: "紧急故障", Priority: "critical", TicketType: "bug", FirstResponseMinutes: 15, ResolutionMinutes: 240}, 1, "", "")
+	require.NoError(t, err)
+	_, err = s.CreatePolicy(&SaveSLAPolicyRequest{Name: "停用", Priority: "critical", TicketType: "bug", Category: "网络", ResolutionMinutes: 10, IsActive: &inactive}, 1, "", "")
+	require.NoError(t, err)
+
+	// 优先级匹配优先于类型与分类，停用的策略不参与匹配
+	policy, err := s.MatchPolicy(&models.Ticket{Priority: models.TicketPriorityCritical, Type: models.TicketTypeBug, Category: "网络"})
+	require.NoError(t, err)
+	assert.Equal(t, critical.ID, policy.ID)
+	policy, err = s.MatchPolicy(&models.Ticket{Priority: models.TicketPriorityLow, Type: models.TicketTypeBug})
+	require.NoError(t, err)
+	assert.Equal(t, general.ID, policy.ID)
+
+	calendar, err := s.CreateCalendar(&SaveBusinessCalendarRequest{Name: "工作日", Holidays: []BusinessHolidayRequest{{Date: "2026-10-01", Name: "国庆节"}}}, 1, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "Asia/Shanghai", calendar.Timezone)
+	assert.Len(t, calendar.Holidays, 1)
+	_, err = s.CreateCalendar(&SaveBusinessCalendarRequest{Name: "工作日"}, 1, "", "")
+	assert.EqualError(t, err, "工作日历名称已存在")
+
+	_, err = s.UpdatePolicy(general.ID, &SaveSLAPolicyRequest{Name: "通用", ResolutionMinutes: 2880, CalendarID: &calendar.ID}, 1, "", "")
+	require.NoError(t, err)
+	assert.EqualError(t, s.DeleteCalendar(calendar.ID, 1, "", ""), "工作日历仍被 1 个SLA策略使用")
+	require.NoError(t, s.DeletePolicy(general.ID, 1, "", ""))
+	require.NoError(t, s.DeleteCalendar(calendar.ID, 1, "", ""))
+}
+
+func TestTicketSLAService_Lifecycle(t *testing.T) {
+	workflow := setupTicketWorkflowTest(t)
+	s := workflow.slaService
+	db := s.db
+	grantRole(t, db, 4, "support")
+
+	start := time.Date(2026, 10, 12, 9, 0, 0, 0, time.Local)
+	now := start
+	warningPercent := 50
+	s.now = func() time.Time { return now }
+
+	_, err := s.CreatePolicy(&SaveSLAPolicyRequest{
+		Name:                 "标准",
+		FirstResponseMinutes: 60,
+		ResolutionMinutes:    240,
+		WarningPercent:       &warningPercent,
+		Escalations: []SLAEscalationRequest{
+			{Trigger: models.SLATriggerResponseBreach, Action: models.SLAEscalationReassign, Role: "support"},
+			{Trigger: models.SLATriggerResolutionWarning, Action: models.SLAEscalationNotify, Targets: []string{"assignee"}},
+			{Trigger: models.SLATriggerResolutionBreach, Action: models.SLAEscalationRaisePriority},
+		},
+	}, 1, "", "")
+	require.NoError(t, err)
+
+	ticket := models.Ticket{Title: "网络中断", Type: models.TicketTypeSupport, Status: models.TicketStatusSubmitted, CreatorID: 2, CreatedAt: start}
+	require.NoError(t, db.Create(&ticket).Error)
+	require.NoError(t, s.ApplyToTicket(&ticket))
+
+	sla, err := s.TicketSLA(ticket.ID)
+	require.NoError(t, err)
+	require.NotNil(t, sla)
+	assert.True(t, start.Add(time.Hour).Equal(*sla.ResponseDueAt))
+	assert.True(t, start.Add(30*time.Minute).Equal(*sla.ResponseWarnAt))
+	assert.True(t, start.Add(4*time.Hour).Equal(*sla.ResolutionDueAt))
+
+	// 首次响应超时后转派给 support 角色
+	result, err := s.CheckSLAs(start.Add(61 * time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, []uint{ticket.ID}, result.Breached)
+	require.NoError(t, db.First(&ticket, ticket.ID).Error)
+	require.NotNil(t, ticket.AssigneeID)
+	assert.Equal(t, uint(4), *ticket.AssigneeID)
+
+	// 处理人接手（首次响应已超时，保持超时），挂起期间暂停计时
+	now = start.Add(70 * time.Minute)
+	carol := actorWith(4, "ticket:assign", "ticket:approve")
+	_, err = workflow.Assign(ticket.ID, 4, true, "", carol, "", "")
+	require.NoError(t, err)
+	_, err = workflow.Transition(ticket.ID, &TicketTransitionRequest{Action: "approve"}, carol, "", "")
+	require.NoError(t, err)
+	_, err = workflow.Transition(ticket.ID, &TicketTransitionRequest{Action: "suspend"}, carol, "", "")
+	require.NoError(t, err)
+
+	sla, err = s.TicketSLA(ticket.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.SLAStateBreached, sla.ResponseState)
+	assert.True(t, sla.Paused)
+	require.NotNil(t, sla.ResolutionRemainingMinutes)
+	assert.Equal(t, 170, *sla.ResolutionRemainingMinutes)
+
+	result, err = s.CheckSLAs(start.Add(5 * time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, result.Warned, "暂停中的工单不检查")
+	assert.Empty(t, result.Breached)
+
+	now = start.Add(130 * time.Minute)
+	_, err = workflow.Transition(ticket.ID, &TicketTransitionRequest{Action: "resume"}, carol, "", "")
+	require.NoError(t, err)
+	sla, err = s.TicketSLA(ticket.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 60, sla.PausedMinutes)
+	assert.True(t, start.Add(5*time.Hour).Equal(*sla.ResolutionDueAt), "暂停的 60 分钟顺延")
+
+	// 解决预警通知处理人，超时后提升优先级
+	result, err = s.CheckSLAs(start.Add(3*time.Hour + time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, []uint{ticket.ID}, result.Warned)
+	var notified int64
+	db.Model(&models.Notification{}).Where("title = ? AND recipients = ?", "SLA提醒", `["carol"]`).Count(&notified)
+	assert.Equal(t, int64(2), notified, "转派与解决预警各通知一次")
+
+	result, err = s.CheckSLAs(start.Add(5*time.Hour + time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, []uint{ticket.ID}, result.Breached)
+	require.NoError(t, db.First(&ticket, ticket.ID).Error)
+	assert.Equal(t, models.TicketPriorityHigh, ticket.Priority)
+	var history models.TicketHistory
+	require.NoError(t, db.Where("ticket_id = ? AND action = ?", ticket.ID, "sla_resolution_breach").First(&history).Error)
+	assert.Equal(t, "解决已超时；优先级：normal -> high", history.Description)
+	systemUserID, err := SystemUserID(db)
+	require.NoError(t, err)
+	assert.Equal(t, systemUserID, history.UserID, "升级历史记在系统账号下")
+
+	// 已超时的计时重复检查不会再次升级
+	result, err = s.CheckSLAs(start.Add(6 * time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, result.Breached)
+
+	now = start.Add(6 * time.Hour)
+	_, err = workflow.Transition(ticket.ID, &TicketTransitionRequest{Action: "resolve"}, carol, "", "")
+	require.NoError(t, err)
+	sla, err = s.TicketSLA(ticket.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.SLAStateBreached, sla.ResolutionState)
+	assert.NotNil(t, sla.ResolvedAt)
+
+	stats, err := s.Statistics(2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Total)
+	assert.Equal(t, int64(0), stats.Active)
+	assert.Equal(t, int64(1), stats.Breached)
+	assert.Equal(t, float64(0), stats.ResolutionComplianceRate)
+}
+
+func TestTicketSLAService_ResponseMet(t *testing.T) {
+	workflow := setupTicketWorkflowTest(t)
+	s := workflow.slaService
+	start := time.Now().Add(-10 * time.Minute)
+
+	_, err := s.CreatePolicy(&SaveSLAPolicyRequest{Name: "标准", FirstResponseMinutes: 60, ResolutionMinutes: 480}, 1, "", "")
+	require.NoError(t, err)
+	ticket := models.Ticket{Title: "账号申请", Type: models.TicketTypeSupport, Status: models.TicketStatusSubmitted, CreatorID: 2, CreatedAt: start}
+	require.NoError(t, s.db.Create(&ticket).Error)
+	require.NoError(t, s.ApplyToTicket(&ticket))
+
+	// 创建者自己的回复不算响应
+	s.RecordResponse(&ticket, 2)
+	sla, err := s.TicketSLA(ticket.ID)
+	require.NoError(t, err)
+	assert.Nil(t, sla.RespondedAt)
+	assert.Equal(t, models.SLAStateRunning, sla.ResponseState)
+
+	s.RecordResponse(&ticket, 3)
+	sla, err = s.TicketSLA(ticket.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.SLAStateMet, sla.ResponseState)
+	assert.Nil(t, sla.ResponseRemainingMinutes)
+
+	// 优先级不再匹配任何策略时移除计时
+	require.NoError(t, s.db.Model(&models.TicketSLAPolicy{}).Where("1 = 1").Update("priority", "critical").Error)
+	require.NoError(t, s.ApplyToTicket(&ticket))
+	sla, err = s.TicketSLA(ticket.ID)
+	require.NoError(t, err)
+	assert.Nil(t, sla)
+}
@@ -0,0 +1,430 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// 满意度调查系统配置，分类为 ticket_survey
+const (
+	ticketSurveyConfigCategory   = "ticket_survey"
+	defaultTicketSurveyValidDays = 7
+	defaultTicketSurveyPoorScore = 2 // 评分不高于该值视为差评
+	defaultTicketSurveyLinkBase  = "/survey/"
+	defaultTicketSurveyManagers  = "role:admin"
+)
+
+// 差评后的处理方式（配置项 poor_action）
+const (
+	TicketSurveyPoorNone   = "none"   // 不处理
+	TicketSurveyPoorReopen = "reopen" // 重新打开工单，流程不支持时改为通知负责人
+	TicketSurveyPoorNotify = "notify" // 通知负责人
+)
+
+// 满意度统计周期
+const (
+	TicketSurveyPeriodDay   = "day"
+	TicketSurveyPeriodWeek  = "week"
+	TicketSurveyPeriodMonth = "month"
+)
+
+// TicketSurveyService 工单满意度调查：提交评分、差评处理与统计
+type TicketSurveyService struct {
+	db              *gorm.DB
+	watchService    *WatchService
+	workflowService *TicketWorkflowService
+}
+
+// NewTicketSurveyService 创建满意度调查服务
+func NewTicketSurveyService(db *gorm.DB, watchService *WatchService, workflowService *TicketWorkflowService) *TicketSurveyService {
+	return &TicketSurveyService{
+		db:              db,
+		watchService:    watchService,
+		workflowService: workflowService,
+	}
+}
+
+// SubmitTicketSurveyRequest 提交满意度调查请求
+type SubmitTicketSurveyRequest struct {
+	Rating  int    `json:"rating" binding:"required,min=1,max=5"`
+	Comment string `json:"comment" binding:"max=2000"`
+}
+
+// TicketSurveyView 通过调查链接看到的工单信息
+type TicketSurveyView struct {
+	TicketID    uint       `json:"ticket_id"`
+	TicketTitle string     `json:"ticket_title"`
+	Status      string     `json:"status"`
+	Assignee    string     `json:"assignee"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	Rating      *int       `json:"rating"`
+	RespondedAt *time.Time `json:"responded_at"`
+}
+
+// TicketSurveyStatisticsQuery 满意度统计查询参数，日期为 YYYY-MM-DD，按调查发送时间筛选
+type TicketSurveyStatisticsQuery struct {
+	From       string `form:"from"`
+	To         string `form:"to"`
+	Period     string `form:"period" binding:"omitempty,oneof=day week month"`
+	AssigneeID *uint  `form:"assignee_id"`
+	Category   string `form:"category"`
+}
+
+// TicketSurveySummary 满意度汇总
+type TicketSurveySummary struct {
+	Sent          int64         `json:"sent"`
+	Responded     int64         `json:"responded"`
+	ResponseRate  float64       `json:"response_rate"`
+	AverageRating float64       `json:"average_rating"`
+	Poor          int64         `json:"poor"`
+	Distribution  map[int]int64 `json:"distribution"` // 各评分的数量
+}
+
+// TicketSurveyGroup 按处理人、分类或周期分组的满意度
+type TicketSurveyGroup struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+	TicketSurveySummary
+}
+
+// TicketSurveyStatistics 满意度统计
+type TicketSurveyStatistics struct {
+	Summary    TicketSurveySummary `json:"summary"`
+	ByAssignee []TicketSurveyGroup `json:"by_assignee"`
+	ByCategory []TicketSurveyGroup `json:"by_category"`
+	ByPeriod   []TicketSurveyGroup `json:"by_period"`
+}
+
+// GetSurvey 通过调查链接获取工单信息，已提交的调查返回评分
+func (s *TicketSurveyService) GetSurvey(token string) (*TicketSurveyView, error) {
+	survey, err := s.findSurvey(token)
+	if err != nil {
+		return nil, err
+	}
+	if survey.RespondedAt == nil && !survey.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("调查链接已过期")
+	}
+	view := &TicketSurveyView{
+		TicketID:    survey.TicketID,
+		TicketTitle: survey.Ticket.Title,
+		Status:      string(survey.Ticket.Status),
+		ExpiresAt:   survey.ExpiresAt,
+		Rating:      survey.Rating,
+		RespondedAt: survey.RespondedAt,
+	}
+	if survey.Assignee != nil {
+		view.Assignee = survey.Assignee.Username
+	}
+	return view, nil
+}
+
+// Submit 提交满意度评分，每个调查链接只能提交一次；差评按配置重新打开工单或通知负责人
+func (s *TicketSurveyService) Submit(token string, req *SubmitTicketSurveyRequest) (*models.TicketSurvey, error) {
+	if req.Rating < 1 || req.Rating > 5 {
+		return nil, fmt.Errorf("评分必须为 1-5 分")
+	}
+	survey, err := s.findSurvey(token)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	comment := strings.TrimSpace(req.Comment)
+	// 条件更新保证并发提交时只有一次成功
+	result := s.db.Model(&models.TicketSurvey{}).
+		Where("id = ? AND responded_at IS NULL AND expires_at > ?", survey.ID, now).
+		Updates(map[string]interface{}{"rating": req.Rating, "comment": comment, "responded_at": now})
+	if result.Error != nil {
+		return nil, fmt.Errorf("提交满意度调查失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		if survey.RespondedAt != nil {
+			return nil, fmt.Errorf("调查已提交")
+		}
+		return nil, fmt.Errorf("调查链接已过期")
+	}
+	survey.Rating, survey.Comment, survey.RespondedAt = &req.Rating, comment, &now
+
+	description := fmt.Sprintf("满意度评分 %d 分", req.Rating)
+	if comment != "" {
+		description += "：" + comment
+	}
+	s.db.Create(&models.TicketHistory{TicketID: survey.TicketID, UserID: survey.UserID, Action: "survey", Description: description})
+
+	if req.Rating <= getConfigInt(s.db, ticketSurveyConfigCategory, "poor_rating", defaultTicketSurveyPoorScore) {
+		s.handlePoorRating(survey, description)
+	}
+	return survey, nil
+}
+
+// TicketSurveys 获取工单的满意度调查结果，最新的在前；需可查看该工单
+func (s *TicketSurveyService) TicketSurveys(ticketID uint, actor *TicketActor) ([]models.TicketSurvey, error) {
+	var ticket models.Ticket
+	if err := s.db.First(&ticket, ticketID).Error; err != nil || !canViewTicket(s.db, &ticket, actor) {
+		return nil, fmt.Errorf("工单不存在或无权查看")
+	}
+	surveys := []models.TicketSurvey{}
+	if err := s.db.Where("ticket_id = ?", ticketID).Order("id DESC").Find(&surveys).Error; err != nil {
+		return nil, fmt.Errorf("获取满意度调查失败: %w", err)
+	}
+	return surveys, nil
+}
+
+// Statistics 按处理人、分类和周期汇总满意度，周期默认按月
+func (s *TicketSurveyService) Statistics(query *TicketSurveyStatisticsQuery) (*TicketSurveyStatistics, error) {
+	db := s.db.Model(&models.TicketSurvey{}).Preload("Assignee")
+	if query.From != "" {
+		from, err := time.ParseInLocation("2006-01-02", query.From, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("开始日期格式应为 YYYY-MM-DD")
+		}
+		db = db.Where("created_at >= ?", from)
+	}
+	if query.To != "" {
+		to, err := time.ParseInLocation("2006-01-02", query.To, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("结束日期格式应为 YYYY-MM-DD")
+		}
+		db = db.Where("created_at < ?", to.AddDate(0, 0, 1))
+	}
+	if query.AssigneeID != nil {
+		db = db.Where("assignee_id = ?", *query.AssigneeID)
+	}
+	if query.Category != "" {
+		db = db.Where("category = ?", query.Category)
+	}
+
+	var surveys []models.TicketSurvey
+	if err := db.Order("created_at ASC, id ASC").Find(&surveys).Error; err != nil {
+		return nil, fmt.Errorf("统计满意度失败: %w", err)
+	}
+
+	categoryLabels := map[string]string{"": "未分类"}
+	var categories []models.TicketCategory
+	s.db.Find(&categories)
+	for _, category := range categories {
+		categoryLabels[category.Name] = category.DisplayName
+	}
+
+	poor := getConfigInt(s.db, ticketSurveyConfigCategory, "poor_rating", defaultTicketSurveyPoorScore)
+	period := query.Period
+	if period == "" {
+		period = TicketSurveyPeriodMonth
+	}
+	stats := &TicketSurveyStatistics{
+		Summary: summarizeTicketSurveys(surveys, poor),
+		ByAssignee: groupTicketSurveys(surveys, poor, func(survey *models.TicketSurvey) (string, string) {
+			if survey.Assignee == nil {
+				return "", "未分配"
+			}
+			return fmt.Sprint(survey.Assignee.ID), survey.Assignee.Username
+		}),
+		ByCategory: groupTicketSurveys(surveys, poor, func(survey *models.TicketSurvey) (string, string) {
+			if label, ok := categoryLabels[survey.Category]; ok {
+				return survey.Category, label
+			}
+			return survey.Category, survey.Category
+		}),
+		ByPeriod: groupTicketSurveys(surveys, poor, func(survey *models.TicketSurvey) (string, string) {
+			key := ticketSurveyPeriodKey(survey.CreatedAt, period)
+			return key, key
+		}),
+	}
+	// 周期按时间顺序，其余按评价数量从多到少
+	sort.SliceStable(stats.ByPeriod, func(i, j int) bool { return stats.ByPeriod[i].Key < stats.ByPeriod[j].Key })
+	return stats, nil
+}
+
+// UserSummary 用户创建或处理的工单的满意度汇总，用于工单统计
+func (s *TicketSurveyService) UserSummary(userID uint) (*TicketSurveySummary, error) {
+	var surveys []models.TicketSurvey
+	if err := s.db.Where("user_id = ? OR assignee_id = ?", userID, userID).Find(&surveys).Error; err != nil {
+		return nil, fmt.Errorf("统计满意度失败: %w", err)
+	}
+	summary := summarizeTicketSurveys(surveys, getConfigInt(s.db, ticketSurveyConfigCategory, "poor_rating", defaultTicketSurveyPoorScore))
+	return &summary, nil
+}
+
+// handlePoorRating 差评后按配置重新打开工单或通知负责人
+func (s *TicketSurveyService) handlePoorRating(survey *models.TicketSurvey, description string) {
+	action := getConfigValue(s.db, ticketSurveyConfigCategory, "poor_action", TicketSurveyPoorNotify)
+	if action == TicketSurveyPoorNone {
+		return
+	}
+
+	var ticket models.Ticket
+	if err := s.db.First(&ticket, survey.TicketID).Error; err != nil {
+		return
+	}
+	if action == TicketSurveyPoorReopen && s.workflowService != nil {
+		req := &TicketTransitionRequest{Action: TicketSurveyReopenAction, Comment: description}
+		actor, err := s.workflowService.SystemActor()
+		if err == nil {
+			_, err = s.workflowService.Transition(ticket.ID, req, actor, "", "")
+		}
+		if err == nil {
+			s.db.Model(survey).Update("follow_up", models.SurveyFollowUpReopened)
+			survey.FollowUp = models.SurveyFollowUpReopened
+			return
+		}
+	}
+
+	targets := strings.Split(getConfigValue(s.db, ticketSurveyConfigCategory, "manager_targets", defaultTicketSurveyManagers), ",")
+	for i := range targets {
+		targets[i] = strings.TrimSpace(targets[i])
+	}
+	managers := uniqueUints(resolveTicketTargets(s.db, &ticket, targets))
+	if len(managers) == 0 {
+		return
+	}
+	s.watchService.NotifyUsers(managers, 0, "工单差评提醒", fmt.Sprintf("工单 #%d %s 收到差评\n%s", ticket.ID, ticket.Title, description))
+	s.db.Model(survey).Update("follow_up", models.SurveyFollowUpNotified)
+	survey.FollowUp = models.SurveyFollowUpNotified
+}
+
+// findSurvey 按链接令牌查找调查
+func (s *TicketSurveyService) findSurvey(token string) (*models.TicketSurvey, error) {
+	var survey models.TicketSurvey
+	err := s.db.Preload("Ticket").Preload("Assignee").
+		Where("token_hash = ?", hashTicketSurveyToken(token)).
+		First(&survey).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("调查不存在")
+		}
+		return nil, fmt.Errorf("获取满意度调查失败: %w", err)
+	}
+	return &survey, nil
+}
+
+// sendTicketSurvey 工单从未完成进入已解决或已关闭时向创建者发送满意度调查，同一工单之前未提交的调查随之失效；
+// 已解决再关闭、合并关闭或关闭调查功能时不发送
+func sendTicketSurvey(db *gorm.DB, watchService *WatchService, ticket *models.Ticket, fromStatus models.TicketStatus) {
+	if ticket.Status != models.TicketStatusResolved && ticket.Status != models.TicketStatusClosed {
+		return
+	}
+	if fromStatus == models.TicketStatusResolved || fromStatus == models.TicketStatusClosed || ticket.MergedIntoID != nil {
+		return
+	}
+	if !getConfigBool(db, ticketSurveyConfigCategory, "enabled", true) {
+		return
+	}
+
+	token, err := generateTicketSurveyToken()
+	if err != nil {
+		return
+	}
+	days := getConfigInt(db, ticketSurveyConfigCategory, "valid_days", defaultTicketSurveyValidDays)
+	if days <= 0 {
+		days = defaultTicketSurveyValidDays
+	}
+	now := time.Now()
+	survey := models.TicketSurvey{
+		TicketID:   ticket.ID,
+		UserID:     ticket.CreatorID,
+		AssigneeID: ticket.AssigneeID,
+		Category:   ticket.Category,
+		TokenHash:  hashTicketSurveyToken(token),
+		ExpiresAt:  now.AddDate(0, 0, days),
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.TicketSurvey{}).
+			Where("ticket_id = ? AND responded_at IS NULL AND expires_at > ?", ticket.ID, now).
+			Update("expires_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&survey).Error
+	})
+	if err != nil {
+		return
+	}
+
+	link := getConfigValue(db, ticketSurveyConfigCategory, "link_base", defaultTicketSurveyLinkBase) + token
+	content := fmt.Sprintf("您的工单 #%d %s 已处理完成，请为本次服务评分（1-5 分）：\n%s\n链接 %d 天内有效，只能提交一次。", ticket.ID, ticket.Title, link, days)
+	// 操作者为 0，创建者自己解决工单时也会收到调查
+	watchService.NotifyUsers([]uint{ticket.CreatorID}, 0, "满意度调查", content)
+}
+
+// generateTicketSurveyToken 生成调查链接令牌
+func generateTicketSurveyToken() (string, error) {
+	bytes := make([]byte, 24)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// hashTicketSurveyToken 数据库只保存令牌的哈希
+func hashTicketSurveyToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// summarizeTicketSurveys 汇总调查的发送、提交与评分分布
+func summarizeTicketSurveys(surveys []models.TicketSurvey, poor int) TicketSurveySummary {
+	summary := TicketSurveySummary{Distribution: map[int]int64{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}}
+	var total int64
+	for _, survey := range surveys {
+		summary.Sent++
+		if survey.Rating == nil {
+			continue
+		}
+		summary.Responded++
+		summary.Distribution[*survey.Rating]++
+		total += int64(*survey.Rating)
+		if *survey.Rating <= poor {
+			summary.Poor++
+		}
+	}
+	if summary.Sent > 0 {
+		summary.ResponseRate = float64(summary.Responded) * 100 / float64(summary.Sent)
+	}
+	if summary.Responded > 0 {
+		summary.AverageRating = float64(total) / float64(summary.Responded)
+	}
+	return summary
+}
+
+// groupTicketSurveys 按 keyOf 返回的键分组汇总，按提交数量从多到少排序
+func groupTicketSurveys(surveys []models.TicketSurvey, poor int, keyOf func(*models.TicketSurvey) (string, string)) []TicketSurveyGroup {
+	grouped := map[string][]models.TicketSurvey{}
+	labels := map[string]string{}
+	keys := []string{}
+	for i := range surveys {
+		key, label := keyOf(&surveys[i])
+		if _, ok := grouped[key]; !ok {
+			keys = append(keys, key)
+			labels[key] = label
+		}
+		grouped[key] = append(grouped[key], surveys[i])
+	}
+
+	groups := make([]TicketSurveyGroup, len(keys))
+	for i, key := range keys {
+		groups[i] = TicketSurveyGroup{Key: key, Label: labels[key], TicketSurveySummary: summarizeTicketSurveys(grouped[key], poor)}
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Responded > groups[j].Responded })
+	return groups
+}
+
+// ticketSurveyPeriodKey 统计周期的键：日为 2006-01-02，周为 ISO 周 2006-W01，月为 2006-01
+func ticketSurveyPeriodKey(t time.Time, period string) string {
+	switch period {
+	case TicketSurveyPeriodDay:
+		return t.Format("2006-01-02")
+	case TicketSurveyPeriodWeek:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	default:
+		return t.Format("2006-01")
+	}
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var surveyLinkPattern = regexp.MustCompile(`/survey/([0-9a-f]+)`)
+
+func setupTicketSurveyTest(t *testing.T) *TicketSurveyService {
+	workflow := setupTicketWorkflowTest(t)
+	return NewTicketSurveyService(workflow.db, workflow.watchService, workflow)
+}
+
+// resolveSurveyTicket 创建 alice 提交、指定处理人处理中的工单并由处理人解决，返回工单与调查链接令牌
+func resolveSurveyTicket(t *testing.T, s *TicketSurveyService, assigneeID uint, category string) (*models.Ticket, string) {
+	ticket := models.Ticket{Title: "VPN 无法连接", Type: "support", Category: category, Status: models.TicketStatusInProgress, CreatorID: 2, AssigneeID: &assigneeID}
+	require.NoError(t, s.db.Create(&ticket).Error)
+	_, err := s.workflowService.Transition(ticket.ID, &TicketTransitionRequest{Action: "resolve"}, actorWith(assigneeID), "", "")
+	require.NoError(t, err)
+
+	var notification models.Notification
+	require.NoError(t, s.db.Where("subject = ? AND created_by = ?", "满意度调查", 2).Order("id DESC").First(&notification).Error)
+	match := surveyLinkPattern.FindStringSubmatch(notification.Content)
+	require.Len(t, match, 2)
+	return &ticket, match[1]
+}
+
+func setSurveyConfig(t *testing.T, s *TicketSurveyService, key, value string) {
+	require.NoError(t, s.db.Create(&models.SystemConfig{Category: ticketSurveyConfigCategory, Key: key, Value: value}).Error)
+}
+
+func TestTicketSurveyService_SendAndSubmit(t *testing.T) {
+	s := setupTicketSurveyTest(t)
+	ticket, token := resolveSurveyTicket(t, s, 3, "")
+
+	var surveys []models.TicketSurvey
+	require.NoError(t, s.db.Find(&surveys).Error)
+	require.Len(t, surveys, 1)
+	assert.Equal(t, uint(2), surveys[0].UserID)
+	assert.Equal(t, uint(3), *surveys[0].AssigneeID)
+	assert.NotEqual(t, token, surveys[0].TokenHash)
+
+	// 已解决再关闭不重复发送
+	_, err := s.workflowService.Transition(ticket.ID, &TicketTransitionRequest{Action: "close"}, actorWith(2), "", "")
+	require.NoError(t, err)
+	var count int64
+	s.db.Model(&models.TicketSurvey{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	view, err := s.GetSurvey(token)
+	require.NoError(t, err)
+	assert.Equal(t, "VPN 无法连接", view.TicketTitle)
+	assert.Equal(t, "bob", view.Assignee)
+	assert.Nil(t, view.Rating)
+	_, err = s.GetSurvey("unknown")
+	assert.EqualError(t, err, "调查不存在")
+
+	survey, err := s.Submit(token, &SubmitTicketSurveyRequest{Rating: 5, Comment: " 很快 "})
+	require.NoError(t, err)
+	assert.Equal(t, 5, *survey.Rating)
+	assert.Equal(t, "很快", survey.Comment)
+	assert.Empty(t, survey.FollowUp)
+	_, err = s.Submit(token, &SubmitTicketSurveyRequest{Rating: 1})
+	assert.EqualError(t, err, "调查已提交")
+
+	var history models.TicketHistory
+	require.NoError(t, s.db.Where("ticket_id = ? AND action = ?", ticket.ID, "survey").First(&history).Error)
+	assert.Equal(t, "满意度评分 5 分：很快", history.Description)
+
+	results, err := s.TicketSurveys(ticket.ID, actorWith(3))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	_, err = s.TicketSurveys(ticket.ID, actorWith(4))
+	assert.EqualError(t, err, "工单不存在或无权查看")
+
+	// 过期的链接不能提交
+	_, expired := resolveSurveyTicket(t, s, 3, "")
+	require.NoError(t, s.db.Model(&models.TicketSurvey{}).Where("rating IS NULL").Update("expires_at", time.Now().Add(-time.Minute)).Error)
+	_, err = s.Submit(expired, &SubmitTicketSurveyRequest{Rating: 4})
+	assert.EqualError(t, err, "调查链接已过期")
+	_, err = s.GetSurvey(expired)
+	assert.EqualError(t, err, "调查链接已过期")
+}
+
+func TestTicketSurveyService_PoorRating(t *testing.T) {
+	s := setupTicketSurveyTest(t)
+	setSurveyConfig(t, s, "poor_action", TicketSurveyPoorReopen)
+	setSurveyConfig(t, s, "manager_targets", "user:4")
+
+	ticket, token := resolveSurveyTicket(t, s, 3, "")
+	survey, err := s.Submit(token, &SubmitTicketSurveyRequest{Rating: 1, Comment: "没有解决"})
+	require.NoError(t, err)
+	assert.Equal(t, models.SurveyFollowUpReopened, survey.FollowUp)
+	var reopened models.Ticket
+	require.NoError(t, s.db.First(&reopened, ticket.ID).Error)
+	assert.Equal(t, models.TicketStatusSubmitted, reopened.Status)
+
+	// 配置为通知负责人
+	require.NoError(t, s.db.Model(&models.SystemConfig{}).Where("key = ?", "poor_action").Update("value", TicketSurveyPoorNotify).Error)
+	_, token = resolveSurveyTicket(t, s, 3, "")
+	survey, err = s.Submit(token, &SubmitTicketSurveyRequest{Rating: 2})
+	require.NoError(t, err)
+	assert.Equal(t, models.SurveyFollowUpNotified, survey.FollowUp)
+	var notification models.Notification
+	require.NoError(t, s.db.Where("subject = ? AND created_by = ?", "工单差评提醒", 4).First(&notification).Error)
+	assert.Contains(t, notification.Content, "满意度评分 2 分")
+
+	_, token = resolveSurveyTicket(t, s, 3, "")
+	survey, err = s.Submit(token, &SubmitTicketSurveyRequest{Rating: 3})
+	require.NoError(t, err)
+	assert.Empty(t, survey.FollowUp)
+}
+
+func TestTicketSurveyService_Statistics(t *testing.T) {
+	s := setupTicketSurveyTest(t)
+	require.NoError(t, s.db.Create(&models.TicketCategory{Name: "network", DisplayName: "网络", IsActive: true}).Error)
+
+	ratings := []struct {
+		assignee uint
+		category string
+		rating   int
+	}{{3, "network", 5}, {3, "network", 4}, {4, "", 1}, {4, "", 0}}
+	for _, r := range ratings {
+		_, token := resolveSurveyTicket(t, s, r.assignee, r.category)
+		if r.rating > 0 {
+			_, err := s.Submit(token, &SubmitTicketSurveyRequest{Rating: r.rating})
+			require.NoError(t, err)
+		}
+	}
+	// 第一份调查记为上个月发送
+	lastMonth := time.Now().AddDate(0, -1, 0)
+	require.NoError(t, s.db.Model(&models.TicketSurvey{}).Where("id = ?", 1).Update("created_at", lastMonth).Error)
+
+	stats, err := s.Statistics(&TicketSurveyStatisticsQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), stats.Summary.Sent)
+	assert.Equal(t, int64(3), stats.Summary.Responded)
+	assert.InDelta(t, 75, stats.Summary.ResponseRate, 0.01)
+	assert.InDelta(t, 10.0/3, stats.Summary.AverageRating, 0.01)
+	assert.Equal(t, int64(1), stats.Summary.Poor)
+	assert.Equal(t, int64(1), stats.Summary.Distribution[5])
+
+	require.Len(t, stats.ByAssignee, 2)
+	assert.Equal(t, "bob", stats.ByAssignee[0].Label)
+	assert.InDelta(t, 4.5, stats.ByAssignee[0].AverageRating, 0.01)
+	assert.Equal(t, "carol", stats.ByAssignee[1].Label)
+	assert.Equal(t, int64(2), stats.ByAssignee[1].Sent)
+
+	require.Len(t, stats.ByCategory, 2)
+	assert.Equal(t, "网络", stats.ByCategory[0].Label)
+	assert.Equal(t, "未分类", stats.ByCategory[1].Label)
+
+	require.Len(t, stats.ByPeriod, 2)
+	assert.Equal(t, lastMonth.Format("2006-01"), stats.ByPeriod[0].Key)
+	assert.Equal(t, int64(1), stats.ByPeriod[0].Responded)
+
+	carol := uint(4)
+	stats, err = s.Statistics(&TicketSurveyStatisticsQuery{AssigneeID: &carol, From: time.Now().Format("2006-01-02"), Period: TicketSurveyPeriodDay})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stats.Summary.Sent)
+	require.Len(t, stats.ByPeriod, 1)
+	assert.Equal(t, time.Now().Format("2006-01-02"), stats.ByPeriod[0].Key)
+	_, err = s.Statistics(&TicketSurveyStatisticsQuery{From: "2024/01/01"})
+	assert.EqualError(t, err, "开始日期格式应为 YYYY-MM-DD")
+
+	summary, err := s.UserSummary(3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), summary.Responded)
+}
@@ -0,0 +1,621 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// 工单模板检查项限制
+const (
+	ticketChecklistMaxItems  = 50
+	ticketChecklistMaxLength = 200
+)
+
+// TicketTemplateService 工单模板服务
+type TicketTemplateService struct {
+	db           *gorm.DB
+	auditService *AuditService
+}
+
+// NewTicketTemplateService 创建工单模板服务
+func NewTicketTemplateService(db *gorm.DB, auditService *AuditService) *TicketTemplateService {
+	return &TicketTemplateService{
+		db:           db,
+		auditService: auditService,
+	}
+}
+
+// TicketTemplateQuery 工单模板列表查询参数
+type TicketTemplateQuery struct {
+	Type     string `form:"type"`
+	Category string `form:"category"`
+}
+
+// CreateTicketTemplateRequest 创建工单模板请求
+type CreateTicketTemplateRequest struct {
+	Name         string                 `json:"name" binding:"required,max=200"`
+	Description  string                 `json:"description" binding:"max=500"`
+	Type         string                 `json:"type" binding:"required,oneof=bug feature support change custom"`
+	Priority     string                 `json:"priority" binding:"omitempty,oneof=low normal high critical"`
+	Category     string                 `json:"category" binding:"max=100"`
+	TitlePattern string                 `json:"title_pattern" binding:"max=500"`
+	Content      string                 `json:"content"`
+	CustomFields map[string]interface{} `json:"custom_fields"`
+	Tags         []string               `json:"tags"`
+	Checklist    []string               `json:"checklist"`
+	Visibility   string                 `json:"visibility" binding:"omitempty,oneof=personal shared"`
+	RoleIDs      []uint                 `json:"role_ids"` // 共享给的角色，为空时共享给所有用户
+	IsActive     *bool                  `json:"is_active"`
+}
+
+// UpdateTicketTemplateRequest 更新工单模板请求，字段为 nil 时不修改
+type UpdateTicketTemplateRequest struct {
+	Name         *string                `json:"name" binding:"omitempty,min=1,max=200"`
+	Description  *string                `json:"description" binding:"omitempty,max=500"`
+	Type         *string                `json:"type" binding:"omitempty,oneof=bug feature support change custom"`
+	Priority     *string                `json:"priority" binding:"omitempty,oneof=low normal high critical"`
+	Category     *string                `json:"category" binding:"omitempty,max=100"`
+	TitlePattern *string                `json:"title_pattern" binding:"omitempty,max=500"`
+	Content      *string                `json:"content"`
+	CustomFields map[string]interface{} `json:"custom_fields"`
+	Tags         []string               `json:"tags"`
+	Checklist    []string               `json:"checklist"`
+	Visibility   *string                `json:"visibility" binding:"omitempty,oneof=personal shared"`
+	RoleIDs      []uint                 `json:"role_ids"`
+	IsActive     *bool                  `json:"is_active"`
+}
+
+// TicketTemplateResponse 工单模板响应
+type TicketTemplateResponse struct {
+	ID           uint                   `json:"id"`
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description"`
+	Type         models.TicketType      `json:"type"`
+	Priority     models.TicketPriority  `json:"priority"`
+	Category     string                 `json:"category"`
+	TitlePattern string                 `json:"title_pattern"`
+	Content      string                 `json:"content"`
+	CustomFields map[string]interface{} `json:"custom_fields"`
+	Tags         []string               `json:"tags"`
+	Checklist    []string               `json:"checklist"`
+	Visibility   string                 `json:"visibility"`
+	RoleIDs      []uint                 `json:"role_ids"`
+	IsActive     bool                   `json:"is_active"`
+	CreatedBy    uint                   `json:"created_by"`
+	Creator      string                 `json:"creator"`
+	CreatedAt    string                 `json:"created_at"`
+	UpdatedAt    string                 `json:"updated_at"`
+}
+
+// TicketTemplateGroup 按分类分组的可用模板，未分类模板的分类为空
+type TicketTemplateGroup struct {
+	Category    string                   `json:"category"`
+	DisplayName string                   `json:"display_name"`
+	Color       string                   `json:"color"`
+	Templates   []TicketTemplateResponse `json:"templates"`
+}
+
+// TicketTemplateValues 创建工单时由模板填充的值，请求中已指定的值优先
+type TicketTemplateValues struct {
+	Title        string
+	Description  string
+	Type         string
+	Priority     string
+	Category     string
+	CustomFields map[string]interface{}
+	Tags         []string
+	Checklist    []string // 模板的检查项，仅作为输出
+}
+
+// ListTemplates 获取当前用户可用的模板（含停用模板）；canManage 时返回全部模板
+func (s *TicketTemplateService) ListTemplates(query *TicketTemplateQuery, userID uint, canManage bool) ([]TicketTemplateResponse, error) {
+	db := s.db.Preload("Creator")
+	if query.Type != "" {
+		db = db.Where("type = ?", query.Type)
+	}
+	if query.Category != "" {
+		db = db.Where("category = ?", query.Category)
+	}
+	if !canManage {
+		db = ticketTemplateAccessScope(db, userID)
+	}
+
+	var templates []models.TicketTemplate
+	if err := db.Order("name ASC, id ASC").Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("获取工单模板失败: %w", err)
+	}
+	return s.templateResponses(templates)
+}
+
+// AvailableTemplates 获取当前用户创建工单时可用的启用模板，按分类分组；分类按名称排序，未分类的模板排在最后
+func (s *TicketTemplateService) AvailableTemplates(userID uint) ([]TicketTemplateGroup, error) {
+	var templates []models.TicketTemplate
+	db := ticketTemplateAccessScope(s.db.Preload("Creator"), userID).Where("is_active = ?", true)
+	if err := db.Order("name ASC, id ASC").Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("获取工单模板失败: %w", err)
+	}
+	if len(templates) == 0 {
+		return []TicketTemplateGroup{}, nil
+	}
+	responses, err := s.templateResponses(templates)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := map[string]*TicketTemplateGroup{}
+	names := []string{}
+	for _, response := range responses {
+		group, ok := groups[response.Category]
+		if !ok {
+			group = &TicketTemplateGroup{Category: response.Category, DisplayName: response.Category}
+			groups[response.Category] = group
+			names = append(names, response.Category)
+		}
+		group.Templates = append(group.Templates, response)
+	}
+
+	var categories []models.TicketCategory
+	if err := s.db.Where("name IN ?", names).Find(&categories).Error; err != nil {
+		return nil, fmt.Errorf("获取工单分类失败: %w", err)
+	}
+	for _, category := range categories {
+		groups[category.Name].DisplayName = category.DisplayName
+		groups[category.Name].Color = category.Color
+	}
+	if group, ok := groups[""]; ok {
+		group.DisplayName = "未分类"
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if names[i] == "" || names[j] == "" {
+			return names[j] == ""
+		}
+		return names[i] < names[j]
+	})
+	result := make([]TicketTemplateGroup, len(names))
+	for i, name := range names {
+		result[i] = *groups[name]
+	}
+	return result, nil
+}
+
+// GetTemplate 获取模板详情
+func (s *TicketTemplateService) GetTemplate(id uint, userID uint, canManage bool) (*TicketTemplateResponse, error) {
+	template, err := findUsableTicketTemplate(s.db, id, userID, canManage)
+	if err != nil {
+		return nil, err
+	}
+	return s.templateResponse(template)
+}
+
+// CreateTemplate 创建工单模板；共享模板需要调用方校验权限
+func (s *TicketTemplateService) CreateTemplate(req *CreateTicketTemplateRequest, userID uint, ipAddress, userAgent string) (*TicketTemplateResponse, error) {
+	template := models.TicketTemplate{
+		Name:         strings.TrimSpace(req.Name),
+		Description:  req.Description,
+		Type:         models.TicketType(req.Type),
+		Priority:     models.TicketPriority(req.Priority),
+		Category:     strings.TrimSpace(req.Category),
+		TitlePattern: req.TitlePattern,
+		Content:      req.Content,
+		Visibility:   req.Visibility,
+		IsActive:     true,
+		CreatedBy:    userID,
+	}
+	if template.Name == "" {
+		return nil, fmt.Errorf("模板名称不能为空")
+	}
+	if template.Visibility == "" {
+		template.Visibility = models.TemplateVisibilityPersonal
+	}
+	if req.IsActive != nil {
+		template.IsActive = *req.IsActive
+	}
+	if err := ValidateTagNames(req.Tags); err != nil {
+		return nil, err
+	}
+	template.Tags = models.StringSlice(req.Tags)
+	checklist, err := normalizeTicketChecklist(req.Checklist)
+	if err != nil {
+		return nil, err
+	}
+	template.Checklist = checklist
+	if err := s.validateTemplate(&template, req.CustomFields); err != nil {
+		return nil, err
+	}
+
+	// 布尔字段有默认值，创建后会被回填为 true，false 需要单独更新
+	inactive := !template.IsActive
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Creator").Create(&template).Error; err != nil {
+			return fmt.Errorf("创建工单模板失败: %w", err)
+		}
+		if inactive {
+			template.IsActive = false
+			if err := tx.Model(&template).Update("is_active", false).Error; err != nil {
+				return fmt.Errorf("创建工单模板失败: %w", err)
+			}
+		}
+		return saveTicketTemplateRoles(tx, &template, req.RoleIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(userID, "CREATE", template.ID, nil, ticketTemplateAuditValues(&template), ipAddress, userAgent)
+	return s.templateResponse(&template)
+}
+
+// UpdateTemplate 更新工单模板，只有创建者或模板管理员可以修改；类型或分类变更时按新的字段定义重新校验预填值
+func (s *TicketTemplateService) UpdateTemplate(id uint, req *UpdateTicketTemplateRequest, userID uint, canManage bool, ipAddress, userAgent string) (*TicketTemplateResponse, error) {
+	template, err := s.findEditableTemplate(id, userID, canManage)
+	if err != nil {
+		return nil, err
+	}
+	oldValues := ticketTemplateAuditValues(template)
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, fmt.Errorf("模板名称不能为空")
+		}
+		template.Name = name
+	}
+	if req.Description != nil {
+		template.Description = *req.Description
+	}
+	if req.Type != nil {
+		template.Type = models.TicketType(*req.Type)
+	}
+	if req.Priority != nil {
+		template.Priority = models.TicketPriority(*req.Priority)
+	}
+	if req.Category != nil {
+		template.Category = strings.TrimSpace(*req.Category)
+	}
+	if req.TitlePattern != nil {
+		template.TitlePattern = *req.TitlePattern
+	}
+	if req.Content != nil {
+		template.Content = *req.Content
+	}
+	if req.Tags != nil {
+		if err := ValidateTagNames(req.Tags); err != nil {
+			return nil, err
+		}
+		template.Tags = models.StringSlice(req.Tags)
+	}
+	if req.Checklist != nil {
+		checklist, err := normalizeTicketChecklist(req.Checklist)
+		if err != nil {
+			return nil, err
+		}
+		template.Checklist = checklist
+	}
+	if req.Visibility != nil {
+		template.Visibility = *req.Visibility
+	}
+	if req.IsActive != nil {
+		template.IsActive = *req.IsActive
+	}
+	customFields := map[string]interface{}(template.CustomFields)
+	if req.CustomFields != nil {
+		customFields = req.CustomFields
+	}
+	if err := s.validateTemplate(template, customFields); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Creator").Save(template).Error; err != nil {
+			return fmt.Errorf("更新工单模板失败: %w", err)
+		}
+		if req.RoleIDs == nil && template.Visibility == models.TemplateVisibilityShared {
+			return nil
+		}
+		return saveTicketTemplateRoles(tx, template, req.RoleIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(userID, "UPDATE", template.ID, oldValues, ticketTemplateAuditValues(template), ipAddress, userAgent)
+	return s.templateResponse(template)
+}
+
+// DeleteTemplate 删除工单模板，只有创建者或模板管理员可以删除
+func (s *TicketTemplateService) DeleteTemplate(id uint, userID uint, canManage bool, ipAddress, userAgent string) error {
+	template, err := s.findEditableTemplate(id, userID, canManage)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("template_id = ?", template.ID).Delete(&models.TicketTemplateRole{}).Error; err != nil {
+			return fmt.Errorf("删除工单模板失败: %w", err)
+		}
+		if err := tx.Delete(&models.TicketTemplate{}, template.ID).Error; err != nil {
+			return fmt.Errorf("删除工单模板失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit(userID, "DELETE", template.ID, ticketTemplateAuditValues(template), nil, ipAddress, userAgent)
+	return nil
+}
+
+// validateTemplate 校验模板的分类与自定义字段预填值
+func (s *TicketTemplateService) validateTemplate(template *models.TicketTemplate, customFields map[string]interface{}) error {
+	if template.Category != "" {
+		var count int64
+		if err := s.db.Model(&models.TicketCategory{}).Where("name = ? AND is_active = ?", template.Category, true).Count(&count).Error; err != nil {
+			return fmt.Errorf("检查工单分类失败: %w", err)
+		}
+		if count == 0 {
+			return fmt.Errorf("工单分类不存在")
+		}
+	}
+	values, err := PrepareTemplateCustomFields(s.db, string(template.Type), template.Category, customFields)
+	if err != nil {
+		return err
+	}
+	template.CustomFields = values
+	return nil
+}
+
+// findEditableTemplate 查找当前用户可修改的模板
+func (s *TicketTemplateService) findEditableTemplate(id uint, userID uint, canManage bool) (*models.TicketTemplate, error) {
+	var template models.TicketTemplate
+	query := s.db.Preload("Creator")
+	if !canManage {
+		query = query.Where("created_by = ?", userID)
+	}
+	if err := query.First(&template, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("工单模板不存在或无权修改")
+		}
+		return nil, fmt.Errorf("获取工单模板失败: %w", err)
+	}
+	return &template, nil
+}
+
+// templateResponse 构建单个模板响应
+func (s *TicketTemplateService) templateResponse(template *models.TicketTemplate) (*TicketTemplateResponse, error) {
+	if template.Creator.ID == 0 {
+		if err := s.db.Preload("Creator").First(template, template.ID).Error; err != nil {
+			return nil, fmt.Errorf("获取工单模板失败: %w", err)
+		}
+	}
+	responses, err := s.templateResponses([]models.TicketTemplate{*template})
+	if err != nil {
+		return nil, err
+	}
+	return &responses[0], nil
+}
+
+// templateResponses 批量构建模板响应
+func (s *TicketTemplateService) templateResponses(templates []models.TicketTemplate) ([]TicketTemplateResponse, error) {
+	ids := make([]uint, len(templates))
+	for i, template := range templates {
+		ids[i] = template.ID
+	}
+	roles := map[uint][]uint{}
+	if len(ids) > 0 {
+		var rows []models.TicketTemplateRole
+		if err := s.db.Where("template_id IN ?", ids).Order("role_id ASC").Find(&rows).Error; err != nil {
+			return nil, fmt.Errorf("获取模板角色失败: %w", err)
+		}
+		for _, row := range rows {
+			roles[row.TemplateID] = append(roles[row.TemplateID], row.RoleID)
+		}
+	}
+
+	result := make([]TicketTemplateResponse, len(templates))
+	for i := range templates {
+		result[i] = newTicketTemplateResponse(&templates[i], roles[templates[i].ID])
+	}
+	return result, nil
+}
+
+// audit 记录工单模板审计日志
+func (s *TicketTemplateService) audit(userID uint, action string, templateID uint, oldValues, newValues map[string]interface{}, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	s.auditService.CreateAuditLog(&AuditLogRequest{
+		UserID:       userID,
+		Action:       action,
+		ResourceType: "ticket_template",
+		ResourceID:   templateID,
+		OldValues:    oldValues,
+		NewValues:    newValues,
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+	})
+}
+
+func ticketTemplateAuditValues(template *models.TicketTemplate) map[string]interface{} {
+	return map[string]interface{}{
+		"name":          template.Name,
+		"type":          template.Type,
+		"priority":      template.Priority,
+		"category":      template.Category,
+		"title_pattern": template.TitlePattern,
+		"custom_fields": template.CustomFields,
+		"tags":          template.Tags,
+		"checklist":     template.Checklist,
+		"visibility":    template.Visibility,
+		"is_active":     template.IsActive,
+	}
+}
+
+// newTicketTemplateResponse 转换模板响应
+func newTicketTemplateResponse(template *models.TicketTemplate, roleIDs []uint) TicketTemplateResponse {
+	if roleIDs == nil {
+		roleIDs = []uint{}
+	}
+	customFields := map[string]interface{}(template.CustomFields)
+	if customFields == nil {
+		customFields = map[string]interface{}{}
+	}
+	tags := []string(template.Tags)
+	if tags == nil {
+		tags = []string{}
+	}
+	checklist := []string(template.Checklist)
+	if checklist == nil {
+		checklist = []string{}
+	}
+	return TicketTemplateResponse{
+		ID:           template.ID,
+		Name:         template.Name,
+		Description:  template.Description,
+		Type:         template.Type,
+		Priority:     template.Priority,
+		Category:     template.Category,
+		TitlePattern: template.TitlePattern,
+		Content:      template.Content,
+		CustomFields: customFields,
+		Tags:         tags,
+		Checklist:    checklist,
+		Visibility:   template.Visibility,
+		RoleIDs:      roleIDs,
+		IsActive:     template.IsActive,
+		CreatedBy:    template.CreatedBy,
+		Creator:      template.Creator.Username,
+		CreatedAt:    template.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:    template.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// normalizeTicketChecklist 去除检查项首尾空白并校验数量与长度
+func normalizeTicketChecklist(items []string) (models.StringSlice, error) {
+	if len(items) > ticketChecklistMaxItems {
+		return nil, fmt.Errorf("检查项不能超过 %d 项", ticketChecklistMaxItems)
+	}
+	result := make(models.StringSlice, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			return nil, fmt.Errorf("检查项不能为空")
+		}
+		if utf8.RuneCountInString(item) > ticketChecklistMaxLength {
+			return nil, fmt.Errorf("检查项不能超过 %d 个字符", ticketChecklistMaxLength)
+		}
+		result = append(result, item)
+	}
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return result, nil
+}
+
+// saveTicketTemplateRoles 保存共享模板的角色；个人模板清空角色
+func saveTicketTemplateRoles(tx *gorm.DB, template *models.TicketTemplate, roleIDs []uint) error {
+	if err := tx.Where("template_id = ?", template.ID).Delete(&models.TicketTemplateRole{}).Error; err != nil {
+		return fmt.Errorf("保存模板角色失败: %w", err)
+	}
+	if template.Visibility != models.TemplateVisibilityShared {
+		return nil
+	}
+
+	roleIDs = uniqueUints(roleIDs)
+	if len(roleIDs) == 0 {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&models.Role{}).Where("id IN ?", roleIDs).Count(&count).Error; err != nil {
+		return fmt.Errorf("保存模板角色失败: %w", err)
+	}
+	if count != int64(len(roleIDs)) {
+		return fmt.Errorf("角色不存在")
+	}
+	rows := make([]models.TicketTemplateRole, len(roleIDs))
+	for i, roleID := range roleIDs {
+		rows[i] = models.TicketTemplateRole{TemplateID: template.ID, RoleID: roleID}
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return fmt.Errorf("保存模板角色失败: %w", err)
+	}
+	return nil
+}
+
+// ticketTemplateAccessScope 限定为用户可用的模板：本人创建，或共享且未限定角色/共享给用户所属角色
+func ticketTemplateAccessScope(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Where(`ticket_templates.created_by = ? OR (ticket_templates.visibility = ? AND (
+		NOT EXISTS (SELECT 1 FROM ticket_template_roles ttr WHERE ttr.template_id = ticket_templates.id) OR
+		EXISTS (SELECT 1 FROM ticket_template_roles ttr JOIN user_roles ur ON ur.role_id = ttr.role_id
+			WHERE ttr.template_id = ticket_templates.id AND ur.user_id = ?)))`,
+		userID, models.TemplateVisibilityShared, userID)
+}
+
+// findUsableTicketTemplate 查找用户可用的模板
+func findUsableTicketTemplate(db *gorm.DB, id uint, userID uint, canManage bool) (*models.TicketTemplate, error) {
+	query := db.Preload("Creator")
+	if !canManage {
+		query = ticketTemplateAccessScope(query, userID)
+	}
+	var template models.TicketTemplate
+	if err := query.First(&template, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("工单模板不存在或无权使用")
+		}
+		return nil, fmt.Errorf("获取工单模板失败: %w", err)
+	}
+	return &template, nil
+}
+
+// ApplyTicketTemplate 用模板填充创建工单的值：请求中已指定的值优先，自定义字段逐项覆盖模板预填值，
+// 未指定标题、描述和标签时使用模板的标题模式、预填描述和默认标签
+func ApplyTicketTemplate(db *gorm.DB, templateID uint, userID uint, values *TicketTemplateValues) error {
+	template, err := findUsableTicketTemplate(db, templateID, userID, false)
+	if err != nil {
+		return err
+	}
+	if !template.IsActive {
+		return fmt.Errorf("工单模板已停用")
+	}
+	if values.Type != "" && values.Type != string(template.Type) {
+		return fmt.Errorf("模板不属于工单类型 %s", values.Type)
+	}
+	values.Type = string(template.Type)
+	if values.Priority == "" {
+		values.Priority = string(template.Priority)
+	}
+	if strings.TrimSpace(values.Category) == "" {
+		values.Category = template.Category
+	}
+
+	customFields := map[string]interface{}{}
+	for key, value := range template.CustomFields {
+		customFields[key] = value
+	}
+	for key, value := range values.CustomFields {
+		customFields[key] = value
+	}
+	values.CustomFields = customFields
+
+	if strings.TrimSpace(values.Title) == "" {
+		var user models.User
+		db.Select("id", "username").First(&user, userID)
+		values.Title = renderTitlePattern(template.TitlePattern, time.Now(), user.Username, string(template.Type))
+	}
+	if strings.TrimSpace(values.Description) == "" {
+		values.Description = template.Content
+	}
+	if values.Tags == nil {
+		values.Tags = append([]string{}, template.Tags...)
+	}
+	values.Checklist = append([]string{}, template.Checklist...)
+	return nil
+}
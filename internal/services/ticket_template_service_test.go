@@ -0,0 +1,144 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTicketTemplateTest(t *testing.T) *TicketTemplateService {
+	workflow := setupTicketWorkflowTest(t)
+	categories := NewTicketCategoryService(workflow.db, nil)
+	_, err := categories.CreateCategory(&SaveTicketCategoryRequest{Name: "network", DisplayName: "网络", CustomFields: fieldConfig(
+		map[string]interface{}{"name": "site", "label": "机房", "type": "select", "options": []interface{}{"bj", "sh"}, "required": true},
+		map[string]interface{}{"name": "ports", "type": "number"},
+	)}, 1, "", "")
+	require.NoError(t, err)
+	_, err = categories.CreateCategory(&SaveTicketCategoryRequest{Name: "account", DisplayName: "账号"}, 1, "", "")
+	require.NoError(t, err)
+	return NewTicketTemplateService(workflow.db, workflow.auditService)
+}
+
+func TestTicketTemplateService_CRUDAndScope(t *testing.T) {
+	s := setupTicketTemplateTest(t)
+	grantRole(t, s.db, 3, "network_ops")
+	var role models.Role
+	require.NoError(t, s.db.Where("name = ?", "network_ops").First(&role).Error)
+
+	_, err := s.CreateTemplate(&CreateTicketTemplateRequest{Name: "x", Type: "bug", Category: "missing"}, 1, "", "")
+	assert.EqualError(t, err, "工单分类不存在")
+	_, err = s.CreateTemplate(&CreateTicketTemplateRequest{Name: "x", Type: "bug", Category: "network", CustomFields: map[string]interface{}{"site": "gz"}}, 1, "", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "自定义字段无效")
+	_, err = s.CreateTemplate(&CreateTicketTemplateRequest{Name: "x", Type: "bug", Checklist: []string{"检查", " "}}, 1, "", "")
+	assert.EqualError(t, err, "检查项不能为空")
+
+	// 必填字段在模板中可以留空
+	shared, err := s.CreateTemplate(&CreateTicketTemplateRequest{
+		Name: "交换机故障", Type: "bug", Priority: "high", Category: "network",
+		TitlePattern: "{{user}} 报告交换机故障", Content: "故障现象：",
+		CustomFields: map[string]interface{}{"ports": "48"}, Tags: []string{"网络"},
+		Checklist: []string{" 确认链路灯 ", "重启端口"}, Visibility: models.TemplateVisibilityShared, RoleIDs: []uint{role.ID},
+	}, 1, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"ports": float64(48)}, shared.CustomFields)
+	assert.Equal(t, []string{"确认链路灯", "重启端口"}, shared.Checklist)
+	assert.Equal(t, []uint{role.ID}, shared.RoleIDs)
+
+	inactive := false
+	_, err = s.CreateTemplate(&CreateTicketTemplateRequest{Name: "旧模板", Type: "support", Visibility: models.TemplateVisibilityShared, IsActive: &inactive}, 1, "", "")
+	require.NoError(t, err)
+	personal, err := s.CreateTemplate(&CreateTicketTemplateRequest{Name: "重置密码", Type: "support", Category: "account"}, 2, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, models.TemplateVisibilityPersonal, personal.Visibility)
+	general, err := s.CreateTemplate(&CreateTicketTemplateRequest{Name: "咨询", Type: "support", Visibility: models.TemplateVisibilityShared}, 1, "", "")
+	require.NoError(t, err)
+
+	names := func(templates []TicketTemplateResponse) []string {
+		result := []string{}
+		for _, template := range templates {
+			result = append(result, template.Name)
+		}
+		return result
+	}
+	all, err := s.ListTemplates(&TicketTemplateQuery{}, 1, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"交换机故障", "咨询", "旧模板", "重置密码"}, names(all))
+	visible, err := s.ListTemplates(&TicketTemplateQuery{}, 2, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"咨询", "旧模板", "重置密码"}, names(visible))
+
+	// 可用模板只含启用模板，按分类分组，未分类排在最后
+	groups, err := s.AvailableTemplates(3)
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	assert.Equal(t, "network", groups[0].Category)
+	assert.Equal(t, "网络", groups[0].DisplayName)
+	assert.Equal(t, []string{"交换机故障"}, names(groups[0].Templates))
+	assert.Equal(t, "未分类", groups[1].DisplayName)
+	assert.Equal(t, []string{"咨询"}, names(groups[1].Templates))
+	groups, err = s.AvailableTemplates(2)
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	assert.Equal(t, "账号", groups[0].DisplayName)
+
+	_, err = s.GetTemplate(shared.ID, 2, false)
+	assert.EqualError(t, err, "工单模板不存在或无权使用")
+	_, err = s.UpdateTemplate(personal.ID, &UpdateTicketTemplateRequest{Content: &personal.Name}, 3, false, "", "")
+	assert.EqualError(t, err, "工单模板不存在或无权修改")
+
+	// 分类变更后按新分类的字段定义重新校验预填值
+	account := "account"
+	_, err = s.UpdateTemplate(shared.ID, &UpdateTicketTemplateRequest{Category: &account}, 1, true, "", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "未定义的字段 ports")
+	updated, err := s.UpdateTemplate(shared.ID, &UpdateTicketTemplateRequest{Category: &account, CustomFields: map[string]interface{}{}}, 1, true, "", "")
+	require.NoError(t, err)
+	assert.Empty(t, updated.CustomFields)
+	assert.Equal(t, []uint{role.ID}, updated.RoleIDs)
+
+	require.NoError(t, s.DeleteTemplate(general.ID, 1, true, "", ""))
+	var count int64
+	s.db.Model(&models.AuditLog{}).Where("resource_type = ?", "ticket_template").Count(&count)
+	assert.Equal(t, int64(6), count)
+}
+
+func TestApplyTicketTemplate(t *testing.T) {
+	s := setupTicketTemplateTest(t)
+	template, err := s.CreateTemplate(&CreateTicketTemplateRequest{
+		Name: "交换机故障", Type: "bug", Priority: "high", Category: "network",
+		TitlePattern: "{{user}} {{date}} 交换机故障", Content: "故障现象：",
+		CustomFields: map[string]interface{}{"site": "bj", "ports": 24}, Tags: []string{"网络"},
+		Checklist: []string{"确认链路灯"}, Visibility: models.TemplateVisibilityShared,
+	}, 1, "", "")
+	require.NoError(t, err)
+
+	values := TicketTemplateValues{Type: "feature"}
+	assert.EqualError(t, ApplyTicketTemplate(s.db, template.ID, 2, &values), "模板不属于工单类型 feature")
+
+	values = TicketTemplateValues{Description: "核心交换机掉线", CustomFields: map[string]interface{}{"site": "sh"}}
+	require.NoError(t, ApplyTicketTemplate(s.db, template.ID, 2, &values))
+	assert.Equal(t, "alice "+time.Now().Format("2006-01-02")+" 交换机故障", values.Title)
+	assert.Equal(t, "核心交换机掉线", values.Description)
+	assert.Equal(t, "bug", values.Type)
+	assert.Equal(t, "high", values.Priority)
+	assert.Equal(t, "network", values.Category)
+	assert.Equal(t, map[string]interface{}{"site": "sh", "ports": float64(24)}, values.CustomFields)
+	assert.Equal(t, []string{"网络"}, values.Tags)
+	assert.Equal(t, []string{"确认链路灯"}, values.Checklist)
+
+	// 填充后的值仍按工单的字段定义校验
+	fields, err := PrepareTicketCustomFields(s.db, values.Type, values.Category, nil, values.CustomFields)
+	require.NoError(t, err)
+	assert.Equal(t, "sh", fields["site"])
+
+	inactive := false
+	_, err = s.UpdateTemplate(template.ID, &UpdateTicketTemplateRequest{IsActive: &inactive}, 1, true, "", "")
+	require.NoError(t, err)
+	values = TicketTemplateValues{}
+	assert.EqualError(t, ApplyTicketTemplate(s.db, template.ID, 2, &values), "工单模板已停用")
+}
@@ -0,0 +1,1155 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// TicketWorkflowService 工单流程引擎，所有工单状态变更都按流程定义校验并执行
+type TicketWorkflowService struct {
+	db           *gorm.DB
+	auditService *AuditService
+	watchService *WatchService
+	slaService   *TicketSLAService
+}
+
+// NewTicketWorkflowService 创建工单流程服务，slaService 为空时不计算 SLA
+func NewTicketWorkflowService(db *gorm.DB, auditService *AuditService, watchService *WatchService, slaService *TicketSLAService) *TicketWorkflowService {
+	return &TicketWorkflowService{
+		db:           db,
+		auditService: auditService,
+		watchService: watchService,
+		slaService:   slaService,
+	}
+}
+
+// 工单流程中的特殊角色
+const (
+	TicketRoleCreator  = "creator"  // 工单创建者
+	TicketRoleAssignee = "assignee" // 当前处理人
+)
+
+// 流转可要求的必填字段
+const (
+	TicketFieldComment    = "comment"    // 备注
+	TicketFieldResolution = "resolution" // 解决说明
+	TicketFieldAssignee   = "assignee"   // 处理人（请求中指定或工单已有）
+)
+
+// 进入状态时可执行的动作
+const (
+	TicketEntryNotify     = "notify"       // 通知指定对象
+	TicketEntryAssign     = "assign"       // 工单无处理人时自动分配
+	TicketEntrySetDueDate = "set_due_date" // 设置截止时间
+	TicketEntryTransition = "transition"   // 自动执行下一个流转
+)
+
+// TicketTimeoutAction 处理超时任务执行的流转动作，流程中未定义该动作时超时的工单保持原状态
+const TicketTimeoutAction = "timeout"
+
+// TicketSurveyReopenAction 满意度差评时执行的流转动作，流程中未定义该动作时改为通知负责人
+const TicketSurveyReopenAction = "survey_reopen"
+
+// maxTicketTransitionChain 进入状态后自动流转的最大连续次数，避免流程定义成环
+const maxTicketTransitionChain = 5
+
+// defaultTicketWorkflowName 未配置任何流程时使用的内置流程名称
+const defaultTicketWorkflowName = "内置默认流程"
+
+// TicketWorkflowDefinition 工单流程定义
+type TicketWorkflowDefinition struct {
+	InitialState string                         `json:"initial_state"`
+	States       []string                       `json:"states"`
+	Transitions  []TicketWorkflowTransition     `json:"transitions"`
+	OnEnter      map[string][]TicketEntryAction `json:"on_enter,omitempty"` // 按状态配置进入时的动作
+}
+
+// TicketWorkflowTransition 工单状态流转定义
+type TicketWorkflowTransition struct {
+	Action         string         `json:"action"`                    // 动作标识，如 assign、accept、resolve
+	Label          string         `json:"label,omitempty"`           // 展示名称
+	From           WorkflowStates `json:"from"`                      // 允许的源状态，"*" 表示任意状态
+	To             string         `json:"to"`                        // 目标状态
+	Roles          []string       `json:"roles,omitempty"`           // 可执行的角色，支持 creator、assignee 与角色名
+	Permissions    []string       `json:"permissions,omitempty"`     // 具备任一权限即可执行；角色与权限都为空时创建者、处理人可执行
+	RequiredFields []string       `json:"required_fields,omitempty"` // 必填字段：comment、resolution、assignee
+	ClearAssignee  bool           `json:"clear_assignee,omitempty"`  // 流转后清空处理人
+	SystemOnly     bool           `json:"system_only,omitempty"`     // 仅由系统自动执行，如处理超时
+}
+
+// TicketEntryAction 进入状态时执行的动作
+type TicketEntryAction struct {
+	Type    string   `json:"type"`
+	Targets []string `json:"targets,omitempty"` // notify：creator、assignee、role:<角色名>、user:<用户ID>
+	UserID  uint     `json:"user_id,omitempty"` // assign：指定处理人
+	Role    string   `json:"role,omitempty"`    // assign：分配给该角色中未完成工单最少的用户
+	Hours   int      `json:"hours,omitempty"`   // set_due_date：截止时间为进入状态后若干小时
+	Action  string   `json:"action,omitempty"`  // transition：自动执行的流转动作
+}
+
+// TicketActor 工单流转的操作者
+type TicketActor struct {
+	UserID        uint
+	System        bool                         // 系统自动操作，不校验角色与权限
+	HasPermission func(permission string) bool // 为空时按数据库中的角色权限判断
+}
+
+// SystemActor 以系统账号执行自动流转的操作者，历史与审计记在该账号下
+func (s *TicketWorkflowService) SystemActor() (*TicketActor, error) {
+	userID, err := SystemUserID(s.db)
+	if err != nil {
+		return nil, err
+	}
+	return &TicketActor{UserID: userID, System: true}, nil
+}
+
+// TicketTransitionRequest 工单状态流转请求，动作与目标状态二选一
+type TicketTransitionRequest struct {
+	Action     string `json:"action"`
+	ToStatus   string `json:"to_status"`
+	Comment    string `json:"comment" binding:"max=2000"`
+	Resolution string `json:"resolution" binding:"max=5000"`
+	AssigneeID *uint  `json:"assignee_id"`
+}
+
+// TicketActionResponse 当前用户可执行的工单动作
+type TicketActionResponse struct {
+	Action         string   `json:"action"`
+	Label          string   `json:"label"`
+	To             string   `json:"to"`
+	RequiredFields []string `json:"required_fields"`
+}
+
+// TicketActionsResponse 工单可执行动作列表
+type TicketActionsResponse struct {
+	TicketID     uint                   `json:"ticket_id"`
+	Status       string                 `json:"status"`
+	WorkflowID   uint                   `json:"workflow_id"` // 0 表示内置默认流程
+	WorkflowName string                 `json:"workflow_name"`
+	Actions      []TicketActionResponse `json:"actions"`
+}
+
+// SaveTicketWorkflowRequest 创建或更新工单流程请求
+type SaveTicketWorkflowRequest struct {
+	Name        string                 `json:"name" binding:"required,max=200"`
+	Description string                 `json:"description" binding:"max=500"`
+	TicketType  string                 `json:"ticket_type" binding:"max=20"`
+	Category    string                 `json:"category" binding:"max=100"`
+	Definition  map[string]interface{} `json:"definition" binding:"required"`
+	IsActive    *bool                  `json:"is_active"`
+}
+
+// DefaultTicketWorkflow 内置默认流程，与原有工单状态流转及权限保持一致
+func DefaultTicketWorkflow() *TicketWorkflowDefinition {
+	return &TicketWorkflowDefinition{
+		InitialState: string(models.TicketStatusSubmitted),
+		States: []string{"submitted", "assigned", "accepted", "approved", "progress",
+			"pending", "resolved", "closed", "rejected", "returned"},
+		Transitions: []TicketWorkflowTransition{
+			{Action: "assign", Label: "分配工单", From: WorkflowStates{"submitted", "assigned", "pending"}, To: "assigned",
+				Permissions: []string{"ticket:assign"}, RequiredFields: []string{TicketFieldAssignee}},
+			{Action: "assign_accept", Label: "分配并接受", From: WorkflowStates{"submitted", "assigned", "accepted", "approved", "progress"}, To: "accepted",
+				Permissions: []string{"ticket:assign"}, RequiredFields: []string{TicketFieldAssignee}},
+			{Action: "accept", Label: "接受工单", From: WorkflowStates{"assigned"}, To: "accepted",
+				Roles: []string{TicketRoleAssignee}},
+			{Action: "reject", Label: "拒绝工单", From: WorkflowStates{"submitted", "assigned", "accepted", "approved"}, To: "rejected",
+				Roles: []string{TicketRoleAssignee}, Permissions: []string{"ticket:approve", "ticket:reject_all"}},
+			{Action: "approve", Label: "审批通过", From: WorkflowStates{"accepted"}, To: "approved",
+				Permissions: []string{"ticket:approve"}},
+			{Action: "return", Label: "退回工单", From: WorkflowStates{"assigned", "accepted", "approved", "progress", "resolved"}, To: "returned",
+				Permissions: []string{"ticket:approve"}},
+			{Action: "start", Label: "开始处理", From: WorkflowStates{"approved"}, To: "progress",
+				Roles: []string{TicketRoleAssignee}, Permissions: []string{"ticket:process"}},
+			{Action: "suspend", Label: "挂起工单", From: WorkflowStates{"progress"}, To: "pending",
+				Roles: []string{TicketRoleAssignee}},
+			{Action: "resume", Label: "继续处理", From: WorkflowStates{"pending"}, To: "progress",
+				Roles: []string{TicketRoleAssignee}, Permissions: []string{"ticket:process"}},
+			{Action: "resolve", Label: "解决工单", From: WorkflowStates{"progress", "pending"}, To: "resolved",
+				Roles: []string{TicketRoleAssignee}, Permissions: []string{"ticket:process"}},
+			{Action: "close", Label: "关闭工单", From: WorkflowStates{"resolved"}, To: "closed",
+				Roles: []string{TicketRoleCreator, TicketRoleAssignee}, Permissions: []string{"ticket:close"}},
+			{Action: "reopen", Label: "重新打开", From: WorkflowStates{"closed"}, To: "submitted",
+				Roles: []string{TicketRoleCreator, TicketRoleAssignee}, Permissions: []string{"ticket:reopen", "ticket:reopen_all"}},
+			{Action: "resubmit", Label: "重新提交", From: WorkflowStates{"rejected", "returned"}, To: "submitted",
+				Roles: []string{TicketRoleCreator}, Permissions: []string{"ticket:resubmit_all"}, ClearAssignee: true},
+			{Action: TicketTimeoutAction, Label: "超时关闭", From: WorkflowStates{"progress"}, To: "closed", SystemOnly: true},
+			{Action: TicketSurveyReopenAction, Label: "差评重新打开", From: WorkflowStates{"resolved", "closed"}, To: "submitted", SystemOnly: true},
+		},
+		OnEnter: map[string][]TicketEntryAction{
+			// 审批通过后自动进入处理阶段
+			"approved": {{Type: TicketEntryTransition, Action: "start"}},
+		},
+	}
+}
+
+// ParseTicketWorkflow 解析并校验工单流程定义，为空时返回内置默认流程
+func ParseTicketWorkflow(raw models.JSONB) (*TicketWorkflowDefinition, error) {
+	if len(raw) == 0 {
+		return DefaultTicketWorkflow(), nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("流程定义格式错误: %w", err)
+	}
+	var workflow TicketWorkflowDefinition
+	if err := json.Unmarshal(data, &workflow); err != nil {
+		return nil, fmt.Errorf("流程定义格式错误: %w", err)
+	}
+
+	if len(workflow.States) == 0 {
+		return nil, fmt.Errorf("流程定义必须包含状态列表")
+	}
+	states := make(map[string]bool, len(workflow.States))
+	for _, state := range workflow.States {
+		if state == "" || len(state) > 20 {
+			return nil, fmt.Errorf("无效的状态名: %q", state)
+		}
+		if states[state] {
+			return nil, fmt.Errorf("状态 %s 重复", state)
+		}
+		states[state] = true
+	}
+	if workflow.InitialState == "" {
+		workflow.InitialState = workflow.States[0]
+	}
+	if !states[workflow.InitialState] {
+		return nil, fmt.Errorf("初始状态 %s 不在状态列表中", workflow.InitialState)
+	}
+
+	actions := make(map[string]bool, len(workflow.Transitions))
+	for i, t := range workflow.Transitions {
+		if !states[t.To] {
+			return nil, fmt.Errorf("第 %d 个流转的目标状态 %s 不在状态列表中", i+1, t.To)
+		}
+		if len(t.From) == 0 {
+			return nil, fmt.Errorf("第 %d 个流转缺少源状态", i+1)
+		}
+		for _, from := range t.From {
+			if from != "*" && !states[from] {
+				return nil, fmt.Errorf("第 %d 个流转的源状态 %s 不在状态列表中", i+1, from)
+			}
+		}
+		for _, field := range t.RequiredFields {
+			if field != TicketFieldComment && field != TicketFieldResolution && field != TicketFieldAssignee {
+				return nil, fmt.Errorf("第 %d 个流转的必填字段 %s 无效", i+1, field)
+			}
+		}
+		if t.Action == "" {
+			workflow.Transitions[i].Action = t.To
+		}
+		if t.Label == "" {
+			workflow.Transitions[i].Label = workflow.Transitions[i].Action
+		}
+		actions[workflow.Transitions[i].Action] = true
+	}
+
+	for state, entries := range workflow.OnEnter {
+		if !states[state] {
+			return nil, fmt.Errorf("进入动作配置的状态 %s 不在状态列表中", state)
+		}
+		for _, entry := range entries {
+			if err := validateTicketEntryAction(state, &entry, actions); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &workflow, nil
+}
+
+// validateTicketEntryAction 校验进入状态时的动作配置
+func validateTicketEntryAction(state string, entry *TicketEntryAction, actions map[string]bool) error {
+	switch entry.Type {
+	case TicketEntryNotify:
+		if len(entry.Targets) == 0 {
+			return fmt.Errorf("状态 %s 的通知动作缺少通知对象", state)
+		}
+		for _, target := range entry.Targets {
+			if !isValidTicketTarget(target) {
+				return fmt.Errorf("状态 %s 的通知对象 %s 无效", state, target)
+			}
+		}
+	case TicketEntryAssign:
+		if entry.UserID == 0 && entry.Role == "" {
+			return fmt.Errorf("状态 %s 的分配动作需要指定用户或角色", state)
+		}
+	case TicketEntrySetDueDate:
+		if entry.Hours <= 0 {
+			return fmt.Errorf("状态 %s 的截止时间动作需要指定大于0的小时数", state)
+		}
+	case TicketEntryTransition:
+		if !actions[entry.Action] {
+			return fmt.Errorf("状态 %s 的自动流转动作 %s 不在流转列表中", state, entry.Action)
+		}
+	default:
+		return fmt.Errorf("状态 %s 的进入动作类型 %s 无效", state, entry.Type)
+	}
+	return nil
+}
+
+// isValidTicketTarget 检查 creator、assignee、role:<角色名>、user:<用户ID> 形式的对象
+func isValidTicketTarget(target string) bool {
+	if target == TicketRoleCreator || target == TicketRoleAssignee {
+		return true
+	}
+	if name, ok := strings.CutPrefix(target, "role:"); ok {
+		return name != ""
+	}
+	if id, ok := strings.CutPrefix(target, "user:"); ok {
+		_, err := strconv.ParseUint(id, 10, 32)
+		return err == nil
+	}
+	return false
+}
+
+// HasState 状态是否在流程中
+func (w *TicketWorkflowDefinition) HasState(state string) bool {
+	for _, s := range w.States {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsFrom 流转是否允许从指定状态发起
+func (t *TicketWorkflowTransition) AllowsFrom(status string) bool {
+	for _, f := range t.From {
+		if f == "*" || f == status {
+			return true
+		}
+	}
+	return false
+}
+
+// findTransition 查找从当前状态出发、匹配动作或目标状态的流转，多个候选时优先返回操作者可执行的
+func (w *TicketWorkflowDefinition) findTransition(from, action, to string, allowed func(*TicketWorkflowTransition) bool) *TicketWorkflowTransition {
+	var first *TicketWorkflowTransition
+	for i := range w.Transitions {
+		t := &w.Transitions[i]
+		if action != "" && t.Action != action {
+			continue
+		}
+		if action == "" && t.To != to {
+			continue
+		}
+		if !t.AllowsFrom(from) {
+			continue
+		}
+		if allowed(t) {
+			return t
+		}
+		if first == nil {
+			first = t
+		}
+	}
+	return first
+}
+
+// toJSONB 将流程定义转换为存储格式
+func (w *TicketWorkflowDefinition) toJSONB() (models.JSONB, error) {
+	data, err := json.Marshal(w)
+	if err != nil {
+		return nil, fmt.Errorf("流程定义格式错误: %w", err)
+	}
+	var raw models.JSONB
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("流程定义格式错误: %w", err)
+	}
+	return raw, nil
+}
+
+// ResolveWorkflow 按工单类型与分类匹配启用的流程：类型+分类 > 类型 > 分类 > 默认，均未配置时使用内置流程
+func (s *TicketWorkflowService) ResolveWorkflow(ticketType, category string) (*models.TicketWorkflow, *TicketWorkflowDefinition, error) {
+	var candidates []models.TicketWorkflow
+	err := s.db.Where("is_active = ?", true).
+		Where("ticket_type = ? OR ticket_type = '' OR ticket_type IS NULL", ticketType).
+		Where("category = ? OR category = '' OR category IS NULL", category).
+		Order("id ASC").
+		Find(&candidates).Error
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取工单流程失败: %w", err)
+	}
+
+	var best *models.TicketWorkflow
+	bestScore := -1
+	for i := range candidates {
+		score := 0
+		if candidates[i].TicketType != "" {
+			score += 2
+		}
+		if candidates[i].Category != "" {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = &candidates[i], score
+		}
+	}
+	if best == nil {
+		return nil, DefaultTicketWorkflow(), nil
+	}
+
+	workflow, err := ParseTicketWorkflow(best.Definition)
+	if err != nil {
+		return nil, nil, fmt.Errorf("工单流程「%s」定义无效: %w", best.Name, err)
+	}
+	return best, workflow, nil
+}
+
+// InitialStatus 获取新工单的初始状态
+func (s *TicketWorkflowService) InitialStatus(ticketType, category string) models.TicketStatus {
+	_, workflow, err := s.ResolveWorkflow(ticketType, category)
+	if err != nil {
+		return models.TicketStatusSubmitted
+	}
+	return models.TicketStatus(workflow.InitialState)
+}
+
+// AvailableActions 获取操作者对工单当前可执行的动作
+func (s *TicketWorkflowService) AvailableActions(ticketID uint, actor *TicketActor) (*TicketActionsResponse, error) {
+	ticket, err := s.findTicket(ticketID)
+	if err != nil {
+		return nil, err
+	}
+	definition, workflow, err := s.ResolveWorkflow(string(ticket.Type), ticket.Category)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &TicketActionsResponse{
+		TicketID:     ticket.ID,
+		Status:       string(ticket.Status),
+		WorkflowName: defaultTicketWorkflowName,
+		Actions:      []TicketActionResponse{},
+	}
+	if definition != nil {
+		response.WorkflowID = definition.ID
+		response.WorkflowName = definition.Name
+	}
+	for i := range workflow.Transitions {
+		t := &workflow.Transitions[i]
+		if !t.AllowsFrom(string(ticket.Status)) || !s.canPerform(t, ticket, actor) {
+			continue
+		}
+		required := t.RequiredFields
+		if required == nil {
+			required = []string{}
+		}
+		response.Actions = append(response.Actions, TicketActionResponse{
+			Action:         t.Action,
+			Label:          t.Label,
+			To:             t.To,
+			RequiredFields: required,
+		})
+	}
+
+	if len(response.Actions) == 0 && !s.canView(ticket, actor) {
+		return nil, fmt.Errorf("工单不存在或无权访问")
+	}
+	return response, nil
+}
+
+// Transition 按流程执行工单状态流转，返回流转后的工单
+func (s *TicketWorkflowService) Transition(ticketID uint, req *TicketTransitionRequest, actor *TicketActor, ipAddress, userAgent string) (*models.Ticket, error) {
+	if req.Action == "" && req.ToStatus == "" {
+		return nil, fmt.Errorf("必须指定动作或目标状态")
+	}
+
+	ticket, err := s.findTicket(ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.transition(ticket, req, actor, ipAddress, userAgent, 0); err != nil {
+		return nil, err
+	}
+
+	var result models.Ticket
+	if err := s.db.Preload("Creator").Preload("Assignee").First(&result, ticket.ID).Error; err != nil {
+		return nil, fmt.Errorf("获取工单失败: %w", err)
+	}
+	return &result, nil
+}
+
+// Assign 分配工单：首次分配执行 assign，自动接受或重新分配已接受的工单时执行 assign_accept；
+// 分配给当前处理人且不自动接受时不做变更
+func (s *TicketWorkflowService) Assign(ticketID, assigneeID uint, autoAccept bool, comment string, actor *TicketActor, ipAddress, userAgent string) (*models.Ticket, error) {
+	ticket, err := s.findTicket(ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if ticket.AssigneeID != nil && *ticket.AssigneeID == assigneeID && !autoAccept {
+		s.db.Preload("Creator").Preload("Assignee").First(ticket, ticket.ID)
+		return ticket, nil
+	}
+
+	action := "assign"
+	switch ticket.Status {
+	case models.TicketStatusAccepted, models.TicketStatusApproved, models.TicketStatusInProgress:
+		// 重新分配时保持已接受状态，避免流程倒退
+		action = "assign_accept"
+	}
+	if autoAccept {
+		action = "assign_accept"
+	}
+
+	return s.Transition(ticketID, &TicketTransitionRequest{Action: action, Comment: comment, AssigneeID: &assigneeID}, actor, ipAddress, userAgent)
+}
+
+// AutoProgressTickets 重试停留在配置了自动流转的状态中的工单（如审批通过后自动开始处理），返回已推进的工单数
+func (s *TicketWorkflowService) AutoProgressTickets() (int, error) {
+	states, err := s.autoTransitionStates()
+	if err != nil || len(states) == 0 {
+		return 0, err
+	}
+
+	var tickets []models.Ticket
+	if err := s.db.Where("status IN ?", states).Order("id ASC").Find(&tickets).Error; err != nil {
+		return 0, fmt.Errorf("查询待推进工单失败: %w", err)
+	}
+	actor, err := s.SystemActor()
+	if err != nil {
+		return 0, err
+	}
+	progressed := 0
+	for i := range tickets {
+		ticket := &tickets[i]
+		_, workflow, err := s.ResolveWorkflow(string(ticket.Type), ticket.Category)
+		if err != nil {
+			continue
+		}
+		for _, entry := range workflow.OnEnter[string(ticket.Status)] {
+			if entry.Type != TicketEntryTransition {
+				continue
+			}
+			// 不满足条件的流转保持原状态，下次运行时重试
+			if err := s.transition(ticket, &TicketTransitionRequest{Action: entry.Action, Comment: "自动推进"}, actor, "", "", 0); err == nil {
+				progressed++
+				break
+			}
+		}
+	}
+	return progressed, nil
+}
+
+// CloseTimedOutTickets 对处理时间超过 ProcessingTimeout 小时的工单执行超时流转，返回已处理的工单数
+func (s *TicketWorkflowService) CloseTimedOutTickets(now time.Time) (int, error) {
+	var tickets []models.Ticket
+	err := s.db.Where("status = ? AND processing_started_at IS NOT NULL AND processing_timeout > 0", models.TicketStatusInProgress).
+		Order("id ASC").
+		Find(&tickets).Error
+	if err != nil {
+		return 0, fmt.Errorf("查询处理中工单失败: %w", err)
+	}
+	actor, err := s.SystemActor()
+	if err != nil {
+		return 0, err
+	}
+
+	closed, failed := 0, 0
+	var lastErr error
+	for i := range tickets {
+		ticket := &tickets[i]
+		timeout := time.Duration(ticket.ProcessingTimeout) * time.Hour
+		if now.Sub(*ticket.ProcessingStartedAt) <= timeout {
+			continue
+		}
+		comment := fmt.Sprintf("处理超过 %d 小时，已自动关闭", ticket.ProcessingTimeout)
+		if err := s.transition(ticket, &TicketTransitionRequest{Action: TicketTimeoutAction, Comment: comment}, actor, "", "", 0); err != nil {
+			failed++
+			lastErr = err
+			continue
+		}
+		closed++
+	}
+	// 超时工单全部流转失败时返回错误，调度记录为失败
+	if closed == 0 && failed > 0 {
+		return 0, fmt.Errorf("%d 个超时工单流转失败: %w", failed, lastErr)
+	}
+	return closed, nil
+}
+
+// autoTransitionStates 内置流程与启用的流程中配置了自动流转的状态
+func (s *TicketWorkflowService) autoTransitionStates() ([]string, error) {
+	definitions := []*TicketWorkflowDefinition{DefaultTicketWorkflow()}
+	var workflows []models.TicketWorkflow
+	if err := s.db.Where("is_active = ?", true).Find(&workflows).Error; err != nil {
+		return nil, fmt.Errorf("获取工单流程失败: %w", err)
+	}
+	for _, workflow := range workflows {
+		if definition, err := ParseTicketWorkflow(workflow.Definition); err == nil {
+			definitions = append(definitions, definition)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var states []string
+	for _, definition := range definitions {
+		for state, entries := range definition.OnEnter {
+			for _, entry := range entries {
+				if entry.Type == TicketEntryTransition && !seen[state] {
+					seen[state] = true
+					states = append(states, state)
+				}
+			}
+		}
+	}
+	sort.Strings(states)
+	return states, nil
+}
+
+// transition 校验并执行一次流转，随后执行目标状态的进入动作
+func (s *TicketWorkflowService) transition(ticket *models.Ticket, req *TicketTransitionRequest, actor *TicketActor, ipAddress, userAgent string, depth int) error {
+	_, workflow, err := s.ResolveWorkflow(string(ticket.Type), ticket.Category)
+	if err != nil {
+		return err
+	}
+	if req.Action == "" && !workflow.HasState(req.ToStatus) {
+		return fmt.Errorf("状态 %s 不在工单流程中", req.ToStatus)
+	}
+
+	transition := workflow.findTransition(string(ticket.Status), req.Action, req.ToStatus, func(t *TicketWorkflowTransition) bool {
+		return s.canPerform(t, ticket, actor)
+	})
+	// 仅系统执行的流转对用户视为不存在
+	if transition == nil || (transition.SystemOnly && !actor.System) {
+		return fmt.Errorf("当前状态 %s 不允许执行该流转", ticket.Status)
+	}
+	if !s.canPerform(transition, ticket, actor) {
+		return fmt.Errorf("无权执行该状态流转")
+	}
+	if err := checkTicketRequiredFields(transition, ticket, req); err != nil {
+		return err
+	}
+	if !actor.System {
+		if ticket.MergedIntoID != nil {
+			return fmt.Errorf("工单已合并到工单 #%d，不能再流转", *ticket.MergedIntoID)
+		}
+		if to := models.TicketStatus(transition.To); to == models.TicketStatusResolved || to == models.TicketStatusClosed {
+			if err := checkTicketBlockers(s.db, ticket.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	var assignee *models.User
+	if req.AssigneeID != nil {
+		var user models.User
+		if err := s.db.Where("is_active = ?", true).First(&user, *req.AssigneeID).Error; err != nil {
+			return fmt.Errorf("指定的处理人不存在")
+		}
+		assignee = &user
+	}
+
+	fromStatus := ticket.Status
+	entries := workflow.OnEnter[transition.To]
+	history := models.TicketHistory{
+		TicketID:    ticket.ID,
+		UserID:      actor.UserID,
+		Action:      transition.Action,
+		Description: ticketTransitionDescription(fromStatus, transition.To, assignee, req),
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		updates := map[string]interface{}{"status": transition.To}
+		switch models.TicketStatus(transition.To) {
+		case models.TicketStatusInProgress:
+			if ticket.ProcessingStartedAt == nil {
+				updates["processing_started_at"] = now
+			}
+		case models.TicketStatusResolved:
+			updates["resolved_at"] = now
+		case models.TicketStatusClosed:
+			updates["closed_at"] = now
+		}
+		if fromStatus == models.TicketStatusClosed && transition.To != string(models.TicketStatusClosed) {
+			updates["closed_at"] = nil
+		}
+
+		newAssigneeID := ticket.AssigneeID
+		if assignee != nil {
+			newAssigneeID = &assignee.ID
+		}
+		if transition.ClearAssignee {
+			newAssigneeID = nil
+		}
+		if req.Resolution != "" {
+			metadata := models.JSONB{}
+			for k, v := range ticket.Metadata {
+				metadata[k] = v
+			}
+			metadata["resolution"] = req.Resolution
+			updates["metadata"] = metadata
+		}
+
+		for _, entry := range entries {
+			switch entry.Type {
+			case TicketEntryAssign:
+				if newAssigneeID == nil {
+					if userID := pickTicketAssignee(tx, &entry); userID != 0 {
+						newAssigneeID = &userID
+					}
+				}
+			case TicketEntrySetDueDate:
+				updates["due_date"] = now.Add(time.Duration(entry.Hours) * time.Hour)
+			}
+		}
+		if newAssigneeID != nil {
+			updates["assignee_id"] = *newAssigneeID
+		} else {
+			updates["assignee_id"] = nil
+		}
+
+		result := tx.Model(&models.Ticket{}).
+			Where("id = ? AND status = ?", ticket.ID, fromStatus).
+			Updates(updates)
+		if result.Error != nil {
+			return fmt.Errorf("更新工单状态失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("工单状态已被修改，请刷新后重试")
+		}
+		if newAssigneeID != nil {
+			// 处理人自动关注工单
+			if err := AddWatchers(tx, models.WatchEntityTicket, ticket.ID, models.WatchReasonAssignee, *newAssigneeID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&history).Error; err != nil {
+			return fmt.Errorf("记录工单历史失败: %w", err)
+		}
+		// 重新读取到新的结构体，确保被清空的字段不会保留旧值
+		var reloaded models.Ticket
+		if err := tx.First(&reloaded, ticket.ID).Error; err != nil {
+			return fmt.Errorf("获取工单失败: %w", err)
+		}
+		*ticket = reloaded
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.afterTransition(ticket, fromStatus, transition, &history, entries, actor, ipAddress, userAgent)
+
+	for _, entry := range entries {
+		if entry.Type != TicketEntryTransition || depth+1 >= maxTicketTransitionChain {
+			continue
+		}
+		systemActor, err := s.SystemActor()
+		if err != nil {
+			break
+		}
+		// 自动流转失败（如当前状态不满足）不影响本次流转结果
+		if err := s.transition(ticket, &TicketTransitionRequest{Action: entry.Action}, systemActor, "", "", depth+1); err == nil {
+			break
+		}
+	}
+	return nil
+}
+
+// afterTransition 流转后的审计与通知
+func (s *TicketWorkflowService) afterTransition(ticket *models.Ticket, fromStatus models.TicketStatus, transition *TicketWorkflowTransition, history *models.TicketHistory, entries []TicketEntryAction, actor *TicketActor, ipAddress, userAgent string) {
+	if s.auditService != nil {
+		s.auditService.CreateAuditLog(&AuditLogRequest{
+			UserID:       actor.UserID,
+			Action:       "STATUS_CHANGE",
+			ResourceType: "ticket",
+			ResourceID:   ticket.ID,
+			OldValues:    map[string]interface{}{"status": string(fromStatus)},
+			NewValues:    map[string]interface{}{"status": transition.To, "action": transition.Action, "assignee_id": ticket.AssigneeID},
+			IPAddress:    ipAddress,
+			UserAgent:    userAgent,
+		})
+	}
+
+	content := "工单状态已更新：" + ticket.Title + "\n" + history.Description
+	var targets []uint
+	for _, entry := range entries {
+		if entry.Type == TicketEntryNotify {
+			targets = append(targets, s.resolveNotifyTargets(ticket, entry.Targets)...)
+		}
+	}
+	targets = uniqueUints(targets)
+	s.slaService.OnStatusChange(ticket, fromStatus, actor)
+	// 关注者（创建者与处理人自动关注）统一收到状态通知，进入动作指定的对象单独通知
+	s.watchService.NotifyWatchers(models.WatchEntityTicket, ticket.ID, actor.UserID, "工单通知", content, targets...)
+	s.watchService.NotifyUsers(targets, actor.UserID, "工单通知", content)
+	if !isTicketDone(fromStatus) {
+		notifyParentRollup(s.db, s.watchService, ticket, actor.UserID)
+	}
+	sendTicketSurvey(s.db, s.watchService, ticket, fromStatus)
+}
+
+// resolveNotifyTargets 将通知对象解析为用户ID
+func (s *TicketWorkflowService) resolveNotifyTargets(ticket *models.Ticket, targets []string) []uint {
+	return resolveTicketTargets(s.db, ticket, targets)
+}
+
+// resolveTicketTargets 将 creator、assignee、role:<角色名>、user:<用户ID> 形式的对象解析为用户ID
+func resolveTicketTargets(db *gorm.DB, ticket *models.Ticket, targets []string) []uint {
+	var userIDs []uint
+	for _, target := range targets {
+		switch {
+		case target == TicketRoleCreator:
+			userIDs = append(userIDs, ticket.CreatorID)
+		case target == TicketRoleAssignee:
+			if ticket.AssigneeID != nil {
+				userIDs = append(userIDs, *ticket.AssigneeID)
+			}
+		case strings.HasPrefix(target, "role:"):
+			var ids []uint
+			db.Table("user_roles").
+				Joins("JOIN roles ON roles.id = user_roles.role_id").
+				Where("roles.name = ?", strings.TrimPrefix(target, "role:")).
+				Pluck("user_roles.user_id", &ids)
+			userIDs = append(userIDs, ids...)
+		case strings.HasPrefix(target, "user:"):
+			if id, err := strconv.ParseUint(strings.TrimPrefix(target, "user:"), 10, 32); err == nil {
+				userIDs = append(userIDs, uint(id))
+			}
+		}
+	}
+	return userIDs
+}
+
+// canPerform 检查操作者是否可执行流转，具备 ticket:admin 权限时不受限制
+func (s *TicketWorkflowService) canPerform(t *TicketWorkflowTransition, ticket *models.Ticket, actor *TicketActor) bool {
+	if actor.System {
+		return true
+	}
+	if t.SystemOnly {
+		return false
+	}
+	if s.actorHas(actor, "ticket:admin") {
+		return true
+	}
+
+	isCreator := ticket.CreatorID == actor.UserID
+	isAssignee := ticket.AssigneeID != nil && *ticket.AssigneeID == actor.UserID
+	if len(t.Roles) == 0 && len(t.Permissions) == 0 {
+		return isCreator || isAssignee || s.actorHas(actor, "ticket:status")
+	}
+
+	for _, permission := range t.Permissions {
+		if s.actorHas(actor, permission) {
+			return true
+		}
+	}
+	var roleNames []string
+	for _, allowed := range t.Roles {
+		switch allowed {
+		case TicketRoleCreator:
+			if isCreator {
+				return true
+			}
+		case TicketRoleAssignee:
+			if isAssignee {
+				return true
+			}
+		default:
+			if roleNames == nil {
+				roleNames = getUserRoleNames(s.db, actor.UserID)
+			}
+			for _, role := range roleNames {
+				if role == allowed {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// canView 操作者是否可查看工单
+func (s *TicketWorkflowService) canView(ticket *models.Ticket, actor *TicketActor) bool {
+	return canViewTicket(s.db, ticket, actor)
+}
+
+// actorHas 检查操作者是否具备权限
+func (s *TicketWorkflowService) actorHas(actor *TicketActor, permission string) bool {
+	return actorHasPermission(s.db, actor, permission)
+}
+
+// canViewTicket 创建者、处理人及具备查看全部或状态管理权限的用户可查看工单
+func canViewTicket(db *gorm.DB, ticket *models.Ticket, actor *TicketActor) bool {
+	if actor.System || ticket.CreatorID == actor.UserID || (ticket.AssigneeID != nil && *ticket.AssigneeID == actor.UserID) {
+		return true
+	}
+	return actorHasPermission(db, actor, "ticket:view_all") || actorHasPermission(db, actor, "ticket:status")
+}
+
+// actorHasPermission 优先按请求上下文判断权限，未提供时查询数据库
+func actorHasPermission(db *gorm.DB, actor *TicketActor, permission string) bool {
+	if actor.HasPermission != nil {
+		return actor.HasPermission(permission)
+	}
+	return userHasPermission(db, actor.UserID, permission)
+}
+
+// findTicket 查找工单
+func (s *TicketWorkflowService) findTicket(ticketID uint) (*models.Ticket, error) {
+	var ticket models.Ticket
+	if err := s.db.First(&ticket, ticketID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("工单不存在")
+		}
+		return nil, fmt.Errorf("获取工单失败: %w", err)
+	}
+	return &ticket, nil
+}
+
+// ListWorkflows 获取全部工单流程
+func (s *TicketWorkflowService) ListWorkflows() ([]models.TicketWorkflow, error) {
+	var workflows []models.TicketWorkflow
+	if err := s.db.Order("ticket_type ASC, category ASC, id ASC").Find(&workflows).Error; err != nil {
+		return nil, fmt.Errorf("获取工单流程失败: %w", err)
+	}
+	return workflows, nil
+}
+
+// GetWorkflow 获取工单流程
+func (s *TicketWorkflowService) GetWorkflow(id uint) (*models.TicketWorkflow, error) {
+	var workflow models.TicketWorkflow
+	if err := s.db.First(&workflow, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("工单流程不存在")
+		}
+		return nil, fmt.Errorf("获取工单流程失败: %w", err)
+	}
+	return &workflow, nil
+}
+
+// CreateWorkflow 创建工单流程
+func (s *TicketWorkflowService) CreateWorkflow(req *SaveTicketWorkflowRequest, userID uint, ipAddress, userAgent string) (*models.TicketWorkflow, error) {
+	workflow := models.TicketWorkflow{CreatedBy: userID, IsActive: true}
+	if err := s.applyWorkflowRequest(&workflow, req); err != nil {
+		return nil, err
+	}
+	// 布尔字段有默认值，创建后会被回填为 true，false 需要单独更新
+	inactive := !workflow.IsActive
+	if err := s.db.Create(&workflow).Error; err != nil {
+		return nil, fmt.Errorf("创建工单流程失败: %w", err)
+	}
+	if inactive {
+		s.db.Model(&workflow).Update("is_active", false)
+	}
+
+	s.auditWorkflow("CREATE", &workflow, nil, userID, ipAddress, userAgent)
+	return &workflow, nil
+}
+
+// UpdateWorkflow 更新工单流程，已在流转中的工单按新定义继续流转
+func (s *TicketWorkflowService) UpdateWorkflow(id uint, req *SaveTicketWorkflowRequest, userID uint, ipAddress, userAgent string) (*models.TicketWorkflow, error) {
+	workflow, err := s.GetWorkflow(id)
+	if err != nil {
+		return nil, err
+	}
+	oldValues := map[string]interface{}{"name": workflow.Name, "ticket_type": workflow.TicketType, "category": workflow.Category, "is_active": workflow.IsActive}
+	if err := s.applyWorkflowRequest(workflow, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.Omit("created_by", "created_at").Save(workflow).Error; err != nil {
+		return nil, fmt.Errorf("更新工单流程失败: %w", err)
+	}
+
+	s.auditWorkflow("UPDATE", workflow, oldValues, userID, ipAddress, userAgent)
+	return workflow, nil
+}
+
+// DeleteWorkflow 删除工单流程，匹配的工单改按其他流程流转
+func (s *TicketWorkflowService) DeleteWorkflow(id uint, userID uint, ipAddress, userAgent string) error {
+	workflow, err := s.GetWorkflow(id)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(workflow).Error; err != nil {
+		return fmt.Errorf("删除工单流程失败: %w", err)
+	}
+
+	s.auditWorkflow("DELETE", workflow, nil, userID, ipAddress, userAgent)
+	return nil
+}
+
+// applyWorkflowRequest 校验请求并写入流程字段，同一类型与分类只能有一个启用的流程
+func (s *TicketWorkflowService) applyWorkflowRequest(workflow *models.TicketWorkflow, req *SaveTicketWorkflowRequest) error {
+	definition, err := ParseTicketWorkflow(models.JSONB(req.Definition))
+	if err != nil {
+		return err
+	}
+	raw, err := definition.toJSONB()
+	if err != nil {
+		return err
+	}
+
+	workflow.Name = strings.TrimSpace(req.Name)
+	workflow.Description = req.Description
+	workflow.TicketType = strings.TrimSpace(req.TicketType)
+	workflow.Category = strings.TrimSpace(req.Category)
+	workflow.Definition = raw
+	if req.IsActive != nil {
+		workflow.IsActive = *req.IsActive
+	}
+	if workflow.Name == "" {
+		return fmt.Errorf("流程名称不能为空")
+	}
+	if !workflow.IsActive {
+		return nil
+	}
+
+	var count int64
+	query := s.db.Model(&models.TicketWorkflow{}).
+		Where("is_active = ? AND ticket_type = ? AND category = ?", true, workflow.TicketType, workflow.Category)
+	if workflow.ID != 0 {
+		query = query.Where("id <> ?", workflow.ID)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return fmt.Errorf("检查工单流程失败: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("该类型与分类已存在启用的工单流程")
+	}
+	return nil
+}
+
+// auditWorkflow 记录流程配置变更审计日志
+func (s *TicketWorkflowService) auditWorkflow(action string, workflow *models.TicketWorkflow, oldValues map[string]interface{}, userID uint, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	s.auditService.CreateAuditLog(&AuditLogRequest{
+		UserID:       userID,
+		Action:       action,
+		ResourceType: "ticket_workflow",
+		ResourceID:   workflow.ID,
+		OldValues:    oldValues,
+		NewValues:    map[string]interface{}{"name": workflow.Name, "ticket_type": workflow.TicketType, "category": workflow.Category, "is_active": workflow.IsActive},
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+	})
+}
+
+// SeedDefaultTicketWorkflow 未配置任何工单流程时写入默认流程，便于管理员在其基础上修改
+func SeedDefaultTicketWorkflow(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&models.TicketWorkflow{}).Count(&count).Error; err != nil {
+		return fmt.Errorf("统计工单流程失败: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	raw, err := DefaultTicketWorkflow().toJSONB()
+	if err != nil {
+		return err
+	}
+	workflow := models.TicketWorkflow{
+		Name:        "默认工单流程",
+		Description: "适用于未单独配置流程的工单类型与分类",
+		Definition:  raw,
+		IsActive:    true,
+	}
+	if err := db.Create(&workflow).Error; err != nil {
+		return fmt.Errorf("创建默认工单流程失败: %w", err)
+	}
+	return nil
+}
+
+// checkTicketRequiredFields 检查流转要求的必填字段
+func checkTicketRequiredFields(t *TicketWorkflowTransition, ticket *models.Ticket, req *TicketTransitionRequest) error {
+	for _, field := range t.RequiredFields {
+		switch field {
+		case TicketFieldComment:
+			if strings.TrimSpace(req.Comment) == "" {
+				return fmt.Errorf("该操作需要填写备注")
+			}
+		case TicketFieldResolution:
+			if strings.TrimSpace(req.Resolution) == "" {
+				return fmt.Errorf("该操作需要填写解决说明")
+			}
+		case TicketFieldAssignee:
+			if req.AssigneeID == nil && ticket.AssigneeID == nil {
+				return fmt.Errorf("该操作需要指定处理人")
+			}
+		}
+	}
+	return nil
+}
+
+// ticketTransitionDescription 生成流转历史描述
+func ticketTransitionDescription(from models.TicketStatus, to string, assignee *models.User, req *TicketTransitionRequest) string {
+	desc := "状态: " + string(from) + " -> " + to
+	if assignee != nil {
+		desc += "，处理人：" + assignee.Username
+	}
+	if req.Comment != "" {
+		desc += "，备注：" + req.Comment
+	}
+	if req.Resolution != "" {
+		desc += "，解决说明：" + req.Resolution
+	}
+	return desc
+}
+
+// pickTicketAssignee 按进入动作选择处理人：指定用户，或角色中未完成工单最少的启用用户
+func pickTicketAssignee(tx *gorm.DB, entry *TicketEntryAction) uint {
+	if entry.UserID != 0 {
+		var count int64
+		tx.Model(&models.User{}).Where("id = ? AND is_active = ?", entry.UserID, true).Count(&count)
+		if count > 0 {
+			return entry.UserID
+		}
+		return 0
+	}
+
+	var candidate struct {
+		ID uint
+	}
+	tx.Table("users").
+		Select("users.id").
+		Joins("JOIN user_roles ON user_roles.user_id = users.id").
+		Joins("JOIN roles ON roles.id = user_roles.role_id").
+		Joins("LEFT JOIN tickets ON tickets.assignee_id = users.id AND tickets.deleted_at IS NULL AND tickets.status NOT IN ?",
+			[]string{string(models.TicketStatusResolved), string(models.TicketStatusClosed), string(models.TicketStatusRejected)}).
+		Where("roles.name = ? AND users.is_active = ? AND users.deleted_at IS NULL", entry.Role, true).
+		Group("users.id").
+		Order("COUNT(tickets.id) ASC, users.id ASC").
+		Limit(1).
+		Scan(&candidate)
+	return candidate.ID
+}
+
+// userHasPermission 按数据库中的角色与直接授权检查用户是否具备权限
+func userHasPermission(db *gorm.DB, userID uint, permission string) bool {
+	if userID == 0 {
+		return false
+	}
+	if userID == 1 {
+		return true
+	}
+	for _, role := range getUserRoleNames(db, userID) {
+		if role == "admin" || role == "系统管理员" || role == "administrator" {
+			return true
+		}
+	}
+
+	var count int64
+	db.Table("permissions").
+		Where("permissions.name = ?", permission).
+		Where("permissions.id IN (?) OR permissions.id IN (?)",
+			db.Table("role_permissions").Select("role_permissions.permission_id").
+				Joins("JOIN user_roles ON user_roles.role_id = role_permissions.role_id").
+				Where("user_roles.user_id = ?", userID),
+			db.Table("user_permissions").Select("permission_id").Where("user_id = ?", userID)).
+		Count(&count)
+	return count > 0
+}
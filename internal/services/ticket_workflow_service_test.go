@@ -0,0 +1,349 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// setupTicketWorkflowTest 创建工单流程与 SLA 测试环境：admin(1)、alice(2)、bob(3)、carol(4)，通知同步发送
+func setupTicketWorkflowTest(t *testing.T) *TicketWorkflowService {
+	db := newServiceTestDB(t)
+	for _, name := range []string{"admin", "alice", "bob", "carol"} {
+		require.NoError(t, db.Create(&models.User{Username: name, Email: name + "@example.com", PasswordHash: "x", IsActive: true}).Error)
+	}
+
+	watchService := NewWatchService(db, NewNotificationService(db))
+	watchService.runAsync = func(f func()) { f() }
+	auditService := NewAuditService(db)
+	return NewTicketWorkflowService(db, auditService, watchService, NewTicketSLAService(db, auditService, watchService))
+}
+
+// grantRole 创建角色并授予用户，permissions 为角色拥有的权限名
+func grantRole(t *testing.T, db *gorm.DB, userID uint, roleName string, permissions ...string) {
+	role := models.Role{Name: roleName, DisplayName: roleName}
+	require.NoError(t, db.Where("name = ?", roleName).FirstOrCreate(&role).Error)
+	require.NoError(t, db.Create(&models.UserRole{UserID: userID, RoleID: role.ID}).Error)
+	for _, name := range permissions {
+		permission := models.Permission{Name: name, DisplayName: name, Resource: "ticket", Action: name}
+		require.NoError(t, db.Create(&permission).Error)
+		require.NoError(t, db.Create(&models.RolePermission{RoleID: role.ID, PermissionID: permission.ID}).Error)
+	}
+}
+
+// actorWith 仅具备指定权限的操作者
+func actorWith(userID uint, permissions ...string) *TicketActor {
+	return &TicketActor{UserID: userID, HasPermission: func(permission string) bool {
+		for _, p := range permissions {
+			if p == permission {
+				return true
+			}
+		}
+		return false
+	}}
+}
+
+func createTestTicket(t *testing.T, s *TicketWorkflowService, ticketType models.TicketType, category string, creatorID uint) *models.Ticket {
+	ticket := models.Ticket{
+		Title:     "打印机故障",
+		Type:      ticketType,
+		Category:  category,
+		Status:    s.InitialStatus(string(ticketType), category),
+		CreatorID: creatorID,
+	}
+	require.NoError(t, s.db.Create(&ticket).Error)
+	require.NoError(t, AddWatchers(s.db, models.WatchEntityTicket, ticket.ID, models.WatchReasonCreator, creatorID))
+	return &ticket
+}
+
+func TestTicketWorkflowService_DefaultWorkflow(t *testing.T) {
+	s := setupTicketWorkflowTest(t)
+	db := s.db
+	alice, bob := actorWith(2), actorWith(3)
+	ticket := createTestTicket(t, s, models.TicketTypeSupport, "", 2)
+	assert.Equal(t, models.TicketStatusSubmitted, ticket.Status)
+
+	// 创建者可以查看但暂无可执行动作，无关用户视为不存在
+	actions, err := s.AvailableActions(ticket.ID, alice)
+	require.NoError(t, err)
+	assert.Equal(t, defaultTicketWorkflowName, actions.WorkflowName)
+	assert.Empty(t, actions.Actions)
+	_, err = s.AvailableActions(ticket.ID, actorWith(4))
+	assert.EqualError(t, err, "工单不存在或无权访问")
+
+	_, err = s.Transition(ticket.ID, &TicketTransitionRequest{Action: "assign", AssigneeID: uintPtr(3)}, alice, "", "")
+	assert.EqualError(t, err, "无权执行该状态流转")
+	manager := actorWith(4, "ticket:assign")
+	_, err = s.Transition(ticket.ID, &TicketTransitionRequest{Action: "assign"}, manager, "", "")
+	assert.EqualError(t, err, "该操作需要指定处理人")
+
+	updated, err := s.Assign(ticket.ID, 3, false, "请处理", manager, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, models.TicketStatusAssigned, updated.Status)
+	require.NotNil(t, updated.Assignee)
+	assert.Equal(t, "bob", updated.Assignee.Username)
+
+	// 处理人自动关注，并收到状态通知
+	var watcher models.Watcher
+	require.NoError(t, db.Where("entity_type = ? AND entity_id = ? AND user_id = ?", models.WatchEntityTicket, ticket.ID, 3).First(&watcher).Error)
+	var recipients []string
+	db.Model(&models.Notification{}).Order("id").Pluck("recipients", &recipients)
+	assert.Equal(t, []string{`["alice"]`, `["bob"]`}, recipients, "创建者与处理人均为关注者，操作者本人不收通知")
+
+	actions, err = s.AvailableActions(ticket.ID, bob)
+	require.NoError(t, err)
+	var names []string
+	for _, a := range actions.Actions {
+		names = append(names, a.Action)
+	}
+	assert.Equal(t, []string{"accept", "reject"}, names)
+
+	_, err = s.Transition(ticket.ID, &TicketTransitionRequest{Action: "accept"}, bob, "", "")
+	require.NoError(t, err)
+	_, err = s.Transition(ticket.ID, &TicketTransitionRequest{ToStatus: string(models.TicketStatusApproved)}, alice, "", "")
+	assert.EqualError(t, err, "无权执行该状态流转")
+
+	// 权限从数据库角色读取；审批通过后自动进入处理阶段
+	grantRole(t, db, 4, "approver", "ticket:approve")
+	updated, err = s.Transition(ticket.ID, &TicketTransitionRequest{ToStatus: string(models.TicketStatusApproved)}, &TicketActor{UserID: 4}, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, models.TicketStatusInProgress, updated.Status)
+	assert.NotNil(t, updated.ProcessingStartedAt)
+
+	_, err = s.Transition(ticket.ID, &TicketTransitionRequest{ToStatus: "closed"}, bob, "", "")
+	assert.EqualError(t, err, "当前状态 progress 不允许执行该流转")
+	_, err = s.Transition(ticket.ID, &TicketTransitionRequest{ToStatus: "bogus"}, bob, "", "")
+	assert.EqualError(t, err, "状态 bogus 不在工单流程中")
+
+	updated, err = s.Transition(ticket.ID, &TicketTransitionRequest{Action: "resolve", Comment: "已更换硒鼓"}, bob, "", "")
+	require.NoError(t, err)
+	assert.NotNil(t, updated.ResolvedAt)
+	updated, err = s.Transition(ticket.ID, &TicketTransitionRequest{Action: "close"}, alice, "", "")
+	require.NoError(t, err)
+	assert.NotNil(t, updated.ClosedAt)
+	updated, err = s.Transition(ticket.ID, &TicketTransitionRequest{Action: "reopen"}, alice, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, models.TicketStatusSubmitted, updated.Status)
+	assert.Nil(t, updated.ClosedAt)
+
+	var history []models.TicketHistory
+	require.NoError(t, db.Where("ticket_id = ?", ticket.ID).Order("id").Find(&history).Error)
+	var actionsLog []string
+	for _, h := range history {
+		actionsLog = append(actionsLog, h.Action)
+	}
+	assert.Equal(t, []string{"assign", "accept", "approve", "start", "resolve", "close", "reopen"}, actionsLog)
+	assert.Equal(t, "状态: submitted -> assigned，处理人：bob，备注：请处理", history[0].Description)
+	systemUserID, err := SystemUserID(db)
+	require.NoError(t, err)
+	assert.Equal(t, systemUserID, history[3].UserID, "自动流转由系统执行")
+}
+
+func TestTicketWorkflowService_CustomWorkflow(t *testing.T) {
+	s := setupTicketWorkflowTest(t)
+	db := s.db
+	definition := map[string]interface{}{
+		"states": []interface{}{"new", "triage", "done"},
+		"transitions": []interface{}{
+			map[string]interface{}{"action": "triage", "label": "分诊", "from": "new", "to": "triage", "roles": []interface{}{"creator"}, "required_fields": []interface{}{"comment"}},
+			map[string]interface{}{"action": "finish", "from": "triage", "to": "done", "roles": []interface{}{"assignee"}, "required_fields": []interface{}{"resolution"}},
+		},
+		"on_enter": map[string]interface{}{
+			"triage": []interface{}{
+				map[string]interface{}{"type": "assign", "role": "support"},
+				map[string]interface{}{"type": "set_due_date", "hours": 24},
+				map[string]interface{}{"type": "notify", "targets": []interface{}{"role:lead"}},
+			},
+		},
+	}
+
+	// 定义校验
+	_, err := s.CreateWorkflow(&SaveTicketWorkflowRequest{Name: "坏流程", Definition: map[string]interface{}{
+		"states":      []interface{}{"new"},
+		"transitions": []interface{}{map[string]interface{}{"from": "new", "to": "gone"}},
+	}}, 1, "", "")
+	assert.EqualError(t, err, "第 1 个流转的目标状态 gone 不在状态列表中")
+	_, err = s.CreateWorkflow(&SaveTicketWorkflowRequest{Name: "坏流程", Definition: map[string]interface{}{
+		"states":   []interface{}{"new"},
+		"on_enter": map[string]interface{}{"new": []interface{}{map[string]interface{}{"type": "transition", "action": "missing"}}},
+	}}, 1, "", "")
+	assert.EqualError(t, err, "状态 new 的自动流转动作 missing 不在流转列表中")
+
+	bugFlow, err := s.CreateWorkflow(&SaveTicketWorkflowRequest{Name: "故障流程", TicketType: "bug", Definition: definition}, 1, "", "")
+	require.NoError(t, err)
+	_, err = s.CreateWorkflow(&SaveTicketWorkflowRequest{Name: "重复", TicketType: "bug", Definition: definition}, 1, "", "")
+	assert.EqualError(t, err, "该类型与分类已存在启用的工单流程")
+	hardwareFlow, err := s.CreateWorkflow(&SaveTicketWorkflowRequest{Name: "硬件流程", Category: "hardware", Definition: definition}, 1, "", "")
+	require.NoError(t, err)
+
+	// 类型匹配优先于分类匹配，均不匹配时使用内置流程
+	matched, _, err := s.ResolveWorkflow("bug", "hardware")
+	require.NoError(t, err)
+	assert.Equal(t, bugFlow.ID, matched.ID)
+	matched, _, err = s.ResolveWorkflow("feature", "hardware")
+	require.NoError(t, err)
+	assert.Equal(t, hardwareFlow.ID, matched.ID)
+	matched, _, err = s.ResolveWorkflow("feature", "")
+	require.NoError(t, err)
+	assert.Nil(t, matched)
+
+	// 支持人员中未完成工单最少的用户被分配
+	grantRole(t, db, 3, "support")
+	grantRole(t, db, 4, "support")
+	grantRole(t, db, 1, "lead")
+	busy := createTestTicket(t, s, models.TicketTypeFeature, "", 2)
+	require.NoError(t, db.Model(busy).Update("assignee_id", 3).Error)
+
+	ticket := createTestTicket(t, s, models.TicketTypeBug, "", 2)
+	assert.Equal(t, models.TicketStatus("new"), ticket.Status)
+	actions, err := s.AvailableActions(ticket.ID, actorWith(2))
+	require.NoError(t, err)
+	assert.Equal(t, "故障流程", actions.WorkflowName)
+	require.Len(t, actions.Actions, 1)
+	assert.Equal(t, TicketActionResponse{Action: "triage", Label: "分诊", To: "triage", RequiredFields: []string{"comment"}}, actions.Actions[0])
+
+	_, err = s.Transition(ticket.ID, &TicketTransitionRequest{Action: "triage"}, actorWith(2), "", "")
+	assert.EqualError(t, err, "该操作需要填写备注")
+	updated, err := s.Transition(ticket.ID, &TicketTransitionRequest{Action: "triage", Comment: "影响全楼层"}, actorWith(2), "", "")
+	require.NoError(t, err)
+	assert.Equal(t, models.TicketStatus("triage"), updated.Status)
+	require.NotNil(t, updated.AssigneeID)
+	assert.Equal(t, uint(4), *updated.AssigneeID)
+	assert.NotNil(t, updated.DueDate)
+
+	// 进入动作指定的对象收到通知
+	var notified int64
+	db.Model(&models.Notification{}).Where("recipients = ?", `["admin"]`).Count(&notified)
+	assert.Equal(t, int64(1), notified)
+
+	_, err = s.Transition(ticket.ID, &TicketTransitionRequest{Action: "finish"}, actorWith(4), "", "")
+	assert.EqualError(t, err, "该操作需要填写解决说明")
+	_, err = s.Transition(ticket.ID, &TicketTransitionRequest{Action: "finish", Resolution: "重启交换机"}, actorWith(3), "", "")
+	assert.EqualError(t, err, "无权执行该状态流转")
+	updated, err = s.Transition(ticket.ID, &TicketTransitionRequest{Action: "finish", Resolution: "重启交换机"}, actorWith(4), "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "重启交换机", updated.Metadata["resolution"])
+
+	// 停用后回落到内置流程
+	inactive := false
+	_, err = s.UpdateWorkflow(bugFlow.ID, &SaveTicketWorkflowRequest{Name: "故障流程", TicketType: "bug", Definition: definition, IsActive: &inactive}, 1, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, models.TicketStatusSubmitted, s.InitialStatus("bug", ""))
+	require.NoError(t, s.DeleteWorkflow(hardwareFlow.ID, 1, "", ""))
+	_, err = s.GetWorkflow(hardwareFlow.ID)
+	assert.EqualError(t, err, "工单流程不存在")
+
+	var audits int64
+	db.Model(&models.AuditLog{}).Where("resource_type = ?", "ticket_workflow").Count(&audits)
+	assert.Equal(t, int64(4), audits)
+}
+
+func TestSeedDefaultTicketWorkflow(t *testing.T) {
+	s := setupTicketWorkflowTest(t)
+	require.NoError(t, SeedDefaultTicketWorkflow(s.db))
+	require.NoError(t, SeedDefaultTicketWorkflow(s.db))
+
+	workflows, err := s.ListWorkflows()
+	require.NoError(t, err)
+	require.Len(t, workflows, 1)
+
+	// 写入的默认流程与内置流程一致
+	definition, err := ParseTicketWorkflow(workflows[0].Definition)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultTicketWorkflow(), definition)
+	matched, _, err := s.ResolveWorkflow("bug", "hardware")
+	require.NoError(t, err)
+	assert.Equal(t, workflows[0].ID, matched.ID)
+}
+
+func TestTicketWorkflowService_Automations(t *testing.T) {
+	s := setupTicketWorkflowTest(t)
+	db := s.db
+	now := time.Now()
+	started := now.Add(-30 * time.Hour)
+
+	// 自动流转未执行而停留在已审批状态的工单会被推进
+	approved := models.Ticket{Title: "审批后未开始", Type: models.TicketTypeSupport, Status: models.TicketStatusApproved, CreatorID: 2, AssigneeID: uintPtr(3)}
+	overdue := models.Ticket{Title: "处理超时", Type: models.TicketTypeSupport, Status: models.TicketStatusInProgress, CreatorID: 2, AssigneeID: uintPtr(3), ProcessingStartedAt: &started, ProcessingTimeout: 24}
+	recent := models.Ticket{Title: "处理中", Type: models.TicketTypeSupport, Status: models.TicketStatusInProgress, CreatorID: 2, AssigneeID: uintPtr(3), ProcessingStartedAt: &started, ProcessingTimeout: 48}
+	for _, ticket := range []*models.Ticket{&approved, &overdue, &recent} {
+		require.NoError(t, db.Create(ticket).Error)
+	}
+
+	progressed, err := s.AutoProgressTickets()
+	require.NoError(t, err)
+	assert.Equal(t, 1, progressed)
+	var reloaded models.Ticket
+	require.NoError(t, db.First(&reloaded, approved.ID).Error)
+	assert.Equal(t, models.TicketStatusInProgress, reloaded.Status)
+	assert.NotNil(t, reloaded.ProcessingStartedAt)
+	progressed, err = s.AutoProgressTickets()
+	require.NoError(t, err)
+	assert.Equal(t, 0, progressed)
+
+	closed, err := s.CloseTimedOutTickets(now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, closed)
+	reloaded = models.Ticket{}
+	require.NoError(t, db.First(&reloaded, overdue.ID).Error)
+	assert.Equal(t, models.TicketStatusClosed, reloaded.Status)
+	assert.NotNil(t, reloaded.ClosedAt)
+	var history models.TicketHistory
+	require.NoError(t, db.Where("ticket_id = ? AND action = ?", overdue.ID, TicketTimeoutAction).First(&history).Error)
+	assert.Contains(t, history.Description, "处理超过 24 小时")
+	reloaded = models.Ticket{}
+	require.NoError(t, db.First(&reloaded, recent.ID).Error)
+	assert.Equal(t, models.TicketStatusInProgress, reloaded.Status)
+
+	// 超时流转只能由系统执行，用户不可见也不可执行
+	actions, err := s.AvailableActions(recent.ID, actorWith(1, "ticket:admin"))
+	require.NoError(t, err)
+	for _, action := range actions.Actions {
+		assert.NotEqual(t, TicketTimeoutAction, action.Action)
+	}
+	_, err = s.Transition(recent.ID, &TicketTransitionRequest{Action: TicketTimeoutAction}, actorWith(1, "ticket:admin"), "", "")
+	assert.EqualError(t, err, "当前状态 progress 不允许执行该流转")
+}
+
+func TestTicketWorkflowService_AutomationsWithForeignKeys(t *testing.T) {
+	db := newForeignKeyTestDB(t)
+	for _, name := range []string{"alice", "bob"} {
+		require.NoError(t, db.Create(&models.User{Username: name, Email: name + "@example.com", PasswordHash: "x", IsActive: true}).Error)
+	}
+	watchService := NewWatchService(db, NewNotificationService(db))
+	watchService.runAsync = func(f func()) { f() }
+	auditService := NewAuditService(db)
+	s := NewTicketWorkflowService(db, auditService, watchService, NewTicketSLAService(db, auditService, watchService))
+
+	started := time.Now().Add(-30 * time.Hour)
+	approved := models.Ticket{Title: "审批后未开始", Type: models.TicketTypeSupport, Status: models.TicketStatusApproved, CreatorID: 1, AssigneeID: uintPtr(2)}
+	overdue := models.Ticket{Title: "处理超时", Type: models.TicketTypeSupport, Status: models.TicketStatusInProgress, CreatorID: 1, AssigneeID: uintPtr(2), ProcessingStartedAt: &started, ProcessingTimeout: 24}
+	for _, ticket := range []*models.Ticket{&approved, &overdue} {
+		require.NoError(t, db.Create(ticket).Error)
+	}
+
+	// 系统流转的历史记在系统账号下，外键约束生效时同样成功
+	progressed, err := s.AutoProgressTickets()
+	require.NoError(t, err)
+	assert.Equal(t, 1, progressed)
+	closed, err := s.CloseTimedOutTickets(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, closed)
+
+	systemUserID, err := SystemUserID(db)
+	require.NoError(t, err)
+	var history []models.TicketHistory
+	require.NoError(t, db.Order("id").Find(&history).Error)
+	require.Len(t, history, 2)
+	for _, h := range history {
+		assert.Equal(t, systemUserID, h.UserID)
+	}
+	var audits int64
+	db.Model(&models.AuditLog{}).Where("action = ? AND user_id = ?", "STATUS_CHANGE", systemUserID).Count(&audits)
+	assert.Equal(t, int64(2), audits)
+}
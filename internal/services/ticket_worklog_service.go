@@ -0,0 +1,507 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// 工时权限：登记自己的工时只需能查看工单，代他人登记或修改、删除他人的记录需管理权限
+const (
+	TicketWorklogManagePermission = "ticket:worklog:manage"
+	TicketWorklogReportPermission = "ticket:worklog:report"
+)
+
+const maxTicketWorklogMinutes = 24 * 60 // 单条工时记录上限
+
+// TicketWorklogService 工单工时登记、剩余估时调整与工时报表
+type TicketWorklogService struct {
+	db           *gorm.DB
+	auditService *AuditService
+}
+
+// NewTicketWorklogService 创建工时服务
+func NewTicketWorklogService(db *gorm.DB, auditService *AuditService) *TicketWorklogService {
+	return &TicketWorklogService{
+		db:           db,
+		auditService: auditService,
+	}
+}
+
+// CreateTicketWorklogRequest 登记工时请求，工作日期为 YYYY-MM-DD
+type CreateTicketWorklogRequest struct {
+	UserID            *uint  `json:"user_id"` // 为空时登记到自己名下
+	Minutes           int    `json:"minutes" binding:"required,min=1"`
+	WorkDate          string `json:"work_date" binding:"required"`
+	Note              string `json:"note" binding:"max=2000"`
+	Billable          *bool  `json:"billable"`                                     // 默认计费
+	RemainingEstimate *int   `json:"remaining_estimate" binding:"omitempty,min=0"` // 为空时按登记时长扣减
+}
+
+// UpdateTicketWorklogRequest 修改工时请求，时长变化按差值调整剩余估时
+type UpdateTicketWorklogRequest struct {
+	Minutes  *int    `json:"minutes" binding:"omitempty,min=1"`
+	WorkDate *string `json:"work_date"`
+	Note     *string `json:"note" binding:"omitempty,max=2000"`
+	Billable *bool   `json:"billable"`
+}
+
+// TicketWorklogTotal 工时合计（分钟）
+type TicketWorklogTotal struct {
+	Minutes         int64 `json:"minutes"`
+	BillableMinutes int64 `json:"billable_minutes"`
+	Entries         int64 `json:"entries"`
+}
+
+// TicketWorklogList 工单的工时记录与估时
+type TicketWorklogList struct {
+	OriginalEstimate  *int                   `json:"original_estimate"`
+	RemainingEstimate *int                   `json:"remaining_estimate"`
+	Total             TicketWorklogTotal     `json:"total"`
+	Worklogs          []models.TicketWorklog `json:"worklogs"`
+}
+
+// TicketWorklogReportQuery 工时报表查询参数，日期为 YYYY-MM-DD，按工作日期筛选
+type TicketWorklogReportQuery struct {
+	From     string `form:"from"`
+	To       string `form:"to"`
+	Period   string `form:"period" binding:"omitempty,oneof=day week month"` // 默认按日
+	TicketID uint   `form:"ticket_id"`
+	UserID   uint   `form:"user_id"`
+	Category string `form:"category"`
+	Billable *bool  `form:"billable"`
+}
+
+// TicketWorklogGroup 按维度汇总的工时
+type TicketWorklogGroup struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+	TicketWorklogTotal
+}
+
+// TicketWorklogReport 工时报表
+type TicketWorklogReport struct {
+	Summary    TicketWorklogTotal   `json:"summary"`
+	ByTicket   []TicketWorklogGroup `json:"by_ticket"`
+	ByUser     []TicketWorklogGroup `json:"by_user"`
+	ByCategory []TicketWorklogGroup `json:"by_category"`
+	ByPeriod   []TicketWorklogGroup `json:"by_period"`
+}
+
+// ListWorklogs 获取工单的工时记录，按工作日期倒序
+func (s *TicketWorklogService) ListWorklogs(ticketID uint, actor *TicketActor) (*TicketWorklogList, error) {
+	ticket, err := s.findVisibleTicket(ticketID, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	var worklogs []models.TicketWorklog
+	if err := s.db.Preload("User").Where("ticket_id = ?", ticket.ID).Order("work_date DESC, id DESC").Find(&worklogs).Error; err != nil {
+		return nil, fmt.Errorf("获取工时记录失败: %w", err)
+	}
+	return &TicketWorklogList{
+		OriginalEstimate:  ticket.OriginalEstimate,
+		RemainingEstimate: ticket.RemainingEstimate,
+		Total:             sumTicketWorklogs(worklogs),
+		Worklogs:          worklogs,
+	}, nil
+}
+
+// CreateWorklog 登记工时并扣减剩余估时，未设置估时的工单不做调整
+func (s *TicketWorklogService) CreateWorklog(ticketID uint, req *CreateTicketWorklogRequest, actor *TicketActor, ipAddress, userAgent string) (*models.TicketWorklog, error) {
+	ticket, err := s.findVisibleTicket(ticketID, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	worklog := models.TicketWorklog{TicketID: ticket.ID, UserID: actor.UserID, Minutes: req.Minutes, Note: strings.TrimSpace(req.Note), Billable: true, CreatedBy: actor.UserID}
+	if req.UserID != nil && *req.UserID != actor.UserID {
+		if !actorHasPermission(s.db, actor, TicketWorklogManagePermission) {
+			return nil, fmt.Errorf("无权为其他用户登记工时")
+		}
+		var count int64
+		if err := s.db.Model(&models.User{}).Where("id = ?", *req.UserID).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("检查用户失败: %w", err)
+		}
+		if count == 0 {
+			return nil, fmt.Errorf("用户不存在")
+		}
+		worklog.UserID = *req.UserID
+	}
+	if req.Billable != nil {
+		worklog.Billable = *req.Billable
+	}
+	if worklog.WorkDate, err = parseTicketWorklogDate(req.WorkDate); err != nil {
+		return nil, err
+	}
+	if err := validateTicketWorklog(&worklog); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Ticket", "User").Create(&worklog).Error; err != nil {
+			return fmt.Errorf("登记工时失败: %w", err)
+		}
+		if req.RemainingEstimate != nil {
+			if err := tx.Model(&models.Ticket{}).Where("id = ?", ticket.ID).Update("remaining_estimate", *req.RemainingEstimate).Error; err != nil {
+				return fmt.Errorf("更新剩余估时失败: %w", err)
+			}
+		} else if err := adjustTicketRemainingEstimate(tx, ticket.ID, -worklog.Minutes); err != nil {
+			return err
+		}
+		return tx.Create(&models.TicketHistory{
+			TicketID:    ticket.ID,
+			UserID:      actor.UserID,
+			Action:      "worklog_added",
+			Description: fmt.Sprintf("登记工时 %s（%s）", FormatWorkMinutes(worklog.Minutes), worklog.WorkDate.Format("2006-01-02")),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(actor.UserID, "CREATE", worklog.ID, nil, ticketWorklogAuditValues(&worklog), ipAddress, userAgent)
+	return s.findWorklog(worklog.ID)
+}
+
+// UpdateWorklog 修改工时记录，本人之外的记录需要管理权限
+func (s *TicketWorklogService) UpdateWorklog(id uint, req *UpdateTicketWorklogRequest, actor *TicketActor, ipAddress, userAgent string) (*models.TicketWorklog, error) {
+	worklog, err := s.findEditableWorklog(id, actor)
+	if err != nil {
+		return nil, err
+	}
+	oldValues := ticketWorklogAuditValues(worklog)
+	oldMinutes := worklog.Minutes
+
+	if req.Minutes != nil {
+		worklog.Minutes = *req.Minutes
+	}
+	if req.WorkDate != nil {
+		if worklog.WorkDate, err = parseTicketWorklogDate(*req.WorkDate); err != nil {
+			return nil, err
+		}
+	}
+	if req.Note != nil {
+		worklog.Note = strings.TrimSpace(*req.Note)
+	}
+	if req.Billable != nil {
+		worklog.Billable = *req.Billable
+	}
+	if err := validateTicketWorklog(worklog); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"minutes":   worklog.Minutes,
+			"work_date": worklog.WorkDate,
+			"note":      worklog.Note,
+			"billable":  worklog.Billable,
+		}
+		if err := tx.Model(&models.TicketWorklog{}).Where("id = ?", worklog.ID).Updates(updates).Error; err != nil {
+			return fmt.Errorf("更新工时记录失败: %w", err)
+		}
+		if worklog.Minutes == oldMinutes {
+			return nil
+		}
+		if err := adjustTicketRemainingEstimate(tx, worklog.TicketID, oldMinutes-worklog.Minutes); err != nil {
+			return err
+		}
+		return tx.Create(&models.TicketHistory{
+			TicketID:    worklog.TicketID,
+			UserID:      actor.UserID,
+			Action:      "worklog_updated",
+			Description: fmt.Sprintf("工时记录 #%d: %s -> %s", worklog.ID, FormatWorkMinutes(oldMinutes), FormatWorkMinutes(worklog.Minutes)),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(actor.UserID, "UPDATE", worklog.ID, oldValues, ticketWorklogAuditValues(worklog), ipAddress, userAgent)
+	return s.findWorklog(worklog.ID)
+}
+
+// DeleteWorklog 删除工时记录并把时长加回剩余估时，本人之外的记录需要管理权限
+func (s *TicketWorklogService) DeleteWorklog(id uint, actor *TicketActor, ipAddress, userAgent string) error {
+	worklog, err := s.findEditableWorklog(id, actor)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.TicketWorklog{}, worklog.ID).Error; err != nil {
+			return fmt.Errorf("删除工时记录失败: %w", err)
+		}
+		if err := adjustTicketRemainingEstimate(tx, worklog.TicketID, worklog.Minutes); err != nil {
+			return err
+		}
+		return tx.Create(&models.TicketHistory{
+			TicketID:    worklog.TicketID,
+			UserID:      actor.UserID,
+			Action:      "worklog_deleted",
+			Description: fmt.Sprintf("删除工时记录 #%d（%s）", worklog.ID, FormatWorkMinutes(worklog.Minutes)),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	s.audit(actor.UserID, "DELETE", worklog.ID, ticketWorklogAuditValues(worklog), nil, ipAddress, userAgent)
+	return nil
+}
+
+// Report 按工单、用户、分类与周期汇总工时
+func (s *TicketWorklogService) Report(query *TicketWorklogReportQuery) (*TicketWorklogReport, error) {
+	worklogs, err := s.ReportWorklogs(query)
+	if err != nil {
+		return nil, err
+	}
+
+	categoryLabels := map[string]string{"": "未分类"}
+	var categories []models.TicketCategory
+	s.db.Find(&categories)
+	for _, category := range categories {
+		categoryLabels[category.Name] = category.DisplayName
+	}
+
+	period := query.Period
+	if period == "" {
+		period = TicketSurveyPeriodDay
+	}
+	report := &TicketWorklogReport{
+		Summary: sumTicketWorklogs(worklogs),
+		ByTicket: groupTicketWorklogs(worklogs, func(worklog *models.TicketWorklog) (string, string) {
+			return fmt.Sprint(worklog.TicketID), fmt.Sprintf("#%d %s", worklog.TicketID, worklog.Ticket.Title)
+		}),
+		ByUser: groupTicketWorklogs(worklogs, func(worklog *models.TicketWorklog) (string, string) {
+			return fmt.Sprint(worklog.UserID), worklog.User.Username
+		}),
+		ByCategory: groupTicketWorklogs(worklogs, func(worklog *models.TicketWorklog) (string, string) {
+			if label, ok := categoryLabels[worklog.Ticket.Category]; ok {
+				return worklog.Ticket.Category, label
+			}
+			return worklog.Ticket.Category, worklog.Ticket.Category
+		}),
+		ByPeriod: groupTicketWorklogs(worklogs, func(worklog *models.TicketWorklog) (string, string) {
+			key := ticketSurveyPeriodKey(worklog.WorkDate, period)
+			return key, key
+		}),
+	}
+	// 周期按时间顺序，其余按工时从多到少
+	sort.SliceStable(report.ByPeriod, func(i, j int) bool { return report.ByPeriod[i].Key < report.ByPeriod[j].Key })
+	return report, nil
+}
+
+// ReportWorklogs 按报表条件查询工时明细，包含已删除工单的记录，用于报表与导出
+func (s *TicketWorklogService) ReportWorklogs(query *TicketWorklogReportQuery) ([]models.TicketWorklog, error) {
+	db := s.db.Model(&models.TicketWorklog{}).
+		Preload("User").
+		Preload("Ticket", func(db *gorm.DB) *gorm.DB { return db.Unscoped() })
+	if query.From != "" {
+		from, err := time.ParseInLocation("2006-01-02", query.From, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("开始日期格式应为 YYYY-MM-DD")
+		}
+		db = db.Where("ticket_worklogs.work_date >= ?", from)
+	}
+	if query.To != "" {
+		to, err := time.ParseInLocation("2006-01-02", query.To, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("结束日期格式应为 YYYY-MM-DD")
+		}
+		db = db.Where("ticket_worklogs.work_date < ?", to.AddDate(0, 0, 1))
+	}
+	if query.TicketID != 0 {
+		db = db.Where("ticket_worklogs.ticket_id = ?", query.TicketID)
+	}
+	if query.UserID != 0 {
+		db = db.Where("ticket_worklogs.user_id = ?", query.UserID)
+	}
+	if query.Category != "" {
+		db = db.Joins("JOIN tickets ON tickets.id = ticket_worklogs.ticket_id").Where("tickets.category = ?", query.Category)
+	}
+	if query.Billable != nil {
+		db = db.Where("ticket_worklogs.billable = ?", *query.Billable)
+	}
+
+	var worklogs []models.TicketWorklog
+	if err := db.Order("ticket_worklogs.work_date ASC, ticket_worklogs.id ASC").Find(&worklogs).Error; err != nil {
+		return nil, fmt.Errorf("统计工时失败: %w", err)
+	}
+	return worklogs, nil
+}
+
+// TicketWorklogTotals 批量汇总工单的工时，用于工单详情与导出
+func TicketWorklogTotals(db *gorm.DB, ticketIDs []uint) (map[uint]TicketWorklogTotal, error) {
+	totals := map[uint]TicketWorklogTotal{}
+	if len(ticketIDs) == 0 {
+		return totals, nil
+	}
+
+	var rows []struct {
+		TicketID uint
+		TicketWorklogTotal
+	}
+	err := db.Model(&models.TicketWorklog{}).
+		Select("ticket_id, SUM(minutes) AS minutes, SUM(CASE WHEN billable THEN minutes ELSE 0 END) AS billable_minutes, COUNT(*) AS entries").
+		Where("ticket_id IN ?", ticketIDs).
+		Group("ticket_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("汇总工时失败: %w", err)
+	}
+	for _, row := range rows {
+		totals[row.TicketID] = row.TicketWorklogTotal
+	}
+	return totals, nil
+}
+
+// FormatWorkMinutes 把分钟数格式化为“X小时Y分钟”
+func FormatWorkMinutes(minutes int) string {
+	hours, rest := minutes/60, minutes%60
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%d分钟", rest)
+	case rest == 0:
+		return fmt.Sprintf("%d小时", hours)
+	default:
+		return fmt.Sprintf("%d小时%d分钟", hours, rest)
+	}
+}
+
+// findVisibleTicket 查找当前用户可查看的工单
+func (s *TicketWorklogService) findVisibleTicket(ticketID uint, actor *TicketActor) (*models.Ticket, error) {
+	var ticket models.Ticket
+	if err := s.db.First(&ticket, ticketID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("工单不存在")
+		}
+		return nil, fmt.Errorf("获取工单失败: %w", err)
+	}
+	if !canViewTicket(s.db, &ticket, actor) {
+		return nil, fmt.Errorf("工单不存在或无权查看")
+	}
+	return &ticket, nil
+}
+
+// findEditableWorklog 查找当前用户可修改的工时记录
+func (s *TicketWorklogService) findEditableWorklog(id uint, actor *TicketActor) (*models.TicketWorklog, error) {
+	worklog, err := s.findWorklog(id)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.System && worklog.UserID != actor.UserID && !actorHasPermission(s.db, actor, TicketWorklogManagePermission) {
+		return nil, fmt.Errorf("无权修改他人的工时记录")
+	}
+	return worklog, nil
+}
+
+// findWorklog 查找工时记录
+func (s *TicketWorklogService) findWorklog(id uint) (*models.TicketWorklog, error) {
+	var worklog models.TicketWorklog
+	if err := s.db.Preload("User").First(&worklog, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("工时记录不存在")
+		}
+		return nil, fmt.Errorf("获取工时记录失败: %w", err)
+	}
+	return &worklog, nil
+}
+
+// audit 记录工时审计日志
+func (s *TicketWorklogService) audit(userID uint, action string, worklogID uint, oldValues, newValues map[string]interface{}, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	s.auditService.CreateAuditLog(&AuditLogRequest{
+		UserID:       userID,
+		Action:       action,
+		ResourceType: "ticket_worklog",
+		ResourceID:   worklogID,
+		OldValues:    oldValues,
+		NewValues:    newValues,
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+	})
+}
+
+func ticketWorklogAuditValues(worklog *models.TicketWorklog) map[string]interface{} {
+	return map[string]interface{}{
+		"ticket_id": worklog.TicketID,
+		"user_id":   worklog.UserID,
+		"minutes":   worklog.Minutes,
+		"work_date": worklog.WorkDate.Format("2006-01-02"),
+		"note":      worklog.Note,
+		"billable":  worklog.Billable,
+	}
+}
+
+// validateTicketWorklog 校验时长与工作日期，不允许登记未来日期
+func validateTicketWorklog(worklog *models.TicketWorklog) error {
+	if worklog.Minutes > maxTicketWorklogMinutes {
+		return fmt.Errorf("单条工时不能超过24小时")
+	}
+	if worklog.WorkDate.After(time.Now()) {
+		return fmt.Errorf("工作日期不能晚于今天")
+	}
+	return nil
+}
+
+// parseTicketWorklogDate 解析 YYYY-MM-DD 格式的工作日期
+func parseTicketWorklogDate(value string) (time.Time, error) {
+	date, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(value), time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("工作日期格式应为 YYYY-MM-DD")
+	}
+	return date, nil
+}
+
+// adjustTicketRemainingEstimate 按增量调整剩余估时，最低为 0，未设置剩余估时的工单不变
+func adjustTicketRemainingEstimate(tx *gorm.DB, ticketID uint, delta int) error {
+	err := tx.Model(&models.Ticket{}).
+		Where("id = ? AND remaining_estimate IS NOT NULL", ticketID).
+		Update("remaining_estimate", gorm.Expr("CASE WHEN remaining_estimate + ? < 0 THEN 0 ELSE remaining_estimate + ? END", delta, delta)).Error
+	if err != nil {
+		return fmt.Errorf("更新剩余估时失败: %w", err)
+	}
+	return nil
+}
+
+func sumTicketWorklogs(worklogs []models.TicketWorklog) TicketWorklogTotal {
+	total := TicketWorklogTotal{Entries: int64(len(worklogs))}
+	for _, worklog := range worklogs {
+		total.Minutes += int64(worklog.Minutes)
+		if worklog.Billable {
+			total.BillableMinutes += int64(worklog.Minutes)
+		}
+	}
+	return total
+}
+
+// groupTicketWorklogs 按维度分组汇总工时，组按工时从多到少排列
+func groupTicketWorklogs(worklogs []models.TicketWorklog, keyOf func(*models.TicketWorklog) (string, string)) []TicketWorklogGroup {
+	grouped := map[string][]models.TicketWorklog{}
+	labels := map[string]string{}
+	keys := []string{}
+	for i := range worklogs {
+		key, label := keyOf(&worklogs[i])
+		if _, ok := grouped[key]; !ok {
+			keys = append(keys, key)
+			labels[key] = label
+		}
+		grouped[key] = append(grouped[key], worklogs[i])
+	}
+
+	groups := make([]TicketWorklogGroup, len(keys))
+	for i, key := range keys {
+		groups[i] = TicketWorklogGroup{Key: key, Label: labels[key], TicketWorklogTotal: sumTicketWorklogs(grouped[key])}
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Minutes > groups[j].Minutes })
+	return groups
+}
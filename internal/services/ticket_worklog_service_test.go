@@ -0,0 +1,168 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTicketWorklogTest(t *testing.T) *TicketWorklogService {
+	workflow := setupTicketWorkflowTest(t)
+	return NewTicketWorklogService(workflow.db, workflow.auditService)
+}
+
+// createWorklogTicket 创建 alice 提交、bob 处理的工单
+func createWorklogTicket(t *testing.T, s *TicketWorklogService, category string, estimate *int) *models.Ticket {
+	assignee := uint(3)
+	ticket := models.Ticket{Title: "机房巡检", Type: "support", Category: category, Status: models.TicketStatusInProgress, CreatorID: 2, AssigneeID: &assignee, OriginalEstimate: estimate, RemainingEstimate: estimate}
+	require.NoError(t, s.db.Create(&ticket).Error)
+	return &ticket
+}
+
+func remainingEstimate(t *testing.T, s *TicketWorklogService, ticketID uint) *int {
+	var ticket models.Ticket
+	require.NoError(t, s.db.First(&ticket, ticketID).Error)
+	return ticket.RemainingEstimate
+}
+
+func TestTicketWorklogService_LogEditDelete(t *testing.T) {
+	s := setupTicketWorklogTest(t)
+	estimate := 300
+	ticket := createWorklogTicket(t, s, "", &estimate)
+	today := time.Now().Format("2006-01-02")
+
+	worklog, err := s.CreateWorklog(ticket.ID, &CreateTicketWorklogRequest{Minutes: 90, WorkDate: today, Note: " 更换交换机 "}, actorWith(3), "", "")
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), worklog.UserID)
+	assert.Equal(t, "bob", worklog.User.Username)
+	assert.Equal(t, "更换交换机", worklog.Note)
+	assert.True(t, worklog.Billable)
+	assert.Equal(t, 210, *remainingEstimate(t, s, ticket.ID))
+
+	// 指定剩余估时时不再自动扣减
+	notBillable, remaining := false, 100
+	_, err = s.CreateWorklog(ticket.ID, &CreateTicketWorklogRequest{Minutes: 30, WorkDate: today, Billable: &notBillable, RemainingEstimate: &remaining}, actorWith(2), "", "")
+	require.NoError(t, err)
+	assert.Equal(t, 100, *remainingEstimate(t, s, ticket.ID))
+
+	_, err = s.CreateWorklog(ticket.ID, &CreateTicketWorklogRequest{Minutes: 30, WorkDate: today}, actorWith(4), "", "")
+	assert.EqualError(t, err, "工单不存在或无权查看")
+	carol := uint(4)
+	_, err = s.CreateWorklog(ticket.ID, &CreateTicketWorklogRequest{UserID: &carol, Minutes: 30, WorkDate: today}, actorWith(3), "", "")
+	assert.EqualError(t, err, "无权为其他用户登记工时")
+	_, err = s.CreateWorklog(ticket.ID, &CreateTicketWorklogRequest{Minutes: 30, WorkDate: time.Now().AddDate(0, 0, 1).Format("2006-01-02")}, actorWith(3), "", "")
+	assert.EqualError(t, err, "工作日期不能晚于今天")
+	_, err = s.CreateWorklog(ticket.ID, &CreateTicketWorklogRequest{Minutes: 30, WorkDate: "2024/01/01"}, actorWith(3), "", "")
+	assert.EqualError(t, err, "工作日期格式应为 YYYY-MM-DD")
+	_, err = s.CreateWorklog(ticket.ID, &CreateTicketWorklogRequest{Minutes: 25 * 60, WorkDate: today}, actorWith(3), "", "")
+	assert.EqualError(t, err, "单条工时不能超过24小时")
+
+	manager := actorWith(1, "ticket:view_all", TicketWorklogManagePermission)
+	onBehalf, err := s.CreateWorklog(ticket.ID, &CreateTicketWorklogRequest{UserID: &carol, Minutes: 120, WorkDate: today}, manager, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, uint(4), onBehalf.UserID)
+	assert.Equal(t, uint(1), onBehalf.CreatedBy)
+	assert.Equal(t, 0, *remainingEstimate(t, s, ticket.ID))
+
+	list, err := s.ListWorklogs(ticket.ID, actorWith(2))
+	require.NoError(t, err)
+	assert.Equal(t, 300, *list.OriginalEstimate)
+	assert.Equal(t, TicketWorklogTotal{Minutes: 240, BillableMinutes: 210, Entries: 3}, list.Total)
+
+	// 只能修改自己的记录，时长变化按差值调整剩余估时
+	sixty := 60
+	_, err = s.UpdateWorklog(worklog.ID, &UpdateTicketWorklogRequest{Minutes: &sixty}, actorWith(4), "", "")
+	assert.EqualError(t, err, "无权修改他人的工时记录")
+	updated, err := s.UpdateWorklog(worklog.ID, &UpdateTicketWorklogRequest{Minutes: &sixty, Billable: &notBillable}, actorWith(3), "", "")
+	require.NoError(t, err)
+	assert.Equal(t, 60, updated.Minutes)
+	assert.False(t, updated.Billable)
+	assert.Equal(t, 30, *remainingEstimate(t, s, ticket.ID))
+
+	assert.EqualError(t, s.DeleteWorklog(onBehalf.ID, actorWith(3), "", ""), "无权修改他人的工时记录")
+	require.NoError(t, s.DeleteWorklog(onBehalf.ID, manager, "", ""))
+	assert.Equal(t, 150, *remainingEstimate(t, s, ticket.ID))
+	assert.EqualError(t, s.DeleteWorklog(onBehalf.ID, manager, "", ""), "工时记录不存在")
+
+	var history models.TicketHistory
+	require.NoError(t, s.db.Where("ticket_id = ? AND action = ?", ticket.ID, "worklog_updated").First(&history).Error)
+	assert.Equal(t, "工时记录 #1: 1小时30分钟 -> 1小时", history.Description)
+	var count int64
+	s.db.Model(&models.AuditLog{}).Where("resource_type = ?", "ticket_worklog").Count(&count)
+	assert.Equal(t, int64(5), count)
+
+	// 未设置估时的工单不调整剩余估时
+	plain := createWorklogTicket(t, s, "", nil)
+	_, err = s.CreateWorklog(plain.ID, &CreateTicketWorklogRequest{Minutes: 45, WorkDate: today}, actorWith(3), "", "")
+	require.NoError(t, err)
+	assert.Nil(t, remainingEstimate(t, s, plain.ID))
+}
+
+func TestTicketWorklogService_Report(t *testing.T) {
+	s := setupTicketWorklogTest(t)
+	require.NoError(t, s.db.Create(&models.TicketCategory{Name: "network", DisplayName: "网络", IsActive: true}).Error)
+	network := createWorklogTicket(t, s, "network", nil)
+	other := createWorklogTicket(t, s, "", nil)
+
+	earlier := time.Now().AddDate(0, 0, -40).Format("2006-01-02")
+	today := time.Now().Format("2006-01-02")
+	notBillable := false
+	entries := []struct {
+		ticketID uint
+		userID   uint
+		minutes  int
+		date     string
+		billable *bool
+	}{
+		{network.ID, 3, 120, earlier, nil},
+		{network.ID, 3, 60, today, nil},
+		{network.ID, 2, 30, today, &notBillable},
+		{other.ID, 2, 45, today, nil},
+	}
+	for _, entry := range entries {
+		_, err := s.CreateWorklog(entry.ticketID, &CreateTicketWorklogRequest{Minutes: entry.minutes, WorkDate: entry.date, Billable: entry.billable}, actorWith(entry.userID), "", "")
+		require.NoError(t, err)
+	}
+
+	report, err := s.Report(&TicketWorklogReportQuery{Period: TicketSurveyPeriodMonth})
+	require.NoError(t, err)
+	assert.Equal(t, TicketWorklogTotal{Minutes: 255, BillableMinutes: 225, Entries: 4}, report.Summary)
+
+	require.Len(t, report.ByTicket, 2)
+	assert.Equal(t, "#1 机房巡检", report.ByTicket[0].Label)
+	assert.Equal(t, int64(210), report.ByTicket[0].Minutes)
+
+	require.Len(t, report.ByUser, 2)
+	assert.Equal(t, "bob", report.ByUser[0].Label)
+	assert.Equal(t, int64(180), report.ByUser[0].Minutes)
+	assert.Equal(t, "alice", report.ByUser[1].Label)
+	assert.Equal(t, int64(45), report.ByUser[1].BillableMinutes)
+
+	require.Len(t, report.ByCategory, 2)
+	assert.Equal(t, "网络", report.ByCategory[0].Label)
+	assert.Equal(t, "未分类", report.ByCategory[1].Label)
+
+	require.Len(t, report.ByPeriod, 2)
+	assert.Equal(t, earlier[:7], report.ByPeriod[0].Key)
+	assert.Equal(t, int64(120), report.ByPeriod[0].Minutes)
+
+	// 日期范围、分类与计费筛选
+	billable := true
+	report, err = s.Report(&TicketWorklogReportQuery{From: today, To: today, Category: "network", Billable: &billable})
+	require.NoError(t, err)
+	assert.Equal(t, TicketWorklogTotal{Minutes: 60, BillableMinutes: 60, Entries: 1}, report.Summary)
+	require.Len(t, report.ByPeriod, 1)
+	assert.Equal(t, today, report.ByPeriod[0].Key)
+	_, err = s.Report(&TicketWorklogReportQuery{To: "2024/01/01"})
+	assert.EqualError(t, err, "结束日期格式应为 YYYY-MM-DD")
+
+	totals, err := TicketWorklogTotals(s.db, []uint{network.ID, other.ID, 99})
+	require.NoError(t, err)
+	assert.Equal(t, TicketWorklogTotal{Minutes: 210, BillableMinutes: 180, Entries: 3}, totals[network.ID])
+	assert.Equal(t, int64(45), totals[other.ID].Minutes)
+	assert.Zero(t, totals[99].Entries)
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"info-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WatchService 记录与工单关注服务
+type WatchService struct {
+	db                  *gorm.DB
+	notificationService *NotificationService
+	runAsync            func(func())
+}
+
+// NewWatchService 创建关注服务
+func NewWatchService(db *gorm.DB, notificationService *NotificationService) *WatchService {
+	return &WatchService{
+		db:                  db,
+		notificationService: notificationService,
+		runAsync:            func(f func()) { go f() },
+	}
+}
+
+// WatcherResponse 关注者响应
+type WatcherResponse struct {
+	UserID      uint   `json:"user_id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	Reason      string `json:"reason"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// WatcherListResponse 关注者列表响应
+type WatcherListResponse struct {
+	Watchers []WatcherResponse `json:"watchers"`
+	Total    int               `json:"total"`
+	Watching bool              `json:"watching"` // 当前用户是否已关注
+}
+
+// UpdateNotificationPreferenceRequest 更新通知偏好请求
+type UpdateNotificationPreferenceRequest struct {
+	Channel string `json:"channel" binding:"required,oneof=wechat email sms none"`
+}
+
+// Watch 关注实体，需具备实体读取权限；重复关注不报错
+func (s *WatchService) Watch(entityType string, entityID uint, scope *LinkAccessScope) (*WatcherListResponse, error) {
+	if !models.IsValidWatchEntityType(entityType) {
+		return nil, fmt.Errorf("无效的关注实体类型")
+	}
+	if _, err := resolveEntityTitle(s.db, entityType, entityID, scope); err != nil {
+		return nil, err
+	}
+	if err := AddWatchers(s.db, entityType, entityID, models.WatchReasonManual, scope.UserID); err != nil {
+		return nil, err
+	}
+	return s.listWatchers(entityType, entityID, scope.UserID)
+}
+
+// Unwatch 取消关注实体
+func (s *WatchService) Unwatch(entityType string, entityID uint, scope *LinkAccessScope) (*WatcherListResponse, error) {
+	if !models.IsValidWatchEntityType(entityType) {
+		return nil, fmt.Errorf("无效的关注实体类型")
+	}
+	if _, err := resolveEntityTitle(s.db, entityType, entityID, scope); err != nil {
+		return nil, err
+	}
+	if err := s.db.Where("entity_type = ? AND entity_id = ? AND user_id = ?", entityType, entityID, scope.UserID).
+		Delete(&models.Watcher{}).Error; err != nil {
+		return nil, fmt.Errorf("取消关注失败: %w", err)
+	}
+	return s.listWatchers(entityType, entityID, scope.UserID)
+}
+
+// GetWatchers 获取实体的关注者列表
+func (s *WatchService) GetWatchers(entityType string, entityID uint, scope *LinkAccessScope) (*WatcherListResponse, error) {
+	if !models.IsValidWatchEntityType(entityType) {
+		return nil, fmt.Errorf("无效的关注实体类型")
+	}
+	if _, err := resolveEntityTitle(s.db, entityType, entityID, scope); err != nil {
+		return nil, err
+	}
+	return s.listWatchers(entityType, entityID, scope.UserID)
+}
+
+// listWatchers 查询关注者
+func (s *WatchService) listWatchers(entityType string, entityID uint, userID uint) (*WatcherListResponse, error) {
+	var watchers []models.Watcher
+	if err := s.db.Preload("User").
+		Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		Order("created_at ASC, id ASC").
+		Find(&watchers).Error; err != nil {
+		return nil, fmt.Errorf("获取关注者失败: %w", err)
+	}
+
+	response := &WatcherListResponse{Watchers: make([]WatcherResponse, len(watchers)), Total: len(watchers)}
+	for i, watcher := range watchers {
+		response.Watchers[i] = WatcherResponse{
+			UserID:      watcher.UserID,
+			Username:    watcher.User.Username,
+			DisplayName: watcher.User.DisplayName,
+			Reason:      watcher.Reason,
+			CreatedAt:   watcher.CreatedAt.Format("2006-01-02 15:04:05"),
+		}
+		if watcher.UserID == userID {
+			response.Watching = true
+		}
+	}
+	return response, nil
+}
+
+// GetPreference 获取用户通知偏好，未设置时返回默认渠道
+func (s *WatchService) GetPreference(userID uint) (*models.NotificationPreference, error) {
+	var preference models.NotificationPreference
+	err := s.db.Where("user_id = ?", userID).First(&preference).Error
+	if err == gorm.ErrRecordNotFound {
+		return &models.NotificationPreference{UserID: userID, Channel: models.NotifyChannelWechat}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取通知偏好失败: %w", err)
+	}
+	return &preference, nil
+}
+
+// UpdatePreference 更新用户接收关注通知的渠道
+func (s *WatchService) UpdatePreference(userID uint, req *UpdateNotificationPreferenceRequest) (*models.NotificationPreference, error) {
+	if !models.IsValidNotifyChannel(req.Channel) {
+		return nil, fmt.Errorf("无效的通知渠道: %s", req.Channel)
+	}
+
+	preference := models.NotificationPreference{UserID: userID, Channel: req.Channel}
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"channel", "updated_at"}),
+	}).Create(&preference).Error
+	if err != nil {
+		return nil, fmt.Errorf("更新通知偏好失败: %w", err)
+	}
+	return s.GetPreference(userID)
+}
+
+// NotifyWatchers 异步通知实体的关注者，操作者本人及 excludeIDs 中的用户不会收到通知
+func (s *WatchService) NotifyWatchers(entityType string, entityID uint, actorID uint, title, content string, excludeIDs ...uint) {
+	if s == nil || s.notificationService == nil {
+		return
+	}
+	s.runAsync(func() { s.notifyWatchers(entityType, entityID, actorID, title, content, excludeIDs) })
+}
+
+// NotifyUsers 异步按偏好渠道通知指定用户，操作者本人不会收到通知
+func (s *WatchService) NotifyUsers(userIDs []uint, actorID uint, title, content string) {
+	if s == nil || s.notificationService == nil || len(userIDs) == 0 {
+		return
+	}
+	s.runAsync(func() { s.notifyUsers(userIDs, actorID, title, content) })
+}
+
+// notifyRecipient 通知接收人及其偏好渠道
+type notifyRecipient struct {
+	UserID   uint
+	Username string
+	Email    string
+	Channel  *string
+}
+
+// notifyWatchers 按关注者的偏好渠道发送通知
+func (s *WatchService) notifyWatchers(entityType string, entityID uint, actorID uint, title, content string, excludeIDs []uint) {
+	var recipients []notifyRecipient
+	query := s.db.Table("watchers").
+		Select("watchers.user_id, users.username, users.email, notification_preferences.channel").
+		Joins("JOIN users ON users.id = watchers.user_id AND users.deleted_at IS NULL").
+		Joins("LEFT JOIN notification_preferences ON notification_preferences.user_id = watchers.user_id").
+		Where("watchers.entity_type = ? AND watchers.entity_id = ? AND watchers.user_id <> ?", entityType, entityID, actorID).
+		Where("users.is_active = ?", true)
+	if len(excludeIDs) > 0 {
+		query = query.Where("watchers.user_id NOT IN ?", excludeIDs)
+	}
+	if err := query.Scan(&recipients).Error; err != nil {
+		return
+	}
+	s.sendToRecipients(recipients, title, content)
+}
+
+// notifyUsers 按用户的偏好渠道发送通知
+func (s *WatchService) notifyUsers(userIDs []uint, actorID uint, title, content string) {
+	var recipients []notifyRecipient
+	err := s.db.Table("users").
+		Select("users.id AS user_id, users.username, users.email, notification_preferences.channel").
+		Joins("LEFT JOIN notification_preferences ON notification_preferences.user_id = users.id").
+		Where("users.id IN ? AND users.id <> ? AND users.deleted_at IS NULL", userIDs, actorID).
+		Where("users.is_active = ?", true).
+		Scan(&recipients).Error
+	if err != nil {
+		return
+	}
+	s.sendToRecipients(recipients, title, content)
+}
+
+// sendToRecipients 按接收人偏好的渠道逐个发送通知
+func (s *WatchService) sendToRecipients(recipients []notifyRecipient, title, content string) {
+	for _, recipient := range recipients {
+		channel := models.NotifyChannelWechat
+		if recipient.Channel != nil && *recipient.Channel != "" {
+			channel = *recipient.Channel
+		}
+		if channel == models.NotifyChannelNone {
+			continue
+		}
+
+		address := recipient.Username
+		if channel == models.NotifyChannelEmail {
+			address = recipient.Email
+		}
+		s.notificationService.SendNotification(&NotificationSendRequest{
+			Type:       channel,
+			Recipients: []string{address},
+			Subject:    title,
+			Content:    content,
+			Priority:   1,
+		}, recipient.UserID)
+	}
+}
+
+// AddWatchers 为实体添加关注者，已关注的用户保持原有关注来源
+func AddWatchers(tx *gorm.DB, entityType string, entityID uint, reason string, userIDs ...uint) error {
+	for _, userID := range uniqueUints(userIDs) {
+		if userID == 0 {
+			continue
+		}
+		watcher := models.Watcher{EntityType: entityType, EntityID: entityID, UserID: userID, Reason: reason}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&watcher).Error; err != nil {
+			return fmt.Errorf("添加关注者失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// deleteEntityWatchers 删除实体的全部关注者
+func deleteEntityWatchers(tx *gorm.DB, entityType string, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := tx.Where("entity_type = ? AND entity_id IN ?", entityType, ids).Delete(&models.Watcher{}).Error; err != nil {
+		return fmt.Errorf("删除关注者失败: %w", err)
+	}
+	return nil
+}
+
+// BackfillDefaultWatchers 为已有记录和工单补充默认关注者（创建者、审核人、处理人），
+// 包括回收站中的数据；仅在关注表首次创建时执行，避免重新加入已取消关注的用户
+func BackfillDefaultWatchers(db *gorm.DB) error {
+	statements := []struct {
+		reason string
+		sql    string
+	}{
+		{models.WatchReasonCreator, "SELECT 'record' AS entity_type, id AS entity_id, created_by AS user_id FROM records WHERE created_by > 0"},
+		{models.WatchReasonAssignee, "SELECT 'record' AS entity_type, id AS entity_id, reviewer_id AS user_id FROM records WHERE reviewer_id > 0 AND reviewer_id <> created_by"},
+		{models.WatchReasonCreator, "SELECT 'ticket' AS entity_type, id AS entity_id, creator_id AS user_id FROM tickets WHERE creator_id > 0"},
+		{models.WatchReasonAssignee, "SELECT 'ticket' AS entity_type, id AS entity_id, assignee_id AS user_id FROM tickets WHERE assignee_id > 0 AND assignee_id <> creator_id"},
+	}
+
+	now := time.Now()
+	for _, stmt := range statements {
+		err := db.Exec("INSERT INTO watchers (entity_type, entity_id, user_id, reason, created_at) "+
+			"SELECT src.entity_type, src.entity_id, src.user_id, ?, ? FROM ("+stmt.sql+") src "+
+			"WHERE NOT EXISTS (SELECT 1 FROM watchers w WHERE w.entity_type = src.entity_type AND w.entity_id = src.entity_id AND w.user_id = src.user_id)",
+			stmt.reason, now).Error
+		if err != nil {
+			return fmt.Errorf("补充默认关注者失败: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"testing"
+
+	"info-management-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupWatchTest 在流程测试环境基础上启用通知，并同步执行关注通知
+func setupWatchTest(t *testing.T) (*RecordService, *RecordWorkflowService, *WatchService) {
+	db, _ := setupWorkflowTest(t)
+	workflowService := NewRecordWorkflowService(db, NewRecordTypeService(db), nil, NewNotificationService(db))
+	workflowService.watchService.runAsync = func(f func()) { f() }
+	return NewRecordService(db, NewRecordTypeService(db), nil, nil, workflowService), workflowService, workflowService.watchService
+}
+
+func TestWatchService_AutoWatchAndManualWatch(t *testing.T) {
+	recordService, workflowService, watchService := setupWatchTest(t)
+
+	record, err := recordService.CreateRecord(&CreateRecordRequest{Type: "review", Title: "报告", Content: map[string]interface{}{"summary": "季度"}}, 1, "", "")
+	require.NoError(t, err)
+	_, err = workflowService.Transition(record.ID, &RecordTransitionRequest{Action: "submit"}, 1, false, "", "")
+	require.NoError(t, err)
+	require.NoError(t, workflowService.AssignReviewer(record.ID, &AssignReviewerRequest{ReviewerID: 2}, 1, false, "", ""))
+
+	list, err := watchService.GetWatchers(models.WatchEntityRecord, record.ID, &LinkAccessScope{UserID: 1})
+	require.NoError(t, err)
+	require.Equal(t, 2, list.Total)
+	assert.True(t, list.Watching)
+	assert.Equal(t, models.WatchReasonCreator, list.Watchers[0].Reason)
+	assert.Equal(t, "reviewer", list.Watchers[1].Username)
+	assert.Equal(t, models.WatchReasonAssignee, list.Watchers[1].Reason)
+
+	// 无读取权限的用户不能关注
+	_, err = watchService.Watch(models.WatchEntityRecord, record.ID, &LinkAccessScope{UserID: 3})
+	assert.EqualError(t, err, "记录不存在或无权访问")
+	_, err = watchService.Watch("file", record.ID, &LinkAccessScope{UserID: 3})
+	assert.EqualError(t, err, "无效的关注实体类型")
+
+	list, err = watchService.Watch(models.WatchEntityRecord, record.ID, &LinkAccessScope{UserID: 3, AllRecords: true})
+	require.NoError(t, err)
+	assert.Equal(t, 3, list.Total)
+	assert.True(t, list.Watching)
+
+	// 重复关注不报错
+	list, err = watchService.Watch(models.WatchEntityRecord, record.ID, &LinkAccessScope{UserID: 3, AllRecords: true})
+	require.NoError(t, err)
+	assert.Equal(t, 3, list.Total)
+
+	list, err = watchService.Unwatch(models.WatchEntityRecord, record.ID, &LinkAccessScope{UserID: 3, AllRecords: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, list.Total)
+	assert.False(t, list.Watching)
+}
+
+func TestWatchService_NotifiesWatchersByPreference(t *testing.T) {
+	recordService, workflowService, watchService := setupWatchTest(t)
+	db := watchService.db
+
+	record, err := recordService.CreateRecord(&CreateRecordRequest{Type: "review", Title: "报告", Content: map[string]interface{}{"summary": "季度"}}, 1, "", "")
+	require.NoError(t, err)
+	_, err = workflowService.Transition(record.ID, &RecordTransitionRequest{Action: "submit"}, 1, false, "", "")
+	require.NoError(t, err)
+
+	// 唯一的关注者是操作者本人，不发送通知
+	var count int64
+	db.Model(&models.Notification{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	require.NoError(t, workflowService.AssignReviewer(record.ID, &AssignReviewerRequest{ReviewerID: 2}, 1, false, "", ""))
+	_, err = watchService.Watch(models.WatchEntityRecord, record.ID, &LinkAccessScope{UserID: 3, AllRecords: true})
+	require.NoError(t, err)
+
+	_, err = watchService.UpdatePreference(3, &UpdateNotificationPreferenceRequest{Channel: "fax"})
+	assert.EqualError(t, err, "无效的通知渠道: fax")
+	_, err = watchService.UpdatePreference(3, &UpdateNotificationPreferenceRequest{Channel: models.NotifyChannelSMS})
+	require.NoError(t, err)
+	preference, err := watchService.UpdatePreference(3, &UpdateNotificationPreferenceRequest{Channel: models.NotifyChannelEmail})
+	require.NoError(t, err)
+	assert.Equal(t, models.NotifyChannelEmail, preference.Channel)
+
+	preference, err = watchService.GetPreference(1)
+	require.NoError(t, err)
+	assert.Equal(t, models.NotifyChannelWechat, preference.Channel, "未设置时使用默认渠道")
+
+	// 指派审核人时单独通知审核人
+	db.Model(&models.Notification{}).Where("subject = ?", "记录审核通知").Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	// 审核人通过：通知创建者（默认渠道）与手动关注者（邮件），不通知审核人本人
+	_, err = workflowService.Approve(record.ID, &RecordReviewRequest{}, 2, false, "", "")
+	require.NoError(t, err)
+
+	var notifications []models.Notification
+	require.NoError(t, db.Where("subject <> ?", "记录审核通知").Order("id").Find(&notifications).Error)
+	require.Len(t, notifications, 2)
+	byRecipient := map[string]string{}
+	for _, n := range notifications {
+		byRecipient[n.Recipients] = n.Type
+		assert.Equal(t, "记录状态通知", n.Subject)
+	}
+	assert.Equal(t, map[string]string{`["author"]`: "wechat", `["outsider@example.com"]`: "email"}, byRecipient)
+
+	// 选择不接收通知的关注者被跳过
+	_, err = watchService.UpdatePreference(1, &UpdateNotificationPreferenceRequest{Channel: models.NotifyChannelNone})
+	require.NoError(t, err)
+	_, err = recordService.UpdateRecord(record.ID, &UpdateRecordRequest{Title: "报告v2"}, 2, true, "", "")
+	require.NoError(t, err)
+	db.Model(&models.Notification{}).Count(&count)
+	assert.Equal(t, int64(4), count)
+}
+
+func TestBackfillDefaultWatchers(t *testing.T) {
+	_, _, watchService := setupWatchTest(t)
+	db := watchService.db
+
+	creator, reviewer := uint(1), uint(2)
+	require.NoError(t, db.Create(&models.Record{Type: "review", Title: "旧记录", Status: "pending", CreatedBy: 1, ReviewerID: &reviewer}).Error)
+	require.NoError(t, db.Create(&models.Record{Type: "review", Title: "自审记录", Status: "pending", CreatedBy: 1, ReviewerID: &creator}).Error)
+	require.NoError(t, db.Create(&models.Ticket{Title: "旧工单", Type: models.TicketTypeBug, CreatorID: 3, AssigneeID: &reviewer}).Error)
+
+	require.NoError(t, BackfillDefaultWatchers(db))
+	require.NoError(t, BackfillDefaultWatchers(db))
+
+	var watchers []models.Watcher
+	require.NoError(t, db.Order("entity_type, entity_id, user_id").Find(&watchers).Error)
+	require.Len(t, watchers, 5)
+	assert.Equal(t, models.Watcher{EntityType: "record", EntityID: 1, UserID: 2, Reason: models.WatchReasonAssignee},
+		models.Watcher{EntityType: watchers[1].EntityType, EntityID: watchers[1].EntityID, UserID: watchers[1].UserID, Reason: watchers[1].Reason})
+	assert.Equal(t, uint(3), watchers[4].UserID)
+	assert.Equal(t, models.WatchReasonCreator, watchers[4].Reason)
+}